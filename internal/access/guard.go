@@ -0,0 +1,129 @@
+package access
+
+import (
+	"fmt"
+	"log"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Guard 群组准入控制：配置文件提供默认模式和名单，后台面板的设置优先
+type Guard struct {
+	db    *database.DB
+	mode  string
+	allow map[int64]bool
+	block map[int64]bool
+}
+
+// NewGuard 创建群组准入控制
+func NewGuard(db *database.DB, cfg *config.Config) *Guard {
+	g := &Guard{
+		db:    db,
+		mode:  cfg.ChatAccessMode,
+		allow: make(map[int64]bool),
+		block: make(map[int64]bool),
+	}
+	if !database.ValidChatAccessMode(g.mode) {
+		log.Printf("⚠️ 无效的群组准入模式 %q，按 open 处理", g.mode)
+		g.mode = models.ChatAccessOpen
+	}
+	for _, chatID := range cfg.ChatAllowlist {
+		g.allow[chatID] = true
+	}
+	for _, chatID := range cfg.ChatBlocklist {
+		g.block[chatID] = true
+	}
+	return g
+}
+
+// Mode 当前生效的准入模式
+func (g *Guard) Mode() (string, error) {
+	mode, ok, err := g.db.GetChatAccessMode()
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return g.mode, nil
+	}
+	return mode, nil
+}
+
+// Allowed 判断群组是否可以使用机器人，私聊始终允许
+func (g *Guard) Allowed(chatID int64) (bool, error) {
+	if chatID > 0 {
+		return true, nil
+	}
+
+	mode, err := g.Mode()
+	if err != nil {
+		return false, err
+	}
+	if mode == models.ChatAccessOpen {
+		return true, nil
+	}
+
+	list, err := g.db.GetChatAccessListType(chatID)
+	if err != nil {
+		return false, err
+	}
+
+	// 后台名单优先，不在后台名单中时按配置文件的名单判断
+	if list == "" {
+		switch {
+		case g.allow[chatID]:
+			list = models.ChatListAllow
+		case g.block[chatID]:
+			list = models.ChatListBlock
+		}
+	}
+
+	if mode == models.ChatAccessAllowlist {
+		return list == models.ChatListAllow, nil
+	}
+	return list != models.ChatListBlock, nil
+}
+
+// Enforce 检查群组准入，未获准时发送提示并退出群组，用于机器人入群和群内请求
+func (g *Guard) Enforce(client telegram.Client, chatID int64) (bool, error) {
+	allowed, err := g.Allowed(chatID)
+	if err != nil || allowed {
+		return allowed, err
+	}
+
+	mode, _ := g.Mode()
+	if _, err := client.Send(tgbotapi.NewMessage(chatID, ui.ChatAccessDeniedMessage(mode))); err != nil {
+		log.Printf("⚠️ 向群组 %d 发送准入提示失败: %v", chatID, err)
+	}
+	if _, err := client.Request(tgbotapi.LeaveChatConfig{ChatID: chatID}); err != nil {
+		return false, fmt.Errorf("退出群组失败: %v", err)
+	}
+
+	log.Printf("🚪 群组 %d 未获准使用（%s 模式），已退出", chatID, mode)
+	return false, nil
+}
+
+// Middleware 拦截未获准群组中的命令、回调和机器人入群，并退出该群组；机器人离开群组时不检查
+func (g *Guard) Middleware() middleware.Middleware {
+	return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+		return func(ctx *middleware.Context) error {
+			if member := ctx.Update.MyChatMember; member != nil && (member.NewChatMember.HasLeft() || member.NewChatMember.WasKicked()) {
+				return next(ctx)
+			}
+			allowed, err := g.Enforce(ctx.Client, ctx.ChatID)
+			if err != nil {
+				return fmt.Errorf("检查群组准入失败: %v", err)
+			}
+			if !allowed {
+				return middleware.ErrAborted
+			}
+			return next(ctx)
+		}
+	}
+}
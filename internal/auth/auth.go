@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SessionCookie 管理后台登录会话的 Cookie 名
+const SessionCookie = "admin_session"
+
+// DefaultSessionTTL 登录会话的有效期
+const DefaultSessionTTL = 12 * time.Hour
+
+// Authenticator 管理后台的账号校验和登录会话。会话保存在签名 Cookie 中，
+// 签名密钥每次启动随机生成，重启后需重新登录
+type Authenticator struct {
+	username string
+	password [sha256.Size]byte
+	key      []byte
+	ttl      time.Duration
+}
+
+// New 创建管理后台认证，密码为空时拒绝创建，避免后台在无密码的情况下对外开放
+func New(username, password string) (*Authenticator, error) {
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("管理后台用户名和密码不能为空")
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("生成会话签名密钥失败: %v", err)
+	}
+	return &Authenticator{
+		username: username,
+		password: sha256.Sum256([]byte(password)),
+		key:      key,
+		ttl:      DefaultSessionTTL,
+	}, nil
+}
+
+// SetSessionTTL 设置登录会话的有效期
+func (a *Authenticator) SetSessionTTL(ttl time.Duration) {
+	if ttl > 0 {
+		a.ttl = ttl
+	}
+}
+
+// ValidateCredentials 校验用户名和密码，比较耗时与输入无关
+func (a *Authenticator) ValidateCredentials(username, password string) bool {
+	hashed := sha256.Sum256([]byte(password))
+	userOK := subtle.ConstantTimeCompare([]byte(username), []byte(a.username)) == 1
+	passOK := subtle.ConstantTimeCompare(hashed[:], a.password[:]) == 1
+	return userOK && passOK
+}
+
+// Login 写入登录会话
+func (a *Authenticator) Login(w http.ResponseWriter, username string) {
+	expires := time.Now().Add(a.ttl)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(username)) + "." + strconv.FormatInt(expires.Unix(), 10)
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    payload + "." + a.sign(payload),
+		Path:     "/admin",
+		Expires:  expires,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// Logout 清除登录会话
+func (a *Authenticator) Logout(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     SessionCookie,
+		Value:    "",
+		Path:     "/admin",
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// Username 请求所属会话的管理员用户名，未登录、签名无效或会话过期时返回空字符串
+func (a *Authenticator) Username(r *http.Request) string {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return ""
+	}
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(a.sign(payload))) {
+		return ""
+	}
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() >= expires {
+		return ""
+	}
+	username, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return ""
+	}
+	return string(username)
+}
+
+// IsAuthenticated 请求是否带有有效的登录会话
+func (a *Authenticator) IsAuthenticated(r *http.Request) bool {
+	return a.Username(r) != ""
+}
+
+// Middleware 拦截未登录的请求：/admin/api/ 下的接口返回 401，页面跳转到登录页
+func (a *Authenticator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.IsAuthenticated(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/admin/api/") {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "未登录或登录已过期",
+			})
+			return
+		}
+		http.Redirect(w, r, "/admin/login", http.StatusFound)
+	})
+}
+
+// sign 计算会话内容的签名
+func (a *Authenticator) sign(payload string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,109 @@
+package balance
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/cache"
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /balance 命令和余额消息刷新按钮的处理器
+type Handler struct {
+	db       *database.DB
+	balances *cache.BalanceCache
+	codec    *callback.Codec
+	interval time.Duration // 同一用户两次刷新的最小间隔
+}
+
+// NewHandler 创建余额处理器
+func NewHandler(db *database.DB, balances *cache.BalanceCache, codec *callback.Codec, interval time.Duration) *Handler {
+	return &Handler{db: db, balances: balances, codec: codec, interval: interval}
+}
+
+// Register 注册 /balance 命令和刷新按钮回调，刷新按用户限频
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.BalanceCommand, h.Show)
+	router.HandleCallback(callback.Prefix(ui.BalanceRefreshAction), h.Refresh, middleware.RateLimit(1, h.interval))
+}
+
+// Show 发送带刷新按钮的余额消息
+func (h *Handler) Show(ctx *middleware.Context) error {
+	balance, err := h.balances.GetCachedBalance(ctx.UserID)
+	if err != nil {
+		return err
+	}
+	text, err := h.render(ctx.UserID, balance)
+	if err != nil {
+		return err
+	}
+
+	msg, err := ui.BuildBalanceMessage(h.codec, ctx.ChatID, ctx.UserID, text)
+	if err != nil {
+		return err
+	}
+	if ctx.Update.Message != nil {
+		msg.ReplyToMessageID = ctx.Update.Message.MessageID
+	}
+	_, err = ctx.Client.Send(msg)
+	return err
+}
+
+// Refresh 刷新按钮：从数据库读取最新余额并原地编辑余额消息，只有余额所属用户可以刷新
+func (h *Handler) Refresh(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	userID, err := ui.ParseBalanceRefreshCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+	if userID != ctx.UserID {
+		return ctx.Reply("⛔ 只能刷新自己的余额，请发送 /balance 查看")
+	}
+
+	balance, err := h.balances.RefreshBalance(userID)
+	if err != nil {
+		return err
+	}
+	text, err := h.render(userID, balance)
+	if err != nil {
+		return err
+	}
+
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "🔄 已刷新")); err != nil {
+		log.Printf("⚠️ 应答余额刷新按钮失败: %v", err)
+	}
+
+	edit, err := ui.BuildBalanceEdit(h.codec, ctx.ChatID, query.Message.MessageID, userID, text)
+	if err != nil {
+		return err
+	}
+	if _, err := ctx.Client.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return err
+	}
+	return nil
+}
+
+// render 生成余额文本：可提现余额取自余额缓存，赠送余额和流水进度取自数据库
+func (h *Handler) render(userID int64, balance cache.CachedBalance) (string, error) {
+	user, err := h.db.GetUser(userID)
+	if err != nil {
+		return "", fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	if user == nil {
+		return "", fmt.Errorf("用户不存在")
+	}
+	progress, err := h.db.GetBonusProgress(userID)
+	if err != nil {
+		return "", fmt.Errorf("获取赠送余额失败: %v", err)
+	}
+
+	user.Balance = balance.Balance
+	return ui.FormatBalanceMessage(user, progress, balance.UpdatedAt), nil
+}
@@ -0,0 +1,268 @@
+package broadcast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/notify"
+	"telegram-dice-bot/internal/pool"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// DefaultRate 每秒最多发送的公告数，低于 Telegram 全局 30 条/秒的限制，为对局消息留出余量
+	DefaultRate = 20
+	// pollInterval 检查定时公告的周期，立即发送的公告通过唤醒发送
+	pollInterval = 30 * time.Second
+	// pageSize 每批读取的待投递接收方数，每批之间检查公告是否已取消
+	pageSize = 50
+	// maxTextLength Telegram 单条消息的长度上限
+	maxTextLength = 4096
+)
+
+// ErrEmptyText 公告内容为空
+var ErrEmptyText = errors.New("公告内容不能为空")
+
+// Broadcaster 向与机器人互动过的群组和用户发送公告：按速率限制将投递任务提交到工作池，
+// 每个接收方的投递结果记录在数据库中，进程重启后继续发送未投递的接收方
+type Broadcaster struct {
+	db      *database.DB
+	client  telegram.Client
+	workers *pool.WorkerPool
+	limiter *pool.RateLimiter
+
+	runMu sync.Mutex  // 同一时间只有一轮发送
+	gate  func() bool // 返回 false 时后台不发送
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+}
+
+// NewBroadcaster 创建公告发送器，rate 为每秒最多发送的消息数，workers 为 nil 时在发送协程中直接投递
+func NewBroadcaster(db *database.DB, client telegram.Client, workers *pool.WorkerPool, rate int) *Broadcaster {
+	if rate <= 0 {
+		rate = DefaultRate
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Broadcaster{
+		db:      db,
+		client:  client,
+		workers: workers,
+		limiter: pool.NewRateLimiter(rate, time.Second),
+		ctx:     ctx,
+		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Start 启动后台发送，启动时立即继续上次进程退出前未发送完的公告
+func (b *Broadcaster) Start() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			if b.gate == nil || b.gate() {
+				if _, err := b.Run(time.Now()); err != nil {
+					log.Printf("❌ 发送公告失败: %v", err)
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-b.wake:
+			case <-b.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// SetGate 设置后台发送的开关，多进程部署时避免同一公告被多个进程重复发送
+func (b *Broadcaster) SetGate(gate func() bool) {
+	b.gate = gate
+}
+
+// Stop 停止后台发送，未投递的接收方保留到下次启动
+func (b *Broadcaster) Stop() {
+	b.cancel()
+	b.limiter.Stop()
+}
+
+// Schedule 创建公告，at 为零值或早于当前时间时立即发送
+func (b *Broadcaster) Schedule(text, audience string, at time.Time, createdBy string) (*models.Broadcast, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, ErrEmptyText
+	}
+	if len([]rune(text)) > maxTextLength {
+		return nil, fmt.Errorf("公告内容不能超过 %d 个字符", maxTextLength)
+	}
+
+	broadcast := &models.Broadcast{
+		Text:        text,
+		Audience:    audience,
+		ScheduledAt: at,
+		CreatedBy:   createdBy,
+	}
+	if err := b.db.CreateBroadcast(broadcast); err != nil {
+		return nil, fmt.Errorf("创建公告失败: %v", err)
+	}
+	log.Printf("📢 管理员 %s 创建公告 #%d（%s），计划于 %s 发送",
+		createdBy, broadcast.ID, audience, broadcast.ScheduledAt.Format("2006-01-02 15:04"))
+
+	if !broadcast.ScheduledAt.After(time.Now()) {
+		b.notify()
+	}
+	return broadcast, nil
+}
+
+// Cancel 取消待发送或发送中的公告，发送中的公告在当前批次结束后停止；公告已结束时返回 false
+func (b *Broadcaster) Cancel(id int64) (bool, error) {
+	ok, err := b.db.CancelBroadcast(id)
+	if err != nil {
+		return false, fmt.Errorf("取消公告失败: %v", err)
+	}
+	if ok {
+		log.Printf("🚫 公告 #%d 已取消", id)
+	}
+	return ok, nil
+}
+
+// Run 发送所有已到发送时间的公告以及未发送完的公告，返回本次送达的消息数
+func (b *Broadcaster) Run(now time.Time) (int, error) {
+	b.runMu.Lock()
+	defer b.runMu.Unlock()
+
+	broadcasts, err := b.db.GetRunnableBroadcasts(now)
+	if err != nil {
+		return 0, fmt.Errorf("获取待发送公告失败: %v", err)
+	}
+
+	sent := 0
+	for _, broadcast := range broadcasts {
+		if broadcast.Status == models.BroadcastScheduled {
+			started, err := b.db.StartBroadcast(broadcast.ID)
+			if err != nil {
+				log.Printf("❌ 开始发送公告 #%d 失败: %v", broadcast.ID, err)
+				continue
+			}
+			if !started {
+				// 已被取消或由其他进程开始发送
+				continue
+			}
+		}
+
+		delivered, err := b.send(broadcast)
+		sent += delivered
+		if err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+// notify 有新公告需要立即发送时唤醒发送协程
+func (b *Broadcaster) notify() {
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+}
+
+// send 分批投递公告的待发送接收方，全部投递后标记公告已完成，返回送达数
+func (b *Broadcaster) send(broadcast *models.Broadcast) (int, error) {
+	sent := 0
+	for {
+		// 每批开始前检查是否已被取消
+		current, err := b.db.GetBroadcast(broadcast.ID)
+		if err != nil {
+			return sent, fmt.Errorf("获取公告 #%d 失败: %v", broadcast.ID, err)
+		}
+		if current == nil || current.Status != models.BroadcastSending {
+			log.Printf("🚫 公告 #%d 已停止发送，送达 %d 条", broadcast.ID, sent)
+			return sent, nil
+		}
+
+		chatIDs, err := b.db.GetPendingDeliveries(broadcast.ID, pageSize)
+		if err != nil {
+			return sent, fmt.Errorf("获取公告 #%d 接收方失败: %v", broadcast.ID, err)
+		}
+		if len(chatIDs) == 0 {
+			if err := b.db.FinishBroadcast(broadcast.ID); err != nil {
+				return sent, fmt.Errorf("完成公告 #%d 失败: %v", broadcast.ID, err)
+			}
+			log.Printf("📢 公告 #%d 发送完成，共 %d 个接收方", broadcast.ID, current.Total)
+			return sent, nil
+		}
+
+		// 本批全部投递完成后才读取下一批，避免重复读取正在投递的接收方
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		for _, chatID := range chatIDs {
+			if err := b.limiter.Wait(b.ctx); err != nil {
+				wg.Wait()
+				return sent, nil
+			}
+
+			chatID := chatID
+			wg.Add(1)
+			job := &pool.MessageJob{Handler: func() error {
+				defer wg.Done()
+				if b.deliver(broadcast, chatID) {
+					mu.Lock()
+					sent++
+					mu.Unlock()
+				}
+				return nil
+			}}
+			if b.workers != nil {
+				b.workers.SubmitWithPriority(job, pool.PriorityLow)
+			} else {
+				job.Execute()
+			}
+		}
+		wg.Wait()
+	}
+}
+
+// deliver 向单个接收方发送公告并记录结果，限流时按 Telegram 要求的时间等待后重试一次
+func (b *Broadcaster) deliver(broadcast *models.Broadcast, chatID int64) bool {
+	_, err := b.client.Send(tgbotapi.NewMessage(chatID, broadcast.Text))
+	var apiErr *tgbotapi.Error
+	if err != nil && errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		select {
+		case <-time.After(time.Duration(apiErr.RetryAfter) * time.Second):
+			_, err = b.client.Send(tgbotapi.NewMessage(chatID, broadcast.Text))
+		case <-b.ctx.Done():
+			// 保持待投递状态，下次启动后重新发送
+			return false
+		}
+	}
+
+	status, errMsg := models.DeliverySent, ""
+	if err != nil {
+		status, errMsg = models.DeliveryFailed, err.Error()
+		if chatID > 0 && notify.IsUnreachable(err) {
+			// 用户屏蔽了机器人，后续公告和私信不再发送
+			if dbErr := b.db.SetUserDMBlocked(chatID, true); dbErr != nil {
+				log.Printf("⚠️ 标记用户 %d 屏蔽私信失败: %v", chatID, dbErr)
+			}
+		}
+		log.Printf("⚠️ 公告 #%d 发送到 %d 失败: %v", broadcast.ID, chatID, err)
+	}
+
+	if dbErr := b.db.SetDeliveryStatus(broadcast.ID, chatID, status, errMsg); dbErr != nil {
+		log.Printf("❌ 记录公告 #%d 投递结果失败: %v", broadcast.ID, dbErr)
+	}
+	return err == nil
+}
@@ -0,0 +1,70 @@
+package broadcast
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+)
+
+// listLimit /broadcast 列出的最近公告数
+const listLimit = 5
+
+// Handler /broadcast 管理员命令的处理器
+type Handler struct {
+	broadcaster *Broadcaster
+	adminIDs    []int64
+}
+
+// NewHandler 创建公告命令处理器
+func NewHandler(broadcaster *Broadcaster, adminIDs []int64) *Handler {
+	return &Handler{broadcaster: broadcaster, adminIDs: adminIDs}
+}
+
+// Register 注册 /broadcast 命令，仅限管理员
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.BroadcastCommand, h.Broadcast, middleware.AdminOnly(h.adminIDs))
+}
+
+// Broadcast 不带参数时查看最近的公告，带接收范围和内容时立即发送，cancel <ID> 取消公告
+func (h *Handler) Broadcast(ctx *middleware.Context) error {
+	args := strings.TrimSpace(ctx.Args)
+	if args == "" {
+		broadcasts, err := h.broadcaster.db.GetBroadcasts(listLimit)
+		if err != nil {
+			return ctx.Reply("❌ 获取公告失败")
+		}
+		return ctx.Reply(ui.FormatBroadcastList(broadcasts))
+	}
+
+	// 内容保留原有的换行
+	action := strings.Fields(args)[0]
+	rest := strings.TrimSpace(args[len(action):])
+
+	switch strings.ToLower(action) {
+	case "cancel":
+		id, err := strconv.ParseInt(rest, 10, 64)
+		if err != nil {
+			return ctx.Reply(ui.FormatBroadcastUsage())
+		}
+		ok, err := h.broadcaster.Cancel(id)
+		if err != nil {
+			return ctx.Reply("❌ " + err.Error())
+		}
+		if !ok {
+			return ctx.Reply("❌ 公告不存在或已结束")
+		}
+		return ctx.Reply("🚫 公告 #" + strconv.FormatInt(id, 10) + " 已取消")
+	case models.BroadcastGroups, models.BroadcastUsers, models.BroadcastAll:
+		broadcast, err := h.broadcaster.Schedule(rest, strings.ToLower(action), time.Time{}, strconv.FormatInt(ctx.UserID, 10))
+		if err != nil {
+			return ctx.Reply("❌ " + err.Error())
+		}
+		return ctx.Reply(ui.FormatBroadcastCreated(broadcast))
+	default:
+		return ctx.Reply(ui.FormatBroadcastUsage())
+	}
+}
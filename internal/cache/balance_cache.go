@@ -117,15 +117,27 @@ func (bc *BalanceCache) UpdateBalance(userID int64, newBalance int64, source str
 	return nil
 }
 
-// refreshBalance 从数据库刷新余额到缓存
-func (bc *BalanceCache) refreshBalance(userID int64) (int64, error) {
+// GetCachedBalance 获取用户余额及读取时间（优先从缓存），用于展示余额的更新时间
+func (bc *BalanceCache) GetCachedBalance(userID int64) (CachedBalance, error) {
+	if cached, exists := bc.cache.Load(userID); exists {
+		cachedBalance := cached.(*CachedBalance)
+		if time.Since(cachedBalance.UpdatedAt) < 5*time.Minute {
+			return *cachedBalance, nil
+		}
+	}
+	return bc.RefreshBalance(userID)
+}
+
+// RefreshBalance 跳过缓存从数据库读取最新余额并更新缓存
+func (bc *BalanceCache) RefreshBalance(userID int64) (CachedBalance, error) {
 	user, err := bc.db.GetUser(userID)
 	if err != nil {
-		return 0, fmt.Errorf("获取用户信息失败: %v", err)
+		return CachedBalance{}, fmt.Errorf("获取用户信息失败: %v", err)
 	}
 	
 	if user == nil {
-		return 0, nil // 用户不存在，返回0余额
+		// 用户不存在，返回0余额
+		return CachedBalance{UserID: userID, UpdatedAt: time.Now()}, nil
 	}
 	
 	// 更新缓存
@@ -137,7 +149,13 @@ func (bc *BalanceCache) refreshBalance(userID int64) (int64, error) {
 	}
 	bc.cache.Store(userID, cachedBalance)
 	
-	return user.Balance, nil
+	return *cachedBalance, nil
+}
+
+// refreshBalance 从数据库刷新余额到缓存
+func (bc *BalanceCache) refreshBalance(userID int64) (int64, error) {
+	cached, err := bc.RefreshBalance(userID)
+	return cached.Balance, err
 }
 
 // SubscribeBalanceUpdates 订阅余额更新通知
@@ -14,6 +14,7 @@ type GameHistoryCache struct {
 	db          GameDatabaseInterface
 	maxRecords  int      // 每用户最大记录数
 	cacheTTL    time.Duration // 缓存过期时间
+	observer    Observer      // 命中统计的接收者
 }
 
 // UserGameHistory 用户游戏历史
@@ -32,11 +33,14 @@ type GameDatabaseInterface interface {
 	UpdateGame(game *models.Game) error
 }
 
-// NewGameHistoryCache 创建新的游戏历史缓存
-func NewGameHistoryCache(db GameDatabaseInterface) *GameHistoryCache {
+// NewGameHistoryCache 创建新的游戏历史缓存，maxRecords 为每用户缓存的最近对局数，不大于 0 时为 5 局
+func NewGameHistoryCache(db GameDatabaseInterface, maxRecords int) *GameHistoryCache {
+	if maxRecords <= 0 {
+		maxRecords = 5
+	}
 	cache := &GameHistoryCache{
 		db:         db,
-		maxRecords: 5,                // 每用户最多保留5局游戏记录
+		maxRecords: maxRecords,
 		cacheTTL:   10 * time.Minute, // 缓存10分钟
 	}
 	
@@ -60,14 +64,21 @@ func (ghc *GameHistoryCache) GetUserGameHistory(userID int64) ([]*models.Game, e
 			// 返回副本，避免并发修改
 			games := make([]*models.Game, len(history.Games))
 			copy(games, history.Games)
+			observe(ghc.observer, true)
 			return games, nil
 		}
 	}
 	
 	// 缓存未命中或过期，从数据库获取
+	observe(ghc.observer, false)
 	return ghc.refreshUserHistory(userID)
 }
 
+// SetObserver 设置命中统计的接收者
+func (ghc *GameHistoryCache) SetObserver(observer Observer) {
+	ghc.observer = observer
+}
+
 // AddGameRecord 添加游戏记录
 func (ghc *GameHistoryCache) AddGameRecord(userID int64, game *models.Game) error {
 	// 获取或创建用户历史记录
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// HeadToHeadStore 交手记录存储接口
+type HeadToHeadStore interface {
+	GetHeadToHead(playerA, playerB int64) (*models.HeadToHead, error)
+}
+
+// HeadToHeadCache 按玩家对缓存交手记录，(A, B) 与 (B, A) 共用同一条缓存
+type HeadToHeadCache struct {
+	db  HeadToHeadStore
+	ttl time.Duration
+
+	mu       sync.Mutex
+	records  map[playerPair]*cachedHeadToHead
+	prunedAt time.Time
+	observer Observer
+}
+
+// playerPair 无序的玩家对，low 为 ID 较小的一方
+type playerPair struct {
+	low, high int64
+}
+
+// cachedHeadToHead 缓存的交手记录及读取时间
+type cachedHeadToHead struct {
+	record   models.HeadToHead
+	loadedAt time.Time
+}
+
+// NewHeadToHeadCache 创建交手记录缓存，ttl 为缓存有效期
+func NewHeadToHeadCache(db HeadToHeadStore, ttl time.Duration) *HeadToHeadCache {
+	return &HeadToHeadCache{
+		db:      db,
+		ttl:     ttl,
+		records: make(map[playerPair]*cachedHeadToHead),
+	}
+}
+
+// SetObserver 设置命中统计的接收者
+func (c *HeadToHeadCache) SetObserver(observer Observer) {
+	c.observer = observer
+}
+
+// Get 获取两名玩家的交手记录，缓存未命中或已过期时从数据库读取
+func (c *HeadToHeadCache) Get(playerA, playerB int64) (*models.HeadToHead, error) {
+	pair := newPlayerPair(playerA, playerB)
+
+	c.mu.Lock()
+	cached, ok := c.records[pair]
+	c.mu.Unlock()
+	hit := ok && time.Since(cached.loadedAt) < c.ttl
+	observe(c.observer, hit)
+	if hit {
+		record := cached.record
+		return &record, nil
+	}
+
+	record, err := c.db.GetHeadToHead(pair.low, pair.high)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.prune()
+	c.records[pair] = &cachedHeadToHead{record: *record, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return record, nil
+}
+
+// Invalidate 两名玩家又完成一局后清除缓存，下次读取时重新统计
+func (c *HeadToHeadCache) Invalidate(playerA, playerB int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.records, newPlayerPair(playerA, playerB))
+}
+
+// prune 每隔一个有效期清除一次过期的缓存，调用方需持有 c.mu
+func (c *HeadToHeadCache) prune() {
+	if time.Since(c.prunedAt) < c.ttl {
+		return
+	}
+	c.prunedAt = time.Now()
+	for pair, cached := range c.records {
+		if time.Since(cached.loadedAt) >= c.ttl {
+			delete(c.records, pair)
+		}
+	}
+}
+
+func newPlayerPair(a, b int64) playerPair {
+	if a > b {
+		a, b = b, a
+	}
+	return playerPair{low: a, high: b}
+}
@@ -0,0 +1,19 @@
+package cache
+
+// Observer 缓存命中统计的接收者，如性能监控
+type Observer interface {
+	RecordCacheHit()
+	RecordCacheMiss()
+}
+
+// observe 向接收者记录一次命中或未命中，未设置接收者时忽略
+func observe(o Observer, hit bool) {
+	if o == nil {
+		return
+	}
+	if hit {
+		o.RecordCacheHit()
+	} else {
+		o.RecordCacheMiss()
+	}
+}
@@ -0,0 +1,65 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// ProfileStore 用户资料存储接口
+type ProfileStore interface {
+	UpdateUserProfile(userID int64, username, firstName, lastName string) (bool, error)
+}
+
+// ProfileSyncer 在每次交互时同步用户资料，资料未变化或刚同步过时跳过数据库写入
+type ProfileSyncer struct {
+	db          ProfileStore
+	minInterval time.Duration
+	profiles    sync.Map // userID -> *syncedProfile
+}
+
+// syncedProfile 最近一次同步的用户资料
+type syncedProfile struct {
+	username  string
+	firstName string
+	lastName  string
+	syncedAt  time.Time
+}
+
+// NewProfileSyncer 创建用户资料同步器，minInterval 为同一用户两次写入的最小间隔
+func NewProfileSyncer(db ProfileStore, minInterval time.Duration) *ProfileSyncer {
+	return &ProfileSyncer{
+		db:          db,
+		minInterval: minInterval,
+	}
+}
+
+// Sync 同步用户资料，返回是否写入了数据库
+func (ps *ProfileSyncer) Sync(userID int64, username, firstName, lastName string) (bool, error) {
+	if cached, ok := ps.profiles.Load(userID); ok {
+		profile := cached.(*syncedProfile)
+		if profile.username == username && profile.firstName == firstName && profile.lastName == lastName {
+			return false, nil
+		}
+		if time.Since(profile.syncedAt) < ps.minInterval {
+			return false, nil
+		}
+	}
+
+	updated, err := ps.db.UpdateUserProfile(userID, username, firstName, lastName)
+	if err != nil {
+		return false, err
+	}
+
+	ps.profiles.Store(userID, &syncedProfile{
+		username:  username,
+		firstName: firstName,
+		lastName:  lastName,
+		syncedAt:  time.Now(),
+	})
+	return updated, nil
+}
+
+// Forget 移除用户的同步记录（如用户被删除）
+func (ps *ProfileSyncer) Forget(userID int64) {
+	ps.profiles.Delete(userID)
+}
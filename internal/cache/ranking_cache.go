@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// RankingStore 群组排行榜存储接口
+type RankingStore interface {
+	GetChatRanking(chatID int64, since time.Time, limit int) ([]*models.RankEntry, error)
+}
+
+// RankingCache 按群组和统计周期缓存排行榜，有效期内重复查看和切换周期不再查询数据库
+type RankingCache struct {
+	db    RankingStore
+	ttl   time.Duration
+	limit int
+
+	mu       sync.Mutex
+	rankings map[rankingKey]*cachedRanking
+	prunedAt time.Time
+}
+
+// rankingKey 群组和统计周期的起点
+type rankingKey struct {
+	chatID int64
+	since  time.Time
+}
+
+// cachedRanking 缓存的排行榜及读取时间
+type cachedRanking struct {
+	entries  []*models.RankEntry
+	loadedAt time.Time
+}
+
+// NewRankingCache 创建排行榜缓存，ttl 为缓存有效期，limit 为每个排行榜的条目数
+func NewRankingCache(db RankingStore, ttl time.Duration, limit int) *RankingCache {
+	return &RankingCache{
+		db:       db,
+		ttl:      ttl,
+		limit:    limit,
+		rankings: make(map[rankingKey]*cachedRanking),
+	}
+}
+
+// Get 获取群组 since 之后的排行榜，缓存未命中或已过期时从数据库读取。
+// since 相同的请求共用缓存，统计周期翻篇后自然换用新的键
+func (c *RankingCache) Get(chatID int64, since time.Time) ([]*models.RankEntry, error) {
+	key := rankingKey{chatID: chatID, since: since}
+
+	c.mu.Lock()
+	cached, ok := c.rankings[key]
+	c.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.entries, nil
+	}
+
+	entries, err := c.db.GetChatRanking(chatID, since, c.limit)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.prune()
+	c.rankings[key] = &cachedRanking{entries: entries, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return entries, nil
+}
+
+// prune 每隔一个有效期清除一次过期的缓存，调用方需持有 c.mu
+func (c *RankingCache) prune() {
+	if time.Since(c.prunedAt) < c.ttl {
+		return
+	}
+	c.prunedAt = time.Now()
+	for key, cached := range c.rankings {
+		if time.Since(cached.loadedAt) >= c.ttl {
+			delete(c.rankings, key)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// UserStatsStore 用户战绩存储接口
+type UserStatsStore interface {
+	GetUserStats(userID int64) (*models.UserStats, error)
+}
+
+// UserStatsCache 按用户缓存战绩统计，有效期内重复查看 /stats 不再查询数据库
+type UserStatsCache struct {
+	db  UserStatsStore
+	ttl time.Duration
+
+	mu       sync.Mutex
+	stats    map[int64]*cachedUserStats
+	prunedAt time.Time
+	observer Observer
+}
+
+// cachedUserStats 缓存的战绩及读取时间
+type cachedUserStats struct {
+	stats    models.UserStats
+	loadedAt time.Time
+}
+
+// NewUserStatsCache 创建用户战绩缓存，ttl 为缓存有效期
+func NewUserStatsCache(db UserStatsStore, ttl time.Duration) *UserStatsCache {
+	return &UserStatsCache{
+		db:    db,
+		ttl:   ttl,
+		stats: make(map[int64]*cachedUserStats),
+	}
+}
+
+// SetObserver 设置命中统计的接收者
+func (c *UserStatsCache) SetObserver(observer Observer) {
+	c.observer = observer
+}
+
+// Get 获取用户战绩，缓存未命中或已过期时从数据库读取
+func (c *UserStatsCache) Get(userID int64) (*models.UserStats, error) {
+	c.mu.Lock()
+	cached, ok := c.stats[userID]
+	c.mu.Unlock()
+	hit := ok && time.Since(cached.loadedAt) < c.ttl
+	observe(c.observer, hit)
+	if hit {
+		stats := cached.stats
+		return &stats, nil
+	}
+
+	stats, err := c.db.GetUserStats(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.prune()
+	c.stats[userID] = &cachedUserStats{stats: *stats, loadedAt: time.Now()}
+	c.mu.Unlock()
+	return stats, nil
+}
+
+// Invalidate 用户又完成一局后清除缓存，下次读取时重新统计
+func (c *UserStatsCache) Invalidate(userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.stats, userID)
+}
+
+// prune 每隔一个有效期清除一次过期的缓存，调用方需持有 c.mu
+func (c *UserStatsCache) prune() {
+	if time.Since(c.prunedAt) < c.ttl {
+		return
+	}
+	c.prunedAt = time.Now()
+	for userID, cached := range c.stats {
+		if time.Since(cached.loadedAt) >= c.ttl {
+			delete(c.stats, userID)
+		}
+	}
+}
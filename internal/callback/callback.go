@@ -0,0 +1,128 @@
+package callback
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version 当前回调数据格式版本，格式变更时递增使旧按钮失效
+const Version = "1"
+
+// MaxLength Telegram 回调数据的最大字节数
+const MaxLength = 64
+
+// separator 字段分隔符，动作和参数中不允许出现
+const separator = "|"
+
+// sigLength 签名截取的字节数（base64 编码后 11 个字符）
+const sigLength = 8
+
+var (
+	// ErrMalformed 回调数据格式错误
+	ErrMalformed = errors.New("回调数据格式错误")
+	// ErrStale 回调数据版本过旧（按钮来自旧版本消息）
+	ErrStale = errors.New("按钮已过期，请重新打开菜单")
+	// ErrForged 回调数据签名校验失败
+	ErrForged = errors.New("回调数据签名无效")
+)
+
+// Data 解析后的回调数据
+type Data struct {
+	Action string
+	Args   []string
+}
+
+// Arg 获取第 i 个参数，不存在时返回空字符串
+func (d *Data) Arg(i int) string {
+	if i < 0 || i >= len(d.Args) {
+		return ""
+	}
+	return d.Args[i]
+}
+
+// Int64 将第 i 个参数解析为整数
+func (d *Data) Int64(i int) (int64, error) {
+	value, err := strconv.ParseInt(d.Arg(i), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("回调参数 %d 不是有效数字: %v", i, err)
+	}
+	return value, nil
+}
+
+// Codec 负责回调数据的编码、签名和校验
+type Codec struct {
+	key []byte
+}
+
+// NewCodec 使用机器人密钥创建编解码器
+func NewCodec(secret string) *Codec {
+	key := sha256.Sum256([]byte("callback:" + secret))
+	return &Codec{key: key[:]}
+}
+
+// Encode 编码回调数据，格式为 版本|动作|参数...|签名
+func (c *Codec) Encode(action string, args ...string) (string, error) {
+	fields := append([]string{Version, action}, args...)
+	for _, field := range fields[1:] {
+		if strings.Contains(field, separator) {
+			return "", fmt.Errorf("回调字段不能包含 %q: %s", separator, field)
+		}
+	}
+	if action == "" {
+		return "", fmt.Errorf("回调动作不能为空")
+	}
+
+	payload := strings.Join(fields, separator)
+	data := payload + separator + c.sign(payload)
+	if len(data) > MaxLength {
+		return "", fmt.Errorf("回调数据超过 %d 字节: %s", MaxLength, payload)
+	}
+	return data, nil
+}
+
+// Decode 校验并解析回调数据
+func (c *Codec) Decode(data string) (*Data, error) {
+	if len(data) > MaxLength {
+		return nil, ErrMalformed
+	}
+	i := strings.LastIndex(data, separator)
+	if i <= 0 {
+		return nil, ErrMalformed
+	}
+	payload, sig := data[:i], data[i+1:]
+
+	fields := strings.Split(payload, separator)
+	if len(fields) < 2 || fields[1] == "" {
+		return nil, ErrMalformed
+	}
+	if fields[0] != Version {
+		return nil, ErrStale
+	}
+	if !hmac.Equal([]byte(sig), []byte(c.sign(payload))) {
+		return nil, ErrForged
+	}
+
+	return &Data{Action: fields[1], Args: fields[2:]}, nil
+}
+
+// IsVersioned 判断回调数据是否为带版本的新格式（用于兼容旧的纯文本回调）
+func IsVersioned(data string) bool {
+	return strings.Count(data, separator) >= 2
+}
+
+// Prefix 返回指定动作编码后的固定前缀，用于按前缀注册回调路由
+func Prefix(action string) string {
+	return Version + separator + action + separator
+}
+
+// sign 计算截断的 HMAC-SHA256 签名
+func (c *Codec) sign(payload string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:sigLength])
+}
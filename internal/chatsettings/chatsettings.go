@@ -0,0 +1,141 @@
+package chatsettings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+)
+
+// Version 当前的群组设置导出格式版本
+const Version = 1
+
+// MaxGameCooldownSeconds 对局冷却时间上限（秒），与 game.MaxGameCooldown 保持一致
+const MaxGameCooldownSeconds = 3600
+
+// Export 导出群组的全部可复制设置
+func Export(db *database.DB, chatID int64) (*models.ChatSettings, error) {
+	chat, err := db.GetChat(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("获取群组信息失败: %v", err)
+	}
+	if chat == nil {
+		return nil, fmt.Errorf("群组 %d 不存在", chatID)
+	}
+
+	language := ui.NormalizeLanguage(chat.Language)
+	return &models.ChatSettings{
+		Version:          Version,
+		Language:         &language,
+		SurrenderEnabled: &chat.SurrenderEnabled,
+		JackpotAnnounce:  &chat.JackpotAnnounce,
+		RevenueShare:     &chat.RevenueShare,
+		FeedOptOut:       &chat.FeedOptOut,
+		SequentialGames:  &chat.SequentialGames,
+		MaxActiveGames:   &chat.MaxActiveGames,
+		GameCooldown:     &chat.GameCooldown,
+		ReadyCheck:       &chat.ReadyCheck,
+	}, nil
+}
+
+// Parse 解析并校验导入的设置 JSON，拒绝未知字段以免拼写错误被静默忽略
+func Parse(data []byte) (*models.ChatSettings, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var settings models.ChatSettings
+	if err := decoder.Decode(&settings); err != nil {
+		return nil, fmt.Errorf("设置格式错误: %v", err)
+	}
+	if err := Validate(&settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// Validate 校验设置的版本和取值范围
+func Validate(settings *models.ChatSettings) error {
+	if settings.Version != Version {
+		return fmt.Errorf("不支持的设置版本: %d", settings.Version)
+	}
+	if settings.Language != nil && ui.NormalizeLanguage(*settings.Language) != *settings.Language {
+		return fmt.Errorf("不支持的语言: %s", *settings.Language)
+	}
+	if settings.RevenueShare != nil && (*settings.RevenueShare < 0 || *settings.RevenueShare > 1) {
+		return fmt.Errorf("分成比例必须在 0 到 1 之间")
+	}
+	if settings.MaxActiveGames != nil && *settings.MaxActiveGames < 0 {
+		return fmt.Errorf("同时进行的对局上限不能为负数")
+	}
+	if settings.GameCooldown != nil && (*settings.GameCooldown < 0 || *settings.GameCooldown > MaxGameCooldownSeconds) {
+		return fmt.Errorf("对局冷却时间必须在 0 到 %d 秒之间", MaxGameCooldownSeconds)
+	}
+	return nil
+}
+
+// Preview 计算设置应用到各群组后的变更，不修改数据
+func Preview(db *database.DB, chatIDs []int64, settings *models.ChatSettings) ([]models.ChatSettingChange, error) {
+	if len(chatIDs) == 0 {
+		return nil, fmt.Errorf("请指定要导入设置的群组")
+	}
+
+	var changes []models.ChatSettingChange
+	for _, chatID := range chatIDs {
+		current, err := Export(db, chatID)
+		if err != nil {
+			return nil, err
+		}
+
+		add := func(field string, from, to interface{}) {
+			if from != to {
+				changes = append(changes, models.ChatSettingChange{ChatID: chatID, Field: field, From: from, To: to})
+			}
+		}
+		if settings.Language != nil {
+			add("language", *current.Language, *settings.Language)
+		}
+		if settings.SurrenderEnabled != nil {
+			add("surrender_enabled", *current.SurrenderEnabled, *settings.SurrenderEnabled)
+		}
+		if settings.JackpotAnnounce != nil {
+			add("jackpot_announce", *current.JackpotAnnounce, *settings.JackpotAnnounce)
+		}
+		if settings.RevenueShare != nil {
+			add("revenue_share", *current.RevenueShare, *settings.RevenueShare)
+		}
+		if settings.FeedOptOut != nil {
+			add("feed_opt_out", *current.FeedOptOut, *settings.FeedOptOut)
+		}
+		if settings.SequentialGames != nil {
+			add("sequential_games", *current.SequentialGames, *settings.SequentialGames)
+		}
+		if settings.MaxActiveGames != nil {
+			add("max_active_games", *current.MaxActiveGames, *settings.MaxActiveGames)
+		}
+		if settings.GameCooldown != nil {
+			add("game_cooldown", *current.GameCooldown, *settings.GameCooldown)
+		}
+		if settings.ReadyCheck != nil {
+			add("ready_check", *current.ReadyCheck, *settings.ReadyCheck)
+		}
+	}
+	return changes, nil
+}
+
+// Apply 校验后将设置应用到各群组，返回实际发生的变更
+func Apply(db *database.DB, chatIDs []int64, settings *models.ChatSettings) ([]models.ChatSettingChange, error) {
+	if err := Validate(settings); err != nil {
+		return nil, err
+	}
+	changes, err := Preview(db, chatIDs, settings)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.ApplyChatSettings(chatIDs, settings); err != nil {
+		return nil, fmt.Errorf("导入群组设置失败: %v", err)
+	}
+	return changes, nil
+}
@@ -0,0 +1,209 @@
+package chatsettings
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pendingTTL 导入预览的有效期
+const pendingTTL = 10 * time.Minute
+
+// pendingImport 已预览、等待管理员确认的导入任务
+type pendingImport struct {
+	userID   int64
+	chatIDs  []int64
+	settings *models.ChatSettings
+	expires  time.Time
+}
+
+// Handler /exportsettings 和 /importsettings 管理员命令的处理器
+type Handler struct {
+	db       *database.DB
+	codec    *callback.Codec
+	adminIDs []int64
+
+	mu      sync.Mutex
+	pending map[string]*pendingImport
+}
+
+// NewHandler 创建群组设置导出导入处理器
+func NewHandler(db *database.DB, codec *callback.Codec, adminIDs []int64) *Handler {
+	return &Handler{
+		db:       db,
+		codec:    codec,
+		adminIDs: adminIDs,
+		pending:  make(map[string]*pendingImport),
+	}
+}
+
+// Register 注册导出、导入命令和确认导入回调，仅限管理员
+func (h *Handler) Register(router *middleware.Router) {
+	adminOnly := middleware.AdminOnly(h.adminIDs)
+	router.Handle(ui.ExportSettingsCommand, h.Export, adminOnly)
+	router.Handle(ui.ImportSettingsCommand, h.Import, adminOnly)
+	router.HandleCallback(callback.Prefix(ui.ChatSettingsApplyAction), h.Confirm, adminOnly)
+}
+
+// Export 导出群组设置，群内不带参数时导出本群
+func (h *Handler) Export(ctx *middleware.Context) error {
+	chatID := ctx.ChatID
+	if args := strings.TrimSpace(ctx.Args); args != "" {
+		id, err := strconv.ParseInt(args, 10, 64)
+		if err != nil {
+			return ctx.Reply("❌ 用法：/exportsettings <群组ID>")
+		}
+		chatID = id
+	} else if chatID >= 0 {
+		return ctx.Reply("❌ 用法：/exportsettings <群组ID>")
+	}
+
+	settings, err := Export(h.db, chatID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	data, err := json.MarshalIndent(settings, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ctx.Reply(ui.FormatChatSettingsExport(chatID, string(data)))
+}
+
+// Import 解析导入内容并发送变更预览，管理员确认后才会应用
+func (h *Handler) Import(ctx *middleware.Context) error {
+	chatIDs, data, err := h.parseImportArgs(ctx)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error() + "\n用法：/importsettings <群组ID,群组ID> <设置JSON>")
+	}
+
+	settings, err := Parse([]byte(data))
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	changes, err := Preview(h.db, chatIDs, settings)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+
+	text := ui.FormatChatSettingsPreview(chatIDs, changes)
+	if len(changes) == 0 {
+		return ctx.Reply(text)
+	}
+
+	token, err := h.store(&pendingImport{
+		userID:   ctx.UserID,
+		chatIDs:  chatIDs,
+		settings: settings,
+		expires:  time.Now().Add(pendingTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	msg, err := ui.BuildChatSettingsPreview(h.codec, ctx.ChatID, token, text)
+	if err != nil {
+		return err
+	}
+	_, err = ctx.Client.Send(msg)
+	return err
+}
+
+// Confirm 确认导入：只有发起预览的管理员可以确认
+func (h *Handler) Confirm(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	token, err := ui.ParseChatSettingsApplyCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+
+	h.mu.Lock()
+	pending, ok := h.pending[token]
+	if ok && pending.userID == ctx.UserID {
+		delete(h.pending, token)
+	}
+	h.mu.Unlock()
+
+	if !ok || time.Now().After(pending.expires) {
+		return ctx.Reply("⌛ 预览已过期，请重新发送 /importsettings")
+	}
+	if pending.userID != ctx.UserID {
+		return ctx.Reply("⛔ 只有发起导入的管理员可以确认")
+	}
+
+	changes, err := Apply(h.db, pending.chatIDs, pending.settings)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	log.Printf("📥 管理员 %d 导入群组设置到 %v，共 %d 项变更", ctx.UserID, pending.chatIDs, len(changes))
+
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "✅ 已导入")); err != nil {
+		log.Printf("⚠️ 应答导入按钮失败: %v", err)
+	}
+	edit := tgbotapi.NewEditMessageText(ctx.ChatID, query.Message.MessageID,
+		fmt.Sprintf("✅ 已将设置导入 %d 个群组，共 %d 项变更", len(pending.chatIDs), len(changes)))
+	_, err = ctx.Client.Request(edit)
+	return err
+}
+
+// parseImportArgs 解析导入参数：群组ID列表和设置JSON，群内省略群组ID时导入本群
+func (h *Handler) parseImportArgs(ctx *middleware.Context) ([]int64, string, error) {
+	args := strings.TrimSpace(ctx.Args)
+	if strings.HasPrefix(args, "{") {
+		if ctx.ChatID >= 0 {
+			return nil, "", fmt.Errorf("请指定要导入设置的群组")
+		}
+		return []int64{ctx.ChatID}, args, nil
+	}
+
+	split := strings.IndexFunc(args, unicode.IsSpace)
+	if split < 0 {
+		return nil, "", fmt.Errorf("缺少设置JSON")
+	}
+	ids, data := args[:split], args[split:]
+
+	var chatIDs []int64
+	for _, field := range strings.Split(ids, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, "", fmt.Errorf("无效的群组ID: %s", field)
+		}
+		chatIDs = append(chatIDs, id)
+	}
+	return chatIDs, strings.TrimSpace(data), nil
+}
+
+// store 保存待确认的导入任务并返回令牌，同时清理过期任务
+func (h *Handler) store(pending *pendingImport) (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for key, p := range h.pending {
+		if now.After(p.expires) {
+			delete(h.pending, key)
+		}
+	}
+	h.pending[token] = pending
+	return token, nil
+}
@@ -1,19 +1,130 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+
+	"telegram-dice-bot/internal/utils"
 )
 
 type Config struct {
 	BotToken    string  `json:"bot_token"`
+	BotAPIURL   string  `json:"bot_api_url"` // Bot API 基础地址，可指向自建的 telegram-bot-api 服务器
 	DatabaseURL string  `json:"database_url"`
 	Port        string  `json:"port"`
 	FeeRate     float64 `json:"fee_rate"`
-	MinBet      int64   `json:"min_bet"`
+	MinBet      int64   `json:"min_bet"` // 金额均为基本单位（0.01 金币），环境变量中按金币填写，可带两位小数
 	MaxBet      int64   `json:"max_bet"`
 
+	// 回调数据签名密钥，未配置时使用 BotToken
+	CallbackSecret string `json:"-"`
+
+	// 认输时退还的下注比例（需在群组中开启认输功能）
+	SurrenderRefundRate float64 `json:"surrender_refund_rate"`
+
+	// 同时服务的群组上限，0 表示不限制
+	MaxActiveChats int64 `json:"max_active_chats"`
+
+	// 分片配置：多个进程共享数据库，按 chatID mod ShardCount 划分群组
+	ShardID    int64 `json:"shard_id"`
+	ShardCount int64 `json:"shard_count"`
+
+	// 充值配置：地址文件为空时不启用 /recharge
+	RechargeAddressFile string  `json:"recharge_address_file"` // 每行一个 TRC20 USDT 地址
+	RechargeMinAmount   float64 `json:"recharge_min_amount"`   // 最低充值金额（USDT）
+	RechargeRate        float64 `json:"recharge_rate"`         // 1 USDT 兑换的金币数
+	RechargeQRURL       string  `json:"recharge_qr_url"`       // 二维码图片地址模板，%s 替换为充值地址，为空时不发送二维码
+
+	// 链上充值检测：定期查询已分配地址收到的 USDT 转账并自动入账，间隔为 0 时只能手动确认
+	RechargeWatchInterval int64  `json:"recharge_watch_interval"` // 查询间隔（秒）
+	RechargeWatchAPI      string `json:"recharge_watch_api"`      // TronGrid 兼容的 API 地址
+	RechargeWatchAPIKey   string `json:"recharge_watch_api_key"`  // TronGrid API Key，可为空
+	RechargeUSDTContract  string `json:"recharge_usdt_contract"`  // USDT TRC20 合约地址
+
+	// 提现配置：按 RechargeRate 折算 USDT，管理员审核后线下转出
+	WithdrawMinAmount int64 `json:"withdraw_min_amount"` // 单笔最低提现金币
+
+	// 群组准入：open 不限制，allowlist 只服务白名单群组，blocklist 拒绝黑名单群组
+	// 后台面板中的设置优先于此处的模式，名单与后台名单合并生效
+	ChatAccessMode string  `json:"chat_access_mode"`
+	ChatAllowlist  []int64 `json:"chat_allowlist"`
+	ChatBlocklist  []int64 `json:"chat_blocklist"`
+
+	// 大奖频道转发：频道ID为 0 时不启用
+	FeedChannelID int64 `json:"feed_channel_id"`
+	FeedMinStake  int64 `json:"feed_min_stake"` // 下注额不低于该值才转发
+	FeedMinGap    int64 `json:"feed_min_gap"`   // 两条转发的最小间隔（秒）
+
+	// 跨群连胜播报：时间窗口内在至少 StreakMinChats 个群获胜时，到开启播报的用户的常驻群庆祝，为 0 时不启用
+	StreakMinChats int64 `json:"streak_min_chats"`
+	StreakWindow   int64 `json:"streak_window"` // 统计窗口（分钟）
+
+	// 数据库维护：在低峰时段执行 WAL 检查点、增量 VACUUM 和 ANALYZE，时段为空时只能手动触发
+	DBMaintenanceWindow   string `json:"db_maintenance_window"`   // 如 03:00-05:00，按服务器本地时间
+	DBMaintenanceInterval int64  `json:"db_maintenance_interval"` // 两次定时维护的最小间隔（小时）
+	DBVacuumPages         int64  `json:"db_vacuum_pages"`         // 每次增量 VACUUM 最多回收的页数
+
+	// 余额对账：每晚在该时段按流水重算用户余额，差异记录后告警，时段为空时只能手动触发
+	ReconcileWindow     string `json:"reconcile_window"`      // 如 02:00-04:00，按服务器本地时间
+	ReconcileAutoFreeze bool   `json:"reconcile_auto_freeze"` // 发现差异时冻结账户（不能下注和提现），管理员处理后解冻
+
+	// 风控：分出胜负的对局结算后统计双方窗口内的对局，标记串通或转移筹码嫌疑并告警，窗口为 0 时不启用
+	RiskWindow      int64   `json:"risk_window"`       // 统计窗口（小时）
+	RiskPairGames   int64   `json:"risk_pair_games"`   // 同一对玩家在窗口内的对局数达到该值时标记双方，0 表示不检查
+	RiskMinGames    int64   `json:"risk_min_games"`    // 胜率检查所需的最少对局数
+	RiskWinRate     float64 `json:"risk_win_rate"`     // 胜率不低于该值时标记，0 表示不检查
+	RiskNetTransfer int64   `json:"risk_net_transfer"` // 同一对玩家之间净输赢达到该金额时标记双方，0 表示不检查
+	RiskAutoFreeze  bool    `json:"risk_auto_freeze"`  // 标记时冻结账户（不能下注和提现），管理员审核后处理
+
+	// 锦标赛：管理员用 /tournament 开设，报名截止后每隔一段时间自动进行一轮淘汰赛
+	TournamentSignup        int64 `json:"tournament_signup"`         // 默认报名时长（分钟）
+	TournamentRoundInterval int64 `json:"tournament_round_interval"` // 两轮之间的间隔（秒）
+
+	// 每周战报：每周一在该时段私信订阅用户上一周的汇总，时段为空时不发送
+	WeeklySummaryWindow string `json:"weekly_summary_window"` // 如 10:00-12:00，按服务器本地时间
+
+	// 管理员公告：每秒最多发送的消息数，需低于 Telegram 全局 30 条/秒的限制
+	BroadcastRate int64 `json:"broadcast_rate"`
+
+	// 群组无活动超过该天数后标记为休眠，不再收到定时播报，有新消息时自动恢复，0 表示不启用
+	ChatDormantDays int64 `json:"chat_dormant_days"`
+
+	// 对局从开骰起超过该时长（秒）仍未结算时强制中止并向双方退款，0 表示不启用
+	MaxGameDuration int64 `json:"max_game_duration"`
+	// 对局从开骰起超过该时长（秒）仍未结算时私信双方说明延迟，0 表示不通知
+	SettlementDelayNotice int64 `json:"settlement_delay_notice"`
+
+	// 关闭服务时等待进行中的对局结算的最长时长（秒），超时仍未结算的对局向双方退款
+	ShutdownTimeout int64 `json:"shutdown_timeout"`
+
+	// 每日签到：每 24 小时可领取一次免费金币，连续签到每天额外加成 DailyStreakRate，最多累计 DailyStreakMaxDays 天，金额为 0 时不启用
+	DailyBonus         int64   `json:"daily_bonus"`
+	DailyStreakRate    float64 `json:"daily_streak_rate"`
+	DailyStreakMaxDays int64   `json:"daily_streak_max_days"`
+
+	// 每人每天最多进行的对局数（含快速桌），按服务器时区零点重置，管理员及被豁免的用户不受限制，0 表示不限制
+	DailyGameLimit int64 `json:"daily_game_limit"`
+
+	// 邀请奖励：被邀请人通过 /start ref_<邀请人ID> 注册时发放注册奖励，累计下注达到 ReferralWagerThreshold 时再发放一次流水奖励，金额为 0 时不发放
+	ReferralSignupReferrer int64 `json:"referral_signup_referrer"`
+	ReferralSignupReferee  int64 `json:"referral_signup_referee"`
+	ReferralWagerThreshold int64 `json:"referral_wager_threshold"`
+	ReferralWagerReferrer  int64 `json:"referral_wager_referrer"`
+	ReferralWagerReferee   int64 `json:"referral_wager_referee"`
+	// 邀请奖励计入赠送余额，完成奖励金额该倍数的下注流水后才可提现，0 表示直接计入可提现余额
+	ReferralWageringMultiplier float64 `json:"referral_wagering_multiplier"`
+
+	// 每个群组每小时（整点起算）的下注总额上限，达到后暂停发起新对局直到下一个整点，0 表示不限制；管理员可按群组调整
+	ChatHourlyCap int64 `json:"chat_hourly_cap"`
+	// 同一群组 24 小时内触发上限的小时数达到该值时向管理员告警，0 表示不告警
+	ChatCapAlertHits int64 `json:"chat_cap_alert_hits"`
+
+	// 启用的玩法插件名称，按顺序组合，为空时点数高者获胜、手续费按费率收取
+	GameRules []string `json:"game_rules"`
+
 	// HTTPS配置
 	Domain       string `json:"domain"`
 	EnableHTTPS  bool   `json:"enable_https"`
@@ -23,16 +134,136 @@ type Config struct {
 
 	// 管理员配置
 	AdminIDs []int64 `json:"admin_ids"`
+
+	// 网络加速器配置
+	EnableAccelerator    bool     `json:"enable_accelerator"`
+	AcceleratorEndpoints []string `json:"accelerator_endpoints"` // 额外探测的 Bot API 地址（如本地 Bot API 服务器）
+	// 接入点切换阈值，可通过 /netconfig 在运行时调整
+	AcceleratorErrorRate     float64 `json:"accelerator_error_rate"`     // 错误率超过该值时切换接入点
+	AcceleratorLatency       int64   `json:"accelerator_latency"`        // 平均延迟超过该值时切换接入点（毫秒）
+	AcceleratorCheckInterval int64   `json:"accelerator_check_interval"` // 检查网络质量的间隔（秒）
+	AcceleratorCacheTTL      int64   `json:"accelerator_cache_ttl"`      // GET 响应缓存时长（秒）
+
+	// 调试服务监听地址（仅限本机，如 127.0.0.1:6060），提供 pprof 和运行时统计，为空时不启用
+	DebugAddr string `json:"debug_addr"`
+	// Prometheus 指标服务监听地址（如 127.0.0.1:9090），提供 /metrics，为空时不启用
+	MetricsAddr string `json:"metrics_addr"`
+	// 管理后台监听地址（如 127.0.0.1:8080），为空或未设置密码时不启用
+	AdminAddr     string `json:"admin_addr"`
+	AdminUsername string `json:"admin_username"`
+	AdminPassword string `json:"-"`
+
+	// 管理员告警群组或频道，为 0 时只记录日志
+	AlertChatID int64 `json:"alert_chat_id"`
+
+	// 代理配置（受限网络环境访问 Telegram）
+	ProxyURL      string `json:"proxy_url"` // 如 socks5://127.0.0.1:1080 或 http://proxy:8080
+	ProxyUsername string `json:"proxy_username"`
+	ProxyPassword string `json:"-"`
+
+	// 沙盒模式：使用第二个机器人和独立的数据库，余额为免费的练习金币，用于演练配置和新功能
+	Sandbox        bool  `json:"sandbox"`
+	SandboxBalance int64 `json:"sandbox_balance"` // /faucet 领取后的练习金币余额
 }
 
 func Load() (*Config, error) {
 	cfg := &Config{
 		BotToken:    getEnv("BOT_TOKEN", ""),
+		BotAPIURL:   strings.TrimRight(getEnv("BOT_API_URL", "https://api.telegram.org"), "/"),
 		DatabaseURL: getEnv("DATABASE_URL", "dice_bot.db"),
 		Port:        getEnv("PORT", "8080"),
 		FeeRate:     getEnvFloat("FEE_RATE", 0.1), // 默认10%
-		MinBet:      getEnvInt("MIN_BET", 1),
-		MaxBet:      getEnvInt("MAX_BET", 100),
+		MinBet:      getEnvAmount("MIN_BET", 1),
+		MaxBet:      getEnvAmount("MAX_BET", 100),
+
+		SurrenderRefundRate: getEnvFloat("SURRENDER_REFUND_RATE", 0.5),
+		MaxActiveChats:      getEnvInt("MAX_ACTIVE_CHATS", 0),
+		ShardID:             getEnvInt("SHARD_ID", 0),
+		ShardCount:          getEnvInt("SHARD_COUNT", 1),
+
+		// 充值配置
+		RechargeAddressFile: getEnv("RECHARGE_ADDRESS_FILE", ""),
+		RechargeMinAmount:   getEnvFloat("RECHARGE_MIN_AMOUNT", 10),
+		RechargeRate:        getEnvFloat("RECHARGE_RATE", 100),
+		RechargeQRURL:       getEnv("RECHARGE_QR_URL", ""),
+
+		// 链上充值检测
+		RechargeWatchInterval: getEnvInt("RECHARGE_WATCH_INTERVAL", 60),
+		RechargeWatchAPI:      getEnv("RECHARGE_WATCH_API", "https://api.trongrid.io"),
+		RechargeWatchAPIKey:   getEnv("RECHARGE_WATCH_API_KEY", ""),
+		RechargeUSDTContract:  getEnv("RECHARGE_USDT_CONTRACT", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"),
+
+		// 提现配置
+		WithdrawMinAmount: getEnvAmount("WITHDRAW_MIN_AMOUNT", 1000),
+
+		// 群组准入
+		ChatAccessMode: getEnv("CHAT_ACCESS_MODE", "open"),
+		ChatAllowlist:  getEnvInt64Slice("CHAT_ALLOWLIST", nil),
+		ChatBlocklist:  getEnvInt64Slice("CHAT_BLOCKLIST", nil),
+
+		// 大奖频道转发
+		FeedChannelID: getEnvInt("FEED_CHANNEL_ID", 0),
+		FeedMinStake:  getEnvAmount("FEED_MIN_STAKE", 1000),
+		FeedMinGap:    getEnvInt("FEED_MIN_GAP", 60),
+
+		// 跨群连胜播报
+		StreakMinChats: getEnvInt("STREAK_MIN_CHATS", 3),
+		StreakWindow:   getEnvInt("STREAK_WINDOW", 30),
+
+		// 数据库维护
+		DBMaintenanceWindow:   getEnv("DB_MAINTENANCE_WINDOW", "03:00-05:00"),
+		DBMaintenanceInterval: getEnvInt("DB_MAINTENANCE_INTERVAL", 24),
+		DBVacuumPages:         getEnvInt("DB_VACUUM_PAGES", 1000),
+
+		// 余额对账
+		ReconcileWindow:     getEnv("RECONCILE_WINDOW", "02:00-04:00"),
+		ReconcileAutoFreeze: getEnvBool("RECONCILE_AUTO_FREEZE", false),
+
+		// 风控
+		RiskWindow:      getEnvInt("RISK_WINDOW", 24),
+		RiskPairGames:   getEnvInt("RISK_PAIR_GAMES", 30),
+		RiskMinGames:    getEnvInt("RISK_MIN_GAMES", 20),
+		RiskWinRate:     getEnvFloat("RISK_WIN_RATE", 0.85),
+		RiskNetTransfer: getEnvAmount("RISK_NET_TRANSFER", 5000),
+		RiskAutoFreeze:  getEnvBool("RISK_AUTO_FREEZE", false),
+
+		// 锦标赛
+		TournamentSignup:        getEnvInt("TOURNAMENT_SIGNUP", 10),
+		TournamentRoundInterval: getEnvInt("TOURNAMENT_ROUND_INTERVAL", 60),
+
+		// 每周战报
+		WeeklySummaryWindow: getEnv("WEEKLY_SUMMARY_WINDOW", "10:00-12:00"),
+
+		// 管理员公告
+		BroadcastRate: getEnvInt("BROADCAST_RATE", 20),
+
+		ChatDormantDays:       getEnvInt("CHAT_DORMANT_DAYS", 30),
+		MaxGameDuration:       getEnvInt("MAX_GAME_DURATION", 300),
+		SettlementDelayNotice: getEnvInt("SETTLEMENT_DELAY_NOTICE", 60),
+		ShutdownTimeout:       getEnvInt("SHUTDOWN_TIMEOUT", 30),
+
+		// 每日签到
+		DailyBonus:         getEnvAmount("DAILY_BONUS", 10),
+		DailyStreakRate:    getEnvFloat("DAILY_STREAK_RATE", 0.1),
+		DailyStreakMaxDays: getEnvInt("DAILY_STREAK_MAX_DAYS", 7),
+
+		// 每日对局数上限
+		DailyGameLimit: getEnvInt("DAILY_GAME_LIMIT", 0),
+
+		// 邀请奖励
+		ReferralSignupReferrer:     getEnvAmount("REFERRAL_SIGNUP_REFERRER", 0),
+		ReferralSignupReferee:      getEnvAmount("REFERRAL_SIGNUP_REFEREE", 5),
+		ReferralWagerThreshold:     getEnvAmount("REFERRAL_WAGER_THRESHOLD", 100),
+		ReferralWagerReferrer:      getEnvAmount("REFERRAL_WAGER_REFERRER", 20),
+		ReferralWagerReferee:       getEnvAmount("REFERRAL_WAGER_REFEREE", 5),
+		ReferralWageringMultiplier: getEnvFloat("REFERRAL_WAGERING_MULTIPLIER", 1),
+
+		// 群组每小时下注上限
+		ChatHourlyCap:    getEnvAmount("CHAT_HOURLY_CAP", 0),
+		ChatCapAlertHits: getEnvInt("CHAT_CAP_ALERT_HITS", 3),
+
+		// 玩法插件
+		GameRules: getEnvStringSlice("GAME_RULES", nil),
 
 		// HTTPS配置
 		Domain:       getEnv("DOMAIN", ""),
@@ -43,6 +274,39 @@ func Load() (*Config, error) {
 
 		// 管理员配置
 		AdminIDs: getEnvInt64Slice("ADMIN_IDS", []int64{}),
+
+		// 网络加速器配置
+		EnableAccelerator:    getEnvBool("ENABLE_ACCELERATOR", true),
+		AcceleratorEndpoints: getEnvStringSlice("ACCELERATOR_ENDPOINTS", nil),
+
+		AcceleratorErrorRate:     getEnvFloat("ACCELERATOR_ERROR_RATE", 0.3),
+		AcceleratorLatency:       getEnvInt("ACCELERATOR_LATENCY", 200),
+		AcceleratorCheckInterval: getEnvInt("ACCELERATOR_CHECK_INTERVAL", 30),
+		AcceleratorCacheTTL:      getEnvInt("ACCELERATOR_CACHE_TTL", 300),
+
+		AlertChatID: getEnvInt("ALERT_CHAT_ID", 0),
+
+		DebugAddr:   getEnv("DEBUG_ADDR", ""),
+		MetricsAddr: getEnv("METRICS_ADDR", ""),
+
+		AdminAddr:     getEnv("ADMIN_ADDR", ""),
+		AdminUsername: getEnv("ADMIN_USERNAME", "admin"),
+		AdminPassword: getEnv("ADMIN_PASSWORD", ""),
+
+		// 代理配置
+		ProxyURL:      getEnv("PROXY_URL", ""),
+		ProxyUsername: getEnv("PROXY_USERNAME", ""),
+		ProxyPassword: getEnv("PROXY_PASSWORD", ""),
+
+		// 沙盒模式
+		Sandbox:        getEnvBool("SANDBOX_MODE", false),
+		SandboxBalance: getEnvAmount("SANDBOX_BALANCE", 100000),
+	}
+
+	if cfg.Sandbox {
+		if err := cfg.applySandbox(); err != nil {
+			return nil, err
+		}
 	}
 
 	if cfg.BotToken == "" {
@@ -50,6 +314,8 @@ func Load() (*Config, error) {
 		cfg.BotToken = "YOUR_BOT_TOKEN_HERE"
 	}
 
+	cfg.CallbackSecret = getEnv("CALLBACK_SECRET", cfg.BotToken)
+
 	// 设置默认管理员ID（如果没有配置）
 	if len(cfg.AdminIDs) == 0 {
 		// 默认管理员ID，建议通过环境变量配置
@@ -59,6 +325,31 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// applySandbox 沙盒模式改用沙盒机器人和独立的数据库文件，不能与正式环境共用
+func (c *Config) applySandbox() error {
+	liveToken, liveDatabase := c.BotToken, c.DatabaseURL
+
+	c.BotToken = getEnv("SANDBOX_BOT_TOKEN", "")
+	if c.BotToken == "" {
+		return fmt.Errorf("沙盒模式需要配置 SANDBOX_BOT_TOKEN")
+	}
+	if c.BotToken == liveToken {
+		return fmt.Errorf("沙盒机器人不能与正式机器人使用同一个 Token")
+	}
+
+	lower := strings.ToLower(liveDatabase)
+	if (strings.HasPrefix(lower, "postgres://") || strings.HasPrefix(lower, "postgresql://")) && getEnv("SANDBOX_DATABASE_URL", "") == "" {
+		return fmt.Errorf("正式数据库为 PostgreSQL 时，沙盒模式需要配置 SANDBOX_DATABASE_URL")
+	}
+
+	c.DatabaseURL = getEnv("SANDBOX_DATABASE_URL",
+		filepath.Join(filepath.Dir(liveDatabase), "sandbox_"+filepath.Base(liveDatabase)))
+	if filepath.Clean(c.DatabaseURL) == filepath.Clean(liveDatabase) {
+		return fmt.Errorf("沙盒数据库不能与正式数据库相同: %s", c.DatabaseURL)
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -84,6 +375,19 @@ func getEnvInt(key string, defaultValue int64) int64 {
 	return defaultValue
 }
 
+// getEnvAmount 读取以金币为单位的金额（如 0.5），换算为基本单位；填 0 表示关闭对应功能，格式错误时使用默认值
+func getEnvAmount(key string, defaultCoins int64) int64 {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		if value == "0" {
+			return 0
+		}
+		if amount, err := utils.ParseAmount(value); err == nil {
+			return amount
+		}
+	}
+	return utils.Coins(defaultCoins)
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if b, err := strconv.ParseBool(value); err == nil {
@@ -93,6 +397,23 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+func getEnvStringSlice(key string, defaultValue []string) []string {
+	if value := os.Getenv(key); value != "" {
+		// 格式: "https://a,https://b"
+		var result []string
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+
+		if len(result) > 0 {
+			return result
+		}
+	}
+	return defaultValue
+}
+
 func getEnvInt64Slice(key string, defaultValue []int64) []int64 {
 	if value := os.Getenv(key); value != "" {
 		// 格式: "123,456,789"
@@ -0,0 +1,64 @@
+package cooldown
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+)
+
+// Handler /cooldown 命令的处理器
+type Handler struct {
+	manager *game.Manager
+}
+
+// NewHandler 创建对局冷却处理器
+func NewHandler(manager *game.Manager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// Register 注册 /cooldown 命令，仅限群管理员
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.CooldownCommand, h.SetCooldown, middleware.ChatAdminOnly())
+}
+
+// SetCooldown 查看或设置本群两局之间的冷却时间
+func (h *Handler) SetCooldown(ctx *middleware.Context) error {
+	arg := strings.ToLower(strings.TrimSpace(ctx.Args))
+	if arg == "" {
+		cooldown, err := h.manager.ChatGameCooldown(ctx.ChatID)
+		if err != nil {
+			return err
+		}
+		return ctx.Reply(ui.FormatChatCooldown(ctx.Lang, cooldown))
+	}
+
+	cooldown, ok := parseCooldown(arg)
+	if !ok {
+		return ctx.Reply(ui.FormatInvalidCooldown(ctx.Lang))
+	}
+	if err := h.manager.SetChatGameCooldown(ctx.ChatID, cooldown); err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	log.Printf("🧊 群管理员 %d 将群组 %d 对局冷却设置为 %v", ctx.UserID, ctx.ChatID, cooldown)
+	return ctx.Reply(ui.FormatChatCooldownSet(ctx.Lang, cooldown))
+}
+
+// parseCooldown 解析冷却时间：off、秒数或 Go 时长格式（如 2m、90s），按秒取整
+func parseCooldown(arg string) (time.Duration, bool) {
+	if arg == ui.CooldownOff {
+		return 0, true
+	}
+	if seconds, err := strconv.Atoi(arg); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	d, err := time.ParseDuration(arg)
+	if err != nil {
+		return 0, false
+	}
+	return d.Truncate(time.Second), true
+}
@@ -0,0 +1,44 @@
+package daily
+
+import (
+	"errors"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+)
+
+// Handler /daily 每日签到的处理器
+type Handler struct {
+	manager *game.Manager
+	cfg     *config.Config
+}
+
+// NewHandler 创建每日签到处理器
+func NewHandler(manager *game.Manager, cfg *config.Config) *Handler {
+	return &Handler{manager: manager, cfg: cfg}
+}
+
+// Register 注册 /daily 命令，签到金额为 0 时不注册
+func (h *Handler) Register(router *middleware.Router) {
+	if h.cfg.DailyBonus <= 0 {
+		return
+	}
+	router.Handle(ui.DailyCommand, h.Claim)
+}
+
+// Claim 领取今天的签到金币，群组和私聊中均可使用
+func (h *Handler) Claim(ctx *middleware.Context) error {
+	claim, balance, err := h.manager.ClaimDaily(ctx.UserID)
+	var notReady *game.DailyNotReadyError
+	if errors.As(err, &notReady) {
+		return ctx.Reply(ui.FormatDailyNotReady(notReady.Remaining, notReady.Streak))
+	}
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+
+	tomorrow := game.DailyReward(h.cfg.DailyBonus, claim.Streak+1, h.cfg.DailyStreakRate, int(h.cfg.DailyStreakMaxDays))
+	return ctx.Reply(ui.FormatDailyClaimed(claim, balance, h.cfg.DailyBonus, tomorrow))
+}
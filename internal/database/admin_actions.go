@@ -0,0 +1,52 @@
+package database
+
+import (
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// RecordAdminAction 记录管理员操作
+func (db *DB) RecordAdminAction(action *models.AdminAction) error {
+	query := `INSERT INTO admin_actions (admin, action, target_type, target_id, details, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`
+
+	action.CreatedAt = time.Now()
+	result, err := db.conn.Exec(query, action.Admin, action.Action, action.TargetType,
+		action.TargetID, action.Details, action.CreatedAt)
+	if err != nil {
+		return err
+	}
+	action.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetAdminActions 获取管理员操作记录，targetType 为空时返回全部
+func (db *DB) GetAdminActions(targetType, targetID string, limit int) ([]*models.AdminAction, error) {
+	query := `SELECT id, admin, action, target_type, target_id, COALESCE(details, ''), created_at
+			  FROM admin_actions`
+	var args []interface{}
+	if targetType != "" {
+		query += ` WHERE target_type = ? AND target_id = ?`
+		args = append(args, targetType, targetID)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []*models.AdminAction
+	for rows.Next() {
+		action := &models.AdminAction{}
+		if err := rows.Scan(&action.ID, &action.Admin, &action.Action, &action.TargetType,
+			&action.TargetID, &action.Details, &action.CreatedAt); err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+	return actions, rows.Err()
+}
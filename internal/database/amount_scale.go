@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// AmountScaleSettingKey 数据库中金额所用换算比例在 bot_settings 中的键，未设置表示旧版按整数金币存储
+const AmountScaleSettingKey = "amount_scale"
+
+// amountColumns 以金额存储的字段，迁移到基本单位时按比例放大
+var amountColumns = []struct{ table, column string }{
+	{"users", "balance"},
+	{"users", "bonus_balance"},
+	{"games", "bet_amount"},
+	{"games", "commission"},
+	{"games", "player1_bonus_stake"},
+	{"games", "player2_bonus_stake"},
+	{"games", "chat_share"},
+	{"games", "insurance_premium"},
+	{"games", "insurance_coverage"},
+	{"transactions", "amount"},
+	{"transactions", "balance"},
+	{"chats", "fund_balance"},
+	{"chats", "prize_pool"},
+	{"chats", "min_bet"},
+	{"chats", "max_bet"},
+	{"bonus_campaigns", "max_bonus"},
+	{"bonus_wagering", "bonus_amount"},
+	{"bonus_wagering", "wagering_required"},
+	{"bonus_wagering", "wagered"},
+	{"game_tables", "ante"},
+	{"game_tables", "commission"},
+	{"table_players", "bonus_stake"},
+	{"table_players", "payout"},
+}
+
+// migrateAmountScale 将旧版按整数金币存储的金额换算为基本单位（0.01 金币），
+// 全部字段和保险配置在同一事务中换算，完成后记录换算比例，重复启动不会再次换算
+func (db *DB) migrateAmountScale() error {
+	value, ok, err := db.GetSetting(AmountScaleSettingKey)
+	if err != nil {
+		return fmt.Errorf("读取金额换算比例失败: %v", err)
+	}
+	if ok {
+		if scale, err := strconv.ParseInt(value, 10, 64); err != nil || scale != utils.AmountScale {
+			return fmt.Errorf("数据库金额换算比例 %s 与程序的 %d 不一致", value, utils.AmountScale)
+		}
+		return nil
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range amountColumns {
+		query := fmt.Sprintf(`UPDATE %s SET %s = %s * ? WHERE %s IS NOT NULL AND %s <> 0`,
+			c.table, c.column, c.column, c.column, c.column)
+		if _, err := tx.Exec(query, utils.AmountScale); err != nil {
+			return fmt.Errorf("换算 %s.%s 失败: %v", c.table, c.column, err)
+		}
+	}
+
+	// 保险配置中的投保门槛同样是金额
+	var raw string
+	err = tx.QueryRow(`SELECT value FROM bot_settings WHERE key = ?`, InsuranceSettingKey).Scan(&raw)
+	if err == nil {
+		settings := &models.InsuranceSettings{}
+		if err := json.Unmarshal([]byte(raw), settings); err != nil {
+			return fmt.Errorf("解析保险配置失败: %v", err)
+		}
+		settings.Threshold *= utils.AmountScale
+		data, err := json.Marshal(settings)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`UPDATE bot_settings SET value = ? WHERE key = ?`, string(data), InsuranceSettingKey); err != nil {
+			return fmt.Errorf("换算保险配置失败: %v", err)
+		}
+	} else if err != sql.ErrNoRows {
+		return fmt.Errorf("读取保险配置失败: %v", err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO bot_settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)`,
+		AmountScaleSettingKey, strconv.FormatInt(utils.AmountScale, 10)); err != nil {
+		return fmt.Errorf("记录金额换算比例失败: %v", err)
+	}
+	return tx.Commit()
+}
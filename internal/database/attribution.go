@@ -0,0 +1,60 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// RecordUserAttribution 记录用户首次来源（仅在尚未记录时写入，不覆盖已有归因）
+func (db *DB) RecordUserAttribution(userID, firstChatID int64, source string) error {
+	query := `UPDATE users SET first_chat_id = ?, source = ?, updated_at = ?
+			  WHERE id = ? AND COALESCE(source, '') = '' AND COALESCE(first_chat_id, 0) = 0`
+	_, err := db.conn.Exec(query, firstChatID, source, time.Now(), userID)
+	return err
+}
+
+// GetAcquisitionSources 按来源和首次接触的群组统计新增用户数
+func (db *DB) GetAcquisitionSources(limit int) ([]*models.AcquisitionSource, error) {
+	query := `SELECT COALESCE(source, ''), COALESCE(first_chat_id, 0), COUNT(*), MAX(created_at)
+			  FROM users
+			  GROUP BY COALESCE(source, ''), COALESCE(first_chat_id, 0)
+			  ORDER BY COUNT(*) DESC LIMIT ?`
+
+	rows, err := db.conn.Query(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sources []*models.AcquisitionSource
+	for rows.Next() {
+		source := &models.AcquisitionSource{}
+		var lastSignup sql.NullString
+		if err := rows.Scan(&source.Source, &source.FirstChatID, &source.UserCount, &lastSignup); err != nil {
+			return nil, err
+		}
+		source.LastSignupAt = parseSQLiteTime(lastSignup.String)
+		sources = append(sources, source)
+	}
+
+	return sources, rows.Err()
+}
+
+// parseSQLiteTime 解析聚合查询返回的时间字符串（SQLite 的聚合函数会丢失列类型；
+// PostgreSQL 返回的时间由 database/sql 格式化为 RFC3339）
+func parseSQLiteTime(value string) time.Time {
+	layouts := []string{
+		"2006-01-02 15:04:05.999999999-07:00",
+		"2006-01-02T15:04:05.999999999-07:00",
+		"2006-01-02 15:04:05",
+		time.RFC3339Nano,
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// BanUser 封禁用户，已封禁时更新原因和操作人，用户不存在时返回 false
+func (db *DB) BanUser(userID int64, reason, bannedBy string) (bool, error) {
+	now := time.Now()
+	result, err := db.conn.Exec(`UPDATE users SET banned = 1, ban_reason = ?, banned_by = ?, banned_at = ?, updated_at = ? WHERE id = ?`,
+		reason, bannedBy, now, now, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// UnbanUser 解除封禁，用户未被封禁时返回 false
+func (db *DB) UnbanUser(userID int64) (bool, error) {
+	result, err := db.conn.Exec(`UPDATE users SET banned = 0, ban_reason = '', banned_by = '', banned_at = NULL, updated_at = ?
+			  WHERE id = ? AND COALESCE(banned, 0) = 1`, time.Now(), userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// GetUserBan 获取用户的封禁记录，未被封禁或用户不存在时返回 nil
+func (db *DB) GetUserBan(userID int64) (*models.UserBan, error) {
+	bans, err := db.queryUserBans(`SELECT id, COALESCE(ban_reason, ''), COALESCE(banned_by, ''), banned_at
+			  FROM users WHERE id = ? AND COALESCE(banned, 0) = 1`, userID)
+	if err != nil || len(bans) == 0 {
+		return nil, err
+	}
+	return bans[0], nil
+}
+
+// IsUserBanned 用户是否被封禁
+func (db *DB) IsUserBanned(userID int64) (bool, error) {
+	ban, err := db.GetUserBan(userID)
+	return ban != nil, err
+}
+
+// GetBannedUsers 被封禁的用户，按封禁时间倒序
+func (db *DB) GetBannedUsers(limit int) ([]*models.UserBan, error) {
+	return db.queryUserBans(`SELECT id, COALESCE(ban_reason, ''), COALESCE(banned_by, ''), banned_at
+			  FROM users WHERE COALESCE(banned, 0) = 1 ORDER BY banned_at DESC, id DESC LIMIT ?`, limit)
+}
+
+// userStatusFilter 管理后台用户列表的状态筛选条件：banned 为已封禁，frozen 为已冻结，其余不筛选
+func userStatusFilter(status string) string {
+	switch status {
+	case "banned":
+		return ` AND COALESCE(banned, 0) = 1`
+	case "frozen":
+		return ` AND COALESCE(frozen, 0) = 1`
+	}
+	return ""
+}
+
+// queryUserBans 查询并扫描封禁记录
+func (db *DB) queryUserBans(query string, args ...interface{}) ([]*models.UserBan, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []*models.UserBan
+	for rows.Next() {
+		ban := &models.UserBan{}
+		var bannedAt sql.NullTime
+		if err := rows.Scan(&ban.UserID, &ban.Reason, &ban.BannedBy, &bannedAt); err != nil {
+			return nil, err
+		}
+		if bannedAt.Valid {
+			ban.BannedAt = bannedAt.Time
+		}
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
@@ -0,0 +1,522 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// 流水要求状态
+const (
+	BonusWageringActive    = "active"
+	BonusWageringCompleted = "completed"
+)
+
+// referralBonusCampaign 邀请奖励不属于充值赠送活动，流水要求的活动ID记为 0
+const referralBonusCampaign = 0
+
+// CreateBonusCampaign 创建充值赠送活动
+func (db *DB) CreateBonusCampaign(campaign *models.BonusCampaign) error {
+	if campaign.Percent <= 0 {
+		return fmt.Errorf("赠送比例必须大于0")
+	}
+	if !campaign.EndsAt.After(campaign.StartsAt) {
+		return fmt.Errorf("活动结束时间必须晚于开始时间")
+	}
+	if campaign.WageringMultiplier < 0 {
+		return fmt.Errorf("流水倍数不能为负数")
+	}
+
+	query := `INSERT INTO bonus_campaigns (name, percent, max_bonus, first_deposit_only,
+			  wagering_multiplier, starts_at, ends_at, active, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	campaign.CreatedAt = time.Now()
+	result, err := db.conn.Exec(query, campaign.Name, campaign.Percent, campaign.MaxBonus,
+		campaign.FirstDepositOnly, campaign.WageringMultiplier, campaign.StartsAt,
+		campaign.EndsAt, campaign.Active, campaign.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	campaign.ID, err = result.LastInsertId()
+	return err
+}
+
+// SetBonusCampaignActive 启用或停用活动
+func (db *DB) SetBonusCampaignActive(id int64, active bool) error {
+	result, err := db.conn.Exec(`UPDATE bonus_campaigns SET active = ? WHERE id = ?`, active, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("活动不存在")
+	}
+	return nil
+}
+
+// GetBonusCampaigns 获取所有活动，按创建时间倒序
+func (db *DB) GetBonusCampaigns() ([]*models.BonusCampaign, error) {
+	query := `SELECT id, name, percent, max_bonus, first_deposit_only, wagering_multiplier,
+			  starts_at, ends_at, active, created_at
+			  FROM bonus_campaigns ORDER BY created_at DESC`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var campaigns []*models.BonusCampaign
+	for rows.Next() {
+		campaign, err := scanBonusCampaign(rows)
+		if err != nil {
+			return nil, err
+		}
+		campaigns = append(campaigns, campaign)
+	}
+
+	return campaigns, rows.Err()
+}
+
+// ApplyDepositBonusInTx 在充值事务中发放赠送金额，返回赠送的游戏币数量
+// 同时有多个活动生效时取赠送金额最高的一个
+func (db *DB) ApplyDepositBonusInTx(tx *sql.Tx, userID, depositCoins int64, firstDeposit bool) (int64, error) {
+	now := time.Now()
+	rows, err := tx.Query(`SELECT id, name, percent, max_bonus, first_deposit_only, wagering_multiplier,
+			  starts_at, ends_at, active, created_at
+			  FROM bonus_campaigns WHERE active = 1 AND starts_at <= ? AND ends_at > ?`, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("查询赠送活动失败: %v", err)
+	}
+
+	var best *models.BonusCampaign
+	var bestBonus int64
+	for rows.Next() {
+		campaign, err := scanBonusCampaign(rows)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("读取赠送活动失败: %v", err)
+		}
+		if campaign.FirstDepositOnly && !firstDeposit {
+			continue
+		}
+		if bonus := CalculateDepositBonus(campaign, depositCoins); bonus > bestBonus {
+			best, bestBonus = campaign, bonus
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	if best == nil || bestBonus <= 0 {
+		return 0, nil
+	}
+
+	if err := db.creditBonusInTx(tx, userID, best.ID, bestBonus, best.WageringMultiplier,
+		models.TransactionTypeBonus, fmt.Sprintf("充值赠送 %s", best.Name)); err != nil {
+		return 0, err
+	}
+	return bestBonus, nil
+}
+
+// creditBonusInTx 向赠送余额发放奖励，并按流水倍数记录流水要求，倍数不大于 0 时直接转为现金。
+// 交易记录中的余额仍为现金余额
+func (db *DB) creditBonusInTx(tx *sql.Tx, userID, campaignID, amount int64, multiplier float64, txType, description string) error {
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return fmt.Errorf("查询用户余额失败: %v", err)
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE users SET bonus_balance = COALESCE(bonus_balance, 0) + ?, updated_at = ? WHERE id = ?`,
+		amount, now, userID); err != nil {
+		return fmt.Errorf("发放赠送金额失败: %v", err)
+	}
+
+	bonusTx := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      userID,
+		Type:        txType,
+		Amount:      amount,
+		Balance:     balance,
+		Description: description,
+	}
+	if err := db.createTransactionInTx(tx, bonusTx); err != nil {
+		return fmt.Errorf("添加赠送交易记录失败: %v", err)
+	}
+
+	required := int64(float64(amount) * multiplier)
+	status := BonusWageringActive
+	if required <= 0 {
+		status = BonusWageringCompleted
+	}
+	_, err := tx.Exec(`INSERT INTO bonus_wagering (user_id, campaign_id, bonus_amount, wagering_required, status, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`, userID, campaignID, amount, required, status, now)
+	if err != nil {
+		return fmt.Errorf("记录流水要求失败: %v", err)
+	}
+
+	// 无流水要求时直接转为现金
+	if status == BonusWageringCompleted {
+		return db.convertBonusIfClearedInTx(tx, userID)
+	}
+	return nil
+}
+
+// CalculateDepositBonus 计算单次充值可获得的赠送金额
+func CalculateDepositBonus(campaign *models.BonusCampaign, depositCoins int64) int64 {
+	bonus := int64(float64(depositCoins) * campaign.Percent / 100)
+	if campaign.MaxBonus > 0 && bonus > campaign.MaxBonus {
+		bonus = campaign.MaxBonus
+	}
+	return bonus
+}
+
+// RecordBonusWager 累计下注流水，按发放顺序依次完成流水要求
+func (db *DB) RecordBonusWager(userID, amount int64) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := db.recordBonusWagerInTx(tx, userID, amount); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// recordGameWagerInTx 对局结算后为双方累计赠送余额和邀请奖励的流水
+func (db *DB) recordGameWagerInTx(tx *sql.Tx, gameID string) error {
+	var player1ID int64
+	var player2ID sql.NullInt64
+	var betAmount int64
+	err := tx.QueryRow(`SELECT player1_id, player2_id, bet_amount FROM games WHERE id = ?`, gameID).
+		Scan(&player1ID, &player2ID, &betAmount)
+	if err != nil {
+		return fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+
+	players := []int64{player1ID}
+	if player2ID.Valid {
+		players = append(players, player2ID.Int64)
+	}
+	for _, playerID := range players {
+		if err := db.recordBonusWagerInTx(tx, playerID, betAmount); err != nil {
+			return err
+		}
+		if err := db.recordReferralWagerInTx(tx, playerID, betAmount); err != nil {
+			return fmt.Errorf("累计邀请流水失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// recordBonusWagerInTx 在事务中累计流水，全部完成后将赠送余额转为现金
+func (db *DB) recordBonusWagerInTx(tx *sql.Tx, userID, amount int64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(`SELECT id, wagering_required, wagered FROM bonus_wagering
+			  WHERE user_id = ? AND status = ? ORDER BY id`, userID, BonusWageringActive)
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		id, required, wagered int64
+	}
+	var items []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.required, &p.wagered); err != nil {
+			rows.Close()
+			return err
+		}
+		items = append(items, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, p := range items {
+		if amount <= 0 {
+			break
+		}
+		used := p.required - p.wagered
+		if used > amount {
+			used = amount
+		}
+		amount -= used
+
+		if p.wagered+used >= p.required {
+			_, err = tx.Exec(`UPDATE bonus_wagering SET wagered = wagering_required, status = ?, completed_at = ? WHERE id = ?`,
+				BonusWageringCompleted, now, p.id)
+		} else {
+			_, err = tx.Exec(`UPDATE bonus_wagering SET wagered = wagered + ? WHERE id = ?`, used, p.id)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return db.convertBonusIfClearedInTx(tx, userID)
+}
+
+// convertBonusIfClearedInTx 所有流水要求完成后，将剩余赠送余额转为现金余额
+func (db *DB) convertBonusIfClearedInTx(tx *sql.Tx, userID int64) error {
+	var active int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM bonus_wagering WHERE user_id = ? AND status = ?`,
+		userID, BonusWageringActive).Scan(&active)
+	if err != nil {
+		return err
+	}
+	if active > 0 {
+		return nil
+	}
+
+	var bonus int64
+	err = tx.QueryRow(`SELECT COALESCE(bonus_balance, 0) FROM users WHERE id = ?`, userID).Scan(&bonus)
+	if err != nil {
+		return err
+	}
+	if bonus <= 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET bonus_balance = COALESCE(bonus_balance, 0) - ? WHERE id = ?`, bonus, userID); err != nil {
+		return err
+	}
+	newBalance, err := db.creditBalanceInTx(tx, userID, bonus)
+	if err != nil {
+		return err
+	}
+
+	return db.createTransactionInTx(tx, &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      userID,
+		Type:        models.TransactionTypeBonusConvert,
+		Amount:      bonus,
+		Balance:     newBalance,
+		Description: "赠送余额完成流水，转为可提现余额",
+	})
+}
+
+// debitStakeInTx 扣除下注金额，优先使用赠送余额，返回扣款后的现金余额和占用的赠送金额
+func (db *DB) debitStakeInTx(tx *sql.Tx, userID, amount int64) (int64, int64, error) {
+	var balance, bonus int64
+	var frozen bool
+	err := tx.QueryRow(`SELECT balance, COALESCE(bonus_balance, 0), COALESCE(frozen, 0) FROM users WHERE id = ?`, userID).
+		Scan(&balance, &bonus, &frozen)
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取用户当前余额失败: %v", err)
+	}
+	if frozen {
+		return 0, 0, ErrAccountFrozen
+	}
+
+	if balance+bonus < amount {
+		return 0, 0, fmt.Errorf("余额不足，请存款后再试。当前余额: %s，需要: %s", utils.FormatAmount(balance+bonus), utils.FormatAmount(amount))
+	}
+
+	bonusUsed := amount
+	if bonusUsed > bonus {
+		bonusUsed = bonus
+	}
+	if bonusUsed > 0 {
+		result, err := tx.Exec(`UPDATE users SET bonus_balance = bonus_balance - ? WHERE id = ? AND bonus_balance >= ?`,
+			bonusUsed, userID, bonusUsed)
+		if err != nil {
+			return 0, 0, err
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return 0, 0, err
+		} else if rowsAffected == 0 {
+			return 0, 0, fmt.Errorf("余额不足，请存款后再试")
+		}
+	}
+	newBalance, err := db.debitBalanceInTx(tx, userID, amount-bonusUsed)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return newBalance, bonusUsed, nil
+}
+
+// restoreBonusStakeInTx 退款后将下注时占用的赠送金额从现金余额转回赠送余额
+// maxAmount 为本次退款金额，小于 0 表示全额退款
+func (db *DB) restoreBonusStakeInTx(tx *sql.Tx, gameID string, userID, maxAmount int64) error {
+	var player1ID int64
+	var stake1, stake2 int64
+	err := tx.QueryRow(`SELECT player1_id, COALESCE(player1_bonus_stake, 0), COALESCE(player2_bonus_stake, 0)
+			  FROM games WHERE id = ?`, gameID).Scan(&player1ID, &stake1, &stake2)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	column, stake := "player2_bonus_stake", stake2
+	if userID == player1ID {
+		column, stake = "player1_bonus_stake", stake1
+	}
+	if maxAmount >= 0 && stake > maxAmount {
+		stake = maxAmount
+	}
+	if stake <= 0 {
+		return nil
+	}
+
+	// 退款后现金余额不足占用的赠送金额时（如已被并发提现），只转回现有的现金
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return fmt.Errorf("获取用户当前余额失败: %v", err)
+	}
+	if stake > balance {
+		stake = balance
+	}
+	if stake <= 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET balance = balance - ?, bonus_balance = COALESCE(bonus_balance, 0) + ?
+			  WHERE id = ?`, stake, stake, userID); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE games SET `+column+` = `+column+` - ? WHERE id = ?`, stake, gameID)
+	return err
+}
+
+// returnBonusWinningsInTx 获胜方派奖后，将下注时由赠送余额出资的那部分奖金从现金余额转回赠送余额，
+// 返回转回的金额。获胜方已没有未完成的流水要求时奖金全部留在现金余额
+func (db *DB) returnBonusWinningsInTx(tx *sql.Tx, gameID string, winnerID, winAmount int64) (int64, error) {
+	var player1ID, betAmount, stake1, stake2 int64
+	err := tx.QueryRow(`SELECT player1_id, bet_amount, COALESCE(player1_bonus_stake, 0), COALESCE(player2_bonus_stake, 0)
+			  FROM games WHERE id = ?`, gameID).Scan(&player1ID, &betAmount, &stake1, &stake2)
+	if err != nil {
+		return 0, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+
+	stake := stake2
+	if winnerID == player1ID {
+		stake = stake1
+	}
+	if stake <= 0 || betAmount <= 0 || winAmount <= 0 {
+		return 0, nil
+	}
+
+	var active int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM bonus_wagering WHERE user_id = ? AND status = ?`,
+		winnerID, BonusWageringActive).Scan(&active); err != nil {
+		return 0, err
+	}
+	if active == 0 {
+		return 0, nil
+	}
+
+	share := winAmount * stake / betAmount
+	if share > winAmount {
+		share = winAmount
+	}
+	result, err := tx.Exec(`UPDATE users SET balance = balance - ?, bonus_balance = COALESCE(bonus_balance, 0) + ?
+			  WHERE id = ? AND balance >= ?`, share, share, winnerID, share)
+	if err != nil {
+		return 0, fmt.Errorf("奖金转回赠送余额失败: %v", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return 0, err
+	} else if rowsAffected == 0 {
+		return 0, fmt.Errorf("奖金转回赠送余额失败: 现金余额不足")
+	}
+	return share, nil
+}
+
+// winnerWinAmount 交易记录中获胜方的派奖金额
+func winnerWinAmount(transactions []*models.Transaction, winnerID int64) int64 {
+	var amount int64
+	for _, transaction := range transactions {
+		if transaction.UserID == winnerID && transaction.Type == models.TransactionTypeWin {
+			amount += transaction.Amount
+		}
+	}
+	return amount
+}
+
+// adjustWinBalance 奖金部分转回赠送余额后，修正获胜交易记录中的现金余额
+func adjustWinBalance(transactions []*models.Transaction, winnerID, share int64) {
+	if share <= 0 {
+		return
+	}
+	for _, transaction := range transactions {
+		if transaction.UserID == winnerID && transaction.Type == models.TransactionTypeWin {
+			transaction.Balance -= share
+		}
+	}
+}
+
+// GetLockedBonus 获取尚未完成流水要求、暂不可提现的赠送余额
+func (db *DB) GetLockedBonus(userID int64) (int64, error) {
+	var locked int64
+	err := db.conn.QueryRow(`SELECT COALESCE(bonus_balance, 0) FROM users WHERE id = ?`, userID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return locked, err
+}
+
+// GetWithdrawableBalance 获取可提现余额（赠送余额不可提现）
+func (db *DB) GetWithdrawableBalance(userID int64) (int64, error) {
+	user, err := db.GetUser(userID)
+	if err != nil {
+		return 0, err
+	}
+	if user == nil {
+		return 0, fmt.Errorf("用户不存在")
+	}
+	return user.Balance, nil
+}
+
+// GetBonusProgress 获取赠送余额及流水完成进度，用于 /balance 展示
+func (db *DB) GetBonusProgress(userID int64) (*models.BonusProgress, error) {
+	progress := &models.BonusProgress{}
+	err := db.conn.QueryRow(`SELECT COALESCE(bonus_balance, 0) FROM users WHERE id = ?`, userID).Scan(&progress.BonusBalance)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	err = db.conn.QueryRow(`SELECT COALESCE(SUM(wagering_required), 0), COALESCE(SUM(wagered), 0)
+			  FROM bonus_wagering WHERE user_id = ? AND status = ?`, userID, BonusWageringActive).
+		Scan(&progress.WageringRequired, &progress.Wagered)
+	if err != nil {
+		return nil, err
+	}
+	return progress, nil
+}
+
+// scanBonusCampaign 扫描活动记录
+func scanBonusCampaign(rows *sql.Rows) (*models.BonusCampaign, error) {
+	campaign := &models.BonusCampaign{}
+	err := rows.Scan(&campaign.ID, &campaign.Name, &campaign.Percent, &campaign.MaxBonus,
+		&campaign.FirstDepositOnly, &campaign.WageringMultiplier, &campaign.StartsAt,
+		&campaign.EndsAt, &campaign.Active, &campaign.CreatedAt)
+	return campaign, err
+}
@@ -0,0 +1,209 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// broadcastColumns 公告查询的列，送达和失败数按投递记录统计
+const broadcastColumns = `b.id, b.text, b.audience, b.status, b.scheduled_at, b.created_by, b.total,
+	(SELECT COUNT(*) FROM broadcast_deliveries d WHERE d.broadcast_id = b.id AND d.status = 'sent'),
+	(SELECT COUNT(*) FROM broadcast_deliveries d WHERE d.broadcast_id = b.id AND d.status = 'failed'),
+	b.created_at, b.started_at, b.finished_at`
+
+// CreateBroadcast 创建待发送的公告
+func (db *DB) CreateBroadcast(broadcast *models.Broadcast) error {
+	switch broadcast.Audience {
+	case models.BroadcastGroups, models.BroadcastUsers, models.BroadcastAll:
+	default:
+		return fmt.Errorf("无效的接收范围: %s", broadcast.Audience)
+	}
+
+	broadcast.Status = models.BroadcastScheduled
+	broadcast.CreatedAt = time.Now()
+	if broadcast.ScheduledAt.IsZero() {
+		broadcast.ScheduledAt = broadcast.CreatedAt
+	}
+	result, err := db.conn.Exec(`INSERT INTO broadcasts (text, audience, status, scheduled_at, created_by, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`,
+		broadcast.Text, broadcast.Audience, broadcast.Status, broadcast.ScheduledAt, broadcast.CreatedBy, broadcast.CreatedAt)
+	if err != nil {
+		return err
+	}
+	broadcast.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetBroadcast 获取公告及投递进度，不存在时返回 nil
+func (db *DB) GetBroadcast(id int64) (*models.Broadcast, error) {
+	broadcast, err := scanBroadcast(db.conn.QueryRow(`SELECT `+broadcastColumns+` FROM broadcasts b WHERE b.id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return broadcast, err
+}
+
+// GetBroadcasts 获取最近的公告，最新的在前
+func (db *DB) GetBroadcasts(limit int) ([]*models.Broadcast, error) {
+	return db.queryBroadcasts(`SELECT `+broadcastColumns+` FROM broadcasts b ORDER BY b.id DESC LIMIT ?`, limit)
+}
+
+// GetRunnableBroadcasts 获取需要发送的公告：已到发送时间的待发送公告，以及进程重启前未发送完的公告
+func (db *DB) GetRunnableBroadcasts(now time.Time) ([]*models.Broadcast, error) {
+	return db.queryBroadcasts(`SELECT `+broadcastColumns+` FROM broadcasts b
+			  WHERE b.status = ? OR (b.status = ? AND b.scheduled_at <= ?) ORDER BY b.id ASC`,
+		models.BroadcastSending, models.BroadcastScheduled, now)
+}
+
+// StartBroadcast 将待发送的公告改为发送中，并在同一事务中按接收范围生成投递记录；公告已不是待发送状态时返回 false。
+// 群组为未退出的群，用户为未注销、开启私信通知且未屏蔽机器人的用户
+func (db *DB) StartBroadcast(id int64) (bool, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE broadcasts SET status = ?, started_at = ? WHERE id = ? AND status = ?`,
+		models.BroadcastSending, now, id, models.BroadcastScheduled)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if rowsAffected == 0 {
+		return false, nil
+	}
+
+	var audience string
+	if err := tx.QueryRow(`SELECT audience FROM broadcasts WHERE id = ?`, id).Scan(&audience); err != nil {
+		return false, err
+	}
+	if audience == models.BroadcastGroups || audience == models.BroadcastAll {
+		if _, err := tx.Exec(`INSERT INTO broadcast_deliveries (broadcast_id, chat_id, status, updated_at)
+				  SELECT ?, id, ?, ? FROM chats WHERE id < 0 AND COALESCE(service_status, '') != ?`,
+			id, models.DeliveryPending, now, models.ChatServiceLeft); err != nil {
+			return false, err
+		}
+	}
+	if audience == models.BroadcastUsers || audience == models.BroadcastAll {
+		if _, err := tx.Exec(`INSERT INTO broadcast_deliveries (broadcast_id, chat_id, status, updated_at)
+				  SELECT ?, id, ?, ? FROM users WHERE id > 0 AND deleted_at IS NULL
+				  AND COALESCE(notifications_enabled, 1) = 1 AND COALESCE(dm_blocked, 0) = 0`,
+			id, models.DeliveryPending, now); err != nil {
+			return false, err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE broadcasts SET total = (SELECT COUNT(*) FROM broadcast_deliveries WHERE broadcast_id = ?) WHERE id = ?`,
+		id, id); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// CancelBroadcast 取消待发送或发送中的公告，尚未投递的接收方不再发送；公告已结束时返回 false
+func (db *DB) CancelBroadcast(id int64) (bool, error) {
+	result, err := db.conn.Exec(`UPDATE broadcasts SET status = ?, finished_at = ? WHERE id = ? AND status IN (?, ?)`,
+		models.BroadcastCancelled, time.Now(), id, models.BroadcastScheduled, models.BroadcastSending)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// FinishBroadcast 所有接收方均已投递，将发送中的公告标记为已完成
+func (db *DB) FinishBroadcast(id int64) error {
+	_, err := db.conn.Exec(`UPDATE broadcasts SET status = ?, finished_at = ? WHERE id = ? AND status = ?`,
+		models.BroadcastCompleted, time.Now(), id, models.BroadcastSending)
+	return err
+}
+
+// GetPendingDeliveries 获取公告尚未投递的接收方
+func (db *DB) GetPendingDeliveries(broadcastID int64, limit int) ([]int64, error) {
+	rows, err := db.conn.Query(`SELECT chat_id FROM broadcast_deliveries WHERE broadcast_id = ? AND status = ?
+			  ORDER BY chat_id ASC LIMIT ?`, broadcastID, models.DeliveryPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if err := rows.Scan(&chatID); err != nil {
+			return nil, err
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+	return chatIDs, rows.Err()
+}
+
+// SetDeliveryStatus 记录对单个接收方的投递结果
+func (db *DB) SetDeliveryStatus(broadcastID, chatID int64, status, errMsg string) error {
+	_, err := db.conn.Exec(`UPDATE broadcast_deliveries SET status = ?, error = ?, updated_at = ? WHERE broadcast_id = ? AND chat_id = ?`,
+		status, errMsg, time.Now(), broadcastID, chatID)
+	return err
+}
+
+// GetBroadcastFailures 获取公告投递失败的接收方及原因
+func (db *DB) GetBroadcastFailures(broadcastID int64, limit int) ([]*models.BroadcastDelivery, error) {
+	rows, err := db.conn.Query(`SELECT broadcast_id, chat_id, status, error, updated_at FROM broadcast_deliveries
+			  WHERE broadcast_id = ? AND status = ? ORDER BY updated_at DESC LIMIT ?`,
+		broadcastID, models.DeliveryFailed, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []*models.BroadcastDelivery
+	for rows.Next() {
+		delivery := &models.BroadcastDelivery{}
+		if err := rows.Scan(&delivery.BroadcastID, &delivery.ChatID, &delivery.Status, &delivery.Error, &delivery.UpdatedAt); err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, rows.Err()
+}
+
+func (db *DB) queryBroadcasts(query string, args ...interface{}) ([]*models.Broadcast, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var broadcasts []*models.Broadcast
+	for rows.Next() {
+		broadcast, err := scanBroadcast(rows)
+		if err != nil {
+			return nil, err
+		}
+		broadcasts = append(broadcasts, broadcast)
+	}
+	return broadcasts, rows.Err()
+}
+
+// scanBroadcast 读取一行 broadcastColumns
+func scanBroadcast(row interface{ Scan(...interface{}) error }) (*models.Broadcast, error) {
+	broadcast := &models.Broadcast{}
+	var startedAt, finishedAt sql.NullTime
+	err := row.Scan(&broadcast.ID, &broadcast.Text, &broadcast.Audience, &broadcast.Status, &broadcast.ScheduledAt,
+		&broadcast.CreatedBy, &broadcast.Total, &broadcast.Sent, &broadcast.Failed, &broadcast.CreatedAt, &startedAt, &finishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if startedAt.Valid {
+		broadcast.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		broadcast.FinishedAt = &finishedAt.Time
+	}
+	return broadcast, nil
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// AdmitChat 在容量限制内接纳群组，超出上限时加入等待队列并返回排队位置
+// maxChats <= 0 表示不限制
+func (db *DB) AdmitChat(chatID int64, maxChats int) (admitted bool, position int, err error) {
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`SELECT COALESCE(service_status, '') FROM chats WHERE id = ?`, chatID).Scan(&status)
+	if err != nil && err != sql.ErrNoRows {
+		return false, 0, err
+	}
+	if status == models.ChatServiceActive {
+		return true, 0, nil
+	}
+
+	var served int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM chats WHERE service_status = ?`, models.ChatServiceActive).Scan(&served); err != nil {
+		return false, 0, err
+	}
+
+	now := time.Now()
+	if maxChats <= 0 || served < maxChats {
+		query := `INSERT INTO chats (id, service_status, joined_at, updated_at) VALUES (?, ?, ?, ?)
+				  ON CONFLICT(id) DO UPDATE SET service_status = excluded.service_status, waitlisted_at = NULL, updated_at = excluded.updated_at`
+		if _, err := tx.Exec(query, chatID, models.ChatServiceActive, now, now); err != nil {
+			return false, 0, err
+		}
+		return true, 0, tx.Commit()
+	}
+
+	if status != models.ChatServiceWaitlist {
+		query := `INSERT INTO chats (id, service_status, waitlisted_at, joined_at, updated_at) VALUES (?, ?, ?, ?, ?)
+				  ON CONFLICT(id) DO UPDATE SET service_status = excluded.service_status, waitlisted_at = excluded.waitlisted_at, updated_at = excluded.updated_at`
+		if _, err := tx.Exec(query, chatID, models.ChatServiceWaitlist, now, now, now); err != nil {
+			return false, 0, err
+		}
+	}
+
+	query := `SELECT COUNT(*) FROM chats WHERE service_status = ?
+			  AND waitlisted_at <= (SELECT waitlisted_at FROM chats WHERE id = ?)`
+	if err := tx.QueryRow(query, models.ChatServiceWaitlist, chatID).Scan(&position); err != nil {
+		return false, 0, err
+	}
+	return false, position, tx.Commit()
+}
+
+// ReleaseChat 机器人离开群组时释放名额，返回等待队列中最早的群组ID（无则为0）
+func (db *DB) ReleaseChat(chatID int64) (int64, error) {
+	_, err := db.conn.Exec(`UPDATE chats SET service_status = ?, updated_at = ? WHERE id = ?`,
+		models.ChatServiceLeft, time.Now(), chatID)
+	if err != nil {
+		return 0, err
+	}
+
+	var nextChatID int64
+	err = db.conn.QueryRow(`SELECT id FROM chats WHERE service_status = ? ORDER BY waitlisted_at ASC LIMIT 1`,
+		models.ChatServiceWaitlist).Scan(&nextChatID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return nextChatID, err
+}
+
+// CountChatsByServiceStatus 统计各服务状态的群组数量
+func (db *DB) CountChatsByServiceStatus() (map[string]int, error) {
+	rows, err := db.conn.Query(`SELECT COALESCE(service_status, ''), COUNT(*) FROM chats GROUP BY COALESCE(service_status, '')`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, err
+		}
+		counts[status] = count
+	}
+	return counts, rows.Err()
+}
+
+// GetChatLoads 统计 since 之后各群组的对局负载，按对局数降序
+func (db *DB) GetChatLoads(since time.Time, limit int) ([]*models.ChatLoad, error) {
+	query := `SELECT g.chat_id, COALESCE(c.title, ''), COALESCE(c.service_status, ''),
+			  COUNT(*),
+			  (SELECT COUNT(*) FROM (
+			      SELECT player1_id FROM games WHERE chat_id = g.chat_id AND created_at >= ?
+			      UNION SELECT player2_id FROM games WHERE chat_id = g.chat_id AND created_at >= ? AND player2_id IS NOT NULL) players),
+			  COALESCE(SUM(g.bet_amount), 0),
+			  SUM(CASE WHEN g.status = 'playing' THEN 1 ELSE 0 END),
+			  MAX(g.created_at)
+			  FROM games g LEFT JOIN chats c ON c.id = g.chat_id
+			  WHERE g.created_at >= ?
+			  GROUP BY g.chat_id, c.title, c.service_status
+			  ORDER BY COUNT(*) DESC LIMIT ?`
+
+	rows, err := db.conn.Query(query, since, since, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var loads []*models.ChatLoad
+	for rows.Next() {
+		load := &models.ChatLoad{}
+		var lastGame sql.NullString
+		if err := rows.Scan(&load.ChatID, &load.Title, &load.ServiceStatus, &load.Games,
+			&load.Players, &load.Volume, &load.PlayingGames, &lastGame); err != nil {
+			return nil, err
+		}
+		load.LastGameAt = parseSQLiteTime(lastGame.String)
+		loads = append(loads, load)
+	}
+	return loads, rows.Err()
+}
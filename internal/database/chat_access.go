@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// ChatAccessModeSettingKey 群组准入模式在 bot_settings 中的键，覆盖配置文件中的模式
+const ChatAccessModeSettingKey = "chat_access_mode"
+
+// ValidChatAccessMode 判断群组准入模式是否有效
+func ValidChatAccessMode(mode string) bool {
+	switch mode {
+	case models.ChatAccessOpen, models.ChatAccessAllowlist, models.ChatAccessBlocklist:
+		return true
+	}
+	return false
+}
+
+// GetChatAccessMode 获取后台设置的群组准入模式，未设置时返回 ok=false
+func (db *DB) GetChatAccessMode() (string, bool, error) {
+	return db.GetSetting(ChatAccessModeSettingKey)
+}
+
+// SetChatAccessMode 设置群组准入模式
+func (db *DB) SetChatAccessMode(mode string) error {
+	if !ValidChatAccessMode(mode) {
+		return fmt.Errorf("无效的准入模式: %s", mode)
+	}
+	return db.SetSetting(ChatAccessModeSettingKey, mode)
+}
+
+// SetChatAccessEntry 将群组加入白名单或黑名单，同一群组只能在一个名单中
+func (db *DB) SetChatAccessEntry(chatID int64, list, note string) error {
+	if list != models.ChatListAllow && list != models.ChatListBlock {
+		return fmt.Errorf("无效的名单类型: %s", list)
+	}
+
+	query := `INSERT INTO chat_access_list (chat_id, list, note, created_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(chat_id) DO UPDATE SET list = excluded.list, note = excluded.note`
+	_, err := db.conn.Exec(query, chatID, list, note, time.Now())
+	return err
+}
+
+// DeleteChatAccessEntry 将群组移出白名单或黑名单
+func (db *DB) DeleteChatAccessEntry(chatID int64) error {
+	_, err := db.conn.Exec(`DELETE FROM chat_access_list WHERE chat_id = ?`, chatID)
+	return err
+}
+
+// GetChatAccessList 获取指定名单中的群组，list 为空时返回全部
+func (db *DB) GetChatAccessList(list string) ([]*models.ChatAccessEntry, error) {
+	query := `SELECT chat_id, list, COALESCE(note, ''), created_at FROM chat_access_list`
+	var args []interface{}
+	if list != "" {
+		query += ` WHERE list = ?`
+		args = append(args, list)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.ChatAccessEntry
+	for rows.Next() {
+		entry := &models.ChatAccessEntry{}
+		if err := rows.Scan(&entry.ChatID, &entry.List, &entry.Note, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetChatAccessListType 获取群组所在的名单，不在任何名单中时返回空字符串
+func (db *DB) GetChatAccessListType(chatID int64) (string, error) {
+	var list string
+	err := db.conn.QueryRow(`SELECT list FROM chat_access_list WHERE chat_id = ?`, chatID).Scan(&list)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return list, err
+}
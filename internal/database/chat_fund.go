@@ -0,0 +1,199 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// GetChatFund 获取群组基金余额和比赛奖池金额
+func (db *DB) GetChatFund(chatID int64) (balance, prizePool int64, err error) {
+	err = db.conn.QueryRow(`SELECT COALESCE(fund_balance, 0), COALESCE(prize_pool, 0) FROM chats WHERE id = ?`,
+		chatID).Scan(&balance, &prizePool)
+	if err == sql.ErrNoRows {
+		return 0, 0, nil
+	}
+	return balance, prizePool, err
+}
+
+// GetActiveChatPlayers 获取 since 之后在群组内完成过对局的玩家
+func (db *DB) GetActiveChatPlayers(chatID int64, since time.Time) ([]int64, error) {
+	query := `SELECT player1_id FROM games WHERE chat_id = ? AND status IN (?, ?) AND created_at >= ?
+			  UNION
+			  SELECT player2_id FROM games WHERE chat_id = ? AND status IN (?, ?) AND created_at >= ? AND player2_id IS NOT NULL
+			  ORDER BY 1`
+
+	rows, err := db.conn.Query(query,
+		chatID, models.GameStatusFinished, models.GameStatusSurrendered, since,
+		chatID, models.GameStatusFinished, models.GameStatusSurrendered, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var players []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		players = append(players, userID)
+	}
+	return players, rows.Err()
+}
+
+// debitChatFundInTx 从群组基金扣款，余额不足时返回错误
+func (db *DB) debitChatFundInTx(tx *sql.Tx, chatID, amount int64) error {
+	result, err := tx.Exec(`UPDATE chats SET fund_balance = fund_balance - ?, updated_at = ?
+			  WHERE id = ? AND COALESCE(fund_balance, 0) >= ?`, amount, time.Now(), chatID, amount)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("群组基金余额不足")
+	}
+	return nil
+}
+
+// AirdropChatFund 将群组基金平均分给 recipients，除不尽的零头留在基金中
+func (db *DB) AirdropChatFund(chatID, amount int64, recipients []int64) (*models.FundAirdrop, error) {
+	if amount <= 0 {
+		return nil, fmt.Errorf("空投金额必须大于 0")
+	}
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("没有可空投的玩家")
+	}
+
+	perUser := amount / int64(len(recipients))
+	if perUser <= 0 {
+		return nil, fmt.Errorf("空投金额不足以分给 %d 名玩家", len(recipients))
+	}
+	total := perUser * int64(len(recipients))
+
+	tx, err := db.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := db.debitChatFundInTx(tx, chatID, total); err != nil {
+		return nil, err
+	}
+
+	for _, userID := range recipients {
+		balance, err := db.creditBalanceInTx(tx, userID, perUser)
+		if err != nil {
+			return nil, fmt.Errorf("发放空投失败: %v", err)
+		}
+
+		transaction := &models.Transaction{
+			ID:          utils.GenerateTransactionID(),
+			UserID:      userID,
+			Type:        models.TransactionTypeFundAirdrop,
+			Amount:      perUser,
+			Balance:     balance,
+			Description: fmt.Sprintf("群组 %d 基金空投", chatID),
+		}
+		if err := db.createTransactionInTx(tx, transaction); err != nil {
+			return nil, err
+		}
+	}
+
+	var remaining int64
+	if err := tx.QueryRow(`SELECT COALESCE(fund_balance, 0) FROM chats WHERE id = ?`, chatID).Scan(&remaining); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &models.FundAirdrop{
+		ChatID:     chatID,
+		Recipients: recipients,
+		PerUser:    perUser,
+		Total:      total,
+		Remaining:  remaining,
+	}, nil
+}
+
+// MoveChatFundToPrizePool 将群组基金转入比赛奖池
+func (db *DB) MoveChatFundToPrizePool(chatID, amount int64) (int64, error) {
+	if amount <= 0 {
+		return 0, fmt.Errorf("转入金额必须大于 0")
+	}
+
+	tx, err := db.BeginTx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := db.debitChatFundInTx(tx, chatID, amount); err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`UPDATE chats SET prize_pool = COALESCE(prize_pool, 0) + ? WHERE id = ?`, amount, chatID); err != nil {
+		return 0, err
+	}
+
+	var prizePool int64
+	if err := tx.QueryRow(`SELECT prize_pool FROM chats WHERE id = ?`, chatID).Scan(&prizePool); err != nil {
+		return 0, err
+	}
+
+	transaction := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      0, // 系统账户
+		Type:        models.TransactionTypeFundPrize,
+		Amount:      amount,
+		Balance:     prizePool,
+		Description: fmt.Sprintf("群组 %d 基金转入比赛奖池", chatID),
+	}
+	if err := db.createTransactionInTx(tx, transaction); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return prizePool, nil
+}
+
+// drawPrizePoolInTx 取出群组比赛奖池的全部金额用于锦标赛奖金，奖池为空时返回 0
+func (db *DB) drawPrizePoolInTx(tx *sql.Tx, chatID int64, tournamentID string) (int64, error) {
+	var prizePool int64
+	err := tx.QueryRow(`SELECT COALESCE(prize_pool, 0) FROM chats WHERE id = ?`, chatID).Scan(&prizePool)
+	if err == sql.ErrNoRows || (err == nil && prizePool <= 0) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.Exec(`UPDATE chats SET prize_pool = COALESCE(prize_pool, 0) - ?, updated_at = ? WHERE id = ?`,
+		prizePool, time.Now(), chatID); err != nil {
+		return 0, err
+	}
+
+	transaction := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      0, // 系统账户
+		Type:        models.TransactionTypeFundPrize,
+		Amount:      -prizePool,
+		Balance:     0,
+		Description: fmt.Sprintf("群组 %d 比赛奖池发放给锦标赛 %s 冠军", chatID, tournamentID),
+	}
+	if err := db.createTransactionInTx(tx, transaction); err != nil {
+		return 0, err
+	}
+	return prizePool, nil
+}
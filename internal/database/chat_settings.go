@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// ApplyChatSettings 在同一事务中将设置应用到多个群组，未填写的字段保持不变，任一群组不存在时全部回滚
+func (db *DB) ApplyChatSettings(chatIDs []int64, settings *models.ChatSettings) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 导入的语言视为管理员手动指定，不再被自动检测覆盖
+	query := `UPDATE chats SET language = COALESCE(?, language),
+			  language_manual = CASE WHEN CAST(? AS TEXT) IS NULL THEN language_manual ELSE 1 END,
+			  surrender_enabled = COALESCE(?, surrender_enabled),
+			  jackpot_announce = COALESCE(?, jackpot_announce),
+			  revenue_share = COALESCE(?, revenue_share),
+			  feed_opt_out = COALESCE(?, feed_opt_out),
+			  sequential_games = COALESCE(?, sequential_games),
+			  max_active_games = COALESCE(?, max_active_games),
+			  game_cooldown = COALESCE(?, game_cooldown),
+			  ready_check = COALESCE(?, ready_check),
+			  updated_at = ?
+			  WHERE id = ?`
+
+	now := time.Now()
+	for _, chatID := range chatIDs {
+		result, err := tx.Exec(query, settings.Language, settings.Language, settings.SurrenderEnabled, settings.JackpotAnnounce,
+			settings.RevenueShare, settings.FeedOptOut, settings.SequentialGames, settings.MaxActiveGames,
+			settings.GameCooldown, settings.ReadyCheck, now, chatID)
+		if err != nil {
+			return fmt.Errorf("更新群组 %d 设置失败: %v", chatID, err)
+		}
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return err
+		} else if rowsAffected == 0 {
+			return fmt.Errorf("群组 %d 不存在", chatID)
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,320 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// UpsertChat 记录机器人所在的群组，已存在时更新标题和类型
+func (db *DB) UpsertChat(chat *models.Chat) error {
+	query := `INSERT INTO chats (id, title, type, language, joined_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET title = excluded.title, type = excluded.type, updated_at = excluded.updated_at`
+
+	now := time.Now()
+	if chat.JoinedAt.IsZero() {
+		chat.JoinedAt = now
+	}
+	chat.UpdatedAt = now
+
+	_, err := db.conn.Exec(query, chat.ID, chat.Title, chat.Type, chat.Language, chat.JoinedAt, chat.UpdatedAt)
+	return err
+}
+
+// GetChat 获取群组信息
+func (db *DB) GetChat(chatID int64) (*models.Chat, error) {
+	chat := &models.Chat{}
+	query := `SELECT id, COALESCE(title, ''), COALESCE(type, ''), COALESCE(language, ''), COALESCE(language_manual, 0),
+			  COALESCE(surrender_enabled, 0), COALESCE(jackpot_announce, 0), COALESCE(revenue_share, 0),
+			  COALESCE(fund_balance, 0), COALESCE(prize_pool, 0), COALESCE(feed_opt_out, 0),
+			  COALESCE(sequential_games, 0), COALESCE(max_active_games, 0), COALESCE(game_cooldown, 0), COALESCE(ready_check, 0),
+			  COALESCE(dormant, 0), joined_at, updated_at
+			  FROM chats WHERE id = ?`
+
+	err := db.conn.QueryRow(query, chatID).Scan(
+		&chat.ID, &chat.Title, &chat.Type, &chat.Language, &chat.LanguageManual,
+		&chat.SurrenderEnabled, &chat.JackpotAnnounce, &chat.RevenueShare,
+		&chat.FundBalance, &chat.PrizePool, &chat.FeedOptOut,
+		&chat.SequentialGames, &chat.MaxActiveGames, &chat.GameCooldown, &chat.ReadyCheck,
+		&chat.Dormant, &chat.JoinedAt, &chat.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return chat, err
+}
+
+// SetChatLanguage 设置群组语言，manual 为 true 时锁定语言不再自动检测
+func (db *DB) SetChatLanguage(chatID int64, language string, manual bool) error {
+	query := `INSERT INTO chats (id, language, language_manual, joined_at, updated_at) VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET language = excluded.language, language_manual = excluded.language_manual,
+			  updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, language, manual, now, now)
+	return err
+}
+
+// SetDetectedChatLanguage 写入自动检测的群组语言，管理员已手动指定时跳过，返回是否写入
+func (db *DB) SetDetectedChatLanguage(chatID int64, language string) (bool, error) {
+	query := `INSERT INTO chats (id, language, joined_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET language = excluded.language, updated_at = excluded.updated_at
+			  WHERE COALESCE(chats.language_manual, 0) = 0 AND COALESCE(chats.language, '') != excluded.language`
+	now := time.Now()
+	result, err := db.conn.Exec(query, chatID, language, now, now)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// SetChatSurrenderEnabled 开启或关闭群组的认输功能
+func (db *DB) SetChatSurrenderEnabled(chatID int64, enabled bool) error {
+	query := `INSERT INTO chats (id, surrender_enabled, joined_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET surrender_enabled = excluded.surrender_enabled, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, enabled, now, now)
+	return err
+}
+
+// IsSurrenderEnabled 群组是否开启认输功能，默认关闭
+func (db *DB) IsSurrenderEnabled(chatID int64) (bool, error) {
+	var enabled bool
+	err := db.conn.QueryRow(`SELECT COALESCE(surrender_enabled, 0) FROM chats WHERE id = ?`, chatID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return enabled, err
+}
+
+// SetChatReadyCheck 开启或关闭群组开骰前的准备确认
+func (db *DB) SetChatReadyCheck(chatID int64, enabled bool) error {
+	query := `INSERT INTO chats (id, ready_check, joined_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET ready_check = excluded.ready_check, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, enabled, now, now)
+	return err
+}
+
+// IsReadyCheckEnabled 群组是否开启开骰前的准备确认，默认关闭
+func (db *DB) IsReadyCheckEnabled(chatID int64) (bool, error) {
+	var enabled bool
+	err := db.conn.QueryRow(`SELECT COALESCE(ready_check, 0) FROM chats WHERE id = ?`, chatID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return enabled, err
+}
+
+// SetChatJackpotAnnounce 开启或关闭群组的奖池播报
+func (db *DB) SetChatJackpotAnnounce(chatID int64, enabled bool) error {
+	query := `INSERT INTO chats (id, jackpot_announce, joined_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET jackpot_announce = excluded.jackpot_announce, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, enabled, now, now)
+	return err
+}
+
+// IsJackpotAnnounceEnabled 群组是否开启奖池播报，默认关闭
+func (db *DB) IsJackpotAnnounceEnabled(chatID int64) (bool, error) {
+	var enabled bool
+	err := db.conn.QueryRow(`SELECT COALESCE(jackpot_announce, 0) FROM chats WHERE id = ?`, chatID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return enabled, err
+}
+
+// SetChatFeedOptOut 设置群组是否退出大奖频道转发
+func (db *DB) SetChatFeedOptOut(chatID int64, optOut bool) error {
+	query := `INSERT INTO chats (id, feed_opt_out, joined_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET feed_opt_out = excluded.feed_opt_out, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, optOut, now, now)
+	return err
+}
+
+// IsChatFeedOptOut 群组是否退出大奖频道转发，默认参与
+func (db *DB) IsChatFeedOptOut(chatID int64) (bool, error) {
+	var optOut bool
+	err := db.conn.QueryRow(`SELECT COALESCE(feed_opt_out, 0) FROM chats WHERE id = ?`, chatID).Scan(&optOut)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return optOut, err
+}
+
+// SetChatGameMode 设置群组对局模式：sequential 为顺序进行，否则并行且最多 maxActive 局（0 不限）
+func (db *DB) SetChatGameMode(chatID int64, sequential bool, maxActive int) error {
+	query := `INSERT INTO chats (id, sequential_games, max_active_games, joined_at, updated_at) VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET sequential_games = excluded.sequential_games,
+			  max_active_games = excluded.max_active_games, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, sequential, maxActive, now, now)
+	return err
+}
+
+// GetChatGameMode 获取群组对局模式，默认并行且不限对局数
+func (db *DB) GetChatGameMode(chatID int64) (sequential bool, maxActive int, err error) {
+	query := `SELECT COALESCE(sequential_games, 0), COALESCE(max_active_games, 0) FROM chats WHERE id = ?`
+	err = db.conn.QueryRow(query, chatID).Scan(&sequential, &maxActive)
+	if err == sql.ErrNoRows {
+		return false, 0, nil
+	}
+	return sequential, maxActive, err
+}
+
+// GetChatGameLimits 获取群组自定义的下注限额、手续费率和等待超时，群组不存在时返回空设置
+func (db *DB) GetChatGameLimits(chatID int64) (*models.ChatGameLimits, error) {
+	limits := &models.ChatGameLimits{}
+	query := `SELECT COALESCE(min_bet, 0), COALESCE(max_bet, 0), fee_rate, COALESCE(game_timeout, 0) FROM chats WHERE id = ?`
+	err := db.conn.QueryRow(query, chatID).Scan(&limits.MinBet, &limits.MaxBet, &limits.FeeRate, &limits.GameTimeout)
+	if err == sql.ErrNoRows {
+		return limits, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return limits, nil
+}
+
+// SetChatGameLimits 保存群组自定义的下注限额、手续费率和等待超时
+func (db *DB) SetChatGameLimits(chatID int64, limits *models.ChatGameLimits) error {
+	query := `INSERT INTO chats (id, min_bet, max_bet, fee_rate, game_timeout, joined_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET min_bet = excluded.min_bet, max_bet = excluded.max_bet,
+			  fee_rate = excluded.fee_rate, game_timeout = excluded.game_timeout, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, limits.MinBet, limits.MaxBet, limits.FeeRate, limits.GameTimeout, now, now)
+	return err
+}
+
+// SetChatGameCooldown 设置群组两局之间的冷却时间（秒），0 表示不限制
+func (db *DB) SetChatGameCooldown(chatID int64, seconds int) error {
+	query := `INSERT INTO chats (id, game_cooldown, joined_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET game_cooldown = excluded.game_cooldown, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, seconds, now, now)
+	return err
+}
+
+// GetChatGameCooldown 获取群组两局之间的冷却时间（秒），默认不限制
+func (db *DB) GetChatGameCooldown(chatID int64) (int, error) {
+	var seconds int
+	err := db.conn.QueryRow(`SELECT COALESCE(game_cooldown, 0) FROM chats WHERE id = ?`, chatID).Scan(&seconds)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return seconds, err
+}
+
+// GetActiveJackpotChats 获取开启奖池播报、未休眠且在 since 之后有对局的群组
+func (db *DB) GetActiveJackpotChats(since time.Time) ([]int64, error) {
+	query := `SELECT c.id FROM chats c
+			  WHERE COALESCE(c.jackpot_announce, 0) = 1 AND COALESCE(c.dormant, 0) = 0
+			  AND EXISTS (SELECT 1 FROM games g WHERE g.chat_id = c.id AND g.created_at >= ?)`
+
+	rows, err := db.conn.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if err := rows.Scan(&chatID); err != nil {
+			return nil, err
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+	return chatIDs, rows.Err()
+}
+
+// TouchChatActivity 记录群组的最近活跃时间并解除休眠，返回群组是否从休眠中恢复
+func (db *DB) TouchChatActivity(chatID int64) (bool, error) {
+	now := time.Now()
+	result, err := db.conn.Exec(`UPDATE chats SET dormant = 0, last_active_at = ?, updated_at = ?
+			  WHERE id = ? AND COALESCE(dormant, 0) = 1`, now, now, chatID)
+	if err != nil {
+		return false, err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if affected > 0 {
+		return true, nil
+	}
+
+	_, err = db.conn.Exec(`UPDATE chats SET last_active_at = ? WHERE id = ?`, now, chatID)
+	return false, err
+}
+
+// MarkDormantChats 将 before 之后既无消息也无对局的群组标记为休眠，返回新标记的群组。
+// 从未记录活跃时间的群组以入群时间为准
+func (db *DB) MarkDormantChats(before time.Time) ([]int64, error) {
+	rows, err := db.conn.Query(`SELECT c.id FROM chats c
+			  WHERE COALESCE(c.dormant, 0) = 0
+			  AND COALESCE(c.last_active_at, c.joined_at) < ?
+			  AND NOT EXISTS (SELECT 1 FROM games g WHERE g.chat_id = c.id AND g.created_at >= ?)`, before, before)
+	if err != nil {
+		return nil, err
+	}
+	var chatIDs []int64
+	for rows.Next() {
+		var chatID int64
+		if err := rows.Scan(&chatID); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		chatIDs = append(chatIDs, chatID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 查询之后刚有新消息的群组不再标记
+	now := time.Now()
+	var marked []int64
+	for _, chatID := range chatIDs {
+		result, err := db.conn.Exec(`UPDATE chats SET dormant = 1, updated_at = ?
+				  WHERE id = ? AND COALESCE(dormant, 0) = 0 AND COALESCE(last_active_at, joined_at) < ?`, now, chatID, before)
+		if err != nil {
+			return marked, err
+		}
+		if affected, _ := result.RowsAffected(); affected > 0 {
+			marked = append(marked, chatID)
+		}
+	}
+	return marked, nil
+}
+
+// GetSetting 获取全局配置项，不存在时返回 ok=false
+func (db *DB) GetSetting(key string) (string, bool, error) {
+	var value string
+	err := db.conn.QueryRow(`SELECT value FROM bot_settings WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+// SetSetting 写入全局配置项
+func (db *DB) SetSetting(key, value string) error {
+	query := `INSERT INTO bot_settings (key, value, updated_at) VALUES (?, ?, ?)
+			  ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at`
+	_, err := db.conn.Exec(query, key, value, time.Now())
+	return err
+}
+
+// DeleteSetting 删除全局配置项，恢复默认值
+func (db *DB) DeleteSetting(key string) error {
+	_, err := db.conn.Exec(`DELETE FROM bot_settings WHERE key = ?`, key)
+	return err
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// ErrDailyAlreadyClaimed 冷却时间内已经签到过
+var ErrDailyAlreadyClaimed = errors.New("今天已经签到过了")
+
+// GetLastDailyClaim 获取用户最近一次签到，从未签到时返回 nil
+func (db *DB) GetLastDailyClaim(userID int64) (*models.DailyClaim, error) {
+	claim := &models.DailyClaim{}
+	err := db.conn.QueryRow(`SELECT id, user_id, amount, streak, claimed_at FROM daily_claims
+			  WHERE user_id = ? ORDER BY claimed_at DESC, id DESC LIMIT 1`, userID).
+		Scan(&claim.ID, &claim.UserID, &claim.Amount, &claim.Streak, &claim.ClaimedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// ClaimDailyBonus 发放签到金币并记录签到，返回发放后的余额。
+// 事务内再次检查 cooldown 内是否已签到，多个进程共用数据库时同样只能领取一次
+func (db *DB) ClaimDailyBonus(claim *models.DailyClaim, cooldown time.Duration) (int64, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var recent int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM daily_claims WHERE user_id = ? AND claimed_at > ?`,
+		claim.UserID, claim.ClaimedAt.Add(-cooldown)).Scan(&recent); err != nil {
+		return 0, fmt.Errorf("检查签到记录失败: %v", err)
+	}
+	if recent > 0 {
+		return 0, ErrDailyAlreadyClaimed
+	}
+
+	newBalance, err := db.creditBalanceInTx(tx, claim.UserID, claim.Amount)
+	if err != nil {
+		return 0, err
+	}
+
+	transaction := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      claim.UserID,
+		Type:        models.TransactionTypeDailyBonus,
+		Amount:      claim.Amount,
+		Balance:     newBalance,
+		Description: fmt.Sprintf("每日签到（连续 %d 天）", claim.Streak),
+	}
+	if err := db.createTransactionInTx(tx, transaction); err != nil {
+		return 0, err
+	}
+
+	result, err := tx.Exec(`INSERT INTO daily_claims (user_id, amount, streak, claimed_at) VALUES (?, ?, ?, ?)`,
+		claim.UserID, claim.Amount, claim.Streak, claim.ClaimedAt)
+	if err != nil {
+		return 0, fmt.Errorf("记录签到失败: %v", err)
+	}
+	if claim.ID, err = result.LastInsertId(); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return newBalance, nil
+}
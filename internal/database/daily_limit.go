@@ -0,0 +1,41 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// CountUserGamesSince 统计用户 since 之后发起或加入的对局数，包括快速桌；已取消和超时退款的对局不计入
+func (db *DB) CountUserGamesSince(userID int64, since time.Time) (int, error) {
+	query := `SELECT
+			  (SELECT COUNT(*) FROM games
+			   WHERE (player1_id = ? OR player2_id = ?) AND created_at >= ? AND status NOT IN (?, ?))
+			  +
+			  (SELECT COUNT(*) FROM table_players p JOIN game_tables t ON t.id = p.table_id
+			   WHERE p.user_id = ? AND p.joined_at >= ? AND t.status <> ?)`
+
+	var count int
+	err := db.conn.QueryRow(query,
+		userID, userID, since, models.GameStatusCancelled, models.GameStatusExpired,
+		userID, since, models.TableStatusCancelled).Scan(&count)
+	return count, err
+}
+
+// SetUserDailyLimitExempt 设置用户是否不受每日对局数上限限制
+func (db *DB) SetUserDailyLimitExempt(userID int64, exempt bool) error {
+	_, err := db.conn.Exec(`UPDATE users SET daily_limit_exempt = ?, updated_at = ? WHERE id = ?`,
+		exempt, time.Now(), userID)
+	return err
+}
+
+// IsUserDailyLimitExempt 用户是否不受每日对局数上限限制，用户不存在时返回 false
+func (db *DB) IsUserDailyLimitExempt(userID int64) (bool, error) {
+	var exempt bool
+	err := db.conn.QueryRow(`SELECT COALESCE(daily_limit_exempt, 0) FROM users WHERE id = ?`, userID).Scan(&exempt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return exempt, err
+}
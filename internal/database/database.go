@@ -2,6 +2,7 @@ package database
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -12,39 +13,83 @@ import (
 )
 
 type DB struct {
-	conn *sql.DB
+	conn    *sql.DB
+	dialect Dialect
 }
 
+// Init 按 DATABASE_URL 的协议前缀连接 SQLite 或 PostgreSQL，并建表、迁移
 func Init(databaseURL string) (*DB, error) {
-	// 优化SQLite连接参数
-	conn, err := sql.Open("sqlite3", databaseURL+"?cache=shared&mode=rwc&_journal_mode=WAL&_synchronous=NORMAL&_cache_size=10000&_temp_store=memory")
+	dialect, dsn := ParseDatabaseURL(databaseURL)
+
+	var conn *sql.DB
+	var err error
+	if dialect == DialectPostgres {
+		conn, err = sql.Open(postgresDriverName, dsn)
+	} else {
+		// 优化SQLite连接参数
+		conn, err = sql.Open("sqlite3", dsn+"?cache=shared&mode=rwc&_journal_mode=WAL&_synchronous=NORMAL&_cache_size=10000&_temp_store=memory")
+	}
 	if err != nil {
 		return nil, err
 	}
+	if dialect == DialectPostgres {
+		if err := conn.Ping(); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("连接 PostgreSQL 失败: %v", err)
+		}
+	}
 
 	// 设置连接池参数
 	conn.SetMaxOpenConns(25)
 	conn.SetMaxIdleConns(25)
 	conn.SetConnMaxLifetime(5 * time.Minute)
 
-	db := &DB{conn: conn}
+	db := &DB{conn: conn, dialect: dialect}
 
 	if err := db.createTables(); err != nil {
 		return nil, err
 	}
 
+	// 为已有数据库补充新增字段
+	if err := db.migrate(); err != nil {
+		return nil, err
+	}
+
+	// 金额改为以 0.01 金币为基本单位存储
+	if err := db.migrateAmountScale(); err != nil {
+		return nil, err
+	}
+
 	// 创建索引以提升查询性能
 	if err := db.createIndexes(); err != nil {
 		return nil, err
 	}
 
+	// 对局状态只能沿状态机允许的方向转换
+	if err := db.createGameStateTriggers(); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
 
+// Dialect 当前连接的数据库方言
+func (db *DB) Dialect() Dialect {
+	return db.dialect
+}
+
 func (db *DB) Close() error {
 	return db.conn.Close()
 }
 
+// Ping 执行一次最简单的查询检查数据库是否可用，返回耗时
+func (db *DB) Ping() (time.Duration, error) {
+	start := time.Now()
+	var one int
+	err := db.conn.QueryRow("SELECT 1").Scan(&one)
+	return time.Since(start), err
+}
+
 func (db *DB) createTables() error {
 	queries := []string{
 		`CREATE TABLE IF NOT EXISTS users (
@@ -89,6 +134,314 @@ func (db *DB) createTables() error {
 			FOREIGN KEY (user_id) REFERENCES users(id),
 			FOREIGN KEY (game_id) REFERENCES games(id)
 		)`,
+		`CREATE TABLE IF NOT EXISTS chats (
+			id INTEGER PRIMARY KEY,
+			title TEXT,
+			type TEXT,
+			language TEXT DEFAULT '',
+			joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS bot_settings (
+			key TEXT PRIMARY KEY,
+			value TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS media_files (
+			bot_id INTEGER NOT NULL,
+			name TEXT NOT NULL,
+			file_id TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (bot_id, name)
+		)`,
+		`CREATE TABLE IF NOT EXISTS bonus_campaigns (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL,
+			percent REAL NOT NULL,
+			max_bonus INTEGER DEFAULT 0,
+			first_deposit_only INTEGER DEFAULT 0,
+			wagering_multiplier REAL DEFAULT 1,
+			starts_at DATETIME NOT NULL,
+			ends_at DATETIME NOT NULL,
+			active INTEGER DEFAULT 1,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS bonus_wagering (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			campaign_id INTEGER NOT NULL,
+			bonus_amount INTEGER NOT NULL,
+			wagering_required INTEGER NOT NULL,
+			wagered INTEGER DEFAULT 0,
+			status TEXT DEFAULT 'active',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			completed_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS shard_nodes (
+			instance_id TEXT PRIMARY KEY,
+			shard_id INTEGER NOT NULL,
+			shard_count INTEGER NOT NULL,
+			started_at DATETIME NOT NULL,
+			heartbeat_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS admin_actions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			admin TEXT NOT NULL,
+			action TEXT NOT NULL,
+			target_type TEXT NOT NULL,
+			target_id TEXT NOT NULL,
+			details TEXT DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS username_history (
+			user_id INTEGER NOT NULL,
+			username TEXT NOT NULL,
+			first_seen DATETIME NOT NULL,
+			last_seen DATETIME NOT NULL,
+			PRIMARY KEY (user_id, username)
+		)`,
+		`CREATE TABLE IF NOT EXISTS distributed_locks (
+			name TEXT PRIMARY KEY,
+			owner TEXT NOT NULL,
+			expires_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS chat_access_list (
+			chat_id INTEGER PRIMARY KEY,
+			list TEXT NOT NULL,
+			note TEXT DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS dead_letters (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL DEFAULT '',
+			payload TEXT NOT NULL DEFAULT '',
+			priority INTEGER NOT NULL DEFAULT 0,
+			error TEXT NOT NULL DEFAULT '',
+			attempts INTEGER NOT NULL DEFAULT 1,
+			status TEXT NOT NULL DEFAULT 'pending',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS game_tables (
+			id TEXT PRIMARY KEY,
+			chat_id INTEGER NOT NULL,
+			creator_id INTEGER NOT NULL,
+			ante INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open',
+			commission INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS table_players (
+			table_id TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			bonus_stake INTEGER NOT NULL DEFAULT 0,
+			dice1 INTEGER NOT NULL DEFAULT 0,
+			dice2 INTEGER NOT NULL DEFAULT 0,
+			dice3 INTEGER NOT NULL DEFAULT 0,
+			rank INTEGER NOT NULL DEFAULT 0,
+			payout INTEGER NOT NULL DEFAULT 0,
+			joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (table_id, user_id),
+			FOREIGN KEY (table_id) REFERENCES game_tables(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS uptime_sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			instance TEXT NOT NULL DEFAULT '',
+			started_at DATETIME NOT NULL,
+			last_seen_at DATETIME NOT NULL,
+			stopped_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS uptime_gaps (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			started_at DATETIME NOT NULL,
+			ended_at DATETIME NOT NULL,
+			reason TEXT NOT NULL DEFAULT ''
+		)`,
+		`CREATE TABLE IF NOT EXISTS daily_claims (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			amount INTEGER NOT NULL,
+			streak INTEGER NOT NULL DEFAULT 1,
+			claimed_at DATETIME NOT NULL,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS withdrawals (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			amount INTEGER NOT NULL,
+			usdt_amount REAL NOT NULL,
+			address TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			reviewer TEXT NOT NULL DEFAULT '',
+			tx_hash TEXT NOT NULL DEFAULT '',
+			reason TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			reviewed_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS game_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			game_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			user_id INTEGER NOT NULL DEFAULT 0,
+			chat_id INTEGER NOT NULL DEFAULT 0,
+			message_id INTEGER NOT NULL DEFAULT 0,
+			detail TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS outbox_messages (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			chat_id INTEGER NOT NULL,
+			game_id TEXT NOT NULL DEFAULT '',
+			text TEXT NOT NULL,
+			parse_mode TEXT NOT NULL DEFAULT '',
+			reply_markup TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'pending',
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			next_attempt DATETIME NOT NULL,
+			created_at DATETIME NOT NULL,
+			sent_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS broadcasts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			text TEXT NOT NULL,
+			audience TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'scheduled',
+			scheduled_at DATETIME NOT NULL,
+			created_by TEXT NOT NULL DEFAULT '',
+			total INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			started_at DATETIME,
+			finished_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS broadcast_deliveries (
+			broadcast_id INTEGER NOT NULL,
+			chat_id INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'pending',
+			error TEXT NOT NULL DEFAULT '',
+			updated_at DATETIME NOT NULL,
+			PRIMARY KEY (broadcast_id, chat_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS chat_members (
+			chat_id INTEGER NOT NULL,
+			user_id INTEGER NOT NULL,
+			first_seen DATETIME NOT NULL,
+			PRIMARY KEY (chat_id, user_id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS referrals (
+			referee_id INTEGER PRIMARY KEY,
+			referrer_id INTEGER NOT NULL,
+			wagered INTEGER NOT NULL DEFAULT 0,
+			wager_threshold INTEGER NOT NULL DEFAULT 0,
+			referrer_wager_bonus INTEGER NOT NULL DEFAULT 0,
+			referee_wager_bonus INTEGER NOT NULL DEFAULT 0,
+			referrer_earned INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME NOT NULL,
+			wager_rewarded_at DATETIME
+		)`,
+		`CREATE TABLE IF NOT EXISTS reconcile_issues (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			expected INTEGER NOT NULL,
+			actual INTEGER NOT NULL,
+			difference INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open',
+			frozen INTEGER NOT NULL DEFAULT 0,
+			reviewer TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			reviewed_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS risk_events (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id INTEGER NOT NULL,
+			counterparty_id INTEGER NOT NULL DEFAULT 0,
+			rule TEXT NOT NULL,
+			game_id TEXT NOT NULL DEFAULT '',
+			detail TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'open',
+			frozen INTEGER NOT NULL DEFAULT 0,
+			reviewer TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			reviewed_at DATETIME,
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS tournaments (
+			id TEXT PRIMARY KEY,
+			chat_id INTEGER NOT NULL,
+			creator_id INTEGER NOT NULL,
+			entry_fee INTEGER NOT NULL,
+			max_players INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open',
+			round INTEGER NOT NULL DEFAULT 0,
+			winner_id INTEGER,
+			prize INTEGER NOT NULL DEFAULT 0,
+			commission INTEGER NOT NULL DEFAULT 0,
+			starts_at DATETIME NOT NULL,
+			next_round_at DATETIME NOT NULL,
+			created_at DATETIME NOT NULL,
+			updated_at DATETIME NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS tournament_entries (
+			tournament_id TEXT NOT NULL,
+			user_id INTEGER NOT NULL,
+			bonus_stake INTEGER NOT NULL DEFAULT 0,
+			seed INTEGER NOT NULL DEFAULT 0,
+			eliminated_round INTEGER NOT NULL DEFAULT 0,
+			joined_at DATETIME NOT NULL,
+			PRIMARY KEY (tournament_id, user_id),
+			FOREIGN KEY (tournament_id) REFERENCES tournaments(id),
+			FOREIGN KEY (user_id) REFERENCES users(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS tournament_matches (
+			tournament_id TEXT NOT NULL,
+			round INTEGER NOT NULL,
+			slot INTEGER NOT NULL,
+			player1_id INTEGER NOT NULL,
+			player2_id INTEGER NOT NULL DEFAULT 0,
+			player1_dice1 INTEGER NOT NULL DEFAULT 0,
+			player1_dice2 INTEGER NOT NULL DEFAULT 0,
+			player1_dice3 INTEGER NOT NULL DEFAULT 0,
+			player2_dice1 INTEGER NOT NULL DEFAULT 0,
+			player2_dice2 INTEGER NOT NULL DEFAULT 0,
+			player2_dice3 INTEGER NOT NULL DEFAULT 0,
+			rerolls INTEGER NOT NULL DEFAULT 0,
+			winner_id INTEGER NOT NULL,
+			played_at DATETIME NOT NULL,
+			PRIMARY KEY (tournament_id, round, slot),
+			FOREIGN KEY (tournament_id) REFERENCES tournaments(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS disputes (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			game_id TEXT NOT NULL UNIQUE,
+			chat_id INTEGER NOT NULL,
+			reporter_id INTEGER NOT NULL,
+			winner_id INTEGER NOT NULL,
+			loser_id INTEGER NOT NULL,
+			bet_amount INTEGER NOT NULL,
+			payout INTEGER NOT NULL,
+			held INTEGER NOT NULL,
+			status TEXT NOT NULL DEFAULT 'open',
+			reviewer TEXT NOT NULL DEFAULT '',
+			note TEXT NOT NULL DEFAULT '',
+			created_at DATETIME NOT NULL,
+			resolved_at DATETIME,
+			FOREIGN KEY (game_id) REFERENCES games(id)
+		)`,
+		`CREATE TABLE IF NOT EXISTS update_queue (
+			update_id INTEGER PRIMARY KEY,
+			chat_id INTEGER NOT NULL DEFAULT 0,
+			payload TEXT NOT NULL,
+			claimed_by TEXT,
+			claimed_at DATETIME,
+			created_at DATETIME NOT NULL
+		)`,
 	}
 
 	for _, query := range queries {
@@ -100,6 +453,104 @@ func (db *DB) createTables() error {
 	return nil
 }
 
+// migrate 为旧版本数据库补充后续新增的字段，已存在的字段会被跳过
+func (db *DB) migrate() error {
+	migrations := []string{
+		// 用户来源归因
+		`ALTER TABLE users ADD COLUMN first_chat_id INTEGER DEFAULT 0`,
+		`ALTER TABLE users ADD COLUMN source TEXT DEFAULT ''`,
+		// 群组认输开关
+		`ALTER TABLE chats ADD COLUMN surrender_enabled INTEGER DEFAULT 0`,
+		// 赠送余额与下注时占用的赠送金额
+		`ALTER TABLE users ADD COLUMN bonus_balance INTEGER DEFAULT 0`,
+		`ALTER TABLE games ADD COLUMN player1_bonus_stake INTEGER DEFAULT 0`,
+		`ALTER TABLE games ADD COLUMN player2_bonus_stake INTEGER DEFAULT 0`,
+		// 群组奖池播报开关
+		`ALTER TABLE chats ADD COLUMN jackpot_announce INTEGER DEFAULT 0`,
+		// 容量限制模式下的群组服务状态
+		`ALTER TABLE chats ADD COLUMN service_status TEXT DEFAULT ''`,
+		`ALTER TABLE chats ADD COLUMN waitlisted_at DATETIME`,
+		// 群组手续费分成
+		`ALTER TABLE chats ADD COLUMN revenue_share REAL DEFAULT 0`,
+		`ALTER TABLE games ADD COLUMN chat_share INTEGER DEFAULT 0`,
+		// 群组基金余额及转入的比赛奖池
+		`ALTER TABLE chats ADD COLUMN fund_balance INTEGER DEFAULT 0`,
+		`ALTER TABLE chats ADD COLUMN prize_pool INTEGER DEFAULT 0`,
+		// 用户私信通知偏好，以及机器人是否被用户屏蔽
+		`ALTER TABLE users ADD COLUMN notifications_enabled INTEGER DEFAULT 1`,
+		`ALTER TABLE users ADD COLUMN dm_blocked INTEGER DEFAULT 0`,
+		// 下注保险
+		`ALTER TABLE games ADD COLUMN insurance_premium INTEGER DEFAULT 0`,
+		`ALTER TABLE games ADD COLUMN insurance_coverage INTEGER DEFAULT 0`,
+		// 群组退出大奖频道转发
+		`ALTER TABLE chats ADD COLUMN feed_opt_out INTEGER DEFAULT 0`,
+		// 用户在排行榜等公开场合匿名显示
+		`ALTER TABLE users ADD COLUMN anonymous INTEGER DEFAULT 0`,
+		// 用户开启跨群连胜播报
+		`ALTER TABLE users ADD COLUMN celebrate_streaks INTEGER DEFAULT 0`,
+		// 群组语言由管理员手动指定
+		`ALTER TABLE chats ADD COLUMN language_manual INTEGER DEFAULT 0`,
+		// 群组对局模式：顺序进行或并行（可限制同时进行的对局数）
+		`ALTER TABLE chats ADD COLUMN sequential_games INTEGER DEFAULT 0`,
+		`ALTER TABLE chats ADD COLUMN max_active_games INTEGER DEFAULT 0`,
+		// 群组两局之间的冷却时间
+		`ALTER TABLE chats ADD COLUMN game_cooldown INTEGER DEFAULT 0`,
+		// 群组最近活跃时间，长期无活动的群组标记为休眠
+		`ALTER TABLE chats ADD COLUMN last_active_at DATETIME`,
+		`ALTER TABLE chats ADD COLUMN dormant INTEGER DEFAULT 0`,
+		// 群组自定义的下注限额、手续费率和等待超时，0 或 NULL 表示沿用全局配置
+		`ALTER TABLE chats ADD COLUMN min_bet INTEGER DEFAULT 0`,
+		`ALTER TABLE chats ADD COLUMN max_bet INTEGER DEFAULT 0`,
+		`ALTER TABLE chats ADD COLUMN fee_rate REAL`,
+		`ALTER TABLE chats ADD COLUMN game_timeout INTEGER DEFAULT 0`,
+		// 快速桌玩法：split 前两名瓜分奖池，battle 多人大战最高点数者独得；座位数为 0 时使用默认座位数
+		`ALTER TABLE game_tables ADD COLUMN mode TEXT NOT NULL DEFAULT 'split'`,
+		`ALTER TABLE game_tables ADD COLUMN max_players INTEGER NOT NULL DEFAULT 0`,
+		// 群组每小时下注上限：0 沿用全局配置，负数表示本群不限制
+		`ALTER TABLE chats ADD COLUMN hourly_cap INTEGER DEFAULT 0`,
+		// 用户 Telegram 账号注销的时间
+		`ALTER TABLE users ADD COLUMN deleted_at DATETIME`,
+		// 可验证公平：对局的服务端种子及开局前公布的承诺哈希
+		`ALTER TABLE games ADD COLUMN server_seed TEXT DEFAULT ''`,
+		`ALTER TABLE games ADD COLUMN seed_hash TEXT DEFAULT ''`,
+		// 对账发现余额差异后冻结账户，管理员核对后解冻
+		`ALTER TABLE users ADD COLUMN frozen INTEGER DEFAULT 0`,
+		// 群组开启开骰前的准备确认
+		`ALTER TABLE chats ADD COLUMN ready_check INTEGER DEFAULT 0`,
+		// 用户订阅每周战报私信，记录订阅时的客户端语言和最近一次战报覆盖的周
+		`ALTER TABLE users ADD COLUMN weekly_summary INTEGER DEFAULT 0`,
+		`ALTER TABLE users ADD COLUMN language TEXT DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN weekly_summary_at DATETIME`,
+		// 群组下注资格：账号估算注册天数和在本群的天数下限，0 表示不限制
+		`ALTER TABLE chats ADD COLUMN min_account_days INTEGER DEFAULT 0`,
+		`ALTER TABLE chats ADD COLUMN min_member_days INTEGER DEFAULT 0`,
+		// 管理员豁免的用户不受每日对局数上限限制
+		`ALTER TABLE users ADD COLUMN daily_limit_exempt INTEGER DEFAULT 0`,
+		// 管理员封禁用户：被封禁的用户不能使用机器人和参与对局，记录原因、操作人和时间
+		`ALTER TABLE users ADD COLUMN banned INTEGER DEFAULT 0`,
+		`ALTER TABLE users ADD COLUMN ban_reason TEXT DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN banned_by TEXT DEFAULT ''`,
+		`ALTER TABLE users ADD COLUMN banned_at DATETIME`,
+		// 邀请奖励计入赠送余额，流水奖励按邀请时的流水倍数设置流水要求
+		`ALTER TABLE referrals ADD COLUMN wagering_multiplier REAL DEFAULT 1`,
+		// 快速桌的入座顺序，同一时间入座的玩家也能按先后排列
+		`ALTER TABLE table_players ADD COLUMN seat INTEGER NOT NULL DEFAULT 0`,
+		// 锦标赛奖金中来自群组比赛奖池的部分
+		`ALTER TABLE tournaments ADD COLUMN fund_prize INTEGER DEFAULT 0`,
+	}
+
+	for _, migration := range migrations {
+		if _, err := db.conn.Exec(migration); err != nil {
+			if strings.Contains(err.Error(), "duplicate column name") {
+				continue
+			}
+			return fmt.Errorf("数据库迁移失败: %v", err)
+		}
+	}
+
+	return nil
+}
+
 // 创建索引以提升查询性能
 func (db *DB) createIndexes() error {
 	indexes := []string{
@@ -110,7 +561,31 @@ func (db *DB) createIndexes() error {
 		`CREATE INDEX IF NOT EXISTS idx_transactions_user ON transactions(user_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_transactions_game ON transactions(game_id)`,
 		`CREATE INDEX IF NOT EXISTS idx_transactions_type ON transactions(type)`,
+		`CREATE INDEX IF NOT EXISTS idx_transactions_created ON transactions(created_at, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_transactions_user_created ON transactions(user_id, created_at)`,
 		`CREATE INDEX IF NOT EXISTS idx_users_username ON users(username)`,
+		`CREATE INDEX IF NOT EXISTS idx_users_username_nocase ON users(username COLLATE NOCASE)`,
+		`CREATE INDEX IF NOT EXISTS idx_username_history_name ON username_history(username COLLATE NOCASE)`,
+		`CREATE INDEX IF NOT EXISTS idx_users_source ON users(source)`,
+		`CREATE INDEX IF NOT EXISTS idx_bonus_wagering_user ON bonus_wagering(user_id, status)`,
+		`CREATE INDEX IF NOT EXISTS idx_chats_service_status ON chats(service_status)`,
+		`CREATE INDEX IF NOT EXISTS idx_admin_actions_target ON admin_actions(target_type, target_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_uptime_sessions_started ON uptime_sessions(started_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_uptime_gaps_started ON uptime_gaps(started_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_daily_claims_user ON daily_claims(user_id, claimed_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_game_events_game ON game_events(game_id, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_withdrawals_status ON withdrawals(status, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_withdrawals_user ON withdrawals(user_id, created_at)`,
+		// 同一用户同时只能有一笔待审核的提现，并发提交时由唯一索引兜底
+		`CREATE UNIQUE INDEX IF NOT EXISTS idx_withdrawals_user_pending ON withdrawals(user_id) WHERE status = 'pending'`,
+		`CREATE INDEX IF NOT EXISTS idx_reconcile_issues_user ON reconcile_issues(user_id, status)`,
+		`CREATE INDEX IF NOT EXISTS idx_disputes_status ON disputes(status, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_risk_events_user ON risk_events(user_id, status)`,
+		`CREATE INDEX IF NOT EXISTS idx_tournaments_status ON tournaments(status, chat_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_outbox_messages_status ON outbox_messages(status, chat_id, id)`,
+		`CREATE INDEX IF NOT EXISTS idx_broadcasts_status ON broadcasts(status, scheduled_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals(referrer_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_update_queue_claim ON update_queue(claimed_by, update_id)`,
 	}
 
 	for _, index := range indexes {
@@ -125,12 +600,12 @@ func (db *DB) createIndexes() error {
 // User operations
 func (db *DB) GetUser(userID int64) (*models.User, error) {
 	user := &models.User{}
-	query := `SELECT id, username, first_name, last_name, balance, created_at, updated_at 
+	query := `SELECT id, username, first_name, last_name, balance, COALESCE(bonus_balance, 0), first_chat_id, source, created_at, updated_at, deleted_at 
 			  FROM users WHERE id = ?`
 
 	err := db.conn.QueryRow(query, userID).Scan(
 		&user.ID, &user.Username, &user.FirstName, &user.LastName,
-		&user.Balance, &user.CreatedAt, &user.UpdatedAt,
+		&user.Balance, &user.BonusBalance, &user.FirstChatID, &user.Source, &user.CreatedAt, &user.UpdatedAt, &user.DeletedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -141,19 +616,49 @@ func (db *DB) GetUser(userID int64) (*models.User, error) {
 }
 
 func (db *DB) CreateUser(user *models.User) error {
-	query := `INSERT INTO users (id, username, first_name, last_name, balance, created_at, updated_at)
-			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO users (id, username, first_name, last_name, balance, first_chat_id, source, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	now := time.Now()
 	user.CreatedAt = now
 	user.UpdatedAt = now
 
 	_, err := db.conn.Exec(query, user.ID, user.Username, user.FirstName,
-		user.LastName, user.Balance, user.CreatedAt, user.UpdatedAt)
+		user.LastName, user.Balance, user.FirstChatID, user.Source, user.CreatedAt, user.UpdatedAt)
 
 	return err
 }
 
+// EnsureUser 用户不存在时创建，已存在时保留原有数据，并用数据库中的记录填充 user；
+// 同一新用户的并发请求只会有一个创建成功，其余读取已创建的记录而不会因主键冲突失败。
+// 返回是否为本次新建
+func (db *DB) EnsureUser(user *models.User) (bool, error) {
+	query := `INSERT INTO users (id, username, first_name, last_name, balance, first_chat_id, source, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO NOTHING`
+
+	now := time.Now()
+	result, err := db.conn.Exec(query, user.ID, user.Username, user.FirstName,
+		user.LastName, user.Balance, user.FirstChatID, user.Source, now, now)
+	if err != nil {
+		return false, err
+	}
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	stored, err := db.GetUser(user.ID)
+	if err != nil {
+		return false, err
+	}
+	if stored == nil {
+		return false, fmt.Errorf("用户 %d 创建后不存在", user.ID)
+	}
+	*user = *stored
+	return created > 0, nil
+}
+
 // Transaction support methods
 func (db *DB) BeginTx() (*sql.Tx, error) {
 	return db.conn.Begin()
@@ -167,35 +672,22 @@ func (db *DB) CreateGameWithTransaction(game *models.Game, userID int64, newBala
 	}
 	defer tx.Rollback()
 
-	// 1. 再次验证用户当前余额（防止并发问题）
-	var currentBalance int64
-	query := `SELECT balance FROM users WHERE id = ?`
-	err = tx.QueryRow(query, userID).Scan(&currentBalance)
+	// 1. 再次验证用户当前余额并扣款（优先使用赠送余额，防止并发问题）
+	calculatedNewBalance, bonusStake, err := db.debitStakeInTx(tx, userID, game.BetAmount)
 	if err != nil {
-		return fmt.Errorf("获取用户当前余额失败: %v", err)
-	}
-
-	// 验证余额是否足够
-	if currentBalance < game.BetAmount {
-		return fmt.Errorf("余额不足，请存款后再试。当前余额: %d，需要: %d", currentBalance, game.BetAmount)
-	}
-
-	// 重新计算新余额
-	calculatedNewBalance := currentBalance - game.BetAmount
-	if calculatedNewBalance < 0 {
-		return fmt.Errorf("余额不足，请存款后再试")
+		return err
 	}
 
 	// 更新交易记录中的余额
 	transaction.Balance = calculatedNewBalance
 
-	// 2. 扣除用户余额
-	if err := db.updateUserBalanceInTx(tx, userID, calculatedNewBalance); err != nil {
+	// 2. 创建游戏
+	if err := db.createGameInTx(tx, game); err != nil {
 		return err
 	}
 
-	// 3. 创建游戏
-	if err := db.createGameInTx(tx, game); err != nil {
+	// 3. 记录占用的赠送金额，退款时原路退回
+	if _, err := tx.Exec(`UPDATE games SET player1_bonus_stake = ? WHERE id = ?`, bonusStake, game.ID); err != nil {
 		return err
 	}
 
@@ -207,51 +699,37 @@ func (db *DB) CreateGameWithTransaction(game *models.Game, userID int64, newBala
 	return tx.Commit()
 }
 
-// JoinGameWithTransaction 在事务中加入游戏并扣除余额
-func (db *DB) JoinGameWithTransaction(gameID string, player2ID int64, newBalance int64, transaction *models.Transaction) error {
+// JoinGameWithTransaction 在事务中加入游戏并扣除余额，status 为加入后的对局状态（进行中或等待准备）
+func (db *DB) JoinGameWithTransaction(gameID string, player2ID int64, newBalance int64, transaction *models.Transaction, status string) error {
 	tx, err := db.BeginTx()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// 1. 再次验证用户当前余额（防止并发问题）
-	var currentBalance int64
+	// 1. 获取游戏的下注金额
 	var betAmount int64
-	query := `SELECT balance FROM users WHERE id = ?`
-	err = tx.QueryRow(query, player2ID).Scan(&currentBalance)
-	if err != nil {
-		return fmt.Errorf("获取用户当前余额失败: %v", err)
-	}
-
-	// 获取游戏的下注金额
-	query = `SELECT bet_amount FROM games WHERE id = ?`
+	query := `SELECT bet_amount FROM games WHERE id = ?`
 	err = tx.QueryRow(query, gameID).Scan(&betAmount)
 	if err != nil {
 		return fmt.Errorf("获取游戏下注金额失败: %v", err)
 	}
 
-	// 验证余额是否足够
-	if currentBalance < betAmount {
-		return fmt.Errorf("余额不足，请存款后再试。当前余额: %d，需要: %d", currentBalance, betAmount)
-	}
-
-	// 重新计算新余额
-	calculatedNewBalance := currentBalance - betAmount
-	if calculatedNewBalance < 0 {
-		return fmt.Errorf("余额不足，请存款后再试")
+	// 2. 再次验证用户当前余额并扣款（优先使用赠送余额，防止并发问题）
+	calculatedNewBalance, bonusStake, err := db.debitStakeInTx(tx, player2ID, betAmount)
+	if err != nil {
+		return err
 	}
 
 	// 更新交易记录中的余额
 	transaction.Balance = calculatedNewBalance
 
-	// 2. 扣除用户余额
-	if err := db.updateUserBalanceInTx(tx, player2ID, calculatedNewBalance); err != nil {
+	// 3. 更新游戏状态
+	if err := db.updateGamePlayer2InTx(tx, gameID, player2ID, status); err != nil {
 		return err
 	}
 
-	// 3. 更新游戏状态
-	if err := db.updateGamePlayer2InTx(tx, gameID, player2ID); err != nil {
+	if _, err := tx.Exec(`UPDATE games SET player2_bonus_stake = ? WHERE id = ?`, bonusStake, gameID); err != nil {
 		return err
 	}
 
@@ -287,23 +765,83 @@ func (db *DB) updateUserBalanceInTx(tx *sql.Tx, userID int64, newBalance int64)
 	return nil
 }
 
+// creditBalanceInTx 在事务内按增量增加用户现金余额，返回入账后的余额。
+// 不先读后写，避免覆盖其他事务（提现、充值、空投等）同时写入的余额
+func (db *DB) creditBalanceInTx(tx *sql.Tx, userID, amount int64) (int64, error) {
+	result, err := tx.Exec(`UPDATE users SET balance = balance + ?, updated_at = ? WHERE id = ?`, amount, time.Now(), userID)
+	if err != nil {
+		return 0, fmt.Errorf("更新用户余额失败: %v", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return 0, err
+	} else if rowsAffected == 0 {
+		return 0, fmt.Errorf("用户不存在")
+	}
+
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("获取用户当前余额失败: %v", err)
+	}
+	return balance, nil
+}
+
+// debitBalanceInTx 在事务内按增量扣除用户现金余额，余额不足时不扣款并返回错误，返回扣款后的余额
+func (db *DB) debitBalanceInTx(tx *sql.Tx, userID, amount int64) (int64, error) {
+	result, err := tx.Exec(`UPDATE users SET balance = balance - ?, updated_at = ? WHERE id = ? AND balance >= ?`,
+		amount, time.Now(), userID, amount)
+	if err != nil {
+		return 0, fmt.Errorf("更新用户余额失败: %v", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return 0, err
+	} else if rowsAffected == 0 {
+		return 0, fmt.Errorf("余额不足，请存款后再试")
+	}
+
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("获取用户当前余额失败: %v", err)
+	}
+	return balance, nil
+}
+
+// creditPayoutsInTx 为派奖、退款和保险赔付交易入账，并回填交易后的现金余额
+func (db *DB) creditPayoutsInTx(tx *sql.Tx, transactions []*models.Transaction) error {
+	for _, transaction := range transactions {
+		switch transaction.Type {
+		case models.TransactionTypeWin, models.TransactionTypeRefund, models.TransactionTypeInsurancePayout:
+		default:
+			continue
+		}
+		balance, err := db.creditBalanceInTx(tx, transaction.UserID, transaction.Amount)
+		if err != nil {
+			return err
+		}
+		transaction.Balance = balance
+	}
+	return nil
+}
+
 func (db *DB) createGameInTx(tx *sql.Tx, game *models.Game) error {
-	query := `INSERT INTO games (id, player1_id, bet_amount, status, chat_id, created_at, updated_at)
-			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO games (id, player1_id, bet_amount, status, chat_id, created_at, updated_at, server_seed, seed_hash)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	now := time.Now()
 	game.CreatedAt = now
 	game.UpdatedAt = now
 
 	_, err := tx.Exec(query, game.ID, game.Player1ID, game.BetAmount,
-		game.Status, game.ChatID, game.CreatedAt, game.UpdatedAt)
+		game.Status, game.ChatID, game.CreatedAt, game.UpdatedAt, game.ServerSeed, game.SeedHash)
 
 	return err
 }
 
-func (db *DB) updateGamePlayer2InTx(tx *sql.Tx, gameID string, player2ID int64) error {
+// ErrGameNotWaiting 加入时对局已不在等待状态（并发加入时被他人抢先）
+var ErrGameNotWaiting = errors.New("游戏不存在或已开始")
+
+func (db *DB) updateGamePlayer2InTx(tx *sql.Tx, gameID string, player2ID int64, status string) error {
 	query := `UPDATE games SET player2_id = ?, status = ?, updated_at = ? WHERE id = ? AND status = ?`
-	result, err := tx.Exec(query, player2ID, models.GameStatusPlaying, time.Now(), gameID, models.GameStatusWaiting)
+	result, err := tx.Exec(query, player2ID, status, time.Now(), gameID, models.GameStatusWaiting)
 	if err != nil {
 		return err
 	}
@@ -314,7 +852,7 @@ func (db *DB) updateGamePlayer2InTx(tx *sql.Tx, gameID string, player2ID int64)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("游戏不存在或已开始")
+		return ErrGameNotWaiting
 	}
 
 	return nil
@@ -332,8 +870,8 @@ func (db *DB) createTransactionInTx(tx *sql.Tx, transaction *models.Transaction)
 	return err
 }
 
-// SettleGameWithTransaction 在事务中结算游戏
-func (db *DB) SettleGameWithTransaction(gameID string, winnerID *int64, commission int64, dice1, dice2, dice3, dice4, dice5, dice6 int, winnerNewBalance int64, transactions []*models.Transaction) error {
+// SettleGameWithTransaction 在事务中结算游戏，outbox 为随结算一同写入的待发送消息
+func (db *DB) SettleGameWithTransaction(gameID string, winnerID *int64, commission int64, dice1, dice2, dice3, dice4, dice5, dice6 int, transactions []*models.Transaction, outbox []*models.OutboxMessage) error {
 	tx, err := db.BeginTx()
 	if err != nil {
 		return err
@@ -348,19 +886,31 @@ func (db *DB) SettleGameWithTransaction(gameID string, winnerID *int64, commissi
 		player1_dice1 = ?, player1_dice2 = ?, player1_dice3 = ?,
 		player2_dice1 = ?, player2_dice2 = ?, player2_dice3 = ?,
 		updated_at = ?
-		WHERE id = ?`
+		WHERE id = ? AND status = ?`
 
-	_, err = tx.Exec(query, models.GameStatusFinished, winnerID, commission,
-		dice1, dice2, dice3, dice4, dice5, dice6, time.Now(), gameID)
+	result, err := tx.Exec(query, models.GameStatusFinished, winnerID, commission,
+		dice1, dice2, dice3, dice4, dice5, dice6, time.Now(), gameID, models.GameStatusPlaying)
 	if err != nil {
 		return err
 	}
 
-	// 2. 更新获胜者余额（如果不是平局）
+	// 游戏可能已在开骰动画期间被认输结算
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("游戏状态已变更，无法结算")
+	}
+
+	// 2. 派奖和保险赔付按增量入账，赠送余额出资的那部分奖金转回赠送余额
+	if err := db.creditPayoutsInTx(tx, transactions); err != nil {
+		return err
+	}
 	if winnerID != nil {
-		if err := db.updateUserBalanceInTx(tx, *winnerID, winnerNewBalance); err != nil {
+		share, err := db.returnBonusWinningsInTx(tx, gameID, *winnerID, winnerWinAmount(transactions, *winnerID))
+		if err != nil {
 			return err
 		}
+		adjustWinBalance(transactions, *winnerID, share)
 	}
 
 	// 3. 创建交易记录
@@ -370,37 +920,192 @@ func (db *DB) SettleGameWithTransaction(gameID string, winnerID *int64, commissi
 		}
 	}
 
+	// 4. 记录手续费中的群组分成
+	if err := db.applyChatShareInTx(tx, gameID, transactions); err != nil {
+		return err
+	}
+
+	// 5. 已完成的对局计入双方的赠送流水
+	if err := db.recordGameWagerInTx(tx, gameID); err != nil {
+		return err
+	}
+
+	// 6. 写入结算消息，由发送器在提交后投递
+	if err := db.enqueueOutboxInTx(tx, outbox); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
-// RefundGameWithTransaction 在事务中退还游戏金额
-func (db *DB) RefundGameWithTransaction(player1ID int64, player1NewBalance int64, player2ID *int64, player2NewBalance *int64, transactions []*models.Transaction) error {
+// SurrenderGameWithTransaction 在事务中结算认输的游戏
+func (db *DB) SurrenderGameWithTransaction(gameID string, winnerID, loserID, loserRefund, commission int64, transactions []*models.Transaction) error {
 	tx, err := db.BeginTx()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// 1. 退还玩家1余额
-	if err := db.updateUserBalanceInTx(tx, player1ID, player1NewBalance); err != nil {
+	// 1. 仅允许进行中的游戏认输，防止与开骰结算冲突
+	query := `UPDATE games SET status = ?, winner_id = ?, commission = ?, updated_at = ?
+		WHERE id = ? AND status = ?`
+	result, err := tx.Exec(query, models.GameStatusSurrendered, winnerID, commission,
+		time.Now(), gameID, models.GameStatusPlaying)
+	if err != nil {
 		return err
 	}
 
-	// 2. 退还玩家2余额（如果存在）
-	if player2ID != nil && player2NewBalance != nil {
-		if err := db.updateUserBalanceInTx(tx, *player2ID, *player2NewBalance); err != nil {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("游戏已结算，无法认输")
+	}
+
+	// 2. 双方的派奖和退款按增量入账
+	if err := db.creditPayoutsInTx(tx, transactions); err != nil {
+		return err
+	}
+
+	// 认输退款中属于赠送余额的部分退回赠送余额
+	if err := db.restoreBonusStakeInTx(tx, gameID, loserID, loserRefund); err != nil {
+		return err
+	}
+	share, err := db.returnBonusWinningsInTx(tx, gameID, winnerID, winnerWinAmount(transactions, winnerID))
+	if err != nil {
+		return err
+	}
+	adjustWinBalance(transactions, winnerID, share)
+
+	// 3. 创建交易记录
+	for _, transaction := range transactions {
+		if err := db.createTransactionInTx(tx, transaction); err != nil {
 			return err
 		}
 	}
 
-	// 3. 创建退款交易记录
+	if err := db.applyChatShareInTx(tx, gameID, transactions); err != nil {
+		return err
+	}
+
+	if err := db.recordGameWagerInTx(tx, gameID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DrawGameWithTransaction 在事务中将平局的对局标记为已结束并向双方退款（含保险费），outbox 为随退款一同写入的待发送消息；
+// 对局已不在进行中（如开骰动画期间被认输或取消）时不退款并返回错误
+func (db *DB) DrawGameWithTransaction(gameID string, dice1, dice2, dice3, dice4, dice5, dice6 int, player1ID int64, player2ID *int64, transactions []*models.Transaction, outbox []*models.OutboxMessage) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// 1. 更新游戏状态和骰子结果
+	query := `UPDATE games SET 
+		status = ?, 
+		winner_id = NULL, 
+		commission = 0,
+		player1_dice1 = ?, player1_dice2 = ?, player1_dice3 = ?,
+		player2_dice1 = ?, player2_dice2 = ?, player2_dice3 = ?,
+		updated_at = ?
+		WHERE id = ? AND status = ?`
+
+	result, err := tx.Exec(query, models.GameStatusFinished,
+		dice1, dice2, dice3, dice4, dice5, dice6, time.Now(), gameID, models.GameStatusPlaying)
+	if err != nil {
+		return err
+	}
+
+	// 游戏可能已在开骰动画期间被认输、中止或取消，退款已由对应流程完成
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("游戏状态已变更，无法结算")
+	}
+
+	// 2. 退还双方下注
+	if err := db.refundGameInTx(tx, player1ID, player2ID, transactions); err != nil {
+		return err
+	}
+
+	// 3. 平局不分胜负，保险费一并退还
+	if err := db.refundGameInsuranceInTx(tx, gameID); err != nil {
+		return err
+	}
+
+	// 4. 写入结算消息，由发送器在提交后投递
+	if err := db.enqueueOutboxInTx(tx, outbox); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CancelGameWithRefund 在同一事务中将对局从 from 状态改为已取消并向双方退款（含保险费），对局已不在 from 状态时返回 false
+func (db *DB) CancelGameWithRefund(gameID, from string, player1ID int64, player2ID *int64, transactions []*models.Transaction) (bool, error) {
+	if err := models.ValidateGameTransition(from, models.GameStatusCancelled); err != nil {
+		return false, err
+	}
+
+	tx, err := db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE games SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		models.GameStatusCancelled, time.Now(), gameID, from)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := db.refundGameInTx(tx, player1ID, player2ID, transactions); err != nil {
+		return false, err
+	}
+	// 对局未结算，保险费一并退还
+	if err := db.refundGameInsuranceInTx(tx, gameID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// refundGameInTx 在事务中退还双方余额、记录退款交易并退回占用的赠送金额
+func (db *DB) refundGameInTx(tx *sql.Tx, player1ID int64, player2ID *int64, transactions []*models.Transaction) error {
+	// 1. 按退款交易的金额退还双方余额
+	if err := db.creditPayoutsInTx(tx, transactions); err != nil {
+		return err
+	}
+
+	// 2. 创建退款交易记录
 	for _, transaction := range transactions {
 		if err := db.createTransactionInTx(tx, transaction); err != nil {
 			return err
 		}
 	}
 
-	return tx.Commit()
+	// 3. 下注时占用的赠送金额退回赠送余额
+	if len(transactions) > 0 && transactions[0].GameID != nil {
+		gameID := *transactions[0].GameID
+		if err := db.restoreBonusStakeInTx(tx, gameID, player1ID, -1); err != nil {
+			return err
+		}
+		if player2ID != nil {
+			if err := db.restoreBonusStakeInTx(tx, gameID, *player2ID, -1); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
 
 func (db *DB) UpdateUserBalance(userID int64, newBalance int64) error {
@@ -430,15 +1135,15 @@ func (db *DB) UpdateUserBalance(userID int64, newBalance int64) error {
 
 // Game operations
 func (db *DB) CreateGame(game *models.Game) error {
-	query := `INSERT INTO games (id, player1_id, bet_amount, status, chat_id, created_at, updated_at)
-			  VALUES (?, ?, ?, ?, ?, ?, ?)`
+	query := `INSERT INTO games (id, player1_id, bet_amount, status, chat_id, created_at, updated_at, server_seed, seed_hash)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	now := time.Now()
 	game.CreatedAt = now
 	game.UpdatedAt = now
 
 	_, err := db.conn.Exec(query, game.ID, game.Player1ID, game.BetAmount,
-		game.Status, game.ChatID, game.CreatedAt, game.UpdatedAt)
+		game.Status, game.ChatID, game.CreatedAt, game.UpdatedAt, game.ServerSeed, game.SeedHash)
 
 	return err
 }
@@ -447,14 +1152,18 @@ func (db *DB) GetGame(gameID string) (*models.Game, error) {
 	game := &models.Game{}
 	query := `SELECT id, player1_id, player2_id, bet_amount, status, player1_dice1, 
 			  player1_dice2, player1_dice3, player2_dice1, player2_dice2, player2_dice3,
-			  winner_id, commission, chat_id, created_at, updated_at 
+			  winner_id, commission, COALESCE(chat_share, 0), chat_id, created_at, updated_at,
+			  COALESCE(insurance_premium, 0), COALESCE(insurance_coverage, 0),
+			  COALESCE(server_seed, ''), COALESCE(seed_hash, '')
 			  FROM games WHERE id = ?`
 
 	err := db.conn.QueryRow(query, gameID).Scan(
 		&game.ID, &game.Player1ID, &game.Player2ID, &game.BetAmount,
 		&game.Status, &game.Player1Dice1, &game.Player1Dice2, &game.Player1Dice3,
 		&game.Player2Dice1, &game.Player2Dice2, &game.Player2Dice3, &game.WinnerID,
-		&game.Commission, &game.ChatID, &game.CreatedAt, &game.UpdatedAt,
+		&game.Commission, &game.ChatShare, &game.ChatID, &game.CreatedAt, &game.UpdatedAt,
+		&game.InsurancePremium, &game.InsuranceCoverage,
+		&game.ServerSeed, &game.SeedHash,
 	)
 
 	if err == sql.ErrNoRows {
@@ -568,7 +1277,7 @@ func (db *DB) CreateTransaction(tx *models.Transaction) error {
 
 // Admin backend methods
 func (db *DB) GetUsersWithPagination(offset, limit int) ([]*models.User, error) {
-	query := `SELECT id, username, first_name, last_name, balance, created_at, updated_at 
+	query := `SELECT id, username, first_name, last_name, balance, COALESCE(bonus_balance, 0), first_chat_id, source, created_at, updated_at 
 			  FROM users ORDER BY created_at DESC LIMIT ? OFFSET ?`
 
 	rows, err := db.conn.Query(query, limit, offset)
@@ -581,7 +1290,7 @@ func (db *DB) GetUsersWithPagination(offset, limit int) ([]*models.User, error)
 	for rows.Next() {
 		user := &models.User{}
 		err := rows.Scan(&user.ID, &user.Username, &user.FirstName, &user.LastName,
-			&user.Balance, &user.CreatedAt, &user.UpdatedAt)
+			&user.Balance, &user.BonusBalance, &user.FirstChatID, &user.Source, &user.CreatedAt, &user.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -600,7 +1309,7 @@ func (db *DB) GetTotalUsersCount() (int, error) {
 
 // GetUsersWithFilters 根据筛选条件获取用户列表
 func (db *DB) GetUsersWithFilters(offset, limit int, search, status, sortBy string) ([]*models.User, error) {
-	query := `SELECT id, username, first_name, last_name, balance, created_at, updated_at 
+	query := `SELECT id, username, first_name, last_name, balance, COALESCE(bonus_balance, 0), first_chat_id, source, created_at, updated_at 
 			  FROM users WHERE 1=1`
 	args := []interface{}{}
 
@@ -610,6 +1319,7 @@ func (db *DB) GetUsersWithFilters(offset, limit int, search, status, sortBy stri
 		searchPattern := "%" + search + "%"
 		args = append(args, searchPattern, searchPattern)
 	}
+	query += userStatusFilter(status)
 
 	// 添加排序
 	switch sortBy {
@@ -634,7 +1344,7 @@ func (db *DB) GetUsersWithFilters(offset, limit int, search, status, sortBy stri
 	for rows.Next() {
 		user := &models.User{}
 		err := rows.Scan(&user.ID, &user.Username, &user.FirstName, &user.LastName,
-			&user.Balance, &user.CreatedAt, &user.UpdatedAt)
+			&user.Balance, &user.BonusBalance, &user.FirstChatID, &user.Source, &user.CreatedAt, &user.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -655,6 +1365,7 @@ func (db *DB) GetTotalUsersCountWithFilters(search, status string) (int, error)
 		searchPattern := "%" + search + "%"
 		args = append(args, searchPattern, searchPattern)
 	}
+	query += userStatusFilter(status)
 
 	var count int
 	err := db.conn.QueryRow(query, args...).Scan(&count)
@@ -866,6 +1577,43 @@ func (db *DB) HasPlayingGames(chatID int64) (bool, error) {
 	return count > 0, nil
 }
 
+// CountPlayingGames 统计指定聊天中进行中的对局数，等待双方准备的对局也计入
+func (db *DB) CountPlayingGames(chatID int64) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM games WHERE status IN (?, ?) AND chat_id = ?`
+	err := db.conn.QueryRow(query, models.GameStatusPlaying, models.GameStatusReady, chatID).Scan(&count)
+	return count, err
+}
+
+// GetOpenGames 获取所有群组未结束的对局（等待中、等待准备、进行中和结算待审核），按群组和发起时间排列
+func (db *DB) GetOpenGames() ([]*models.Game, error) {
+	rows, err := db.conn.Query(`SELECT id, player1_id, player2_id, bet_amount, status, player1_dice1,
+			  player1_dice2, player1_dice3, player2_dice1, player2_dice2, player2_dice3,
+			  winner_id, commission, chat_id, created_at, updated_at, COALESCE(insurance_premium, 0)
+			  FROM games WHERE status IN (?, ?, ?, ?) ORDER BY chat_id ASC, created_at ASC`,
+		models.GameStatusWaiting, models.GameStatusReady, models.GameStatusPlaying, models.GameStatusHeld)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []*models.Game
+	for rows.Next() {
+		game := &models.Game{}
+		err := rows.Scan(
+			&game.ID, &game.Player1ID, &game.Player2ID, &game.BetAmount,
+			&game.Status, &game.Player1Dice1, &game.Player1Dice2, &game.Player1Dice3,
+			&game.Player2Dice1, &game.Player2Dice2, &game.Player2Dice3, &game.WinnerID,
+			&game.Commission, &game.ChatID, &game.CreatedAt, &game.UpdatedAt, &game.InsurancePremium,
+		)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	return games, rows.Err()
+}
+
 // HasActivelyPlayingGames 检查指定聊天是否有真正在进行中的游戏（已经开始掷骰子）
 func (db *DB) HasActivelyPlayingGames(chatID int64) (bool, error) {
 	var count int
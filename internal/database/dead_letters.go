@@ -0,0 +1,81 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// AddDeadLetter 记录执行失败的任务，返回记录ID
+func (db *DB) AddDeadLetter(letter *models.DeadLetter) (int64, error) {
+	now := time.Now()
+	result, err := db.conn.Exec(`INSERT INTO dead_letters (kind, payload, priority, error, attempts, status, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, 1, ?, ?, ?)`,
+		letter.Kind, letter.Payload, letter.Priority, letter.Error, models.DeadLetterPending, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// GetDeadLetter 获取失败任务，不存在时返回 nil
+func (db *DB) GetDeadLetter(id int64) (*models.DeadLetter, error) {
+	letter := &models.DeadLetter{}
+	err := db.conn.QueryRow(`SELECT id, kind, payload, priority, error, attempts, status, created_at, updated_at
+			  FROM dead_letters WHERE id = ?`, id).Scan(
+		&letter.ID, &letter.Kind, &letter.Payload, &letter.Priority, &letter.Error,
+		&letter.Attempts, &letter.Status, &letter.CreatedAt, &letter.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return letter, err
+}
+
+// GetDeadLetters 按状态获取失败任务，最新的在前，status 为空时返回全部
+func (db *DB) GetDeadLetters(status string, limit int) ([]*models.DeadLetter, error) {
+	query := `SELECT id, kind, payload, priority, error, attempts, status, created_at, updated_at FROM dead_letters`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var letters []*models.DeadLetter
+	for rows.Next() {
+		letter := &models.DeadLetter{}
+		if err := rows.Scan(&letter.ID, &letter.Kind, &letter.Payload, &letter.Priority, &letter.Error,
+			&letter.Attempts, &letter.Status, &letter.CreatedAt, &letter.UpdatedAt); err != nil {
+			return nil, err
+		}
+		letters = append(letters, letter)
+	}
+	return letters, rows.Err()
+}
+
+// SetDeadLetterStatus 将处于 from 状态的失败任务改为 to 状态，状态不符时返回 false
+func (db *DB) SetDeadLetterStatus(id int64, from, to string) (bool, error) {
+	result, err := db.conn.Exec(`UPDATE dead_letters SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		to, time.Now(), id, from)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// FailDeadLetter 重放再次失败，记录新的错误并恢复为待处理
+func (db *DB) FailDeadLetter(id int64, errMsg string) error {
+	_, err := db.conn.Exec(`UPDATE dead_letters SET status = ?, error = ?, attempts = attempts + 1, updated_at = ? WHERE id = ?`,
+		models.DeadLetterPending, errMsg, time.Now(), id)
+	return err
+}
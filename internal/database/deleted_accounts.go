@@ -0,0 +1,60 @@
+package database
+
+import (
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// MarkUserDeleted 标记用户的 Telegram 账号已注销：停止私信，名字改为统一的占位名，返回是否为首次标记
+func (db *DB) MarkUserDeleted(userID int64) (bool, error) {
+	now := time.Now()
+	result, err := db.conn.Exec(`UPDATE users SET deleted_at = ?, dm_blocked = 1, username = '', first_name = ?, last_name = '', updated_at = ?
+			  WHERE id = ? AND deleted_at IS NULL`, now, models.DeletedUserName, now, userID)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
+
+// GetWaitingGameIDsByCreator 用户发起的、仍在等待加入的对局ID
+func (db *DB) GetWaitingGameIDsByCreator(userID int64) ([]string, error) {
+	rows, err := db.conn.Query(`SELECT id FROM games WHERE player1_id = ? AND status = ? ORDER BY created_at`,
+		userID, models.GameStatusWaiting)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetWaitingCreators 所有等待加入的对局的发起者及所在群组，每个发起者和群组的组合只返回一个对局，
+// 仅填充 ID、Player1ID 和 ChatID
+func (db *DB) GetWaitingCreators() ([]*models.Game, error) {
+	rows, err := db.conn.Query(`SELECT MIN(id), player1_id, chat_id FROM games WHERE status = ?
+			  GROUP BY player1_id, chat_id`, models.GameStatusWaiting)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []*models.Game
+	for rows.Next() {
+		game := &models.Game{}
+		if err := rows.Scan(&game.ID, &game.Player1ID, &game.ChatID); err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	return games, rows.Err()
+}
@@ -0,0 +1,193 @@
+package database
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Dialect 数据库方言，由 DATABASE_URL 的协议前缀决定
+type Dialect string
+
+const (
+	// DialectSQLite 默认方言，DATABASE_URL 为文件路径、:memory: 或 sqlite:// 前缀
+	DialectSQLite Dialect = "sqlite"
+	// DialectPostgres DATABASE_URL 以 postgres:// 或 postgresql:// 开头，用于多实例部署
+	DialectPostgres Dialect = "postgres"
+)
+
+// ParseDatabaseURL 根据协议前缀识别方言，返回方言和交给驱动的连接串
+func ParseDatabaseURL(databaseURL string) (Dialect, string) {
+	lower := strings.ToLower(databaseURL)
+	switch {
+	case strings.HasPrefix(lower, "postgres://"), strings.HasPrefix(lower, "postgresql://"):
+		return DialectPostgres, databaseURL
+	case strings.HasPrefix(lower, "sqlite3://"):
+		return DialectSQLite, databaseURL[len("sqlite3://"):]
+	case strings.HasPrefix(lower, "sqlite://"):
+		return DialectSQLite, databaseURL[len("sqlite://"):]
+	default:
+		return DialectSQLite, databaseURL
+	}
+}
+
+// DisplayURL 用于日志显示的连接串，隐藏 PostgreSQL 连接串中的密码
+func DisplayURL(databaseURL string) string {
+	if dialect, _ := ParseDatabaseURL(databaseURL); dialect != DialectPostgres {
+		return databaseURL
+	}
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return "postgres://***"
+	}
+	return u.Redacted()
+}
+
+// postgresRewrite 一条按正则匹配的改写规则
+type postgresRewrite struct {
+	pattern *regexp.Regexp
+	replace string
+}
+
+// postgresRewrites 将 SQLite 写法改写为 PostgreSQL 写法，按顺序应用，只作用于字符串常量之外的部分。
+// 金额、用户ID等整数统一用 BIGINT（Telegram ID 超出 32 位），时间统一带时区存储
+var postgresRewrites = []postgresRewrite{
+	{regexp.MustCompile(`\bINTEGER PRIMARY KEY AUTOINCREMENT\b`), "BIGSERIAL PRIMARY KEY"},
+	{regexp.MustCompile(`\bINTEGER\b`), "BIGINT"},
+	{regexp.MustCompile(`\bDATETIME\b`), "TIMESTAMPTZ"},
+	{regexp.MustCompile(`\bREAL\b`), "DOUBLE PRECISION"},
+	{regexp.MustCompile(`\bADD COLUMN\b`), "ADD COLUMN IF NOT EXISTS"},
+	// 忽略主键或唯一约束冲突的插入
+	{regexp.MustCompile(`(?is)^(\s*)INSERT OR IGNORE INTO\b(.*?)\s*$`), "${1}INSERT INTO$2 ON CONFLICT DO NOTHING"},
+	// 不区分大小写的比较和索引
+	{regexp.MustCompile(`\((\w+) COLLATE NOCASE\)`), "(LOWER($1))"},
+	{regexp.MustCompile(`(\w+) = \? COLLATE NOCASE`), "LOWER($1) = LOWER(?)"},
+	{regexp.MustCompile(`\bLIKE\b`), "ILIKE"},
+}
+
+// postgresFunctions 参数为字符串常量的 SQLite 日期函数，在屏蔽字符串常量时按原文改写
+var postgresFunctions = []postgresRewrite{
+	{regexp.MustCompile(`^datetime\('now', '-(\d+) days'\)`), "(NOW() - INTERVAL '$1 days')"},
+	{regexp.MustCompile(`^date\('now'\)`), "CURRENT_DATE"},
+}
+
+// maskedLiteral 屏蔽后的字符串常量占位标记，\x00 不会出现在语句中
+var maskedLiteral = regexp.MustCompile(`\x00(\d+)\x00`)
+
+// Translate 将按 SQLite 编写的语句改写为当前方言可执行的语句，SQLite 方言原样返回；
+// 字符串常量、带引号的标识符和 $$ 包围的函数体保持原样
+func (d Dialect) Translate(query string) string {
+	if d != DialectPostgres {
+		return query
+	}
+	masked, literals := maskLiterals(query)
+	for _, rewrite := range postgresRewrites {
+		masked = rewrite.pattern.ReplaceAllString(masked, rewrite.replace)
+	}
+	query = maskedLiteral.ReplaceAllStringFunc(masked, func(mark string) string {
+		n, _ := strconv.Atoi(mark[1 : len(mark)-1])
+		return literals[n]
+	})
+	return rebindPostgres(query)
+}
+
+// maskLiterals 将字符串常量、带引号的标识符和 $$ 包围的函数体替换为占位标记，返回屏蔽后的语句和被屏蔽的原文；
+// 日期函数在此一并改写，改写结果同样屏蔽
+func maskLiterals(query string) (string, []string) {
+	var b strings.Builder
+	b.Grow(len(query))
+
+	var literals []string
+	mask := func(literal string) {
+		b.WriteByte(0)
+		b.WriteString(strconv.Itoa(len(literals)))
+		b.WriteByte(0)
+		literals = append(literals, literal)
+	}
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(query[i+1:], c)
+			if end < 0 {
+				end = len(query)
+			} else {
+				end += i + 2
+			}
+			mask(query[i:end])
+			i = end
+			continue
+		case strings.HasPrefix(query[i:], "$$"):
+			end := strings.Index(query[i+2:], "$$")
+			if end < 0 {
+				end = len(query)
+			} else {
+				end += i + 4
+			}
+			mask(query[i:end])
+			i = end
+			continue
+		case i == 0 || !isWordByte(query[i-1]):
+			if replaced, n := rewriteFunction(query[i:]); n > 0 {
+				mask(replaced)
+				i += n
+				continue
+			}
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String(), literals
+}
+
+// rewriteFunction 语句开头是可改写的日期函数时返回改写结果和原文长度
+func rewriteFunction(query string) (string, int) {
+	for _, rewrite := range postgresFunctions {
+		if loc := rewrite.pattern.FindStringSubmatchIndex(query); loc != nil {
+			return string(rewrite.pattern.ExpandString(nil, rewrite.replace, query, loc)), loc[1]
+		}
+	}
+	return "", 0
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// rebindPostgres 将 ? 占位符改为 $1、$2 …，跳过字符串常量、带引号的标识符和 $$ 包围的函数体
+func rebindPostgres(query string) string {
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n := 0
+	var quote byte
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case strings.HasPrefix(query[i:], "$$"):
+			end := strings.Index(query[i+2:], "$$")
+			if end < 0 {
+				end = len(query) - i - 2
+			} else {
+				end += 2
+			}
+			b.WriteString(query[i : i+2+end])
+			i += 1 + end
+			continue
+		case c == '?':
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,240 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// ErrDisputeExists 对局已有争议记录，每局只能提出一次
+var ErrDisputeExists = errors.New("该对局已提出过争议")
+
+// ErrDisputeNotOpen 争议不存在或已处理
+var ErrDisputeNotOpen = errors.New("争议不存在或已处理")
+
+// disputeColumns 查询对局争议的字段
+const disputeColumns = `id, game_id, chat_id, reporter_id, winner_id, loser_id, bet_amount, payout, held, status,
+			  reviewer, note, created_at, resolved_at`
+
+// CreateDispute 记录对局争议并冻结获胜者的派奖。派奖金额取自该局的获胜流水，
+// 获胜者余额不足时冻结全部现金余额，实际冻结金额写入 dispute.Held
+func (db *DB) CreateDispute(dispute *models.Dispute) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM disputes WHERE game_id = ?`, dispute.GameID).Scan(&existing); err != nil {
+		return fmt.Errorf("检查争议记录失败: %v", err)
+	}
+	if existing > 0 {
+		return ErrDisputeExists
+	}
+
+	if err := tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE game_id = ? AND user_id = ? AND type = ?`,
+		dispute.GameID, dispute.WinnerID, models.TransactionTypeWin).Scan(&dispute.Payout); err != nil {
+		return fmt.Errorf("获取派奖记录失败: %v", err)
+	}
+	if dispute.Payout <= 0 {
+		return fmt.Errorf("对局 %s 没有派奖记录", dispute.GameID)
+	}
+
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, dispute.WinnerID).Scan(&balance); err != nil {
+		return fmt.Errorf("获取用户当前余额失败: %v", err)
+	}
+	dispute.Held = dispute.Payout
+	if balance < dispute.Held {
+		dispute.Held = balance
+	}
+
+	dispute.Status = models.DisputeStatusOpen
+	dispute.CreatedAt = time.Now()
+	result, err := tx.Exec(`INSERT INTO disputes (game_id, chat_id, reporter_id, winner_id, loser_id, bet_amount, payout, held, status, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		dispute.GameID, dispute.ChatID, dispute.ReporterID, dispute.WinnerID, dispute.LoserID, dispute.BetAmount,
+		dispute.Payout, dispute.Held, dispute.Status, dispute.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("记录争议失败: %v", err)
+	}
+	if dispute.ID, err = result.LastInsertId(); err != nil {
+		return err
+	}
+
+	if dispute.Held > 0 {
+		description := fmt.Sprintf("对局 %s 结果争议 #%d，冻结派奖", dispute.GameID, dispute.ID)
+		if err := db.disputeTransactionInTx(tx, dispute, dispute.WinnerID, -dispute.Held, models.TransactionTypeDisputeHold, description); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReleaseDispute 维持原结果：关闭争议并将冻结的派奖退回获胜者
+func (db *DB) ReleaseDispute(id int64, reviewer, note string) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	dispute, err := db.closeDisputeInTx(tx, id, models.DisputeStatusReleased, reviewer, note)
+	if err != nil {
+		return err
+	}
+	if dispute.Held > 0 {
+		description := fmt.Sprintf("争议 #%d 维持原结果，退回冻结的派奖", dispute.ID)
+		if err := db.disputeTransactionInTx(tx, dispute, dispute.WinnerID, dispute.Held, models.TransactionTypeDisputeRelease, description); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ReverseDispute 撤销原结果：冻结的派奖扣除赢得部分后退回获胜者的下注，输家退还下注（已获保险赔付的部分除外）。
+// 对局状态为终态保持不变，作废以争议记录为准；获胜者冻结金额不足以抵扣赢得部分时差额由平台承担
+func (db *DB) ReverseDispute(id int64, reviewer, note string) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	dispute, err := db.closeDisputeInTx(tx, id, models.DisputeStatusReversed, reviewer, note)
+	if err != nil {
+		return err
+	}
+
+	// 冻结金额中超出赢得部分（派奖 - 下注）的即为获胜者自己的下注
+	winnerRefund := dispute.Held - (dispute.Payout - dispute.BetAmount)
+	if winnerRefund > 0 {
+		description := fmt.Sprintf("争议 #%d 撤销对局结果，退还下注", dispute.ID)
+		if err := db.disputeTransactionInTx(tx, dispute, dispute.WinnerID, winnerRefund, models.TransactionTypeDisputeReversal, description); err != nil {
+			return err
+		}
+	}
+
+	var insured int64
+	if err := tx.QueryRow(`SELECT COALESCE(SUM(amount), 0) FROM transactions WHERE game_id = ? AND user_id = ? AND type = ?`,
+		dispute.GameID, dispute.LoserID, models.TransactionTypeInsurancePayout).Scan(&insured); err != nil {
+		return fmt.Errorf("获取保险赔付记录失败: %v", err)
+	}
+	if loserRefund := dispute.BetAmount - insured; loserRefund > 0 {
+		description := fmt.Sprintf("争议 #%d 撤销对局结果，退还下注", dispute.ID)
+		if err := db.disputeTransactionInTx(tx, dispute, dispute.LoserID, loserRefund, models.TransactionTypeDisputeReversal, description); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDispute 获取对局争议，不存在时返回 nil
+func (db *DB) GetDispute(id int64) (*models.Dispute, error) {
+	dispute, err := scanDispute(db.conn.QueryRow(`SELECT `+disputeColumns+` FROM disputes WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return dispute, err
+}
+
+// GetDisputes 按状态获取对局争议，status 为空时不限状态；待审核的按提出时间正序，其余按时间倒序
+func (db *DB) GetDisputes(status string, limit int) ([]*models.Dispute, error) {
+	query := `SELECT ` + disputeColumns + ` FROM disputes`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	if status == models.DisputeStatusOpen {
+		query += ` ORDER BY created_at, id`
+	} else {
+		query += ` ORDER BY created_at DESC, id DESC`
+	}
+	query += ` LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var disputes []*models.Dispute
+	for rows.Next() {
+		dispute, err := scanDispute(rows)
+		if err != nil {
+			return nil, err
+		}
+		disputes = append(disputes, dispute)
+	}
+	return disputes, rows.Err()
+}
+
+// closeDisputeInTx 将待审核的争议标记为已处理，返回处理前的记录
+func (db *DB) closeDisputeInTx(tx *sql.Tx, id int64, status, reviewer, note string) (*models.Dispute, error) {
+	dispute, err := scanDispute(tx.QueryRow(`SELECT `+disputeColumns+` FROM disputes WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrDisputeNotOpen
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := tx.Exec(`UPDATE disputes SET status = ?, reviewer = ?, note = ?, resolved_at = ? WHERE id = ? AND status = ?`,
+		status, reviewer, note, time.Now(), id, models.DisputeStatusOpen)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rows == 0 {
+		return nil, ErrDisputeNotOpen
+	}
+	return dispute, nil
+}
+
+// disputeTransactionInTx 变更用户余额并记录关联到对局的争议流水
+func (db *DB) disputeTransactionInTx(tx *sql.Tx, dispute *models.Dispute, userID, amount int64, transactionType, description string) error {
+	balance, err := db.creditBalanceInTx(tx, userID, amount)
+	if err != nil {
+		return err
+	}
+	return db.createTransactionInTx(tx, &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      userID,
+		GameID:      &dispute.GameID,
+		Type:        transactionType,
+		Amount:      amount,
+		Balance:     balance,
+		Description: description,
+	})
+}
+
+// disputeScanner 可扫描一行结果的 *sql.Row 或 *sql.Rows
+type disputeScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanDispute 按 disputeColumns 的顺序扫描一条对局争议
+func scanDispute(row disputeScanner) (*models.Dispute, error) {
+	dispute := &models.Dispute{}
+	var resolvedAt sql.NullTime
+	err := row.Scan(&dispute.ID, &dispute.GameID, &dispute.ChatID, &dispute.ReporterID, &dispute.WinnerID, &dispute.LoserID,
+		&dispute.BetAmount, &dispute.Payout, &dispute.Held, &dispute.Status, &dispute.Reviewer, &dispute.Note,
+		&dispute.CreatedAt, &resolvedAt)
+	if err != nil {
+		return nil, err
+	}
+	if resolvedAt.Valid {
+		dispute.ResolvedAt = &resolvedAt.Time
+	}
+	return dispute, nil
+}
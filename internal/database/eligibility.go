@@ -0,0 +1,48 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// GetChatEligibility 获取群组的下注资格要求，群组不存在时返回不限制
+func (db *DB) GetChatEligibility(chatID int64) (*models.ChatEligibility, error) {
+	eligibility := &models.ChatEligibility{}
+	err := db.conn.QueryRow(`SELECT COALESCE(min_account_days, 0), COALESCE(min_member_days, 0) FROM chats WHERE id = ?`, chatID).
+		Scan(&eligibility.MinAccountDays, &eligibility.MinMemberDays)
+	if err == sql.ErrNoRows {
+		return eligibility, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return eligibility, nil
+}
+
+// SetChatEligibility 保存群组的下注资格要求
+func (db *DB) SetChatEligibility(chatID int64, eligibility *models.ChatEligibility) error {
+	query := `INSERT INTO chats (id, min_account_days, min_member_days, joined_at, updated_at) VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET min_account_days = excluded.min_account_days,
+			  min_member_days = excluded.min_member_days, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, eligibility.MinAccountDays, eligibility.MinMemberDays, now, now)
+	return err
+}
+
+// RecordChatMember 记录机器人首次在群组中见到用户的时间，已有记录时保持不变
+func (db *DB) RecordChatMember(chatID, userID int64, seenAt time.Time) error {
+	_, err := db.conn.Exec(`INSERT INTO chat_members (chat_id, user_id, first_seen) VALUES (?, ?, ?)
+			  ON CONFLICT(chat_id, user_id) DO NOTHING`, chatID, userID, seenAt)
+	return err
+}
+
+// GetChatMemberSince 获取机器人首次在群组中见到用户的时间，没有记录时 ok 为 false
+func (db *DB) GetChatMemberSince(chatID, userID int64) (since time.Time, ok bool, err error) {
+	err = db.conn.QueryRow(`SELECT first_seen FROM chat_members WHERE chat_id = ? AND user_id = ?`, chatID, userID).Scan(&since)
+	if err == sql.ErrNoRows {
+		return time.Time{}, false, nil
+	}
+	return since, err == nil, err
+}
@@ -7,8 +7,8 @@ import (
 	"telegram-dice-bot/internal/models"
 )
 
-// ExpireGameWithTransaction 在事务中处理游戏超时
-func (db *DB) ExpireGameWithTransaction(gameID string, playerID int64, newBalance int64, transaction *models.Transaction) error {
+// ExpireGameWithTransaction 在事务中处理游戏超时，按退款交易的金额向发起者退款并回填交易后的余额，同时退还保险费
+func (db *DB) ExpireGameWithTransaction(gameID string, playerID int64, transaction *models.Transaction) error {
 	tx, err := db.BeginTx()
 	if err != nil {
 		return err
@@ -31,16 +31,28 @@ func (db *DB) ExpireGameWithTransaction(gameID string, playerID int64, newBalanc
 		return fmt.Errorf("游戏不存在或状态不正确")
 	}
 
-	// 2. 更新玩家余额
-	if err := db.updateUserBalanceInTx(tx, playerID, newBalance); err != nil {
+	// 2. 按增量退还玩家余额
+	balance, err := db.creditBalanceInTx(tx, playerID, transaction.Amount)
+	if err != nil {
 		return err
 	}
+	transaction.Balance = balance
 
 	// 3. 创建退款交易记录
 	if err := db.createTransactionInTx(tx, transaction); err != nil {
 		return err
 	}
 
+	// 4. 下注时占用的赠送金额退回赠送余额
+	if err := db.restoreBonusStakeInTx(tx, gameID, playerID, -1); err != nil {
+		return err
+	}
+
+	// 5. 对局未开始，退还保险费
+	if err := db.refundGameInsuranceInTx(tx, gameID); err != nil {
+		return err
+	}
+
 	return tx.Commit()
 }
 
@@ -69,18 +81,39 @@ func (db *DB) SettleGameWithTransactionEnhanced(gameID string, winnerID *int64,
 		return err
 	}
 
-	// 2. 处理交易记录和余额更新
-	for _, transaction := range transactions {
-		// 更新用户余额
-		if err := db.updateUserBalanceInTx(tx, transaction.UserID, transaction.Balance); err != nil {
+	// 2. 派奖和退款按增量入账
+	if err := db.creditPayoutsInTx(tx, transactions); err != nil {
+		return err
+	}
+
+	// 3. 赠送余额出资的部分原路退回：平局退回下注占用的赠送金额，获胜转回相应比例的奖金
+	if winnerID == nil {
+		for _, transaction := range transactions {
+			if err := db.restoreBonusStakeInTx(tx, gameID, transaction.UserID, -1); err != nil {
+				return err
+			}
+		}
+	} else {
+		share, err := db.returnBonusWinningsInTx(tx, gameID, *winnerID, winAmount)
+		if err != nil {
 			return err
 		}
-		
-		// 创建交易记录
+		adjustWinBalance(transactions, *winnerID, share)
+	}
+
+	// 4. 创建交易记录
+	for _, transaction := range transactions {
 		if err := db.createTransactionInTx(tx, transaction); err != nil {
 			return err
 		}
 	}
 
+	// 5. 已完成的对局计入双方的赠送流水
+	if winnerID != nil {
+		if err := db.recordGameWagerInTx(tx, gameID); err != nil {
+			return err
+		}
+	}
+
 	return tx.Commit()
 }
\ No newline at end of file
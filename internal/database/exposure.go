@@ -0,0 +1,43 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// GetChatTurnover 群组 since 之后的下注总额：双人对局按已入座人数计算，快速桌按入座的底注计算，已取消或超时退款的不计入
+func (db *DB) GetChatTurnover(chatID int64, since time.Time) (int64, error) {
+	query := `SELECT
+			  (SELECT COALESCE(SUM(bet_amount * CASE WHEN player2_id IS NULL THEN 1 ELSE 2 END), 0) FROM games
+			   WHERE chat_id = ? AND created_at >= ? AND status NOT IN (?, ?))
+			  +
+			  (SELECT COALESCE(SUM(t.ante), 0) FROM table_players p JOIN game_tables t ON t.id = p.table_id
+			   WHERE t.chat_id = ? AND p.joined_at >= ? AND t.status <> ?)`
+
+	var turnover int64
+	err := db.conn.QueryRow(query,
+		chatID, since, models.GameStatusCancelled, models.GameStatusExpired,
+		chatID, since, models.TableStatusCancelled).Scan(&turnover)
+	return turnover, err
+}
+
+// GetChatHourlyCap 获取群组自定义的每小时下注上限，0 表示沿用全局配置，负数表示不限制
+func (db *DB) GetChatHourlyCap(chatID int64) (int64, error) {
+	var hourlyCap int64
+	err := db.conn.QueryRow(`SELECT COALESCE(hourly_cap, 0) FROM chats WHERE id = ?`, chatID).Scan(&hourlyCap)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return hourlyCap, err
+}
+
+// SetChatHourlyCap 保存群组自定义的每小时下注上限
+func (db *DB) SetChatHourlyCap(chatID int64, hourlyCap int64) error {
+	query := `INSERT INTO chats (id, hourly_cap, joined_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET hourly_cap = excluded.hourly_cap, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, hourlyCap, now, now)
+	return err
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// maxTimelineRecords 时间线中每类记录的最大条数
+const maxTimelineRecords = 500
+
+// RecordGameEvent 记录对局事件，未填写时间时使用当前时间
+func (db *DB) RecordGameEvent(event *models.GameEvent) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	result, err := db.conn.Exec(`INSERT INTO game_events (game_id, type, user_id, chat_id, message_id, detail, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		event.GameID, event.Type, event.UserID, event.ChatID, event.MessageID, event.Detail, event.CreatedAt)
+	if err != nil {
+		return err
+	}
+	event.ID, err = result.LastInsertId()
+	return err
+}
+
+// GetGameEvents 获取对局的全部事件，按时间顺序
+func (db *DB) GetGameEvents(gameID string) ([]*models.GameEvent, error) {
+	rows, err := db.conn.Query(`SELECT id, game_id, type, user_id, chat_id, message_id, detail, created_at
+			  FROM game_events WHERE game_id = ? ORDER BY created_at, id LIMIT ?`, gameID, maxTimelineRecords)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.GameEvent
+	for rows.Next() {
+		event := &models.GameEvent{}
+		if err := rows.Scan(&event.ID, &event.GameID, &event.Type, &event.UserID, &event.ChatID,
+			&event.MessageID, &event.Detail, &event.CreatedAt); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// GetGameTimeline 汇总对局事件、资金流水和管理员操作，按时间顺序排列，对局不存在时返回 nil
+func (db *DB) GetGameTimeline(gameID string) (*models.GameTimeline, error) {
+	game, err := db.GetGame(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取对局失败: %v", err)
+	}
+	if game == nil {
+		return nil, nil
+	}
+
+	events, err := db.GetGameEvents(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取对局事件失败: %v", err)
+	}
+	transactions, _, err := db.SearchTransactions(&models.TransactionFilter{GameID: gameID}, "", maxTimelineRecords)
+	if err != nil {
+		return nil, fmt.Errorf("获取对局流水失败: %v", err)
+	}
+	actions, err := db.GetAdminActions("game", gameID, maxTimelineRecords)
+	if err != nil {
+		return nil, fmt.Errorf("获取管理员操作失败: %v", err)
+	}
+
+	var entries []*models.GameTimelineEntry
+	for _, event := range events {
+		entries = append(entries, &models.GameTimelineEntry{
+			Time:      event.CreatedAt,
+			Type:      event.Type,
+			UserID:    event.UserID,
+			MessageID: event.MessageID,
+			Detail:    event.Detail,
+		})
+	}
+	// 流水和管理员操作按时间倒序返回，逆序加入
+	for i := len(transactions) - 1; i >= 0; i-- {
+		tx := transactions[i]
+		entries = append(entries, &models.GameTimelineEntry{
+			Time:   tx.CreatedAt,
+			Type:   models.GameEventTransaction,
+			UserID: tx.UserID,
+			Amount: tx.Amount,
+			Detail: fmt.Sprintf("%s：%s", tx.Type, tx.Description),
+		})
+	}
+	for i := len(actions) - 1; i >= 0; i-- {
+		action := actions[i]
+		detail := fmt.Sprintf("%s（%s）", action.Action, action.Admin)
+		if action.Details != "" {
+			detail += " " + action.Details
+		}
+		entries = append(entries, &models.GameTimelineEntry{
+			Time:   action.CreatedAt,
+			Type:   models.GameEventAdmin,
+			Detail: detail,
+		})
+	}
+
+	// 同一时刻的记录保持事件、流水、管理员操作的先后
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return &models.GameTimeline{Game: game, Entries: entries}, nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// createGameStateTriggers 在数据库层强制对局状态机：拒绝无效状态的写入和非法的状态转换。
+// SQLite 无法为已有的表追加约束，因此用触发器实现，每次启动按当前状态机重建（PostgreSQL 同样用触发器，保持两种数据库行为一致）
+func (db *DB) createGameStateTriggers() error {
+	var states, transitions []string
+	for _, state := range models.GameStates() {
+		states = append(states, fmt.Sprintf("'%s'", state))
+	}
+	for _, transition := range models.GameTransitions() {
+		transitions = append(transitions, fmt.Sprintf("'%s>%s'", transition[0], transition[1]))
+	}
+
+	if db.dialect == DialectPostgres {
+		return db.createPostgresGameStateTrigger(states, transitions)
+	}
+
+	queries := []string{
+		`DROP TRIGGER IF EXISTS games_status_insert`,
+		fmt.Sprintf(`CREATE TRIGGER games_status_insert BEFORE INSERT ON games
+			WHEN NEW.status NOT IN (%s)
+			BEGIN SELECT RAISE(ABORT, 'invalid game status'); END`, strings.Join(states, ", ")),
+		`DROP TRIGGER IF EXISTS games_status_transition`,
+		fmt.Sprintf(`CREATE TRIGGER games_status_transition BEFORE UPDATE OF status ON games
+			WHEN NEW.status <> OLD.status AND (OLD.status || '>' || NEW.status) NOT IN (%s)
+			BEGIN SELECT RAISE(ABORT, 'illegal game status transition'); END`, strings.Join(transitions, ", ")),
+	}
+	for _, query := range queries {
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("创建对局状态触发器失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// createPostgresGameStateTrigger PostgreSQL 版本的状态机触发器，插入和状态更新共用一个触发函数
+func (db *DB) createPostgresGameStateTrigger(states, transitions []string) error {
+	queries := []string{
+		fmt.Sprintf(`CREATE OR REPLACE FUNCTION games_status_check() RETURNS trigger AS $$
+			BEGIN
+				IF TG_OP = 'INSERT' AND NEW.status NOT IN (%s) THEN
+					RAISE EXCEPTION 'invalid game status';
+				END IF;
+				IF TG_OP = 'UPDATE' AND NEW.status <> OLD.status AND (OLD.status || '>' || NEW.status) NOT IN (%s) THEN
+					RAISE EXCEPTION 'illegal game status transition';
+				END IF;
+				RETURN NEW;
+			END
+			$$ LANGUAGE plpgsql`, strings.Join(states, ", "), strings.Join(transitions, ", ")),
+		`DROP TRIGGER IF EXISTS games_status_check ON games`,
+		`CREATE TRIGGER games_status_check BEFORE INSERT OR UPDATE OF status ON games
+			FOR EACH ROW EXECUTE FUNCTION games_status_check()`,
+	}
+	for _, query := range queries {
+		if _, err := db.conn.Exec(query); err != nil {
+			return fmt.Errorf("创建对局状态触发器失败: %v", err)
+		}
+	}
+	return nil
+}
+
+// TransitionGameStatus 仅当对局处于 from 状态时改为 to，状态已变更（如已被其他管理员处理）时返回 false，
+// 状态机不允许的转换返回 *models.GameTransitionError
+func (db *DB) TransitionGameStatus(gameID, from, to string) (bool, error) {
+	if err := models.ValidateGameTransition(from, to); err != nil {
+		return false, err
+	}
+
+	query := `UPDATE games SET status = ?, updated_at = ? WHERE id = ? AND status = ?`
+	result, err := db.conn.Exec(query, to, time.Now(), gameID, from)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
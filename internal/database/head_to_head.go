@@ -0,0 +1,27 @@
+package database
+
+import (
+	"telegram-dice-bot/internal/models"
+)
+
+// GetHeadToHead 统计两名玩家之间已完成（含认输）对局的交手记录，不区分先后手
+func (db *DB) GetHeadToHead(playerA, playerB int64) (*models.HeadToHead, error) {
+	if playerA > playerB {
+		playerA, playerB = playerB, playerA
+	}
+
+	query := `SELECT COUNT(*),
+			  COALESCE(SUM(CASE WHEN winner_id = ? THEN 1 ELSE 0 END), 0),
+			  COALESCE(SUM(CASE WHEN winner_id = ? THEN 1 ELSE 0 END), 0)
+			  FROM games
+			  WHERE status IN (?, ?)
+			  AND ((player1_id = ? AND player2_id = ?) OR (player1_id = ? AND player2_id = ?))`
+
+	record := &models.HeadToHead{PlayerA: playerA, PlayerB: playerB}
+	err := db.conn.QueryRow(query, playerA, playerB, models.GameStatusFinished, models.GameStatusSurrendered,
+		playerA, playerB, playerB, playerA).Scan(&record.Games, &record.WinsA, &record.WinsB)
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// InsuranceSettingKey 下注保险配置在 bot_settings 中的键
+const InsuranceSettingKey = "insurance"
+
+// DefaultInsuranceSettings 默认保险配置：关闭，下注 1000 金币起可投保，保费 5%，输局退还一半
+func DefaultInsuranceSettings() *models.InsuranceSettings {
+	return &models.InsuranceSettings{
+		Enabled:     false,
+		Threshold:   utils.Coins(1000),
+		PremiumRate: 0.05,
+		RefundRate:  0.5,
+	}
+}
+
+// GetInsuranceSettings 获取下注保险配置，未设置时返回默认配置
+func (db *DB) GetInsuranceSettings() (*models.InsuranceSettings, error) {
+	value, ok, err := db.GetSetting(InsuranceSettingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := DefaultInsuranceSettings()
+	if !ok {
+		return settings, nil
+	}
+	if err := json.Unmarshal([]byte(value), settings); err != nil {
+		return nil, fmt.Errorf("解析保险配置失败: %v", err)
+	}
+	return settings, nil
+}
+
+// SetInsuranceSettings 保存下注保险配置
+func (db *DB) SetInsuranceSettings(settings *models.InsuranceSettings) error {
+	if settings.Threshold <= 0 {
+		return fmt.Errorf("投保门槛必须大于 0")
+	}
+	if settings.PremiumRate <= 0 || settings.PremiumRate >= 1 {
+		return fmt.Errorf("保险费率必须在 0 到 1 之间")
+	}
+	if settings.RefundRate <= 0 || settings.RefundRate > 1 {
+		return fmt.Errorf("赔付比例必须在 0 到 1 之间")
+	}
+
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return db.SetSetting(InsuranceSettingKey, string(data))
+}
+
+// BuyGameInsurance 发起者为等待中的对局购买保险，保险费只能使用可提现余额，返回扣费后的余额
+func (db *DB) BuyGameInsurance(gameID string, userID, premium, coverage int64) (int64, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE games SET insurance_premium = ?, insurance_coverage = ?, updated_at = ?
+			  WHERE id = ? AND player1_id = ? AND status = ? AND COALESCE(insurance_premium, 0) = 0`,
+		premium, coverage, time.Now(), gameID, userID, models.GameStatusWaiting)
+	if err != nil {
+		return 0, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return 0, err
+	} else if rowsAffected == 0 {
+		return 0, fmt.Errorf("对局已开始或已投保")
+	}
+
+	var balance int64
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return 0, fmt.Errorf("获取用户当前余额失败: %v", err)
+	}
+	if balance < premium {
+		return 0, fmt.Errorf("余额不足以支付保险费。当前余额: %s，需要: %s", utils.FormatAmount(balance), utils.FormatAmount(premium))
+	}
+
+	newBalance, err := db.debitBalanceInTx(tx, userID, premium)
+	if err != nil {
+		return 0, err
+	}
+
+	transaction := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      userID,
+		GameID:      &gameID,
+		Type:        models.TransactionTypeInsurance,
+		Amount:      -premium,
+		Balance:     newBalance,
+		Description: fmt.Sprintf("游戏 %s 下注保险", gameID),
+	}
+	if err := db.createTransactionInTx(tx, transaction); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return newBalance, nil
+}
+
+// refundGameInsuranceInTx 对局未结算即取消、超时或以平局结束时，在同一事务中退还发起者的保险费，未投保时不做处理
+func (db *DB) refundGameInsuranceInTx(tx *sql.Tx, gameID string) error {
+	var userID, premium int64
+	err := tx.QueryRow(`SELECT player1_id, COALESCE(insurance_premium, 0) FROM games WHERE id = ?`, gameID).Scan(&userID, &premium)
+	if err == sql.ErrNoRows || (err == nil && premium == 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE games SET insurance_premium = 0, insurance_coverage = 0 WHERE id = ?`, gameID); err != nil {
+		return err
+	}
+	balance, err := db.creditBalanceInTx(tx, userID, premium)
+	if err != nil {
+		return err
+	}
+
+	return db.createTransactionInTx(tx, &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      userID,
+		GameID:      &gameID,
+		Type:        models.TransactionTypeRefund,
+		Amount:      premium,
+		Balance:     balance,
+		Description: fmt.Sprintf("游戏 %s 未分胜负，退还保险费", gameID),
+	})
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// GetLeaderboard 按 since 之后赢得的金币排名，chatID 为 0 时统计全部群组
+func (db *DB) GetLeaderboard(chatID int64, since time.Time, limit int) ([]*models.LeaderboardEntry, error) {
+	query := `SELECT u.id, COALESCE(u.username, ''), COALESCE(u.first_name, ''), COALESCE(u.anonymous, 0),
+			  COUNT(*), COALESCE(SUM(g.bet_amount * 2 - g.commission), 0) AS winnings
+			  FROM games g JOIN users u ON u.id = g.winner_id
+			  WHERE g.status = ? AND g.created_at >= ?`
+	args := []interface{}{models.GameStatusFinished, since}
+	if chatID != 0 {
+		query += ` AND g.chat_id = ?`
+		args = append(args, chatID)
+	}
+	query += ` GROUP BY u.id ORDER BY winnings DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.LeaderboardEntry
+	for rows.Next() {
+		entry := &models.LeaderboardEntry{}
+		if err := rows.Scan(&entry.UserID, &entry.Username, &entry.FirstName, &entry.Anonymous,
+			&entry.Wins, &entry.Winnings); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetChatRanking 群组 since 之后已结束对局的玩家排名，按净盈亏排序，净盈亏按对局相关的流水合计
+func (db *DB) GetChatRanking(chatID int64, since time.Time, limit int) ([]*models.RankEntry, error) {
+	query := `SELECT u.id, COALESCE(u.username, ''), COALESCE(u.first_name, ''), COALESCE(u.anonymous, 0),
+			  COUNT(*) AS games,
+			  SUM(CASE WHEN g.winner_id = u.id THEN 1 ELSE 0 END) AS wins,
+			  COALESCE(SUM((SELECT SUM(t.amount) FROM transactions t WHERE t.game_id = g.id AND t.user_id = u.id)), 0) AS net
+			  FROM games g JOIN users u ON u.id = g.player1_id OR u.id = g.player2_id
+			  WHERE g.chat_id = ? AND g.status IN (?, ?) AND g.created_at >= ?
+			  GROUP BY u.id ORDER BY net DESC, wins DESC, games DESC LIMIT ?`
+
+	rows, err := db.conn.Query(query, chatID, models.GameStatusFinished, models.GameStatusSurrendered, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.RankEntry
+	for rows.Next() {
+		entry := &models.RankEntry{}
+		if err := rows.Scan(&entry.UserID, &entry.Username, &entry.FirstName, &entry.Anonymous,
+			&entry.Games, &entry.Wins, &entry.NetProfit); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// sqliteAutoVacuumIncremental PRAGMA auto_vacuum 的增量模式取值
+const sqliteAutoVacuumIncremental = 2
+
+// Size 数据库文件大小（页数 × 页大小，不含 WAL 文件），PostgreSQL 为整个数据库的大小
+func (db *DB) Size() (int64, error) {
+	if db.dialect == DialectPostgres {
+		var size int64
+		err := db.conn.QueryRow(`SELECT pg_database_size(current_database())`).Scan(&size)
+		return size, err
+	}
+
+	var pageCount, pageSize int64
+	if err := db.conn.QueryRow(`PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return 0, err
+	}
+	if err := db.conn.QueryRow(`PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0, err
+	}
+	return pageCount * pageSize, nil
+}
+
+// RunMaintenance 依次执行 WAL 检查点、增量 VACUUM（最多回收 vacuumPages 页）和 ANALYZE
+// 旧数据库未开启增量模式时，首次运行会切换模式并执行一次完整 VACUUM；PostgreSQL 只执行 VACUUM ANALYZE
+func (db *DB) RunMaintenance(ctx context.Context, vacuumPages int) (*models.MaintenanceRun, error) {
+	run := &models.MaintenanceRun{StartedAt: time.Now()}
+
+	size, err := db.Size()
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库大小失败: %v", err)
+	}
+	run.SizeBefore = size
+
+	if db.dialect == DialectPostgres {
+		return db.runPostgresMaintenance(ctx, run)
+	}
+
+	// PRAGMA 只作用于当前连接，维护步骤需在同一连接上执行
+	conn, err := db.conn.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %v", err)
+	}
+	defer conn.Close()
+
+	var busy, logFrames, checkpointed int
+	if err := conn.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &checkpointed); err != nil {
+		return nil, fmt.Errorf("WAL 检查点失败: %v", err)
+	}
+
+	var autoVacuum int
+	if err := conn.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&autoVacuum); err != nil {
+		return nil, fmt.Errorf("读取 auto_vacuum 失败: %v", err)
+	}
+	if autoVacuum != sqliteAutoVacuumIncremental {
+		if _, err := conn.ExecContext(ctx, `PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+			return nil, fmt.Errorf("切换增量 VACUUM 模式失败: %v", err)
+		}
+		if _, err := conn.ExecContext(ctx, `VACUUM`); err != nil {
+			return nil, fmt.Errorf("完整 VACUUM 失败: %v", err)
+		}
+		run.FullVacuum = true
+	}
+
+	freeBefore, err := freelistCount(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`PRAGMA incremental_vacuum(%d)`, vacuumPages)); err != nil {
+		return nil, fmt.Errorf("增量 VACUUM 失败: %v", err)
+	}
+	freeAfter, err := freelistCount(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	run.FreedPages = freeBefore - freeAfter
+
+	if _, err := conn.ExecContext(ctx, `ANALYZE`); err != nil {
+		return nil, fmt.Errorf("ANALYZE 失败: %v", err)
+	}
+
+	if size, err := db.Size(); err == nil {
+		run.SizeAfter = size
+	}
+	run.Duration = time.Since(run.StartedAt)
+	return run, nil
+}
+
+// runPostgresMaintenance PostgreSQL 由 autovacuum 回收空间，这里只补一次 VACUUM ANALYZE 更新统计信息
+func (db *DB) runPostgresMaintenance(ctx context.Context, run *models.MaintenanceRun) (*models.MaintenanceRun, error) {
+	if _, err := db.conn.ExecContext(ctx, `VACUUM (ANALYZE)`); err != nil {
+		return nil, fmt.Errorf("VACUUM ANALYZE 失败: %v", err)
+	}
+	if size, err := db.Size(); err == nil {
+		run.SizeAfter = size
+	}
+	run.Duration = time.Since(run.StartedAt)
+	return run, nil
+}
+
+// freelistCount 数据库中空闲页的数量
+func freelistCount(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var count int64
+	if err := conn.QueryRowContext(ctx, `PRAGMA freelist_count`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("读取空闲页数失败: %v", err)
+	}
+	return count, nil
+}
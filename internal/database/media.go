@@ -0,0 +1,33 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// GetMediaFileID 获取已上传素材的 file_id（file_id 仅对上传它的机器人有效）
+func (db *DB) GetMediaFileID(botID int64, name string) (string, bool, error) {
+	var fileID string
+	err := db.conn.QueryRow(`SELECT file_id FROM media_files WHERE bot_id = ? AND name = ?`, botID, name).Scan(&fileID)
+	if err == sql.ErrNoRows {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return fileID, true, nil
+}
+
+// SaveMediaFileID 保存素材的 file_id
+func (db *DB) SaveMediaFileID(botID int64, name, fileID string) error {
+	query := `INSERT INTO media_files (bot_id, name, file_id, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(bot_id, name) DO UPDATE SET file_id = excluded.file_id, updated_at = excluded.updated_at`
+	_, err := db.conn.Exec(query, botID, name, fileID, time.Now())
+	return err
+}
+
+// DeleteMediaFileID 删除失效的 file_id，下次使用时重新上传
+func (db *DB) DeleteMediaFileID(botID int64, name string) error {
+	_, err := db.conn.Exec(`DELETE FROM media_files WHERE bot_id = ? AND name = ?`, botID, name)
+	return err
+}
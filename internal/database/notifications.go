@@ -0,0 +1,34 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SetUserNotifications 开启或关闭用户的私信通知
+func (db *DB) SetUserNotifications(userID int64, enabled bool) error {
+	_, err := db.conn.Exec(`UPDATE users SET notifications_enabled = ?, updated_at = ? WHERE id = ?`,
+		enabled, time.Now(), userID)
+	return err
+}
+
+// SetUserDMBlocked 记录用户是否屏蔽了机器人（私信发送失败时标记，用户再次私聊时清除）
+func (db *DB) SetUserDMBlocked(userID int64, blocked bool) error {
+	_, err := db.conn.Exec(`UPDATE users SET dm_blocked = ?, updated_at = ? WHERE id = ?`,
+		blocked, time.Now(), userID)
+	return err
+}
+
+// CanDMUser 用户是否开启通知且未屏蔽机器人，用户不存在时返回 false
+func (db *DB) CanDMUser(userID int64) (bool, error) {
+	var enabled, blocked bool
+	err := db.conn.QueryRow(`SELECT COALESCE(notifications_enabled, 1), COALESCE(dm_blocked, 0) FROM users WHERE id = ?`,
+		userID).Scan(&enabled, &blocked)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return enabled && !blocked, nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// EnqueueOutbox 写入待发送的消息，用于不伴随对局状态变更的消息
+func (db *DB) EnqueueOutbox(messages []*models.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := db.enqueueOutboxInTx(tx, messages); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// enqueueOutboxInTx 在事务中写入待发送的消息，与对局状态一同提交或回滚
+func (db *DB) enqueueOutboxInTx(tx *sql.Tx, messages []*models.OutboxMessage) error {
+	now := time.Now()
+	for _, message := range messages {
+		result, err := tx.Exec(`INSERT INTO outbox_messages (chat_id, game_id, text, parse_mode, reply_markup, status, attempts, next_attempt, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, 0, ?, ?)`,
+			message.ChatID, message.GameID, message.Text, message.ParseMode, message.ReplyMarkup, models.OutboxPending, now, now)
+		if err != nil {
+			return err
+		}
+		if id, err := result.LastInsertId(); err == nil {
+			message.ID = id
+		}
+		message.Status = models.OutboxPending
+		message.NextAttempt = now
+		message.CreatedAt = now
+	}
+	return nil
+}
+
+// GetDueOutbox 获取已到发送时间的消息：每个群组只取最早一条待发送消息，前一条未送达时后续消息继续等待，保证群内顺序
+func (db *DB) GetDueOutbox(now time.Time, limit int) ([]*models.OutboxMessage, error) {
+	rows, err := db.conn.Query(`SELECT o.id, o.chat_id, o.game_id, o.text, o.parse_mode, o.reply_markup, o.status,
+			  o.attempts, o.last_error, o.next_attempt, o.created_at
+			  FROM outbox_messages o
+			  WHERE o.status = ? AND o.next_attempt <= ?
+			  AND NOT EXISTS (SELECT 1 FROM outbox_messages p WHERE p.chat_id = o.chat_id AND p.status = ? AND p.id < o.id)
+			  ORDER BY o.id ASC LIMIT ?`,
+		models.OutboxPending, now, models.OutboxPending, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var messages []*models.OutboxMessage
+	for rows.Next() {
+		message := &models.OutboxMessage{}
+		if err := rows.Scan(&message.ID, &message.ChatID, &message.GameID, &message.Text, &message.ParseMode,
+			&message.ReplyMarkup, &message.Status, &message.Attempts, &message.LastError,
+			&message.NextAttempt, &message.CreatedAt); err != nil {
+			return nil, err
+		}
+		messages = append(messages, message)
+	}
+	return messages, rows.Err()
+}
+
+// MarkOutboxSent 标记消息已送达
+func (db *DB) MarkOutboxSent(id int64) error {
+	_, err := db.conn.Exec(`UPDATE outbox_messages SET status = ?, attempts = attempts + 1, last_error = '', sent_at = ? WHERE id = ?`,
+		models.OutboxSent, time.Now(), id)
+	return err
+}
+
+// RetryOutbox 发送失败，记录错误并在 next 之后重试
+func (db *DB) RetryOutbox(id int64, errMsg string, next time.Time) error {
+	_, err := db.conn.Exec(`UPDATE outbox_messages SET attempts = attempts + 1, last_error = ?, next_attempt = ? WHERE id = ?`,
+		errMsg, next, id)
+	return err
+}
+
+// FailOutbox 放弃发送（重试次数用尽或消息无法送达），同群组的后续消息继续投递
+func (db *DB) FailOutbox(id int64, errMsg string) error {
+	_, err := db.conn.Exec(`UPDATE outbox_messages SET status = ?, attempts = attempts + 1, last_error = ? WHERE id = ?`,
+		models.OutboxFailed, errMsg, id)
+	return err
+}
+
+// CountPendingOutbox 统计尚未送达的消息数
+func (db *DB) CountPendingOutbox() (int, error) {
+	var count int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM outbox_messages WHERE status = ?`, models.OutboxPending).Scan(&count)
+	return count, err
+}
+
+// PurgeSentOutbox 删除 before 之前已送达的消息，返回删除数量
+func (db *DB) PurgeSentOutbox(before time.Time) (int64, error) {
+	result, err := db.conn.Exec(`DELETE FROM outbox_messages WHERE status = ? AND sent_at < ?`, models.OutboxSent, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// postgresDriverName 包装后的 PostgreSQL 驱动名，语句在执行前按 DialectPostgres 改写
+const postgresDriverName = "dicebot-postgres"
+
+// postgresBaseDrivers 可被包装的 PostgreSQL 驱动，按顺序查找已注册的驱动；
+// 默认使用随程序构建的 pgx，也可改为引入 lib/pq 注册的 postgres 驱动
+var postgresBaseDrivers = []string{"pgx", "postgres"}
+
+var (
+	createSerialTable = regexp.MustCompile(`(?is)^\s*CREATE TABLE IF NOT EXISTS (\w+) \(.*\bAUTOINCREMENT\b`)
+	insertInto        = regexp.MustCompile(`(?is)^\s*INSERT (?:OR IGNORE )?INTO (\w+)\b`)
+)
+
+func init() {
+	sql.Register(postgresDriverName, &postgresDriver{})
+}
+
+// postgresDriver 包装已注册的 PostgreSQL 驱动：改写 SQLite 写法、把布尔参数存为 0/1，
+// 并为自增主键的表补上 RETURNING id 以支持 LastInsertId
+type postgresDriver struct {
+	// 建表时记录的自增主键表
+	serialTables sync.Map
+}
+
+// postgresBaseDriver 查找已注册的 PostgreSQL 驱动
+func postgresBaseDriver() (driver.Driver, error) {
+	registered := sql.Drivers()
+	for _, name := range postgresBaseDrivers {
+		for _, r := range registered {
+			if r != name {
+				continue
+			}
+			db, err := sql.Open(name, "")
+			if err != nil {
+				return nil, err
+			}
+			defer db.Close()
+			return db.Driver(), nil
+		}
+	}
+	return nil, fmt.Errorf("未注册 PostgreSQL 驱动（%s），请在构建时引入", strings.Join(postgresBaseDrivers, " 或 "))
+}
+
+func (d *postgresDriver) Open(dsn string) (driver.Conn, error) {
+	base, err := postgresBaseDriver()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := base.Open(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresConn{conn: conn, driver: d}, nil
+}
+
+// translate 改写语句，返回是否需要通过 RETURNING id 取得自增主键
+func (d *postgresDriver) translate(query string) (string, bool) {
+	if m := createSerialTable.FindStringSubmatch(query); m != nil {
+		d.serialTables.Store(m[1], true)
+	}
+
+	translated := DialectPostgres.Translate(query)
+	if m := insertInto.FindStringSubmatch(query); m != nil && !strings.Contains(strings.ToUpper(query), "RETURNING") {
+		if _, ok := d.serialTables.Load(m[1]); ok {
+			return translated + " RETURNING id", true
+		}
+	}
+	return translated, false
+}
+
+// postgresConn 包装底层连接
+type postgresConn struct {
+	conn   driver.Conn
+	driver *postgresDriver
+}
+
+func (c *postgresConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *postgresConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	translated, returning := c.driver.translate(query)
+
+	var stmt driver.Stmt
+	var err error
+	if preparer, ok := c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = preparer.PrepareContext(ctx, translated)
+	} else {
+		stmt, err = c.conn.Prepare(translated)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &postgresStmt{stmt: stmt, returning: returning}, nil
+}
+
+func (c *postgresConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *postgresConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *postgresConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.conn.Begin()
+}
+
+// postgresStmt 包装预处理语句
+type postgresStmt struct {
+	stmt      driver.Stmt
+	returning bool
+}
+
+func (s *postgresStmt) Close() error {
+	return s.stmt.Close()
+}
+
+func (s *postgresStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *postgresStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *postgresStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *postgresStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	args = integerBools(args)
+	if s.returning {
+		rows, err := s.query(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		return insertResult(rows)
+	}
+
+	if execer, ok := s.stmt.(driver.StmtExecContext); ok {
+		return execer.ExecContext(ctx, args)
+	}
+	return s.stmt.Exec(plainValues(args))
+}
+
+func (s *postgresStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.query(ctx, integerBools(args))
+}
+
+func (s *postgresStmt) query(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	if queryer, ok := s.stmt.(driver.StmtQueryContext); ok {
+		return queryer.QueryContext(ctx, args)
+	}
+	return s.stmt.Query(plainValues(args))
+}
+
+// postgresResult 由 RETURNING id 得到的执行结果
+type postgresResult struct {
+	lastID   int64
+	affected int64
+}
+
+func (r postgresResult) LastInsertId() (int64, error) {
+	return r.lastID, nil
+}
+
+func (r postgresResult) RowsAffected() (int64, error) {
+	return r.affected, nil
+}
+
+// insertResult 读取 RETURNING id 返回的行
+func insertResult(rows driver.Rows) (driver.Result, error) {
+	defer rows.Close()
+
+	var result postgresResult
+	dest := make([]driver.Value, len(rows.Columns()))
+	for {
+		err := rows.Next(dest)
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		result.affected++
+		switch id := dest[0].(type) {
+		case int64:
+			result.lastID = id
+		case int32:
+			result.lastID = int64(id)
+		default:
+			return nil, fmt.Errorf("无法识别的自增主键类型 %T", dest[0])
+		}
+	}
+}
+
+// integerBools 整数列沿用 SQLite 的 0/1 存储布尔值，参数中的布尔值需转换为整数
+func integerBools(args []driver.NamedValue) []driver.NamedValue {
+	for i, arg := range args {
+		if b, ok := arg.Value.(bool); ok {
+			if b {
+				args[i].Value = int64(1)
+			} else {
+				args[i].Value = int64(0)
+			}
+		}
+	}
+	return args
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	named := make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{Ordinal: i + 1, Value: v}
+	}
+	return named
+}
+
+func plainValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// SetUserAnonymous 设置用户是否在排行榜、播报和大奖频道中匿名显示
+func (db *DB) SetUserAnonymous(userID int64, anonymous bool) error {
+	_, err := db.conn.Exec(`UPDATE users SET anonymous = ?, updated_at = ? WHERE id = ?`,
+		anonymous, time.Now(), userID)
+	return err
+}
+
+// IsUserAnonymous 用户是否选择匿名显示，用户不存在时返回 false
+func (db *DB) IsUserAnonymous(userID int64) (bool, error) {
+	var anonymous bool
+	err := db.conn.QueryRow(`SELECT COALESCE(anonymous, 0) FROM users WHERE id = ?`, userID).Scan(&anonymous)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return anonymous, err
+}
+
+// SetUserCelebrateStreaks 设置用户是否开启跨群连胜播报
+func (db *DB) SetUserCelebrateStreaks(userID int64, enabled bool) error {
+	_, err := db.conn.Exec(`UPDATE users SET celebrate_streaks = ?, updated_at = ? WHERE id = ?`,
+		enabled, time.Now(), userID)
+	return err
+}
+
+// GetUserPreferences 获取用户的私信通知、匿名显示和跨群连胜播报设置
+func (db *DB) GetUserPreferences(userID int64) (notifications, anonymous, celebrate bool, err error) {
+	err = db.conn.QueryRow(`SELECT COALESCE(notifications_enabled, 1), COALESCE(anonymous, 0), COALESCE(celebrate_streaks, 0)
+			  FROM users WHERE id = ?`, userID).Scan(&notifications, &anonymous, &celebrate)
+	if err == sql.ErrNoRows {
+		return true, false, false, nil
+	}
+	return notifications, anonymous, celebrate, err
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// RevertJoinWithRefund 加入者未在限定时间内确认准备：在同一事务中撤销加入、恢复等待，并向加入者退款。
+// 对局已不在等待准备状态时返回 false
+func (db *DB) RevertJoinWithRefund(gameID string, player2ID int64, transaction *models.Transaction) (bool, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var current int64
+	err = tx.QueryRow(`SELECT COUNT(*) FROM games WHERE id = ? AND status = ? AND player2_id = ?`,
+		gameID, models.GameStatusReady, player2ID).Scan(&current)
+	if err != nil {
+		return false, err
+	}
+	if current == 0 {
+		return false, nil
+	}
+
+	balance, err := db.creditBalanceInTx(tx, player2ID, transaction.Amount)
+	if err != nil {
+		return false, err
+	}
+	transaction.Balance = balance
+	if err := db.createTransactionInTx(tx, transaction); err != nil {
+		return false, err
+	}
+	if err := db.restoreBonusStakeInTx(tx, gameID, player2ID, -1); err != nil {
+		return false, err
+	}
+
+	// 退回赠送金额需要读取对局上记录的占用金额，撤销加入放在最后
+	if _, err := tx.Exec(`UPDATE games SET player2_id = NULL, player2_bonus_stake = 0, status = ?, updated_at = ? WHERE id = ?`,
+		models.GameStatusWaiting, time.Now(), gameID); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// GetStaleReadyGameIDs 获取在 before 之前进入等待准备、仍未开局的对局（如进程重启前加入的对局）
+func (db *DB) GetStaleReadyGameIDs(before time.Time) ([]string, error) {
+	rows, err := db.conn.Query(`SELECT id FROM games WHERE status = ? AND updated_at < ? ORDER BY updated_at ASC`,
+		models.GameStatusReady, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,186 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// ErrAccountFrozen 账户因对账差异或风控标记被冻结，管理员处理前不能下注或提现
+var ErrAccountFrozen = errors.New("账户已暂时冻结等待管理员审核，请联系管理员")
+
+// ErrReconcileIssueNotOpen 对账差异不存在或已处理
+var ErrReconcileIssueNotOpen = errors.New("对账差异不存在或已处理")
+
+// reconcileIssueColumns 查询对账差异的字段
+const reconcileIssueColumns = `id, user_id, expected, actual, difference, status, frozen, reviewer, note, created_at, reviewed_at`
+
+// LedgerMismatches 按流水重算每个用户的余额并与实际余额（现金 + 赠送）比较，返回核对的用户数和不一致的用户。
+// 赠送转现金只是在两种余额之间划转，不计入重算；管理员确认过的差额计入基线
+func (db *DB) LedgerMismatches() (int, []*models.ReconcileIssue, error) {
+	rows, err := db.conn.Query(`SELECT u.id, u.balance + COALESCE(u.bonus_balance, 0),
+			  COALESCE(l.total, 0) + COALESCE(a.total, 0)
+			  FROM users u
+			  LEFT JOIN (SELECT user_id, SUM(amount) AS total FROM transactions WHERE type != ? GROUP BY user_id) l
+			  ON l.user_id = u.id
+			  LEFT JOIN (SELECT user_id, SUM(difference) AS total FROM reconcile_issues WHERE status = ? GROUP BY user_id) a
+			  ON a.user_id = u.id
+			  WHERE u.id != 0`,
+		models.TransactionTypeBonusConvert, models.ReconcileStatusAccepted)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	checked := 0
+	var mismatches []*models.ReconcileIssue
+	for rows.Next() {
+		issue := &models.ReconcileIssue{}
+		if err := rows.Scan(&issue.UserID, &issue.Actual, &issue.Expected); err != nil {
+			return 0, nil, err
+		}
+		checked++
+		if issue.Actual != issue.Expected {
+			issue.Difference = issue.Actual - issue.Expected
+			mismatches = append(mismatches, issue)
+		}
+	}
+	return checked, mismatches, rows.Err()
+}
+
+// RecordReconcileIssue 记录对账差异，每个用户只保留一条待处理记录。
+// 待处理记录的差额未变化时返回 false；freeze 为 true 时同时冻结账户
+func (db *DB) RecordReconcileIssue(issue *models.ReconcileIssue, freeze bool) (bool, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var existingID, existingDiff int64
+	var existingFrozen bool
+	err = tx.QueryRow(`SELECT id, difference, frozen FROM reconcile_issues WHERE user_id = ? AND status = ?`,
+		issue.UserID, models.ReconcileStatusOpen).Scan(&existingID, &existingDiff, &existingFrozen)
+	switch {
+	case err == sql.ErrNoRows:
+		issue.Status = models.ReconcileStatusOpen
+		issue.Frozen = freeze
+		issue.CreatedAt = time.Now()
+		result, err := tx.Exec(`INSERT INTO reconcile_issues (user_id, expected, actual, difference, status, frozen, created_at)
+				  VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			issue.UserID, issue.Expected, issue.Actual, issue.Difference, issue.Status, issue.Frozen, issue.CreatedAt)
+		if err != nil {
+			return false, err
+		}
+		if issue.ID, err = result.LastInsertId(); err != nil {
+			return false, err
+		}
+	case err != nil:
+		return false, err
+	case existingDiff == issue.Difference:
+		return false, nil
+	default:
+		issue.ID = existingID
+		issue.Status = models.ReconcileStatusOpen
+		issue.Frozen = existingFrozen || freeze
+		if _, err := tx.Exec(`UPDATE reconcile_issues SET expected = ?, actual = ?, difference = ?, frozen = ? WHERE id = ?`,
+			issue.Expected, issue.Actual, issue.Difference, issue.Frozen, issue.ID); err != nil {
+			return false, err
+		}
+	}
+
+	if freeze {
+		if _, err := tx.Exec(`UPDATE users SET frozen = 1, updated_at = ? WHERE id = ?`, time.Now(), issue.UserID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// ResolveReconcileIssue 处理待核对的差异，用户没有其他冻结中的风控事件时解冻账户。
+// status 为 accepted 时差额计入对账基线，为 resolved 时表示余额已手动修正
+func (db *DB) ResolveReconcileIssue(id int64, status, reviewer, note string) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	err = tx.QueryRow(`SELECT user_id FROM reconcile_issues WHERE id = ? AND status = ?`,
+		id, models.ReconcileStatusOpen).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return ErrReconcileIssueNotOpen
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE reconcile_issues SET status = ?, reviewer = ?, note = ?, reviewed_at = ? WHERE id = ?`,
+		status, reviewer, note, now, id); err != nil {
+		return err
+	}
+	if err := unfreezeUserInTx(tx, userID, now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetReconcileIssue 获取对账差异，不存在时返回 nil
+func (db *DB) GetReconcileIssue(id int64) (*models.ReconcileIssue, error) {
+	issues, err := db.queryReconcileIssues(`SELECT `+reconcileIssueColumns+` FROM reconcile_issues WHERE id = ?`, id)
+	if err != nil || len(issues) == 0 {
+		return nil, err
+	}
+	return issues[0], nil
+}
+
+// GetReconcileIssues 按状态获取对账差异，status 为空时不限状态，按发现时间倒序
+func (db *DB) GetReconcileIssues(status string, limit int) ([]*models.ReconcileIssue, error) {
+	query := `SELECT ` + reconcileIssueColumns + ` FROM reconcile_issues`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+	return db.queryReconcileIssues(query, args...)
+}
+
+// IsUserFrozen 用户是否因对账差异或风控标记被冻结
+func (db *DB) IsUserFrozen(userID int64) (bool, error) {
+	var frozen bool
+	err := db.conn.QueryRow(`SELECT COALESCE(frozen, 0) FROM users WHERE id = ?`, userID).Scan(&frozen)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return frozen, err
+}
+
+// queryReconcileIssues 查询并扫描对账差异
+func (db *DB) queryReconcileIssues(query string, args ...interface{}) ([]*models.ReconcileIssue, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var issues []*models.ReconcileIssue
+	for rows.Next() {
+		issue := &models.ReconcileIssue{}
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&issue.ID, &issue.UserID, &issue.Expected, &issue.Actual, &issue.Difference, &issue.Status,
+			&issue.Frozen, &issue.Reviewer, &issue.Note, &issue.CreatedAt, &reviewedAt); err != nil {
+			return nil, err
+		}
+		if reviewedAt.Valid {
+			issue.ReviewedAt = &reviewedAt.Time
+		}
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
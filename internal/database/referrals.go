@@ -0,0 +1,162 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+var (
+	// ErrSelfReferral 不能通过自己的邀请链接注册
+	ErrSelfReferral = errors.New("不能使用自己的邀请链接")
+	// ErrReferrerNotFound 邀请人不存在或已注销
+	ErrReferrerNotFound = errors.New("邀请链接无效")
+	// ErrAlreadyReferred 用户已有邀请人，或已有交易记录不再是新用户
+	ErrAlreadyReferred = errors.New("邀请奖励仅限新用户领取")
+)
+
+// CreateReferral 记录被邀请人通过邀请链接注册并发放注册奖励，流水奖励按本次的设置在下注达标后发放。
+// 被邀请人必须是尚未产生任何交易记录的新用户，每人只能被邀请一次
+func (db *DB) CreateReferral(refereeID, referrerID int64, rewards *models.ReferralRewards) (*models.Referral, error) {
+	if refereeID == referrerID {
+		return nil, ErrSelfReferral
+	}
+
+	tx, err := db.BeginTx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var referrers int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM users WHERE id = ? AND deleted_at IS NULL`, referrerID).Scan(&referrers); err != nil {
+		return nil, fmt.Errorf("获取邀请人失败: %v", err)
+	}
+	if referrers == 0 {
+		return nil, ErrReferrerNotFound
+	}
+
+	var existing int
+	if err := tx.QueryRow(`SELECT (SELECT COUNT(*) FROM referrals WHERE referee_id = ?) + (SELECT COUNT(*) FROM transactions WHERE user_id = ?)`,
+		refereeID, refereeID).Scan(&existing); err != nil {
+		return nil, fmt.Errorf("检查邀请记录失败: %v", err)
+	}
+	if existing > 0 {
+		return nil, ErrAlreadyReferred
+	}
+
+	referral := &models.Referral{
+		RefereeID:          refereeID,
+		ReferrerID:         referrerID,
+		WagerThreshold:     rewards.WagerThreshold,
+		ReferrerWagerBonus: rewards.WagerReferrer,
+		RefereeWagerBonus:  rewards.WagerReferee,
+		ReferrerEarned:     rewards.SignupReferrer,
+		WageringMultiplier: rewards.WageringMultiplier,
+		CreatedAt:          time.Now(),
+	}
+	// 没有流水奖励时不再累计流水
+	if referral.ReferrerWagerBonus <= 0 && referral.RefereeWagerBonus <= 0 {
+		referral.WagerRewardedAt = &referral.CreatedAt
+	}
+	_, err = tx.Exec(`INSERT INTO referrals (referee_id, referrer_id, wager_threshold, referrer_wager_bonus, referee_wager_bonus,
+			  referrer_earned, wagering_multiplier, created_at, wager_rewarded_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		referral.RefereeID, referral.ReferrerID, referral.WagerThreshold, referral.ReferrerWagerBonus, referral.RefereeWagerBonus,
+		referral.ReferrerEarned, referral.WageringMultiplier, referral.CreatedAt, referral.WagerRewardedAt)
+	if err != nil {
+		return nil, fmt.Errorf("记录邀请关系失败: %v", err)
+	}
+
+	if err := db.creditReferralBonusInTx(tx, refereeID, rewards.SignupReferee, rewards.WageringMultiplier, "邀请注册奖励"); err != nil {
+		return nil, err
+	}
+	if err := db.creditReferralBonusInTx(tx, referrerID, rewards.SignupReferrer, rewards.WageringMultiplier,
+		fmt.Sprintf("邀请用户 %d 注册奖励", refereeID)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return referral, nil
+}
+
+// GetReferral 获取用户的邀请关系，没有邀请人时返回 nil
+func (db *DB) GetReferral(refereeID int64) (*models.Referral, error) {
+	referral := &models.Referral{}
+	var rewardedAt sql.NullTime
+	err := db.conn.QueryRow(`SELECT referee_id, referrer_id, wagered, wager_threshold, referrer_wager_bonus, referee_wager_bonus,
+			  referrer_earned, COALESCE(wagering_multiplier, 1), created_at, wager_rewarded_at FROM referrals WHERE referee_id = ?`, refereeID).
+		Scan(&referral.RefereeID, &referral.ReferrerID, &referral.Wagered, &referral.WagerThreshold, &referral.ReferrerWagerBonus,
+			&referral.RefereeWagerBonus, &referral.ReferrerEarned, &referral.WageringMultiplier, &referral.CreatedAt, &rewardedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rewardedAt.Valid {
+		referral.WagerRewardedAt = &rewardedAt.Time
+	}
+	return referral, nil
+}
+
+// GetReferralSummary 统计邀请人邀请的人数、流水达标的人数和获得的奖励
+func (db *DB) GetReferralSummary(referrerID int64) (*models.ReferralSummary, error) {
+	summary := &models.ReferralSummary{}
+	err := db.conn.QueryRow(`SELECT COUNT(*),
+			  COALESCE(SUM(CASE WHEN wager_rewarded_at IS NOT NULL AND (referrer_wager_bonus > 0 OR referee_wager_bonus > 0) THEN 1 ELSE 0 END), 0),
+			  COALESCE(SUM(referrer_earned), 0)
+			  FROM referrals WHERE referrer_id = ?`, referrerID).
+		Scan(&summary.Invited, &summary.Qualified, &summary.Earned)
+	if err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
+
+// recordReferralWagerInTx 累计被邀请人的下注流水，首次达到门槛时向邀请人和被邀请人发放流水奖励
+func (db *DB) recordReferralWagerInTx(tx *sql.Tx, refereeID, amount int64) error {
+	if amount <= 0 {
+		return nil
+	}
+
+	referral := &models.Referral{}
+	err := tx.QueryRow(`SELECT referrer_id, wagered, wager_threshold, referrer_wager_bonus, referee_wager_bonus, COALESCE(wagering_multiplier, 1)
+			  FROM referrals WHERE referee_id = ? AND wager_rewarded_at IS NULL`, refereeID).
+		Scan(&referral.ReferrerID, &referral.Wagered, &referral.WagerThreshold, &referral.ReferrerWagerBonus, &referral.RefereeWagerBonus,
+			&referral.WageringMultiplier)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	wagered := referral.Wagered + amount
+	if wagered < referral.WagerThreshold {
+		_, err := tx.Exec(`UPDATE referrals SET wagered = ? WHERE referee_id = ?`, wagered, refereeID)
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE referrals SET wagered = ?, wager_rewarded_at = ?, referrer_earned = referrer_earned + ? WHERE referee_id = ?`,
+		wagered, time.Now(), referral.ReferrerWagerBonus, refereeID); err != nil {
+		return err
+	}
+	if err := db.creditReferralBonusInTx(tx, refereeID, referral.RefereeWagerBonus, referral.WageringMultiplier, "邀请流水达标奖励"); err != nil {
+		return err
+	}
+	return db.creditReferralBonusInTx(tx, referral.ReferrerID, referral.ReferrerWagerBonus, referral.WageringMultiplier,
+		fmt.Sprintf("邀请用户 %d 流水达标奖励", refereeID))
+}
+
+// creditReferralBonusInTx 向用户赠送余额发放邀请奖励，完成 multiplier 倍流水后转为可提现余额
+func (db *DB) creditReferralBonusInTx(tx *sql.Tx, userID, amount int64, multiplier float64, description string) error {
+	if amount <= 0 {
+		return nil
+	}
+	return db.creditBonusInTx(tx, userID, referralBonusCampaign, amount, multiplier, models.TransactionTypeReferralBonus, description)
+}
@@ -0,0 +1,80 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// SetChatRevenueShare 设置群组的手续费分成比例（0-1）
+func (db *DB) SetChatRevenueShare(chatID int64, share float64) error {
+	if share < 0 || share > 1 {
+		return fmt.Errorf("分成比例必须在 0 到 1 之间")
+	}
+
+	query := `INSERT INTO chats (id, revenue_share, joined_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET revenue_share = excluded.revenue_share, updated_at = excluded.updated_at`
+	now := time.Now()
+	_, err := db.conn.Exec(query, chatID, share, now, now)
+	return err
+}
+
+// GetChatRevenueShare 获取群组的手续费分成比例，未设置时为 0
+func (db *DB) GetChatRevenueShare(chatID int64) (float64, error) {
+	var share float64
+	err := db.conn.QueryRow(`SELECT COALESCE(revenue_share, 0) FROM chats WHERE id = ?`, chatID).Scan(&share)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return share, err
+}
+
+// applyChatShareInTx 根据结算交易中的分成记录更新对局的群组分成金额，并计入群组基金
+func (db *DB) applyChatShareInTx(tx *sql.Tx, gameID string, transactions []*models.Transaction) error {
+	var chatShare int64
+	for _, transaction := range transactions {
+		if transaction.Type == models.TransactionTypeRevenueShare {
+			chatShare += transaction.Amount
+		}
+	}
+	if chatShare == 0 {
+		return nil
+	}
+
+	if _, err := tx.Exec(`UPDATE games SET chat_share = ? WHERE id = ?`, chatShare, gameID); err != nil {
+		return err
+	}
+
+	_, err := tx.Exec(`UPDATE chats SET fund_balance = COALESCE(fund_balance, 0) + ?, updated_at = ?
+			  WHERE id = (SELECT chat_id FROM games WHERE id = ?)`, chatShare, time.Now(), gameID)
+	return err
+}
+
+// GetRevenueShareReport 统计 since 之后各群组的手续费及分成
+func (db *DB) GetRevenueShareReport(since time.Time) ([]*models.RevenueShareReport, error) {
+	query := `SELECT g.chat_id, COALESCE(c.title, ''), COALESCE(c.revenue_share, 0), COUNT(*),
+			  COALESCE(SUM(g.commission), 0), COALESCE(SUM(g.chat_share), 0)
+			  FROM games g LEFT JOIN chats c ON c.id = g.chat_id
+			  WHERE g.created_at >= ? AND g.commission > 0
+			  GROUP BY g.chat_id, c.title, c.revenue_share
+			  ORDER BY SUM(g.chat_share) DESC, SUM(g.commission) DESC`
+
+	rows, err := db.conn.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reports []*models.RevenueShareReport
+	for rows.Next() {
+		report := &models.RevenueShareReport{}
+		if err := rows.Scan(&report.ChatID, &report.Title, &report.Share, &report.Games,
+			&report.Commission, &report.ChatShare); err != nil {
+			return nil, err
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
@@ -0,0 +1,180 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// ErrRiskEventNotOpen 风控事件不存在或已处理
+var ErrRiskEventNotOpen = errors.New("风控事件不存在或已处理")
+
+// riskEventColumns 查询风控事件的字段
+const riskEventColumns = `id, user_id, counterparty_id, rule, game_id, detail, status, frozen, reviewer, note, created_at, reviewed_at`
+
+// GetPairActivity 统计两名玩家自 since 起结算（含认输）的交手记录，以及 playerA 从 playerB 处净赢得的下注额。
+// 与 GetHeadToHead 不同，记录中的 PlayerA 即第一个参数
+func (db *DB) GetPairActivity(playerA, playerB int64, since time.Time) (*models.HeadToHead, int64, error) {
+	query := `SELECT COUNT(*),
+			  COALESCE(SUM(CASE WHEN winner_id = ? THEN 1 ELSE 0 END), 0),
+			  COALESCE(SUM(CASE WHEN winner_id = ? THEN 1 ELSE 0 END), 0),
+			  COALESCE(SUM(CASE WHEN winner_id = ? THEN bet_amount WHEN winner_id = ? THEN -bet_amount ELSE 0 END), 0)
+			  FROM games
+			  WHERE status IN (?, ?) AND updated_at >= ?
+			  AND ((player1_id = ? AND player2_id = ?) OR (player1_id = ? AND player2_id = ?))`
+
+	record := &models.HeadToHead{PlayerA: playerA, PlayerB: playerB}
+	var net int64
+	err := db.conn.QueryRow(query, playerA, playerB, playerA, playerB,
+		models.GameStatusFinished, models.GameStatusSurrendered, since,
+		playerA, playerB, playerB, playerA).Scan(&record.Games, &record.WinsA, &record.WinsB, &net)
+	if err != nil {
+		return nil, 0, err
+	}
+	return record, net, nil
+}
+
+// GetWinRecord 统计用户自 since 起分出胜负（不含平局）的对局数和胜场
+func (db *DB) GetWinRecord(userID int64, since time.Time) (int, int, error) {
+	var games, wins int
+	err := db.conn.QueryRow(`SELECT COUNT(*), COALESCE(SUM(CASE WHEN winner_id = ? THEN 1 ELSE 0 END), 0)
+			  FROM games
+			  WHERE status IN (?, ?) AND winner_id IS NOT NULL AND updated_at >= ?
+			  AND (player1_id = ? OR player2_id = ?)`,
+		userID, models.GameStatusFinished, models.GameStatusSurrendered, since, userID, userID).Scan(&games, &wins)
+	return games, wins, err
+}
+
+// RecordRiskEvent 记录风控事件，同一用户、规则和对手只保留一条待审核记录，自 reviewedSince 起审核过的也不再重复标记，
+// 未记录时返回 false。freeze 为 true 时同时冻结账户
+func (db *DB) RecordRiskEvent(event *models.RiskEvent, freeze bool, reviewedSince time.Time) (bool, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var existing int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM risk_events WHERE user_id = ? AND rule = ? AND counterparty_id = ?
+			  AND (status = ? OR reviewed_at >= ?)`,
+		event.UserID, event.Rule, event.CounterpartyID, models.RiskStatusOpen, reviewedSince).Scan(&existing); err != nil {
+		return false, err
+	}
+	if existing > 0 {
+		return false, nil
+	}
+
+	event.Status = models.RiskStatusOpen
+	event.Frozen = freeze
+	event.CreatedAt = time.Now()
+	result, err := tx.Exec(`INSERT INTO risk_events (user_id, counterparty_id, rule, game_id, detail, status, frozen, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		event.UserID, event.CounterpartyID, event.Rule, event.GameID, event.Detail, event.Status, event.Frozen, event.CreatedAt)
+	if err != nil {
+		return false, err
+	}
+	if event.ID, err = result.LastInsertId(); err != nil {
+		return false, err
+	}
+
+	if freeze {
+		if _, err := tx.Exec(`UPDATE users SET frozen = 1, updated_at = ? WHERE id = ?`, event.CreatedAt, event.UserID); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// ResolveRiskEvent 处理待审核的风控事件。status 为 dismissed 时视为误报，
+// 用户没有其他冻结中的风控事件或对账差异时解冻账户；为 confirmed 时账户保持冻结
+func (db *DB) ResolveRiskEvent(id int64, status, reviewer, note string) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	err = tx.QueryRow(`SELECT user_id FROM risk_events WHERE id = ? AND status = ?`, id, models.RiskStatusOpen).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return ErrRiskEventNotOpen
+	}
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec(`UPDATE risk_events SET status = ?, reviewer = ?, note = ?, reviewed_at = ? WHERE id = ?`,
+		status, reviewer, note, now, id); err != nil {
+		return err
+	}
+	if status == models.RiskStatusDismissed {
+		if err := unfreezeUserInTx(tx, userID, now); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetRiskEvent 获取风控事件，不存在时返回 nil
+func (db *DB) GetRiskEvent(id int64) (*models.RiskEvent, error) {
+	events, err := db.queryRiskEvents(`SELECT `+riskEventColumns+` FROM risk_events WHERE id = ?`, id)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return events[0], nil
+}
+
+// GetRiskEvents 按状态获取风控事件，status 为空时不限状态，按标记时间倒序
+func (db *DB) GetRiskEvents(status string, limit int) ([]*models.RiskEvent, error) {
+	query := `SELECT ` + riskEventColumns + ` FROM risk_events`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit)
+	return db.queryRiskEvents(query, args...)
+}
+
+// unfreezeUserInTx 用户没有其他冻结中的风控事件或对账差异时解冻账户
+func unfreezeUserInTx(tx *sql.Tx, userID int64, now time.Time) error {
+	var pending int
+	if err := tx.QueryRow(`SELECT (SELECT COUNT(*) FROM risk_events WHERE user_id = ? AND status = ? AND frozen = 1)
+			  + (SELECT COUNT(*) FROM reconcile_issues WHERE user_id = ? AND status = ? AND frozen = 1)`,
+		userID, models.RiskStatusOpen, userID, models.ReconcileStatusOpen).Scan(&pending); err != nil {
+		return err
+	}
+	if pending > 0 {
+		return nil
+	}
+	_, err := tx.Exec(`UPDATE users SET frozen = 0, updated_at = ? WHERE id = ?`, now, userID)
+	return err
+}
+
+// queryRiskEvents 查询并扫描风控事件
+func (db *DB) queryRiskEvents(query string, args ...interface{}) ([]*models.RiskEvent, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.RiskEvent
+	for rows.Next() {
+		event := &models.RiskEvent{}
+		var reviewedAt sql.NullTime
+		if err := rows.Scan(&event.ID, &event.UserID, &event.CounterpartyID, &event.Rule, &event.GameID, &event.Detail,
+			&event.Status, &event.Frozen, &event.Reviewer, &event.Note, &event.CreatedAt, &reviewedAt); err != nil {
+			return nil, err
+		}
+		if reviewedAt.Valid {
+			event.ReviewedAt = &reviewedAt.Time
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"fmt"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// SandboxSettingKey 标记数据库为沙盒数据库的配置键，写入后不再清除
+const SandboxSettingKey = "sandbox"
+
+// IsSandbox 数据库是否为沙盒数据库，其中的余额均为练习金币
+func (db *DB) IsSandbox() (bool, error) {
+	_, ok, err := db.GetSetting(SandboxSettingKey)
+	return ok, err
+}
+
+// MarkSandbox 将数据库标记为沙盒数据库
+func (db *DB) MarkSandbox() error {
+	return db.SetSetting(SandboxSettingKey, "1")
+}
+
+// GrantPlayMoney 沙盒模式下将用户余额补足到 amount，返回补发的金额和当前余额，余额已足够时不补发
+func (db *DB) GrantPlayMoney(userID, amount int64) (granted, balance int64, err error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer tx.Rollback()
+
+	if err := tx.QueryRow(`SELECT balance FROM users WHERE id = ?`, userID).Scan(&balance); err != nil {
+		return 0, 0, fmt.Errorf("获取用户当前余额失败: %v", err)
+	}
+	if balance >= amount {
+		return 0, balance, nil
+	}
+
+	grant := amount - balance
+	if err := db.updateUserBalanceInTx(tx, userID, amount); err != nil {
+		return 0, 0, err
+	}
+
+	transaction := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      userID,
+		Type:        models.TransactionTypeSandboxGrant,
+		Amount:      grant,
+		Balance:     amount,
+		Description: "沙盒练习金币",
+	}
+	if err := db.createTransactionInTx(tx, transaction); err != nil {
+		return 0, 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, 0, err
+	}
+	return grant, amount, nil
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// SettlementHoldKey 暂停自动结算的截止时间（RFC3339），删除后恢复自动结算
+const SettlementHoldKey = "settlement_hold_until"
+
+// HoldGameSettlement 记录进行中对局的骰子结果并暂停结算，等待管理员审核
+func (db *DB) HoldGameSettlement(gameID string, dice1, dice2, dice3, dice4, dice5, dice6 int) error {
+	query := `UPDATE games SET status = ?,
+			  player1_dice1 = ?, player1_dice2 = ?, player1_dice3 = ?,
+			  player2_dice1 = ?, player2_dice2 = ?, player2_dice3 = ?,
+			  updated_at = ?
+			  WHERE id = ? AND status = ?`
+
+	result, err := db.conn.Exec(query, models.GameStatusHeld, dice1, dice2, dice3, dice4, dice5, dice6,
+		time.Now(), gameID, models.GameStatusPlaying)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("游戏状态已变更，无法暂停结算")
+	}
+	return nil
+}
+
+// GetHeldGames 获取暂停结算、等待审核的对局，按开骰时间排列
+func (db *DB) GetHeldGames(limit int) ([]*models.Game, error) {
+	query := `SELECT id, player1_id, player2_id, bet_amount, status, player1_dice1,
+			  player1_dice2, player1_dice3, player2_dice1, player2_dice2, player2_dice3,
+			  winner_id, commission, chat_id, created_at, updated_at
+			  FROM games WHERE status = ? ORDER BY updated_at ASC LIMIT ?`
+
+	rows, err := db.conn.Query(query, models.GameStatusHeld, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var games []*models.Game
+	for rows.Next() {
+		game := &models.Game{}
+		err := rows.Scan(
+			&game.ID, &game.Player1ID, &game.Player2ID, &game.BetAmount,
+			&game.Status, &game.Player1Dice1, &game.Player1Dice2, &game.Player1Dice3,
+			&game.Player2Dice1, &game.Player2Dice2, &game.Player2Dice3, &game.WinnerID,
+			&game.Commission, &game.ChatID, &game.CreatedAt, &game.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, game)
+	}
+	return games, rows.Err()
+}
+
+// GetStalledGameIDs 获取开局时间早于 before 仍未结算的对局
+func (db *DB) GetStalledGameIDs(before time.Time) ([]string, error) {
+	rows, err := db.conn.Query(`SELECT id FROM games WHERE status = ? AND updated_at < ? ORDER BY updated_at ASC`,
+		models.GameStatusPlaying, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
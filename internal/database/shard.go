@@ -0,0 +1,71 @@
+package database
+
+import (
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// AcquireLock 获取或续期分布式锁，锁已被其他持有者占用且未过期时返回 false
+func (db *DB) AcquireLock(name, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	query := `INSERT INTO distributed_locks (name, owner, expires_at) VALUES (?, ?, ?)
+			  ON CONFLICT(name) DO UPDATE SET owner = excluded.owner, expires_at = excluded.expires_at
+			  WHERE distributed_locks.owner = excluded.owner OR distributed_locks.expires_at < ?`
+
+	result, err := db.conn.Exec(query, name, owner, now.Add(ttl), now)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ReleaseLock 释放自己持有的分布式锁
+func (db *DB) ReleaseLock(name, owner string) error {
+	_, err := db.conn.Exec(`DELETE FROM distributed_locks WHERE name = ? AND owner = ?`, name, owner)
+	return err
+}
+
+// ShardHeartbeat 上报分片进程心跳
+func (db *DB) ShardHeartbeat(node *models.ShardNode) error {
+	query := `INSERT INTO shard_nodes (instance_id, shard_id, shard_count, started_at, heartbeat_at)
+			  VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT(instance_id) DO UPDATE SET shard_id = excluded.shard_id,
+			  shard_count = excluded.shard_count, heartbeat_at = excluded.heartbeat_at`
+
+	node.HeartbeatAt = time.Now()
+	_, err := db.conn.Exec(query, node.InstanceID, node.ShardID, node.ShardCount, node.StartedAt, node.HeartbeatAt)
+	return err
+}
+
+// RemoveShardNode 进程退出时移除心跳记录
+func (db *DB) RemoveShardNode(instanceID string) error {
+	_, err := db.conn.Exec(`DELETE FROM shard_nodes WHERE instance_id = ?`, instanceID)
+	return err
+}
+
+// GetShardNodes 获取 since 之后仍有心跳的分片进程
+func (db *DB) GetShardNodes(since time.Time) ([]*models.ShardNode, error) {
+	query := `SELECT instance_id, shard_id, shard_count, started_at, heartbeat_at
+			  FROM shard_nodes WHERE heartbeat_at >= ? ORDER BY shard_id, started_at`
+
+	rows, err := db.conn.Query(query, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []*models.ShardNode
+	for rows.Next() {
+		node := &models.ShardNode{}
+		if err := rows.Scan(&node.InstanceID, &node.ShardID, &node.ShardCount, &node.StartedAt, &node.HeartbeatAt); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
@@ -0,0 +1,261 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// CreateTableWithAnte 在事务中开设快速桌并扣除开桌者的底注
+func (db *DB) CreateTableWithAnte(table *models.Table) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	table.Status = models.TableStatusOpen
+	table.CreatedAt, table.UpdatedAt = now, now
+	_, err = tx.Exec(`INSERT INTO game_tables (id, chat_id, creator_id, ante, status, mode, max_players, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		table.ID, table.ChatID, table.CreatorID, table.Ante, table.Status, table.Mode, table.MaxPlayers, now, now)
+	if err != nil {
+		return err
+	}
+
+	if err := db.seatPlayerInTx(tx, table, table.CreatorID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// JoinTableWithAnte 在事务中加入快速桌并扣除底注，返回加入后的人数
+func (db *DB) JoinTableWithAnte(tableID string, userID int64, maxPlayers int) (int, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	table, err := scanTable(tx.QueryRow(`SELECT `+tableColumns+` FROM game_tables WHERE id = ?`, tableID))
+	if err != nil {
+		return 0, err
+	}
+	if table == nil {
+		return 0, fmt.Errorf("快速桌不存在")
+	}
+	if table.Status != models.TableStatusOpen {
+		return 0, fmt.Errorf("快速桌已开骰或已关闭")
+	}
+
+	var seated, joined int
+	err = tx.QueryRow(`SELECT COUNT(*), COALESCE(SUM(CASE WHEN user_id = ? THEN 1 ELSE 0 END), 0)
+			  FROM table_players WHERE table_id = ?`, userID, tableID).Scan(&seated, &joined)
+	if err != nil {
+		return 0, err
+	}
+	if joined > 0 {
+		return 0, fmt.Errorf("你已在这张快速桌上")
+	}
+	if seated >= maxPlayers {
+		return 0, fmt.Errorf("快速桌已满")
+	}
+
+	if err := db.seatPlayerInTx(tx, table, userID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return seated + 1, nil
+}
+
+// seatPlayerInTx 扣除底注（优先使用赠送余额）并让玩家入座
+func (db *DB) seatPlayerInTx(tx *sql.Tx, table *models.Table, userID int64) error {
+	newBalance, bonusStake, err := db.debitStakeInTx(tx, userID, table.Ante)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO table_players (table_id, user_id, bonus_stake, joined_at, seat)
+			  SELECT ?, ?, ?, ?, COALESCE(MAX(seat), 0) + 1 FROM table_players WHERE table_id = ?`,
+		table.ID, userID, bonusStake, time.Now(), table.ID)
+	if err != nil {
+		return err
+	}
+
+	return db.createTransactionInTx(tx, &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      userID,
+		Type:        models.TransactionTypeBet,
+		Amount:      -table.Ante,
+		Balance:     newBalance,
+		Description: fmt.Sprintf("快速桌 %s 底注", table.ID),
+	})
+}
+
+// GetTable 获取快速桌，不存在时返回 nil
+func (db *DB) GetTable(tableID string) (*models.Table, error) {
+	return scanTable(db.conn.QueryRow(`SELECT `+tableColumns+` FROM game_tables WHERE id = ?`, tableID))
+}
+
+// tableColumns scanTable 读取的快速桌字段
+const tableColumns = `id, chat_id, creator_id, ante, status, COALESCE(mode, 'split'), COALESCE(max_players, 0),
+			  commission, created_at, updated_at`
+
+// scanTable 读取一行快速桌记录，不存在时返回 nil
+func scanTable(row *sql.Row) (*models.Table, error) {
+	table := &models.Table{}
+	err := row.Scan(&table.ID, &table.ChatID, &table.CreatorID, &table.Ante, &table.Status, &table.Mode, &table.MaxPlayers,
+		&table.Commission, &table.CreatedAt, &table.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// GetTablePlayers 获取快速桌上的玩家，按入座顺序排列
+func (db *DB) GetTablePlayers(tableID string) ([]*models.TablePlayer, error) {
+	query := `SELECT p.table_id, p.user_id, COALESCE(u.username, ''), COALESCE(u.first_name, ''), COALESCE(u.anonymous, 0),
+			  p.bonus_stake, p.dice1, p.dice2, p.dice3, p.rank, p.payout, p.joined_at
+			  FROM table_players p LEFT JOIN users u ON u.id = p.user_id
+			  WHERE p.table_id = ? ORDER BY p.seat, p.joined_at`
+
+	rows, err := db.conn.Query(query, tableID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var players []*models.TablePlayer
+	for rows.Next() {
+		player := &models.TablePlayer{}
+		if err := rows.Scan(&player.TableID, &player.UserID, &player.Username, &player.FirstName, &player.Anonymous,
+			&player.BonusStake, &player.Dice1, &player.Dice2, &player.Dice3, &player.Rank, &player.Payout, &player.JoinedAt); err != nil {
+			return nil, err
+		}
+		players = append(players, player)
+	}
+	return players, rows.Err()
+}
+
+// SettleTableWithTransaction 在事务中记录快速桌的掷骰结果并派奖，
+// 手续费交易中的群组分成计入群组基金，全部玩家的底注计入赠送流水
+func (db *DB) SettleTableWithTransaction(table *models.Table, players []*models.TablePlayer, commission int64, transactions []*models.Transaction) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE game_tables SET status = ?, commission = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		models.TableStatusFinished, commission, now, table.ID, models.TableStatusOpen)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("快速桌状态已变更，无法结算")
+	}
+
+	for _, player := range players {
+		_, err := tx.Exec(`UPDATE table_players SET dice1 = ?, dice2 = ?, dice3 = ?, rank = ?, payout = ?
+				  WHERE table_id = ? AND user_id = ?`,
+			player.Dice1, player.Dice2, player.Dice3, player.Rank, player.Payout, table.ID, player.UserID)
+		if err != nil {
+			return err
+		}
+		if player.Payout > 0 {
+			newBalance, err := db.creditBalanceInTx(tx, player.UserID, player.Payout)
+			if err != nil {
+				return err
+			}
+			if err := db.createTransactionInTx(tx, &models.Transaction{
+				ID:          utils.GenerateTransactionID(),
+				UserID:      player.UserID,
+				Type:        models.TransactionTypeWin,
+				Amount:      player.Payout,
+				Balance:     newBalance,
+				Description: fmt.Sprintf("快速桌 %s 第 %d 名奖金", table.ID, player.Rank),
+			}); err != nil {
+				return err
+			}
+		}
+		if err := db.recordBonusWagerInTx(tx, player.UserID, table.Ante); err != nil {
+			return err
+		}
+	}
+
+	var chatShare int64
+	for _, transaction := range transactions {
+		if err := db.createTransactionInTx(tx, transaction); err != nil {
+			return err
+		}
+		if transaction.Type == models.TransactionTypeRevenueShare {
+			chatShare += transaction.Amount
+		}
+	}
+	if chatShare > 0 {
+		if _, err := tx.Exec(`UPDATE chats SET fund_balance = COALESCE(fund_balance, 0) + ?, updated_at = ? WHERE id = ?`,
+			chatShare, now, table.ChatID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// CancelTableWithRefund 在事务中关闭未开骰的快速桌并退还全部底注，占用的赠送金额原路退回
+func (db *DB) CancelTableWithRefund(table *models.Table, players []*models.TablePlayer) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE game_tables SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		models.TableStatusCancelled, time.Now(), table.ID, models.TableStatusOpen)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("快速桌已开骰或已关闭")
+	}
+
+	for _, player := range players {
+		cash := table.Ante - player.BonusStake
+		newBalance, err := db.creditBalanceInTx(tx, player.UserID, cash)
+		if err != nil {
+			return err
+		}
+		if player.BonusStake > 0 {
+			if _, err := tx.Exec(`UPDATE users SET bonus_balance = COALESCE(bonus_balance, 0) + ? WHERE id = ?`,
+				player.BonusStake, player.UserID); err != nil {
+				return err
+			}
+		}
+		if err := db.createTransactionInTx(tx, &models.Transaction{
+			ID:          utils.GenerateTransactionID(),
+			UserID:      player.UserID,
+			Type:        models.TransactionTypeRefund,
+			Amount:      table.Ante,
+			Balance:     newBalance,
+			Description: fmt.Sprintf("快速桌 %s 关闭，退还底注", table.ID),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
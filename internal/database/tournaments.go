@@ -0,0 +1,419 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// tournamentColumns queryTournaments 读取的锦标赛字段
+const tournamentColumns = `id, chat_id, creator_id, entry_fee, max_players, status, round, winner_id, prize, COALESCE(fund_prize, 0), commission,
+			  starts_at, next_round_at, created_at, updated_at`
+
+// CreateTournament 开设报名中的锦标赛
+func (db *DB) CreateTournament(tournament *models.Tournament) error {
+	now := time.Now()
+	tournament.Status = models.TournamentStatusOpen
+	tournament.NextRoundAt = tournament.StartsAt
+	tournament.CreatedAt, tournament.UpdatedAt = now, now
+	_, err := db.conn.Exec(`INSERT INTO tournaments (id, chat_id, creator_id, entry_fee, max_players, status, starts_at, next_round_at, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		tournament.ID, tournament.ChatID, tournament.CreatorID, tournament.EntryFee, tournament.MaxPlayers, tournament.Status,
+		tournament.StartsAt, tournament.NextRoundAt, now, now)
+	return err
+}
+
+// EnterTournamentWithFee 在事务中报名锦标赛并扣除报名费（优先使用赠送余额），返回报名后的人数。
+// 报满时报名截止时间提前到当前，由调度器立即开赛
+func (db *DB) EnterTournamentWithFee(tournamentID string, userID int64) (int, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var entryFee int64
+	var maxPlayers int
+	var status string
+	err = tx.QueryRow(`SELECT entry_fee, max_players, status FROM tournaments WHERE id = ?`, tournamentID).
+		Scan(&entryFee, &maxPlayers, &status)
+	if err == sql.ErrNoRows {
+		return 0, fmt.Errorf("锦标赛不存在")
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status != models.TournamentStatusOpen {
+		return 0, fmt.Errorf("锦标赛报名已截止")
+	}
+
+	var entered, joined int
+	err = tx.QueryRow(`SELECT COUNT(*), COALESCE(SUM(CASE WHEN user_id = ? THEN 1 ELSE 0 END), 0)
+			  FROM tournament_entries WHERE tournament_id = ?`, userID, tournamentID).Scan(&entered, &joined)
+	if err != nil {
+		return 0, err
+	}
+	if joined > 0 {
+		return 0, fmt.Errorf("你已报名这场锦标赛")
+	}
+	if entered >= maxPlayers {
+		return 0, fmt.Errorf("锦标赛报名人数已满")
+	}
+
+	newBalance, bonusStake, err := db.debitStakeInTx(tx, userID, entryFee)
+	if err != nil {
+		return 0, err
+	}
+	now := time.Now()
+	if _, err := tx.Exec(`INSERT INTO tournament_entries (tournament_id, user_id, bonus_stake, joined_at) VALUES (?, ?, ?, ?)`,
+		tournamentID, userID, bonusStake, now); err != nil {
+		return 0, err
+	}
+	if err := db.createTransactionInTx(tx, &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      userID,
+		Type:        models.TransactionTypeBet,
+		Amount:      -entryFee,
+		Balance:     newBalance,
+		Description: fmt.Sprintf("锦标赛 %s 报名费", tournamentID),
+	}); err != nil {
+		return 0, err
+	}
+
+	if entered+1 >= maxPlayers {
+		if _, err := tx.Exec(`UPDATE tournaments SET starts_at = ?, next_round_at = ?, updated_at = ? WHERE id = ?`,
+			now, now, now, tournamentID); err != nil {
+			return 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return entered + 1, nil
+}
+
+// GetTournament 获取锦标赛，不存在时返回 nil
+func (db *DB) GetTournament(tournamentID string) (*models.Tournament, error) {
+	tournaments, err := db.queryTournaments(`SELECT `+tournamentColumns+` FROM tournaments WHERE id = ?`, tournamentID)
+	if err != nil || len(tournaments) == 0 {
+		return nil, err
+	}
+	return tournaments[0], nil
+}
+
+// GetActiveTournament 获取群组内报名中或比赛中的锦标赛，没有时返回 nil
+func (db *DB) GetActiveTournament(chatID int64) (*models.Tournament, error) {
+	tournaments, err := db.queryTournaments(`SELECT `+tournamentColumns+` FROM tournaments
+			  WHERE chat_id = ? AND status IN (?, ?) ORDER BY created_at DESC LIMIT 1`,
+		chatID, models.TournamentStatusOpen, models.TournamentStatusRunning)
+	if err != nil || len(tournaments) == 0 {
+		return nil, err
+	}
+	return tournaments[0], nil
+}
+
+// GetDueTournaments 获取已到报名截止或下一轮开赛时间的锦标赛
+func (db *DB) GetDueTournaments(now time.Time) ([]*models.Tournament, error) {
+	return db.queryTournaments(`SELECT `+tournamentColumns+` FROM tournaments
+			  WHERE status IN (?, ?) AND next_round_at <= ? ORDER BY next_round_at`,
+		models.TournamentStatusOpen, models.TournamentStatusRunning, now)
+}
+
+// GetTournaments 按状态获取锦标赛，status 为空时不限状态，按开设时间倒序
+func (db *DB) GetTournaments(status string, limit int) ([]*models.Tournament, error) {
+	query := `SELECT ` + tournamentColumns + ` FROM tournaments`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+	return db.queryTournaments(query, args...)
+}
+
+// GetTournamentEntries 获取锦标赛的参赛玩家，开赛后按种子位排列，报名期间按报名顺序排列
+func (db *DB) GetTournamentEntries(tournamentID string) ([]*models.TournamentEntry, error) {
+	query := `SELECT e.tournament_id, e.user_id, COALESCE(u.username, ''), COALESCE(u.first_name, ''), COALESCE(u.anonymous, 0),
+			  e.bonus_stake, e.seed, e.eliminated_round, e.joined_at
+			  FROM tournament_entries e LEFT JOIN users u ON u.id = e.user_id
+			  WHERE e.tournament_id = ? ORDER BY e.seed, e.joined_at, e.user_id`
+
+	rows, err := db.conn.Query(query, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*models.TournamentEntry
+	for rows.Next() {
+		entry := &models.TournamentEntry{}
+		if err := rows.Scan(&entry.TournamentID, &entry.UserID, &entry.Username, &entry.FirstName, &entry.Anonymous,
+			&entry.BonusStake, &entry.Seed, &entry.EliminatedRound, &entry.JoinedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetTournamentMatches 获取锦标赛已进行的对阵，按轮次和对阵序号排列
+func (db *DB) GetTournamentMatches(tournamentID string) ([]*models.TournamentMatch, error) {
+	query := `SELECT tournament_id, round, slot, player1_id, player2_id,
+			  player1_dice1, player1_dice2, player1_dice3, player2_dice1, player2_dice2, player2_dice3,
+			  rerolls, winner_id, played_at
+			  FROM tournament_matches WHERE tournament_id = ? ORDER BY round, slot`
+
+	rows, err := db.conn.Query(query, tournamentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []*models.TournamentMatch
+	for rows.Next() {
+		match := &models.TournamentMatch{}
+		if err := rows.Scan(&match.TournamentID, &match.Round, &match.Slot, &match.Player1ID, &match.Player2ID,
+			&match.Player1Dice[0], &match.Player1Dice[1], &match.Player1Dice[2],
+			&match.Player2Dice[0], &match.Player2Dice[1], &match.Player2Dice[2],
+			&match.Rerolls, &match.WinnerID, &match.PlayedAt); err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+// StartTournament 在事务中截止报名并记录抽签的种子位，全部报名费计入赠送流水
+func (db *DB) StartTournament(tournament *models.Tournament, entries []*models.TournamentEntry) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE tournaments SET status = ?, next_round_at = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		models.TournamentStatusRunning, tournament.NextRoundAt, now, tournament.ID, models.TournamentStatusOpen)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("锦标赛已开赛或已取消")
+	}
+
+	for _, entry := range entries {
+		if _, err := tx.Exec(`UPDATE tournament_entries SET seed = ? WHERE tournament_id = ? AND user_id = ?`,
+			entry.Seed, tournament.ID, entry.UserID); err != nil {
+			return err
+		}
+		if err := db.recordBonusWagerInTx(tx, entry.UserID, tournament.EntryFee); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	tournament.Status = models.TournamentStatusRunning
+	tournament.UpdatedAt = now
+	return nil
+}
+
+// RecordTournamentRound 在事务中记录一轮的对阵结果并淘汰负者，tournament.Round 为本轮轮次
+func (db *DB) RecordTournamentRound(tournament *models.Tournament, matches []*models.TournamentMatch) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE tournaments SET round = ?, next_round_at = ?, updated_at = ? WHERE id = ? AND status = ? AND round = ?`,
+		tournament.Round, tournament.NextRoundAt, now, tournament.ID, models.TournamentStatusRunning, tournament.Round-1)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("锦标赛第 %d 轮已进行或比赛已结束", tournament.Round)
+	}
+
+	for _, match := range matches {
+		_, err := tx.Exec(`INSERT INTO tournament_matches (tournament_id, round, slot, player1_id, player2_id,
+				  player1_dice1, player1_dice2, player1_dice3, player2_dice1, player2_dice2, player2_dice3, rerolls, winner_id, played_at)
+				  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			tournament.ID, match.Round, match.Slot, match.Player1ID, match.Player2ID,
+			match.Player1Dice[0], match.Player1Dice[1], match.Player1Dice[2],
+			match.Player2Dice[0], match.Player2Dice[1], match.Player2Dice[2], match.Rerolls, match.WinnerID, match.PlayedAt)
+		if err != nil {
+			return err
+		}
+		if match.Bye() {
+			continue
+		}
+		loserID := match.Player1ID
+		if match.WinnerID == match.Player1ID {
+			loserID = match.Player2ID
+		}
+		if _, err := tx.Exec(`UPDATE tournament_entries SET eliminated_round = ? WHERE tournament_id = ? AND user_id = ?`,
+			match.Round, tournament.ID, loserID); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	tournament.UpdatedAt = now
+	return nil
+}
+
+// FinishTournamentWithPrize 在事务中结束锦标赛并向冠军派发奖金，群组比赛奖池全部追加到奖金中，
+// 手续费交易中的群组分成计入群组基金
+func (db *DB) FinishTournamentWithPrize(tournament *models.Tournament, transactions []*models.Transaction) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	fundPrize, err := db.drawPrizePoolInTx(tx, tournament.ChatID, tournament.ID)
+	if err != nil {
+		return err
+	}
+	prize := tournament.Prize + fundPrize
+
+	now := time.Now()
+	result, err := tx.Exec(`UPDATE tournaments SET status = ?, winner_id = ?, prize = ?, fund_prize = ?, commission = ?, updated_at = ?
+			  WHERE id = ? AND status = ?`,
+		models.TournamentStatusFinished, tournament.WinnerID, prize, fundPrize, tournament.Commission, now,
+		tournament.ID, models.TournamentStatusRunning)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("锦标赛状态已变更，无法派奖")
+	}
+
+	if prize > 0 {
+		newBalance, err := db.creditBalanceInTx(tx, *tournament.WinnerID, prize)
+		if err != nil {
+			return err
+		}
+		if err := db.createTransactionInTx(tx, &models.Transaction{
+			ID:          utils.GenerateTransactionID(),
+			UserID:      *tournament.WinnerID,
+			Type:        models.TransactionTypeWin,
+			Amount:      prize,
+			Balance:     newBalance,
+			Description: fmt.Sprintf("锦标赛 %s 冠军奖金", tournament.ID),
+		}); err != nil {
+			return err
+		}
+	}
+
+	var chatShare int64
+	for _, transaction := range transactions {
+		if err := db.createTransactionInTx(tx, transaction); err != nil {
+			return err
+		}
+		if transaction.Type == models.TransactionTypeRevenueShare {
+			chatShare += transaction.Amount
+		}
+	}
+	if chatShare > 0 {
+		if _, err := tx.Exec(`UPDATE chats SET fund_balance = COALESCE(fund_balance, 0) + ?, updated_at = ? WHERE id = ?`,
+			chatShare, now, tournament.ChatID); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	tournament.Status = models.TournamentStatusFinished
+	tournament.Prize, tournament.FundPrize = prize, fundPrize
+	tournament.UpdatedAt = now
+	return nil
+}
+
+// CancelTournamentWithRefund 在事务中取消报名中的锦标赛并退还全部报名费，占用的赠送金额原路退回
+func (db *DB) CancelTournamentWithRefund(tournament *models.Tournament, entries []*models.TournamentEntry) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE tournaments SET status = ?, updated_at = ? WHERE id = ? AND status = ?`,
+		models.TournamentStatusCancelled, time.Now(), tournament.ID, models.TournamentStatusOpen)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return fmt.Errorf("锦标赛已开赛或已取消")
+	}
+
+	for _, entry := range entries {
+		newBalance, err := db.creditBalanceInTx(tx, entry.UserID, tournament.EntryFee-entry.BonusStake)
+		if err != nil {
+			return err
+		}
+		if entry.BonusStake > 0 {
+			if _, err := tx.Exec(`UPDATE users SET bonus_balance = COALESCE(bonus_balance, 0) + ? WHERE id = ?`,
+				entry.BonusStake, entry.UserID); err != nil {
+				return err
+			}
+		}
+		if err := db.createTransactionInTx(tx, &models.Transaction{
+			ID:          utils.GenerateTransactionID(),
+			UserID:      entry.UserID,
+			Type:        models.TransactionTypeRefund,
+			Amount:      tournament.EntryFee,
+			Balance:     newBalance,
+			Description: fmt.Sprintf("锦标赛 %s 取消，退还报名费", tournament.ID),
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	tournament.Status = models.TournamentStatusCancelled
+	return nil
+}
+
+// queryTournaments 查询并扫描锦标赛
+func (db *DB) queryTournaments(query string, args ...interface{}) ([]*models.Tournament, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tournaments []*models.Tournament
+	for rows.Next() {
+		tournament := &models.Tournament{}
+		var winnerID sql.NullInt64
+		if err := rows.Scan(&tournament.ID, &tournament.ChatID, &tournament.CreatorID, &tournament.EntryFee, &tournament.MaxPlayers,
+			&tournament.Status, &tournament.Round, &winnerID, &tournament.Prize, &tournament.FundPrize, &tournament.Commission,
+			&tournament.StartsAt, &tournament.NextRoundAt, &tournament.CreatedAt, &tournament.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if winnerID.Valid {
+			tournament.WinnerID = &winnerID.Int64
+		}
+		tournaments = append(tournaments, tournament)
+	}
+	return tournaments, rows.Err()
+}
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// transactionWhere 根据筛选条件构建 WHERE 子句
+func transactionWhere(filter *models.TransactionFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.UserID != 0 {
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, filter.UserID)
+	}
+	if filter.GameID != "" {
+		conditions = append(conditions, "game_id = ?")
+		args = append(args, filter.GameID)
+	}
+	if filter.Type != "" {
+		conditions = append(conditions, "type = ?")
+		args = append(args, filter.Type)
+	}
+	if filter.Memo != "" {
+		conditions = append(conditions, "description LIKE ?")
+		args = append(args, "%"+filter.Memo+"%")
+	}
+	if filter.MinAmount != nil {
+		conditions = append(conditions, "amount >= ?")
+		args = append(args, *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		conditions = append(conditions, "amount <= ?")
+		args = append(args, *filter.MaxAmount)
+	}
+	if !filter.From.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.From)
+	}
+	if !filter.To.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.To)
+	}
+
+	if len(conditions) == 0 {
+		return "1=1", args
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// SearchTransactions 按条件搜索交易记录，按时间倒序使用游标分页
+// cursor 为空表示第一页，返回的 nextCursor 为空表示没有更多数据
+func (db *DB) SearchTransactions(filter *models.TransactionFilter, cursor string, limit int) ([]*models.Transaction, string, error) {
+	where, args := transactionWhere(filter)
+
+	if cursor != "" {
+		createdAt, id, err := decodeTransactionCursor(cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		where += " AND (created_at < ? OR (created_at = ? AND id < ?))"
+		args = append(args, createdAt, createdAt, id)
+	}
+
+	query := `SELECT id, user_id, game_id, type, amount, balance, description, created_at
+			  FROM transactions WHERE ` + where + `
+			  ORDER BY created_at DESC, id DESC LIMIT ?`
+	args = append(args, limit+1)
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		tx := &models.Transaction{}
+		if err := rows.Scan(&tx.ID, &tx.UserID, &tx.GameID, &tx.Type,
+			&tx.Amount, &tx.Balance, &tx.Description, &tx.CreatedAt); err != nil {
+			return nil, "", err
+		}
+		transactions = append(transactions, tx)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+
+	// 多取一条用于判断是否还有下一页
+	nextCursor := ""
+	if len(transactions) > limit {
+		transactions = transactions[:limit]
+		last := transactions[limit-1]
+		nextCursor = encodeTransactionCursor(last.CreatedAt, last.ID)
+	}
+	return transactions, nextCursor, nil
+}
+
+// SumTransactions 统计筛选结果的笔数和金额汇总
+func (db *DB) SumTransactions(filter *models.TransactionFilter) (*models.TransactionTotals, error) {
+	where, args := transactionWhere(filter)
+	query := `SELECT COUNT(*), COALESCE(SUM(amount), 0),
+			  COALESCE(SUM(CASE WHEN amount > 0 THEN amount ELSE 0 END), 0),
+			  COALESCE(SUM(CASE WHEN amount < 0 THEN -amount ELSE 0 END), 0)
+			  FROM transactions WHERE ` + where
+
+	totals := &models.TransactionTotals{}
+	err := db.conn.QueryRow(query, args...).Scan(&totals.Count, &totals.Net, &totals.Inflow, &totals.Outflow)
+	return totals, err
+}
+
+// encodeTransactionCursor 将最后一条记录的时间和ID编码为分页游标
+func encodeTransactionCursor(createdAt time.Time, id string) string {
+	raw := createdAt.Format(time.RFC3339Nano) + "|" + id
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeTransactionCursor 解析分页游标
+func decodeTransactionCursor(cursor string) (time.Time, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("无效的分页游标")
+	}
+	parts := strings.SplitN(string(raw), "|", 2)
+	if len(parts) != 2 {
+		return time.Time{}, "", fmt.Errorf("无效的分页游标")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, parts[0])
+	if err != nil {
+		return time.Time{}, "", fmt.Errorf("无效的分页游标")
+	}
+	return createdAt, parts[1], nil
+}
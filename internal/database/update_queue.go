@@ -0,0 +1,111 @@
+package database
+
+import (
+	"strconv"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// EnqueueUpdates 写入拉取到的更新并推进 offsetKey 记录的偏移量，两者在同一事务中提交：
+// 偏移量只在更新落库后前进，重复投递的更新按 update_id 忽略
+func (db *DB) EnqueueUpdates(updates []*models.QueuedUpdate, offsetKey string) error {
+	if len(updates) == 0 {
+		return nil
+	}
+
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	lastID := 0
+	for _, update := range updates {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO update_queue (update_id, chat_id, payload, created_at) VALUES (?, ?, ?, ?)`,
+			update.UpdateID, update.ChatID, update.Payload, now); err != nil {
+			return err
+		}
+		if update.UpdateID > lastID {
+			lastID = update.UpdateID
+		}
+	}
+
+	// 偏移量只前进不后退：接收进程交接时，旧进程迟到的提交不会覆盖新进程已推进的偏移量
+	if _, err := tx.Exec(`INSERT INTO bot_settings (key, value, updated_at) VALUES (?, ?, ?)
+			  ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+			  WHERE CAST(bot_settings.value AS INTEGER) < CAST(excluded.value AS INTEGER)`,
+		offsetKey, strconv.Itoa(lastID), now); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ClaimUpdates 按 update_id 顺序领取分片 shardID 的待处理更新，最多 limit 条。
+// 未被领取或领取者已无心跳（liveSince 之后没有心跳）的更新可以领取，逐条以条件更新认领，多个进程不会领到同一条
+func (db *DB) ClaimUpdates(shardID, shardCount int, owner string, liveSince time.Time, limit int) ([]*models.QueuedUpdate, error) {
+	const claimable = `(claimed_by IS NULL OR (claimed_by <> ? AND claimed_by NOT IN (SELECT instance_id FROM shard_nodes WHERE heartbeat_at >= ?)))`
+
+	rows, err := db.conn.Query(`SELECT update_id, chat_id, payload, created_at FROM update_queue
+			  WHERE ABS(chat_id) % ? = ? AND `+claimable+`
+			  ORDER BY update_id ASC LIMIT ?`,
+		shardCount, shardID, owner, liveSince, limit)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []*models.QueuedUpdate
+	for rows.Next() {
+		update := &models.QueuedUpdate{}
+		if err := rows.Scan(&update.UpdateID, &update.ChatID, &update.Payload, &update.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		candidates = append(candidates, update)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var claimed []*models.QueuedUpdate
+	now := time.Now()
+	for _, update := range candidates {
+		result, err := db.conn.Exec(`UPDATE update_queue SET claimed_by = ?, claimed_at = ? WHERE update_id = ? AND `+claimable,
+			owner, now, update.UpdateID, owner, liveSince)
+		if err != nil {
+			return claimed, err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return claimed, err
+		} else if n == 1 {
+			update.ClaimedBy = owner
+			claimed = append(claimed, update)
+		}
+	}
+	return claimed, nil
+}
+
+// CompleteUpdate 更新处理完毕，从队列中删除
+func (db *DB) CompleteUpdate(updateID int) error {
+	_, err := db.conn.Exec(`DELETE FROM update_queue WHERE update_id = ?`, updateID)
+	return err
+}
+
+// ReleaseUpdates 放回已领取但尚未开始处理的更新，由同一分片的进程重新领取
+func (db *DB) ReleaseUpdates(owner string, updateIDs []int) error {
+	for _, id := range updateIDs {
+		if _, err := db.conn.Exec(`UPDATE update_queue SET claimed_by = NULL, claimed_at = NULL WHERE update_id = ? AND claimed_by = ?`,
+			id, owner); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountQueuedUpdates 队列中尚未处理完的更新数
+func (db *DB) CountQueuedUpdates() (int, error) {
+	var count int
+	err := db.conn.QueryRow(`SELECT COUNT(*) FROM update_queue`).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// StartUptimeSession 记录进程启动，返回会话ID
+func (db *DB) StartUptimeSession(instance string) (int64, error) {
+	now := time.Now()
+	result, err := db.conn.Exec(`INSERT INTO uptime_sessions (instance, started_at, last_seen_at) VALUES (?, ?, ?)`,
+		instance, now, now)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// TouchUptimeSession 更新会话的心跳时间，异常退出时以最后一次心跳作为停机时间
+func (db *DB) TouchUptimeSession(sessionID int64) error {
+	_, err := db.conn.Exec(`UPDATE uptime_sessions SET last_seen_at = ? WHERE id = ?`, time.Now(), sessionID)
+	return err
+}
+
+// StopUptimeSession 记录进程正常停止
+func (db *DB) StopUptimeSession(sessionID int64) error {
+	now := time.Now()
+	_, err := db.conn.Exec(`UPDATE uptime_sessions SET last_seen_at = ?, stopped_at = ? WHERE id = ?`, now, now, sessionID)
+	return err
+}
+
+// RecordUptimeGap 记录运行期间检测到的服务中断
+func (db *DB) RecordUptimeGap(gap *models.UptimeGap) error {
+	_, err := db.conn.Exec(`INSERT INTO uptime_gaps (started_at, ended_at, reason) VALUES (?, ?, ?)`,
+		gap.StartedAt, gap.EndedAt, gap.Reason)
+	return err
+}
+
+// GetUptimeSessions 获取与 [from, to) 有重叠的运行会话，并附带 from 之前的最后一个会话，
+// 用于计算周期开始时仍在停机的时长，按启动时间排列
+func (db *DB) GetUptimeSessions(from, to time.Time) ([]*models.UptimeSession, error) {
+	query := `SELECT id, instance, started_at, last_seen_at, stopped_at FROM uptime_sessions
+			  WHERE started_at < ? AND (started_at >= ? OR id = (
+				  SELECT id FROM uptime_sessions WHERE started_at < ? ORDER BY started_at DESC LIMIT 1))
+			  ORDER BY started_at`
+
+	rows, err := db.conn.Query(query, to, from, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.UptimeSession
+	for rows.Next() {
+		session := &models.UptimeSession{}
+		var stoppedAt sql.NullTime
+		if err := rows.Scan(&session.ID, &session.Instance, &session.StartedAt, &session.LastSeenAt, &stoppedAt); err != nil {
+			return nil, err
+		}
+		if stoppedAt.Valid {
+			session.StoppedAt = &stoppedAt.Time
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// GetUptimeGaps 获取与 [from, to) 有重叠的服务中断记录，按开始时间排列
+func (db *DB) GetUptimeGaps(from, to time.Time) ([]*models.UptimeGap, error) {
+	rows, err := db.conn.Query(`SELECT started_at, ended_at, reason FROM uptime_gaps
+			  WHERE started_at < ? AND ended_at > ? ORDER BY started_at`, to, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var gaps []*models.UptimeGap
+	for rows.Next() {
+		gap := &models.UptimeGap{}
+		if err := rows.Scan(&gap.StartedAt, &gap.EndedAt, &gap.Reason); err != nil {
+			return nil, err
+		}
+		gaps = append(gaps, gap)
+	}
+	return gaps, rows.Err()
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// ImportUser 创建从其他机器人迁移的用户，余额大于 0 时在同一事务中记录迁移交易。
+// 用户已存在时不做任何修改并返回 false
+func (db *DB) ImportUser(user *models.User, description string) (bool, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	result, err := tx.Exec(`INSERT INTO users (id, username, first_name, last_name, balance, first_chat_id, source, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT(id) DO NOTHING`,
+		user.ID, user.Username, user.FirstName, user.LastName, user.Balance, user.FirstChatID, user.Source, now, now)
+	if err != nil {
+		return false, err
+	}
+	created, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if created == 0 {
+		return false, nil
+	}
+
+	if user.Balance > 0 {
+		transaction := &models.Transaction{
+			ID:          utils.GenerateTransactionID(),
+			UserID:      user.ID,
+			Type:        models.TransactionTypeMigration,
+			Amount:      user.Balance,
+			Balance:     user.Balance,
+			Description: description,
+		}
+		if err := db.createTransactionInTx(tx, transaction); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	user.CreatedAt = now
+	user.UpdatedAt = now
+	return true, nil
+}
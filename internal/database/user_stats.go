@@ -0,0 +1,29 @@
+package database
+
+import (
+	"telegram-dice-bot/internal/models"
+)
+
+// GetUserStats 统计用户已完成（含认输）对局的胜负、最大单局净赢额和净收益，
+// 每局的输赢按该局与用户相关的流水合计，与排行榜的口径一致
+func (db *DB) GetUserStats(userID int64) (*models.UserStats, error) {
+	query := `SELECT COUNT(*),
+			  COALESCE(SUM(CASE WHEN winner_id = ? THEN 1 ELSE 0 END), 0),
+			  COALESCE(SUM(CASE WHEN winner_id IS NULL THEN 1 ELSE 0 END), 0),
+			  COALESCE(MAX(CASE WHEN winner_id = ? AND net > 0 THEN net END), 0),
+			  COALESCE(SUM(net), 0)
+			  FROM (SELECT g.winner_id,
+				  (SELECT COALESCE(SUM(t.amount), 0) FROM transactions t WHERE t.game_id = g.id AND t.user_id = ?) AS net
+				  FROM games g
+				  WHERE (g.player1_id = ? OR g.player2_id = ?) AND g.status IN (?, ?))`
+
+	stats := &models.UserStats{UserID: userID}
+	err := db.conn.QueryRow(query, userID, userID, userID, userID, userID,
+		models.GameStatusFinished, models.GameStatusSurrendered).
+		Scan(&stats.Games, &stats.Wins, &stats.Draws, &stats.BiggestWin, &stats.NetProfit)
+	if err != nil {
+		return nil, err
+	}
+	stats.Losses = stats.Games - stats.Wins - stats.Draws
+	return stats, nil
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// TrackUsername 根据更新中的用户名同步用户资料，用户名变化时记录历史
+// Telegram 用户名全局唯一，被其他用户占用时会从旧用户上移除
+func (db *DB) TrackUsername(userID int64, username string) error {
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var current sql.NullString
+	err = tx.QueryRow(`SELECT username FROM users WHERE id = ?`, userID).Scan(&current)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if current.String == username {
+		return nil
+	}
+
+	now := time.Now()
+	if current.String != "" {
+		// 记录旧用户名停止使用的时间
+		query := `INSERT INTO username_history (user_id, username, first_seen, last_seen) VALUES (?, ?, ?, ?)
+				  ON CONFLICT(user_id, username) DO UPDATE SET last_seen = excluded.last_seen`
+		if _, err := tx.Exec(query, userID, current.String, now, now); err != nil {
+			return err
+		}
+	}
+
+	if username != "" {
+		// 用户名被转移给了新用户，旧用户不再持有
+		if _, err := tx.Exec(`UPDATE users SET username = '', updated_at = ? WHERE username = ? COLLATE NOCASE AND id != ?`,
+			now, username, userID); err != nil {
+			return err
+		}
+
+		query := `INSERT INTO username_history (user_id, username, first_seen, last_seen) VALUES (?, ?, ?, ?)
+				  ON CONFLICT(user_id, username) DO UPDATE SET last_seen = excluded.last_seen`
+		if _, err := tx.Exec(query, userID, username, now, now); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`UPDATE users SET username = ?, updated_at = ? WHERE id = ?`, username, now, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// FindUserByUsername 按用户名查找用户（不区分大小写，可带 @），找不到时返回 nil
+func (db *DB) FindUserByUsername(username string) (*models.User, error) {
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if username == "" {
+		return nil, nil
+	}
+
+	var userID int64
+	err := db.conn.QueryRow(`SELECT id FROM users WHERE username = ? COLLATE NOCASE LIMIT 1`, username).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return db.GetUser(userID)
+}
+
+// FindUsersByFormerUsername 查找曾使用过该用户名的用户ID，按最近使用排序
+func (db *DB) FindUsersByFormerUsername(username string) ([]int64, error) {
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	rows, err := db.conn.Query(`SELECT user_id FROM username_history WHERE username = ? COLLATE NOCASE ORDER BY last_seen DESC`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []int64
+	for rows.Next() {
+		var userID int64
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// GetUsernameHistory 获取用户的历史用户名，按最近使用排序
+func (db *DB) GetUsernameHistory(userID int64) ([]*models.UsernameHistory, error) {
+	rows, err := db.conn.Query(`SELECT user_id, username, first_seen, last_seen FROM username_history
+			  WHERE user_id = ? ORDER BY last_seen DESC`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []*models.UsernameHistory
+	for rows.Next() {
+		item := &models.UsernameHistory{}
+		if err := rows.Scan(&item.UserID, &item.Username, &item.FirstSeen, &item.LastSeen); err != nil {
+			return nil, err
+		}
+		history = append(history, item)
+	}
+	return history, rows.Err()
+}
+
+// UpdateUserProfile 同步用户的用户名和姓名，仅在有变化时写入，返回是否有更新
+func (db *DB) UpdateUserProfile(userID int64, username, firstName, lastName string) (bool, error) {
+	user, err := db.GetUser(userID)
+	if err != nil || user == nil {
+		return false, err
+	}
+
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if user.Username == username && user.FirstName == firstName && user.LastName == lastName {
+		return false, nil
+	}
+
+	if user.Username != username {
+		if err := db.TrackUsername(userID, username); err != nil {
+			return false, err
+		}
+	}
+
+	if user.FirstName != firstName || user.LastName != lastName {
+		_, err := db.conn.Exec(`UPDATE users SET first_name = ?, last_name = ?, updated_at = ? WHERE id = ?`,
+			firstName, lastName, time.Now(), userID)
+		if err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// SetUserWeeklySummary 订阅或退订每周战报私信，language 为订阅时的客户端语言，用于选择战报语言
+func (db *DB) SetUserWeeklySummary(userID int64, enabled bool, language string) error {
+	_, err := db.conn.Exec(`UPDATE users SET weekly_summary = ?, language = ?, updated_at = ? WHERE id = ?`,
+		enabled, language, time.Now(), userID)
+	return err
+}
+
+// IsWeeklySummaryEnabled 用户是否订阅了每周战报，用户不存在时返回 false
+func (db *DB) IsWeeklySummaryEnabled(userID int64) (bool, error) {
+	var enabled bool
+	err := db.conn.QueryRow(`SELECT COALESCE(weekly_summary, 0) FROM users WHERE id = ?`, userID).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return enabled, err
+}
+
+// GetWeeklySummaryRecipients 获取订阅了每周战报、可以接收私信且尚未收到截至 until 这一周战报的用户
+func (db *DB) GetWeeklySummaryRecipients(until time.Time) ([]*models.WeeklySummaryRecipient, error) {
+	rows, err := db.conn.Query(`SELECT id, COALESCE(language, '') FROM users
+			  WHERE COALESCE(weekly_summary, 0) = 1 AND COALESCE(notifications_enabled, 1) = 1
+			  AND COALESCE(dm_blocked, 0) = 0 AND deleted_at IS NULL
+			  AND (weekly_summary_at IS NULL OR weekly_summary_at < ?)
+			  ORDER BY id ASC`, until)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recipients []*models.WeeklySummaryRecipient
+	for rows.Next() {
+		recipient := &models.WeeklySummaryRecipient{}
+		if err := rows.Scan(&recipient.UserID, &recipient.Language); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, recipient)
+	}
+	return recipients, rows.Err()
+}
+
+// GetWeeklySummary 统计用户在 [since, until) 内已结束（含认输）对局的胜负、净盈亏、最大单局净赢额、
+// 获胜时扣除的手续费，以及按净盈亏在同期全部玩家中的排名
+func (db *DB) GetWeeklySummary(userID int64, since, until time.Time) (*models.WeeklySummary, error) {
+	summary := &models.WeeklySummary{UserID: userID, Since: since, Until: until}
+
+	query := `SELECT COUNT(*),
+			  COALESCE(SUM(CASE WHEN winner_id = ? THEN 1 ELSE 0 END), 0),
+			  COALESCE(SUM(CASE WHEN winner_id IS NULL THEN 1 ELSE 0 END), 0),
+			  COALESCE(MAX(CASE WHEN winner_id = ? AND net > 0 THEN net END), 0),
+			  COALESCE(SUM(net), 0),
+			  COALESCE(SUM(CASE WHEN winner_id = ? THEN commission ELSE 0 END), 0)
+			  FROM (SELECT g.winner_id, g.commission,
+				  (SELECT COALESCE(SUM(t.amount), 0) FROM transactions t WHERE t.game_id = g.id AND t.user_id = ?) AS net
+				  FROM games g
+				  WHERE (g.player1_id = ? OR g.player2_id = ?) AND g.status IN (?, ?)
+				  AND g.created_at >= ? AND g.created_at < ?)`
+	err := db.conn.QueryRow(query, userID, userID, userID, userID, userID, userID,
+		models.GameStatusFinished, models.GameStatusSurrendered, since, until).
+		Scan(&summary.Games, &summary.Wins, &summary.Draws, &summary.BestWin, &summary.NetProfit, &summary.Fees)
+	if err != nil {
+		return nil, err
+	}
+	summary.Losses = summary.Games - summary.Wins - summary.Draws
+	if summary.Games == 0 {
+		return summary, nil
+	}
+
+	// 排名：同期净盈亏高于该用户的玩家数 + 1
+	var ahead int
+	err = db.conn.QueryRow(`WITH nets AS (
+			  SELECT u.id AS user_id,
+			  COALESCE(SUM((SELECT SUM(t.amount) FROM transactions t WHERE t.game_id = g.id AND t.user_id = u.id)), 0) AS net
+			  FROM games g JOIN users u ON u.id = g.player1_id OR u.id = g.player2_id
+			  WHERE g.status IN (?, ?) AND g.created_at >= ? AND g.created_at < ?
+			  GROUP BY u.id)
+			  SELECT COUNT(*), COALESCE(SUM(CASE WHEN net > (SELECT net FROM nets WHERE user_id = ?) THEN 1 ELSE 0 END), 0)
+			  FROM nets`,
+		models.GameStatusFinished, models.GameStatusSurrendered, since, until, userID).
+		Scan(&summary.Players, &ahead)
+	if err != nil {
+		return nil, err
+	}
+	summary.Rank = ahead + 1
+	return summary, nil
+}
+
+// EnqueueWeeklySummary 在同一事务中记录用户已收到截至 until 这一周的战报并写入发件箱，
+// 该周战报已写入过时返回 false，多个进程同时生成时每周只发送一次；message 为 nil 时只记录（如本周没有对局）
+func (db *DB) EnqueueWeeklySummary(userID int64, until time.Time, message *models.OutboxMessage) (bool, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE users SET weekly_summary_at = ? WHERE id = ? AND (weekly_summary_at IS NULL OR weekly_summary_at < ?)`,
+		until, userID, until)
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return false, err
+	} else if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if message != nil {
+		if err := db.enqueueOutboxInTx(tx, []*models.OutboxMessage{message}); err != nil {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
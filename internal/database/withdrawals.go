@@ -0,0 +1,239 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// ErrWithdrawalPending 用户已有待审核的提现申请
+var ErrWithdrawalPending = errors.New("已有待审核的提现申请，请等待处理后再提交")
+
+// ErrWithdrawalNotPending 提现申请不存在或已审核
+var ErrWithdrawalNotPending = errors.New("提现申请不存在或已审核")
+
+// withdrawalColumns 查询提现申请的字段
+const withdrawalColumns = `id, user_id, amount, usdt_amount, address, status, reviewer, tx_hash, reason, created_at, reviewed_at`
+
+// CreateWithdrawal 创建提现申请并从余额中冻结金额，返回冻结后的余额。
+// 事务内检查余额和待审核申请，同一用户同时只能有一笔待审核的提现；
+// 并发提交越过检查时由待审核提现的唯一索引拒绝，同样返回 ErrWithdrawalPending
+func (db *DB) CreateWithdrawal(withdrawal *models.Withdrawal) (int64, error) {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var pending int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM withdrawals WHERE user_id = ? AND status = ?`,
+		withdrawal.UserID, models.WithdrawalStatusPending).Scan(&pending); err != nil {
+		return 0, fmt.Errorf("检查提现申请失败: %v", err)
+	}
+	if pending > 0 {
+		return 0, ErrWithdrawalPending
+	}
+
+	var balance int64
+	var frozen bool
+	if err := tx.QueryRow(`SELECT balance, COALESCE(frozen, 0) FROM users WHERE id = ?`, withdrawal.UserID).Scan(&balance, &frozen); err != nil {
+		return 0, fmt.Errorf("获取用户当前余额失败: %v", err)
+	}
+	if frozen {
+		return 0, ErrAccountFrozen
+	}
+	if balance < withdrawal.Amount {
+		return 0, fmt.Errorf("可提现余额不足，当前余额: %s，需要: %s", utils.FormatAmount(balance), utils.FormatAmount(withdrawal.Amount))
+	}
+	newBalance, err := db.debitBalanceInTx(tx, withdrawal.UserID, withdrawal.Amount)
+	if err != nil {
+		return 0, err
+	}
+
+	withdrawal.Status = models.WithdrawalStatusPending
+	withdrawal.CreatedAt = time.Now()
+	result, err := tx.Exec(`INSERT INTO withdrawals (user_id, amount, usdt_amount, address, status, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?)`,
+		withdrawal.UserID, withdrawal.Amount, withdrawal.USDTAmount, withdrawal.Address, withdrawal.Status, withdrawal.CreatedAt)
+	if isUniqueViolation(err) {
+		return 0, ErrWithdrawalPending
+	}
+	if err != nil {
+		return 0, fmt.Errorf("记录提现申请失败: %v", err)
+	}
+	if withdrawal.ID, err = result.LastInsertId(); err != nil {
+		return 0, err
+	}
+
+	transaction := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      withdrawal.UserID,
+		Type:        models.TransactionTypeWithdraw,
+		Amount:      -withdrawal.Amount,
+		Balance:     newBalance,
+		Description: fmt.Sprintf("提现申请 #%d 冻结，%.2f USDT 至 %s", withdrawal.ID, withdrawal.USDTAmount, withdrawal.Address),
+	}
+	if err := db.createTransactionInTx(tx, transaction); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return newBalance, nil
+}
+
+// GetWithdrawal 获取提现申请，不存在时返回 nil
+func (db *DB) GetWithdrawal(id int64) (*models.Withdrawal, error) {
+	withdrawal, err := scanWithdrawal(db.conn.QueryRow(`SELECT `+withdrawalColumns+` FROM withdrawals WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return withdrawal, err
+}
+
+// GetWithdrawals 按状态获取提现申请，status 为空时不限状态；待审核的按申请时间正序，其余按时间倒序
+func (db *DB) GetWithdrawals(status string, limit int) ([]*models.Withdrawal, error) {
+	query := `SELECT ` + withdrawalColumns + ` FROM withdrawals`
+	var args []interface{}
+	if status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, status)
+	}
+	if status == models.WithdrawalStatusPending {
+		query += ` ORDER BY created_at, id`
+	} else {
+		query += ` ORDER BY created_at DESC, id DESC`
+	}
+	query += ` LIMIT ?`
+	args = append(args, limit)
+	return db.queryWithdrawals(query, args...)
+}
+
+// GetUserWithdrawals 获取用户最近的提现申请，按时间倒序
+func (db *DB) GetUserWithdrawals(userID int64, limit int) ([]*models.Withdrawal, error) {
+	return db.queryWithdrawals(`SELECT `+withdrawalColumns+` FROM withdrawals
+			  WHERE user_id = ? ORDER BY created_at DESC, id DESC LIMIT ?`, userID, limit)
+}
+
+// ApproveWithdrawal 批准待审核的提现申请，冻结的金额不再退回
+func (db *DB) ApproveWithdrawal(id int64, reviewer, txHash string) error {
+	result, err := db.conn.Exec(`UPDATE withdrawals SET status = ?, reviewer = ?, tx_hash = ?, reviewed_at = ?
+			  WHERE id = ? AND status = ?`,
+		models.WithdrawalStatusApproved, reviewer, txHash, time.Now(), id, models.WithdrawalStatusPending)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWithdrawalNotPending
+	}
+	return nil
+}
+
+// RejectWithdrawal 拒绝待审核的提现申请，并在同一事务中将冻结的金额退回余额
+func (db *DB) RejectWithdrawal(id int64, reviewer, reason string) error {
+	tx, err := db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	withdrawal, err := scanWithdrawal(tx.QueryRow(`SELECT `+withdrawalColumns+` FROM withdrawals WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return ErrWithdrawalNotPending
+	}
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`UPDATE withdrawals SET status = ?, reviewer = ?, reason = ?, reviewed_at = ?
+			  WHERE id = ? AND status = ?`,
+		models.WithdrawalStatusRejected, reviewer, reason, time.Now(), id, models.WithdrawalStatusPending)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrWithdrawalNotPending
+	}
+
+	newBalance, err := db.creditBalanceInTx(tx, withdrawal.UserID, withdrawal.Amount)
+	if err != nil {
+		return err
+	}
+
+	transaction := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      withdrawal.UserID,
+		Type:        models.TransactionTypeWithdrawRefund,
+		Amount:      withdrawal.Amount,
+		Balance:     newBalance,
+		Description: fmt.Sprintf("提现申请 #%d 被拒绝，退回冻结金额", withdrawal.ID),
+	}
+	if err := db.createTransactionInTx(tx, transaction); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// queryWithdrawals 查询并扫描多条提现申请
+func (db *DB) queryWithdrawals(query string, args ...interface{}) ([]*models.Withdrawal, error) {
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var withdrawals []*models.Withdrawal
+	for rows.Next() {
+		withdrawal, err := scanWithdrawal(rows)
+		if err != nil {
+			return nil, err
+		}
+		withdrawals = append(withdrawals, withdrawal)
+	}
+	return withdrawals, rows.Err()
+}
+
+// withdrawalScanner 可扫描一行结果的 *sql.Row 或 *sql.Rows
+type withdrawalScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWithdrawal 按 withdrawalColumns 的顺序扫描一条提现申请
+func scanWithdrawal(row withdrawalScanner) (*models.Withdrawal, error) {
+	withdrawal := &models.Withdrawal{}
+	var reviewedAt sql.NullTime
+	err := row.Scan(&withdrawal.ID, &withdrawal.UserID, &withdrawal.Amount, &withdrawal.USDTAmount, &withdrawal.Address,
+		&withdrawal.Status, &withdrawal.Reviewer, &withdrawal.TxHash, &withdrawal.Reason, &withdrawal.CreatedAt, &reviewedAt)
+	if err != nil {
+		return nil, err
+	}
+	if reviewedAt.Valid {
+		withdrawal.ReviewedAt = &reviewedAt.Time
+	}
+	return withdrawal, nil
+}
+
+// isUniqueViolation 是否为唯一约束冲突（SQLite 与 PostgreSQL 的错误信息）
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") || strings.Contains(msg, "SQLSTATE 23505") ||
+		strings.Contains(msg, "duplicate key value violates unique constraint")
+}
@@ -0,0 +1,40 @@
+package deadletter
+
+import (
+	"encoding/json"
+
+	"telegram-dice-bot/internal/pool"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// KindSendMessage 发送群组或私信消息的任务类型（如结算消息）
+const KindSendMessage = "send_message"
+
+// Message 发送消息任务的参数
+type Message struct {
+	ChatID    int64  `json:"chat_id"`
+	Text      string `json:"text"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+// HandleMessages 注册发送消息任务
+func (q *Queue) HandleMessages(client telegram.Client) {
+	q.Handle(KindSendMessage, func(payload json.RawMessage) error {
+		var message Message
+		if err := json.Unmarshal(payload, &message); err != nil {
+			return err
+		}
+
+		msg := tgbotapi.NewMessage(message.ChatID, message.Text)
+		msg.ParseMode = message.ParseMode
+		_, err := client.Send(msg)
+		return err
+	})
+}
+
+// SendMessage 通过工作池发送消息，发送失败时记入死信队列
+func (q *Queue) SendMessage(message Message, priority pool.Priority) error {
+	return q.Submit(KindSendMessage, message, priority)
+}
@@ -0,0 +1,155 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/pool"
+)
+
+// ErrNotReplayable 失败任务没有注册对应的任务类型，无法重放
+var ErrNotReplayable = errors.New("该任务无法重放")
+
+// HandlerFunc 按任务参数执行一类任务
+type HandlerFunc func(payload json.RawMessage) error
+
+// Job 具名任务，参数可持久化，失败后可以从死信队列重放
+type Job struct {
+	Kind    string
+	Payload json.RawMessage
+
+	queue    *Queue
+	letterID int64 // 由重放产生时为对应的死信记录ID
+}
+
+// Execute 实现 pool.Job
+func (j *Job) Execute() error {
+	handler := j.queue.handler(j.Kind)
+	if handler == nil {
+		return fmt.Errorf("未注册的任务类型: %s", j.Kind)
+	}
+	if err := handler(j.Payload); err != nil {
+		return err
+	}
+
+	if j.letterID != 0 {
+		if _, err := j.queue.db.SetDeadLetterStatus(j.letterID, models.DeadLetterReplaying, models.DeadLetterResolved); err != nil {
+			log.Printf("⚠️ 更新死信 %d 状态失败: %v", j.letterID, err)
+		}
+	}
+	return nil
+}
+
+// Queue 死信队列：记录工作池中执行失败的任务，并支持修复后重放
+type Queue struct {
+	db   *database.DB
+	pool *pool.WorkerPool
+
+	mu       sync.RWMutex
+	handlers map[string]HandlerFunc
+}
+
+// NewQueue 创建死信队列并挂接到工作池，需在工作池启动前调用
+func NewQueue(db *database.DB, workerPool *pool.WorkerPool) *Queue {
+	q := &Queue{
+		db:       db,
+		pool:     workerPool,
+		handlers: make(map[string]HandlerFunc),
+	}
+	workerPool.SetDeadLetterSink(q)
+	return q
+}
+
+// Handle 注册任务类型
+func (q *Queue) Handle(kind string, handler HandlerFunc) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+func (q *Queue) handler(kind string) HandlerFunc {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.handlers[kind]
+}
+
+// Submit 以具名任务提交到工作池，失败后可在后台重放
+func (q *Queue) Submit(kind string, payload interface{}, priority pool.Priority) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("序列化任务参数失败: %v", err)
+	}
+	q.pool.SubmitWithPriority(&Job{Kind: kind, Payload: data, queue: q}, priority)
+	return nil
+}
+
+// Capture 实现 pool.DeadLetterSink：记录失败任务，重放再次失败时更新原记录
+func (q *Queue) Capture(job pool.Job, priority pool.Priority, err error) {
+	letter := &models.DeadLetter{Priority: int(priority), Error: err.Error()}
+	if named, ok := job.(*Job); ok {
+		if named.letterID != 0 {
+			if err := q.db.FailDeadLetter(named.letterID, letter.Error); err != nil {
+				log.Printf("❌ 更新死信 %d 失败: %v", named.letterID, err)
+			}
+			log.Printf("⚠️ 死信 %d 重放失败: %s", named.letterID, letter.Error)
+			return
+		}
+		letter.Kind = named.Kind
+		letter.Payload = string(named.Payload)
+	}
+
+	id, dbErr := q.db.AddDeadLetter(letter)
+	if dbErr != nil {
+		log.Printf("❌ 记录失败任务失败: %v（任务错误: %v）", dbErr, err)
+		return
+	}
+	log.Printf("📮 任务 %s 执行失败，已记入死信 %d: %v", letter.Kind, id, err)
+}
+
+// Replay 重新提交待处理的失败任务
+func (q *Queue) Replay(id int64) error {
+	letter, err := q.db.GetDeadLetter(id)
+	if err != nil {
+		return fmt.Errorf("获取死信失败: %v", err)
+	}
+	if letter == nil {
+		return fmt.Errorf("死信 %d 不存在", id)
+	}
+	if letter.Kind == "" || q.handler(letter.Kind) == nil {
+		return ErrNotReplayable
+	}
+
+	ok, err := q.db.SetDeadLetterStatus(id, models.DeadLetterPending, models.DeadLetterReplaying)
+	if err != nil {
+		return fmt.Errorf("更新死信状态失败: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("死信 %d 当前状态为 %s，不能重放", id, letter.Status)
+	}
+
+	job := &Job{Kind: letter.Kind, Payload: json.RawMessage(letter.Payload), queue: q, letterID: id}
+	q.pool.SubmitWithPriority(job, pool.Priority(letter.Priority))
+	return nil
+}
+
+// Discard 放弃待处理的失败任务
+func (q *Queue) Discard(id int64) error {
+	ok, err := q.db.SetDeadLetterStatus(id, models.DeadLetterPending, models.DeadLetterDiscarded)
+	if err != nil {
+		return fmt.Errorf("更新死信状态失败: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("死信 %d 不存在或不是待处理状态", id)
+	}
+	return nil
+}
+
+// Replayable 失败任务是否可以重放
+func (q *Queue) Replayable(letter *models.DeadLetter) bool {
+	return letter.Kind != "" && q.handler(letter.Kind) != nil
+}
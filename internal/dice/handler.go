@@ -0,0 +1,294 @@
+package dice
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// CustomBetTimeout 自定义赌注提示发出后等待玩家回复的时间
+const CustomBetTimeout = 2 * time.Minute
+
+// pendingKey 等待输入金额的玩家及所在聊天
+type pendingKey struct {
+	chatID int64
+	userID int64
+}
+
+// pendingBet 已发出的自定义赌注提示
+type pendingBet struct {
+	promptID  int
+	expiresAt time.Time
+}
+
+// Handler /dice 命令、梭哈确认和自定义赌注按钮的处理器
+type Handler struct {
+	db      *database.DB
+	manager *game.Manager
+	codec   *callback.Codec
+	client  telegram.Client
+	// 发起对局前的下注资格检查，未设置时不限制
+	eligibility middleware.EligibilityChecker
+
+	mu      sync.Mutex
+	pending map[pendingKey]pendingBet
+}
+
+// NewHandler 创建发起对局处理器
+func NewHandler(db *database.DB, manager *game.Manager, codec *callback.Codec, client telegram.Client) *Handler {
+	return &Handler{
+		db:      db,
+		manager: manager,
+		codec:   codec,
+		client:  client,
+		pending: make(map[pendingKey]pendingBet),
+	}
+}
+
+// Register 注册 /dice 命令、梭哈确认和自定义赌注按钮回调，发起对局前检查下注资格
+func (h *Handler) Register(router *middleware.Router) {
+	eligible := middleware.Eligible(h.eligible)
+	router.Handle(ui.DiceCommand, h.Dice, eligible)
+	router.HandleCallback(callback.Prefix(ui.DiceConfirmAction), h.Confirm, eligible)
+	router.HandleCallback(callback.Prefix(ui.DiceCancelAction), h.Confirm)
+	router.HandleCallback(callback.Prefix(ui.CustomBetAction), h.CustomBet, eligible)
+}
+
+// SetEligibility 设置下注资格检查（如 eligibility.Checker.Check），未设置时不限制
+func (h *Handler) SetEligibility(check middleware.EligibilityChecker) {
+	h.eligibility = check
+}
+
+// eligible 按已设置的下注资格检查用户
+func (h *Handler) eligible(chatID int64, from *tgbotapi.User) (string, error) {
+	if h.eligibility == nil {
+		return "", nil
+	}
+	return h.eligibility(chatID, from)
+}
+
+// Dice 按金额或金额表达式（all、half、25%）发起对局，押上全部余额时先请发起者确认
+func (h *Handler) Dice(ctx *middleware.Context) error {
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("❌ 对局只能在群组中发起")
+	}
+
+	available, err := h.available(ctx.UserID)
+	if err != nil {
+		return err
+	}
+	limits, err := h.manager.ChatLimits(ctx.ChatID)
+	if err != nil {
+		return err
+	}
+	amount, all, err := utils.ResolveBetAmount(ctx.Args, available, limits.MinBet, limits.MaxBet)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error() + "\n" + ui.FormatDiceUsage())
+	}
+	return h.start(ctx, amount, all, available)
+}
+
+// start 押上全部余额时先请发起者确认，否则直接发起对局
+func (h *Handler) start(ctx *middleware.Context, amount int64, all bool, available int64) error {
+	if all {
+		msg, err := ui.BuildBetAllConfirm(h.codec, ctx.ChatID, ctx.UserID, amount, available)
+		if err != nil {
+			return err
+		}
+		if ctx.Update.Message != nil {
+			msg.ReplyToMessageID = ctx.Update.Message.MessageID
+		}
+		_, err = ctx.Client.Send(msg)
+		return err
+	}
+	return h.create(ctx, amount)
+}
+
+// CustomBet 点击自定义赌注后请玩家直接回复金额，超时未回复则作废
+func (h *Handler) CustomBet(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("❌ 对局只能在群组中发起")
+	}
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Printf("⚠️ 应答自定义赌注按钮失败: %v", err)
+	}
+
+	name := "玩家"
+	if ctx.From != nil {
+		name = ui.PublicName(ctx.UserID, ctx.From.UserName, ctx.From.FirstName, false)
+	}
+	limits, err := h.manager.ChatLimits(ctx.ChatID)
+	if err != nil {
+		return err
+	}
+	sent, err := ctx.Client.Send(ui.BuildCustomBetPrompt(ctx.ChatID, name, limits.MinBet, limits.MaxBet, CustomBetTimeout))
+	if err != nil {
+		return err
+	}
+
+	key := pendingKey{chatID: ctx.ChatID, userID: ctx.UserID}
+	h.mu.Lock()
+	h.pending[key] = pendingBet{promptID: sent.MessageID, expiresAt: time.Now().Add(CustomBetTimeout)}
+	h.mu.Unlock()
+	time.AfterFunc(CustomBetTimeout, func() {
+		h.expire(key, sent.MessageID)
+	})
+	return nil
+}
+
+// HandleReply 处理玩家对自定义赌注提示的回复，返回消息是否已被处理。
+// 金额无效时提示重新输入，提示在超时前一直有效
+func (h *Handler) HandleReply(msg *tgbotapi.Message) bool {
+	if msg == nil || msg.Chat == nil || msg.From == nil || msg.IsCommand() {
+		return false
+	}
+	if msg.ReplyToMessage == nil {
+		return false
+	}
+
+	key := pendingKey{chatID: msg.Chat.ID, userID: msg.From.ID}
+	h.mu.Lock()
+	pending, ok := h.pending[key]
+	if ok && time.Now().After(pending.expiresAt) {
+		delete(h.pending, key)
+		ok = false
+	}
+	h.mu.Unlock()
+	if !ok || msg.ReplyToMessage.MessageID != pending.promptID {
+		return false
+	}
+
+	ctx := &middleware.Context{
+		Update: &tgbotapi.Update{Message: msg},
+		Client: h.client,
+		ChatID: msg.Chat.ID,
+		UserID: msg.From.ID,
+		Args:   msg.Text,
+		From:   msg.From,
+	}
+	available, err := h.available(ctx.UserID)
+	if err != nil {
+		log.Printf("❌ 处理自定义赌注失败: %v", err)
+		return true
+	}
+	limits, err := h.manager.ChatLimits(ctx.ChatID)
+	if err != nil {
+		log.Printf("❌ 处理自定义赌注失败: %v", err)
+		return true
+	}
+	amount, all, err := utils.ResolveBetAmount(msg.Text, available, limits.MinBet, limits.MaxBet)
+	if err != nil {
+		if err := ctx.Reply("❌ " + err.Error() + "\n请重新回复提示消息输入金额"); err != nil {
+			log.Printf("⚠️ 发送自定义赌注提示失败: %v", err)
+		}
+		return true
+	}
+
+	h.mu.Lock()
+	delete(h.pending, key)
+	h.mu.Unlock()
+	if err := h.start(ctx, amount, all, available); err != nil {
+		log.Printf("❌ 自定义赌注发起对局失败: %v", err)
+	}
+	return true
+}
+
+// expire 自定义赌注提示超时，玩家仍未回复时作废并更新提示
+func (h *Handler) expire(key pendingKey, promptID int) {
+	h.mu.Lock()
+	pending, ok := h.pending[key]
+	if !ok || pending.promptID != promptID {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.pending, key)
+	h.mu.Unlock()
+
+	edit := tgbotapi.NewEditMessageText(key.chatID, promptID, "⌛ 自定义赌注已超时，请重新点击按钮或发送 /dice <金额>")
+	if _, err := h.client.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		log.Printf("⚠️ 更新自定义赌注提示失败: %v", err)
+	}
+}
+
+// Confirm 处理梭哈的确认和取消按钮，只有发起者本人可以操作
+func (h *Handler) Confirm(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	action, userID, amount, err := ui.ParseDiceConfirmCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+	if userID != ctx.UserID {
+		return ctx.Reply("⚠️ 只有发起者本人可以确认")
+	}
+
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Printf("⚠️ 应答梭哈确认按钮失败: %v", err)
+	}
+	if action == ui.DiceCancelAction {
+		return h.edit(ctx, query.Message.MessageID, "↩️ 已取消梭哈")
+	}
+	if err := h.edit(ctx, query.Message.MessageID, fmt.Sprintf("✅ 已确认梭哈 %s 金币", utils.FormatAmount(amount))); err != nil {
+		return err
+	}
+	return h.create(ctx, amount)
+}
+
+// create 发起对局并发送带加入按钮的公告
+func (h *Handler) create(ctx *middleware.Context, amount int64) error {
+	gameID, err := h.manager.CreateGame(ctx.UserID, ctx.ChatID, amount)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+
+	creator := "玩家"
+	if user, err := h.db.GetUser(ctx.UserID); err == nil && user != nil {
+		anonymous, _ := h.db.IsUserAnonymous(user.ID)
+		creator = ui.PublicName(user.ID, user.Username, user.FirstName, anonymous)
+	}
+	var seedHash string
+	if game, err := h.db.GetGame(gameID); err != nil {
+		log.Printf("⚠️ 获取对局 %s 种子承诺失败: %v", gameID, err)
+	} else if game != nil {
+		seedHash = game.SeedHash
+	}
+	msg, err := ui.BuildGameCreated(h.codec, ctx.ChatID, gameID, amount, creator, seedHash)
+	if err != nil {
+		return err
+	}
+	_, err = ctx.Client.Send(msg)
+	return err
+}
+
+// available 玩家可用于下注的余额（现金加赠送余额）
+func (h *Handler) available(userID int64) (int64, error) {
+	user, err := h.db.GetUser(userID)
+	if err != nil {
+		return 0, fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	if user == nil {
+		return 0, nil
+	}
+	return user.Balance + user.BonusBalance, nil
+}
+
+// edit 原地更新确认消息并移除按钮，内容未变化时忽略
+func (h *Handler) edit(ctx *middleware.Context, messageID int, text string) error {
+	if _, err := ctx.Client.Request(tgbotapi.NewEditMessageText(ctx.ChatID, messageID, text)); err != nil &&
+		!strings.Contains(err.Error(), "message is not modified") {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package dispute
+
+import (
+	"log"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler 对局结果下方的争议按钮：对局一方点击后冻结派奖并进入管理员审核
+type Handler struct {
+	manager *game.Manager
+	codec   *callback.Codec
+}
+
+// NewHandler 创建争议处理器
+func NewHandler(manager *game.Manager, codec *callback.Codec) *Handler {
+	return &Handler{manager: manager, codec: codec}
+}
+
+// Register 注册争议按钮回调
+func (h *Handler) Register(router *middleware.Router) {
+	router.HandleCallback(callback.Prefix(ui.DisputeAction), h.Dispute)
+}
+
+// Dispute 争议按钮：提出争议并在群内公告派奖已冻结
+func (h *Handler) Dispute(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	gameID, err := ui.ParseDisputeCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+
+	dispute, err := h.manager.OpenDispute(gameID, ctx.UserID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "⚖️ 已提交争议，等待管理员审核")); err != nil {
+		log.Printf("⚠️ 应答争议按钮失败: %v", err)
+	}
+
+	reporter := "玩家"
+	if ctx.From != nil {
+		reporter = ui.PublicName(ctx.UserID, ctx.From.UserName, ctx.From.FirstName, false)
+	}
+	_, err = ctx.Client.Send(tgbotapi.NewMessage(dispute.ChatID, ui.FormatDisputeOpened(dispute, reporter)))
+	return err
+}
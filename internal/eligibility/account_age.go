@@ -0,0 +1,61 @@
+package eligibility
+
+import (
+	"sort"
+	"time"
+)
+
+// idAnchor 已知注册时间的用户ID
+type idAnchor struct {
+	id   int64
+	date time.Time
+}
+
+func anchor(id int64, year int, month time.Month) idAnchor {
+	return idAnchor{id: id, date: time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// idAnchors 按公开的用户ID分布整理的锚点，用户ID随注册时间大致递增，按ID升序排列
+var idAnchors = []idAnchor{
+	anchor(1, 2013, time.August),
+	anchor(10_000_000, 2014, time.January),
+	anchor(50_000_000, 2014, time.December),
+	anchor(100_000_000, 2015, time.April),
+	anchor(200_000_000, 2016, time.June),
+	anchor(300_000_000, 2017, time.January),
+	anchor(400_000_000, 2017, time.August),
+	anchor(500_000_000, 2018, time.January),
+	anchor(700_000_000, 2018, time.September),
+	anchor(1_000_000_000, 2019, time.September),
+	anchor(1_500_000_000, 2020, time.December),
+	anchor(2_000_000_000, 2021, time.August),
+	anchor(5_000_000_000, 2021, time.December),
+	anchor(6_000_000_000, 2023, time.January),
+	anchor(7_000_000_000, 2024, time.February),
+	anchor(8_000_000_000, 2025, time.January),
+}
+
+// EstimateAccountCreated 按用户ID在相邻锚点之间线性插值估算 Telegram 账号的注册时间，
+// 误差可达数月，只用于拦截明显的新号；超出最后一个锚点时按最近的注册速度外推，不晚于 now
+func EstimateAccountCreated(userID int64, now time.Time) time.Time {
+	if userID <= idAnchors[0].id {
+		return idAnchors[0].date
+	}
+
+	i := sort.Search(len(idAnchors), func(i int) bool { return idAnchors[i].id >= userID })
+	if i == len(idAnchors) {
+		i = len(idAnchors) - 1
+	}
+	lo, hi := idAnchors[i-1], idAnchors[i]
+	ratio := float64(userID-lo.id) / float64(hi.id-lo.id)
+	estimate := lo.date.Add(time.Duration(ratio * float64(hi.date.Sub(lo.date))))
+	if estimate.After(now) {
+		return now
+	}
+	return estimate
+}
+
+// AccountAgeDays 按用户ID估算的账号注册天数
+func AccountAgeDays(userID int64, now time.Time) int {
+	return int(now.Sub(EstimateAccountCreated(userID, now)) / (24 * time.Hour))
+}
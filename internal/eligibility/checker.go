@@ -0,0 +1,90 @@
+package eligibility
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxObserved 内存中记录已写入首次见到时间的群成员数上限，超过后清空重新记录
+const maxObserved = 100000
+
+// memberKey 群组中的用户
+type memberKey struct {
+	chatID int64
+	userID int64
+}
+
+// Checker 按群组设置检查用户的下注资格：账号估算注册天数和机器人首次在本群见到该用户以来的天数
+type Checker struct {
+	db *database.DB
+
+	mu       sync.Mutex
+	observed map[memberKey]bool // 已写入首次见到时间的群成员，避免每条消息都写库
+}
+
+// NewChecker 创建下注资格检查器
+func NewChecker(db *database.DB) *Checker {
+	return &Checker{db: db, observed: make(map[memberKey]bool)}
+}
+
+// Observe 群内有用户发言或加入时记录机器人首次见到该用户的时间，作为在本群天数的起点
+func (c *Checker) Observe(chatID, userID int64) {
+	if chatID >= 0 || userID == 0 {
+		return
+	}
+
+	key := memberKey{chatID: chatID, userID: userID}
+	c.mu.Lock()
+	if c.observed[key] {
+		c.mu.Unlock()
+		return
+	}
+	if len(c.observed) >= maxObserved {
+		c.observed = make(map[memberKey]bool)
+	}
+	c.observed[key] = true
+	c.mu.Unlock()
+
+	if err := c.db.RecordChatMember(chatID, userID, time.Now()); err != nil {
+		log.Printf("⚠️ 记录群组 %d 成员 %d 失败: %v", chatID, userID, err)
+		c.mu.Lock()
+		delete(c.observed, key)
+		c.mu.Unlock()
+	}
+}
+
+// Check 检查用户能否在群组中发起或加入对局，不满足时返回拒绝提示，可直接用作 middleware.Eligible 的检查函数
+func (c *Checker) Check(chatID int64, from *tgbotapi.User) (string, error) {
+	c.Observe(chatID, from.ID)
+
+	eligibility, err := c.db.GetChatEligibility(chatID)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+
+	if eligibility.MinAccountDays > 0 && AccountAgeDays(from.ID, now) < eligibility.MinAccountDays {
+		return ui.FormatAccountTooNew(eligibility.MinAccountDays), nil
+	}
+
+	if eligibility.MinMemberDays > 0 {
+		since, ok, err := c.db.GetChatMemberSince(chatID, from.ID)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			since = now
+		}
+		eligibleAt := since.AddDate(0, 0, eligibility.MinMemberDays)
+		if now.Before(eligibleAt) {
+			return ui.FormatMemberTooNew(eligibility.MinMemberDays, eligibleAt.Sub(now)), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,81 @@
+package eligibility
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+)
+
+// maxDays 下注资格天数的上限
+const maxDays = 3650
+
+// Handler /eligibility 命令的处理器
+type Handler struct {
+	db *database.DB
+}
+
+// NewHandler 创建下注资格设置处理器
+func NewHandler(db *database.DB) *Handler {
+	return &Handler{db: db}
+}
+
+// Register 注册 /eligibility 命令，仅限群管理员
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.EligibilityCommand, h.Eligibility, middleware.ChatAdminOnly())
+}
+
+// Eligibility 不带参数时查看本群的下注资格要求，带 key=value 参数时修改，off 取消全部要求
+func (h *Handler) Eligibility(ctx *middleware.Context) error {
+	current, err := h.db.GetChatEligibility(ctx.ChatID)
+	if err != nil {
+		return err
+	}
+	args := strings.ToLower(strings.TrimSpace(ctx.Args))
+	if args == "" {
+		return ctx.Reply(ui.FormatChatEligibility(current))
+	}
+
+	updated, err := parseEligibility(*current, args)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error() + "\n" + ui.FormatEligibilityUsage())
+	}
+	if err := h.db.SetChatEligibility(ctx.ChatID, &updated); err != nil {
+		return ctx.Reply("❌ 保存下注资格失败")
+	}
+	log.Printf("🪪 群管理员 %d 将群组 %d 下注资格设置为 %+v", ctx.UserID, ctx.ChatID, updated)
+	return ctx.Reply("✅ 已更新\n\n" + ui.FormatChatEligibility(&updated))
+}
+
+// parseEligibility 在当前设置的基础上应用 account=天数、member=天数 形式的修改
+func parseEligibility(current models.ChatEligibility, args string) (models.ChatEligibility, error) {
+	if args == ui.EligibilityOff {
+		return models.ChatEligibility{}, nil
+	}
+
+	for _, field := range strings.Fields(args) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return current, fmt.Errorf("参数格式错误: %s", field)
+		}
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 || days > maxDays {
+			return current, fmt.Errorf("天数需为 0 到 %d 之间的整数: %s", maxDays, field)
+		}
+
+		switch key {
+		case "account":
+			current.MinAccountDays = days
+		case "member":
+			current.MinMemberDays = days
+		default:
+			return current, fmt.Errorf("未知参数: %s", key)
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,54 @@
+package events
+
+import (
+	"log"
+	"sync"
+)
+
+// Event 事件总线上传递的事件
+type Event interface {
+	Name() string
+}
+
+// Handler 事件处理函数
+type Handler func(Event)
+
+// Bus 进程内事件总线，订阅者异步处理事件，不阻塞发布方（如对局结算）
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe 订阅指定名称的事件
+func (b *Bus) Subscribe(name string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], handler)
+}
+
+// Publish 发布事件，每个订阅者在独立的 goroutine 中处理；b 为 nil 时忽略
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[event.Name()]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		go func(handler Handler) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("❌ 处理事件 %s 时发生 panic: %v", event.Name(), r)
+				}
+			}()
+			handler(event)
+		}(handler)
+	}
+}
@@ -0,0 +1,57 @@
+package events
+
+// GameSettledEvent 对局结算事件名
+const GameSettledEvent = "game.settled"
+
+// GameSettled 对局完成结算（平局时 WinnerID 为 0）
+type GameSettled struct {
+	GameID     string
+	ChatID     int64
+	Player1ID  int64
+	Player2ID  int64
+	BetAmount  int64
+	WinAmount  int64
+	Commission int64
+	WinnerID   int64
+	LoserID    int64
+	Draw       bool
+	// 获胜者的用户名和名字，对外展示前需脱敏
+	WinnerUsername  string
+	WinnerFirstName string
+}
+
+// Name 实现 Event
+func (GameSettled) Name() string {
+	return GameSettledEvent
+}
+
+// GameCreatedEvent 发起对局事件名
+const GameCreatedEvent = "game.created"
+
+// GameCreated 玩家在群组中发起了等待加入的对局
+type GameCreated struct {
+	GameID    string
+	ChatID    int64
+	PlayerID  int64
+	BetAmount int64
+}
+
+// Name 实现 Event
+func (GameCreated) Name() string {
+	return GameCreatedEvent
+}
+
+// GameJoinedEvent 对局被加入事件名
+const GameJoinedEvent = "game.joined"
+
+// GameJoined 等待中的对局已被其他玩家加入，不再出现在等待列表中
+type GameJoined struct {
+	GameID   string
+	ChatID   int64
+	PlayerID int64
+}
+
+// Name 实现 Event
+func (GameJoined) Name() string {
+	return GameJoinedEvent
+}
@@ -0,0 +1,72 @@
+package exposure
+
+import (
+	"log"
+	"strings"
+
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+)
+
+// Handler /exposure 管理员命令的处理器，用于查看和调整群组每小时下注上限
+type Handler struct {
+	manager  *game.Manager
+	adminIDs []int64
+}
+
+// NewHandler 创建群组下注上限处理器
+func NewHandler(manager *game.Manager, adminIDs []int64) *Handler {
+	return &Handler{manager: manager, adminIDs: adminIDs}
+}
+
+// Register 注册 /exposure 命令，仅限管理员
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.ExposureCommand, h.Exposure, middleware.AdminOnly(h.adminIDs))
+}
+
+// Exposure 不带参数时查看本群本小时的下注总额和上限，带参数时调整上限或解除本小时的暂停
+func (h *Handler) Exposure(ctx *middleware.Context) error {
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("⚠️ 请在群组中使用 /exposure")
+	}
+
+	arg := strings.ToLower(strings.TrimSpace(ctx.Args))
+	switch arg {
+	case "":
+		exposure, err := h.manager.ChatExposure(ctx.ChatID)
+		if err != nil {
+			return err
+		}
+		return ctx.Reply(ui.FormatChatExposure(exposure.Cap, exposure.Custom, exposure.Turnover, exposure.WindowEnd, exposure.Overridden))
+	case ui.ExposureResume:
+		exposure, err := h.manager.ChatExposure(ctx.ChatID)
+		if err != nil {
+			return err
+		}
+		h.manager.OverrideExposureCap(ctx.ChatID)
+		log.Printf("🔓 管理员 %d 解除群组 %d 本小时的下注上限暂停", ctx.UserID, ctx.ChatID)
+		return ctx.Reply(ui.FormatExposureResumed(exposure.WindowEnd))
+	}
+
+	var hourlyCap int64
+	switch arg {
+	case ui.ExposureOff:
+		hourlyCap = -1
+	case ui.ExposureDefault:
+		hourlyCap = 0
+	default:
+		amount, err := utils.ParseAmount(arg)
+		if err != nil {
+			return ctx.Reply("❌ 无效的金额\n" + ui.FormatExposureUsage())
+		}
+		hourlyCap = amount
+	}
+	if err := h.manager.SetChatHourlyCap(ctx.ChatID, hourlyCap); err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	result := ui.FormatExposureCapSet(hourlyCap)
+	log.Printf("🛡️ 管理员 %d 调整群组 %d 每小时下注上限: %s", ctx.UserID, ctx.ChatID, result)
+	return ctx.Reply(result)
+}
@@ -0,0 +1,95 @@
+package feed
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/events"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Relay 将大额对局的结算转发到公开的大奖频道
+type Relay struct {
+	db        *database.DB
+	client    telegram.Client
+	channelID int64
+	minStake  int64         // 下注额不低于该值才转发
+	minGap    time.Duration // 两条转发的最小间隔，间隔内的大奖直接丢弃
+
+	mu       sync.Mutex
+	lastSent time.Time
+}
+
+// NewRelay 创建大奖频道转发器
+func NewRelay(db *database.DB, client telegram.Client, channelID, minStake int64, minGap time.Duration) *Relay {
+	return &Relay{
+		db:        db,
+		client:    client,
+		channelID: channelID,
+		minStake:  minStake,
+		minGap:    minGap,
+	}
+}
+
+// Subscribe 订阅对局结算事件
+func (r *Relay) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.GameSettledEvent, r.handle)
+}
+
+// handle 处理结算事件：平局、小额对局、退出转发的群组不转发
+func (r *Relay) handle(event events.Event) {
+	settled, ok := event.(events.GameSettled)
+	if !ok || settled.Draw || settled.BetAmount < r.minStake {
+		return
+	}
+
+	optOut, err := r.db.IsChatFeedOptOut(settled.ChatID)
+	if err != nil {
+		log.Printf("⚠️ 获取群组 %d 转发设置失败: %v", settled.ChatID, err)
+		return
+	}
+	if optOut {
+		return
+	}
+
+	if !r.reserve() {
+		log.Printf("⏳ 大奖频道转发过于频繁，跳过对局 %s", settled.GameID)
+		return
+	}
+
+	// 选择匿名的玩家只显示代号，其他玩家显示脱敏后的名字
+	name := ui.MaskName(settled.WinnerUsername, settled.WinnerFirstName)
+	anonymous, err := r.db.IsUserAnonymous(settled.WinnerID)
+	if err != nil {
+		log.Printf("⚠️ 获取用户 %d 匿名设置失败: %v", settled.WinnerID, err)
+		return
+	}
+	if anonymous {
+		name = ui.AnonymousAlias(settled.WinnerID)
+	}
+
+	text := ui.FormatBigWin(name, settled.BetAmount, settled.WinAmount)
+	if _, err := r.client.Send(tgbotapi.NewMessage(r.channelID, text)); err != nil {
+		log.Printf("❌ 转发大奖到频道 %d 失败: %v", r.channelID, err)
+		return
+	}
+	log.Printf("📣 已转发对局 %s 的大奖到频道 %d", settled.GameID, r.channelID)
+}
+
+// reserve 检查并占用一次转发机会
+func (r *Relay) reserve() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if !r.lastSent.IsZero() && now.Sub(r.lastSent) < r.minGap {
+		return false
+	}
+	r.lastSent = now
+	return true
+}
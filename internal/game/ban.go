@@ -0,0 +1,69 @@
+package game
+
+import (
+	"fmt"
+	"log"
+)
+
+// BannedError 用户已被管理员封禁，不能参与对局
+type BannedError struct {
+	Reason string
+}
+
+func (e *BannedError) Error() string {
+	text := "🙏 抱歉，您的账户已被暂停使用，暂时无法参与对局"
+	if e.Reason != "" {
+		text += "\n📝 原因：" + e.Reason
+	}
+	return text + "\n如有疑问请联系管理员"
+}
+
+// checkBanned 用户被封禁时返回 BannedError
+func (m *Manager) checkBanned(userID int64) error {
+	ban, err := m.db.GetUserBan(userID)
+	if err != nil {
+		return fmt.Errorf("获取用户封禁状态失败: %v", err)
+	}
+	if ban != nil {
+		return &BannedError{Reason: ban.Reason}
+	}
+	return nil
+}
+
+// BanUser 封禁用户，并让其发起的等待中对局立即过期退款。返回过期的对局数
+func (m *Manager) BanUser(userID int64, reason, bannedBy string) (int, error) {
+	found, err := m.db.BanUser(userID, reason, bannedBy)
+	if err != nil {
+		return 0, fmt.Errorf("封禁用户失败: %v", err)
+	}
+	if !found {
+		return 0, fmt.Errorf("用户不存在")
+	}
+	log.Printf("🚫 用户 %d 已被 %s 封禁：%s", userID, bannedBy, reason)
+
+	gameIDs, err := m.db.GetWaitingGameIDsByCreator(userID)
+	if err != nil {
+		return 0, fmt.Errorf("获取用户等待中的对局失败: %v", err)
+	}
+	for _, gameID := range gameIDs {
+		m.cancelGameTimeout(gameID)
+		m.expireWaitingGame(gameID, "发起者已被封禁，自动退款")
+	}
+	if len(gameIDs) > 0 {
+		log.Printf("↩️ 被封禁用户 %d 的 %d 个等待中对局已过期退款", userID, len(gameIDs))
+	}
+	return len(gameIDs), nil
+}
+
+// UnbanUser 解除用户的封禁，用户未被封禁时返回错误
+func (m *Manager) UnbanUser(userID int64) error {
+	unbanned, err := m.db.UnbanUser(userID)
+	if err != nil {
+		return fmt.Errorf("解除封禁失败: %v", err)
+	}
+	if !unbanned {
+		return fmt.Errorf("用户未被封禁")
+	}
+	log.Printf("✅ 用户 %d 已解除封禁", userID)
+	return nil
+}
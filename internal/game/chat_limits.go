@@ -0,0 +1,121 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+const (
+	// DefaultGameTimeout 对局等待加入的默认超时
+	DefaultGameTimeout = 60 * time.Second
+	// MinGameTimeout 群组可设置的等待超时下限
+	MinGameTimeout = 10 * time.Second
+	// MaxGameTimeout 群组可设置的等待超时上限
+	MaxGameTimeout = 10 * time.Minute
+	// MaxChatFeeRate 群组可设置的手续费率上限
+	MaxChatFeeRate = 0.5
+)
+
+// ChatLimits 群组实际生效的下注限额、手续费率和等待超时
+type ChatLimits struct {
+	MinBet      int64
+	MaxBet      int64
+	FeeRate     float64
+	GameTimeout time.Duration
+	// 群组自定义的原始设置，用于区分沿用全局配置的项
+	Custom *models.ChatGameLimits
+}
+
+// MergeChatLimits 群组未设置的项沿用全局的下注限额和手续费率
+func MergeChatLimits(custom *models.ChatGameLimits, minBet, maxBet int64, feeRate float64) *ChatLimits {
+	limits := &ChatLimits{
+		MinBet:      minBet,
+		MaxBet:      maxBet,
+		FeeRate:     feeRate,
+		GameTimeout: DefaultGameTimeout,
+		Custom:      custom,
+	}
+	if custom == nil {
+		limits.Custom = &models.ChatGameLimits{}
+		return limits
+	}
+	if custom.MinBet > 0 {
+		limits.MinBet = custom.MinBet
+	}
+	if custom.MaxBet > 0 {
+		limits.MaxBet = custom.MaxBet
+	}
+	if custom.FeeRate != nil {
+		limits.FeeRate = *custom.FeeRate
+	}
+	if custom.GameTimeout > 0 {
+		limits.GameTimeout = time.Duration(custom.GameTimeout) * time.Second
+	}
+	return limits
+}
+
+// ChatLimits 获取群组生效的下注限额、手续费率和等待超时
+func (m *Manager) ChatLimits(chatID int64) (*ChatLimits, error) {
+	custom, err := m.db.GetChatGameLimits(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("获取群组对局设置失败: %v", err)
+	}
+	return MergeChatLimits(custom, m.config.MinBet, m.config.MaxBet, m.feeRate), nil
+}
+
+// SetChatGameLimits 校验并保存群组自定义的下注限额、手续费率和等待超时。
+// 自定义限额只能在全局限额之内收紧，已发起的对局按原下注额继续进行
+func (m *Manager) SetChatGameLimits(chatID int64, custom *models.ChatGameLimits) error {
+	if custom.MinBet < 0 || custom.MaxBet < 0 {
+		return fmt.Errorf("下注限额不能为负数")
+	}
+	if custom.MinBet > 0 && custom.MinBet < m.config.MinBet {
+		return fmt.Errorf("最低下注不能低于全局下限 %s", utils.FormatAmount(m.config.MinBet))
+	}
+	if custom.MaxBet > m.config.MaxBet {
+		return fmt.Errorf("最高下注不能超过全局上限 %s", utils.FormatAmount(m.config.MaxBet))
+	}
+
+	merged := MergeChatLimits(custom, m.config.MinBet, m.config.MaxBet, m.feeRate)
+	if merged.MinBet > merged.MaxBet {
+		return fmt.Errorf("最低下注 %s 不能高于最高下注 %s", utils.FormatAmount(merged.MinBet), utils.FormatAmount(merged.MaxBet))
+	}
+	if custom.FeeRate != nil && (*custom.FeeRate < 0 || *custom.FeeRate > MaxChatFeeRate) {
+		return fmt.Errorf("手续费率必须在 0 到 %.0f%% 之间", MaxChatFeeRate*100)
+	}
+	if custom.GameTimeout != 0 {
+		timeout := time.Duration(custom.GameTimeout) * time.Second
+		if timeout < MinGameTimeout || timeout > MaxGameTimeout {
+			return fmt.Errorf("等待超时必须在 %v 到 %v 之间", MinGameTimeout, MaxGameTimeout)
+		}
+	}
+
+	if err := m.db.SetChatGameLimits(chatID, custom); err != nil {
+		return fmt.Errorf("更新群组对局设置失败: %v", err)
+	}
+	return nil
+}
+
+// chatFeeRate 结算时使用的群组手续费率，读取失败时沿用全局费率
+func (m *Manager) chatFeeRate(chatID int64) float64 {
+	limits, err := m.ChatLimits(chatID)
+	if err != nil {
+		log.Printf("⚠️ %v，按全局手续费率结算", err)
+		return m.feeRate
+	}
+	return limits.FeeRate
+}
+
+// chatGameTimeout 群组对局等待加入的超时，读取失败时使用默认值
+func (m *Manager) chatGameTimeout(chatID int64) time.Duration {
+	limits, err := m.ChatLimits(chatID)
+	if err != nil {
+		log.Printf("⚠️ %v，使用默认等待超时", err)
+		return DefaultGameTimeout
+	}
+	return limits.GameTimeout
+}
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// MaxGameCooldown 群组两局之间冷却时间的上限
+const MaxGameCooldown = time.Hour
+
+// CooldownError 本群上一局刚结束、仍在冷却中，Remaining 后才能开始下一局
+type CooldownError struct {
+	Remaining time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("本群上一局刚结束，请 %d 秒后再开始下一局", cooldownSeconds(e.Remaining))
+}
+
+// cooldownSeconds 剩余冷却时间向上取整到秒，避免显示 0 秒
+func cooldownSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+// SetChatGameCooldown 设置群组两局之间的冷却时间，0 表示不限制
+func (m *Manager) SetChatGameCooldown(chatID int64, cooldown time.Duration) error {
+	if cooldown < 0 || cooldown > MaxGameCooldown {
+		return fmt.Errorf("冷却时间必须在 0 到 %v 之间", MaxGameCooldown)
+	}
+	if err := m.db.SetChatGameCooldown(chatID, int(cooldown/time.Second)); err != nil {
+		return fmt.Errorf("更新群组冷却时间失败: %v", err)
+	}
+	// 缩短或取消冷却后，排队的请求可能已经可以开局
+	go m.startQueued(chatID)
+	return nil
+}
+
+// ChatGameCooldown 获取群组两局之间的冷却时间
+func (m *Manager) ChatGameCooldown(chatID int64) (time.Duration, error) {
+	seconds, err := m.db.GetChatGameCooldown(chatID)
+	if err != nil {
+		return 0, fmt.Errorf("获取群组冷却时间失败: %v", err)
+	}
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// CooldownRemaining 本群距离可以开始下一局的剩余时间，不在冷却中时返回 0。
+// 结束时间只保存在内存中，重启后不再冷却
+func (m *Manager) CooldownRemaining(chatID int64) (time.Duration, error) {
+	m.cooldownMu.Lock()
+	finished, ok := m.lastFinished[chatID]
+	m.cooldownMu.Unlock()
+	if !ok {
+		return 0, nil
+	}
+
+	cooldown, err := m.ChatGameCooldown(chatID)
+	if err != nil {
+		return 0, err
+	}
+	if remaining := time.Until(finished.Add(cooldown)); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+// markFinished 记录本群对局结束时间，作为冷却的起点
+func (m *Manager) markFinished(chatID int64) {
+	m.cooldownMu.Lock()
+	defer m.cooldownMu.Unlock()
+	m.lastFinished[chatID] = time.Now()
+}
+
+// checkCooldown 冷却中且玩家不是管理员时返回 CooldownError
+func (m *Manager) checkCooldown(chatID, playerID int64) error {
+	if m.isAdmin(playerID) {
+		return nil
+	}
+	remaining, err := m.CooldownRemaining(chatID)
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		return &CooldownError{Remaining: remaining}
+	}
+	return nil
+}
+
+// isAdmin 管理员不受冷却限制
+func (m *Manager) isAdmin(userID int64) bool {
+	for _, id := range m.config.AdminIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// scheduleQueued 冷却结束后继续为本群排队的加入请求开局
+func (m *Manager) scheduleQueued(chatID int64, after time.Duration) {
+	log.Printf("⏳ 群组 %d 冷却中，%d 秒后处理排队的加入请求", chatID, cooldownSeconds(after))
+	time.AfterFunc(after, func() {
+		m.startQueued(chatID)
+	})
+}
@@ -0,0 +1,98 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// DailyCooldown 两次签到之间的最短间隔
+const DailyCooldown = 24 * time.Hour
+
+// dailyStreakWindow 距上次签到超过该时长时连续签到中断，重新从第 1 天计算
+const dailyStreakWindow = 2 * DailyCooldown
+
+// DailyNotReadyError 距离上次签到不足 24 小时，Remaining 后才能再次签到
+type DailyNotReadyError struct {
+	Remaining time.Duration
+	Streak    int
+}
+
+func (e *DailyNotReadyError) Error() string {
+	return fmt.Sprintf("今天已经签到过了，请 %v 后再来", e.Remaining.Round(time.Minute))
+}
+
+// DailyReward 连续签到 streak 天的签到金额：第 2 天起每天加成 rate（如 0.1 即第 2 天 +10%），最多累计 maxDays 天
+func DailyReward(base int64, streak int, rate float64, maxDays int) int64 {
+	if maxDays > 0 && streak > maxDays {
+		streak = maxDays
+	}
+	if streak < 1 || rate <= 0 {
+		return base
+	}
+	return int64(math.Round(float64(base) * (1 + rate*float64(streak-1))))
+}
+
+// NextDailyStreak 本次签到的连续天数：上次签到在 48 小时内时延续，否则从 1 重新计算
+func NextDailyStreak(last *models.DailyClaim, now time.Time) int {
+	if last == nil || now.Sub(last.ClaimedAt) >= dailyStreakWindow {
+		return 1
+	}
+	return last.Streak + 1
+}
+
+// ClaimDaily 领取每日签到金币，返回本次签到记录和到账后的余额。
+// 与下注共用管理器锁和余额验证器的操作间隔限制，数据库事务内再次检查是否已签到
+func (m *Manager) ClaimDaily(userID int64) (*models.DailyClaim, int64, error) {
+	if m.config.DailyBonus <= 0 {
+		return nil, 0, fmt.Errorf("每日签到未开启")
+	}
+	if err := m.validator.ThrottleUser(userID); err != nil {
+		return nil, 0, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	user, err := m.db.GetUser(userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	if user == nil {
+		return nil, 0, fmt.Errorf("用户不存在，请先发送 /start")
+	}
+
+	last, err := m.db.GetLastDailyClaim(userID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("获取签到记录失败: %v", err)
+	}
+	now := time.Now()
+	if last != nil && now.Sub(last.ClaimedAt) < DailyCooldown {
+		return nil, 0, &DailyNotReadyError{Remaining: last.ClaimedAt.Add(DailyCooldown).Sub(now), Streak: last.Streak}
+	}
+
+	streak := NextDailyStreak(last, now)
+	claim := &models.DailyClaim{
+		UserID:    userID,
+		Amount:    DailyReward(m.config.DailyBonus, streak, m.config.DailyStreakRate, int(m.config.DailyStreakMaxDays)),
+		Streak:    streak,
+		ClaimedAt: now,
+	}
+	balance, err := m.db.ClaimDailyBonus(claim, DailyCooldown)
+	if errors.Is(err, database.ErrDailyAlreadyClaimed) {
+		// 其他进程抢先完成了签到
+		return nil, 0, &DailyNotReadyError{Remaining: DailyCooldown, Streak: streak}
+	}
+	if err != nil {
+		return nil, 0, fmt.Errorf("发放签到金币失败: %v", err)
+	}
+
+	log.Printf("🎁 用户 %d 每日签到，连续 %d 天，获得 %s 金币", userID, streak, utils.FormatAmount(claim.Amount))
+	return claim, balance, nil
+}
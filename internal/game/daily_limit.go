@@ -0,0 +1,85 @@
+package game
+
+import (
+	"fmt"
+	"time"
+)
+
+// DailyLimitError 用户今天的对局数已达每日上限，ResetAt 后恢复
+type DailyLimitError struct {
+	Limit   int
+	ResetAt time.Time
+}
+
+func (e *DailyLimitError) Error() string {
+	return fmt.Sprintf("🎯 你今天已经玩了 %d 局，达到每日对局上限\n⏰ 上限将于 %s 重置（约 %s 后），休息一下，明天再来吧",
+		e.Limit, e.ResetAt.Format("01-02 15:04"), formatResetIn(time.Until(e.ResetAt)))
+}
+
+// formatResetIn 距离重置的时长，不足一小时时显示分钟
+func formatResetIn(d time.Duration) string {
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes < 60 {
+		return fmt.Sprintf("%d 分钟", minutes)
+	}
+	return fmt.Sprintf("%d 小时 %d 分钟", minutes/60, minutes%60)
+}
+
+// DailyGames 用户的每日对局情况
+type DailyGames struct {
+	Played  int
+	Limit   int // 0 表示不限制
+	Exempt  bool
+	ResetAt time.Time
+}
+
+// Reached 是否已达上限
+func (d *DailyGames) Reached() bool {
+	return d.Limit > 0 && !d.Exempt && d.Played >= d.Limit
+}
+
+// dailyLimitWindow 当日统计窗口的起点和重置时间，按服务器所在时区的零点重置
+func dailyLimitWindow(now time.Time) (start, reset time.Time) {
+	start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	return start, start.AddDate(0, 0, 1)
+}
+
+// UserDailyGames 获取用户今天已进行的对局数、生效的上限及是否豁免；管理员始终豁免
+func (m *Manager) UserDailyGames(userID int64) (*DailyGames, error) {
+	start, reset := dailyLimitWindow(time.Now())
+	daily := &DailyGames{Limit: int(m.config.DailyGameLimit), ResetAt: reset}
+
+	exempt, err := m.db.IsUserDailyLimitExempt(userID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户每日对局豁免失败: %v", err)
+	}
+	daily.Exempt = exempt || m.isAdmin(userID)
+
+	if daily.Played, err = m.db.CountUserGamesSince(userID, start); err != nil {
+		return nil, fmt.Errorf("统计用户今日对局数失败: %v", err)
+	}
+	return daily, nil
+}
+
+// SetDailyLimitExempt 管理员豁免或恢复用户的每日对局数上限
+func (m *Manager) SetDailyLimitExempt(userID int64, exempt bool) error {
+	if err := m.db.SetUserDailyLimitExempt(userID, exempt); err != nil {
+		return fmt.Errorf("更新用户每日对局豁免失败: %v", err)
+	}
+	return nil
+}
+
+// checkDailyLimit 用户今天的对局数已达上限且未被豁免时返回 DailyLimitError
+func (m *Manager) checkDailyLimit(userID int64) error {
+	if m.config.DailyGameLimit <= 0 || m.isAdmin(userID) {
+		return nil
+	}
+	daily, err := m.UserDailyGames(userID)
+	if err != nil {
+		return err
+	}
+	if !daily.Reached() {
+		return nil
+	}
+	return &DailyLimitError{Limit: daily.Limit, ResetAt: daily.ResetAt}
+}
@@ -0,0 +1,32 @@
+package game
+
+import (
+	"fmt"
+	"log"
+)
+
+// HandleAccountDeleted 玩家的 Telegram 账号已注销：标记用户，并立即让其发起的等待中对局过期，
+// 下注退回账本余额，私信发送失败不影响退款。返回过期的对局数
+func (m *Manager) HandleAccountDeleted(userID int64) (int, error) {
+	// 先标记注销（同时关闭私信），过期回调中的私信通知会被跳过
+	marked, err := m.db.MarkUserDeleted(userID)
+	if err != nil {
+		return 0, fmt.Errorf("标记用户注销失败: %v", err)
+	}
+	if marked {
+		log.Printf("🪦 用户 %d 的 Telegram 账号已注销", userID)
+	}
+
+	gameIDs, err := m.db.GetWaitingGameIDsByCreator(userID)
+	if err != nil {
+		return 0, fmt.Errorf("获取用户等待中的对局失败: %v", err)
+	}
+	for _, gameID := range gameIDs {
+		m.cancelGameTimeout(gameID)
+		m.expireWaitingGame(gameID, "发起者账号已注销，自动退款")
+	}
+	if len(gameIDs) > 0 {
+		log.Printf("↩️ 已注销用户 %d 的 %d 个等待中对局已过期退款", userID, len(gameIDs))
+	}
+	return len(gameIDs), nil
+}
@@ -0,0 +1,143 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// DisputeWindow 对局结算后可以提出争议的时长
+const DisputeWindow = 10 * time.Minute
+
+// SetDisputeOpenedCallback 设置玩家提出争议后的回调（提醒管理员审核）
+func (m *Manager) SetDisputeOpenedCallback(callback func(dispute *models.Dispute)) {
+	m.onDisputeOpened = callback
+}
+
+// SetDisputeResolvedCallback 设置争议审核完成后的回调（通知双方）
+func (m *Manager) SetDisputeResolvedCallback(callback func(dispute *models.Dispute)) {
+	m.onDisputeResolved = callback
+}
+
+// OpenDispute 对局一方在结算后 DisputeWindow 内对结果提出争议，冻结获胜者的派奖等待管理员审核。
+// 平局和认输的对局没有可冻结的派奖，不能提出争议
+func (m *Manager) OpenDispute(gameID string, reporterID int64) (*models.Dispute, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+	if game == nil || game.Player2ID == nil {
+		return nil, fmt.Errorf("游戏不存在")
+	}
+	if reporterID != game.Player1ID && reporterID != *game.Player2ID {
+		return nil, fmt.Errorf("只有对局双方可以提出争议")
+	}
+	if game.Status != models.GameStatusFinished {
+		return nil, fmt.Errorf("只能对已结算的对局提出争议")
+	}
+	if game.WinnerID == nil {
+		return nil, fmt.Errorf("平局已退还双方下注，无需提出争议")
+	}
+	if time.Since(game.UpdatedAt) > DisputeWindow {
+		return nil, fmt.Errorf("对局结算已超过 %d 分钟，不能再提出争议", int(DisputeWindow.Minutes()))
+	}
+
+	loserID := game.Player1ID
+	if *game.WinnerID == game.Player1ID {
+		loserID = *game.Player2ID
+	}
+	dispute := &models.Dispute{
+		GameID:     game.ID,
+		ChatID:     game.ChatID,
+		ReporterID: reporterID,
+		WinnerID:   *game.WinnerID,
+		LoserID:    loserID,
+		BetAmount:  game.BetAmount,
+	}
+	if err := m.db.CreateDispute(dispute); err != nil {
+		return nil, err
+	}
+
+	log.Printf("⚖️ 玩家 %d 对对局 %s 提出争议 #%d，冻结派奖 %s", reporterID, gameID, dispute.ID, utils.FormatAmount(dispute.Held))
+	m.recordGameEvent(&models.GameEvent{
+		GameID: gameID,
+		Type:   models.GameEventDisputed,
+		UserID: reporterID,
+		ChatID: game.ChatID,
+		Detail: fmt.Sprintf("提出争议 #%d，冻结获胜者派奖 %s 金币（派奖 %s 金币）", dispute.ID,
+			utils.FormatAmount(dispute.Held), utils.FormatAmount(dispute.Payout)),
+	})
+	if m.onDisputeOpened != nil {
+		go m.onDisputeOpened(dispute)
+	}
+	return dispute, nil
+}
+
+// ReleaseDispute 审核后维持原结果，冻结的派奖退回获胜者
+func (m *Manager) ReleaseDispute(id int64, reviewer, note string) (*models.Dispute, error) {
+	if err := m.db.ReleaseDispute(id, reviewer, note); err != nil {
+		return nil, err
+	}
+	dispute, err := m.resolvedDispute(id, "维持原结果，退回冻结的派奖")
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("✅ 争议 #%d 已由 %s 维持原结果", id, reviewer)
+	return dispute, nil
+}
+
+// ReverseDispute 审核后撤销原结果：对局作废，双方按下注额退款，冻结金额不足以收回赢得部分时差额由平台承担
+func (m *Manager) ReverseDispute(id int64, reviewer, note string) (*models.Dispute, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if err := m.db.ReverseDispute(id, reviewer, note); err != nil {
+		return nil, err
+	}
+	dispute, err := m.resolvedDispute(id, "撤销原结果，对局作废并退还双方下注")
+	if err != nil {
+		return nil, err
+	}
+	if shortfall := dispute.Payout - dispute.BetAmount - dispute.Held; shortfall > 0 {
+		log.Printf("⚠️ 争议 #%d 冻结金额不足，平台承担 %s 金币", id, utils.FormatAmount(shortfall))
+	}
+	log.Printf("↩️ 争议 #%d 已由 %s 撤销对局 %s 的结果", id, reviewer, dispute.GameID)
+	return dispute, nil
+}
+
+// Disputes 按状态获取对局争议，status 为空时不限状态
+func (m *Manager) Disputes(status string, limit int) ([]*models.Dispute, error) {
+	return m.db.GetDisputes(status, limit)
+}
+
+// resolvedDispute 读取审核后的争议，写入对局时间线并触发回调
+func (m *Manager) resolvedDispute(id int64, detail string) (*models.Dispute, error) {
+	dispute, err := m.db.GetDispute(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取争议记录失败: %v", err)
+	}
+	if dispute == nil {
+		return nil, fmt.Errorf("争议 #%d 不存在", id)
+	}
+
+	if dispute.Note != "" {
+		detail += "；" + dispute.Note
+	}
+	m.recordGameEvent(&models.GameEvent{
+		GameID: dispute.GameID,
+		Type:   models.GameEventDisputed,
+		ChatID: dispute.ChatID,
+		Detail: fmt.Sprintf("争议 #%d 由 %s 审核：%s", dispute.ID, dispute.Reviewer, detail),
+	})
+	m.invalidatePlayerCaches(&models.Game{Player1ID: dispute.WinnerID, Player2ID: &dispute.LoserID})
+	if m.onDisputeResolved != nil {
+		go m.onDisputeResolved(dispute)
+	}
+	return dispute, nil
+}
@@ -0,0 +1,81 @@
+package game
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	// chatTouchInterval 同一群组两次写入活跃时间的最小间隔，避免每条消息都写库
+	chatTouchInterval = 10 * time.Minute
+	// dormancySweepInterval 两次检查休眠群组的最小间隔
+	dormancySweepInterval = time.Hour
+)
+
+// SetChatDormancy 设置群组无活动多久后标记为休眠，0 表示不启用。
+// 休眠的群组不再收到奖池播报等定时消息，群内有新消息时自动恢复
+func (m *Manager) SetChatDormancy(after time.Duration) {
+	m.dormancyMu.Lock()
+	defer m.dormancyMu.Unlock()
+	m.dormantAfter = after
+}
+
+// ObserveChatActivity 群内有新消息时记录活跃时间，休眠中的群组随即恢复
+func (m *Manager) ObserveChatActivity(chatID int64) {
+	m.dormancyMu.Lock()
+	if m.dormantAfter <= 0 {
+		m.dormancyMu.Unlock()
+		return
+	}
+	if touched, ok := m.chatTouched[chatID]; ok && time.Since(touched) < chatTouchInterval {
+		m.dormancyMu.Unlock()
+		return
+	}
+	m.chatTouched[chatID] = time.Now()
+	m.dormancyMu.Unlock()
+
+	reactivated, err := m.db.TouchChatActivity(chatID)
+	if err != nil {
+		log.Printf("⚠️ 记录群组 %d 活跃时间失败: %v", chatID, err)
+		m.dormancyMu.Lock()
+		delete(m.chatTouched, chatID)
+		m.dormancyMu.Unlock()
+		return
+	}
+	if reactivated {
+		log.Printf("🌅 群组 %d 有新消息，已解除休眠", chatID)
+	}
+}
+
+// sweepDormantChats 将长期无活动的群组标记为休眠，并释放这些群组在内存中的状态
+func (m *Manager) sweepDormantChats() {
+	m.dormancyMu.Lock()
+	after := m.dormantAfter
+	now := time.Now()
+	if after <= 0 || now.Sub(m.lastDormancySweep) < dormancySweepInterval {
+		m.dormancyMu.Unlock()
+		return
+	}
+	m.lastDormancySweep = now
+	for chatID, touched := range m.chatTouched {
+		if now.Sub(touched) >= chatTouchInterval {
+			delete(m.chatTouched, chatID)
+		}
+	}
+	m.dormancyMu.Unlock()
+
+	chatIDs, err := m.db.MarkDormantChats(now.Add(-after))
+	if err != nil {
+		log.Printf("❌ 标记休眠群组失败: %v", err)
+	}
+	if len(chatIDs) == 0 {
+		return
+	}
+
+	m.cooldownMu.Lock()
+	for _, chatID := range chatIDs {
+		delete(m.lastFinished, chatID)
+	}
+	m.cooldownMu.Unlock()
+	log.Printf("💤 %d 个群组超过 %.0f 天无活动，已标记为休眠", len(chatIDs), after.Hours()/24)
+}
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"telegram-dice-bot/internal/database"
 	"telegram-dice-bot/internal/logger"
 	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/rules"
 	"telegram-dice-bot/internal/security"
 	"telegram-dice-bot/internal/utils"
 )
@@ -86,8 +88,8 @@ func (em *EnhancedManager) CreateGameSecure(playerID, chatID int64, betAmount in
 
 	if betAmount > em.config.MaxBet {
 		audit.Success = false
-		audit.ErrorMsg = fmt.Sprintf("下注金额不能超过%d", em.config.MaxBet)
-		return "", fmt.Errorf(audit.ErrorMsg)
+		audit.ErrorMsg = fmt.Sprintf("下注金额不能超过%s", utils.FormatAmount(em.config.MaxBet))
+		return "", errors.New(audit.ErrorMsg)
 	}
 
 	// 获取用户信息
@@ -95,13 +97,13 @@ func (em *EnhancedManager) CreateGameSecure(playerID, chatID int64, betAmount in
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("获取用户信息失败: %v", err)
-		return "", fmt.Errorf(audit.ErrorMsg)
+		return "", errors.New(audit.ErrorMsg)
 	}
 
 	if user == nil {
 		audit.Success = false
 		audit.ErrorMsg = "用户不存在"
-		return "", fmt.Errorf(audit.ErrorMsg)
+		return "", errors.New(audit.ErrorMsg)
 	}
 
 	// 记录操作前的余额
@@ -111,8 +113,8 @@ func (em *EnhancedManager) CreateGameSecure(playerID, chatID int64, betAmount in
 	// 严格的余额验证
 	if user.Balance < betAmount {
 		audit.Success = false
-		audit.ErrorMsg = fmt.Sprintf("余额不足，请存款后再试。当前余额: %d，需要: %d", user.Balance, betAmount)
-		return "", fmt.Errorf(audit.ErrorMsg)
+		audit.ErrorMsg = fmt.Sprintf("余额不足，请存款后再试。当前余额: %s，需要: %s", utils.FormatAmount(user.Balance), utils.FormatAmount(betAmount))
+		return "", errors.New(audit.ErrorMsg)
 	}
 
 	// 计算新余额
@@ -120,7 +122,7 @@ func (em *EnhancedManager) CreateGameSecure(playerID, chatID int64, betAmount in
 	if newBalance < 0 {
 		audit.Success = false
 		audit.ErrorMsg = "余额不足，请存款后再试"
-		return "", fmt.Errorf(audit.ErrorMsg)
+		return "", errors.New(audit.ErrorMsg)
 	}
 
 	audit.Details["new_balance"] = newBalance
@@ -147,7 +149,7 @@ func (em *EnhancedManager) CreateGameSecure(playerID, chatID int64, betAmount in
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("安全验证失败: %v", err)
 		em.security.FailOperation(securityOp.ID, audit.ErrorMsg)
-		return "", fmt.Errorf(audit.ErrorMsg)
+		return "", errors.New(audit.ErrorMsg)
 	}
 
 	// 创建游戏和交易记录
@@ -158,6 +160,13 @@ func (em *EnhancedManager) CreateGameSecure(playerID, chatID int64, betAmount in
 		Status:    models.GameStatusWaiting,
 		ChatID:    chatID,
 	}
+	// 开局前生成种子，自动开骰时按种子生成骰子
+	if err := seedGame(game); err != nil {
+		audit.Success = false
+		audit.ErrorMsg = err.Error()
+		em.security.RollbackOperation(securityOp.ID, audit.ErrorMsg)
+		return "", err
+	}
 
 	tx := &models.Transaction{
 		ID:          utils.GenerateTransactionID(),
@@ -176,7 +185,7 @@ func (em *EnhancedManager) CreateGameSecure(playerID, chatID int64, betAmount in
 		
 		// 回滚安全操作
 		em.security.RollbackOperation(securityOp.ID, audit.ErrorMsg)
-		return "", fmt.Errorf(audit.ErrorMsg)
+		return "", errors.New(audit.ErrorMsg)
 	}
 
 	// 标记安全操作完成
@@ -187,7 +196,8 @@ func (em *EnhancedManager) CreateGameSecure(playerID, chatID int64, betAmount in
 
 	// 记录成功
 	audit.Success = true
-	em.logger.Info(fmt.Sprintf("游戏创建成功: 用户=%d, 游戏ID=%s, 金额=%d", playerID, gameID, betAmount))
+	audit.GameID = &gameID
+	em.logger.Info("游戏创建成功: 用户=%d, 游戏ID=%s, 金额=%d", playerID, gameID, betAmount)
 
 	return gameID, nil
 }
@@ -221,13 +231,13 @@ func (em *EnhancedManager) JoinGameSecure(gameID string, playerID int64) (*GameR
 	if gameID == "" {
 		audit.Success = false
 		audit.ErrorMsg = "游戏ID不能为空"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	if playerID <= 0 {
 		audit.Success = false
 		audit.ErrorMsg = "无效的玩家ID"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	// 获取游戏信息
@@ -235,28 +245,34 @@ func (em *EnhancedManager) JoinGameSecure(gameID string, playerID int64) (*GameR
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("获取游戏信息失败: %v", err)
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	if game == nil {
 		audit.Success = false
 		audit.ErrorMsg = "游戏不存在"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	audit.Details["bet_amount"] = game.BetAmount
 	audit.Details["player1_id"] = game.Player1ID
 
+	if gameTaken(game.Status) {
+		audit.Success = false
+		audit.ErrorMsg = ErrGameTaken.Error()
+		return nil, em.rejectJoin(game, playerID)
+	}
+
 	if game.Status != models.GameStatusWaiting {
 		audit.Success = false
 		audit.ErrorMsg = "游戏已开始或已结束"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	if game.Player1ID == playerID {
 		audit.Success = false
 		audit.ErrorMsg = "不能加入自己创建的游戏"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	// 使用余额验证器进行预验证
@@ -271,13 +287,13 @@ func (em *EnhancedManager) JoinGameSecure(gameID string, playerID int64) (*GameR
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("获取玩家信息失败: %v", err)
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	if player2 == nil {
 		audit.Success = false
 		audit.ErrorMsg = "玩家不存在"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	// 记录操作前的余额
@@ -287,8 +303,8 @@ func (em *EnhancedManager) JoinGameSecure(gameID string, playerID int64) (*GameR
 	// 严格的余额验证
 	if player2.Balance < game.BetAmount {
 		audit.Success = false
-		audit.ErrorMsg = fmt.Sprintf("余额不足，请存款后再试。当前余额: %d，需要: %d", player2.Balance, game.BetAmount)
-		return nil, fmt.Errorf("余额不足，请存款后再试。当前余额: %d，需要: %d", player2.Balance, game.BetAmount)
+		audit.ErrorMsg = fmt.Sprintf("余额不足，请存款后再试。当前余额: %s，需要: %s", utils.FormatAmount(player2.Balance), utils.FormatAmount(game.BetAmount))
+		return nil, fmt.Errorf("余额不足，请存款后再试。当前余额: %s，需要: %s", utils.FormatAmount(player2.Balance), utils.FormatAmount(game.BetAmount))
 	}
 
 	// 计算新余额
@@ -296,7 +312,7 @@ func (em *EnhancedManager) JoinGameSecure(gameID string, playerID int64) (*GameR
 	if newBalance < 0 {
 		audit.Success = false
 		audit.ErrorMsg = "余额不足，请存款后再试"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	audit.Details["new_balance"] = newBalance
@@ -320,7 +336,7 @@ func (em *EnhancedManager) JoinGameSecure(gameID string, playerID int64) (*GameR
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("安全验证失败: %v", err)
 		em.security.FailOperation(securityOp.ID, audit.ErrorMsg)
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	// 创建交易记录
@@ -335,13 +351,16 @@ func (em *EnhancedManager) JoinGameSecure(gameID string, playerID int64) (*GameR
 	}
 
 	// 使用事务确保原子性
-	if err := em.db.JoinGameWithTransaction(gameID, playerID, newBalance, tx2); err != nil {
+	if err := em.db.JoinGameWithTransaction(gameID, playerID, newBalance, tx2, models.GameStatusPlaying); err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("加入游戏失败: %v", err)
 		
 		// 回滚安全操作
 		em.security.RollbackOperation(securityOp.ID, audit.ErrorMsg)
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		if isJoinRace(err) {
+			return nil, em.rejectJoin(game, playerID)
+		}
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	// 标记安全操作完成
@@ -365,7 +384,7 @@ func (em *EnhancedManager) JoinGameSecure(gameID string, playerID int64) (*GameR
 		"is_draw": result.Winner == nil,
 	}
 	
-	em.logger.Info(fmt.Sprintf("加入游戏成功: 用户=%d, 游戏ID=%s, 金额=%d", playerID, gameID, game.BetAmount))
+	em.logger.Info("加入游戏成功: 用户=%d, 游戏ID=%s, 金额=%d", playerID, gameID, game.BetAmount)
 
 	return result, nil
 }
@@ -379,11 +398,26 @@ func (em *EnhancedManager) recordAudit(audit *AuditRecord) {
 	
 	// 记录到日志系统
 	if audit.Success {
-		em.logger.Info(fmt.Sprintf("审计记录: %s - %s 成功, 用户=%d, 耗时=%v", 
-			audit.ID, audit.Operation, audit.UserID, audit.Duration))
+		em.logger.Info("审计记录: %s - %s 成功, 用户=%d, 耗时=%v", 
+			audit.ID, audit.Operation, audit.UserID, audit.Duration)
 	} else {
-		em.logger.Error(fmt.Sprintf("审计记录: %s - %s 失败, 用户=%d, 错误=%s, 耗时=%v", 
-			audit.ID, audit.Operation, audit.UserID, audit.ErrorMsg, audit.Duration))
+		em.logger.Error("审计记录: %s - %s 失败, 用户=%d, 错误=%s, 耗时=%v", 
+			audit.ID, audit.Operation, audit.UserID, audit.ErrorMsg, audit.Duration)
+	}
+
+	// 涉及对局的操作同时写入对局时间线
+	if audit.GameID != nil {
+		detail := fmt.Sprintf("%s 成功（审计 %s）", audit.Operation, audit.ID)
+		if !audit.Success {
+			detail = fmt.Sprintf("%s 失败: %s（审计 %s）", audit.Operation, audit.ErrorMsg, audit.ID)
+		}
+		em.recordGameEvent(&models.GameEvent{
+			GameID:    *audit.GameID,
+			Type:      models.GameEventSecurity,
+			UserID:    audit.UserID,
+			Detail:    detail,
+			CreatedAt: audit.Timestamp,
+		})
 	}
 }
 
@@ -457,19 +491,19 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("获取游戏信息失败: %v", err)
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	if game == nil {
 		audit.Success = false
 		audit.ErrorMsg = "游戏不存在"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	if game.Status != models.GameStatusPlaying {
 		audit.Success = false
 		audit.ErrorMsg = "游戏状态不正确"
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	// 计算骰子总和
@@ -479,9 +513,21 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 	audit.Details["player1_total"] = player1Total
 	audit.Details["player2_total"] = player2Total
 
+	// 按启用的玩法插件判定胜负和派奖
+	outcome := rules.Outcome{
+		ChatID:    game.ChatID,
+		BetAmount: game.BetAmount,
+		Player1:   [3]int{dice1, dice2, dice3},
+		Player2:   [3]int{dice4, dice5, dice6},
+	}
+	outcome.Winner = em.gameRules.Winner(outcome)
+
 	// 计算总奖池和抽水
 	totalPot := game.BetAmount * 2
-	commission := utils.CalculateCommission(totalPot, em.feeRate)
+	commission := em.gameRules.Payout(outcome, rules.Payout{
+		Pot:        totalPot,
+		Commission: utils.CalculateCommission(totalPot, em.feeRate),
+	}).Commission
 	
 	audit.Details["total_pot"] = totalPot
 	audit.Details["commission"] = commission
@@ -491,7 +537,7 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 	var winAmount int64
 
 	// 判断游戏结果
-	if player1Total == player2Total {
+	if outcome.Winner == rules.Draw {
 		// 平局 - 退还双方本金
 		audit.Details["result"] = "draw"
 		
@@ -500,7 +546,7 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 		if err != nil {
 			audit.Success = false
 			audit.ErrorMsg = fmt.Sprintf("获取玩家1信息失败: %v", err)
-			return nil, fmt.Errorf(audit.ErrorMsg)
+			return nil, errors.New(audit.ErrorMsg)
 		}
 		
 		newBalance1 := player1.Balance + game.BetAmount
@@ -537,7 +583,7 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 				audit.Success = false
 				audit.ErrorMsg = fmt.Sprintf("获取玩家2信息失败: %v", err)
 				em.security.FailOperation(securityOp1.ID, audit.ErrorMsg)
-				return nil, fmt.Errorf(audit.ErrorMsg)
+				return nil, errors.New(audit.ErrorMsg)
 			}
 			
 			newBalance2 := player2.Balance + game.BetAmount
@@ -573,7 +619,7 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 				audit.ErrorMsg = fmt.Sprintf("玩家2安全验证失败: %v", err)
 				em.security.FailOperation(securityOp1.ID, audit.ErrorMsg)
 				em.security.FailOperation(securityOp2.ID, audit.ErrorMsg)
-				return nil, fmt.Errorf(audit.ErrorMsg)
+				return nil, errors.New(audit.ErrorMsg)
 			}
 		}
 
@@ -582,12 +628,12 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 			audit.Success = false
 			audit.ErrorMsg = fmt.Sprintf("玩家1安全验证失败: %v", err)
 			em.security.FailOperation(securityOp1.ID, audit.ErrorMsg)
-			return nil, fmt.Errorf(audit.ErrorMsg)
+			return nil, errors.New(audit.ErrorMsg)
 		}
 
 	} else {
 		// 有胜负
-		if player1Total > player2Total {
+		if outcome.Winner == rules.Player1 {
 			winnerID = &game.Player1ID
 			audit.Details["winner"] = "player1"
 		} else {
@@ -603,7 +649,7 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 		if err != nil {
 			audit.Success = false
 			audit.ErrorMsg = fmt.Sprintf("获取获胜者信息失败: %v", err)
-			return nil, fmt.Errorf(audit.ErrorMsg)
+			return nil, errors.New(audit.ErrorMsg)
 		}
 
 		newWinnerBalance := winner.Balance + winAmount
@@ -628,7 +674,7 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 			audit.Success = false
 			audit.ErrorMsg = fmt.Sprintf("获胜者安全验证失败: %v", err)
 			em.security.FailOperation(securityOp.ID, audit.ErrorMsg)
-			return nil, fmt.Errorf(audit.ErrorMsg)
+			return nil, errors.New(audit.ErrorMsg)
 		}
 
 		// 创建获胜者交易记录
@@ -657,7 +703,7 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 				em.security.RollbackOperation(op.ID, audit.ErrorMsg)
 			}
 		}
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	// 标记所有安全操作完成
@@ -672,20 +718,20 @@ func (em *EnhancedManager) PlayGameWithDiceResultsSecure(gameID string, dice1, d
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("获取更新后游戏信息失败: %v", err)
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 	
-	result, err := em.buildGameResult(updatedGame, player1Total == player2Total)
+	result, err := em.buildGameResult(updatedGame, outcome.Winner == rules.Draw)
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("构建游戏结果失败: %v", err)
-		return nil, fmt.Errorf(audit.ErrorMsg)
+		return nil, errors.New(audit.ErrorMsg)
 	}
 
 	// 记录成功
 	audit.Success = true
 	audit.UserID = game.Player1ID // 设置主要用户ID
-	em.logger.Info(fmt.Sprintf("游戏结算成功: 游戏ID=%s, 结果=%s", gameID, audit.Details["result"]))
+	em.logger.Info("游戏结算成功: 游戏ID=%s, 结果=%s", gameID, audit.Details["result"])
 
 	return result, nil
 }
@@ -720,19 +766,19 @@ func (em *EnhancedManager) ExpireGameSecure(gameID string) error {
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("获取游戏信息失败: %v", err)
-		return fmt.Errorf(audit.ErrorMsg)
+		return errors.New(audit.ErrorMsg)
 	}
 
 	if game == nil {
 		audit.Success = false
 		audit.ErrorMsg = "游戏不存在"
-		return fmt.Errorf(audit.ErrorMsg)
+		return errors.New(audit.ErrorMsg)
 	}
 
 	if game.Status != models.GameStatusWaiting {
 		audit.Success = false
 		audit.ErrorMsg = "游戏状态不正确，无法超时"
-		return fmt.Errorf(audit.ErrorMsg)
+		return errors.New(audit.ErrorMsg)
 	}
 
 	audit.UserID = game.Player1ID
@@ -742,7 +788,7 @@ func (em *EnhancedManager) ExpireGameSecure(gameID string) error {
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("获取玩家1信息失败: %v", err)
-		return fmt.Errorf(audit.ErrorMsg)
+		return errors.New(audit.ErrorMsg)
 	}
 
 	// 计算退款金额和新余额
@@ -753,7 +799,7 @@ func (em *EnhancedManager) ExpireGameSecure(gameID string) error {
 	if player1.Balance < 0 {
 		audit.Success = false
 		audit.ErrorMsg = "用户余额异常，无法执行退款"
-		return fmt.Errorf(audit.ErrorMsg)
+		return errors.New(audit.ErrorMsg)
 	}
 	
 	audit.Details["refund_amount"] = refundAmount
@@ -779,7 +825,7 @@ func (em *EnhancedManager) ExpireGameSecure(gameID string) error {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("安全验证失败: %v", err)
 		em.security.FailOperation(securityOp.ID, audit.ErrorMsg)
-		return fmt.Errorf(audit.ErrorMsg)
+		return errors.New(audit.ErrorMsg)
 	}
 
 	// 创建退款交易记录
@@ -794,12 +840,12 @@ func (em *EnhancedManager) ExpireGameSecure(gameID string) error {
 	}
 
 	// 使用事务执行超时退款
-	err = em.db.ExpireGameWithTransaction(gameID, game.Player1ID, newBalance, tx)
+	err = em.db.ExpireGameWithTransaction(gameID, game.Player1ID, tx)
 	if err != nil {
 		audit.Success = false
 		audit.ErrorMsg = fmt.Sprintf("超时退款失败: %v", err)
 		em.security.RollbackOperation(securityOp.ID, audit.ErrorMsg)
-		return fmt.Errorf(audit.ErrorMsg)
+		return errors.New(audit.ErrorMsg)
 	}
 
 	// 标记安全操作完成
@@ -815,7 +861,7 @@ func (em *EnhancedManager) ExpireGameSecure(gameID string) error {
 
 	// 记录成功
 	audit.Success = true
-	em.logger.Info(fmt.Sprintf("游戏超时处理成功: 游戏ID=%s, 退款金额=%d", gameID, refundAmount))
+	em.logger.Info("游戏超时处理成功: 游戏ID=%s, 退款金额=%d", gameID, refundAmount)
 
 	return nil
 }
@@ -833,22 +879,30 @@ func (em *EnhancedManager) playGameAndSettleNoLock(game *models.Game, player2ID
 		return nil, err
 	}
 
-	// 生成随机骰子结果 (1-6)
-	dice1, _ := utils.SecureRandomInt(6)
-	dice1 += 1
-	dice2, _ := utils.SecureRandomInt(6)
-	dice2 += 1
-	dice3, _ := utils.SecureRandomInt(6)
-	dice3 += 1
-	dice4, _ := utils.SecureRandomInt(6)
-	dice4 += 1
-	dice5, _ := utils.SecureRandomInt(6)
-	dice5 += 1
-	dice6, _ := utils.SecureRandomInt(6)
-	dice6 += 1
+	// 按开局前承诺的种子生成骰子，玩家可在结束后用 /verify 核对
+	var dice1, dice2, dice3, dice4, dice5, dice6 int
+	if game.ServerSeed != "" {
+		dice1, dice2, dice3 = RollDiceWithSeed(game.ServerSeed, 1)
+		dice4, dice5, dice6 = RollDiceWithSeed(game.ServerSeed, 2)
+		em.recordGameEvent(&models.GameEvent{
+			GameID: game.ID,
+			Type:   models.GameEventSeedRoll,
+			ChatID: game.ChatID,
+			Detail: diceDetail(dice1, dice2, dice3, dice4, dice5, dice6),
+		})
+	} else {
+		// 功能上线前创建的对局没有种子，仍使用安全随机数
+		var err error
+		if dice1, dice2, dice3, err = em.rollDice(); err != nil {
+			return nil, err
+		}
+		if dice4, dice5, dice6, err = em.rollDice(); err != nil {
+			return nil, err
+		}
+	}
 
 	// 直接调用原始Manager的PlayGameWithDiceResults方法避免死锁
-	return em.Manager.PlayGameWithDiceResults(game.ID, int(dice1), int(dice2), int(dice3), int(dice4), int(dice5), int(dice6))
+	return em.Manager.PlayGameWithDiceResults(game.ID, dice1, dice2, dice3, dice4, dice5, dice6)
 }
 
 // ...
@@ -0,0 +1,139 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/utils"
+)
+
+// exposureAlertWindow 统计群组反复触发下注上限的时间范围
+const exposureAlertWindow = 24 * time.Hour
+
+// ExposureCapError 本群本小时的下注总额已达上限，新对局暂停到下一个整点
+type ExposureCapError struct {
+	Cap       int64
+	Turnover  int64
+	Remaining time.Duration
+}
+
+func (e *ExposureCapError) Error() string {
+	minutes := int((e.Remaining + time.Minute - 1) / time.Minute)
+	return fmt.Sprintf("本群本小时下注总额已达上限 %s 金币，暂停发起新对局，请 %d 分钟后再来", utils.FormatAmount(e.Cap), minutes)
+}
+
+// ChatExposure 群组本小时的下注总额及生效的上限
+type ChatExposure struct {
+	Cap        int64 // 生效的上限，0 表示不限制
+	Custom     int64 // 群组自定义的上限，0 表示沿用全局配置，负数表示本群不限制
+	Turnover   int64
+	WindowEnd  time.Time
+	Overridden bool // 管理员已解除本小时的暂停
+}
+
+// Paused 本小时是否已暂停发起新对局
+func (e *ChatExposure) Paused() bool {
+	return e.Cap > 0 && e.Turnover >= e.Cap && !e.Overridden
+}
+
+// exposureWindow 当前整点统计窗口的起点
+func exposureWindow(now time.Time) time.Time {
+	return now.Truncate(time.Hour)
+}
+
+// SetExposureAlertCallback 设置群组反复触发下注上限时的告警回调，hits 为 24 小时内触发上限的小时数
+func (m *Manager) SetExposureAlertCallback(callback func(chatID int64, hits int, hourlyCap int64)) {
+	m.onExposureAlert = callback
+}
+
+// ChatExposure 获取群组本小时的下注总额和生效的上限
+func (m *Manager) ChatExposure(chatID int64) (*ChatExposure, error) {
+	custom, err := m.db.GetChatHourlyCap(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("获取群组下注上限失败: %v", err)
+	}
+	exposure := &ChatExposure{Cap: m.config.ChatHourlyCap, Custom: custom}
+	if custom > 0 {
+		exposure.Cap = custom
+	} else if custom < 0 {
+		exposure.Cap = 0
+	}
+
+	window := exposureWindow(time.Now())
+	exposure.WindowEnd = window.Add(time.Hour)
+	if exposure.Turnover, err = m.db.GetChatTurnover(chatID, window); err != nil {
+		return nil, fmt.Errorf("统计群组下注总额失败: %v", err)
+	}
+
+	m.exposureMu.Lock()
+	exposure.Overridden = m.exposureOverrides[chatID].Equal(window)
+	m.exposureMu.Unlock()
+	return exposure, nil
+}
+
+// SetChatHourlyCap 设置群组每小时下注上限：正数为上限，0 沿用全局配置，负数表示本群不限制
+func (m *Manager) SetChatHourlyCap(chatID int64, hourlyCap int64) error {
+	if hourlyCap < 0 {
+		hourlyCap = -1
+	}
+	if err := m.db.SetChatHourlyCap(chatID, hourlyCap); err != nil {
+		return fmt.Errorf("更新群组下注上限失败: %v", err)
+	}
+	return nil
+}
+
+// OverrideExposureCap 管理员解除群组本小时的暂停，下一个整点起恢复按上限检查
+func (m *Manager) OverrideExposureCap(chatID int64) {
+	m.exposureMu.Lock()
+	defer m.exposureMu.Unlock()
+	m.exposureOverrides[chatID] = exposureWindow(time.Now())
+}
+
+// checkExposure 本群本小时的下注总额已达上限且未被管理员解除时返回 ExposureCapError
+func (m *Manager) checkExposure(chatID int64) error {
+	exposure, err := m.ChatExposure(chatID)
+	if err != nil {
+		return err
+	}
+	if !exposure.Paused() {
+		return nil
+	}
+
+	m.recordExposureHit(chatID, exposure)
+	return &ExposureCapError{
+		Cap:       exposure.Cap,
+		Turnover:  exposure.Turnover,
+		Remaining: time.Until(exposure.WindowEnd),
+	}
+}
+
+// recordExposureHit 记录群组本小时触发了上限，24 小时内触发的小时数达到告警阈值时通知管理员
+func (m *Manager) recordExposureHit(chatID int64, exposure *ChatExposure) {
+	window := exposure.WindowEnd.Add(-time.Hour)
+
+	m.exposureMu.Lock()
+	hits := m.exposureHits[chatID]
+	if len(hits) > 0 && hits[len(hits)-1].Equal(window) {
+		m.exposureMu.Unlock()
+		return
+	}
+	kept := hits[:0]
+	for _, hit := range hits {
+		if window.Sub(hit) < exposureAlertWindow {
+			kept = append(kept, hit)
+		}
+	}
+	hits = append(kept, window)
+	m.exposureHits[chatID] = hits
+	m.exposureMu.Unlock()
+
+	log.Printf("🛑 群组 %d 本小时下注总额 %s 达到上限 %s，暂停发起新对局（24 小时内第 %d 次）",
+		chatID, utils.FormatAmount(exposure.Turnover), utils.FormatAmount(exposure.Cap), len(hits))
+
+	threshold := int(m.config.ChatCapAlertHits)
+	if threshold > 0 && len(hits) >= threshold && m.onExposureAlert != nil {
+		// 调用方持有管理器锁，告警在后台发送
+		go m.onExposureAlert(chatID, len(hits), exposure.Cap)
+	}
+}
@@ -0,0 +1,141 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// generateRandomSeed 生成对局的服务端种子：对局ID、发起者和安全随机数的哈希，对局结束前保密
+func generateRandomSeed(gameID string, playerID int64) (string, error) {
+	nonce := make([]byte, 32)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s-%d-%x", gameID, playerID, nonce)))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// SeedCommitment 种子的承诺哈希，即种子的 SHA256 十六进制，开局前公布
+func SeedCommitment(seed string) string {
+	hash := sha256.Sum256([]byte(seed))
+	return hex.EncodeToString(hash[:])
+}
+
+// RollDiceWithSeed 按种子重算玩家的3个骰子，playerIndex 为 1 或 2。
+// 每个骰子取 SHA256("种子-玩家序号" 加后缀) 前8字节的无符号整数对 6 取余再加 1，三个骰子的后缀依次为空、dice2、dice3
+func RollDiceWithSeed(seed string, playerIndex int) (int, int, int) {
+	seedData := fmt.Sprintf("%s-%d", seed, playerIndex)
+	roll := func(suffix string) int {
+		hash := sha256.Sum256([]byte(seedData + suffix))
+		return int(binary.BigEndian.Uint64(hash[:8])%6) + 1
+	}
+	return roll(""), roll("dice2"), roll("dice3")
+}
+
+// seedGame 为新对局生成种子和承诺哈希
+func seedGame(game *models.Game) error {
+	seed, err := generateRandomSeed(game.ID, game.Player1ID)
+	if err != nil {
+		return fmt.Errorf("生成对局种子失败: %v", err)
+	}
+	game.ServerSeed = seed
+	game.SeedHash = SeedCommitment(seed)
+	return nil
+}
+
+// seedRevealed 对局结束后才公开种子，进行中或待审核的对局只公布承诺哈希
+func seedRevealed(status string) bool {
+	switch status {
+	case models.GameStatusFinished, models.GameStatusSurrendered, models.GameStatusCancelled, models.GameStatusExpired:
+		return true
+	}
+	return false
+}
+
+// VerifyGame 对局的审计信息：结束后公开种子并核对承诺哈希。骰子按种子生成时重算核对；
+// 由 Telegram 骰子动画开出时种子不决定点数，改为核对结算点数与时间线中记录的骰子消息
+func (m *Manager) VerifyGame(gameID string) (*models.GameVerification, error) {
+	game, err := m.db.GetGame(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取对局失败: %v", err)
+	}
+	if game == nil {
+		return nil, fmt.Errorf("对局 %s 不存在", gameID)
+	}
+	if game.SeedHash == "" {
+		return nil, fmt.Errorf("对局 %s 没有种子记录，无法验证", gameID)
+	}
+
+	verification := &models.GameVerification{Game: game, SeedHash: game.SeedHash}
+	if !seedRevealed(game.Status) {
+		return verification, nil
+	}
+
+	verification.Revealed = true
+	verification.Seed = game.ServerSeed
+	verification.HashValid = SeedCommitment(game.ServerSeed) == game.SeedHash
+
+	events, err := m.db.GetGameEvents(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取对局事件失败: %v", err)
+	}
+	for _, event := range events {
+		switch event.Type {
+		case models.GameEventSeedRoll:
+			verification.DiceSource = models.DiceSourceSeed
+		case models.GameEventRoll:
+			var value int
+			if _, err := fmt.Sscanf(event.Detail, "%d", &value); err != nil {
+				continue
+			}
+			if game.Player2ID != nil && event.UserID == *game.Player2ID {
+				verification.TelegramDice2 = append(verification.TelegramDice2, value)
+			} else if event.UserID == game.Player1ID {
+				verification.TelegramDice1 = append(verification.TelegramDice1, value)
+			}
+		}
+	}
+	if verification.DiceSource == "" && (len(verification.TelegramDice1) > 0 || len(verification.TelegramDice2) > 0) {
+		verification.DiceSource = models.DiceSourceTelegram
+	}
+	if verification.DiceSource == models.DiceSourceSeed {
+		p1d1, p1d2, p1d3 := RollDiceWithSeed(game.ServerSeed, 1)
+		p2d1, p2d2, p2d3 := RollDiceWithSeed(game.ServerSeed, 2)
+		verification.SeedDice1 = [3]int{p1d1, p1d2, p1d3}
+		verification.SeedDice2 = [3]int{p2d1, p2d2, p2d3}
+	}
+
+	if game.Player1Dice1 == nil || game.Player1Dice2 == nil || game.Player1Dice3 == nil ||
+		game.Player2Dice1 == nil || game.Player2Dice2 == nil || game.Player2Dice3 == nil {
+		return verification, nil
+	}
+	verification.Rolled = true
+	verification.Dice1 = [3]int{*game.Player1Dice1, *game.Player1Dice2, *game.Player1Dice3}
+	verification.Dice2 = [3]int{*game.Player2Dice1, *game.Player2Dice2, *game.Player2Dice3}
+	switch verification.DiceSource {
+	case models.DiceSourceSeed:
+		verification.DiceMatch = verification.Dice1 == verification.SeedDice1 && verification.Dice2 == verification.SeedDice2
+	case models.DiceSourceTelegram:
+		verification.DiceMatch = diceEqual(verification.Dice1, verification.TelegramDice1) &&
+			diceEqual(verification.Dice2, verification.TelegramDice2)
+	}
+	return verification, nil
+}
+
+// diceEqual 结算的三个骰子与记录的骰子消息点数是否逐个一致
+func diceEqual(dice [3]int, values []int) bool {
+	if len(values) != len(dice) {
+		return false
+	}
+	for i, value := range values {
+		if dice[i] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// ErrInsuranceUnavailable 保险未开启或下注额未达投保门槛
+var ErrInsuranceUnavailable = errors.New("该对局不可购买保险")
+
+// InsuranceQuote 按配置计算下注额对应的保险费和输局赔付额，未开启或未达门槛时 ok=false
+func InsuranceQuote(settings *models.InsuranceSettings, betAmount int64) (premium, coverage int64, ok bool) {
+	if settings == nil || !settings.Enabled || betAmount < settings.Threshold {
+		return 0, 0, false
+	}
+
+	premium = int64(float64(betAmount)*settings.PremiumRate + 0.5)
+	if premium < 1 {
+		premium = 1
+	}
+	coverage = int64(float64(betAmount) * settings.RefundRate)
+	return premium, coverage, coverage > 0
+}
+
+// InsuranceOffer 获取下注额对应的保险报价，用于在建局键盘中展示投保按钮
+func (m *Manager) InsuranceOffer(betAmount int64) (premium, coverage int64, ok bool) {
+	settings, err := m.db.GetInsuranceSettings()
+	if err != nil {
+		log.Printf("⚠️ 获取保险配置失败: %v", err)
+		return 0, 0, false
+	}
+	return InsuranceQuote(settings, betAmount)
+}
+
+// BuyInsurance 发起者在对局等待加入期间购买保险
+func (m *Manager) BuyInsurance(gameID string, playerID int64) (premium, coverage int64, err error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+	if game == nil {
+		return 0, 0, fmt.Errorf("游戏不存在")
+	}
+	if game.Player1ID != playerID {
+		return 0, 0, fmt.Errorf("只有对局发起者可以购买保险")
+	}
+	if game.InsurancePremium > 0 {
+		return 0, 0, fmt.Errorf("该对局已投保")
+	}
+	if game.Status != models.GameStatusWaiting {
+		return 0, 0, fmt.Errorf("对局已开始，无法购买保险")
+	}
+
+	premium, coverage, ok := m.InsuranceOffer(game.BetAmount)
+	if !ok {
+		return 0, 0, ErrInsuranceUnavailable
+	}
+
+	if _, err := m.db.BuyGameInsurance(gameID, playerID, premium, coverage); err != nil {
+		return 0, 0, fmt.Errorf("购买保险失败: %v", err)
+	}
+
+	log.Printf("🛡️ 玩家 %d 为对局 %s 投保，保险费 %d，赔付 %d", playerID, gameID, premium, coverage)
+	return premium, coverage, nil
+}
+
+// insurancePayout 投保的发起者输掉对局时生成保险赔付交易，余额在结算事务中回填
+func insurancePayout(game *models.Game, winnerID int64) *models.Transaction {
+	if game.InsuranceCoverage <= 0 || winnerID == game.Player1ID {
+		return nil
+	}
+
+	return &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      game.Player1ID,
+		GameID:      &game.ID,
+		Type:        models.TransactionTypeInsurancePayout,
+		Amount:      game.InsuranceCoverage,
+		Description: fmt.Sprintf("游戏 %s 保险赔付", game.ID),
+	}
+}
@@ -0,0 +1,33 @@
+package game
+
+import (
+	"errors"
+	"log"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+)
+
+// ErrGameTaken 对局已被其他玩家抢先加入
+var ErrGameTaken = errors.New("对局已被抢先加入")
+
+// gameTaken 判断对局是否因已被他人加入而无法加入（超时、取消的对局不算）
+func gameTaken(status string) bool {
+	switch status {
+	case models.GameStatusPlaying, models.GameStatusFinished, models.GameStatusSurrendered:
+		return true
+	}
+	return false
+}
+
+// rejectJoin 记录被拒绝的加入请求并返回 ErrGameTaken
+func (m *Manager) rejectJoin(game *models.Game, playerID int64) error {
+	m.metrics.joinRejected()
+	log.Printf("🚫 玩家 %d 加入对局 %s 失败：对局已被抢先加入（状态 %s）", playerID, game.ID, game.Status)
+	return ErrGameTaken
+}
+
+// isJoinRace 判断加入事务失败是否因为对局在校验后被他人抢先加入
+func isJoinRace(err error) bool {
+	return errors.Is(err, database.ErrGameNotWaiting)
+}
@@ -2,16 +2,20 @@ package game
 
 import (
 	"crypto/rand"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
+	"log"
 	"math/big"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"telegram-dice-bot/internal/cache"
 	"telegram-dice-bot/internal/config"
 	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/events"
 	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/risk"
+	"telegram-dice-bot/internal/rules"
 	"telegram-dice-bot/internal/utils"
 	"telegram-dice-bot/internal/validator"
 )
@@ -27,6 +31,75 @@ type Manager struct {
 	timerMutex sync.RWMutex
 	// 超时通知回调
 	onGameExpired func(gameID string, chatID int64)
+	// 对局开始/结束回调（用于预热网络连接）
+	onGameStarted  func(gameID string)
+	onGameFinished func(gameID string)
+	// 游戏流程指标
+	metrics *Metrics
+	// 对局动画节奏控制
+	pacer *Pacer
+	// 对局事件发布（发起、加入、结算）
+	events *events.Bus
+	// 顺序模式下等待开局的加入请求，按群组排队
+	queues  map[int64][]queuedJoin
+	queueMu sync.Mutex
+	// 排队的加入请求开局（或开局失败）时的回调
+	onQueuedJoin func(chatID, playerID int64, result *GameResult, err error)
+	// 管理员移除排队请求后的回调
+	onQueueRemoved func(entry *models.QueueEntry)
+	// 等待对手接受的再来一局邀请，按新对局ID索引
+	rematches map[string]*rematch
+	// 已加入、等待双方确认准备的对局，以及双方确认开骰和准备超时的回调
+	readyChecks    map[string]*readyCheck
+	readyWindow    time.Duration
+	onReadyStarted func(chatID int64, result *GameResult)
+	onReadyExpired func(state *ReadyState, reverted bool)
+	// 各群组上一局的结束时间，用于两局之间的冷却
+	lastFinished map[int64]time.Time
+	cooldownMu   sync.Mutex
+	// 快速桌超时关闭后的回调
+	onTableExpired func(table *models.Table, players []*models.TablePlayer)
+	// 交手记录缓存，开局时附带双方的历史战绩
+	headToHead *cache.HeadToHeadCache
+	// 用户战绩缓存，对局结束后失效
+	userStats *cache.UserStatsCache
+	// 用户对局记录缓存，对局结束后失效
+	gameHistory *cache.GameHistoryCache
+	// 群组无活动超过该时长后标记为休眠，0 表示不启用
+	dormantAfter      time.Duration
+	chatTouched       map[int64]time.Time // 各群组最近一次写入活跃时间的时刻
+	lastDormancySweep time.Time
+	dormancyMu        sync.Mutex
+	// 对局从开骰到结算的最长时长，超时后中止并退款，0 表示不启用
+	maxGameDuration time.Duration
+	watchdogs       map[string]*time.Timer
+	watchdogMu      sync.Mutex
+	// 开骰后超过该时长仍未结算时私信双方说明延迟，0 表示不启用
+	settlementNotice    time.Duration
+	delayNotices        map[string]*time.Timer
+	onSettlementDelayed func(game *models.Game, refundIn time.Duration)
+	// 对局中止并退款后的回调（开骰超时、管理员强制中止或服务关闭）
+	onGameAborted func(game *models.Game, reason string)
+	// 服务正在关闭，不再接受新的对局
+	draining atomic.Bool
+	// 本进程开局（等待准备或已开骰）、尚未结束的对局，关闭时只等待和退款这些对局
+	owned   map[string]struct{}
+	ownedMu sync.Mutex
+	// 玩家提出结果争议和管理员审核完成后的回调
+	onDisputeOpened   func(dispute *models.Dispute)
+	onDisputeResolved func(dispute *models.Dispute)
+	// 群组每小时下注上限：管理员解除暂停的整点窗口、各群组触发上限的整点窗口和反复触发时的告警回调
+	exposureOverrides map[int64]time.Time
+	exposureHits      map[int64][]time.Time
+	exposureMu        sync.Mutex
+	onExposureAlert   func(chatID int64, hits int, hourlyCap int64)
+	// 启用的玩法插件，nil 时按默认规则结算
+	gameRules *rules.Set
+	// 风控引擎，分出胜负的对局结算后检查双方，nil 时不检查
+	risk *risk.Engine
+	// 结算消息生成器，生成的消息与结算在同一事务中写入发件箱；消息提交后唤醒发送器
+	composeSettlement func(result *GameResult) []*models.OutboxMessage
+	wakeOutbox        func()
 }
 
 type GameResult struct {
@@ -46,8 +119,18 @@ type GameResult struct {
 	Winner       *models.User
 	WinAmount    int64
 	Commission   int64
+	ChatShare    int64 // 手续费中归入群组基金的部分
 	BetAmount    int64
 	RandomSeed   string
+	// 投保的发起者输局时获得的保险赔付
+	InsurancePayout int64
+	// 结算已暂停，派奖等待管理员审核
+	Held bool
+	// 开局时双方此前的交手记录，从未交手时为 nil
+	HeadToHead *models.HeadToHead
+	// 群组开启准备确认时为 true：对局尚未开骰，等待双方在 ReadyDeadline 前确认准备
+	AwaitingReady bool
+	ReadyDeadline time.Time
 }
 
 func NewManager(db *database.DB, cfg *config.Config, feeRate float64) *Manager {
@@ -57,6 +140,18 @@ func NewManager(db *database.DB, cfg *config.Config, feeRate float64) *Manager {
 		feeRate:    feeRate,
 		gameTimers: make(map[string]*time.Timer),
 		validator:  validator.NewBalanceValidator(db),
+		metrics:    NewMetrics(),
+		queues:     make(map[int64][]queuedJoin),
+		rematches:  make(map[string]*rematch),
+		readyChecks: make(map[string]*readyCheck),
+		readyWindow: ReadyWindow,
+		lastFinished: make(map[int64]time.Time),
+		chatTouched:  make(map[int64]time.Time),
+		watchdogs:    make(map[string]*time.Timer),
+		delayNotices: make(map[string]*time.Timer),
+		owned:        make(map[string]struct{}),
+		exposureOverrides: make(map[int64]time.Time),
+		exposureHits:      make(map[int64][]time.Time),
 	}
 
 	// 启动定期清理过期游戏的后台任务
@@ -68,15 +163,101 @@ func NewManager(db *database.DB, cfg *config.Config, feeRate float64) *Manager {
 	return manager
 }
 
+// SetPacer 设置对局动画节奏控制器
+func (m *Manager) SetPacer(pacer *Pacer) {
+	m.pacer = pacer
+}
+
+// PlaybackPlan 获取群组本局的动画播放方案
+func (m *Manager) PlaybackPlan(chatID int64) Playback {
+	return m.pacer.Plan(chatID)
+}
+
+// SetEventBus 设置事件总线，对局发起、被加入和结算后发布对应事件
+func (m *Manager) SetEventBus(bus *events.Bus) {
+	m.events = bus
+}
+
+// SetHeadToHeadCache 设置交手记录缓存，开局结果中附带双方的历史战绩
+func (m *Manager) SetHeadToHeadCache(headToHead *cache.HeadToHeadCache) {
+	m.headToHead = headToHead
+}
+
+// SetUserStatsCache 设置用户战绩缓存，对局结束后清除双方的缓存
+func (m *Manager) SetUserStatsCache(userStats *cache.UserStatsCache) {
+	m.userStats = userStats
+}
+
+// SetGameHistoryCache 设置用户对局记录缓存，对局结束后清除双方的缓存
+func (m *Manager) SetGameHistoryCache(gameHistory *cache.GameHistoryCache) {
+	m.gameHistory = gameHistory
+}
+
+// SetOperationInterval 设置同一用户两次下注操作的最小间隔
+func (m *Manager) SetOperationInterval(interval time.Duration) {
+	m.validator.SetOperationInterval(interval)
+}
+
 // SetGameExpiredCallback 设置游戏超时回调函数
 func (m *Manager) SetGameExpiredCallback(callback func(gameID string, chatID int64)) {
 	m.onGameExpired = callback
 }
 
+// SetGameLifecycleCallbacks 设置对局开始和结束的回调函数
+func (m *Manager) SetGameLifecycleCallbacks(onStarted, onFinished func(gameID string)) {
+	m.onGameStarted = onStarted
+	m.onGameFinished = onFinished
+}
+
+// Metrics 获取游戏流程指标
+func (m *Manager) Metrics() *Metrics {
+	return m.metrics
+}
+
+// notifyGameFinished 通知对局结束，开始本群的冷却，并让本群排队的加入请求开局
+func (m *Manager) notifyGameFinished(game *models.Game) {
+	if m.onGameFinished != nil {
+		m.onGameFinished(game.ID)
+	}
+	m.disarmWatchdog(game.ID)
+	m.untrackOwned(game.ID)
+	m.markFinished(game.ChatID)
+	m.invalidatePlayerCaches(game)
+	go m.startQueued(game.ChatID)
+}
+
+// invalidatePlayerCaches 对局结果变化后清除双方的交手记录、战绩和对局记录缓存
+func (m *Manager) invalidatePlayerCaches(game *models.Game) {
+	if m.headToHead != nil && game.Player2ID != nil {
+		m.headToHead.Invalidate(game.Player1ID, *game.Player2ID)
+	}
+	if m.userStats != nil {
+		m.userStats.Invalidate(game.Player1ID)
+		if game.Player2ID != nil {
+			m.userStats.Invalidate(*game.Player2ID)
+		}
+	}
+	if m.gameHistory != nil {
+		m.gameHistory.ClearUserCache(game.Player1ID)
+		if game.Player2ID != nil {
+			m.gameHistory.ClearUserCache(*game.Player2ID)
+		}
+	}
+}
+
 func (m *Manager) CreateGame(playerID, chatID int64, betAmount int64) (string, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	return m.createGame(playerID, chatID, betAmount, 0)
+}
+
+// createGame 发起对局，timeout 为等待加入的时长，为 0 时使用本群的等待超时设置，调用方需持有 m.mutex
+func (m *Manager) createGame(playerID, chatID int64, betAmount int64, timeout time.Duration) (string, error) {
+	if err := m.checkDraining(false); err != nil {
+		return "", err
+	}
+
 	// 使用余额验证器进行预验证
 	if err := m.validator.ValidateUserBalance(playerID, betAmount); err != nil {
 		return "", err
@@ -87,8 +268,31 @@ func (m *Manager) CreateGame(playerID, chatID int64, betAmount int64) (string, e
 		return "", fmt.Errorf("下注金额必须大于0")
 	}
 
-	if betAmount > m.config.MaxBet {
-		return "", fmt.Errorf("下注金额不能超过%d", m.config.MaxBet)
+	// 按本群的下注限额校验
+	limits, err := m.ChatLimits(chatID)
+	if err != nil {
+		return "", err
+	}
+	if betAmount < limits.MinBet {
+		return "", fmt.Errorf("下注金额不能低于%s", utils.FormatAmount(limits.MinBet))
+	}
+	if betAmount > limits.MaxBet {
+		return "", fmt.Errorf("下注金额不能超过%s", utils.FormatAmount(limits.MaxBet))
+	}
+
+	// 本群本小时的下注总额达到上限时暂停发起新对局
+	if err := m.checkExposure(chatID); err != nil {
+		return "", err
+	}
+
+	// 被封禁的用户不能发起对局
+	if err := m.checkBanned(playerID); err != nil {
+		return "", err
+	}
+
+	// 每人每天的对局数上限
+	if err := m.checkDailyLimit(playerID); err != nil {
+		return "", err
 	}
 
 	// 检查用户余额 - 增强验证逻辑
@@ -101,13 +305,14 @@ func (m *Manager) CreateGame(playerID, chatID int64, betAmount int64) (string, e
 		return "", fmt.Errorf("用户不存在")
 	}
 
-	// 严格的余额验证：确保余额足够且不会导致负数
-	if user.Balance < betAmount {
-		return "", fmt.Errorf("余额不足，请存款后再试。当前余额: %d，需要: %d", user.Balance, betAmount)
+	// 严格的余额验证：确保余额足够且不会导致负数（赠送余额可用于下注）
+	spendable := user.Balance + user.BonusBalance
+	if spendable < betAmount {
+		return "", fmt.Errorf("余额不足，请存款后再试。当前余额: %s，需要: %s", utils.FormatAmount(spendable), utils.FormatAmount(betAmount))
 	}
 
 	// 二次验证：计算新余额确保不为负数
-	newBalance := user.Balance - betAmount
+	newBalance := spendable - betAmount
 	if newBalance < 0 {
 		return "", fmt.Errorf("余额不足，请存款后再试")
 	}
@@ -121,6 +326,10 @@ func (m *Manager) CreateGame(playerID, chatID int64, betAmount int64) (string, e
 		Status:    models.GameStatusWaiting,
 		ChatID:    chatID,
 	}
+	// 开局前生成种子，只公布承诺哈希
+	if err := seedGame(game); err != nil {
+		return "", err
+	}
 
 	tx := &models.Transaction{
 		ID:          utils.GenerateTransactionID(),
@@ -137,8 +346,21 @@ func (m *Manager) CreateGame(playerID, chatID int64, betAmount int64) (string, e
 		return "", fmt.Errorf("创建游戏失败: %v", err)
 	}
 
-	// 设置60秒超时定时器
-	m.setGameTimeout(gameID, 60*time.Second)
+	m.recordGameEvent(&models.GameEvent{
+		GameID: gameID,
+		Type:   models.GameEventCreated,
+		UserID: playerID,
+		ChatID: chatID,
+		Detail: fmt.Sprintf("发起对局，下注 %s 金币，种子承诺 %s", utils.FormatAmount(betAmount), game.SeedHash),
+	})
+
+	// 按本群的等待超时设置定时器
+	if timeout <= 0 {
+		timeout = limits.GameTimeout
+	}
+	m.setGameTimeout(gameID, timeout)
+	m.metrics.gameCreated()
+	m.events.Publish(events.GameCreated{GameID: gameID, ChatID: chatID, PlayerID: playerID, BetAmount: betAmount})
 
 	return gameID, nil
 }
@@ -147,6 +369,11 @@ func (m *Manager) JoinGame(gameID string, playerID int64) (*GameResult, error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
+	return m.joinGame(gameID, playerID, false)
+}
+
+// joinGame 加入对局，queued 为 true 表示处理排队中的加入请求，调用方需持有 m.mutex
+func (m *Manager) joinGame(gameID string, playerID int64, queued bool) (*GameResult, error) {
 	// 验证输入参数
 	if gameID == "" {
 		return nil, fmt.Errorf("游戏ID不能为空")
@@ -166,6 +393,9 @@ func (m *Manager) JoinGame(gameID string, playerID int64) (*GameResult, error) {
 		return nil, fmt.Errorf("游戏不存在")
 	}
 
+	if gameTaken(game.Status) {
+		return nil, m.rejectJoin(game, playerID)
+	}
 	if game.Status != models.GameStatusWaiting {
 		return nil, fmt.Errorf("游戏已开始或已结束")
 	}
@@ -174,6 +404,27 @@ func (m *Manager) JoinGame(gameID string, playerID int64) (*GameResult, error) {
 		return nil, fmt.Errorf("不能加入自己创建的游戏")
 	}
 
+	if err := m.checkDraining(queued); err != nil {
+		return nil, err
+	}
+
+	if err := m.checkBanned(playerID); err != nil {
+		return nil, err
+	}
+	if err := m.checkDailyLimit(playerID); err != nil {
+		return nil, err
+	}
+
+	// 再来一局的对局只保留给上一局的对手
+	if err := m.checkRematchJoin(gameID, playerID); err != nil {
+		return nil, err
+	}
+
+	// 按群组对局模式决定立即开局、排队还是拒绝
+	if err := m.admitJoin(game, playerID, queued); err != nil {
+		return nil, err
+	}
+
 	// 使用余额验证器进行预验证
 	if err := m.validator.ValidateUserBalance(playerID, game.BetAmount); err != nil {
 		return nil, err
@@ -189,13 +440,24 @@ func (m *Manager) JoinGame(gameID string, playerID int64) (*GameResult, error) {
 		return nil, fmt.Errorf("玩家不存在")
 	}
 
-	// 严格的余额验证：确保余额足够且不会导致负数
-	if player2.Balance < game.BetAmount {
-		return nil, fmt.Errorf("余额不足，请存款后再试。当前余额: %d，需要: %d", player2.Balance, game.BetAmount)
+	// 严格的余额验证：确保余额足够且不会导致负数（赠送余额可用于下注）
+	spendable := player2.Balance + player2.BonusBalance
+	if spendable < game.BetAmount {
+		return nil, fmt.Errorf("余额不足，请存款后再试。当前余额: %s，需要: %s", utils.FormatAmount(spendable), utils.FormatAmount(game.BetAmount))
+	}
+
+	// 群组开启准备确认时，加入后等待双方确认再开骰
+	readyCheck, err := m.db.IsReadyCheckEnabled(game.ChatID)
+	if err != nil {
+		return nil, fmt.Errorf("获取群组设置失败: %v", err)
+	}
+	joinStatus := models.GameStatusPlaying
+	if readyCheck {
+		joinStatus = models.GameStatusReady
 	}
 
 	// 二次验证：计算新余额确保不为负数
-	newBalance := player2.Balance - game.BetAmount
+	newBalance := spendable - game.BetAmount
 	if newBalance < 0 {
 		return nil, fmt.Errorf("余额不足，请存款后再试")
 	}
@@ -210,15 +472,44 @@ func (m *Manager) JoinGame(gameID string, playerID int64) (*GameResult, error) {
 	}
 
 	// 使用事务确保原子性
-	if err := m.db.JoinGameWithTransaction(gameID, playerID, newBalance, tx2); err != nil {
+	if err := m.db.JoinGameWithTransaction(gameID, playerID, newBalance, tx2, joinStatus); err != nil {
+		if isJoinRace(err) {
+			return nil, m.rejectJoin(game, playerID)
+		}
 		return nil, fmt.Errorf("加入游戏失败: %v", err)
 	}
 
+	m.recordGameEvent(&models.GameEvent{
+		GameID: gameID,
+		Type:   models.GameEventJoined,
+		UserID: playerID,
+		ChatID: game.ChatID,
+		Detail: fmt.Sprintf("加入对局，下注 %s 金币", utils.FormatAmount(game.BetAmount)),
+	})
+
 	// 取消游戏超时定时器（有人加入了）
 	m.cancelGameTimeout(gameID)
-
+	m.clearRematch(gameID)
+	m.events.Publish(events.GameJoined{GameID: gameID, ChatID: game.ChatID, PlayerID: playerID})
+	if readyCheck {
+		return m.awaitReady(game, playerID)
+	}
 	// 开始游戏
-	return m.playGame(game, playerID)
+	result, err := m.playGame(game, playerID)
+	if err == nil {
+		m.markStarted(gameID)
+	}
+	return result, err
+}
+
+// markStarted 对局开骰：启动超时中止计时并记录指标
+func (m *Manager) markStarted(gameID string) {
+	m.trackOwned(gameID)
+	m.armWatchdog(gameID)
+	m.metrics.gameJoined(gameID)
+	if m.onGameStarted != nil {
+		m.onGameStarted(gameID)
+	}
 }
 
 func (m *Manager) playGame(game *models.Game, player2ID int64) (*GameResult, error) {
@@ -230,7 +521,11 @@ func (m *Manager) playGame(game *models.Game, player2ID int64) (*GameResult, err
 	if err := m.db.UpdateGame(game); err != nil {
 		return nil, err
 	}
+	return m.startedResult(game, player2ID)
+}
 
+// startedResult 开局时返回给调用方的双方信息和交手记录
+func (m *Manager) startedResult(game *models.Game, player2ID int64) (*GameResult, error) {
 	// 获取玩家信息
 	player1, err := m.db.GetUser(game.Player1ID)
 	if err != nil {
@@ -249,10 +544,27 @@ func (m *Manager) playGame(game *models.Game, player2ID int64) (*GameResult, err
 		Player2:   player2,
 		BetAmount: game.BetAmount,
 	}
+	result.HeadToHead = m.rivalry(game.Player1ID, player2ID)
 
 	return result, nil
 }
 
+// rivalry 双方此前的交手记录，未设置缓存、从未交手或读取失败时返回 nil
+func (m *Manager) rivalry(player1ID, player2ID int64) *models.HeadToHead {
+	if m.headToHead == nil {
+		return nil
+	}
+	record, err := m.headToHead.Get(player1ID, player2ID)
+	if err != nil {
+		log.Printf("⚠️ 获取玩家 %d 与 %d 的交手记录失败: %v", player1ID, player2ID, err)
+		return nil
+	}
+	if record.Games == 0 {
+		return nil
+	}
+	return record
+}
+
 // PlayGameWithDiceResults 使用TG骰子动画的实际结果完成游戏
 func (m *Manager) PlayGameWithDiceResults(gameID string, p1d1, p1d2, p1d3, p2d1, p2d2, p2d3 int) (*GameResult, error) {
 	// 获取游戏信息
@@ -269,22 +581,39 @@ func (m *Manager) PlayGameWithDiceResults(gameID string, p1d1, p1d2, p1d3, p2d1,
 		return nil, fmt.Errorf("游戏状态错误")
 	}
 
-	// 计算总和
-	player1Total := p1d1 + p1d2 + p1d3
-	player2Total := p2d1 + p2d2 + p2d3
+	// 事故处理期间暂停自动结算，骰子结果保留待管理员审核
+	held, err := m.SettlementsHeld()
+	if err != nil {
+		log.Printf("⚠️ 读取结算暂停状态失败，按正常流程结算: %v", err)
+	} else if held {
+		return m.holdSettlement(game, p1d1, p1d2, p1d3, p2d1, p2d2, p2d3)
+	}
+
+	return m.settleGame(game, p1d1, p1d2, p1d3, p2d1, p2d2, p2d3)
+}
+
+// settleGame 按骰子结果结算进行中的对局：平局退款，否则向获胜者派奖
+func (m *Manager) settleGame(game *models.Game, p1d1, p1d2, p1d3, p2d1, p2d2, p2d3 int) (*GameResult, error) {
+	// 按启用的玩法插件判定胜负
+	outcome := rules.Outcome{
+		ChatID:    game.ChatID,
+		BetAmount: game.BetAmount,
+		Player1:   [3]int{p1d1, p1d2, p1d3},
+		Player2:   [3]int{p2d1, p2d2, p2d3},
+	}
+	outcome.Winner = m.gameRules.Winner(outcome)
 
 	// 计算结果
 	totalPot := game.BetAmount * 2
-	commission := utils.CalculateCommission(totalPot, m.feeRate)
-	winAmount := totalPot - commission
+	payout := m.gameRules.Payout(outcome, rules.Payout{
+		Pot:        totalPot,
+		Commission: utils.CalculateCommission(totalPot, m.chatFeeRate(game.ChatID)),
+	})
+	commission := payout.Commission
+	winAmount := payout.WinAmount()
 
 	// 检查是否平局
-	if player1Total == player2Total {
-		// 平局，退还下注金额
-		if err := m.refundGame(game); err != nil {
-			return nil, err
-		}
-		
+	if outcome.Winner == rules.Draw {
 		// 更新游戏状态为平局
 		game.Status = models.GameStatusFinished
 		game.Player1Dice1 = &p1d1
@@ -293,31 +622,40 @@ func (m *Manager) PlayGameWithDiceResults(gameID string, p1d1, p1d2, p1d3, p2d1,
 		game.Player2Dice1 = &p2d1
 		game.Player2Dice2 = &p2d2
 		game.Player2Dice3 = &p2d3
-		
-		if err := m.db.UpdateGame(game); err != nil {
+
+		// 平局，退还下注金额，结算消息随退款一同写入
+		credits := map[int64]int64{game.Player1ID: game.BetAmount}
+		if game.Player2ID != nil {
+			credits[*game.Player2ID] = game.BetAmount
+		}
+		outbox := m.settlementOutbox(game, true, credits)
+		if err := m.drawGame(game, outbox); err != nil {
 			return nil, err
 		}
-		
+		m.outboxCommitted(outbox)
+
+		m.metrics.gameRefunded(game.ID)
+		m.recordGameEvent(&models.GameEvent{
+			GameID: game.ID,
+			Type:   models.GameEventSettled,
+			ChatID: game.ChatID,
+			Detail: "平局，双方退款；" + diceDetail(p1d1, p1d2, p1d3, p2d1, p2d2, p2d3),
+		})
+		m.notifyGameFinished(game)
 		result, _ := m.buildGameResult(game, true)
+		m.publishSettled(game, result)
 		return result, nil
 	}
 
 	// 确定获胜者
 	var winnerID int64
-	if player1Total > player2Total {
+	if outcome.Winner == rules.Player1 {
 		winnerID = game.Player1ID
 	} else {
 		winnerID = *game.Player2ID
 	}
 
-	// 获取获胜者信息
-	winner, err := m.db.GetUser(winnerID)
-	if err != nil {
-		return nil, err
-	}
-	newWinnerBalance := winner.Balance + winAmount
-
-	// 准备交易记录
+	// 准备交易记录，交易后的余额在结算事务中入账时回填
 	var transactions []*models.Transaction
 
 	// 获胜交易记录
@@ -327,27 +665,18 @@ func (m *Manager) PlayGameWithDiceResults(gameID string, p1d1, p1d2, p1d3, p2d1,
 		GameID:      &game.ID,
 		Type:        models.TransactionTypeWin,
 		Amount:      winAmount,
-		Balance:     newWinnerBalance,
 		Description: fmt.Sprintf("赢得游戏 %s", game.ID),
 	}
 	transactions = append(transactions, winTx)
 
-	// 手续费交易记录
-	commissionTx := &models.Transaction{
-		ID:          utils.GenerateTransactionID(),
-		UserID:      0, // 系统账户
-		GameID:      &game.ID,
-		Type:        models.TransactionTypeCommission,
-		Amount:      commission,
-		Balance:     0,
-		Description: fmt.Sprintf("游戏 %s 手续费", game.ID),
-	}
-	transactions = append(transactions, commissionTx)
+	// 手续费交易记录（含群组分成）
+	commissionTxs, chatShare := m.commissionTransactions(game, commission)
+	transactions = append(transactions, commissionTxs...)
 
-	// 使用事务结算游戏
-	if err := m.db.SettleGameWithTransaction(game.ID, &winnerID, commission, 
-		p1d1, p1d2, p1d3, p2d1, p2d2, p2d3, newWinnerBalance, transactions); err != nil {
-		return nil, err
+	// 保险赔付交易记录（发起者投保且输局）
+	payoutTx := insurancePayout(game, winnerID)
+	if payoutTx != nil {
+		transactions = append(transactions, payoutTx)
 	}
 
 	// 更新本地游戏对象以构建结果
@@ -360,65 +689,142 @@ func (m *Manager) PlayGameWithDiceResults(gameID string, p1d1, p1d2, p1d3, p2d1,
 	game.Player2Dice3 = &p2d3
 	game.WinnerID = &winnerID
 	game.Commission = commission
+	game.ChatShare = chatShare
+
+	// 结算消息与结算在同一事务中写入
+	credits := map[int64]int64{winnerID: winAmount}
+	if payoutTx != nil {
+		credits[payoutTx.UserID] += payoutTx.Amount
+	}
+	outbox := m.settlementOutbox(game, false, credits)
+
+	// 使用事务结算游戏
+	if err := m.db.SettleGameWithTransaction(game.ID, &winnerID, commission,
+		p1d1, p1d2, p1d3, p2d1, p2d2, p2d3, transactions, outbox); err != nil {
+		return nil, err
+	}
+	m.outboxCommitted(outbox)
+	m.metrics.gameSettled(game.ID)
+	m.recordGameEvent(&models.GameEvent{
+		GameID: game.ID,
+		Type:   models.GameEventSettled,
+		UserID: winnerID,
+		ChatID: game.ChatID,
+		Detail: fmt.Sprintf("玩家 %d 获胜，派奖 %s 金币，手续费 %s 金币；%s", winnerID,
+			utils.FormatAmount(winAmount), utils.FormatAmount(commission), diceDetail(p1d1, p1d2, p1d3, p2d1, p2d2, p2d3)),
+	})
+	m.notifyGameFinished(game)
+	loserID := game.Player1ID
+	if winnerID == game.Player1ID {
+		loserID = *game.Player2ID
+	}
+	m.checkRisk(game, winnerID, loserID)
 
 	result, err := m.buildGameResult(game, false)
 	if err != nil {
 		return nil, err
 	}
+	if payoutTx != nil {
+		result.InsurancePayout = payoutTx.Amount
+	}
+	m.publishSettled(game, result)
 	return result, nil
 }
 
-func (m *Manager) refundGame(game *models.Game) error {
-	// 获取玩家1信息
-	player1, err := m.db.GetUser(game.Player1ID)
+// publishSettled 发布对局结算事件
+func (m *Manager) publishSettled(game *models.Game, result *GameResult) {
+	if m.events == nil || result == nil {
+		return
+	}
+
+	event := events.GameSettled{
+		GameID:     game.ID,
+		ChatID:     game.ChatID,
+		Player1ID:  game.Player1ID,
+		BetAmount:  game.BetAmount,
+		WinAmount:  result.WinAmount,
+		Commission: result.Commission,
+		Draw:       result.Winner == nil,
+	}
+	if game.Player2ID != nil {
+		event.Player2ID = *game.Player2ID
+	}
+	if result.Winner != nil {
+		event.WinnerID = result.Winner.ID
+		event.WinnerUsername = result.Winner.Username
+		event.WinnerFirstName = result.Winner.FirstName
+		if result.Winner.ID == game.Player1ID && game.Player2ID != nil {
+			event.LoserID = *game.Player2ID
+		} else {
+			event.LoserID = game.Player1ID
+		}
+	}
+	m.events.Publish(event)
+}
+
+// gameRefund 向对局双方退还下注的退款交易记录，余额在退款事务中按增量入账
+type gameRefund struct {
+	player2ID    *int64
+	transactions []*models.Transaction
+}
+
+// drawGame 平局结束对局并向双方退款，状态更新与退款在同一事务中完成，对局已被认输或取消时不会重复退款
+func (m *Manager) drawGame(game *models.Game, outbox []*models.OutboxMessage) error {
+	refund, err := m.prepareRefund(game)
 	if err != nil {
 		return err
 	}
-	newBalance1 := player1.Balance + game.BetAmount
 
-	// 准备交易记录
-	var transactions []*models.Transaction
-	
+	return m.db.DrawGameWithTransaction(game.ID, *game.Player1Dice1, *game.Player1Dice2, *game.Player1Dice3,
+		*game.Player2Dice1, *game.Player2Dice2, *game.Player2Dice3, game.Player1ID, refund.player2ID, refund.transactions, outbox)
+}
+
+// cancelGame 将对局从 from 状态改为已取消，并在同一事务中向双方退款，对局已被其他流程处理时返回 false
+func (m *Manager) cancelGame(game *models.Game, from string) (bool, error) {
+	refund, err := m.prepareRefund(game)
+	if err != nil {
+		return false, err
+	}
+	ok, err := m.db.CancelGameWithRefund(game.ID, from, game.Player1ID, refund.player2ID, refund.transactions)
+	if ok && err == nil {
+		m.recordGameEvent(&models.GameEvent{
+			GameID: game.ID,
+			Type:   models.GameEventCancelled,
+			ChatID: game.ChatID,
+			Detail: fmt.Sprintf("从 %s 状态取消，退还双方下注", from),
+		})
+	}
+	return ok, err
+}
+
+// prepareRefund 准备向双方退还下注的退款交易记录
+func (m *Manager) prepareRefund(game *models.Game) (*gameRefund, error) {
 	// 玩家1退款交易记录
-	tx1 := &models.Transaction{
+	transactions := []*models.Transaction{{
 		ID:          utils.GenerateTransactionID(),
 		UserID:      game.Player1ID,
 		GameID:      &game.ID,
 		Type:        models.TransactionTypeRefund,
 		Amount:      game.BetAmount,
-		Balance:     newBalance1,
 		Description: "游戏退款",
-	}
-	transactions = append(transactions, tx1)
-
-	var player2ID *int64
-	var newBalance2 *int64
+	}}
 
 	// 如果有玩家2，准备玩家2的退款
 	if game.Player2ID != nil {
-		player2, err := m.db.GetUser(*game.Player2ID)
-		if err != nil {
-			return err
-		}
-		balance2 := player2.Balance + game.BetAmount
-		player2ID = game.Player2ID
-		newBalance2 = &balance2
-
-		// 玩家2退款交易记录
-		tx2 := &models.Transaction{
+		transactions = append(transactions, &models.Transaction{
 			ID:          utils.GenerateTransactionID(),
 			UserID:      *game.Player2ID,
 			GameID:      &game.ID,
 			Type:        models.TransactionTypeRefund,
 			Amount:      game.BetAmount,
-			Balance:     balance2,
 			Description: "游戏退款",
-		}
-		transactions = append(transactions, tx2)
+		})
 	}
 
-	// 使用事务执行退款
-	return m.db.RefundGameWithTransaction(game.Player1ID, newBalance1, player2ID, newBalance2, transactions)
+	return &gameRefund{
+		player2ID:    game.Player2ID,
+		transactions: transactions,
+	}, nil
 }
 
 func (m *Manager) buildGameResult(game *models.Game, isDraw bool) (*GameResult, error) {
@@ -440,6 +846,7 @@ func (m *Manager) buildGameResult(game *models.Game, isDraw bool) (*GameResult,
 		Player1:    player1,
 		Player2:    player2,
 		Commission: game.Commission,
+		ChatShare:  game.ChatShare,
 		BetAmount:  game.BetAmount,
 	}
 
@@ -470,75 +877,6 @@ func (m *Manager) buildGameResult(game *models.Game, isDraw bool) (*GameResult,
 	return result, nil
 }
 
-// generateRandomSeed 生成可验证的随机种子
-func (m *Manager) generateRandomSeed(gameID string, player1ID, player2ID int64) string {
-	// 使用游戏ID、玩家ID和当前时间戳生成种子
-	data := fmt.Sprintf("%s-%d-%d-%d", gameID, player1ID, player2ID, time.Now().UnixNano())
-	hash := sha256.Sum256([]byte(data))
-	return hex.EncodeToString(hash[:])
-}
-
-// rollDiceWithSeed 使用种子生成可验证的骰子结果（3个骰子）
-func (m *Manager) rollDiceWithSeed(seed string, playerIndex int) (int, int, int, error) {
-	// 将种子和玩家索引组合
-	seedData := fmt.Sprintf("%s-%d", seed, playerIndex)
-	hash := sha256.Sum256([]byte(seedData))
-
-	// 使用哈希的前8字节作为随机数种子
-	var seedInt int64
-	for i := 0; i < 8; i++ {
-		seedInt = (seedInt << 8) | int64(hash[i])
-	}
-
-	// 生成3个骰子的结果
-	dice1 := (seedInt % 6) + 1
-	if dice1 < 0 {
-		dice1 = -dice1
-	}
-	if dice1 == 0 {
-		dice1 = 1
-	}
-	if dice1 > 6 {
-		dice1 = (dice1 % 6) + 1
-	}
-
-	// 为第二个骰子生成新的种子
-	hash2 := sha256.Sum256([]byte(seedData + "dice2"))
-	var seedInt2 int64
-	for i := 0; i < 8; i++ {
-		seedInt2 = (seedInt2 << 8) | int64(hash2[i])
-	}
-	dice2 := (seedInt2 % 6) + 1
-	if dice2 < 0 {
-		dice2 = -dice2
-	}
-	if dice2 == 0 {
-		dice2 = 1
-	}
-	if dice2 > 6 {
-		dice2 = (dice2 % 6) + 1
-	}
-
-	// 为第三个骰子生成新的种子
-	hash3 := sha256.Sum256([]byte(seedData + "dice3"))
-	var seedInt3 int64
-	for i := 0; i < 8; i++ {
-		seedInt3 = (seedInt3 << 8) | int64(hash3[i])
-	}
-	dice3 := (seedInt3 % 6) + 1
-	if dice3 < 0 {
-		dice3 = -dice3
-	}
-	if dice3 == 0 {
-		dice3 = 1
-	}
-	if dice3 > 6 {
-		dice3 = (dice3 % 6) + 1
-	}
-
-	return int(dice1), int(dice2), int(dice3), nil
-}
-
 func (m *Manager) rollDice() (int, int, int, error) {
 	// 使用加密安全的随机数生成器生成3个骰子
 	n1, err := rand.Int(rand.Reader, big.NewInt(6))
@@ -600,6 +938,11 @@ func (m *Manager) cancelGameTimeout(gameID string) {
 
 // expireGame 处理游戏超时
 func (m *Manager) expireGame(gameID string) {
+	m.expireWaitingGame(gameID, "无人加入，超时退款")
+}
+
+// expireWaitingGame 将等待中的对局标记为过期并向发起者退款，detail 记入对局时间线
+func (m *Manager) expireWaitingGame(gameID, detail string) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
@@ -614,33 +957,29 @@ func (m *Manager) expireGame(gameID string) {
 		return
 	}
 
-	// 更新游戏状态为过期
-	if err := m.db.UpdateGameStatus(gameID, models.GameStatusExpired); err != nil {
-		return
-	}
-
-	// 退还玩家1的下注金额
-	player1, err := m.db.GetUser(game.Player1ID)
-	if err != nil {
-		return
-	}
-
-	newBalance := player1.Balance + game.BetAmount
-	if err := m.db.UpdateUserBalance(game.Player1ID, newBalance); err != nil {
-		return
-	}
-
-	// 记录退款交易
+	// 对局改为过期并向玩家1退款，在同一事务中完成
 	tx := &models.Transaction{
 		ID:          utils.GenerateTransactionID(),
 		UserID:      game.Player1ID,
 		GameID:      &gameID,
 		Type:        models.TransactionTypeRefund,
 		Amount:      game.BetAmount,
-		Balance:     newBalance,
 		Description: fmt.Sprintf("游戏超时退款 %s", gameID),
 	}
-	m.db.CreateTransaction(tx)
+	if err := m.db.ExpireGameWithTransaction(gameID, game.Player1ID, tx); err != nil {
+		log.Printf("⚠️ 对局 %s 过期退款失败: %v", gameID, err)
+		return
+	}
+	m.clearRematch(gameID)
+	m.recordGameEvent(&models.GameEvent{
+		GameID: gameID,
+		Type:   models.GameEventExpired,
+		UserID: game.Player1ID,
+		ChatID: game.ChatID,
+		Detail: detail,
+	})
+
+	m.metrics.gameExpired()
 
 	// 发送超时通知
 	if m.onGameExpired != nil {
@@ -658,6 +997,9 @@ func (m *Manager) startCleanupTask() {
 
 	for range ticker.C {
 		m.cleanupExpiredGames()
+		m.sweepStalledGames()
+		m.sweepStaleReady()
+		m.sweepDormantChats()
 	}
 }
 
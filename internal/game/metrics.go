@@ -0,0 +1,220 @@
+package game
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// settlementBuckets 结算耗时直方图的分桶（秒），从加入对局到结算完成
+var settlementBuckets = []float64{1, 2, 5, 10, 20, 30, 60, 120}
+
+// rateWindow 计算每分钟对局数的统计窗口
+const rateWindow = 5 * time.Minute
+
+// Metrics 游戏流程指标
+type Metrics struct {
+	created     int64
+	joined      int64
+	settled     int64
+	refunded    int64
+	surrendered int64
+	expired     int64
+	// 超过最长时长被强制中止的对局
+	aborted int64
+	// 对局已被抢先加入而被拒绝的加入请求
+	joinsRejected int64
+
+	mu            sync.Mutex
+	startedAt     map[string]time.Time
+	bucketCounts  []int64
+	latencySum    float64
+	latencyCount  int64
+	finishedTimes []time.Time
+}
+
+// MetricsSnapshot 指标快照，用于管理后台展示
+type MetricsSnapshot struct {
+	Created        int64         `json:"created"`
+	Joined         int64         `json:"joined"`
+	Settled        int64         `json:"settled"`
+	Refunded       int64         `json:"refunded"`
+	Surrendered    int64         `json:"surrendered"`
+	Expired        int64         `json:"expired"`
+	Aborted        int64         `json:"aborted"`
+	JoinsRejected  int64         `json:"joins_rejected"`
+	GamesPerMinute float64       `json:"games_per_minute"`
+	AvgSettlement  time.Duration `json:"avg_settlement"`
+	RefundRate     float64       `json:"refund_rate"`
+}
+
+// NewMetrics 创建游戏指标
+func NewMetrics() *Metrics {
+	return &Metrics{
+		startedAt:    make(map[string]time.Time),
+		bucketCounts: make([]int64, len(settlementBuckets)),
+	}
+}
+
+func (mt *Metrics) gameCreated() {
+	atomic.AddInt64(&mt.created, 1)
+}
+
+func (mt *Metrics) gameJoined(gameID string) {
+	atomic.AddInt64(&mt.joined, 1)
+
+	mt.mu.Lock()
+	mt.startedAt[gameID] = time.Now()
+	mt.mu.Unlock()
+}
+
+func (mt *Metrics) gameSettled(gameID string) {
+	atomic.AddInt64(&mt.settled, 1)
+	mt.finish(gameID)
+}
+
+func (mt *Metrics) gameRefunded(gameID string) {
+	atomic.AddInt64(&mt.refunded, 1)
+	mt.finish(gameID)
+}
+
+func (mt *Metrics) gameSurrendered(gameID string) {
+	atomic.AddInt64(&mt.surrendered, 1)
+	mt.finish(gameID)
+}
+
+func (mt *Metrics) gameExpired() {
+	atomic.AddInt64(&mt.expired, 1)
+}
+
+// gameAborted 对局超时中止，不计入结算耗时
+func (mt *Metrics) gameAborted(gameID string) {
+	atomic.AddInt64(&mt.aborted, 1)
+
+	mt.mu.Lock()
+	delete(mt.startedAt, gameID)
+	mt.finishedTimes = append(mt.finishedTimes, time.Now())
+	mt.mu.Unlock()
+}
+
+func (mt *Metrics) joinRejected() {
+	atomic.AddInt64(&mt.joinsRejected, 1)
+}
+
+// finish 记录对局结束并统计结算耗时
+func (mt *Metrics) finish(gameID string) {
+	now := time.Now()
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	mt.finishedTimes = append(mt.finishedTimes, now)
+	mt.pruneLocked(now)
+
+	started, ok := mt.startedAt[gameID]
+	if !ok {
+		return
+	}
+	delete(mt.startedAt, gameID)
+
+	seconds := now.Sub(started).Seconds()
+	mt.latencySum += seconds
+	mt.latencyCount++
+	for i, bound := range settlementBuckets {
+		if seconds <= bound {
+			mt.bucketCounts[i]++
+		}
+	}
+}
+
+// pruneLocked 清理统计窗口外的结束记录
+func (mt *Metrics) pruneLocked(now time.Time) {
+	i := 0
+	for i < len(mt.finishedTimes) && now.Sub(mt.finishedTimes[i]) > rateWindow {
+		i++
+	}
+	mt.finishedTimes = mt.finishedTimes[i:]
+}
+
+// Snapshot 获取当前指标快照
+func (mt *Metrics) Snapshot() MetricsSnapshot {
+	snapshot := MetricsSnapshot{
+		Created:     atomic.LoadInt64(&mt.created),
+		Joined:      atomic.LoadInt64(&mt.joined),
+		Settled:     atomic.LoadInt64(&mt.settled),
+		Refunded:    atomic.LoadInt64(&mt.refunded),
+		Surrendered: atomic.LoadInt64(&mt.surrendered),
+		Expired:     atomic.LoadInt64(&mt.expired),
+		Aborted:     atomic.LoadInt64(&mt.aborted),
+
+		JoinsRejected: atomic.LoadInt64(&mt.joinsRejected),
+	}
+
+	mt.mu.Lock()
+	mt.pruneLocked(time.Now())
+	snapshot.GamesPerMinute = float64(len(mt.finishedTimes)) / rateWindow.Minutes()
+	if mt.latencyCount > 0 {
+		snapshot.AvgSettlement = time.Duration(mt.latencySum / float64(mt.latencyCount) * float64(time.Second))
+	}
+	mt.mu.Unlock()
+
+	// 退款率：平局退款、超时退款和中止退款占所有结束对局的比例
+	finished := snapshot.Settled + snapshot.Refunded + snapshot.Surrendered + snapshot.Expired + snapshot.Aborted
+	if finished > 0 {
+		snapshot.RefundRate = float64(snapshot.Refunded+snapshot.Expired+snapshot.Aborted) / float64(finished)
+	}
+	return snapshot
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出指标
+func (mt *Metrics) WritePrometheus(w io.Writer) error {
+	snapshot := mt.Snapshot()
+
+	counters := []struct {
+		event string
+		value int64
+	}{
+		{"created", snapshot.Created},
+		{"joined", snapshot.Joined},
+		{"settled", snapshot.Settled},
+		{"refunded", snapshot.Refunded},
+		{"surrendered", snapshot.Surrendered},
+		{"expired", snapshot.Expired},
+		{"aborted", snapshot.Aborted},
+		{"join_rejected", snapshot.JoinsRejected},
+	}
+
+	if _, err := fmt.Fprintln(w, "# HELP dice_games_total Number of game lifecycle events.\n# TYPE dice_games_total counter"); err != nil {
+		return err
+	}
+	for _, c := range counters {
+		if _, err := fmt.Fprintf(w, "dice_games_total{event=%q} %d\n", c.event, c.value); err != nil {
+			return err
+		}
+	}
+
+	mt.mu.Lock()
+	buckets := append([]int64(nil), mt.bucketCounts...)
+	sum, count := mt.latencySum, mt.latencyCount
+	mt.mu.Unlock()
+
+	if _, err := fmt.Fprintln(w, "# HELP dice_game_settlement_seconds Time from join to settlement.\n# TYPE dice_game_settlement_seconds histogram"); err != nil {
+		return err
+	}
+	for i, bound := range settlementBuckets {
+		if _, err := fmt.Fprintf(w, "dice_game_settlement_seconds_bucket{le=\"%g\"} %d\n", bound, buckets[i]); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "dice_game_settlement_seconds_bucket{le=\"+Inf\"} %d\ndice_game_settlement_seconds_sum %g\ndice_game_settlement_seconds_count %d\n",
+		count, sum, count)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "# HELP dice_games_per_minute Finished games per minute over the last 5 minutes.\n# TYPE dice_games_per_minute gauge\ndice_games_per_minute %g\n",
+		snapshot.GamesPerMinute)
+	return err
+}
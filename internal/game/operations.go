@@ -0,0 +1,190 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// 对局所处的阶段，用于管理后台的运营页面
+const (
+	PhaseWaiting  = "waiting"  // 等待对手加入或双方确认准备
+	PhaseRolling  = "rolling"  // 已开骰，等待骰子结果
+	PhaseSettling = "settling" // 骰子已开出，结算暂停等待审核
+)
+
+// ActiveGame 未结束的对局及其所处阶段
+type ActiveGame struct {
+	Game  *models.Game
+	Phase string
+	// 进入当前阶段后经过的时长
+	Elapsed time.Duration
+	// 排队等待加入该对局的玩家，没有时为 0
+	QueuedPlayerID int64
+}
+
+// ChatOperations 单个群组未结束的对局和排队的加入请求数
+type ChatOperations struct {
+	ChatID     int64
+	Games      []*ActiveGame
+	QueueDepth int
+}
+
+// gamePhase 对局状态对应的阶段
+func gamePhase(status string) string {
+	switch status {
+	case models.GameStatusPlaying:
+		return PhaseRolling
+	case models.GameStatusHeld:
+		return PhaseSettling
+	default:
+		return PhaseWaiting
+	}
+}
+
+// Operations 汇总所有群组未结束的对局及各群排队的加入请求，按群组ID排列
+func (m *Manager) Operations() ([]*ChatOperations, error) {
+	games, err := m.db.GetOpenGames()
+	if err != nil {
+		return nil, fmt.Errorf("获取进行中的对局失败: %v", err)
+	}
+
+	m.queueMu.Lock()
+	queued := make(map[string]int64)
+	depths := make(map[int64]int, len(m.queues))
+	for chatID, queue := range m.queues {
+		depths[chatID] = len(queue)
+		for _, join := range queue {
+			queued[join.gameID] = join.playerID
+		}
+	}
+	m.queueMu.Unlock()
+
+	now := time.Now()
+	chats := make(map[int64]*ChatOperations)
+	chatOperations := func(chatID int64) *ChatOperations {
+		ops, exists := chats[chatID]
+		if !exists {
+			ops = &ChatOperations{ChatID: chatID, QueueDepth: depths[chatID]}
+			chats[chatID] = ops
+		}
+		return ops
+	}
+	for _, game := range games {
+		ops := chatOperations(game.ChatID)
+		ops.Games = append(ops.Games, &ActiveGame{
+			Game:           game,
+			Phase:          gamePhase(game.Status),
+			Elapsed:        now.Sub(game.UpdatedAt),
+			QueuedPlayerID: queued[game.ID],
+		})
+	}
+	// 排队的对局可能刚结束、尚未从队列移除，仍列出该群的排队数
+	for chatID := range depths {
+		chatOperations(chatID)
+	}
+
+	result := make([]*ChatOperations, 0, len(chats))
+	for _, ops := range chats {
+		result = append(result, ops)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ChatID < result[j].ChatID
+	})
+	return result, nil
+}
+
+// AbortGame 管理员强制中止未结束的对局，向双方退还下注和保险费，排队加入该对局的玩家被移出队列
+func (m *Manager) AbortGame(gameID string) (*models.Game, error) {
+	game, err := m.abortGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🛑 管理员中止对局 %s，已向双方退款", gameID)
+
+	// 通知可能需要访问 Telegram，不在调用方的请求中等待
+	if m.onGameAborted != nil {
+		go m.onGameAborted(game, models.GameAbortAdmin)
+	}
+	return game, nil
+}
+
+// abortGame 取消未结束的对局并退款，清理超时、准备确认和排队请求，返回取消后的对局
+func (m *Manager) abortGame(gameID string) (*models.Game, error) {
+	m.mutex.Lock()
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+	if game == nil {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("游戏不存在")
+	}
+	if models.GameState(game.Status).Terminal() {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("对局 %s 已结束", gameID)
+	}
+
+	// 取消和退款在同一事务中完成，迟到的加入、准备和骰子结果不会再处理
+	ok, err := m.cancelGame(game, game.Status)
+	if err != nil {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("退款失败: %v", err)
+	}
+	if !ok {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("对局 %s 已被处理", gameID)
+	}
+
+	m.cancelGameTimeout(gameID)
+	if check, exists := m.readyChecks[gameID]; exists {
+		if check.timer != nil {
+			check.timer.Stop()
+		}
+		delete(m.readyChecks, gameID)
+	}
+	m.clearRematch(gameID)
+	removed, position := m.dropQueuedJoin(game.ChatID, gameID)
+
+	joined := game.Player2ID != nil
+	game.Status = models.GameStatusCancelled
+	m.metrics.gameAborted(gameID)
+	if joined {
+		// 对手已加入的对局占用着群组的名额
+		m.notifyGameFinished(game)
+	}
+	m.mutex.Unlock()
+
+	if removed != nil {
+		if entry, err := m.queueEntry(game.ChatID, position, *removed); err != nil {
+			log.Printf("⚠️ 获取对局 %s 排队信息失败: %v", gameID, err)
+		} else {
+			m.notifyQueueRemoved(entry)
+		}
+	}
+	return game, nil
+}
+
+// dropQueuedJoin 移除排队加入该对局的请求，返回被移除的请求及其原来的位置
+func (m *Manager) dropQueuedJoin(chatID int64, gameID string) (*queuedJoin, int) {
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+
+	queue := m.queues[chatID]
+	for i, join := range queue {
+		if join.gameID != gameID {
+			continue
+		}
+		m.queues[chatID] = append(queue[:i:i], queue[i+1:]...)
+		if len(m.queues[chatID]) == 0 {
+			delete(m.queues, chatID)
+		}
+		return &join, i + 1
+	}
+	return nil, 0
+}
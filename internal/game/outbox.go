@@ -0,0 +1,56 @@
+package game
+
+import (
+	"log"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// SetSettlementOutbox 设置结算消息生成器：生成的消息与结算（或平局退款）在同一事务中写入发件箱，
+// 由发件箱发送器投递，进程在发送途中崩溃时重启后继续发送，群内消息与对局状态保持一致。
+// wake 在消息提交后调用，用于立即唤醒发送器（如 outbox.Sender.Notify）
+func (m *Manager) SetSettlementOutbox(compose func(result *GameResult) []*models.OutboxMessage, wake func()) {
+	m.composeSettlement = compose
+	m.wakeOutbox = wake
+}
+
+// outboxCommitted 结算消息已随结算提交，唤醒发送器
+func (m *Manager) outboxCommitted(messages []*models.OutboxMessage) {
+	if len(messages) > 0 && m.wakeOutbox != nil {
+		m.wakeOutbox()
+	}
+}
+
+// settlementOutbox 按即将提交的结算结果生成结算消息，credits 为结算中各玩家增加的余额；未设置生成器时返回 nil
+func (m *Manager) settlementOutbox(game *models.Game, isDraw bool, credits map[int64]int64) []*models.OutboxMessage {
+	if m.composeSettlement == nil {
+		return nil
+	}
+
+	result, err := m.buildGameResult(game, isDraw)
+	if err != nil {
+		log.Printf("⚠️ 生成对局 %s 结算消息失败: %v", game.ID, err)
+		return nil
+	}
+
+	// 结算尚未提交，消息中按结算后的余额显示
+	for _, user := range []*models.User{result.Player1, result.Player2} {
+		if user != nil {
+			user.Balance += credits[user.ID]
+		}
+	}
+	if !isDraw && game.WinnerID != nil && *game.WinnerID != game.Player1ID {
+		result.InsurancePayout = credits[game.Player1ID]
+	}
+
+	messages := m.composeSettlement(result)
+	for _, message := range messages {
+		if message.ChatID == 0 {
+			message.ChatID = game.ChatID
+		}
+		if message.GameID == "" {
+			message.GameID = game.ID
+		}
+	}
+	return messages
+}
@@ -0,0 +1,67 @@
+package game
+
+import "time"
+
+// PlaybackMode 对局动画播放模式
+type PlaybackMode string
+
+const (
+	// PlaybackFast 额度充足，逐个骰子快速播放
+	PlaybackFast PlaybackMode = "fast"
+	// PlaybackNormal 额度偏紧，逐个骰子播放但拉长间隔
+	PlaybackNormal PlaybackMode = "normal"
+	// PlaybackCompact 额度接近用尽，合并为一条结果消息
+	PlaybackCompact PlaybackMode = "compact"
+)
+
+// BudgetSource 提供群组剩余发送额度占比（0-1），由 telegram.Limiter 实现
+type BudgetSource interface {
+	Budget(chatID int64) float64
+}
+
+// Playback 一局对局的播放方案
+type Playback struct {
+	Mode  PlaybackMode
+	Delay time.Duration // 相邻解说消息之间的间隔
+}
+
+// Pacer 根据群组限流压力决定动画播放速度
+type Pacer struct {
+	budget BudgetSource
+
+	FastDelay    time.Duration // 空闲时的消息间隔
+	MaxDelay     time.Duration // 压力较大时的最长消息间隔
+	FastAbove    float64       // 剩余额度高于该值时使用快速模式
+	CompactBelow float64       // 剩余额度低于该值时使用精简模式
+}
+
+// NewPacer 创建动画节奏控制器
+func NewPacer(budget BudgetSource) *Pacer {
+	return &Pacer{
+		budget:       budget,
+		FastDelay:    500 * time.Millisecond,
+		MaxDelay:     4 * time.Second,
+		FastAbove:    0.6,
+		CompactBelow: 0.25,
+	}
+}
+
+// Plan 根据群组当前剩余额度给出播放方案，额度越少间隔越长
+func (p *Pacer) Plan(chatID int64) Playback {
+	if p == nil || p.budget == nil {
+		return Playback{Mode: PlaybackNormal, Delay: time.Second}
+	}
+
+	budget := p.budget.Budget(chatID)
+	switch {
+	case budget >= p.FastAbove:
+		return Playback{Mode: PlaybackFast, Delay: p.FastDelay}
+	case budget < p.CompactBelow:
+		return Playback{Mode: PlaybackCompact}
+	}
+
+	// 在 FastDelay 与 MaxDelay 之间按压力线性插值
+	pressure := (p.FastAbove - budget) / (p.FastAbove - p.CompactBelow)
+	delay := p.FastDelay + time.Duration(pressure*float64(p.MaxDelay-p.FastDelay))
+	return Playback{Mode: PlaybackNormal, Delay: delay}
+}
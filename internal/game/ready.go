@@ -0,0 +1,277 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// ReadyWindow 对手加入后双方确认准备的默认时长
+const ReadyWindow = 30 * time.Second
+
+// ErrNotAwaitingReady 对局不在等待准备阶段（已开骰、已撤销或已取消）
+var ErrNotAwaitingReady = errors.New("对局不在准备阶段")
+
+// readyCheck 已加入、等待双方确认准备的对局
+type readyCheck struct {
+	chatID    int64
+	betAmount int64
+	player1ID int64
+	player2ID int64
+	ready1    bool
+	ready2    bool
+	deadline  time.Time
+	timer     *time.Timer
+}
+
+// ReadyState 准备确认的进度
+type ReadyState struct {
+	GameID       string
+	ChatID       int64
+	BetAmount    int64
+	Player1ID    int64
+	Player2ID    int64
+	Player1Ready bool
+	Player2Ready bool
+	Deadline     time.Time
+	// 双方均已准备，对局已开骰
+	Started bool
+}
+
+// SetReadyCallbacks 设置双方确认后开骰的回调，以及准备超时的回调：
+// reverted 为 true 表示加入者未确认，已撤销加入并恢复等待；否则发起者未确认，对局已取消并退款
+func (m *Manager) SetReadyCallbacks(onStarted func(chatID int64, result *GameResult), onExpired func(state *ReadyState, reverted bool)) {
+	m.onReadyStarted = onStarted
+	m.onReadyExpired = onExpired
+}
+
+// SetReadyWindow 设置双方确认准备的时长，默认为 ReadyWindow（测试中使用更短的时长）
+func (m *Manager) SetReadyWindow(window time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.readyWindow = window
+}
+
+// awaitReady 对局进入等待准备阶段，时限内双方未全部确认时按超时处理，调用方需持有 m.mutex
+func (m *Manager) awaitReady(game *models.Game, player2ID int64) (*GameResult, error) {
+	game.Player2ID = &player2ID
+	game.Status = models.GameStatusReady
+
+	result, err := m.startedResult(game, player2ID)
+	if err != nil {
+		return nil, err
+	}
+
+	gameID := game.ID
+	check := &readyCheck{
+		chatID:    game.ChatID,
+		betAmount: game.BetAmount,
+		player1ID: game.Player1ID,
+		player2ID: player2ID,
+		deadline:  time.Now().Add(m.readyWindow),
+	}
+	check.timer = time.AfterFunc(m.readyWindow, func() {
+		m.expireReady(gameID)
+	})
+	m.readyChecks[gameID] = check
+	m.trackOwned(gameID)
+
+	result.AwaitingReady = true
+	result.ReadyDeadline = check.deadline
+	log.Printf("⏳ 对局 %s 等待双方确认准备（%v 内）", gameID, m.readyWindow)
+	return result, nil
+}
+
+// ConfirmReady 玩家确认准备，双方均确认后立即开骰并通过回调通知
+func (m *Manager) ConfirmReady(gameID string, playerID int64) (*ReadyState, error) {
+	m.mutex.Lock()
+
+	check, ok := m.readyChecks[gameID]
+	if !ok {
+		m.mutex.Unlock()
+		return nil, ErrNotAwaitingReady
+	}
+
+	var already bool
+	switch playerID {
+	case check.player1ID:
+		already, check.ready1 = check.ready1, true
+	case check.player2ID:
+		already, check.ready2 = check.ready2, true
+	default:
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("您不是该游戏的玩家")
+	}
+	if already {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("你已确认准备，请等待对手")
+	}
+
+	m.recordGameEvent(&models.GameEvent{
+		GameID: gameID,
+		Type:   models.GameEventReady,
+		UserID: playerID,
+		ChatID: check.chatID,
+		Detail: "确认准备",
+	})
+
+	state := check.state(gameID)
+	if !check.ready1 || !check.ready2 {
+		m.mutex.Unlock()
+		return state, nil
+	}
+
+	// 双方均已准备，开骰
+	check.timer.Stop()
+	delete(m.readyChecks, gameID)
+	result, err := m.startReadyGame(gameID)
+	m.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	state.Started = true
+	log.Printf("✅ 对局 %s 双方已确认准备，开始掷骰", gameID)
+	if m.onReadyStarted != nil {
+		m.onReadyStarted(check.chatID, result)
+	}
+	return state, nil
+}
+
+// startReadyGame 将等待准备的对局改为进行中，调用方需持有 m.mutex
+func (m *Manager) startReadyGame(gameID string) (*GameResult, error) {
+	ok, err := m.db.TransitionGameStatus(gameID, models.GameStatusReady, models.GameStatusPlaying)
+	if err != nil {
+		return nil, fmt.Errorf("开始对局失败: %v", err)
+	}
+	if !ok {
+		return nil, ErrNotAwaitingReady
+	}
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil || game == nil || game.Player2ID == nil {
+		return nil, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+	result, err := m.startedResult(game, *game.Player2ID)
+	if err != nil {
+		return nil, err
+	}
+	m.markStarted(gameID)
+	return result, nil
+}
+
+// expireReady 准备超时：加入者未确认时撤销加入、退还其下注并恢复等待；加入者已确认而发起者未确认时取消对局并向双方退款
+func (m *Manager) expireReady(gameID string) {
+	m.mutex.Lock()
+
+	check, tracked := m.readyChecks[gameID]
+	delete(m.readyChecks, gameID)
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil || game == nil || game.Status != models.GameStatusReady || game.Player2ID == nil {
+		m.mutex.Unlock()
+		return
+	}
+
+	var state *ReadyState
+	if tracked {
+		state = check.state(gameID)
+	} else {
+		// 进程重启前加入的对局没有准备记录，按双方均未确认处理
+		state = &ReadyState{GameID: gameID, ChatID: game.ChatID, BetAmount: game.BetAmount, Player1ID: game.Player1ID, Player2ID: *game.Player2ID}
+	}
+
+	reverted := !state.Player2Ready
+	var ok bool
+	if reverted {
+		ok, err = m.revertJoin(game)
+	} else {
+		ok, err = m.cancelGame(game, models.GameStatusReady)
+	}
+	m.mutex.Unlock()
+
+	if err != nil {
+		log.Printf("❌ 对局 %s 准备超时处理失败: %v", gameID, err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	if reverted {
+		log.Printf("⌛ 对局 %s 加入者 %d 未确认准备，已撤销加入并退款", gameID, state.Player2ID)
+	} else {
+		log.Printf("⌛ 对局 %s 发起者 %d 未确认准备，已取消并退款", gameID, state.Player1ID)
+	}
+	if m.onReadyExpired != nil {
+		m.onReadyExpired(state, reverted)
+	}
+	// 等待准备的对局占用的名额已释放
+	go m.startQueued(game.ChatID)
+}
+
+// revertJoin 撤销加入者的加入并退款，对局恢复等待并重新计算等待超时，调用方需持有 m.mutex
+func (m *Manager) revertJoin(game *models.Game) (bool, error) {
+	player2ID := *game.Player2ID
+	tx := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      player2ID,
+		GameID:      &game.ID,
+		Type:        models.TransactionTypeRefund,
+		Amount:      game.BetAmount,
+		Description: fmt.Sprintf("未确认准备，退还对局 %s 下注", game.ID),
+	}
+	ok, err := m.db.RevertJoinWithRefund(game.ID, player2ID, tx)
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	m.recordGameEvent(&models.GameEvent{
+		GameID: game.ID,
+		Type:   models.GameEventReady,
+		UserID: player2ID,
+		ChatID: game.ChatID,
+		Detail: "加入者未在限定时间内确认准备，撤销加入并退款",
+	})
+	m.setGameTimeout(game.ID, m.chatGameTimeout(game.ChatID))
+	return true, nil
+}
+
+// sweepStaleReady 处理计时器之外遗漏的准备超时对局（如进程重启前加入的对局）
+func (m *Manager) sweepStaleReady() {
+	m.mutex.RLock()
+	window := m.readyWindow
+	m.mutex.RUnlock()
+
+	gameIDs, err := m.db.GetStaleReadyGameIDs(time.Now().Add(-2 * window))
+	if err != nil {
+		log.Printf("⚠️ 检查准备超时的对局失败: %v", err)
+		return
+	}
+	for _, gameID := range gameIDs {
+		m.mutex.RLock()
+		_, tracked := m.readyChecks[gameID]
+		m.mutex.RUnlock()
+		if !tracked {
+			m.expireReady(gameID)
+		}
+	}
+}
+
+// state 准备确认的进度快照，调用方需持有 m.mutex
+func (c *readyCheck) state(gameID string) *ReadyState {
+	return &ReadyState{
+		GameID:       gameID,
+		ChatID:       c.chatID,
+		BetAmount:    c.betAmount,
+		Player1ID:    c.player1ID,
+		Player2ID:    c.player2ID,
+		Player1Ready: c.ready1,
+		Player2Ready: c.ready2,
+		Deadline:     c.deadline,
+	}
+}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// RematchWindow 再来一局邀请等待对手接受的时长，超时后按普通对局超时退款
+const RematchWindow = 30 * time.Second
+
+// ErrRematchPending 对手已发起再来一局，等待本人接受
+var ErrRematchPending = errors.New("对手已发起再来一局，点击邀请中的接受按钮即可开始")
+
+// rematch 再来一局邀请：由上一局的一方发起，只有另一方可以加入
+type rematch struct {
+	sourceID    string // 上一局的对局ID
+	initiatorID int64
+	opponentID  int64
+}
+
+// Rematch 再来一局邀请的结果
+type Rematch struct {
+	GameID      string
+	ChatID      int64
+	InitiatorID int64
+	OpponentID  int64
+	BetAmount   int64
+	ExpiresAt   time.Time
+}
+
+// CreateRematch 上一局的任一方以相同下注额向同一对手发起新对局，只有对手能在 RematchWindow 内加入。
+// 对手加入时与普通加入一样按群组对局模式排队，不会与其他进行中的对局冲突
+func (m *Manager) CreateRematch(sourceGameID string, playerID int64) (*Rematch, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	source, err := m.db.GetGame(sourceGameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+	if source == nil || source.Player2ID == nil {
+		return nil, fmt.Errorf("游戏不存在")
+	}
+	if source.Status != models.GameStatusFinished && source.Status != models.GameStatusSurrendered {
+		return nil, fmt.Errorf("对局尚未结束")
+	}
+
+	var opponentID int64
+	switch playerID {
+	case source.Player1ID:
+		opponentID = *source.Player2ID
+	case *source.Player2ID:
+		opponentID = source.Player1ID
+	default:
+		return nil, fmt.Errorf("只有上一局的双方可以再来一局")
+	}
+
+	// 同一局只保留一个等待中的邀请
+	for _, pending := range m.rematches {
+		if pending.sourceID != sourceGameID {
+			continue
+		}
+		if pending.initiatorID == playerID {
+			return nil, fmt.Errorf("已发起再来一局，请等待对手接受")
+		}
+		return nil, ErrRematchPending
+	}
+
+	gameID, err := m.createGame(playerID, source.ChatID, source.BetAmount, RematchWindow)
+	if err != nil {
+		return nil, err
+	}
+	m.rematches[gameID] = &rematch{sourceID: sourceGameID, initiatorID: playerID, opponentID: opponentID}
+	log.Printf("🔄 玩家 %d 向 %d 发起再来一局 %s（上一局 %s）", playerID, opponentID, gameID, sourceGameID)
+
+	return &Rematch{
+		GameID:      gameID,
+		ChatID:      source.ChatID,
+		InitiatorID: playerID,
+		OpponentID:  opponentID,
+		BetAmount:   source.BetAmount,
+		ExpiresAt:   time.Now().Add(RematchWindow),
+	}, nil
+}
+
+// checkRematchJoin 再来一局的对局只允许上一局的对手加入，调用方需持有 m.mutex
+func (m *Manager) checkRematchJoin(gameID string, playerID int64) error {
+	if pending, ok := m.rematches[gameID]; ok && pending.opponentID != playerID {
+		return fmt.Errorf("这是再来一局邀请，只有上一局的对手可以加入")
+	}
+	return nil
+}
+
+// clearRematch 对局被加入或超时后移除邀请，调用方需持有 m.mutex
+func (m *Manager) clearRematch(gameID string) {
+	delete(m.rematches, gameID)
+}
@@ -0,0 +1,61 @@
+package game
+
+import (
+	"fmt"
+	"log"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// CalculateChatShare 按群组分成比例计算手续费中归入群组基金的金额
+func CalculateChatShare(commission int64, share float64) int64 {
+	if commission <= 0 || share <= 0 {
+		return 0
+	}
+	if share > 1 {
+		share = 1
+	}
+	return int64(float64(commission) * share)
+}
+
+// commissionTransactions 构建手续费交易记录，配置了群组分成时拆分为平台和群组两部分
+func (m *Manager) commissionTransactions(game *models.Game, commission int64) ([]*models.Transaction, int64) {
+	return m.splitCommission(game.ChatID, &game.ID, fmt.Sprintf("游戏 %s", game.ID), commission)
+}
+
+// splitCommission 按群组分成比例拆分手续费，gameID 为空表示不是 1v1 对局（如快速桌）
+func (m *Manager) splitCommission(chatID int64, gameID *string, label string, commission int64) ([]*models.Transaction, int64) {
+	share, err := m.db.GetChatRevenueShare(chatID)
+	if err != nil {
+		// 读取失败时不分成，手续费全部归平台
+		log.Printf("⚠️ 获取群组 %d 分成比例失败: %v", chatID, err)
+		share = 0
+	}
+	chatShare := CalculateChatShare(commission, share)
+
+	transactions := []*models.Transaction{
+		{
+			ID:          utils.GenerateTransactionID(),
+			UserID:      0, // 系统账户
+			GameID:      gameID,
+			Type:        models.TransactionTypeCommission,
+			Amount:      commission - chatShare,
+			Balance:     0,
+			Description: fmt.Sprintf("%s 手续费", label),
+		},
+	}
+
+	if chatShare > 0 {
+		transactions = append(transactions, &models.Transaction{
+			ID:          utils.GenerateTransactionID(),
+			UserID:      0, // 系统账户
+			GameID:      gameID,
+			Type:        models.TransactionTypeRevenueShare,
+			Amount:      chatShare,
+			Balance:     0,
+			Description: fmt.Sprintf("%s 手续费分成至群组 %d", label, chatID),
+		})
+	}
+	return transactions, chatShare
+}
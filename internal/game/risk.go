@@ -0,0 +1,25 @@
+package game
+
+import (
+	"log"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/risk"
+)
+
+// SetRiskEngine 设置风控引擎，分出胜负的对局结算后检查双方近期的对局
+func (m *Manager) SetRiskEngine(engine *risk.Engine) {
+	m.risk = engine
+}
+
+// checkRisk 结算完成后异步执行风控检查，不阻塞结算
+func (m *Manager) checkRisk(game *models.Game, winnerID, loserID int64) {
+	if !m.risk.Enabled() {
+		return
+	}
+	go func() {
+		if _, err := m.risk.CheckSettlement(game, winnerID, loserID); err != nil {
+			log.Printf("⚠️ 对局 %s 风控检查失败: %v", game.ID, err)
+		}
+	}()
+}
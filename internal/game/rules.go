@@ -0,0 +1,8 @@
+package game
+
+import "telegram-dice-bot/internal/rules"
+
+// SetRules 设置启用的玩法插件，自定义胜负判定和派奖，nil 表示按默认规则结算
+func (m *Manager) SetRules(set *rules.Set) {
+	m.gameRules = set
+}
@@ -0,0 +1,371 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// ErrTooManyGames 并行模式下本群同时进行的对局已达上限
+var ErrTooManyGames = errors.New("本群同时进行的对局已达上限，请稍后再试")
+
+// errQueueBlocked 处理排队请求时仍有对局在进行，需继续等待
+var errQueueBlocked = errors.New("仍有对局在进行")
+
+// JoinQueuedError 顺序模式下本群已有对局在进行，加入请求已排队，轮到时通过回调开局
+type JoinQueuedError struct {
+	Position int // 在本群队列中的位置，从 1 开始
+}
+
+func (e *JoinQueuedError) Error() string {
+	return fmt.Sprintf("本群当前有对局在进行，已排队等待开局（第 %d 位）", e.Position)
+}
+
+// AlreadyQueuedError 玩家在本群已有排队中的加入请求，每人每群只能排队一个，可改为排队加入新的对局
+type AlreadyQueuedError struct {
+	GameID    string // 已排队加入的对局
+	Position  int    // 已排队请求在本群队列中的位置，从 1 开始
+	NewGameID string // 本次想要加入的对局
+}
+
+func (e *AlreadyQueuedError) Error() string {
+	return fmt.Sprintf("你已在本群排队等待开局（第 %d 位），每人同时只能排队一个加入请求", e.Position)
+}
+
+// queuedJoin 排队等待开局的加入请求
+type queuedJoin struct {
+	gameID   string
+	playerID int64
+	queuedAt time.Time
+}
+
+// SetQueuedJoinCallback 设置排队的加入请求开局（或开局失败）时的回调
+func (m *Manager) SetQueuedJoinCallback(callback func(chatID, playerID int64, result *GameResult, err error)) {
+	m.onQueuedJoin = callback
+}
+
+// SetQueueRemovedCallback 设置管理员移除排队请求后的回调，用于通知被移出的玩家
+func (m *Manager) SetQueueRemovedCallback(callback func(entry *models.QueueEntry)) {
+	m.onQueueRemoved = callback
+}
+
+// SetChatGameMode 设置群组对局模式：sequential 为顺序进行，否则并行且最多 maxActive 局（0 不限）
+func (m *Manager) SetChatGameMode(chatID int64, sequential bool, maxActive int) error {
+	if maxActive < 0 {
+		return fmt.Errorf("对局上限不能为负数")
+	}
+	if err := m.db.SetChatGameMode(chatID, sequential, maxActive); err != nil {
+		return fmt.Errorf("更新群组对局模式失败: %v", err)
+	}
+	// 切换为并行模式后，已排队的请求不必再等待
+	if !sequential {
+		go m.startQueued(chatID)
+	}
+	return nil
+}
+
+// QueueLength 群组中排队等待开局的加入请求数
+func (m *Manager) QueueLength(chatID int64) int {
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+	return len(m.queues[chatID])
+}
+
+// ChatQueue 群组中排队等待开局的加入请求，按排队顺序排列
+func (m *Manager) ChatQueue(chatID int64) ([]*models.QueueEntry, error) {
+	m.queueMu.Lock()
+	queue := append([]queuedJoin(nil), m.queues[chatID]...)
+	m.queueMu.Unlock()
+
+	entries := make([]*models.QueueEntry, 0, len(queue))
+	for i, join := range queue {
+		entry, err := m.queueEntry(chatID, i+1, join)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// RemoveQueuedJoin 管理员移除排队中的加入请求，恢复对局的超时并通知玩家，随后继续处理队列
+func (m *Manager) RemoveQueuedJoin(chatID int64, gameID string) (*models.QueueEntry, error) {
+	m.mutex.Lock()
+	m.queueMu.Lock()
+	queue := m.queues[chatID]
+	index := -1
+	for i, join := range queue {
+		if join.gameID == gameID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		m.queueMu.Unlock()
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("队列中没有该对局的加入请求")
+	}
+	removed := queue[index]
+	m.queues[chatID] = append(queue[:index:index], queue[index+1:]...)
+	if len(m.queues[chatID]) == 0 {
+		delete(m.queues, chatID)
+	}
+	m.queueMu.Unlock()
+
+	m.releaseQueuedGame(removed.gameID)
+	m.mutex.Unlock()
+
+	entry, err := m.queueEntry(chatID, index+1, removed)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🧹 已移除群组 %d 排队中的加入请求：玩家 %d，对局 %s", chatID, removed.playerID, removed.gameID)
+	m.notifyQueueRemoved(entry)
+	// 移除卡住的队首后，后面的请求可能已经可以开局
+	go m.startQueued(chatID)
+	return entry, nil
+}
+
+// ReplaceQueuedJoin 将玩家在本群排队中的加入请求改为加入另一局，保留原来的排队位置，
+// 原对局恢复超时，新对局暂停超时以保留给该玩家
+func (m *Manager) ReplaceQueuedJoin(chatID, playerID int64, gameID string) (*models.QueueEntry, error) {
+	m.mutex.Lock()
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil {
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+	switch {
+	case game == nil || game.ChatID != chatID:
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("游戏不存在")
+	case game.Status != models.GameStatusWaiting:
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("游戏已开始或已结束")
+	case game.Player1ID == playerID:
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("不能加入自己创建的游戏")
+	}
+
+	m.queueMu.Lock()
+	queue := m.queues[chatID]
+	index := -1
+	for i, join := range queue {
+		if join.playerID == playerID {
+			index = i
+		} else if join.gameID == gameID {
+			m.queueMu.Unlock()
+			m.mutex.Unlock()
+			return nil, fmt.Errorf("该对局已有玩家排队加入")
+		}
+	}
+	if index < 0 {
+		m.queueMu.Unlock()
+		m.mutex.Unlock()
+		return nil, fmt.Errorf("你在本群没有排队中的加入请求")
+	}
+	previous := queue[index]
+	replaced := queuedJoin{gameID: gameID, playerID: playerID, queuedAt: previous.queuedAt}
+	queue[index] = replaced
+	m.queueMu.Unlock()
+
+	if previous.gameID != gameID {
+		m.cancelGameTimeout(gameID)
+		m.releaseQueuedGame(previous.gameID)
+		log.Printf("🔁 玩家 %d 将排队请求从对局 %s 改为 %s（群组 %d 第 %d 位）", playerID, previous.gameID, gameID, chatID, index+1)
+	}
+	m.mutex.Unlock()
+
+	return m.queueEntry(chatID, index+1, replaced)
+}
+
+// ClearQueue 管理员清空群组的排队请求，恢复各对局的超时并通知全部玩家
+func (m *Manager) ClearQueue(chatID int64) ([]*models.QueueEntry, error) {
+	m.mutex.Lock()
+	m.queueMu.Lock()
+	queue := m.queues[chatID]
+	delete(m.queues, chatID)
+	m.queueMu.Unlock()
+
+	for _, join := range queue {
+		m.releaseQueuedGame(join.gameID)
+	}
+	m.mutex.Unlock()
+
+	entries := make([]*models.QueueEntry, 0, len(queue))
+	for i, join := range queue {
+		entry, err := m.queueEntry(chatID, i+1, join)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+		m.notifyQueueRemoved(entry)
+	}
+	if len(queue) > 0 {
+		log.Printf("🧹 已清空群组 %d 的排队请求（%d 个）", chatID, len(queue))
+	}
+	return entries, nil
+}
+
+// queueEntry 补充对局下注额和玩家信息
+func (m *Manager) queueEntry(chatID int64, position int, join queuedJoin) (*models.QueueEntry, error) {
+	entry := &models.QueueEntry{
+		Position: position,
+		ChatID:   chatID,
+		GameID:   join.gameID,
+		PlayerID: join.playerID,
+		QueuedAt: join.queuedAt,
+	}
+	game, err := m.db.GetGame(join.gameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取对局失败: %v", err)
+	}
+	if game != nil {
+		entry.BetAmount = game.BetAmount
+	}
+	user, err := m.db.GetUser(join.playerID)
+	if err != nil {
+		return nil, fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	if user != nil {
+		entry.Username, entry.FirstName = user.Username, user.FirstName
+	}
+	return entry, nil
+}
+
+// releaseQueuedGame 对局不再为排队的玩家保留，仍在等待时恢复超时，调用方需持有 m.mutex
+func (m *Manager) releaseQueuedGame(gameID string) {
+	if game, err := m.db.GetGame(gameID); err == nil && game != nil && game.Status == models.GameStatusWaiting {
+		m.setGameTimeout(gameID, m.chatGameTimeout(game.ChatID))
+	}
+}
+
+// notifyQueueRemoved 通知被管理员移出队列的玩家
+func (m *Manager) notifyQueueRemoved(entry *models.QueueEntry) {
+	if m.onQueueRemoved != nil {
+		m.onQueueRemoved(entry)
+	}
+}
+
+// admitJoin 按群组对局模式和冷却检查能否立即开局：顺序模式下有对局在进行（或已有人排队）时排队，
+// 并行模式下超出上限时拒绝，可以开局但仍在冷却中时拒绝（排队的请求继续等待）
+func (m *Manager) admitJoin(game *models.Game, playerID int64, queued bool) error {
+	sequential, maxActive, err := m.db.GetChatGameMode(game.ChatID)
+	if err != nil {
+		return fmt.Errorf("获取群组对局模式失败: %v", err)
+	}
+	if sequential {
+		maxActive = 1
+	}
+
+	// 顺序模式下新的加入请求不能插队
+	if sequential && !queued && m.QueueLength(game.ChatID) > 0 {
+		return m.enqueueJoin(game, playerID)
+	}
+
+	if maxActive > 0 {
+		playing, err := m.db.CountPlayingGames(game.ChatID)
+		if err != nil {
+			return fmt.Errorf("获取进行中的对局失败: %v", err)
+		}
+		if playing >= maxActive {
+			switch {
+			case queued:
+				return errQueueBlocked
+			case sequential:
+				return m.enqueueJoin(game, playerID)
+			default:
+				return ErrTooManyGames
+			}
+		}
+	}
+
+	return m.checkCooldown(game.ChatID, playerID)
+}
+
+// enqueueJoin 将加入请求排队，并暂停对局的超时以保留给排队的玩家
+func (m *Manager) enqueueJoin(game *models.Game, playerID int64) error {
+	m.queueMu.Lock()
+	defer m.queueMu.Unlock()
+
+	queue := m.queues[game.ChatID]
+	for i, join := range queue {
+		if join.playerID != playerID {
+			continue
+		}
+		if join.gameID == game.ID {
+			return fmt.Errorf("你已在排队等待加入该对局（第 %d 位）", i+1)
+		}
+		return &AlreadyQueuedError{GameID: join.gameID, Position: i + 1, NewGameID: game.ID}
+	}
+	for _, join := range queue {
+		if join.gameID == game.ID {
+			return fmt.Errorf("该对局已有玩家排队加入")
+		}
+	}
+
+	m.queues[game.ChatID] = append(queue, queuedJoin{gameID: game.ID, playerID: playerID, queuedAt: time.Now()})
+	m.cancelGameTimeout(game.ID)
+	log.Printf("⏳ 玩家 %d 排队加入对局 %s（群组 %d 第 %d 位）", playerID, game.ID, game.ChatID, len(queue)+1)
+	return &JoinQueuedError{Position: len(queue) + 1}
+}
+
+// startQueued 依次为本群排队的加入请求开局，直到队列为空或对局数达到上限，冷却中时等冷却结束再处理
+func (m *Manager) startQueued(chatID int64) {
+	type outcome struct {
+		playerID int64
+		result   *GameResult
+		err      error
+	}
+
+	// 回调可能再次调用管理器，需在释放锁之后执行
+	var outcomes []outcome
+	defer func() {
+		if m.onQueuedJoin == nil {
+			return
+		}
+		for _, o := range outcomes {
+			m.onQueuedJoin(chatID, o.playerID, o.result, o.err)
+		}
+	}()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for {
+		m.queueMu.Lock()
+		queue := m.queues[chatID]
+		if len(queue) == 0 {
+			delete(m.queues, chatID)
+			m.queueMu.Unlock()
+			return
+		}
+		next := queue[0]
+		m.queueMu.Unlock()
+
+		result, err := m.joinGame(next.gameID, next.playerID, true)
+		if errors.Is(err, errQueueBlocked) {
+			return
+		}
+		var cooling *CooldownError
+		if errors.As(err, &cooling) {
+			m.scheduleQueued(chatID, cooling.Remaining)
+			return
+		}
+
+		m.queueMu.Lock()
+		m.queues[chatID] = m.queues[chatID][1:]
+		m.queueMu.Unlock()
+
+		if err != nil {
+			log.Printf("⚠️ 排队玩家 %d 加入对局 %s 失败: %v", next.playerID, next.gameID, err)
+			// 对局仍在等待时恢复超时，避免一直保留
+			m.releaseQueuedGame(next.gameID)
+		}
+		outcomes = append(outcomes, outcome{playerID: next.playerID, result: result, err: err})
+	}
+}
@@ -0,0 +1,175 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+)
+
+// MaxSettlementHold 单次暂停自动结算的最长时间，到期后自动恢复
+const MaxSettlementHold = 24 * time.Hour
+
+// HoldSettlements 在疑似漏洞被利用时暂停自动结算：对局照常开骰，派奖等待管理员逐一审核，
+// duration 后自动恢复
+func (m *Manager) HoldSettlements(duration time.Duration) (time.Time, error) {
+	if duration <= 0 || duration > MaxSettlementHold {
+		return time.Time{}, fmt.Errorf("暂停时长必须在 0 到 %v 之间", MaxSettlementHold)
+	}
+
+	until := time.Now().Add(duration)
+	if err := m.db.SetSetting(database.SettlementHoldKey, until.Format(time.RFC3339Nano)); err != nil {
+		return time.Time{}, fmt.Errorf("暂停自动结算失败: %v", err)
+	}
+	log.Printf("⏸️ 自动结算已暂停，将于 %s 自动恢复", until.Format("2006-01-02 15:04:05"))
+	return until, nil
+}
+
+// ResumeSettlements 立即恢复自动结算，已暂停的对局仍需管理员审核
+func (m *Manager) ResumeSettlements() error {
+	if err := m.db.DeleteSetting(database.SettlementHoldKey); err != nil {
+		return fmt.Errorf("恢复自动结算失败: %v", err)
+	}
+	log.Printf("▶️ 自动结算已恢复")
+	return nil
+}
+
+// SettlementHoldUntil 自动结算暂停的截止时间，未暂停或已到期时返回 ok=false
+func (m *Manager) SettlementHoldUntil() (time.Time, bool, error) {
+	value, ok, err := m.db.GetSetting(database.SettlementHoldKey)
+	if err != nil || !ok {
+		return time.Time{}, false, err
+	}
+
+	until, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("解析结算暂停时间失败: %v", err)
+	}
+	if !time.Now().Before(until) {
+		// 到期自动恢复，清除配置项避免重复判断
+		if err := m.db.DeleteSetting(database.SettlementHoldKey); err != nil {
+			log.Printf("⚠️ 清除到期的结算暂停失败: %v", err)
+		} else {
+			log.Printf("▶️ 结算暂停已到期，自动恢复结算")
+		}
+		return time.Time{}, false, nil
+	}
+	return until, true, nil
+}
+
+// SettlementsHeld 自动结算是否处于暂停状态
+func (m *Manager) SettlementsHeld() (bool, error) {
+	_, held, err := m.SettlementHoldUntil()
+	return held, err
+}
+
+// HeldSettlements 等待审核的对局
+func (m *Manager) HeldSettlements(limit int) ([]*models.Game, error) {
+	return m.db.GetHeldGames(limit)
+}
+
+// holdSettlement 保存骰子结果并暂停派奖，返回不含胜负的结果供群内展示
+func (m *Manager) holdSettlement(game *models.Game, p1d1, p1d2, p1d3, p2d1, p2d2, p2d3 int) (*GameResult, error) {
+	if err := m.db.HoldGameSettlement(game.ID, p1d1, p1d2, p1d3, p2d1, p2d2, p2d3); err != nil {
+		return nil, err
+	}
+	log.Printf("⏸️ 对局 %s 已开骰，结算暂停等待审核", game.ID)
+	m.recordGameEvent(&models.GameEvent{
+		GameID: game.ID,
+		Type:   models.GameEventHeld,
+		ChatID: game.ChatID,
+		Detail: "结算暂停等待审核；" + diceDetail(p1d1, p1d2, p1d3, p2d1, p2d2, p2d3),
+	})
+	// 开骰已完成，不再需要超时中止和关闭前退款；对局结束的通知在审核结算或退款时发出
+	m.disarmWatchdog(game.ID)
+	m.untrackOwned(game.ID)
+
+	game.Status = models.GameStatusHeld
+	game.Player1Dice1, game.Player1Dice2, game.Player1Dice3 = &p1d1, &p1d2, &p1d3
+	game.Player2Dice1, game.Player2Dice2, game.Player2Dice3 = &p2d1, &p2d2, &p2d3
+
+	result, err := m.buildGameResult(game, false)
+	if err != nil {
+		return nil, err
+	}
+	result.Held = true
+	return result, nil
+}
+
+// ApproveHeldSettlement 审核通过，按保存的骰子结果正常结算
+func (m *Manager) ApproveHeldSettlement(gameID string) (*GameResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	game, err := m.heldGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if game.Player1Dice1 == nil || game.Player1Dice2 == nil || game.Player1Dice3 == nil ||
+		game.Player2Dice1 == nil || game.Player2Dice2 == nil || game.Player2Dice3 == nil {
+		return nil, fmt.Errorf("对局 %s 缺少骰子结果，只能退款", gameID)
+	}
+
+	// 恢复为进行中再走正常结算流程，持有 m.mutex 期间不会被认输抢先
+	ok, err := m.db.TransitionGameStatus(gameID, models.GameStatusHeld, models.GameStatusPlaying)
+	if err != nil {
+		return nil, fmt.Errorf("更新对局状态失败: %v", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("对局 %s 已被处理", gameID)
+	}
+	game.Status = models.GameStatusPlaying
+
+	result, err := m.settleGame(game, *game.Player1Dice1, *game.Player1Dice2, *game.Player1Dice3,
+		*game.Player2Dice1, *game.Player2Dice2, *game.Player2Dice3)
+	if err != nil {
+		if _, revertErr := m.db.TransitionGameStatus(gameID, models.GameStatusPlaying, models.GameStatusHeld); revertErr != nil {
+			log.Printf("❌ 对局 %s 结算失败且无法恢复暂停状态: %v", gameID, revertErr)
+		}
+		return nil, fmt.Errorf("结算对局失败: %v", err)
+	}
+	log.Printf("✅ 暂停的对局 %s 已审核结算", gameID)
+	return result, nil
+}
+
+// RefundHeldSettlement 审核拒绝，取消对局并向双方退还下注
+func (m *Manager) RefundHeldSettlement(gameID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	game, err := m.heldGame(gameID)
+	if err != nil {
+		return err
+	}
+
+	// 取消和退款在同一事务中完成，避免重复退款
+	ok, err := m.cancelGame(game, models.GameStatusHeld)
+	if err != nil {
+		return fmt.Errorf("退款失败: %v", err)
+	}
+	if !ok {
+		return fmt.Errorf("对局 %s 已被处理", gameID)
+	}
+	game.Status = models.GameStatusCancelled
+	m.metrics.gameRefunded(gameID)
+	m.notifyGameFinished(game)
+	log.Printf("↩️ 暂停的对局 %s 已退款", gameID)
+	return nil
+}
+
+// heldGame 获取等待审核的对局
+func (m *Manager) heldGame(gameID string) (*models.Game, error) {
+	game, err := m.db.GetGame(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+	if game == nil {
+		return nil, fmt.Errorf("游戏不存在")
+	}
+	if game.Status != models.GameStatusHeld {
+		return nil, fmt.Errorf("对局 %s 不在待审核状态", gameID)
+	}
+	return game, nil
+}
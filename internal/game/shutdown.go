@@ -0,0 +1,120 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// ErrShuttingDown 服务正在关闭，不再接受新的对局
+var ErrShuttingDown = errors.New("机器人正在维护重启，请稍后再发起或加入对局")
+
+// drainPollInterval 关闭服务时检查进行中对局的间隔
+const drainPollInterval = 200 * time.Millisecond
+
+// Draining 服务是否正在关闭，关闭期间不再接受新的对局
+func (m *Manager) Draining() bool {
+	return m.draining.Load()
+}
+
+// checkDraining 关闭期间拒绝发起和加入对局；排队的加入请求继续保留，重启后由玩家重新加入
+func (m *Manager) checkDraining(queued bool) error {
+	if !m.draining.Load() {
+		return nil
+	}
+	if queued {
+		return errQueueBlocked
+	}
+	return ErrShuttingDown
+}
+
+// Drain 关闭服务前调用：停止接受新的对局，等待进行中（等待准备或已开骰）的对局在 timeout 内结束，
+// 超时仍未结束的对局取消并向双方退款，退款通知发送完毕后返回。
+// 等待对手的对局和结算待审核的对局保留到重启后处理。返回等待期间结束的对局数和退款的对局数
+func (m *Manager) Drain(timeout time.Duration) (settled, refunded int, err error) {
+	m.draining.Store(true)
+
+	inFlight, err := m.inFlightGames()
+	if err != nil {
+		return 0, 0, err
+	}
+	total := len(inFlight)
+	if total > 0 {
+		log.Printf("⏳ 等待 %d 局进行中的对局结算（最长 %s）", total, timeout)
+	}
+
+	deadline := time.Now().Add(timeout)
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+	for len(inFlight) > 0 && time.Now().Before(deadline) {
+		<-ticker.C
+		if inFlight, err = m.inFlightGames(); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	for _, game := range inFlight {
+		aborted, err := m.abortGame(game.ID)
+		if err != nil {
+			// 可能恰好在超时后结算完成
+			log.Printf("⚠️ 关闭前中止对局 %s 失败: %v", game.ID, err)
+			continue
+		}
+		refunded++
+		log.Printf("↩️ 对局 %s 未能在关闭前结算，已向双方退款", game.ID)
+		// 进程即将退出，通知发送完毕后再返回
+		if m.onGameAborted != nil {
+			m.onGameAborted(aborted, models.GameAbortShutdown)
+		}
+	}
+
+	// 关闭期间不会有新的对局开局，未退款的都已正常结束
+	settled = total - refunded
+	if settled < 0 {
+		settled = 0
+	}
+	return settled, refunded, nil
+}
+
+// inFlightGames 本进程开局、尚未结束的对局：等待准备和已开骰的对局。
+// 其他进程（如其他分片）的对局由各自的进程处理，不在本进程关闭时等待或退款
+func (m *Manager) inFlightGames() ([]*models.Game, error) {
+	m.ownedMu.Lock()
+	ids := make([]string, 0, len(m.owned))
+	for id := range m.owned {
+		ids = append(ids, id)
+	}
+	m.ownedMu.Unlock()
+
+	var inFlight []*models.Game
+	for _, id := range ids {
+		game, err := m.db.GetGame(id)
+		if err != nil {
+			return nil, fmt.Errorf("获取进行中的对局失败: %v", err)
+		}
+		if game != nil && (game.Status == models.GameStatusReady || game.Status == models.GameStatusPlaying) {
+			inFlight = append(inFlight, game)
+			continue
+		}
+		// 已结束或准备超时退回等待对手
+		m.untrackOwned(id)
+	}
+	return inFlight, nil
+}
+
+// trackOwned 记录本进程开局的对局
+func (m *Manager) trackOwned(gameID string) {
+	m.ownedMu.Lock()
+	defer m.ownedMu.Unlock()
+	m.owned[gameID] = struct{}{}
+}
+
+// untrackOwned 对局结束后不再由本进程跟踪
+func (m *Manager) untrackOwned(gameID string) {
+	m.ownedMu.Lock()
+	defer m.ownedMu.Unlock()
+	delete(m.owned, gameID)
+}
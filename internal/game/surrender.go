@@ -0,0 +1,136 @@
+package game
+
+import (
+	"fmt"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// SurrenderResult 认输结算结果
+type SurrenderResult struct {
+	GameID     string
+	Winner     *models.User
+	Loser      *models.User
+	BetAmount  int64
+	Refund     int64 // 认输方退回的金额
+	WinAmount  int64 // 获胜方实际到账金额（含本金）
+	Commission int64
+	ChatShare  int64 // 手续费中归入群组基金的部分
+}
+
+// CalculateSurrender 计算认输后的退款、获胜方到账和抽水
+// 获胜方收回本金加上认输方被没收的部分，抽水按获胜方所得奖池计算
+func CalculateSurrender(betAmount int64, refundRate, feeRate float64) (refund, winAmount, commission int64) {
+	if refundRate < 0 {
+		refundRate = 0
+	}
+	if refundRate > 1 {
+		refundRate = 1
+	}
+
+	refund = int64(float64(betAmount) * refundRate)
+	pot := betAmount*2 - refund
+	commission = utils.CalculateCommission(pot, feeRate)
+	winAmount = pot - commission
+	return refund, winAmount, commission
+}
+
+// Surrender 玩家在开骰动画结束前认输，按配置比例退还部分下注
+func (m *Manager) Surrender(gameID string, playerID int64) (*SurrenderResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil {
+		return nil, fmt.Errorf("获取游戏信息失败: %v", err)
+	}
+
+	if game == nil {
+		return nil, fmt.Errorf("游戏不存在")
+	}
+
+	if game.Status != models.GameStatusPlaying || game.Player2ID == nil {
+		return nil, fmt.Errorf("只能在对局进行中认输")
+	}
+
+	enabled, err := m.db.IsSurrenderEnabled(game.ChatID)
+	if err != nil {
+		return nil, fmt.Errorf("获取群组设置失败: %v", err)
+	}
+	if !enabled {
+		return nil, fmt.Errorf("本群未开启认输功能")
+	}
+
+	var winnerID int64
+	switch playerID {
+	case game.Player1ID:
+		winnerID = *game.Player2ID
+	case *game.Player2ID:
+		winnerID = game.Player1ID
+	default:
+		return nil, fmt.Errorf("您不是该游戏的玩家")
+	}
+
+	winner, err := m.db.GetUser(winnerID)
+	if err != nil {
+		return nil, fmt.Errorf("获取玩家信息失败: %v", err)
+	}
+
+	loser, err := m.db.GetUser(playerID)
+	if err != nil {
+		return nil, fmt.Errorf("获取玩家信息失败: %v", err)
+	}
+
+	refund, winAmount, commission := CalculateSurrender(game.BetAmount, m.config.SurrenderRefundRate, m.chatFeeRate(game.ChatID))
+
+	// 交易后的余额在结算事务中入账时回填
+	refundTx := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      playerID,
+		GameID:      &game.ID,
+		Type:        models.TransactionTypeRefund,
+		Amount:      refund,
+		Description: fmt.Sprintf("认输退款 %s", game.ID),
+	}
+	winTx := &models.Transaction{
+		ID:          utils.GenerateTransactionID(),
+		UserID:      winnerID,
+		GameID:      &game.ID,
+		Type:        models.TransactionTypeWin,
+		Amount:      winAmount,
+		Description: fmt.Sprintf("对手认输 %s", game.ID),
+	}
+	transactions := []*models.Transaction{refundTx, winTx}
+	commissionTxs, chatShare := m.commissionTransactions(game, commission)
+	transactions = append(transactions, commissionTxs...)
+
+	if err := m.db.SurrenderGameWithTransaction(game.ID, winnerID, playerID, refund, commission, transactions); err != nil {
+		return nil, fmt.Errorf("认输结算失败: %v", err)
+	}
+
+	m.metrics.gameSurrendered(game.ID)
+	m.recordGameEvent(&models.GameEvent{
+		GameID: game.ID,
+		Type:   models.GameEventSurrendered,
+		UserID: playerID,
+		ChatID: game.ChatID,
+		Detail: fmt.Sprintf("认输，退还 %s 金币，对手获得 %s 金币", utils.FormatAmount(refund), utils.FormatAmount(winAmount)),
+	})
+	m.notifyGameFinished(game)
+	m.checkRisk(game, winnerID, playerID)
+
+	winner.Balance = winTx.Balance
+	loser.Balance = refundTx.Balance
+
+	return &SurrenderResult{
+		GameID:     game.ID,
+		Winner:     winner,
+		Loser:      loser,
+		BetAmount:  game.BetAmount,
+		Refund:     refund,
+		WinAmount:  winAmount,
+		Commission: commission,
+		ChatShare:  chatShare,
+	}, nil
+}
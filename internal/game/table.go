@@ -0,0 +1,385 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+const (
+	// MinTablePlayers 快速桌开骰所需的最少人数
+	MinTablePlayers = 3
+	// MaxTablePlayers 快速桌的座位数，坐满后自动开骰
+	MaxTablePlayers = 6
+	// TableTimeout 快速桌开设后未开骰的等待时间，超时自动关闭并退还底注
+	TableTimeout = 3 * time.Minute
+	// MaxBattlePlayers 多人大战可设置的座位数上限
+	MaxBattlePlayers = 10
+)
+
+// TablePrizeShares 名次对应的奖池（扣除手续费后）分配百分比，只有前两名得奖
+var TablePrizeShares = []int64{60, 40}
+
+// TableResult 快速桌的开骰结果
+type TableResult struct {
+	Table      *models.Table
+	Players    []*models.TablePlayer // 按名次排列
+	Pot        int64                 // 全部底注
+	Commission int64
+}
+
+// SetTableExpiredCallback 设置快速桌超时关闭后的回调
+func (m *Manager) SetTableExpiredCallback(callback func(table *models.Table, players []*models.TablePlayer)) {
+	m.onTableExpired = callback
+}
+
+// CreateTable 开设快速桌，开桌者先下底注入座
+func (m *Manager) CreateTable(creatorID, chatID, ante int64) (*models.Table, error) {
+	return m.openNewTable(creatorID, chatID, ante, models.TableModeSplit, MaxTablePlayers)
+}
+
+// CreateBattle 开设多人大战：最多 maxPlayers 人各下相同底注，每人掷一次，点数最高者赢得扣除手续费后的全部奖池
+func (m *Manager) CreateBattle(creatorID, chatID, ante int64, maxPlayers int) (*models.Table, error) {
+	if maxPlayers < MinTablePlayers || maxPlayers > MaxBattlePlayers {
+		return nil, fmt.Errorf("人数上限必须在 %d 到 %d 之间", MinTablePlayers, MaxBattlePlayers)
+	}
+	return m.openNewTable(creatorID, chatID, ante, models.TableModeBattle, maxPlayers)
+}
+
+// openNewTable 按玩法开设快速桌，开桌者先下底注入座
+func (m *Manager) openNewTable(creatorID, chatID, ante int64, mode string, maxPlayers int) (*models.Table, error) {
+	if err := m.checkDraining(false); err != nil {
+		return nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	limits, err := m.ChatLimits(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if ante < limits.MinBet || ante > limits.MaxBet {
+		return nil, fmt.Errorf("底注必须在 %s 到 %s 之间", utils.FormatAmount(limits.MinBet), utils.FormatAmount(limits.MaxBet))
+	}
+	if err := m.checkExposure(chatID); err != nil {
+		return nil, err
+	}
+	if err := m.checkBanned(creatorID); err != nil {
+		return nil, err
+	}
+	if err := m.checkDailyLimit(creatorID); err != nil {
+		return nil, err
+	}
+	if err := m.validator.ValidateUserBalance(creatorID, ante); err != nil {
+		return nil, err
+	}
+
+	table := &models.Table{
+		ID:         utils.GenerateTableID(),
+		ChatID:     chatID,
+		CreatorID:  creatorID,
+		Ante:       ante,
+		Mode:       mode,
+		MaxPlayers: maxPlayers,
+	}
+	if err := m.db.CreateTableWithAnte(table); err != nil {
+		return nil, fmt.Errorf("开设快速桌失败: %v", err)
+	}
+
+	time.AfterFunc(TableTimeout, func() {
+		m.expireTable(table.ID)
+	})
+	log.Printf("🎲 玩家 %d 在群组 %d 开设快速桌 %s（%s，%d 座），底注 %d", creatorID, chatID, table.ID, mode, maxPlayers, ante)
+	return table, nil
+}
+
+// JoinTable 加入快速桌并下底注，返回入座后的玩家列表
+func (m *Manager) JoinTable(tableID string, userID int64) (*models.Table, []*models.TablePlayer, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	table, err := m.openTable(tableID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.checkBanned(userID); err != nil {
+		return nil, nil, err
+	}
+	if err := m.checkDailyLimit(userID); err != nil {
+		return nil, nil, err
+	}
+	if err := m.validator.ValidateUserBalance(userID, table.Ante); err != nil {
+		return nil, nil, err
+	}
+	if _, err := m.db.JoinTableWithAnte(tableID, userID, TableSeats(table)); err != nil {
+		return nil, nil, err
+	}
+
+	players, err := m.db.GetTablePlayers(tableID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取快速桌玩家失败: %v", err)
+	}
+	return table, players, nil
+}
+
+// StartTable 开桌者开骰；坐满时任何入座的玩家都可以开骰
+func (m *Manager) StartTable(tableID string, userID int64) (*TableResult, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	table, err := m.openTable(tableID)
+	if err != nil {
+		return nil, err
+	}
+	players, err := m.db.GetTablePlayers(tableID)
+	if err != nil {
+		return nil, fmt.Errorf("获取快速桌玩家失败: %v", err)
+	}
+	if userID != table.CreatorID && !(len(players) >= TableSeats(table) && seated(players, userID)) {
+		return nil, fmt.Errorf("只有开桌者可以开骰")
+	}
+	if len(players) < MinTablePlayers {
+		return nil, fmt.Errorf("至少需要 %d 名玩家才能开骰，当前 %d 人", MinTablePlayers, len(players))
+	}
+
+	return m.rollTable(table, players)
+}
+
+// CancelTable 开桌者在开骰前关闭快速桌，退还全部底注
+func (m *Manager) CancelTable(tableID string, userID int64) (*models.Table, []*models.TablePlayer, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	table, err := m.openTable(tableID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if userID != table.CreatorID {
+		return nil, nil, fmt.Errorf("只有开桌者可以关闭快速桌")
+	}
+	players, err := m.refundTable(table)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("↩️ 快速桌 %s 已被开桌者关闭", tableID)
+	return table, players, nil
+}
+
+// TablePlayers 获取快速桌上的玩家
+func (m *Manager) TablePlayers(tableID string) ([]*models.TablePlayer, error) {
+	return m.db.GetTablePlayers(tableID)
+}
+
+// rollTable 每名玩家掷一次三个骰子，按点数排名并派奖
+func (m *Manager) rollTable(table *models.Table, players []*models.TablePlayer) (*TableResult, error) {
+	totals := make([]int, len(players))
+	for i, player := range players {
+		d1, d2, d3, err := m.rollDice()
+		if err != nil {
+			return nil, fmt.Errorf("生成骰子失败: %v", err)
+		}
+		player.Dice1, player.Dice2, player.Dice3 = d1, d2, d3
+		totals[i] = player.Total()
+	}
+
+	pot := table.Ante * int64(len(players))
+	ranks := RankTable(totals)
+	commission := utils.CalculateCommission(pot, m.chatFeeRate(table.ChatID))
+	if TableAllTied(ranks) {
+		// 全部并列时视同平局，原额退还且不收手续费
+		commission = 0
+	}
+	var payouts []int64
+	if table.Mode == models.TableModeBattle {
+		payouts = SplitBattlePot(ranks, pot-commission)
+	} else {
+		payouts = SplitTablePot(ranks, pot-commission)
+	}
+	for i, player := range players {
+		player.Rank = ranks[i]
+		player.Payout = payouts[i]
+	}
+
+	var transactions []*models.Transaction
+	if commission > 0 {
+		transactions, _ = m.splitCommission(table.ChatID, nil, fmt.Sprintf("快速桌 %s", table.ID), commission)
+	}
+	if err := m.db.SettleTableWithTransaction(table, players, commission, transactions); err != nil {
+		return nil, fmt.Errorf("结算快速桌失败: %v", err)
+	}
+	table.Status = models.TableStatusFinished
+	table.Commission = commission
+
+	sorted := append([]*models.TablePlayer(nil), players...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Rank < sorted[j].Rank
+	})
+	log.Printf("✅ 快速桌 %s 已结算：%d 人，奖池 %d，手续费 %d", table.ID, len(players), pot, commission)
+	return &TableResult{Table: table, Players: sorted, Pot: pot, Commission: commission}, nil
+}
+
+// RankTable 按点数从高到低排名，并列的玩家名次相同（如 1、2、2、4）
+func RankTable(totals []int) []int {
+	ranks := make([]int, len(totals))
+	for i, total := range totals {
+		ranks[i] = 1
+		for _, other := range totals {
+			if other > total {
+				ranks[i]++
+			}
+		}
+	}
+	return ranks
+}
+
+// TableAllTied 全部玩家是否并列第一；ranks 按入座顺序排列，
+// 只要有一名玩家不是第一名就不算全部并列，照常收取手续费
+func TableAllTied(ranks []int) bool {
+	for _, rank := range ranks {
+		if rank != 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// SplitTablePot 按名次分配奖池：并列的玩家平分所占名次的奖金之和，
+// 除不尽的零头归先入座的玩家，奖池分配完毕不留余额
+func SplitTablePot(ranks []int, pot int64) []int64 {
+	payouts := make([]int64, len(ranks))
+	if len(ranks) == 0 || pot <= 0 {
+		return payouts
+	}
+
+	// 各名次的奖金，取整后的零头归第一名
+	prizes := make([]int64, len(TablePrizeShares))
+	var assigned int64
+	for i, share := range TablePrizeShares {
+		prizes[i] = pot * share / 100
+		assigned += prizes[i]
+	}
+	prizes[0] += pot - assigned
+
+	// 名次 r 的并列组占据第 r 到第 r+n-1 位，平分这些位置上的奖金
+	groups := make(map[int][]int)
+	for i, rank := range ranks {
+		groups[rank] = append(groups[rank], i)
+	}
+	var unclaimed int64
+	for position := len(ranks) + 1; position <= len(prizes); position++ {
+		// 人数少于得奖名次时，空缺名次的奖金归第一名
+		unclaimed += prizes[position-1]
+	}
+	for rank, members := range groups {
+		var amount int64
+		for position := rank; position < rank+len(members) && position <= len(prizes); position++ {
+			amount += prizes[position-1]
+		}
+		if rank == 1 {
+			amount += unclaimed
+		}
+		if amount == 0 {
+			continue
+		}
+		each := amount / int64(len(members))
+		for _, i := range members {
+			payouts[i] = each
+		}
+		payouts[members[0]] += amount - each*int64(len(members))
+	}
+	return payouts
+}
+
+// SplitBattlePot 多人大战由点数最高的玩家独得奖池，并列最高时平分，
+// 除不尽的零头归先入座的玩家
+func SplitBattlePot(ranks []int, pot int64) []int64 {
+	payouts := make([]int64, len(ranks))
+	if len(ranks) == 0 || pot <= 0 {
+		return payouts
+	}
+
+	var winners []int
+	for i, rank := range ranks {
+		if rank == 1 {
+			winners = append(winners, i)
+		}
+	}
+	each := pot / int64(len(winners))
+	for _, i := range winners {
+		payouts[i] = each
+	}
+	payouts[winners[0]] += pot - each*int64(len(winners))
+	return payouts
+}
+
+// TableSeats 快速桌的座位数，坐满后自动开骰；早期开设的快速桌没有记录座位数，使用默认值
+func TableSeats(table *models.Table) int {
+	if table.MaxPlayers > 0 {
+		return table.MaxPlayers
+	}
+	return MaxTablePlayers
+}
+
+// openTable 获取仍在等待开骰的快速桌
+func (m *Manager) openTable(tableID string) (*models.Table, error) {
+	table, err := m.db.GetTable(tableID)
+	if err != nil {
+		return nil, fmt.Errorf("获取快速桌失败: %v", err)
+	}
+	if table == nil {
+		return nil, fmt.Errorf("快速桌不存在")
+	}
+	if table.Status != models.TableStatusOpen {
+		return nil, fmt.Errorf("快速桌已开骰或已关闭")
+	}
+	return table, nil
+}
+
+// refundTable 关闭快速桌并退还全部底注
+func (m *Manager) refundTable(table *models.Table) ([]*models.TablePlayer, error) {
+	players, err := m.db.GetTablePlayers(table.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取快速桌玩家失败: %v", err)
+	}
+	if err := m.db.CancelTableWithRefund(table, players); err != nil {
+		return nil, fmt.Errorf("关闭快速桌失败: %v", err)
+	}
+	table.Status = models.TableStatusCancelled
+	return players, nil
+}
+
+// expireTable 超时仍未开骰的快速桌自动关闭并退款
+func (m *Manager) expireTable(tableID string) {
+	m.mutex.Lock()
+	table, err := m.db.GetTable(tableID)
+	if err != nil || table == nil || table.Status != models.TableStatusOpen {
+		m.mutex.Unlock()
+		return
+	}
+	players, err := m.refundTable(table)
+	m.mutex.Unlock()
+	if err != nil {
+		log.Printf("❌ 快速桌 %s 超时关闭失败: %v", tableID, err)
+		return
+	}
+
+	log.Printf("⏰ 快速桌 %s 超时未开骰，已退还 %d 名玩家的底注", tableID, len(players))
+	if m.onTableExpired != nil {
+		m.onTableExpired(table, players)
+	}
+}
+
+// seated 玩家是否已在桌上
+func seated(players []*models.TablePlayer, userID int64) bool {
+	for _, player := range players {
+		if player.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
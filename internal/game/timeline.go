@@ -0,0 +1,58 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// RecordDiceRoll 记录为对局发送的骰子消息及点数，sentAt 为消息的发送时间
+func (m *Manager) RecordDiceRoll(gameID string, chatID, playerID int64, messageID, value int, sentAt time.Time) {
+	m.recordGameEvent(&models.GameEvent{
+		GameID:    gameID,
+		Type:      models.GameEventRoll,
+		UserID:    playerID,
+		ChatID:    chatID,
+		MessageID: messageID,
+		Detail:    fmt.Sprintf("%d 点", value),
+		CreatedAt: sentAt,
+	})
+}
+
+// RecordGameMessage 记录为对局发送的消息（开局、结算公告等）
+func (m *Manager) RecordGameMessage(gameID string, chatID int64, messageID int, text string) {
+	m.recordGameEvent(&models.GameEvent{
+		GameID:    gameID,
+		Type:      models.GameEventMessage,
+		ChatID:    chatID,
+		MessageID: messageID,
+		Detail:    text,
+	})
+}
+
+// GameTimeline 获取对局的完整时间线，用于处理玩家争议
+func (m *Manager) GameTimeline(gameID string) (*models.GameTimeline, error) {
+	timeline, err := m.db.GetGameTimeline(gameID)
+	if err != nil {
+		return nil, err
+	}
+	if timeline == nil {
+		return nil, fmt.Errorf("对局 %s 不存在", gameID)
+	}
+	return timeline, nil
+}
+
+// recordGameEvent 记录对局事件，失败只记日志不影响对局流程
+func (m *Manager) recordGameEvent(event *models.GameEvent) {
+	if err := m.db.RecordGameEvent(event); err != nil {
+		log.Printf("⚠️ 记录对局 %s 事件 %s 失败: %v", event.GameID, event.Type, err)
+	}
+}
+
+// diceDetail 双方骰子点数和总点数
+func diceDetail(p1d1, p1d2, p1d3, p2d1, p2d2, p2d3 int) string {
+	return fmt.Sprintf("玩家1 %d+%d+%d=%d，玩家2 %d+%d+%d=%d",
+		p1d1, p1d2, p1d3, p1d1+p1d2+p1d3, p2d1, p2d2, p2d3, p2d1+p2d2+p2d3)
+}
@@ -22,6 +22,9 @@ type TimeoutManager struct {
 	onGameExpired func(gameID string, chatID int64)
 	cleanupTicker *time.Ticker
 	stopCleanup   chan bool
+
+	// 每次超时定时器处理完成（无论是否退款）后调用，便于测试同步
+	onTimeoutHandled func(gameID string)
 }
 
 // GameTimer 游戏定时器信息
@@ -54,6 +57,11 @@ func (tm *TimeoutManager) SetGameExpiredCallback(callback func(gameID string, ch
 	tm.onGameExpired = callback
 }
 
+// SetTimeoutHandledCallback 设置超时定时器处理完成的回调函数
+func (tm *TimeoutManager) SetTimeoutHandledCallback(callback func(gameID string)) {
+	tm.onTimeoutHandled = callback
+}
+
 // SetGameTimeout 设置游戏超时定时器
 func (tm *TimeoutManager) SetGameTimeout(gameID string, chatID int64, duration time.Duration) {
 	tm.timerMutex.Lock()
@@ -68,6 +76,9 @@ func (tm *TimeoutManager) SetGameTimeout(gameID string, chatID int64, duration t
 	// 创建新的定时器
 	timer := time.AfterFunc(duration, func() {
 		tm.handleGameTimeout(gameID)
+		if tm.onTimeoutHandled != nil {
+			tm.onTimeoutHandled(gameID)
+		}
 	})
 
 	gameTimer := &GameTimer{
@@ -181,7 +192,7 @@ func (tm *TimeoutManager) expireGameSecure(game *models.Game) error {
 	}
 
 	// 使用事务执行超时退款
-	if err := tm.db.ExpireGameWithTransaction(game.ID, game.Player1ID, newBalance, tx); err != nil {
+	if err := tm.db.ExpireGameWithTransaction(game.ID, game.Player1ID, tx); err != nil {
 		tm.security.RollbackOperation(securityOp.ID, fmt.Sprintf("超时退款失败: %v", err))
 		return fmt.Errorf("超时退款失败: %v", err)
 	}
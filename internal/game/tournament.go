@@ -0,0 +1,418 @@
+package game
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log"
+	"math/big"
+	"sort"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+const (
+	// MinTournamentPlayers 锦标赛开赛所需的最少人数，报名截止时不足则取消并退款
+	MinTournamentPlayers = 2
+	// MaxTournamentPlayers 锦标赛可设置的人数上限
+	MaxTournamentPlayers = 64
+	// MaxTournamentSignup 锦标赛报名时长的上限
+	MaxTournamentSignup = 24 * time.Hour
+)
+
+// TournamentProgress 调度器推进一场锦标赛后的状态：取消、开赛、进行了一轮或决出冠军
+type TournamentProgress struct {
+	Tournament *models.Tournament
+	Entries    []*models.TournamentEntry // 开赛后按种子位排列
+	Matches    []*models.TournamentMatch // 本轮对阵，取消或开赛时为空
+}
+
+// OpenTournament 在群内开设锦标赛，报名持续 signup 时长，报满时提前开赛
+func (m *Manager) OpenTournament(creatorID, chatID, entryFee int64, maxPlayers int, signup time.Duration) (*models.Tournament, error) {
+	if err := m.checkDraining(false); err != nil {
+		return nil, err
+	}
+	if maxPlayers < MinTournamentPlayers || maxPlayers > MaxTournamentPlayers {
+		return nil, fmt.Errorf("人数上限必须在 %d 到 %d 之间", MinTournamentPlayers, MaxTournamentPlayers)
+	}
+	if signup <= 0 || signup > MaxTournamentSignup {
+		return nil, fmt.Errorf("报名时长必须在 1 分钟到 %d 小时之间", int(MaxTournamentSignup.Hours()))
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	limits, err := m.ChatLimits(chatID)
+	if err != nil {
+		return nil, err
+	}
+	if entryFee < limits.MinBet || entryFee > limits.MaxBet {
+		return nil, fmt.Errorf("报名费必须在 %s 到 %s 之间", utils.FormatAmount(limits.MinBet), utils.FormatAmount(limits.MaxBet))
+	}
+	active, err := m.db.GetActiveTournament(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("获取锦标赛失败: %v", err)
+	}
+	if active != nil {
+		return nil, fmt.Errorf("本群已有报名中或比赛中的锦标赛")
+	}
+
+	tournament := &models.Tournament{
+		ID:         utils.GenerateTournamentID(),
+		ChatID:     chatID,
+		CreatorID:  creatorID,
+		EntryFee:   entryFee,
+		MaxPlayers: maxPlayers,
+		StartsAt:   time.Now().Add(signup),
+	}
+	if err := m.db.CreateTournament(tournament); err != nil {
+		return nil, fmt.Errorf("开设锦标赛失败: %v", err)
+	}
+	log.Printf("🏆 管理员 %d 在群组 %d 开设锦标赛 %s（%d 人），报名费 %d", creatorID, chatID, tournament.ID, maxPlayers, entryFee)
+	return tournament, nil
+}
+
+// EnterTournament 报名本群正在报名的锦标赛并缴纳报名费，返回报名后的参赛玩家
+func (m *Manager) EnterTournament(chatID, userID int64) (*models.Tournament, []*models.TournamentEntry, error) {
+	if err := m.checkDraining(false); err != nil {
+		return nil, nil, err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tournament, err := m.openTournament(chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := m.checkBanned(userID); err != nil {
+		return nil, nil, err
+	}
+	if err := m.validator.ValidateUserBalance(userID, tournament.EntryFee); err != nil {
+		return nil, nil, err
+	}
+	if _, err := m.db.EnterTournamentWithFee(tournament.ID, userID); err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := m.db.GetTournamentEntries(tournament.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取参赛玩家失败: %v", err)
+	}
+	return tournament, entries, nil
+}
+
+// CancelTournament 取消本群报名中的锦标赛，退还全部报名费；开赛后不能取消
+func (m *Manager) CancelTournament(chatID int64) (*models.Tournament, []*models.TournamentEntry, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tournament, err := m.openTournament(chatID)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := m.refundTournament(tournament)
+	if err != nil {
+		return nil, nil, err
+	}
+	log.Printf("↩️ 锦标赛 %s 已被管理员取消", tournament.ID)
+	return tournament, entries, nil
+}
+
+// ActiveTournament 获取本群报名中或比赛中的锦标赛及其参赛玩家和已进行的对阵，没有时返回 nil
+func (m *Manager) ActiveTournament(chatID int64) (*TournamentProgress, error) {
+	tournament, err := m.db.GetActiveTournament(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("获取锦标赛失败: %v", err)
+	}
+	if tournament == nil {
+		return nil, nil
+	}
+	return m.tournamentProgress(tournament)
+}
+
+// TournamentDetail 获取锦标赛及其参赛玩家和全部对阵，不存在时返回 nil
+func (m *Manager) TournamentDetail(tournamentID string) (*TournamentProgress, error) {
+	tournament, err := m.db.GetTournament(tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取锦标赛失败: %v", err)
+	}
+	if tournament == nil {
+		return nil, nil
+	}
+	return m.tournamentProgress(tournament)
+}
+
+// DueTournaments 获取已到报名截止或下一轮开赛时间的锦标赛
+func (m *Manager) DueTournaments(now time.Time) ([]*models.Tournament, error) {
+	return m.db.GetDueTournaments(now)
+}
+
+// AdvanceTournament 推进到期的锦标赛：报名截止时人数不足则取消退款，否则抽签开赛；
+// 比赛中则进行下一轮，只剩一人时向冠军派发扣除手续费后的奖池。roundInterval 为两轮之间的间隔
+func (m *Manager) AdvanceTournament(tournamentID string, roundInterval time.Duration) (*TournamentProgress, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	tournament, err := m.db.GetTournament(tournamentID)
+	if err != nil {
+		return nil, fmt.Errorf("获取锦标赛失败: %v", err)
+	}
+	if tournament == nil {
+		return nil, fmt.Errorf("锦标赛不存在")
+	}
+
+	switch tournament.Status {
+	case models.TournamentStatusOpen:
+		entries, err := m.db.GetTournamentEntries(tournament.ID)
+		if err != nil {
+			return nil, fmt.Errorf("获取参赛玩家失败: %v", err)
+		}
+		if len(entries) < MinTournamentPlayers {
+			if entries, err = m.refundTournament(tournament); err != nil {
+				return nil, err
+			}
+			log.Printf("⏰ 锦标赛 %s 报名人数不足，已取消并退还 %d 名玩家的报名费", tournament.ID, len(entries))
+			return &TournamentProgress{Tournament: tournament, Entries: entries}, nil
+		}
+		return m.startTournament(tournament, entries, roundInterval)
+	case models.TournamentStatusRunning:
+		return m.playTournamentRound(tournament, roundInterval)
+	default:
+		return nil, fmt.Errorf("锦标赛已结束")
+	}
+}
+
+// startTournament 截止报名并随机抽签决定种子位，第一轮在 roundInterval 后进行
+func (m *Manager) startTournament(tournament *models.Tournament, entries []*models.TournamentEntry, roundInterval time.Duration) (*TournamentProgress, error) {
+	order := make([]int, len(entries))
+	for i := range order {
+		order[i] = i
+	}
+	for i := len(order) - 1; i > 0; i-- {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return nil, fmt.Errorf("抽签失败: %v", err)
+		}
+		j := int(n.Int64())
+		order[i], order[j] = order[j], order[i]
+	}
+	for seed, i := range order {
+		entries[i].Seed = seed + 1
+	}
+	sortBySeed(entries)
+
+	tournament.NextRoundAt = time.Now().Add(roundInterval)
+	if err := m.db.StartTournament(tournament, entries); err != nil {
+		return nil, fmt.Errorf("锦标赛开赛失败: %v", err)
+	}
+	log.Printf("🏆 锦标赛 %s 开赛：%d 名玩家，奖池 %d", tournament.ID, len(entries), tournament.EntryFee*int64(len(entries)))
+	return &TournamentProgress{Tournament: tournament, Entries: entries}, nil
+}
+
+// playTournamentRound 按种子位两两对阵进行一轮，只剩一人时结束比赛并派奖
+func (m *Manager) playTournamentRound(tournament *models.Tournament, roundInterval time.Duration) (*TournamentProgress, error) {
+	entries, err := m.db.GetTournamentEntries(tournament.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取参赛玩家失败: %v", err)
+	}
+	survivors := tournamentSurvivors(entries)
+	if len(survivors) == 0 {
+		return nil, fmt.Errorf("锦标赛 %s 没有未淘汰的玩家", tournament.ID)
+	}
+
+	progress := &TournamentProgress{Tournament: tournament, Entries: entries}
+	if len(survivors) > 1 {
+		now := time.Now()
+		tournament.Round++
+		tournament.NextRoundAt = now.Add(roundInterval)
+		matches, err := m.pairTournamentRound(tournament, survivors, now)
+		if err != nil {
+			tournament.Round--
+			return nil, err
+		}
+		if err := m.db.RecordTournamentRound(tournament, matches); err != nil {
+			tournament.Round--
+			return nil, fmt.Errorf("记录锦标赛第 %d 轮失败: %v", tournament.Round+1, err)
+		}
+		eliminateLosers(entries, matches)
+		progress.Matches = matches
+		survivors = tournamentSurvivors(entries)
+		log.Printf("🎲 锦标赛 %s 第 %d 轮结束：%d 场对阵，剩余 %d 人", tournament.ID, tournament.Round, len(matches), len(survivors))
+	}
+
+	if len(survivors) == 1 {
+		if err := m.finishTournament(tournament, entries, survivors[0].UserID); err != nil {
+			return nil, err
+		}
+	}
+	return progress, nil
+}
+
+// pairTournamentRound 按种子位两两对阵并掷骰，同点时重掷直到分出胜负。
+// 人数不是 2 的幂时，种子位靠前的玩家轮空直接晋级，之后每轮人数都是 2 的幂
+func (m *Manager) pairTournamentRound(tournament *models.Tournament, survivors []*models.TournamentEntry, now time.Time) ([]*models.TournamentMatch, error) {
+	byes := TournamentBracketSize(len(survivors)) - len(survivors)
+
+	var matches []*models.TournamentMatch
+	for i := 0; i < len(survivors); {
+		match := &models.TournamentMatch{
+			TournamentID: tournament.ID,
+			Round:        tournament.Round,
+			Slot:         len(matches) + 1,
+			Player1ID:    survivors[i].UserID,
+			PlayedAt:     now,
+		}
+		if byes > 0 || i+1 == len(survivors) {
+			match.WinnerID = match.Player1ID
+			matches = append(matches, match)
+			byes--
+			i++
+			continue
+		}
+
+		match.Player2ID = survivors[i+1].UserID
+		for {
+			var err error
+			if match.Player1Dice, err = m.rollTournamentDice(); err != nil {
+				return nil, err
+			}
+			if match.Player2Dice, err = m.rollTournamentDice(); err != nil {
+				return nil, err
+			}
+			total1, total2 := match.Totals()
+			if total1 != total2 {
+				match.WinnerID = match.Player1ID
+				if total2 > total1 {
+					match.WinnerID = match.Player2ID
+				}
+				break
+			}
+			match.Rerolls++
+		}
+		matches = append(matches, match)
+		i += 2
+	}
+	return matches, nil
+}
+
+// rollTournamentDice 掷三个骰子
+func (m *Manager) rollTournamentDice() ([3]int, error) {
+	d1, d2, d3, err := m.rollDice()
+	if err != nil {
+		return [3]int{}, fmt.Errorf("生成骰子失败: %v", err)
+	}
+	return [3]int{d1, d2, d3}, nil
+}
+
+// finishTournament 结束比赛，冠军独得扣除手续费后的全部报名费和群组比赛奖池
+func (m *Manager) finishTournament(tournament *models.Tournament, entries []*models.TournamentEntry, winnerID int64) error {
+	pool := tournament.EntryFee * int64(len(entries))
+	commission := utils.CalculateCommission(pool, m.chatFeeRate(tournament.ChatID))
+
+	var transactions []*models.Transaction
+	if commission > 0 {
+		transactions, _ = m.splitCommission(tournament.ChatID, nil, fmt.Sprintf("锦标赛 %s", tournament.ID), commission)
+	}
+	tournament.WinnerID = &winnerID
+	tournament.Prize = pool - commission
+	tournament.Commission = commission
+	if err := m.db.FinishTournamentWithPrize(tournament, transactions); err != nil {
+		tournament.WinnerID = nil
+		return fmt.Errorf("锦标赛派奖失败: %v", err)
+	}
+	log.Printf("👑 锦标赛 %s 冠军为玩家 %d，奖金 %d（群组奖池 %d），手续费 %d", tournament.ID, winnerID, tournament.Prize, tournament.FundPrize, commission)
+	return nil
+}
+
+// openTournament 获取本群正在报名的锦标赛
+func (m *Manager) openTournament(chatID int64) (*models.Tournament, error) {
+	tournament, err := m.db.GetActiveTournament(chatID)
+	if err != nil {
+		return nil, fmt.Errorf("获取锦标赛失败: %v", err)
+	}
+	if tournament == nil {
+		return nil, fmt.Errorf("本群没有报名中的锦标赛")
+	}
+	if tournament.Status != models.TournamentStatusOpen {
+		return nil, fmt.Errorf("锦标赛已开赛，报名已截止")
+	}
+	return tournament, nil
+}
+
+// refundTournament 取消报名中的锦标赛并退还全部报名费
+func (m *Manager) refundTournament(tournament *models.Tournament) ([]*models.TournamentEntry, error) {
+	entries, err := m.db.GetTournamentEntries(tournament.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取参赛玩家失败: %v", err)
+	}
+	if err := m.db.CancelTournamentWithRefund(tournament, entries); err != nil {
+		return nil, fmt.Errorf("取消锦标赛失败: %v", err)
+	}
+	return entries, nil
+}
+
+// tournamentProgress 读取锦标赛的参赛玩家和已进行的对阵
+func (m *Manager) tournamentProgress(tournament *models.Tournament) (*TournamentProgress, error) {
+	entries, err := m.db.GetTournamentEntries(tournament.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取参赛玩家失败: %v", err)
+	}
+	matches, err := m.db.GetTournamentMatches(tournament.ID)
+	if err != nil {
+		return nil, fmt.Errorf("获取锦标赛对阵失败: %v", err)
+	}
+	return &TournamentProgress{Tournament: tournament, Entries: entries, Matches: matches}, nil
+}
+
+// TournamentBracketSize 容纳 players 名玩家的签表大小（不小于人数的最小 2 的幂）
+func TournamentBracketSize(players int) int {
+	size := 1
+	for size < players {
+		size *= 2
+	}
+	return size
+}
+
+// TournamentRounds 单败淘汰赛决出冠军所需的轮数
+func TournamentRounds(players int) int {
+	rounds := 0
+	for size := TournamentBracketSize(players); size > 1; size /= 2 {
+		rounds++
+	}
+	return rounds
+}
+
+// tournamentSurvivors 尚未被淘汰的玩家，按种子位排列
+func tournamentSurvivors(entries []*models.TournamentEntry) []*models.TournamentEntry {
+	var survivors []*models.TournamentEntry
+	for _, entry := range entries {
+		if entry.EliminatedRound == 0 {
+			survivors = append(survivors, entry)
+		}
+	}
+	return survivors
+}
+
+// eliminateLosers 按本轮结果标记被淘汰的玩家
+func eliminateLosers(entries []*models.TournamentEntry, matches []*models.TournamentMatch) {
+	winners := make(map[int64]bool, len(matches))
+	for _, match := range matches {
+		winners[match.WinnerID] = true
+	}
+	for _, match := range matches {
+		for _, entry := range entries {
+			if (entry.UserID == match.Player1ID || entry.UserID == match.Player2ID) && !winners[entry.UserID] {
+				entry.EliminatedRound = match.Round
+			}
+		}
+	}
+}
+
+// sortBySeed 按种子位排列参赛玩家
+func sortBySeed(entries []*models.TournamentEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Seed < entries[j].Seed
+	})
+}
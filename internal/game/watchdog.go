@@ -0,0 +1,153 @@
+package game
+
+import (
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// SetMaxGameDuration 设置对局从开骰到结算的最长时长，超时后中止对局并向双方退款，0 表示不启用。
+// 用于开骰动画中途失败（如 Telegram 故障）导致对局一直占用群组的情况
+func (m *Manager) SetMaxGameDuration(duration time.Duration) {
+	m.watchdogMu.Lock()
+	defer m.watchdogMu.Unlock()
+	m.maxGameDuration = duration
+}
+
+// SetGameAbortedCallback 设置对局中止并退款后的回调函数，reason 为 models.GameAbort* 之一
+func (m *Manager) SetGameAbortedCallback(callback func(game *models.Game, reason string)) {
+	m.onGameAborted = callback
+}
+
+// SetSettlementDelayNotice 设置开骰后结算延迟通知的时长，超过该时长仍未结算时私信双方说明延迟，
+// 并告知到达最长时长后会自动退款。需同时启用最长时长，0 或不短于最长时长时不通知
+func (m *Manager) SetSettlementDelayNotice(delay time.Duration) {
+	m.watchdogMu.Lock()
+	defer m.watchdogMu.Unlock()
+	m.settlementNotice = delay
+}
+
+// SetSettlementDelayedCallback 设置结算延迟时的回调函数，refundIn 为距离自动退款的剩余时长
+func (m *Manager) SetSettlementDelayedCallback(callback func(game *models.Game, refundIn time.Duration)) {
+	m.onSettlementDelayed = callback
+}
+
+// armWatchdog 对局开骰后开始计时
+func (m *Manager) armWatchdog(gameID string) {
+	m.watchdogMu.Lock()
+	defer m.watchdogMu.Unlock()
+
+	if m.maxGameDuration <= 0 {
+		return
+	}
+	if timer, exists := m.watchdogs[gameID]; exists {
+		timer.Stop()
+	}
+	m.watchdogs[gameID] = time.AfterFunc(m.maxGameDuration, func() {
+		m.abortStalledGame(gameID)
+	})
+
+	if m.settlementNotice <= 0 || m.settlementNotice >= m.maxGameDuration {
+		return
+	}
+	if timer, exists := m.delayNotices[gameID]; exists {
+		timer.Stop()
+	}
+	refundIn := m.maxGameDuration - m.settlementNotice
+	m.delayNotices[gameID] = time.AfterFunc(m.settlementNotice, func() {
+		m.noticeSettlementDelay(gameID, refundIn)
+	})
+}
+
+// disarmWatchdog 对局结束后停止计时
+func (m *Manager) disarmWatchdog(gameID string) {
+	m.watchdogMu.Lock()
+	defer m.watchdogMu.Unlock()
+
+	if timer, exists := m.watchdogs[gameID]; exists {
+		timer.Stop()
+		delete(m.watchdogs, gameID)
+	}
+	if timer, exists := m.delayNotices[gameID]; exists {
+		timer.Stop()
+		delete(m.delayNotices, gameID)
+	}
+}
+
+// noticeSettlementDelay 开骰后迟迟未结算时通知双方，对局仍会在最长时长到达时自动退款
+func (m *Manager) noticeSettlementDelay(gameID string, refundIn time.Duration) {
+	m.watchdogMu.Lock()
+	delete(m.delayNotices, gameID)
+	m.watchdogMu.Unlock()
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil || game == nil {
+		log.Printf("⚠️ 结算延迟通知获取对局 %s 失败: %v", gameID, err)
+		return
+	}
+	if game.Status != models.GameStatusPlaying {
+		return
+	}
+
+	log.Printf("🐢 对局 %s 开骰后迟迟未结算，通知双方 %s 后自动退款", gameID, refundIn)
+	if m.onSettlementDelayed != nil {
+		m.onSettlementDelayed(game, refundIn)
+	}
+}
+
+// abortStalledGame 中止超过最长时长仍未结算的对局，向双方退还下注和保险费
+func (m *Manager) abortStalledGame(gameID string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.disarmWatchdog(gameID)
+
+	game, err := m.db.GetGame(gameID)
+	if err != nil || game == nil {
+		log.Printf("⚠️ 中止对局 %s 时获取对局失败: %v", gameID, err)
+		return
+	}
+	if game.Status != models.GameStatusPlaying {
+		return
+	}
+
+	// 取消和退款在同一事务中完成，迟到的骰子结果不会再结算
+	ok, err := m.cancelGame(game, models.GameStatusPlaying)
+	if err != nil {
+		log.Printf("❌ 中止对局 %s 退款失败: %v", gameID, err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	game.Status = models.GameStatusCancelled
+	m.metrics.gameAborted(gameID)
+	m.notifyGameFinished(game)
+	log.Printf("⏱️ 对局 %s 超过最长时长仍未结算，已中止并退款", gameID)
+
+	// 通知可能需要访问 Telegram，不在持有锁时等待
+	if m.onGameAborted != nil {
+		go m.onGameAborted(game, models.GameAbortStalled)
+	}
+}
+
+// sweepStalledGames 中止计时器之外遗漏的超时对局（如进程重启前已开骰的对局）
+func (m *Manager) sweepStalledGames() {
+	m.watchdogMu.Lock()
+	maxDuration := m.maxGameDuration
+	m.watchdogMu.Unlock()
+	if maxDuration <= 0 {
+		return
+	}
+
+	gameIDs, err := m.db.GetStalledGameIDs(time.Now().Add(-maxDuration))
+	if err != nil {
+		log.Printf("⚠️ 检查超时对局失败: %v", err)
+		return
+	}
+	for _, gameID := range gameIDs {
+		m.abortStalledGame(gameID)
+	}
+}
@@ -0,0 +1,139 @@
+package help
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /help 命令和帮助主题按钮的处理器
+type Handler struct {
+	db    *database.DB
+	cfg   *config.Config
+	codec *callback.Codec
+}
+
+// NewHandler 创建帮助处理器
+func NewHandler(db *database.DB, cfg *config.Config, codec *callback.Codec) *Handler {
+	return &Handler{db: db, cfg: cfg, codec: codec}
+}
+
+// Register 注册 /help 命令和帮助主题按钮回调
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.HelpCommand, h.Help)
+	router.HandleCallback(callback.Prefix(ui.HelpTopicAction), h.Topic)
+}
+
+// Help 显示帮助目录，带主题参数时显示该命令的用法、示例和本群限制
+func (h *Handler) Help(ctx *middleware.Context) error {
+	topic, ok := ui.LookupHelpTopic(ctx.Args)
+	if !ok {
+		return ctx.Reply(ui.FormatUnknownHelpTopic(ctx.Lang, strings.TrimSpace(ctx.Args)))
+	}
+
+	lang, data, err := h.load(ctx)
+	if err != nil {
+		return err
+	}
+	msg, err := ui.BuildHelpMessage(h.codec, ctx.ChatID, lang, topic, data)
+	if err != nil {
+		return err
+	}
+	if ctx.Update.Message != nil {
+		msg.ReplyToMessageID = ctx.Update.Message.MessageID
+	}
+	_, err = ctx.Client.Send(msg)
+	return err
+}
+
+// Topic 点击主题按钮后原地切换帮助内容
+func (h *Handler) Topic(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	topic, err := ui.ParseHelpCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+
+	lang, data, err := h.load(ctx)
+	if err != nil {
+		return err
+	}
+	edit, err := ui.BuildHelpEdit(h.codec, ctx.ChatID, query.Message.MessageID, lang, topic, data)
+	if err != nil {
+		return err
+	}
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Printf("⚠️ 应答帮助按钮失败: %v", err)
+	}
+	if _, err := ctx.Client.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return err
+	}
+	return nil
+}
+
+// load 帮助使用的语言和本群当前的限制；私聊时按用户客户端语言显示，不含群组设置
+func (h *Handler) load(ctx *middleware.Context) (string, ui.HelpData, error) {
+	data := ui.HelpData{
+		MinBet:                 utils.FormatAmount(h.cfg.MinBet),
+		MaxBet:                 utils.FormatAmount(h.cfg.MaxBet),
+		FeePercent:             percent(h.cfg.FeeRate),
+		SurrenderRefundPercent: percent(h.cfg.SurrenderRefundRate),
+		TableMinPlayers:        game.MinTablePlayers,
+		TableMaxPlayers:        game.MaxTablePlayers,
+		TableTimeout:           int(game.TableTimeout.Minutes()),
+	}
+	if h.cfg.DailyBonus > 0 {
+		data.DailyBonus = utils.FormatAmount(h.cfg.DailyBonus)
+	}
+
+	lang := ui.DefaultLanguage
+	if ctx.From != nil {
+		lang = ui.NormalizeLanguage(ctx.From.LanguageCode)
+	}
+	if ctx.ChatID >= 0 {
+		return lang, data, nil
+	}
+
+	data.InGroup = true
+	chat, err := h.db.GetChat(ctx.ChatID)
+	if err != nil {
+		return "", data, fmt.Errorf("获取群组信息失败: %v", err)
+	}
+	if chat == nil {
+		return lang, data, nil
+	}
+	if chat.Language != "" {
+		lang = ui.NormalizeLanguage(chat.Language)
+	}
+	custom, err := h.db.GetChatGameLimits(ctx.ChatID)
+	if err != nil {
+		return "", data, fmt.Errorf("获取群组对局设置失败: %v", err)
+	}
+	limits := game.MergeChatLimits(custom, h.cfg.MinBet, h.cfg.MaxBet, h.cfg.FeeRate)
+	data.MinBet = utils.FormatAmount(limits.MinBet)
+	data.MaxBet = utils.FormatAmount(limits.MaxBet)
+	data.FeePercent = percent(limits.FeeRate)
+	data.Sequential = chat.SequentialGames
+	data.MaxActiveGames = chat.MaxActiveGames
+	data.Cooldown = chat.GameCooldown
+	data.SurrenderEnabled = chat.SurrenderEnabled
+	data.ReadyCheck = chat.ReadyCheck
+	data.ReadySeconds = int(game.ReadyWindow.Seconds())
+	return lang, data, nil
+}
+
+// percent 比例转为百分数，保留一位小数以免浮点误差出现在文案中
+func percent(rate float64) float64 {
+	return math.Round(rate*1000) / 10
+}
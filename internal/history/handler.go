@@ -0,0 +1,122 @@
+package history
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"telegram-dice-bot/internal/cache"
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /history 最近对局记录的处理器，记录经缓存读取，翻页按钮原地编辑消息
+type Handler struct {
+	db      *database.DB
+	history *cache.GameHistoryCache
+	client  telegram.Client
+	codec   *callback.Codec
+}
+
+// NewHandler 创建对局记录处理器，history 应缓存至少 ui.HistoryGames 局
+func NewHandler(db *database.DB, history *cache.GameHistoryCache, client telegram.Client, codec *callback.Codec) *Handler {
+	return &Handler{db: db, history: history, client: client, codec: codec}
+}
+
+// Register 注册 /history 命令和翻页按钮回调
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.HistoryCommand, h.Show)
+	router.HandleCallback(callback.Prefix(ui.HistoryAction), h.Page)
+}
+
+// Show 发送调用者最近对局记录的第一页
+func (h *Handler) Show(ctx *middleware.Context) error {
+	games, names, err := h.load(ctx.UserID)
+	if err != nil {
+		return err
+	}
+
+	msg, err := ui.BuildHistoryMessage(h.codec, ctx.ChatID, ctx.UserID, 0, games, names)
+	if err != nil {
+		return err
+	}
+	if ctx.Update.Message != nil {
+		msg.ReplyToMessageID = ctx.Update.Message.MessageID
+	}
+	_, err = h.client.Send(msg)
+	return err
+}
+
+// Page 翻页按钮：只有记录的主人可以翻页
+func (h *Handler) Page(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	userID, page, err := ui.ParseHistoryCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+	if userID != ctx.UserID {
+		return ctx.Reply("⚠️ 只能翻阅自己的对局记录，发送 /history 查看你的记录")
+	}
+
+	games, names, err := h.load(userID)
+	if err != nil {
+		return err
+	}
+	// 记录可能在两次翻页之间变少，翻到最后一页
+	if last := ui.HistoryPages(len(games)) - 1; page > last {
+		page = last
+	}
+	edit, err := ui.BuildHistoryEdit(h.codec, ctx.ChatID, query.Message.MessageID, userID, page, games, names)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.client.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Printf("⚠️ 应答对局记录按钮失败: %v", err)
+	}
+	if _, err := h.client.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return err
+	}
+	return nil
+}
+
+// load 读取用户最近的对局和对手的显示名称，开启匿名的对手只显示代号
+func (h *Handler) load(userID int64) ([]*models.Game, map[int64]string, error) {
+	games, err := h.history.GetUserGameHistory(userID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取对局记录失败: %v", err)
+	}
+
+	names := make(map[int64]string)
+	for _, game := range games {
+		if game.Player2ID == nil {
+			continue
+		}
+		opponentID := *game.Player2ID
+		if opponentID == userID {
+			opponentID = game.Player1ID
+		}
+		if _, ok := names[opponentID]; ok {
+			continue
+		}
+
+		var username, firstName string
+		if opponent, err := h.db.GetUser(opponentID); err != nil {
+			log.Printf("⚠️ 对局记录获取用户 %d 失败: %v", opponentID, err)
+		} else if opponent != nil {
+			username, firstName = opponent.Username, opponent.FirstName
+		}
+		anonymous, err := h.db.IsUserAnonymous(opponentID)
+		if err != nil {
+			log.Printf("⚠️ 获取用户 %d 匿名设置失败: %v", opponentID, err)
+		}
+		names[opponentID] = ui.PublicName(opponentID, username, firstName, anonymous)
+	}
+	return games, names, nil
+}
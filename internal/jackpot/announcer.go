@@ -0,0 +1,174 @@
+package jackpot
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// PoolSource 返回当前奖池金额，奖池未启用时返回 0
+type PoolSource func() (int64, error)
+
+// Announcer 群组奖池播报和险胜提示
+type Announcer struct {
+	db     *database.DB
+	client telegram.Client
+	pool   PoolSource
+
+	interval     time.Duration // 奖池播报周期
+	minGap       time.Duration // 同一群组两条播报的最小间隔
+	activeWindow time.Duration // 最近有对局的群组才会收到播报
+
+	mu       sync.Mutex
+	lastSent map[int64]time.Time
+	quit     chan struct{}
+}
+
+// NewAnnouncer 创建奖池播报器
+func NewAnnouncer(db *database.DB, client telegram.Client, pool PoolSource, interval time.Duration) *Announcer {
+	if interval <= 0 {
+		interval = 30 * time.Minute
+	}
+	return &Announcer{
+		db:           db,
+		client:       client,
+		pool:         pool,
+		interval:     interval,
+		minGap:       10 * time.Minute,
+		activeWindow: 24 * time.Hour,
+		lastSent:     make(map[int64]time.Time),
+		quit:         make(chan struct{}),
+	}
+}
+
+// Start 启动周期播报
+func (a *Announcer) Start() {
+	go func() {
+		ticker := time.NewTicker(a.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				a.announcePool()
+			case <-a.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止周期播报
+func (a *Announcer) Stop() {
+	close(a.quit)
+}
+
+// announcePool 向活跃群组播报奖池金额
+func (a *Announcer) announcePool() {
+	if a.pool == nil {
+		return
+	}
+
+	amount, err := a.pool()
+	if err != nil {
+		log.Printf("⚠️ 获取奖池金额失败: %v", err)
+		return
+	}
+	if amount <= 0 {
+		return
+	}
+
+	chatIDs, err := a.db.GetActiveJackpotChats(time.Now().Add(-a.activeWindow))
+	if err != nil {
+		log.Printf("⚠️ 获取奖池播报群组失败: %v", err)
+		return
+	}
+
+	text := fmt.Sprintf("💰 当前奖池：%s💎\n\n掷出三个6即可赢走奖池，快来挑战吧！", utils.FormatAmount(amount))
+	for _, chatID := range chatIDs {
+		a.send(chatID, text)
+	}
+}
+
+// AnnounceNearMiss 对局结束后，若有玩家掷出两个6则发送险胜提示
+func (a *Announcer) AnnounceNearMiss(chatID int64, result *game.GameResult) {
+	text := NearMissText(result)
+	if text == "" {
+		return
+	}
+
+	enabled, err := a.db.IsJackpotAnnounceEnabled(chatID)
+	if err != nil || !enabled {
+		return
+	}
+
+	a.send(chatID, text)
+}
+
+// send 按群组限流发送
+func (a *Announcer) send(chatID int64, text string) {
+	a.mu.Lock()
+	if last, ok := a.lastSent[chatID]; ok && time.Since(last) < a.minGap {
+		a.mu.Unlock()
+		return
+	}
+	a.lastSent[chatID] = time.Now()
+	a.mu.Unlock()
+
+	if _, err := a.client.Send(tgbotapi.NewMessage(chatID, text)); err != nil {
+		log.Printf("⚠️ 发送奖池播报到群组 %d 失败: %v", chatID, err)
+	}
+}
+
+// NearMissText 生成险胜提示，没有玩家掷出两个6时返回空字符串
+func NearMissText(result *game.GameResult) string {
+	if result == nil {
+		return ""
+	}
+
+	type roll struct {
+		name string
+		dice [3]int
+	}
+	var rolls []roll
+	if result.Player1 != nil {
+		rolls = append(rolls, roll{displayName(result.Player1.Username, result.Player1.FirstName),
+			[3]int{result.Player1Dice1, result.Player1Dice2, result.Player1Dice3}})
+	}
+	if result.Player2 != nil {
+		rolls = append(rolls, roll{displayName(result.Player2.Username, result.Player2.FirstName),
+			[3]int{result.Player2Dice1, result.Player2Dice2, result.Player2Dice3}})
+	}
+
+	for _, r := range rolls {
+		sixes := 0
+		for _, d := range r.dice {
+			if d == 6 {
+				sixes++
+			}
+		}
+		if sixes == 2 {
+			return fmt.Sprintf("😱 %s 掷出了两个6，离奖池只差一个6！", r.name)
+		}
+	}
+	return ""
+}
+
+// displayName 优先显示用户名
+func displayName(username, firstName string) string {
+	if username != "" {
+		return "@" + username
+	}
+	if firstName != "" {
+		return firstName
+	}
+	return "玩家"
+}
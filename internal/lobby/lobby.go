@@ -0,0 +1,164 @@
+package lobby
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/events"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Lobby 群组的等待对局列表：刷新按钮原地编辑消息，对局发起或被加入时自动刷新本群最新的列表消息
+type Lobby struct {
+	db       *database.DB
+	client   telegram.Client
+	codec    *callback.Codec
+	debounce time.Duration // 同一用户两次刷新的最小间隔，也是自动刷新的合并间隔
+
+	mu      sync.Mutex
+	latest  map[int64]int  // 群组ID -> 最新的列表消息ID
+	pending map[int64]bool // 已安排自动刷新的群组
+}
+
+// NewLobby 创建对局列表处理器
+func NewLobby(db *database.DB, client telegram.Client, codec *callback.Codec, debounce time.Duration) *Lobby {
+	return &Lobby{
+		db:       db,
+		client:   client,
+		codec:    codec,
+		debounce: debounce,
+		latest:   make(map[int64]int),
+		pending:  make(map[int64]bool),
+	}
+}
+
+// Register 注册 /games 命令和刷新按钮回调，刷新按用户限频
+func (l *Lobby) Register(router *middleware.Router) {
+	router.Handle(ui.GamesCommand, l.Show)
+	router.HandleCallback(callback.Prefix(ui.RefreshGamesAction), l.Refresh, middleware.RateLimit(1, l.debounce))
+}
+
+// Subscribe 订阅对局发起和加入事件，用于自动刷新列表
+func (l *Lobby) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.GameCreatedEvent, l.handle)
+	bus.Subscribe(events.GameJoinedEvent, l.handle)
+}
+
+// Show 发送本群等待中的对局列表，并记为本群最新的列表消息
+func (l *Lobby) Show(ctx *middleware.Context) error {
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("⚠️ 请在群组中使用 /games 查看等待中的对局")
+	}
+
+	games, err := l.db.GetWaitingGames(ctx.ChatID)
+	if err != nil {
+		return fmt.Errorf("获取等待中的对局失败: %v", err)
+	}
+	msg, err := ui.BuildWaitingGamesMessage(l.codec, ctx.ChatID, games, 0)
+	if err != nil {
+		return err
+	}
+
+	sent, err := l.client.Send(msg)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	l.latest[ctx.ChatID] = sent.MessageID
+	l.mu.Unlock()
+	return nil
+}
+
+// Refresh 刷新按钮：原地编辑被点击的列表消息
+func (l *Lobby) Refresh(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	parsed, err := l.codec.Decode(query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+	if parsed.Action != ui.RefreshGamesAction || ctx.ChatID >= 0 {
+		return ctx.Reply("⚠️ " + callback.ErrMalformed.Error())
+	}
+
+	if _, err := l.client.Request(tgbotapi.NewCallback(query.ID, "🔄 已刷新")); err != nil {
+		log.Printf("⚠️ 应答刷新按钮失败: %v", err)
+	}
+
+	messageID := query.Message.MessageID
+	l.mu.Lock()
+	if messageID > l.latest[ctx.ChatID] {
+		l.latest[ctx.ChatID] = messageID
+	}
+	l.mu.Unlock()
+
+	return l.edit(ctx.ChatID, messageID)
+}
+
+// handle 对局发起或被加入时，在合并间隔后刷新本群最新的列表消息
+func (l *Lobby) handle(event events.Event) {
+	var chatID int64
+	switch e := event.(type) {
+	case events.GameCreated:
+		chatID = e.ChatID
+	case events.GameJoined:
+		chatID = e.ChatID
+	default:
+		return
+	}
+
+	l.mu.Lock()
+	_, listed := l.latest[chatID]
+	if !listed || l.pending[chatID] {
+		l.mu.Unlock()
+		return
+	}
+	l.pending[chatID] = true
+	l.mu.Unlock()
+
+	time.AfterFunc(l.debounce, func() {
+		l.mu.Lock()
+		delete(l.pending, chatID)
+		messageID, listed := l.latest[chatID]
+		l.mu.Unlock()
+		if !listed {
+			return
+		}
+
+		if err := l.edit(chatID, messageID); err != nil {
+			// 列表消息已被删除或无法编辑时不再自动刷新
+			log.Printf("⚠️ 自动刷新群组 %d 对局列表失败: %v", chatID, err)
+			l.mu.Lock()
+			if l.latest[chatID] == messageID {
+				delete(l.latest, chatID)
+			}
+			l.mu.Unlock()
+		}
+	})
+}
+
+// edit 将列表消息更新为最新的等待对局，内容未变化时忽略
+func (l *Lobby) edit(chatID int64, messageID int) error {
+	games, err := l.db.GetWaitingGames(chatID)
+	if err != nil {
+		return fmt.Errorf("获取等待中的对局失败: %v", err)
+	}
+	edit, err := ui.BuildWaitingGamesEdit(l.codec, chatID, messageID, games, 0)
+	if err != nil {
+		return err
+	}
+
+	if _, err := l.client.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package locale
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"unicode"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// sampleSize 每个群组保留的最近消息语言样本数
+	sampleSize = 30
+	// minSamples 样本数达到后才根据群内消息判断语言
+	minSamples = 10
+)
+
+// Detector 根据拉机器人入群者的语言设置和群内最近消息检测群组语言，
+// 管理员通过 /language 手动指定后不再自动调整
+type Detector struct {
+	db *database.DB
+
+	mu      sync.Mutex
+	samples map[int64][]string // 群组最近消息的语言，按时间顺序
+	current map[int64]string   // 已写入数据库的检测结果，避免重复写入
+}
+
+// NewDetector 创建群组语言检测器
+func NewDetector(db *database.DB) *Detector {
+	return &Detector{
+		db:      db,
+		samples: make(map[int64][]string),
+		current: make(map[int64]string),
+	}
+}
+
+// Language 群组当前语言，供多语言模板选择使用
+func (d *Detector) Language(chatID int64) (string, error) {
+	chat, err := d.db.GetChat(chatID)
+	if err != nil {
+		return "", fmt.Errorf("获取群组信息失败: %v", err)
+	}
+	if chat == nil {
+		return ui.DefaultLanguage, nil
+	}
+	return ui.NormalizeLanguage(chat.Language), nil
+}
+
+// Resolve 回复使用的语言：群组中为群组语言，尚未确定时与私聊一样按用户客户端语言，
+// 可用作 middleware.WithLanguage 的参数
+func (d *Detector) Resolve(chatID int64, from *tgbotapi.User) (string, error) {
+	lang := ui.DefaultLanguage
+	if from != nil {
+		lang = ui.NormalizeLanguage(from.LanguageCode)
+	}
+	if chatID >= 0 {
+		return lang, nil
+	}
+	chat, err := d.db.GetChat(chatID)
+	if err != nil {
+		return "", fmt.Errorf("获取群组信息失败: %v", err)
+	}
+	if chat == nil || chat.Language == "" {
+		return lang, nil
+	}
+	return ui.NormalizeLanguage(chat.Language), nil
+}
+
+// ObserveJoin 机器人入群时以拉入者的客户端语言作为群组初始语言
+func (d *Detector) ObserveJoin(update *tgbotapi.ChatMemberUpdated) {
+	if !ui.BotJoinedChat(update) {
+		return
+	}
+	lang, ok := ui.LookupLanguage(update.From.LanguageCode)
+	if !ok {
+		return
+	}
+	d.apply(update.Chat.ID, lang)
+}
+
+// Observe 记录群内普通消息的语言，样本足够时按多数语言更新群组语言
+func (d *Detector) Observe(msg *tgbotapi.Message) {
+	if msg == nil || msg.Chat == nil || (!msg.Chat.IsGroup() && !msg.Chat.IsSuperGroup()) {
+		return
+	}
+	if msg.IsCommand() || (msg.From != nil && msg.From.IsBot) {
+		return
+	}
+	lang, ok := messageLanguage(msg)
+	if !ok {
+		return
+	}
+
+	d.mu.Lock()
+	samples := append(d.samples[msg.Chat.ID], lang)
+	if len(samples) > sampleSize {
+		samples = samples[len(samples)-sampleSize:]
+	}
+	d.samples[msg.Chat.ID] = samples
+	detected, ok := predominant(samples)
+	d.mu.Unlock()
+
+	if ok {
+		d.apply(msg.Chat.ID, detected)
+	}
+}
+
+// Detected 群组最近消息的主要语言，样本不足时返回 ok=false
+func (d *Detector) Detected(chatID int64) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return predominant(d.samples[chatID])
+}
+
+// Reset 管理员修改语言后清除缓存的检测结果，使下次检测重新写入
+func (d *Detector) Reset(chatID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.current, chatID)
+}
+
+// apply 写入检测到的语言，手动指定语言的群组由数据库跳过
+func (d *Detector) apply(chatID int64, lang string) {
+	d.mu.Lock()
+	if d.current[chatID] == lang {
+		d.mu.Unlock()
+		return
+	}
+	d.current[chatID] = lang
+	d.mu.Unlock()
+
+	changed, err := d.db.SetDetectedChatLanguage(chatID, lang)
+	if err != nil {
+		log.Printf("⚠️ 更新群组 %d 语言失败: %v", chatID, err)
+		d.Reset(chatID)
+		return
+	}
+	if changed {
+		log.Printf("🌐 群组 %d 语言自动设置为 %s", chatID, lang)
+	}
+}
+
+// predominant 样本中超过半数的语言
+func predominant(samples []string) (string, bool) {
+	if len(samples) < minSamples {
+		return "", false
+	}
+	counts := make(map[string]int)
+	for _, lang := range samples {
+		counts[lang]++
+		if counts[lang]*2 > len(samples) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// messageLanguage 根据消息文字判断语言，无法判断时使用发送者的客户端语言
+func messageLanguage(msg *tgbotapi.Message) (string, bool) {
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+
+	var han, latin int
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			han++
+		case r < unicode.MaxLatin1 && unicode.IsLetter(r):
+			latin++
+		}
+	}
+	// 一个汉字承载的信息约等于一个英文单词，中文消息中夹杂少量英文仍判为中文
+	switch {
+	case han > 0 && han*4 >= latin:
+		return "zh", true
+	case latin >= 3:
+		return "en", true
+	}
+
+	if msg.From != nil {
+		return ui.LookupLanguage(msg.From.LanguageCode)
+	}
+	return "", false
+}
@@ -0,0 +1,56 @@
+package locale
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+)
+
+// Register 注册 /language 命令，仅限群管理员
+func (d *Detector) Register(router *middleware.Router) {
+	router.Handle(ui.LanguageCommand, d.SetLanguage, middleware.ChatAdminOnly())
+}
+
+// SetLanguage 查看群组语言，或手动指定语言、恢复自动检测
+func (d *Detector) SetLanguage(ctx *middleware.Context) error {
+	arg := strings.ToLower(strings.TrimSpace(ctx.Args))
+	if arg == "" {
+		chat, err := d.db.GetChat(ctx.ChatID)
+		if err != nil {
+			return fmt.Errorf("获取群组信息失败: %v", err)
+		}
+		if chat == nil {
+			return ctx.Reply(ui.FormatChatLanguage(ui.DefaultLanguage, false))
+		}
+		return ctx.Reply(ui.FormatChatLanguage(chat.Language, chat.LanguageManual))
+	}
+
+	if arg == ui.LanguageAuto {
+		lang, ok := d.Detected(ctx.ChatID)
+		if !ok {
+			if lang, ok = ui.LookupLanguage(ctx.From.LanguageCode); !ok {
+				lang = ui.DefaultLanguage
+			}
+		}
+		if err := d.db.SetChatLanguage(ctx.ChatID, lang, false); err != nil {
+			return fmt.Errorf("设置群组语言失败: %v", err)
+		}
+		d.Reset(ctx.ChatID)
+		log.Printf("🌐 群组 %d 恢复自动检测语言: %s", ctx.ChatID, lang)
+		return ctx.Reply(ui.FormatChatLanguageSet(lang, false))
+	}
+
+	lang, ok := ui.LookupLanguage(arg)
+	if !ok {
+		return ctx.Reply(ui.FormatUnsupportedLanguage(ctx.Lang))
+	}
+	if err := d.db.SetChatLanguage(ctx.ChatID, lang, true); err != nil {
+		return fmt.Errorf("设置群组语言失败: %v", err)
+	}
+	d.Reset(ctx.ChatID)
+	log.Printf("🌐 群管理员 %d 将群组 %d 语言设置为 %s", ctx.UserID, ctx.ChatID, lang)
+	return ctx.Reply(ui.FormatChatLanguageSet(lang, true))
+}
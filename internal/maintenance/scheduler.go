@@ -0,0 +1,233 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+)
+
+// lockName 多进程共享数据库时，同一时间只允许一个进程执行维护
+const lockName = "db_maintenance"
+
+// ErrRunning 已有维护任务在执行
+var ErrRunning = errors.New("数据库维护正在进行中")
+
+// Window 每日低峰时段，End 早于 Start 时表示跨越午夜
+type Window struct {
+	Start time.Duration // 距当日零点的偏移
+	End   time.Duration
+}
+
+// ParseWindow 解析 "03:00-05:00" 格式的维护时段
+func ParseWindow(s string) (Window, error) {
+	start, end, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Window{}, fmt.Errorf("维护时段格式应为 HH:MM-HH:MM: %s", s)
+	}
+
+	var w Window
+	for i, part := range []string{start, end} {
+		t, err := time.Parse("15:04", strings.TrimSpace(part))
+		if err != nil {
+			return Window{}, fmt.Errorf("维护时段格式应为 HH:MM-HH:MM: %s", s)
+		}
+		offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+		if i == 0 {
+			w.Start = offset
+		} else {
+			w.End = offset
+		}
+	}
+	return w, nil
+}
+
+// Contains 判断时间是否在维护时段内
+func (w Window) Contains(t time.Time) bool {
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if w.Start <= w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// Scheduler 在低峰时段定期执行数据库维护，并支持后台手动触发
+type Scheduler struct {
+	db          *database.DB
+	window      Window
+	interval    time.Duration // 两次定时维护的最小间隔
+	vacuumPages int
+	owner       string
+
+	mu      sync.Mutex
+	running bool
+	runs    int64
+	failed  int64
+	last    *models.MaintenanceRun
+	quit    chan struct{}
+}
+
+// NewScheduler 创建数据库维护调度器，owner 用于多进程间的维护锁
+func NewScheduler(db *database.DB, window Window, interval time.Duration, vacuumPages int, owner string) *Scheduler {
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+	if vacuumPages <= 0 {
+		vacuumPages = 1000
+	}
+	return &Scheduler{
+		db:          db,
+		window:      window,
+		interval:    interval,
+		vacuumPages: vacuumPages,
+		owner:       owner,
+		quit:        make(chan struct{}),
+	}
+}
+
+// Start 启动定时检查，每 10 分钟检查一次是否进入维护时段
+func (s *Scheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if s.due(now) {
+					if _, err := s.Run("schedule"); err != nil && !errors.Is(err, ErrRunning) {
+						log.Printf("❌ 定时数据库维护失败: %v", err)
+					}
+				}
+			case <-s.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时检查
+func (s *Scheduler) Stop() {
+	close(s.quit)
+}
+
+// due 判断当前是否应执行定时维护
+func (s *Scheduler) due(now time.Time) bool {
+	if !s.window.Contains(now) {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last == nil || now.Sub(s.last.StartedAt) >= s.interval
+}
+
+// Run 立即执行一次维护，trigger 为 schedule 或 manual
+func (s *Scheduler) Run(trigger string) (*models.MaintenanceRun, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, ErrRunning
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.mu.Unlock()
+	}()
+
+	acquired, err := s.db.AcquireLock(lockName, s.owner, time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("获取维护锁失败: %v", err)
+	}
+	if !acquired {
+		return nil, ErrRunning
+	}
+	defer s.db.ReleaseLock(lockName, s.owner)
+
+	log.Printf("🧹 开始数据库维护（%s）", trigger)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Minute)
+	defer cancel()
+
+	run, err := s.db.RunMaintenance(ctx, s.vacuumPages)
+	if run == nil {
+		run = &models.MaintenanceRun{StartedAt: time.Now()}
+	}
+	run.Trigger = trigger
+
+	s.mu.Lock()
+	s.runs++
+	if err != nil {
+		s.failed++
+		run.Error = err.Error()
+	}
+	s.last = run
+	s.mu.Unlock()
+
+	if err != nil {
+		return run, err
+	}
+
+	log.Printf("✅ 数据库维护完成：%d → %d 字节，回收 %d 页，耗时 %v",
+		run.SizeBefore, run.SizeAfter, run.FreedPages, run.Duration)
+	return run, nil
+}
+
+// Status 维护状态快照
+func (s *Scheduler) Status() map[string]interface{} {
+	size, _ := s.db.Size()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]interface{}{
+		"running":  s.running,
+		"runs":     s.runs,
+		"failed":   s.failed,
+		"last_run": s.last,
+		"db_size":  size,
+		"window":   fmt.Sprintf("%s-%s", formatOffset(s.window.Start), formatOffset(s.window.End)),
+		"interval": s.interval.String(),
+	}
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出数据库大小和维护指标
+func (s *Scheduler) WritePrometheus(w io.Writer) error {
+	size, err := s.db.Size()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	runs, failed := s.runs, s.failed
+	var duration float64
+	if s.last != nil {
+		duration = s.last.Duration.Seconds()
+	}
+	s.mu.Unlock()
+
+	_, err = fmt.Fprintf(w, `# HELP dice_db_size_bytes SQLite database file size.
+# TYPE dice_db_size_bytes gauge
+dice_db_size_bytes %d
+# HELP dice_db_maintenance_runs_total Database maintenance runs.
+# TYPE dice_db_maintenance_runs_total counter
+dice_db_maintenance_runs_total{result="success"} %d
+dice_db_maintenance_runs_total{result="failure"} %d
+# HELP dice_db_maintenance_last_duration_seconds Duration of the last maintenance run.
+# TYPE dice_db_maintenance_last_duration_seconds gauge
+dice_db_maintenance_last_duration_seconds %g
+`, size, runs-failed, failed, duration)
+	return err
+}
+
+func formatOffset(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,208 @@
+package media
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Kind 素材类型
+type Kind string
+
+const (
+	KindPhoto     Kind = "photo"
+	KindSticker   Kind = "sticker"
+	KindAnimation Kind = "animation"
+	KindDocument  Kind = "document"
+)
+
+// Asset 逻辑素材，Path 与 Data 二选一
+type Asset struct {
+	Name string
+	Kind Kind
+	Path string // 本地文件路径
+	Data []byte // 动态生成的内容（如二维码）
+}
+
+// Registry 素材注册表，首次使用时上传并缓存 file_id，之后直接复用
+type Registry struct {
+	db     *database.DB
+	client telegram.Client
+	botID  int64
+
+	mu     sync.RWMutex
+	assets map[string]Asset
+	cache  map[string]string // name -> file_id
+}
+
+// NewRegistry 创建素材注册表
+func NewRegistry(db *database.DB, client telegram.Client) *Registry {
+	return &Registry{
+		db:     db,
+		client: client,
+		botID:  client.Self().ID,
+		assets: make(map[string]Asset),
+		cache:  make(map[string]string),
+	}
+}
+
+// Register 注册素材，同名素材内容变化时需调用 Invalidate
+func (r *Registry) Register(asset Asset) error {
+	if asset.Name == "" {
+		return fmt.Errorf("素材名称不能为空")
+	}
+	if asset.Path == "" && len(asset.Data) == 0 {
+		return fmt.Errorf("素材 %s 缺少文件内容", asset.Name)
+	}
+
+	r.mu.Lock()
+	r.assets[asset.Name] = asset
+	r.mu.Unlock()
+	return nil
+}
+
+// Invalidate 使素材的 file_id 失效，下次发送时重新上传
+func (r *Registry) Invalidate(name string) error {
+	r.mu.Lock()
+	delete(r.cache, name)
+	r.mu.Unlock()
+
+	return r.db.DeleteMediaFileID(r.botID, name)
+}
+
+// Send 发送素材，优先使用已缓存的 file_id
+func (r *Registry) Send(chatID int64, name, caption string) (tgbotapi.Message, error) {
+	r.mu.RLock()
+	asset, ok := r.assets[name]
+	r.mu.RUnlock()
+	if !ok {
+		return tgbotapi.Message{}, fmt.Errorf("未注册的素材: %s", name)
+	}
+
+	fileID, err := r.fileID(name)
+	if err != nil {
+		log.Printf("⚠️ 读取素材 %s 的 file_id 失败: %v", name, err)
+	}
+
+	if fileID != "" {
+		msg, err := r.client.Send(buildMedia(asset.Kind, chatID, tgbotapi.FileID(fileID), caption))
+		if err == nil {
+			return msg, nil
+		}
+		if !isInvalidFileError(err) {
+			return msg, err
+		}
+
+		log.Printf("🔄 素材 %s 的 file_id 已失效，重新上传", name)
+		if err := r.Invalidate(name); err != nil {
+			log.Printf("⚠️ 清除素材 %s 的 file_id 失败: %v", name, err)
+		}
+	}
+
+	return r.upload(asset, chatID, caption)
+}
+
+// fileID 依次从内存和数据库中查找 file_id
+func (r *Registry) fileID(name string) (string, error) {
+	r.mu.RLock()
+	fileID, ok := r.cache[name]
+	r.mu.RUnlock()
+	if ok {
+		return fileID, nil
+	}
+
+	fileID, found, err := r.db.GetMediaFileID(r.botID, name)
+	if err != nil || !found {
+		return "", err
+	}
+
+	r.mu.Lock()
+	r.cache[name] = fileID
+	r.mu.Unlock()
+	return fileID, nil
+}
+
+// upload 上传素材并记录返回的 file_id
+func (r *Registry) upload(asset Asset, chatID int64, caption string) (tgbotapi.Message, error) {
+	var file tgbotapi.RequestFileData = tgbotapi.FilePath(asset.Path)
+	if len(asset.Data) > 0 {
+		file = tgbotapi.FileBytes{Name: asset.Name, Bytes: asset.Data}
+	}
+
+	msg, err := r.client.Send(buildMedia(asset.Kind, chatID, file, caption))
+	if err != nil {
+		return msg, fmt.Errorf("上传素材 %s 失败: %v", asset.Name, err)
+	}
+
+	fileID := extractFileID(asset.Kind, msg)
+	if fileID == "" {
+		return msg, nil
+	}
+
+	r.mu.Lock()
+	r.cache[asset.Name] = fileID
+	r.mu.Unlock()
+
+	if err := r.db.SaveMediaFileID(r.botID, asset.Name, fileID); err != nil {
+		log.Printf("⚠️ 保存素材 %s 的 file_id 失败: %v", asset.Name, err)
+	}
+	return msg, nil
+}
+
+// buildMedia 根据素材类型构建发送请求
+func buildMedia(kind Kind, chatID int64, file tgbotapi.RequestFileData, caption string) tgbotapi.Chattable {
+	switch kind {
+	case KindSticker:
+		return tgbotapi.NewSticker(chatID, file)
+	case KindAnimation:
+		cfg := tgbotapi.NewAnimation(chatID, file)
+		cfg.Caption = caption
+		return cfg
+	case KindDocument:
+		cfg := tgbotapi.NewDocument(chatID, file)
+		cfg.Caption = caption
+		return cfg
+	default:
+		cfg := tgbotapi.NewPhoto(chatID, file)
+		cfg.Caption = caption
+		return cfg
+	}
+}
+
+// extractFileID 从发送结果中取出 file_id
+func extractFileID(kind Kind, msg tgbotapi.Message) string {
+	switch kind {
+	case KindSticker:
+		if msg.Sticker != nil {
+			return msg.Sticker.FileID
+		}
+	case KindAnimation:
+		if msg.Animation != nil {
+			return msg.Animation.FileID
+		}
+	case KindDocument:
+		if msg.Document != nil {
+			return msg.Document.FileID
+		}
+	default:
+		// 取最大尺寸的图片
+		if len(msg.Photo) > 0 {
+			return msg.Photo[len(msg.Photo)-1].FileID
+		}
+	}
+	return ""
+}
+
+// isInvalidFileError 判断是否为 file_id 失效类错误
+func isInvalidFileError(err error) bool {
+	text := strings.ToLower(err.Error())
+	return strings.Contains(text, "wrong file identifier") ||
+		strings.Contains(text, "file reference") ||
+		strings.Contains(text, "wrong remote file")
+}
@@ -0,0 +1,412 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/monitor"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ErrAborted 中间件已向用户回复并终止处理
+var ErrAborted = errors.New("请求已被中间件拦截")
+
+// Context 一次命令或回调的处理上下文
+type Context struct {
+	Update *tgbotapi.Update
+	Client telegram.Client
+
+	ChatID  int64
+	UserID  int64
+	Command string // 命令名（不含 /）或回调前缀
+	Args    string // 命令参数或完整回调数据
+	From    *tgbotapi.User
+	User    *models.User // 由 EnsureUser 填充
+	Lang    string       // 回复使用的语言，由 WithLanguage 填充，为空时使用默认语言
+}
+
+// T 以请求的语言渲染消息目录中的消息
+func (c *Context) T(key string, data interface{}) string {
+	return ui.T(c.Lang, key, data)
+}
+
+// IsCallback 是否为回调查询
+func (c *Context) IsCallback() bool {
+	return c.Update.CallbackQuery != nil
+}
+
+// Reply 回复用户：回调以弹窗提示，命令以消息回复
+func (c *Context) Reply(text string) error {
+	if c.IsCallback() {
+		_, err := c.Client.Request(tgbotapi.NewCallbackWithAlert(c.Update.CallbackQuery.ID, text))
+		return err
+	}
+
+	msg := tgbotapi.NewMessage(c.ChatID, text)
+	if c.Update.Message != nil {
+		msg.ReplyToMessageID = c.Update.Message.MessageID
+	}
+	_, err := c.Client.Send(msg)
+	return err
+}
+
+// abort 回复用户并终止后续处理
+func (c *Context) abort(text string) error {
+	if err := c.Reply(text); err != nil {
+		log.Printf("⚠️ 发送拦截提示失败: %v", err)
+	}
+	return ErrAborted
+}
+
+// HandlerFunc 命令或回调处理函数，只包含业务逻辑
+type HandlerFunc func(ctx *Context) error
+
+// Middleware 包装处理函数以处理横切逻辑
+type Middleware func(next HandlerFunc) HandlerFunc
+
+// Chain 按顺序组合中间件，第一个中间件位于最外层
+func Chain(handler HandlerFunc, middlewares ...Middleware) HandlerFunc {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+	return handler
+}
+
+// Recover 捕获处理函数中的 panic，避免单个请求导致机器人退出
+func Recover() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					log.Printf("❌ 处理 %s 时发生panic: %v\n%s", ctx.Command, r, debug.Stack())
+					err = fmt.Errorf("处理请求时发生panic: %v", r)
+				}
+			}()
+			return next(ctx)
+		}
+	}
+}
+
+// Logging 记录请求及处理耗时
+func Logging() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			start := time.Now()
+			err := next(ctx)
+			if err != nil && !errors.Is(err, ErrAborted) {
+				log.Printf("❌ 用户 %d 在群组 %d 执行 %s 失败 (%v): %v", ctx.UserID, ctx.ChatID, ctx.Command, time.Since(start), err)
+			} else {
+				log.Printf("📨 用户 %d 在群组 %d 执行 %s (%v)", ctx.UserID, ctx.ChatID, ctx.Command, time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// Metrics 将处理耗时和结果记录到性能监控
+func Metrics(pm *monitor.PerformanceMonitor) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			start := time.Now()
+			err := next(ctx)
+			pm.RecordRequest(ctx.Command, time.Since(start), err == nil || errors.Is(err, ErrAborted))
+			return err
+		}
+	}
+}
+
+// UserLoader 获取或创建发起请求的用户
+type UserLoader func(from *tgbotapi.User) (*models.User, error)
+
+// EnsureUser 确保用户存在并填充到上下文
+func EnsureUser(load UserLoader) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if ctx.From == nil {
+				return next(ctx)
+			}
+			user, err := load(ctx.From)
+			if err != nil {
+				return fmt.Errorf("获取用户失败: %v", err)
+			}
+			ctx.User = user
+			return next(ctx)
+		}
+	}
+}
+
+// LanguageResolver 确定回复使用的语言：群组中为群组语言，私聊中为用户客户端语言
+type LanguageResolver func(chatID int64, from *tgbotapi.User) (string, error)
+
+// WithLanguage 确定请求的语言并填充到上下文，失败时记录日志并使用默认语言
+func WithLanguage(resolve LanguageResolver) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			lang, err := resolve(ctx.ChatID, ctx.From)
+			if err != nil {
+				log.Printf("⚠️ 获取群组 %d 语言失败: %v", ctx.ChatID, err)
+				lang = ui.DefaultLanguage
+			}
+			ctx.Lang = lang
+			return next(ctx)
+		}
+	}
+}
+
+// BanLookup 获取用户的封禁记录，未被封禁时返回 nil
+type BanLookup func(userID int64) (*models.UserBan, error)
+
+// BanCheck 拦截被封禁用户的请求，并礼貌地告知封禁原因，成员变更不受限制
+func BanCheck(lookup BanLookup) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if ctx.Update.MyChatMember != nil {
+				return next(ctx)
+			}
+			ban, err := lookup(ctx.UserID)
+			if err != nil {
+				return fmt.Errorf("检查封禁状态失败: %v", err)
+			}
+			if ban != nil {
+				return ctx.abort(ctx.T("access.banned", ui.Args{"Reason": ban.Reason}))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// AdminOnly 仅允许配置中的管理员使用
+func AdminOnly(adminIDs []int64) Middleware {
+	admins := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if !admins[ctx.UserID] {
+				return ctx.abort(ctx.T("access.admin_only", nil))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// ChatAdminOnly 仅允许群组创建者和管理员使用，私聊中不可用
+func ChatAdminOnly() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if ctx.ChatID >= 0 {
+				return ctx.abort(ctx.T("access.group_only", nil))
+			}
+
+			member, err := ctx.Client.GetChatMember(tgbotapi.GetChatMemberConfig{
+				ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: ctx.ChatID, UserID: ctx.UserID},
+			})
+			if err != nil {
+				return fmt.Errorf("获取群成员信息失败: %v", err)
+			}
+			if !member.IsCreator() && !member.IsAdministrator() {
+				return ctx.abort(ctx.T("access.chat_admin", nil))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// ChatChecker 判断群组是否启用了机器人
+type ChatChecker func(chatID int64) (bool, error)
+
+// ChatEnabled 拦截未启用机器人的群组中的请求
+func ChatEnabled(isEnabled ChatChecker) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			enabled, err := isEnabled(ctx.ChatID)
+			if err != nil {
+				return fmt.Errorf("检查群组状态失败: %v", err)
+			}
+			if !enabled {
+				return ctx.abort(ctx.T("access.chat_setup", nil))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// ShardChecker 判断当前进程是否负责处理该群组
+type ShardChecker func(chatID int64) bool
+
+// ShardOwner 多进程分片部署时只处理本进程负责的群组，其他群组的请求由对应进程处理，这里静默忽略
+func ShardOwner(owns ShardChecker) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if !owns(ctx.ChatID) {
+				return ErrAborted
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// CapacityChecker 在服务容量内接纳群组，超出上限时返回等待队列中的位置
+type CapacityChecker func(chatID int64) (admitted bool, position int, err error)
+
+// Capacity 容量模式下拦截未获得服务名额的群组，回复排队位置，私聊和成员变更不受限制
+func Capacity(admit CapacityChecker) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if ctx.ChatID >= 0 || ctx.Update.MyChatMember != nil {
+				return next(ctx)
+			}
+			admitted, position, err := admit(ctx.ChatID)
+			if err != nil {
+				return fmt.Errorf("检查群组服务名额失败: %v", err)
+			}
+			if !admitted {
+				return ctx.abort(ui.WaitlistMessage(position))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// ChatReleaser 释放群组的服务名额，返回等待队列中最早的群组ID（无则为0）
+type ChatReleaser func(chatID int64) (int64, error)
+
+// ReleaseOnLeave 机器人离开或被移出群组时释放服务名额，并通知等待队列中最早的群组
+func ReleaseOnLeave(release ChatReleaser) HandlerFunc {
+	return func(ctx *Context) error {
+		member := ctx.Update.MyChatMember.NewChatMember
+		if !member.HasLeft() && !member.WasKicked() {
+			return nil
+		}
+		nextChatID, err := release(ctx.ChatID)
+		if err != nil {
+			return fmt.Errorf("释放群组服务名额失败: %v", err)
+		}
+		if nextChatID == 0 {
+			return nil
+		}
+		if _, err := ctx.Client.Send(tgbotapi.NewMessage(nextChatID, ui.WaitlistAdmittedMessage())); err != nil {
+			log.Printf("⚠️ 通知群组 %d 获得服务名额失败: %v", nextChatID, err)
+		}
+		return nil
+	}
+}
+
+// AttributionRecorder 记录用户首次来源，已有归因时不覆盖
+type AttributionRecorder func(userID, firstChatID int64, source string) error
+
+// Attribution 用户的任意命令或回调处理成功（用户已创建）后记录首次来源：/start 的深度链接参数，
+// 没有时按首次接触的聊天类型；群组中首次接触时同时记录该群组。EnsureUser 已读到归因的用户不再写库
+func Attribution(record AttributionRecorder) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if err := next(ctx); err != nil {
+				return err
+			}
+			if ctx.UserID == 0 || ctx.Update.MyChatMember != nil {
+				return nil
+			}
+			if ctx.User != nil && (ctx.User.Source != "" || ctx.User.FirstChatID != 0) {
+				return nil
+			}
+			var startParam string
+			if ctx.Command == "start" && !ctx.IsCallback() {
+				startParam = ctx.Args
+			}
+			var firstChatID int64
+			if ctx.ChatID < 0 {
+				firstChatID = ctx.ChatID
+			}
+			if err := record(ctx.UserID, firstChatID, utils.AcquisitionSource(startParam, ctx.ChatID)); err != nil {
+				log.Printf("⚠️ 记录用户 %d 来源失败: %v", ctx.UserID, err)
+			}
+			return nil
+		}
+	}
+}
+
+// EligibilityChecker 判断用户是否满足在群组中下注的条件，不满足时返回拒绝提示
+type EligibilityChecker func(chatID int64, from *tgbotapi.User) (denial string, err error)
+
+// Eligible 拦截不满足群组下注资格的用户发起或加入对局，私聊中不检查
+func Eligible(check EligibilityChecker) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			if ctx.ChatID >= 0 || ctx.From == nil {
+				return next(ctx)
+			}
+			denial, err := check(ctx.ChatID, ctx.From)
+			if err != nil {
+				return fmt.Errorf("检查下注资格失败: %v", err)
+			}
+			if denial != "" {
+				return ctx.abort(denial)
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// RateLimit 按用户限制请求频率：window 时间内最多 limit 次
+func RateLimit(limit int, window time.Duration) Middleware {
+	var mu sync.Mutex
+	requests := make(map[int64][]time.Time)
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			now := time.Now()
+
+			mu.Lock()
+			recent := requests[ctx.UserID][:0]
+			for _, t := range requests[ctx.UserID] {
+				if now.Sub(t) < window {
+					recent = append(recent, t)
+				}
+			}
+			allowed := len(recent) < limit
+			if allowed {
+				recent = append(recent, now)
+			}
+			if len(recent) == 0 {
+				delete(requests, ctx.UserID)
+			} else {
+				requests[ctx.UserID] = recent
+			}
+			mu.Unlock()
+
+			if !allowed {
+				return ctx.abort(ctx.T("access.rate_limited", nil))
+			}
+			return next(ctx)
+		}
+	}
+}
+
+// PrivateOnly 仅允许在私聊中使用，群组内提示用户私聊机器人
+func PrivateOnly() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(ctx *Context) error {
+			var chat *tgbotapi.Chat
+			if ctx.Update.Message != nil {
+				chat = ctx.Update.Message.Chat
+			} else if ctx.Update.CallbackQuery != nil && ctx.Update.CallbackQuery.Message != nil {
+				chat = ctx.Update.CallbackQuery.Message.Chat
+			}
+			if chat == nil || !chat.IsPrivate() {
+				return ctx.abort(ctx.T("access.private_only", nil))
+			}
+			return next(ctx)
+		}
+	}
+}
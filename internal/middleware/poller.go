@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"log"
+	"sync"
+
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DefaultPollTimeout 长轮询的超时时间（秒）
+const DefaultPollTimeout = 60
+
+// DefaultMaxConcurrent 同时处理的更新数上限，开骰动画等耗时的处理不会阻塞其他群组
+const DefaultMaxConcurrent = 64
+
+// Observer 在分发前查看每条更新，如记录群组语言、入群时间
+type Observer func(update *tgbotapi.Update)
+
+// UpdateSource 长轮询以外的更新来源，如分片部署时的共享更新队列
+type UpdateSource interface {
+	// Updates 开始接收更新，Stop 后关闭返回的通道
+	Updates() <-chan tgbotapi.Update
+	// Done 更新处理完毕（无论成功与否）
+	Done(updateID int) error
+	// Stop 停止接收
+	Stop()
+}
+
+// Poller 以长轮询拉取更新并并发分发到路由器，不支持的更新（如频道消息）直接回应
+type Poller struct {
+	client    telegram.Client
+	router    *Router
+	timeout   int
+	observers []Observer
+	tracker   *telegram.UpdateTracker
+	source    UpdateSource
+
+	sem  chan struct{}
+	wg   sync.WaitGroup
+	done chan struct{}
+}
+
+// NewPoller 创建长轮询分发器
+func NewPoller(client telegram.Client, router *Router) *Poller {
+	return &Poller{
+		client:  client,
+		router:  router,
+		timeout: DefaultPollTimeout,
+		sem:     make(chan struct{}, DefaultMaxConcurrent),
+		done:    make(chan struct{}),
+	}
+}
+
+// Observe 添加在分发前查看每条更新的观察者
+func (p *Poller) Observe(observer Observer) {
+	p.observers = append(p.observers, observer)
+}
+
+// SetUpdateTracker 设置更新跟踪器：从上次处理完的 update_id 继续拉取，跳过重复投递的更新，
+// 每条更新处理完后推进持久化的偏移量
+func (p *Poller) SetUpdateTracker(tracker *telegram.UpdateTracker) {
+	p.tracker = tracker
+}
+
+// SetSource 改为从指定来源接收更新，不再直接长轮询，更新跟踪器随之失效
+func (p *Poller) SetSource(source UpdateSource) {
+	p.source = source
+}
+
+// Start 开始拉取并分发更新
+func (p *Poller) Start() {
+	if p.source != nil {
+		go p.run(p.source.Updates())
+		return
+	}
+	go p.run(p.client.GetUpdatesChan(p.updateConfig()))
+}
+
+// Stop 停止拉取，等待已接收的更新处理完（偏移量已推进）后返回
+func (p *Poller) Stop() {
+	if p.source != nil {
+		p.source.Stop()
+	} else {
+		p.client.StopReceivingUpdates()
+	}
+	<-p.done
+}
+
+// updateConfig 长轮询配置，设置了更新跟踪器时从持久化的偏移量开始
+func (p *Poller) updateConfig() tgbotapi.UpdateConfig {
+	if p.tracker != nil {
+		return p.tracker.UpdateConfig(p.timeout)
+	}
+	config := tgbotapi.NewUpdate(0)
+	config.Timeout = p.timeout
+	return config
+}
+
+// run 逐条接收更新，每条在独立的协程中处理
+func (p *Poller) run(updates <-chan tgbotapi.Update) {
+	defer close(p.done)
+
+	for update := range updates {
+		if p.source == nil && p.tracker != nil && !p.tracker.Accept(update) {
+			continue
+		}
+		p.sem <- struct{}{}
+		p.wg.Add(1)
+		go func(update tgbotapi.Update) {
+			defer func() {
+				<-p.sem
+				p.wg.Done()
+			}()
+			defer p.markProcessed(update.UpdateID)
+			p.handle(&update)
+		}(update)
+	}
+	p.wg.Wait()
+}
+
+// markProcessed 推进已处理的偏移量，处理失败的更新同样推进，避免重启后反复处理
+func (p *Poller) markProcessed(updateID int) {
+	if p.source != nil {
+		if err := p.source.Done(updateID); err != nil {
+			log.Printf("⚠️ 标记更新 %d 已处理失败: %v", updateID, err)
+		}
+		return
+	}
+	if p.tracker == nil {
+		return
+	}
+	if err := p.tracker.MarkProcessed(updateID); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// handle 处理单条更新
+func (p *Poller) handle(update *tgbotapi.Update) {
+	for _, observe := range p.observers {
+		observe(update)
+	}
+
+	if !telegram.IsSupported(update) {
+		if response := telegram.UnsupportedResponse(update); response != nil {
+			if _, err := p.client.Request(response); err != nil {
+				log.Printf("⚠️ 回应不支持的更新 %d 失败: %v", update.UpdateID, err)
+			}
+		}
+		return
+	}
+
+	if _, err := p.router.Dispatch(update); err != nil {
+		log.Printf("❌ 处理更新 %d 失败: %v", update.UpdateID, err)
+	}
+}
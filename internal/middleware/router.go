@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"errors"
+	"strings"
+
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Router 将命令和回调分发到处理函数，并统一套用中间件
+type Router struct {
+	client      telegram.Client
+	middlewares []Middleware
+	commands    map[string]route
+	callbacks   map[string]route
+	prefixes    []string
+	membership  *route
+}
+
+// route 注册的处理函数及仅作用于它的中间件，全局中间件在分发时套用
+type route struct {
+	handler     HandlerFunc
+	middlewares []Middleware
+}
+
+// NewRouter 创建路由器，middlewares 按顺序作用于所有处理函数
+func NewRouter(client telegram.Client, middlewares ...Middleware) *Router {
+	return &Router{
+		client:      client,
+		middlewares: middlewares,
+		commands:    make(map[string]route),
+		callbacks:   make(map[string]route),
+	}
+}
+
+// Use 追加作用于所有处理函数的中间件，无论处理函数在此之前还是之后注册，全局中间件都按添加顺序位于外层
+func (r *Router) Use(middlewares ...Middleware) {
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+// Handle 注册命令处理函数，command 不含 /，可附加仅作用于该命令的中间件
+func (r *Router) Handle(command string, handler HandlerFunc, middlewares ...Middleware) {
+	r.commands[command] = route{handler: handler, middlewares: middlewares}
+}
+
+// HandleCallback 注册回调处理函数，按回调数据前缀匹配（最长前缀优先）
+func (r *Router) HandleCallback(prefix string, handler HandlerFunc, middlewares ...Middleware) {
+	if _, exists := r.callbacks[prefix]; !exists {
+		r.prefixes = append(r.prefixes, prefix)
+	}
+	r.callbacks[prefix] = route{handler: handler, middlewares: middlewares}
+}
+
+// HandleMembership 注册机器人自身成员状态变更（被拉入、移出群组）的处理函数
+func (r *Router) HandleMembership(handler HandlerFunc, middlewares ...Middleware) {
+	r.membership = &route{handler: handler, middlewares: middlewares}
+}
+
+// chain 以全局中间件在外、路由中间件在内的顺序包装处理函数
+func (r *Router) chain(rt route) HandlerFunc {
+	middlewares := make([]Middleware, 0, len(r.middlewares)+len(rt.middlewares))
+	middlewares = append(append(middlewares, r.middlewares...), rt.middlewares...)
+	return Chain(rt.handler, middlewares...)
+}
+
+// Dispatch 分发更新，未注册的命令或回调返回 handled=false
+func (r *Router) Dispatch(update *tgbotapi.Update) (handled bool, err error) {
+	ctx, handler := r.match(update)
+	if handler == nil {
+		return false, nil
+	}
+
+	err = handler(ctx)
+	if errors.Is(err, ErrAborted) {
+		return true, nil
+	}
+	return true, err
+}
+
+// match 查找更新对应的处理函数
+func (r *Router) match(update *tgbotapi.Update) (*Context, HandlerFunc) {
+	if update == nil {
+		return nil, nil
+	}
+
+	if msg := update.Message; msg != nil && msg.Chat != nil && msg.IsCommand() {
+		rt, ok := r.commands[msg.Command()]
+		if !ok {
+			return nil, nil
+		}
+		return &Context{
+			Update:  update,
+			Client:  r.client,
+			ChatID:  msg.Chat.ID,
+			UserID:  senderID(msg.From),
+			Command: msg.Command(),
+			Args:    msg.CommandArguments(),
+			From:    msg.From,
+		}, r.chain(rt)
+	}
+
+	if query := update.CallbackQuery; query != nil && query.Message != nil && query.Message.Chat != nil {
+		prefix := r.matchPrefix(query.Data)
+		if prefix == "" {
+			return nil, nil
+		}
+		return &Context{
+			Update:  update,
+			Client:  r.client,
+			ChatID:  query.Message.Chat.ID,
+			UserID:  senderID(query.From),
+			Command: prefix,
+			Args:    query.Data,
+			From:    query.From,
+		}, r.chain(r.callbacks[prefix])
+	}
+
+	if member := update.MyChatMember; member != nil && r.membership != nil {
+		return &Context{
+			Update:  update,
+			Client:  r.client,
+			ChatID:  member.Chat.ID,
+			UserID:  member.From.ID,
+			Command: "my_chat_member",
+			From:    &member.From,
+		}, r.chain(*r.membership)
+	}
+
+	return nil, nil
+}
+
+// matchPrefix 返回与回调数据匹配的最长前缀
+func (r *Router) matchPrefix(data string) string {
+	best := ""
+	for _, prefix := range r.prefixes {
+		if strings.HasPrefix(data, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	return best
+}
+
+// Commands 返回已注册的命令列表
+func (r *Router) Commands() []string {
+	commands := make([]string, 0, len(r.commands))
+	for command := range r.commands {
+		commands = append(commands, command)
+	}
+	return commands
+}
+
+func senderID(user *tgbotapi.User) int64 {
+	if user == nil {
+		return 0
+	}
+	return user.ID
+}
@@ -0,0 +1,484 @@
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/utils"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// Check 一项迁移前后的不变量检查
+type Check struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// Report 迁移校验报告
+type Report struct {
+	Source       string
+	AddedTables  []string
+	AddedColumns []string // 表名.字段名
+	AddedIndexes []string
+	Checks       []Check
+}
+
+// OK 迁移成功且全部检查通过
+func (r *Report) OK() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// String 可读的校验报告
+func (r *Report) String() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("🔍 迁移校验：%s\n\n", r.Source))
+	writeList := func(title string, items []string) {
+		b.WriteString(fmt.Sprintf("%s（%d）\n", title, len(items)))
+		for _, item := range items {
+			b.WriteString(fmt.Sprintf("  + %s\n", item))
+		}
+	}
+	writeList("新增表", r.AddedTables)
+	writeList("新增字段", r.AddedColumns)
+	writeList("新增索引", r.AddedIndexes)
+
+	b.WriteString("\n不变量检查\n")
+	for _, check := range r.Checks {
+		mark := "✅"
+		if !check.Passed {
+			mark = "❌"
+		}
+		b.WriteString(fmt.Sprintf("  %s %s：%s\n", mark, check.Name, check.Detail))
+	}
+	if r.OK() {
+		b.WriteString("\n✅ 迁移校验通过")
+	} else {
+		b.WriteString("\n❌ 迁移校验未通过，请勿直接升级")
+	}
+	return b.String()
+}
+
+// Verify 在副本上校验迁移：source 为现有的 .db 文件或 .sql 结构快照，原文件不会被修改。
+// 依次记录迁移前的结构和数据、执行启动时的建表与迁移、再对比迁移后的结构并检查不变量
+func Verify(source string) (*Report, error) {
+	dir, err := os.MkdirTemp("", "dice-bot-migration-")
+	if err != nil {
+		return nil, fmt.Errorf("创建临时目录失败: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "verify.db")
+	if err := prepareCopy(source, path); err != nil {
+		return nil, err
+	}
+
+	before, err := inspect(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移前的数据库失败: %v", err)
+	}
+
+	report := &Report{Source: source}
+	db, err := database.Init(path)
+	if err != nil {
+		report.Checks = append(report.Checks, Check{Name: "执行迁移", Passed: false, Detail: err.Error()})
+		return report, nil
+	}
+	db.Close()
+	report.Checks = append(report.Checks, Check{Name: "执行迁移", Passed: true, Detail: "建表、字段迁移和索引均已执行"})
+
+	after, err := inspect(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取迁移后的数据库失败: %v", err)
+	}
+
+	report.AddedTables, report.AddedColumns, report.AddedIndexes = before.diff(after)
+	report.Checks = append(report.Checks,
+		checkIntegrity(after),
+		checkForeignKeys(before, after),
+		checkBalances(before, after),
+		checkLedger(before, after),
+		checkGames(before, after),
+	)
+	return report, nil
+}
+
+// prepareCopy 将 .db 文件（连同 WAL 文件）复制到 path，或在 path 上执行 .sql 结构快照
+func prepareCopy(source, path string) error {
+	if strings.EqualFold(filepath.Ext(source), ".sql") {
+		script, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("读取结构快照失败: %v", err)
+		}
+		conn, err := sql.Open("sqlite3", path)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		if _, err := conn.Exec(string(script)); err != nil {
+			return fmt.Errorf("加载结构快照失败: %v", err)
+		}
+		return nil
+	}
+
+	if err := copyFile(source, path); err != nil {
+		return fmt.Errorf("复制数据库失败: %v", err)
+	}
+	if _, err := os.Stat(source + "-wal"); err == nil {
+		if err := copyFile(source+"-wal", path+"-wal"); err != nil {
+			return fmt.Errorf("复制 WAL 文件失败: %v", err)
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// snapshot 数据库结构和用于不变量检查的数据
+type snapshot struct {
+	tables      map[string]map[string]bool // 表名 -> 字段集合
+	indexes     map[string]bool
+	integrity   string
+	fkViolation map[string]int  // 表名 -> 外键违例数
+	balances    map[int64]int64 // 用户 -> 现金余额 + 赠送余额，统一换算为基本单位
+	ledger      map[int64]int64 // 用户 -> 交易流水合计，统一换算为基本单位
+	games       map[string]int  // 对局状态 -> 数量
+}
+
+// inspect 读取数据库的结构和数据快照
+func inspect(path string) (*snapshot, error) {
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	s := &snapshot{
+		tables:      make(map[string]map[string]bool),
+		indexes:     make(map[string]bool),
+		fkViolation: make(map[string]int),
+		balances:    make(map[int64]int64),
+		ledger:      make(map[int64]int64),
+		games:       make(map[string]int),
+	}
+
+	rows, err := conn.Query(`SELECT type, name FROM sqlite_master WHERE name NOT LIKE 'sqlite_%' AND type IN ('table', 'index')`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if kind == "table" {
+			tables = append(tables, name)
+		} else {
+			s.indexes[name] = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, table := range tables {
+		columns, err := tableColumns(conn, table)
+		if err != nil {
+			return nil, err
+		}
+		s.tables[table] = columns
+	}
+
+	if err := conn.QueryRow(`PRAGMA integrity_check`).Scan(&s.integrity); err != nil {
+		return nil, err
+	}
+	if err := s.loadForeignKeyViolations(conn); err != nil {
+		return nil, err
+	}
+	if err := s.loadBalances(conn); err != nil {
+		return nil, err
+	}
+	s.scaleAmounts(conn)
+	return s, nil
+}
+
+func tableColumns(conn *sql.DB, table string) (map[string]bool, error) {
+	rows, err := conn.Query(fmt.Sprintf(`PRAGMA table_info("%s")`, strings.ReplaceAll(table, `"`, `""`)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, kind string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &kind, &notNull, &defaultValue, &pk); err != nil {
+			return nil, err
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
+
+func (s *snapshot) loadForeignKeyViolations(conn *sql.DB) error {
+	rows, err := conn.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fkID int
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return err
+		}
+		s.fkViolation[table+" -> "+parent]++
+	}
+	return rows.Err()
+}
+
+// loadBalances 读取用户余额、交易流水合计和对局数，旧版本缺少的表或字段按空值处理
+func (s *snapshot) loadBalances(conn *sql.DB) error {
+	if users, ok := s.tables["users"]; ok {
+		query := `SELECT id, balance FROM users`
+		if users["bonus_balance"] {
+			query = `SELECT id, balance + COALESCE(bonus_balance, 0) FROM users`
+		}
+		if err := scanTotals(conn, query, s.balances); err != nil {
+			return err
+		}
+	}
+	if _, ok := s.tables["transactions"]; ok {
+		if err := scanTotals(conn, `SELECT user_id, SUM(amount) FROM transactions WHERE user_id != 0 GROUP BY user_id`, s.ledger); err != nil {
+			return err
+		}
+	}
+	if _, ok := s.tables["games"]; ok {
+		rows, err := conn.Query(`SELECT status, COUNT(*) FROM games GROUP BY status`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var status string
+			var count int
+			if err := rows.Scan(&status, &count); err != nil {
+				return err
+			}
+			s.games[status] = count
+		}
+		return rows.Err()
+	}
+	return nil
+}
+
+// scaleAmounts 旧版本按整数金币记账，换算为基本单位后再与迁移后的金额比较
+func (s *snapshot) scaleAmounts(conn *sql.DB) {
+	if _, ok := s.tables["bot_settings"]; ok {
+		var value string
+		err := conn.QueryRow(`SELECT value FROM bot_settings WHERE key = ?`, database.AmountScaleSettingKey).Scan(&value)
+		if err == nil {
+			return
+		}
+	}
+	for id := range s.balances {
+		s.balances[id] *= utils.AmountScale
+	}
+	for id := range s.ledger {
+		s.ledger[id] *= utils.AmountScale
+	}
+}
+
+func scanTotals(conn *sql.DB, query string, totals map[int64]int64) error {
+	rows, err := conn.Query(query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, amount int64
+		if err := rows.Scan(&id, &amount); err != nil {
+			return err
+		}
+		totals[id] = amount
+	}
+	return rows.Err()
+}
+
+// diff 迁移后新增的表、字段和索引；迁移只会新增，不会删除
+func (s *snapshot) diff(after *snapshot) (tables, columns, indexes []string) {
+	for table, afterColumns := range after.tables {
+		beforeColumns, ok := s.tables[table]
+		if !ok {
+			tables = append(tables, table)
+			continue
+		}
+		for column := range afterColumns {
+			if !beforeColumns[column] {
+				columns = append(columns, table+"."+column)
+			}
+		}
+	}
+	for index := range after.indexes {
+		if !s.indexes[index] {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Strings(tables)
+	sort.Strings(columns)
+	sort.Strings(indexes)
+	return tables, columns, indexes
+}
+
+// checkIntegrity 迁移后的数据库文件完整
+func checkIntegrity(after *snapshot) Check {
+	return Check{Name: "完整性", Passed: after.integrity == "ok", Detail: after.integrity}
+}
+
+// checkForeignKeys 迁移不能新增外键违例（系统账户等历史数据中已有的违例只做提示）
+func checkForeignKeys(before, after *snapshot) Check {
+	var added []string
+	total := 0
+	for key, count := range after.fkViolation {
+		total += count
+		if count > before.fkViolation[key] {
+			added = append(added, fmt.Sprintf("%s +%d", key, count-before.fkViolation[key]))
+		}
+	}
+	sort.Strings(added)
+	if len(added) > 0 {
+		return Check{Name: "外键", Passed: false, Detail: "新增违例：" + strings.Join(added, "，")}
+	}
+	return Check{Name: "外键", Passed: true, Detail: fmt.Sprintf("无新增违例（已有 %d 条）", total)}
+}
+
+// checkBalances 迁移前后每个用户的余额（含赠送余额）不变
+func checkBalances(before, after *snapshot) Check {
+	changed := diffTotals(before.balances, after.balances)
+	if len(changed) > 0 {
+		return Check{Name: "余额", Passed: false, Detail: "余额发生变化的用户：" + sample(changed)}
+	}
+	return Check{Name: "余额", Passed: true, Detail: fmt.Sprintf("%d 个用户的余额未变", len(after.balances))}
+}
+
+// checkLedger 重放交易流水：迁移后对不上余额的用户不能比迁移前多
+func checkLedger(before, after *snapshot) Check {
+	beforeMismatch := ledgerMismatches(before)
+	var added []int64
+	for _, userID := range ledgerMismatches(after) {
+		if !containsID(beforeMismatch, userID) {
+			added = append(added, userID)
+		}
+	}
+	if len(added) > 0 {
+		return Check{Name: "流水重放", Passed: false, Detail: "迁移后流水与余额不符的用户：" + sample(added)}
+	}
+	return Check{Name: "流水重放", Passed: true,
+		Detail: fmt.Sprintf("无新增不符（迁移前已有 %d 个用户流水与余额不符）", len(beforeMismatch))}
+}
+
+// checkGames 迁移前后各状态的对局数不变
+func checkGames(before, after *snapshot) Check {
+	var changed []string
+	for status, count := range after.games {
+		if before.games[status] != count {
+			changed = append(changed, fmt.Sprintf("%s %d -> %d", status, before.games[status], count))
+		}
+	}
+	for status, count := range before.games {
+		if _, ok := after.games[status]; !ok {
+			changed = append(changed, fmt.Sprintf("%s %d -> 0", status, count))
+		}
+	}
+	sort.Strings(changed)
+	if len(changed) > 0 {
+		return Check{Name: "对局", Passed: false, Detail: strings.Join(changed, "，")}
+	}
+	total := 0
+	for _, count := range after.games {
+		total += count
+	}
+	return Check{Name: "对局", Passed: true, Detail: fmt.Sprintf("%d 局对局状态未变", total)}
+}
+
+// ledgerMismatches 交易流水合计与余额不符的用户
+func ledgerMismatches(s *snapshot) []int64 {
+	var users []int64
+	for userID, balance := range s.balances {
+		if s.ledger[userID] != balance {
+			users = append(users, userID)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+	return users
+}
+
+// diffTotals 两份合计中取值不同的用户
+func diffTotals(before, after map[int64]int64) []int64 {
+	var users []int64
+	for userID, amount := range after {
+		if previous, ok := before[userID]; !ok || previous != amount {
+			users = append(users, userID)
+		}
+	}
+	for userID := range before {
+		if _, ok := after[userID]; !ok {
+			users = append(users, userID)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i] < users[j] })
+	return users
+}
+
+func containsID(ids []int64, id int64) bool {
+	i := sort.Search(len(ids), func(i int) bool { return ids[i] >= id })
+	return i < len(ids) && ids[i] == id
+}
+
+// sample 最多列出 10 个用户ID
+func sample(ids []int64) string {
+	parts := make([]string, 0, 10)
+	for i, id := range ids {
+		if i == 10 {
+			parts = append(parts, fmt.Sprintf("等 %d 个", len(ids)))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%d", id))
+	}
+	return strings.Join(parts, ", ")
+}
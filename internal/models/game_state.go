@@ -0,0 +1,96 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// GameState 对局状态机：状态只能沿 gameTransitions 中列出的方向转换
+type GameState string
+
+// gameTransitions 各状态允许转换到的状态，未列出的状态为终态
+var gameTransitions = map[GameState][]GameState{
+	GameStatusWaiting: {GameStatusPlaying, GameStatusReady, GameStatusExpired, GameStatusCancelled},
+	// 双方确认后开局；加入者未确认时撤销加入，恢复等待
+	GameStatusReady:   {GameStatusPlaying, GameStatusWaiting, GameStatusCancelled},
+	GameStatusPlaying: {GameStatusFinished, GameStatusSurrendered, GameStatusHeld, GameStatusCancelled},
+	// 审核通过后恢复进行中并结算，拒绝时取消退款
+	GameStatusHeld: {GameStatusPlaying, GameStatusCancelled},
+}
+
+// gameStates 全部有效的对局状态
+var gameStates = []GameState{
+	GameStatusWaiting, GameStatusReady, GameStatusPlaying, GameStatusHeld,
+	GameStatusFinished, GameStatusSurrendered, GameStatusExpired, GameStatusCancelled,
+}
+
+// Valid 是否为有效的对局状态
+func (s GameState) Valid() bool {
+	for _, state := range gameStates {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Terminal 是否为终态，终态的对局不能再变更状态
+func (s GameState) Terminal() bool {
+	return s.Valid() && len(gameTransitions[s]) == 0
+}
+
+// CanTransitionTo 能否转换到 to 状态，状态不变视为允许
+func (s GameState) CanTransitionTo(to GameState) bool {
+	if !s.Valid() || !to.Valid() {
+		return false
+	}
+	if s == to {
+		return true
+	}
+	for _, next := range gameTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// GameTransitionError 非法的对局状态转换
+type GameTransitionError struct {
+	From string
+	To   string
+}
+
+func (e *GameTransitionError) Error() string {
+	return fmt.Sprintf("对局状态不能从 %s 变更为 %s", e.From, e.To)
+}
+
+// ValidateGameTransition 校验对局状态转换，非法时返回 *GameTransitionError
+func ValidateGameTransition(from, to string) error {
+	if !GameState(from).CanTransitionTo(GameState(to)) {
+		return &GameTransitionError{From: from, To: to}
+	}
+	return nil
+}
+
+// GameStates 全部有效的对局状态
+func GameStates() []GameState {
+	return append([]GameState(nil), gameStates...)
+}
+
+// GameTransitions 全部允许的状态转换（不含状态不变），按起止状态排序
+func GameTransitions() [][2]GameState {
+	var transitions [][2]GameState
+	for from, targets := range gameTransitions {
+		for _, to := range targets {
+			transitions = append(transitions, [2]GameState{from, to})
+		}
+	}
+	sort.Slice(transitions, func(i, j int) bool {
+		if transitions[i][0] != transitions[j][0] {
+			return transitions[i][0] < transitions[j][0]
+		}
+		return transitions[i][1] < transitions[j][1]
+	})
+	return transitions
+}
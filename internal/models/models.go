@@ -6,13 +6,31 @@ import (
 
 // User 用户模型
 type User struct {
-	ID        int64     `json:"id" db:"id"`
-	Username  string    `json:"username" db:"username"`
-	FirstName string    `json:"first_name" db:"first_name"`
-	LastName  string    `json:"last_name" db:"last_name"`
-	Balance   int64     `json:"balance" db:"balance"` // 余额（以最小单位计算）
-	CreatedAt time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+	ID        int64  `json:"id" db:"id"`
+	Username  string `json:"username" db:"username"`
+	FirstName string `json:"first_name" db:"first_name"`
+	LastName  string `json:"last_name" db:"last_name"`
+	Balance   int64  `json:"balance" db:"balance"` // 余额（以最小单位计算）
+	// 赠送余额，完成流水要求后转入余额
+	BonusBalance int64 `json:"bonus_balance" db:"bonus_balance"`
+	// 来源归因：首次接触机器人的群组和深度链接参数
+	FirstChatID int64     `json:"first_chat_id" db:"first_chat_id"`
+	Source      string    `json:"source" db:"source"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+	// Telegram 账号已注销的时间，未注销时为 nil
+	DeletedAt *time.Time `json:"deleted_at,omitempty" db:"deleted_at"`
+}
+
+// DeletedUserName 已注销账号统一显示的名字
+const DeletedUserName = "已注销用户"
+
+// UserBan 管理员对用户的封禁记录
+type UserBan struct {
+	UserID   int64     `json:"user_id"`
+	Reason   string    `json:"reason"`
+	BannedBy string    `json:"banned_by"`
+	BannedAt time.Time `json:"banned_at"`
 }
 
 // Game 游戏模型
@@ -32,9 +50,92 @@ type Game struct {
 	Player2Dice3 *int      `json:"player2_dice3" db:"player2_dice3"`
 	WinnerID     *int64    `json:"winner_id" db:"winner_id"`
 	Commission   int64     `json:"commission" db:"commission"` // 平台抽水
+	ChatShare    int64     `json:"chat_share" db:"chat_share"` // 手续费中归入群组基金的部分
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
 	ChatID       int64     `json:"chat_id" db:"chat_id"` // 群组ID
+	// 发起者购买的下注保险：保险费及输局时的赔付额
+	InsurancePremium  int64 `json:"insurance_premium" db:"insurance_premium"`
+	InsuranceCoverage int64 `json:"insurance_coverage" db:"insurance_coverage"`
+	// 可验证公平：服务端种子在对局结束前保密，开局前公布其 SHA256 承诺哈希
+	ServerSeed string `json:"-" db:"server_seed"`
+	SeedHash   string `json:"seed_hash" db:"seed_hash"`
+}
+
+// Chat 机器人所在的群组
+type Chat struct {
+	ID       int64  `json:"id" db:"id"`
+	Title    string `json:"title" db:"title"`
+	Type     string `json:"type" db:"type"` // group, supergroup, private
+	Language string `json:"language" db:"language"`
+	// 语言由群管理员通过 /language 手动指定，不再自动检测
+	LanguageManual bool `json:"language_manual" db:"language_manual"`
+	// 是否允许对局中途认输并部分退款
+	SurrenderEnabled bool `json:"surrender_enabled" db:"surrender_enabled"`
+	// 是否播报奖池和险胜提示
+	JackpotAnnounce bool `json:"jackpot_announce" db:"jackpot_announce"`
+	// 手续费中归入本群基金的比例，0.5 表示 50%
+	RevenueShare float64 `json:"revenue_share" db:"revenue_share"`
+	// 群组基金余额，来自手续费分成
+	FundBalance int64 `json:"fund_balance" db:"fund_balance"`
+	// 由群组基金转入、留作比赛奖金的金额
+	PrizePool int64 `json:"prize_pool" db:"prize_pool"`
+	// 是否退出大奖频道转发
+	FeedOptOut bool `json:"feed_opt_out" db:"feed_opt_out"`
+	// 顺序模式：同一时间只进行一局，其余加入请求排队
+	SequentialGames bool `json:"sequential_games" db:"sequential_games"`
+	// 并行模式下同时进行的对局上限，0 表示不限
+	MaxActiveGames int `json:"max_active_games" db:"max_active_games"`
+	// 一局结束到下一局开始的最短间隔（秒），0 表示不限制
+	GameCooldown int `json:"game_cooldown" db:"game_cooldown"`
+	// 对手加入后需双方在限定时间内确认准备才开骰
+	ReadyCheck bool `json:"ready_check" db:"ready_check"`
+	// 长期无活动被标记为休眠，不再收到定时播报，群内有新消息时自动恢复
+	Dormant   bool      `json:"dormant" db:"dormant"`
+	JoinedAt  time.Time `json:"joined_at" db:"joined_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AcquisitionSource 用户来源统计
+type AcquisitionSource struct {
+	Source       string    `json:"source"`
+	FirstChatID  int64     `json:"first_chat_id"`
+	UserCount    int       `json:"user_count"`
+	LastSignupAt time.Time `json:"last_signup_at"`
+}
+
+// BonusCampaign 充值赠送活动
+type BonusCampaign struct {
+	ID                 int64     `json:"id" db:"id"`
+	Name               string    `json:"name" db:"name"`
+	Percent            float64   `json:"percent" db:"percent"`     // 赠送比例，10 表示 +10%
+	MaxBonus           int64     `json:"max_bonus" db:"max_bonus"` // 单次赠送上限，0 表示不限
+	FirstDepositOnly   bool      `json:"first_deposit_only" db:"first_deposit_only"`
+	WageringMultiplier float64   `json:"wagering_multiplier" db:"wagering_multiplier"` // 提现前需完成的流水倍数
+	StartsAt           time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt             time.Time `json:"ends_at" db:"ends_at"`
+	Active             bool      `json:"active" db:"active"`
+	CreatedAt          time.Time `json:"created_at" db:"created_at"`
+}
+
+// BonusWagering 赠送金额的流水要求
+type BonusWagering struct {
+	ID               int64      `json:"id" db:"id"`
+	UserID           int64      `json:"user_id" db:"user_id"`
+	CampaignID       int64      `json:"campaign_id" db:"campaign_id"`
+	BonusAmount      int64      `json:"bonus_amount" db:"bonus_amount"`
+	WageringRequired int64      `json:"wagering_required" db:"wagering_required"`
+	Wagered          int64      `json:"wagered" db:"wagered"`
+	Status           string     `json:"status" db:"status"` // active, completed
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	CompletedAt      *time.Time `json:"completed_at" db:"completed_at"`
+}
+
+// BonusProgress 赠送余额流水进度
+type BonusProgress struct {
+	BonusBalance     int64 `json:"bonus_balance"`
+	WageringRequired int64 `json:"wagering_required"`
+	Wagered          int64 `json:"wagered"`
 }
 
 // Transaction 交易记录
@@ -49,6 +150,26 @@ type Transaction struct {
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 }
 
+// TransactionFilter 交易记录筛选条件，零值字段表示不限制
+type TransactionFilter struct {
+	UserID    int64
+	GameID    string
+	Type      string
+	Memo      string // 按描述模糊匹配
+	MinAmount *int64
+	MaxAmount *int64
+	From      time.Time
+	To        time.Time
+}
+
+// TransactionTotals 筛选结果的汇总
+type TransactionTotals struct {
+	Count   int   `json:"count"`
+	Net     int64 `json:"net"`
+	Inflow  int64 `json:"inflow"`
+	Outflow int64 `json:"outflow"`
+}
+
 // GameStatus 游戏状态常量
 const (
 	GameStatusWaiting   = "waiting"
@@ -56,6 +177,43 @@ const (
 	GameStatusFinished  = "finished"
 	GameStatusCancelled = "cancelled"
 	GameStatusExpired   = "expired"
+	// 玩家在开骰前认输
+	GameStatusSurrendered = "surrendered"
+	// 骰子已开出，结算暂停等待管理员审核
+	GameStatusHeld = "held"
+	// 对手已加入，等待双方确认准备后开骰
+	GameStatusReady = "ready"
+)
+
+// GameAbort 对局被中止并退款的原因
+const (
+	// 开骰后超过最长时长仍未结算
+	GameAbortStalled = "stalled"
+	// 管理员在运营页面强制中止
+	GameAbortAdmin = "admin"
+	// 服务关闭前未能结算
+	GameAbortShutdown = "shutdown"
+)
+
+// TableStatus 快速桌状态常量
+const (
+	TableStatusOpen      = "open"
+	TableStatusFinished  = "finished"
+	TableStatusCancelled = "cancelled"
+)
+
+// TableMode 快速桌玩法常量
+const (
+	TableModeSplit  = "split"
+	TableModeBattle = "battle"
+)
+
+// TournamentStatus 锦标赛状态常量
+const (
+	TournamentStatusOpen      = "open"    // 报名中
+	TournamentStatusRunning   = "running" // 比赛中
+	TournamentStatusFinished  = "finished"
+	TournamentStatusCancelled = "cancelled"
 )
 
 // TransactionType 交易类型常量
@@ -66,4 +224,744 @@ const (
 	TransactionTypeDeposit    = "deposit"
 	TransactionTypeWithdraw   = "withdraw"
 	TransactionTypeRefund     = "refund"
+	TransactionTypeBonus      = "bonus"
+	// 赠送余额完成流水后转为可提现余额
+	TransactionTypeBonusConvert = "bonus_convert"
+	// 手续费中按群组分成比例归入群组基金的部分
+	TransactionTypeRevenueShare = "revenue_share"
+	// 群组基金空投给活跃玩家
+	TransactionTypeFundAirdrop = "fund_airdrop"
+	// 群组基金转入比赛奖池
+	TransactionTypeFundPrize = "fund_prize"
+	// 购买下注保险的保险费
+	TransactionTypeInsurance = "insurance"
+	// 投保对局输掉后的保险赔付
+	TransactionTypeInsurancePayout = "insurance_payout"
+	// 沙盒模式下领取的练习金币
+	TransactionTypeSandboxGrant = "sandbox_grant"
+	// 每日签到领取的免费金币
+	TransactionTypeDailyBonus = "daily_bonus"
+	// 提现申请被拒绝后退回冻结的金额
+	TransactionTypeWithdrawRefund = "withdraw_refund"
+	// 邀请奖励：被邀请人注册或下注流水达标后发放给邀请人和被邀请人
+	TransactionTypeReferralBonus = "referral_bonus"
+	// 从其他机器人迁移时导入的初始余额
+	TransactionTypeMigration = "migration"
+	// 对局结果被提出争议时冻结获胜者的派奖
+	TransactionTypeDisputeHold = "dispute_hold"
+	// 争议审核维持原结果，退回冻结的派奖
+	TransactionTypeDisputeRelease = "dispute_release"
+	// 争议审核撤销原结果，按作废对局向双方退还下注
+	TransactionTypeDisputeReversal = "dispute_reversal"
+)
+
+// WithdrawalStatus 提现申请状态常量
+const (
+	WithdrawalStatusPending  = "pending"
+	WithdrawalStatusApproved = "approved"
+	WithdrawalStatusRejected = "rejected"
+)
+
+// ReconcileStatus 对账差异的处理状态常量
+const (
+	// 待管理员核对
+	ReconcileStatusOpen = "open"
+	// 确认当前余额无误，差额计入对账基线
+	ReconcileStatusAccepted = "accepted"
+	// 已手动修正余额，下次对账重新核对
+	ReconcileStatusResolved = "resolved"
+)
+
+// DisputeStatus 对局争议的处理状态常量
+const (
+	// 待管理员审核
+	DisputeStatusOpen = "open"
+	// 维持原结果，冻结的派奖退回获胜者
+	DisputeStatusReleased = "released"
+	// 撤销原结果，对局作废并向双方退还下注
+	DisputeStatusReversed = "reversed"
+)
+
+// RiskStatus 风控事件的处理状态常量
+const (
+	// 待管理员审核
+	RiskStatusOpen = "open"
+	// 误报，解除因此冻结的账户
+	RiskStatusDismissed = "dismissed"
+	// 确认违规，账户保持冻结
+	RiskStatusConfirmed = "confirmed"
+)
+
+// RiskRule 风控规则常量
+const (
+	// 同一对玩家在统计窗口内反复对局
+	RiskRulePairGames = "pair_games"
+	// 统计窗口内胜率异常
+	RiskRuleWinRate = "win_rate"
+	// 同一对玩家之间的净输赢过大（疑似输钱转移筹码）
+	RiskRuleChipDump = "chip_dump"
+)
+
+// ChatService 群组服务状态常量（容量限制模式）
+const (
+	ChatServiceActive   = "active"
+	ChatServiceWaitlist = "waitlist"
+	ChatServiceLeft     = "left"
+)
+
+// ChatAccessMode 群组准入模式常量
+const (
+	// 不限制群组
+	ChatAccessOpen = "open"
+	// 只在白名单群组中提供服务
+	ChatAccessAllowlist = "allowlist"
+	// 拒绝黑名单中的群组
+	ChatAccessBlocklist = "blocklist"
+)
+
+// 群组准入名单类型
+const (
+	ChatListAllow = "allow"
+	ChatListBlock = "block"
 )
+
+// ChatAccessEntry 群组白名单或黑名单条目
+type ChatAccessEntry struct {
+	ChatID    int64     `json:"chat_id"`
+	List      string    `json:"list"` // allow, block
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ChatLoad 群组负载统计，用于容量规划
+type ChatLoad struct {
+	ChatID        int64     `json:"chat_id"`
+	Title         string    `json:"title"`
+	ServiceStatus string    `json:"service_status"`
+	Games         int       `json:"games"`
+	Players       int       `json:"players"`
+	Volume        int64     `json:"volume"`
+	PlayingGames  int       `json:"playing_games"`
+	LastGameAt    time.Time `json:"last_game_at"`
+}
+
+// ShardNode 分片进程的心跳记录
+type ShardNode struct {
+	InstanceID  string    `json:"instance_id"`
+	ShardID     int       `json:"shard_id"`
+	ShardCount  int       `json:"shard_count"`
+	StartedAt   time.Time `json:"started_at"`
+	HeartbeatAt time.Time `json:"heartbeat_at"`
+}
+
+// QueuedUpdate 分片部署时由接收进程写入共享队列、等待对应分片处理的更新
+type QueuedUpdate struct {
+	UpdateID  int       `json:"update_id"`
+	ChatID    int64     `json:"chat_id"` // 更新所属的聊天，用于分配分片，无聊天的更新为 0
+	Payload   string    `json:"payload"` // 更新的 JSON
+	ClaimedBy string    `json:"claimed_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AdminAction 管理员操作审计记录
+type AdminAction struct {
+	ID         int64     `json:"id"`
+	Admin      string    `json:"admin"`
+	Action     string    `json:"action"`      // 如 update_user、update_balance、delete_user
+	TargetType string    `json:"target_type"` // 如 user、chat、bonus_campaign、setting
+	TargetID   string    `json:"target_id"`
+	Details    string    `json:"details"` // JSON 格式的变更内容
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// UsernameHistory 用户曾用的用户名
+type UsernameHistory struct {
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// LeaderboardEntry 排行榜条目，Anonymous 为 true 时对外只显示匿名代号
+type LeaderboardEntry struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	Anonymous bool   `json:"anonymous"`
+	Wins      int    `json:"wins"`
+	Winnings  int64  `json:"winnings"`
+}
+
+// RankEntry 群组排行榜条目：统计周期内已结束对局的场数、胜场和净盈亏（含手续费、退款和保险）
+type RankEntry struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	FirstName string `json:"first_name"`
+	Anonymous bool   `json:"anonymous"`
+	Games     int    `json:"games"`
+	Wins      int    `json:"wins"`
+	NetProfit int64  `json:"net_profit"`
+}
+
+// FundAirdrop 群组基金空投结果
+type FundAirdrop struct {
+	ChatID     int64   `json:"chat_id"`
+	Recipients []int64 `json:"recipients"`
+	PerUser    int64   `json:"per_user"`
+	Total      int64   `json:"total"`
+	Remaining  int64   `json:"remaining"`
+}
+
+// RevenueShareReport 群组手续费分成统计
+type RevenueShareReport struct {
+	ChatID     int64   `json:"chat_id"`
+	Title      string  `json:"title"`
+	Share      float64 `json:"share"`
+	Games      int     `json:"games"`
+	Commission int64   `json:"commission"`
+	ChatShare  int64   `json:"chat_share"`
+}
+
+// InsuranceSettings 下注保险配置，由运营方在后台设置
+type InsuranceSettings struct {
+	Enabled     bool    `json:"enabled"`
+	Threshold   int64   `json:"threshold"`    // 下注额不低于该值时可投保
+	PremiumRate float64 `json:"premium_rate"` // 保险费占下注额的比例，0.05 表示 5%
+	RefundRate  float64 `json:"refund_rate"`  // 输局时赔付下注额的比例，0.5 表示退还一半
+}
+
+// MaintenanceRun 一次数据库维护（WAL 检查点、增量 VACUUM、ANALYZE）的结果
+type MaintenanceRun struct {
+	StartedAt  time.Time     `json:"started_at"`
+	Duration   time.Duration `json:"duration"`
+	Trigger    string        `json:"trigger"` // schedule, manual
+	SizeBefore int64         `json:"size_before"`
+	SizeAfter  int64         `json:"size_after"`
+	FreedPages int64         `json:"freed_pages"`
+	FullVacuum bool          `json:"full_vacuum"` // 首次切换到增量 VACUUM 模式时执行了完整 VACUUM
+	Error      string        `json:"error,omitempty"`
+}
+
+// ChatEligibility 群组的下注资格要求，0 表示不限制
+type ChatEligibility struct {
+	MinAccountDays int `json:"min_account_days"` // 按用户ID估算的 Telegram 账号注册天数
+	MinMemberDays  int `json:"min_member_days"`  // 机器人首次在本群见到该用户以来的天数
+}
+
+// ChatGameLimits 群组自定义的下注限额、手续费率和等待超时，0 或 nil 表示沿用全局配置
+type ChatGameLimits struct {
+	MinBet      int64    `json:"min_bet"`
+	MaxBet      int64    `json:"max_bet"`
+	FeeRate     *float64 `json:"fee_rate,omitempty"`
+	GameTimeout int      `json:"game_timeout"` // 对局等待加入的超时（秒）
+}
+
+// ChatSettings 可在群组间导出和导入的群组设置，导入时未填写的字段保持不变
+type ChatSettings struct {
+	Version          int      `json:"version"`
+	Language         *string  `json:"language,omitempty"`
+	SurrenderEnabled *bool    `json:"surrender_enabled,omitempty"`
+	JackpotAnnounce  *bool    `json:"jackpot_announce,omitempty"`
+	RevenueShare     *float64 `json:"revenue_share,omitempty"`
+	FeedOptOut       *bool    `json:"feed_opt_out,omitempty"`
+	SequentialGames  *bool    `json:"sequential_games,omitempty"`
+	MaxActiveGames   *int     `json:"max_active_games,omitempty"`
+	GameCooldown     *int     `json:"game_cooldown,omitempty"`
+	ReadyCheck       *bool    `json:"ready_check,omitempty"`
+}
+
+// ChatSettingChange 导入预览中某个群组的一项设置变更
+type ChatSettingChange struct {
+	ChatID int64       `json:"chat_id"`
+	Field  string      `json:"field"`
+	From   interface{} `json:"from"`
+	To     interface{} `json:"to"`
+}
+
+// Table 快速桌：多名玩家各下固定底注进入同一奖池，每人掷一次，前两名瓜分奖池
+type Table struct {
+	ID         string    `json:"id"`
+	ChatID     int64     `json:"chat_id"`
+	CreatorID  int64     `json:"creator_id"`
+	Ante       int64     `json:"ante"`
+	Status     string    `json:"status"` // open, finished, cancelled
+	Mode       string    `json:"mode"`   // split 前两名瓜分奖池，battle 最高点数者独得
+	MaxPlayers int       `json:"max_players"`
+	Commission int64     `json:"commission"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TablePlayer 快速桌上的玩家及其掷骰结果
+type TablePlayer struct {
+	TableID    string    `json:"table_id"`
+	UserID     int64     `json:"user_id"`
+	Username   string    `json:"username"`
+	FirstName  string    `json:"first_name"`
+	Anonymous  bool      `json:"anonymous"`
+	BonusStake int64     `json:"-"` // 底注中占用的赠送金额，退款时原路退回
+	Dice1      int       `json:"dice1"`
+	Dice2      int       `json:"dice2"`
+	Dice3      int       `json:"dice3"`
+	Rank       int       `json:"rank"`   // 名次，并列时相同，0 表示尚未开骰
+	Payout     int64     `json:"payout"` // 分得的奖金
+	JoinedAt   time.Time `json:"joined_at"`
+}
+
+// Total 三个骰子的点数之和
+func (p *TablePlayer) Total() int {
+	return p.Dice1 + p.Dice2 + p.Dice3
+}
+
+// Tournament 锦标赛：管理员在群内开设，玩家缴纳报名费进入奖池，单败淘汰的一对一骰子对决，冠军独得扣除手续费后的奖池
+type Tournament struct {
+	ID          string    `json:"id"`
+	ChatID      int64     `json:"chat_id"`
+	CreatorID   int64     `json:"creator_id"`
+	EntryFee    int64     `json:"entry_fee"`
+	MaxPlayers  int       `json:"max_players"`
+	Status      string    `json:"status"` // open, running, finished, cancelled
+	Round       int       `json:"round"`  // 已进行的轮次
+	WinnerID    *int64    `json:"winner_id,omitempty"`
+	Prize       int64     `json:"prize"`
+	FundPrize   int64     `json:"fund_prize"` // 奖金中来自群组比赛奖池的部分
+	Commission  int64     `json:"commission"`
+	StartsAt    time.Time `json:"starts_at"`     // 报名截止时间，报满时提前开赛
+	NextRoundAt time.Time `json:"next_round_at"` // 下一轮的开赛时间
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TournamentEntry 锦标赛的参赛玩家
+type TournamentEntry struct {
+	TournamentID    string    `json:"tournament_id"`
+	UserID          int64     `json:"user_id"`
+	Username        string    `json:"username"`
+	FirstName       string    `json:"first_name"`
+	Anonymous       bool      `json:"anonymous"`
+	BonusStake      int64     `json:"-"`                // 报名费中占用的赠送金额，退款时原路退回
+	Seed            int       `json:"seed"`             // 开赛时抽签的种子位，决定对阵
+	EliminatedRound int       `json:"eliminated_round"` // 被淘汰的轮次，0 表示仍在比赛中
+	JoinedAt        time.Time `json:"joined_at"`
+}
+
+// TournamentMatch 锦标赛某一轮的对阵及结果，Player2ID 为 0 表示轮空直接晋级
+type TournamentMatch struct {
+	TournamentID string    `json:"tournament_id"`
+	Round        int       `json:"round"`
+	Slot         int       `json:"slot"` // 本轮的对阵序号，从 1 开始
+	Player1ID    int64     `json:"player1_id"`
+	Player2ID    int64     `json:"player2_id"`
+	Player1Dice  [3]int    `json:"player1_dice"`
+	Player2Dice  [3]int    `json:"player2_dice"`
+	Rerolls      int       `json:"rerolls"` // 同点重掷的次数，记录的是最后一次的点数
+	WinnerID     int64     `json:"winner_id"`
+	PlayedAt     time.Time `json:"played_at"`
+}
+
+// Bye 是否轮空
+func (m *TournamentMatch) Bye() bool {
+	return m.Player2ID == 0
+}
+
+// Totals 双方三个骰子的点数之和
+func (m *TournamentMatch) Totals() (int, int) {
+	return m.Player1Dice[0] + m.Player1Dice[1] + m.Player1Dice[2], m.Player2Dice[0] + m.Player2Dice[1] + m.Player2Dice[2]
+}
+
+// HeadToHead 两名玩家之间已完成对局的交手记录，PlayerA 为 ID 较小的一方
+type HeadToHead struct {
+	PlayerA int64 `json:"player_a"`
+	PlayerB int64 `json:"player_b"`
+	Games   int   `json:"games"`
+	WinsA   int   `json:"wins_a"`
+	WinsB   int   `json:"wins_b"`
+}
+
+// Wins 指定玩家在交手中的胜场
+func (h *HeadToHead) Wins(userID int64) int {
+	if userID == h.PlayerA {
+		return h.WinsA
+	}
+	if userID == h.PlayerB {
+		return h.WinsB
+	}
+	return 0
+}
+
+// Draws 平局场数
+func (h *HeadToHead) Draws() int {
+	return h.Games - h.WinsA - h.WinsB
+}
+
+// UserStats 用户已完成（含认输）对局的战绩，金额为基本单位
+type UserStats struct {
+	UserID     int64 `json:"user_id"`
+	Games      int   `json:"games"`
+	Wins       int   `json:"wins"`
+	Losses     int   `json:"losses"`
+	Draws      int   `json:"draws"`
+	BiggestWin int64 `json:"biggest_win"` // 单局最大净赢额
+	NetProfit  int64 `json:"net_profit"`  // 对局相关流水（下注、奖金、退款、保险等）合计
+}
+
+// WinRate 胜率（0-1），没有对局时为 0
+func (s *UserStats) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Games)
+}
+
+// WeeklySummary 用户一周内已结束对局的汇总，用于每周战报私信
+type WeeklySummary struct {
+	UserID    int64     `json:"user_id"`
+	Since     time.Time `json:"since"`
+	Until     time.Time `json:"until"`
+	Games     int       `json:"games"`
+	Wins      int       `json:"wins"`
+	Losses    int       `json:"losses"`
+	Draws     int       `json:"draws"`
+	NetProfit int64     `json:"net_profit"` // 对局相关流水合计，与个人战绩口径一致
+	BestWin   int64     `json:"best_win"`   // 单局最大净赢额
+	Fees      int64     `json:"fees"`       // 获胜对局中扣除的手续费
+	Rank      int       `json:"rank"`       // 按本周净盈亏在全部玩家中的排名，本周没有对局时为 0
+	Players   int       `json:"players"`    // 本周有对局的玩家数
+}
+
+// WeeklySummaryRecipient 订阅每周战报的用户及其订阅时的语言
+type WeeklySummaryRecipient struct {
+	UserID   int64
+	Language string
+}
+
+// Broadcast 管理员向群组或用户群发的公告
+type Broadcast struct {
+	ID          int64      `json:"id"`
+	Text        string     `json:"text"`
+	Audience    string     `json:"audience"` // groups, users, all
+	Status      string     `json:"status"`   // scheduled, sending, completed, cancelled
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	CreatedBy   string     `json:"created_by"` // 管理后台用户名或管理员的 Telegram 用户ID
+	Total       int        `json:"total"`      // 开始发送时确定的接收方数量
+	Sent        int        `json:"sent"`       // 已送达
+	Failed      int        `json:"failed"`     // 无法送达
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+}
+
+// Broadcast 接收范围常量
+const (
+	BroadcastGroups = "groups"
+	BroadcastUsers  = "users"
+	BroadcastAll    = "all"
+)
+
+// Broadcast 状态常量
+const (
+	BroadcastScheduled = "scheduled"
+	BroadcastSending   = "sending"
+	BroadcastCompleted = "completed"
+	BroadcastCancelled = "cancelled"
+)
+
+// BroadcastDelivery 公告对单个群组或用户的投递记录
+type BroadcastDelivery struct {
+	BroadcastID int64     `json:"broadcast_id"`
+	ChatID      int64     `json:"chat_id"`
+	Status      string    `json:"status"` // pending, sent, failed
+	Error       string    `json:"error,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// BroadcastDelivery 状态常量
+const (
+	DeliveryPending = "pending"
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed"
+)
+
+// DeadLetter 执行失败的后台任务，修复问题后可在后台重放
+type DeadLetter struct {
+	ID        int64     `json:"id"`
+	Kind      string    `json:"kind"`    // 任务类型，为空表示无法重放的匿名任务
+	Payload   string    `json:"payload"` // 任务参数（JSON）
+	Priority  int       `json:"priority"`
+	Error     string    `json:"error"`
+	Attempts  int       `json:"attempts"`
+	Status    string    `json:"status"` // pending, replaying, resolved, discarded
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DeadLetter 状态常量
+const (
+	DeadLetterPending   = "pending"
+	DeadLetterReplaying = "replaying"
+	DeadLetterResolved  = "resolved"
+	DeadLetterDiscarded = "discarded"
+)
+
+// OutboxMessage 待发送的对局消息：与对局状态在同一事务中写入，由后台发送器按群组顺序投递，至少投递一次
+type OutboxMessage struct {
+	ID          int64      `json:"id"`
+	ChatID      int64      `json:"chat_id"`
+	GameID      string     `json:"game_id,omitempty"`
+	Text        string     `json:"text"`
+	ParseMode   string     `json:"parse_mode,omitempty"`
+	ReplyMarkup string     `json:"reply_markup,omitempty"` // 内联键盘（JSON）
+	Status      string     `json:"status"`                 // pending, sent, failed
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	NextAttempt time.Time  `json:"next_attempt"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+}
+
+// OutboxMessage 状态常量
+const (
+	OutboxPending = "pending"
+	OutboxSent    = "sent"
+	OutboxFailed  = "failed"
+)
+
+// QueueEntry 顺序模式下排队等待开局的加入请求，供管理员查看和移除
+type QueueEntry struct {
+	Position  int       `json:"position"` // 在本群队列中的位置，从 1 开始
+	ChatID    int64     `json:"chat_id"`
+	GameID    string    `json:"game_id"`
+	PlayerID  int64     `json:"player_id"`
+	Username  string    `json:"username"`
+	FirstName string    `json:"first_name"`
+	BetAmount int64     `json:"bet_amount"`
+	QueuedAt  time.Time `json:"queued_at"`
+}
+
+// UptimeSession 一次进程运行：启动时间、最近一次心跳和正常停止的时间（异常退出时为空）
+type UptimeSession struct {
+	ID         int64      `json:"id"`
+	Instance   string     `json:"instance"`
+	StartedAt  time.Time  `json:"started_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	StoppedAt  *time.Time `json:"stopped_at,omitempty"`
+}
+
+// EndedAt 会话最后在线的时间，异常退出时以最后一次心跳为准
+func (s *UptimeSession) EndedAt() time.Time {
+	if s.StoppedAt != nil {
+		return *s.StoppedAt
+	}
+	return s.LastSeenAt
+}
+
+// UptimeGap 进程运行期间检测到的服务中断，如长时间积压的更新
+type UptimeGap struct {
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	Reason    string    `json:"reason"`
+}
+
+// UptimeReport 统计周期内的可用性
+type UptimeReport struct {
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	Monitored    time.Duration `json:"monitored"` // 有记录以来的统计时长
+	Downtime     time.Duration `json:"downtime"`
+	Availability float64       `json:"availability"` // 百分比，如 99.95
+	Outages      []*UptimeGap  `json:"outages"`      // 合并后的停机时段，按时间排列
+}
+
+// DailyClaim 一次每日签到领取：金额含连续签到加成，Streak 为截至本次的连续签到天数
+type DailyClaim struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Amount    int64     `json:"amount"`
+	Streak    int       `json:"streak"`
+	ClaimedAt time.Time `json:"claimed_at"`
+}
+
+// ReferralRewards 邀请奖励设置：注册奖励在被邀请人通过邀请链接注册时发放，
+// 流水奖励在被邀请人累计下注达到 WagerThreshold 时发放一次，均计入赠送余额
+type ReferralRewards struct {
+	SignupReferrer int64 `json:"signup_referrer"`
+	SignupReferee  int64 `json:"signup_referee"`
+	WagerThreshold int64 `json:"wager_threshold"`
+	WagerReferrer  int64 `json:"wager_referrer"`
+	WagerReferee   int64 `json:"wager_referee"`
+	// 邀请奖励计入赠送余额，完成奖励金额该倍数的下注流水后转为可提现余额
+	WageringMultiplier float64 `json:"wagering_multiplier"`
+}
+
+// Referral 邀请关系，流水奖励按邀请时的设置发放
+type Referral struct {
+	RefereeID          int64      `json:"referee_id"`
+	ReferrerID         int64      `json:"referrer_id"`
+	Wagered            int64      `json:"wagered"` // 被邀请人累计下注
+	WagerThreshold     int64      `json:"wager_threshold"`
+	ReferrerWagerBonus int64      `json:"referrer_wager_bonus"`
+	RefereeWagerBonus  int64      `json:"referee_wager_bonus"`
+	ReferrerEarned     int64      `json:"referrer_earned"` // 邀请人从该被邀请人获得的奖励合计
+	WageringMultiplier float64    `json:"wagering_multiplier"`
+	CreatedAt          time.Time  `json:"created_at"`
+	WagerRewardedAt    *time.Time `json:"wager_rewarded_at,omitempty"`
+}
+
+// ReferralSummary 邀请人的邀请统计
+type ReferralSummary struct {
+	Invited   int   `json:"invited"`   // 通过邀请链接注册的人数
+	Qualified int   `json:"qualified"` // 下注流水已达标的人数
+	Earned    int64 `json:"earned"`    // 获得的邀请奖励合计
+}
+
+// Withdrawal 提现申请：申请时从余额中冻结金额，管理员批准后线下转出 USDT，拒绝时自动退回余额
+type Withdrawal struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Amount     int64      `json:"amount"`      // 冻结的金币（基本单位）
+	USDTAmount float64    `json:"usdt_amount"` // 按申请时的兑换比例折算的 USDT
+	Address    string     `json:"address"`     // TRC20 收款地址
+	Status     string     `json:"status"`
+	Reviewer   string     `json:"reviewer,omitempty"`
+	TxHash     string     `json:"tx_hash,omitempty"` // 批准后转账的交易哈希
+	Reason     string     `json:"reason,omitempty"`  // 拒绝原因
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// Dispute 对局结果争议：玩家在结算后提出，冻结获胜者的派奖，管理员审核后退回或撤销结果
+type Dispute struct {
+	ID         int64      `json:"id"`
+	GameID     string     `json:"game_id"`
+	ChatID     int64      `json:"chat_id"`
+	ReporterID int64      `json:"reporter_id"`
+	WinnerID   int64      `json:"winner_id"`
+	LoserID    int64      `json:"loser_id"`
+	BetAmount  int64      `json:"bet_amount"`
+	Payout     int64      `json:"payout"` // 获胜者所得派奖（基本单位）
+	Held       int64      `json:"held"`   // 实际冻结的金额，获胜者余额不足时少于派奖
+	Status     string     `json:"status"`
+	Reviewer   string     `json:"reviewer,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+// ReconcileIssue 对账发现的余额差异：按流水重算的余额与实际余额（含赠送余额）不一致
+type ReconcileIssue struct {
+	ID         int64      `json:"id"`
+	UserID     int64      `json:"user_id"`
+	Expected   int64      `json:"expected"`   // 按流水重算的余额（基本单位）
+	Actual     int64      `json:"actual"`     // 现金余额与赠送余额之和
+	Difference int64      `json:"difference"` // Actual - Expected
+	Status     string     `json:"status"`
+	Frozen     bool       `json:"frozen"` // 发现差异时是否冻结了账户
+	Reviewer   string     `json:"reviewer,omitempty"`
+	Note       string     `json:"note,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// RiskEvent 风控规则在对局结算后标记的可疑账户
+type RiskEvent struct {
+	ID             int64      `json:"id"`
+	UserID         int64      `json:"user_id"`
+	CounterpartyID int64      `json:"counterparty_id,omitempty"` // 涉及的对手，胜率规则为 0
+	Rule           string     `json:"rule"`
+	GameID         string     `json:"game_id"` // 触发检查的对局
+	Detail         string     `json:"detail"`
+	Status         string     `json:"status"`
+	Frozen         bool       `json:"frozen"` // 标记时是否冻结了账户
+	Reviewer       string     `json:"reviewer,omitempty"`
+	Note           string     `json:"note,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ReviewedAt     *time.Time `json:"reviewed_at,omitempty"`
+}
+
+// ReconcileRun 一次对账的结果
+type ReconcileRun struct {
+	Trigger   string            `json:"trigger"` // schedule 或 manual
+	StartedAt time.Time         `json:"started_at"`
+	Duration  time.Duration     `json:"duration"`
+	Checked   int               `json:"checked"`
+	Issues    []*ReconcileIssue `json:"issues"` // 本次新发现或金额有变化的差异
+	Frozen    int               `json:"frozen"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// 对局时间线的条目类型
+const (
+	GameEventCreated     = "created"     // 发起对局
+	GameEventJoined      = "joined"      // 对手加入并开局
+	GameEventReady       = "ready"       // 玩家确认准备，或准备超时后撤销加入
+	GameEventRoll        = "roll"        // 发送的骰子消息及点数
+	GameEventSeedRoll    = "seed_roll"   // 按承诺种子生成骰子
+	GameEventHeld        = "held"        // 开骰后结算暂停等待审核
+	GameEventSettled     = "settled"     // 按骰子结果结算（含平局退款）
+	GameEventSurrendered = "surrendered" // 玩家认输
+	GameEventCancelled   = "cancelled"   // 中止或审核拒绝后取消并退款
+	GameEventExpired     = "expired"     // 无人加入超时退款
+	GameEventDisputed    = "disputed"    // 玩家对结果提出争议及审核结果
+	GameEventSecurity    = "security"    // 安全校验下的对局操作
+	GameEventMessage     = "message"     // 为对局发送的消息
+	GameEventTransaction = "transaction" // 资金流水，仅出现在时间线中
+	GameEventAdmin       = "admin"       // 管理员操作，仅出现在时间线中
+)
+
+// GameEvent 对局过程中记录的事件，与资金流水和管理员操作一起还原对局时间线
+type GameEvent struct {
+	ID        int64     `json:"id"`
+	GameID    string    `json:"game_id"`
+	Type      string    `json:"type"`
+	UserID    int64     `json:"user_id,omitempty"`
+	ChatID    int64     `json:"chat_id,omitempty"`
+	MessageID int       `json:"message_id,omitempty"`
+	Detail    string    `json:"detail"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// GameTimelineEntry 对局时间线条目，Amount 仅资金流水有值
+type GameTimelineEntry struct {
+	Time      time.Time `json:"time"`
+	Type      string    `json:"type"`
+	UserID    int64     `json:"user_id,omitempty"`
+	MessageID int       `json:"message_id,omitempty"`
+	Amount    int64     `json:"amount,omitempty"`
+	Detail    string    `json:"detail"`
+}
+
+// GameTimeline 对局及按时间排序的全部相关记录
+type GameTimeline struct {
+	Game    *Game                `json:"game"`
+	Entries []*GameTimelineEntry `json:"entries"`
+}
+
+// 对局骰子的来源
+const (
+	DiceSourceSeed     = "seed"     // 按开局前承诺的种子生成，可按种子重算核对
+	DiceSourceTelegram = "telegram" // 由 Telegram 骰子动画开出，种子只作承诺审计
+)
+
+// GameVerification 对局的审计信息：种子在对局结束后才公开；骰子按种子生成时可重算核对，
+// 由 Telegram 动画开出时核对结算点数与记录的骰子消息
+type GameVerification struct {
+	Game      *Game  `json:"game"`
+	SeedHash  string `json:"seed_hash"`
+	Revealed  bool   `json:"revealed"`
+	Seed      string `json:"seed,omitempty"`
+	HashValid bool   `json:"hash_valid"` // 公开的种子与开局前公布的承诺哈希一致
+	// 骰子来源，时间线中没有来源记录时为空
+	DiceSource string `json:"dice_source"`
+	// 按种子重算的双方骰子，仅骰子按种子生成时有值
+	SeedDice1 [3]int `json:"seed_dice1"`
+	SeedDice2 [3]int `json:"seed_dice2"`
+	// 时间线中记录的双方 Telegram 骰子消息点数，按发送顺序
+	TelegramDice1 []int `json:"telegram_dice1,omitempty"`
+	TelegramDice2 []int `json:"telegram_dice2,omitempty"`
+	// 对局实际结算的双方骰子，未开骰时 Rolled 为 false
+	Rolled bool   `json:"rolled"`
+	Dice1  [3]int `json:"dice1"`
+	Dice2  [3]int `json:"dice2"`
+	// 结算骰子与来源（种子重算结果或 Telegram 骰子消息）一致
+	DiceMatch bool `json:"dice_match"`
+}
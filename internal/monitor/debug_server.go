@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+)
+
+// DebugServer 仅监听本机地址的调试服务，提供 net/http/pprof 和运行时统计，用于排查内存和协程泄漏
+type DebugServer struct {
+	addr      string
+	server    *http.Server
+	startTime time.Time
+}
+
+// NewDebugServer 创建调试服务，addr 必须是本机回环地址（如 127.0.0.1:6060）
+func NewDebugServer(addr string) (*DebugServer, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("调试服务地址无效: %v", err)
+	}
+	if !isLoopback(host) {
+		return nil, fmt.Errorf("调试服务只能监听本机地址，当前为 %s", addr)
+	}
+
+	ds := &DebugServer{addr: addr, startTime: time.Now()}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("/debug/runtime", ds.runtimeStats)
+
+	ds.server = &http.Server{
+		Addr:              addr,
+		Handler:           localOnly(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return ds, nil
+}
+
+// Addr 调试服务监听的地址
+func (ds *DebugServer) Addr() string {
+	return ds.addr
+}
+
+// Start 在后台启动调试服务，监听失败时返回错误
+func (ds *DebugServer) Start() error {
+	listener, err := net.Listen("tcp", ds.addr)
+	if err != nil {
+		return fmt.Errorf("启动调试服务失败: %v", err)
+	}
+	ds.addr = listener.Addr().String()
+
+	go func() {
+		if err := ds.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ 调试服务错误: %v", err)
+		}
+	}()
+	log.Printf("🩺 调试服务已启动: http://%s/debug/pprof/", ds.addr)
+	return nil
+}
+
+// Stop 停止调试服务
+func (ds *DebugServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ds.server.Shutdown(ctx); err != nil {
+		log.Printf("⚠️ 停止调试服务失败: %v", err)
+	}
+}
+
+// runtimeStats 输出协程数和堆内存等运行时统计
+func (ds *DebugServer) runtimeStats(w http.ResponseWriter, r *http.Request) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"uptime_seconds":  int64(time.Since(ds.startTime).Seconds()),
+		"goroutine_count": runtime.NumGoroutine(),
+		"heap_alloc_mb":   float64(memStats.HeapAlloc) / 1024 / 1024,
+		"heap_inuse_mb":   float64(memStats.HeapInuse) / 1024 / 1024,
+		"heap_objects":    memStats.HeapObjects,
+		"sys_mb":          float64(memStats.Sys) / 1024 / 1024,
+		"gc_count":        memStats.NumGC,
+		"last_gc_pause":   time.Duration(memStats.PauseNs[(memStats.NumGC+255)%256]).String(),
+	})
+}
+
+// localOnly 拒绝非本机来源的请求，防止经端口转发等方式暴露到外网
+func localOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil || !isLoopback(host) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isLoopback 判断主机名是否为本机回环地址
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,75 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// MetricsServer 以 Prometheus 文本格式提供 /metrics 的抓取服务，指标不含用户数据，可监听内网地址供 Prometheus 抓取
+type MetricsServer struct {
+	addr       string
+	server     *http.Server
+	collectors []Collector
+}
+
+// NewMetricsServer 创建指标服务，collectors 按顺序输出
+func NewMetricsServer(addr string, collectors ...Collector) (*MetricsServer, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("指标服务地址无效: %v", err)
+	}
+
+	ms := &MetricsServer{addr: addr, collectors: collectors}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", ms.metrics)
+
+	ms.server = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return ms, nil
+}
+
+// Addr 指标服务监听的地址
+func (ms *MetricsServer) Addr() string {
+	return ms.addr
+}
+
+// Start 在后台启动指标服务，监听失败时返回错误
+func (ms *MetricsServer) Start() error {
+	listener, err := net.Listen("tcp", ms.addr)
+	if err != nil {
+		return fmt.Errorf("启动指标服务失败: %v", err)
+	}
+	ms.addr = listener.Addr().String()
+
+	go func() {
+		if err := ms.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ 指标服务错误: %v", err)
+		}
+	}()
+	log.Printf("📈 指标服务已启动: http://%s/metrics", ms.addr)
+	return nil
+}
+
+// Stop 停止指标服务
+func (ms *MetricsServer) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := ms.server.Shutdown(ctx); err != nil {
+		log.Printf("⚠️ 停止指标服务失败: %v", err)
+	}
+}
+
+// metrics 输出全部组件的指标
+func (ms *MetricsServer) metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := WriteMetrics(w, ms.collectors...); err != nil {
+		log.Printf("输出Prometheus指标失败: %v", err)
+	}
+}
@@ -24,6 +24,10 @@ type PerformanceMonitor struct {
 	maxResponseTime int64 // 最大响应时间(毫秒)
 	minResponseTime int64 // 最小响应时间(毫秒)
 
+	// 响应时间直方图：各桶为不超过对应上限的累计请求数，与 responseBuckets 对应
+	durationBuckets []int64
+	durationSumNs   int64 // 响应时间总和(纳秒)
+
 	// 系统信息
 	startTime      time.Time
 	lastReportTime time.Time
@@ -35,6 +39,8 @@ type PerformanceMonitor struct {
 	// 配置
 	reportInterval time.Duration
 	maxRecentCount int
+	// 调试服务地址，为空时未启用
+	debugAddr string
 
 	// 停止信号
 	stopChan chan struct{}
@@ -42,6 +48,9 @@ type PerformanceMonitor struct {
 	mutex    sync.RWMutex
 }
 
+// responseBuckets 响应时间直方图的桶上限（秒）
+var responseBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
 // RequestMetric 请求指标
 type RequestMetric struct {
 	Timestamp    time.Time
@@ -60,6 +69,7 @@ func NewPerformanceMonitor() *PerformanceMonitor {
 		recentRequests:  make([]RequestMetric, 0, 1000),
 		stopChan:        make(chan struct{}),
 		minResponseTime: int64(^uint64(0) >> 1), // 初始化为最大值
+		durationBuckets: make([]int64, len(responseBuckets)),
 	}
 
 	return pm
@@ -106,6 +116,7 @@ func (pm *PerformanceMonitor) RecordRequest(requestType string, responseTime tim
 	// 更新响应时间统计
 	responseTimeMs := responseTime.Milliseconds()
 	pm.updateResponseTime(responseTimeMs)
+	pm.observeDuration(responseTime)
 
 	// 记录详细请求信息
 	pm.requestMutex.Lock()
@@ -155,6 +166,17 @@ func (pm *PerformanceMonitor) updateResponseTime(responseTimeMs int64) {
 	}
 }
 
+// observeDuration 将响应时间计入直方图
+func (pm *PerformanceMonitor) observeDuration(responseTime time.Duration) {
+	atomic.AddInt64(&pm.durationSumNs, int64(responseTime))
+	seconds := responseTime.Seconds()
+	for i, bound := range responseBuckets {
+		if seconds <= bound {
+			atomic.AddInt64(&pm.durationBuckets[i], 1)
+		}
+	}
+}
+
 // IncrementGameCount 增加游戏计数
 func (pm *PerformanceMonitor) IncrementGameCount() {
 	atomic.AddInt64(&pm.gameCount, 1)
@@ -245,6 +267,7 @@ func (pm *PerformanceMonitor) generateReport() {
   💾 内存使用: %.2f MB
   🗑️ GC次数: %d
   🔄 协程数: %d
+  🩺 调试服务: %s
 
 📊 最近活动:
   📨 请求数: %d
@@ -270,6 +293,7 @@ func (pm *PerformanceMonitor) generateReport() {
 		float64(memStats.Alloc)/1024/1024,
 		memStats.NumGC,
 		runtime.NumGoroutine(),
+		pm.debugServerHint(),
 		recentStats.requestCount,
 		recentStats.successRate,
 		recentStats.qps,
@@ -347,7 +371,21 @@ func (pm *PerformanceMonitor) GetCurrentStats() map[string]interface{} {
 		"goroutine_count": runtime.NumGoroutine(),
 		"gc_count":        memStats.NumGC,
 		"system_status":   "healthy",
+		"debug_server":    pm.debugServerHint(),
+	}
+}
+
+// SetDebugAddr 设置调试服务地址，性能报告中附带 pprof 入口
+func (pm *PerformanceMonitor) SetDebugAddr(addr string) {
+	pm.debugAddr = addr
+}
+
+// debugServerHint 调试服务的访问方式，未启用时提示配置项
+func (pm *PerformanceMonitor) debugServerHint() string {
+	if pm.debugAddr == "" {
+		return "未启用（设置 DEBUG_ADDR=127.0.0.1:6060 开启）"
 	}
+	return fmt.Sprintf("http://%s/debug/pprof/ ，运行时统计 http://%s/debug/runtime（仅限本机访问）", pm.debugAddr, pm.debugAddr)
 }
 
 // SetReportInterval 设置报告间隔
@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Collector 以 Prometheus 文本格式输出指标的组件，如游戏流程指标和数据库维护指标
+type Collector interface {
+	WritePrometheus(w io.Writer) error
+}
+
+// WritePrometheus 以 Prometheus 文本格式输出请求数、错误数、响应时间直方图、缓存命中和运行时指标
+func (pm *PerformanceMonitor) WritePrometheus(w io.Writer) error {
+	messages := atomic.LoadInt64(&pm.messageCount)
+	failed := atomic.LoadInt64(&pm.errorCount)
+	if _, err := fmt.Fprintf(w, "# HELP dice_requests_total Number of handled bot requests.\n# TYPE dice_requests_total counter\n"+
+		"dice_requests_total{result=\"success\"} %d\ndice_requests_total{result=\"error\"} %d\n", messages-failed, failed); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(w, "# HELP dice_request_duration_seconds Time spent handling bot requests.\n# TYPE dice_request_duration_seconds histogram"); err != nil {
+		return err
+	}
+	for i, bound := range responseBuckets {
+		if _, err := fmt.Fprintf(w, "dice_request_duration_seconds_bucket{le=\"%g\"} %d\n", bound, atomic.LoadInt64(&pm.durationBuckets[i])); err != nil {
+			return err
+		}
+	}
+	sum := time.Duration(atomic.LoadInt64(&pm.durationSumNs)).Seconds()
+	if _, err := fmt.Fprintf(w, "dice_request_duration_seconds_bucket{le=\"+Inf\"} %d\ndice_request_duration_seconds_sum %g\ndice_request_duration_seconds_count %d\n",
+		messages, sum, messages); err != nil {
+		return err
+	}
+
+	hits := atomic.LoadInt64(&pm.cacheHitCount)
+	misses := atomic.LoadInt64(&pm.cacheMissCount)
+	hitRate := float64(0)
+	if hits+misses > 0 {
+		hitRate = float64(hits) / float64(hits+misses)
+	}
+	if _, err := fmt.Fprintf(w, "# HELP dice_cache_requests_total Number of cache lookups.\n# TYPE dice_cache_requests_total counter\n"+
+		"dice_cache_requests_total{result=\"hit\"} %d\ndice_cache_requests_total{result=\"miss\"} %d\n"+
+		"# HELP dice_cache_hit_ratio Share of cache lookups served from cache.\n# TYPE dice_cache_hit_ratio gauge\ndice_cache_hit_ratio %g\n",
+		hits, misses, hitRate); err != nil {
+		return err
+	}
+
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	_, err := fmt.Fprintf(w, "# HELP dice_goroutines Number of goroutines.\n# TYPE dice_goroutines gauge\ndice_goroutines %d\n"+
+		"# HELP dice_memory_alloc_bytes Bytes of allocated heap objects.\n# TYPE dice_memory_alloc_bytes gauge\ndice_memory_alloc_bytes %d\n"+
+		"# HELP dice_uptime_seconds Seconds since the process started.\n# TYPE dice_uptime_seconds gauge\ndice_uptime_seconds %d\n",
+		runtime.NumGoroutine(), memStats.Alloc, int64(time.Since(pm.startTime).Seconds()))
+	return err
+}
+
+// WriteMetrics 依次输出各组件的指标
+func WriteMetrics(w io.Writer, collectors ...Collector) error {
+	for _, collector := range collectors {
+		if err := collector.WritePrometheus(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -18,18 +18,34 @@ type NetworkAccelerator struct {
 	cache         *ResponseCache
 	monitor       *NetworkMonitor
 	originalAPI   *tgbotapi.BotAPI
+
+	// 连接预热与对局期间保活
+	warmURL       string
+	keepAlive     *keepAlive
 }
 
-// NewNetworkAccelerator 创建网络加速器
-func NewNetworkAccelerator() *NetworkAccelerator {
-	// 创建网络优化器
-	optimizer := NewNetworkOptimizer()
-	
+// NewNetworkAccelerator 创建网络加速器，endpoints 为待探测的 Bot API 地址
+func NewNetworkAccelerator(endpoints ...string) *NetworkAccelerator {
+	return newNetworkAccelerator(NewNetworkOptimizer(endpoints...))
+}
+
+// NewNetworkAcceleratorWithProxy 创建通过代理访问 Telegram 的网络加速器
+func NewNetworkAcceleratorWithProxy(proxy ProxyConfig, endpoints ...string) (*NetworkAccelerator, error) {
+	optimizer, err := NewNetworkOptimizerWithProxy(proxy, endpoints...)
+	if err != nil {
+		return nil, err
+	}
+	return newNetworkAccelerator(optimizer), nil
+}
+
+func newNetworkAccelerator(optimizer *NetworkOptimizer) *NetworkAccelerator {
 	// 创建可重试的HTTP客户端
 	retryClient := NewRetryableHTTPClient(optimizer.GetOptimizedClient(), DefaultRetryConfig())
 	
+	thresholds := DefaultThresholds()
+
 	// 创建响应缓存
-	cache := NewResponseCache(5 * time.Minute)
+	cache := NewResponseCache(thresholds.CacheTTL)
 	
 	// 创建网络监控器
 	monitor := NewNetworkMonitor(optimizer)
@@ -39,15 +55,23 @@ func NewNetworkAccelerator() *NetworkAccelerator {
 		retryClient: retryClient,
 		cache:       cache,
 		monitor:     monitor,
+		warmURL:     optimizer.PrimaryEndpoint(),
+		keepAlive:   newKeepAlive(),
 	}
 }
 
 // InitializeWithBot 使用机器人初始化加速器
 func (na *NetworkAccelerator) InitializeWithBot(api *tgbotapi.BotAPI) error {
 	na.originalAPI = api
-	
-	// 查找最佳数据中心
-	ctx, cancel := context.WithTimeout(context.Background(), 45*time.Second) // 增加超时时间
+
+	// 最佳接入点变化时同步到 API 客户端
+	na.optimizer.SetSwitchCallback(func(dc *TelegramDC) {
+		na.warmURL = dc.Endpoint
+		api.SetAPIEndpoint(dc.Endpoint + "/bot%s/%s")
+	})
+	
+	// 探测 Bot API 接入点延迟
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	
 	bestDC, err := na.optimizer.FindBestDatacenter(ctx)
@@ -56,7 +80,7 @@ func (na *NetworkAccelerator) InitializeWithBot(api *tgbotapi.BotAPI) error {
 	}
 	
 	fmt.Printf("🚀 网络加速器已启动\n")
-	fmt.Printf("📍 最佳数据中心: %s (%s)\n", bestDC.Name, bestDC.Location)
+	fmt.Printf("📍 最佳接入点: %s (%s)\n", bestDC.Name, bestDC.Location)
 	fmt.Printf("⚡ 延迟: %v\n", bestDC.Latency)
 	fmt.Printf("🔧 优化功能: HTTP/2, 连接池, 智能重试, 响应缓存, 质量监控\n")
 	fmt.Printf("🎯 重试配置: 最大%d次, 基础延迟%v, 最大延迟%v\n", 
@@ -70,6 +94,11 @@ func (na *NetworkAccelerator) InitializeWithBot(api *tgbotapi.BotAPI) error {
 	return nil
 }
 
+// HTTPClient 返回加速器使用的 HTTP 客户端（含代理和连接池），Bot API 客户端共用它才能受益于连接预热
+func (na *NetworkAccelerator) HTTPClient() *http.Client {
+	return na.optimizer.GetOptimizedClient()
+}
+
 // MakeRequest 执行优化的HTTP请求
 func (na *NetworkAccelerator) MakeRequest(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
 	start := time.Now()
@@ -119,7 +148,7 @@ func (na *NetworkAccelerator) MakeRequest(ctx context.Context, method, url strin
 		bodyBytes, err := io.ReadAll(resp.Body)
 		if err == nil {
 			resp.Body.Close()
-			na.cache.Set(method, url, req.Header, resp, bodyBytes, 5*time.Minute)
+			na.cache.Set(method, url, req.Header, resp, bodyBytes, 0)
 			resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 		}
 	}
@@ -132,25 +161,47 @@ func (na *NetworkAccelerator) GetNetworkStats() map[string]*NetworkStats {
 	return na.monitor.GetAllStats()
 }
 
-// GetDatacenterInfo 获取数据中心信息
+// GetDatacenterInfo 获取接入点信息
 func (na *NetworkAccelerator) GetDatacenterInfo() []TelegramDC {
 	return na.optimizer.GetDatacenterStats()
 }
 
-// GetCurrentDatacenter 获取当前数据中心
+// GetCurrentDatacenter 获取当前接入点
 func (na *NetworkAccelerator) GetCurrentDatacenter() *TelegramDC {
 	return na.optimizer.GetBestDatacenter()
 }
 
-// RefreshDatacenters 刷新数据中心测试
+// RefreshDatacenters 重新探测接入点
 func (na *NetworkAccelerator) RefreshDatacenters() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	
 	_, err := na.optimizer.FindBestDatacenter(ctx)
 	return err
 }
 
+// Thresholds 当前的切换阈值和缓存时长
+func (na *NetworkAccelerator) Thresholds() Thresholds {
+	t := na.monitor.Thresholds()
+	t.CacheTTL = na.cache.TTL()
+	return t
+}
+
+// SetThresholds 校验并在运行时调整切换阈值和缓存时长
+func (na *NetworkAccelerator) SetThresholds(t Thresholds) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+	na.monitor.applyThresholds(t)
+	na.cache.SetTTL(t.CacheTTL)
+	return nil
+}
+
+// SetAlertHandler 设置告警回调，因超过阈值切换接入点时调用
+func (na *NetworkAccelerator) SetAlertHandler(alert func(message string)) {
+	na.monitor.SetAlertHandler(alert)
+}
+
 // ClearCache 清空缓存
 func (na *NetworkAccelerator) ClearCache() {
 	na.cache.Clear()
@@ -163,6 +214,7 @@ func (na *NetworkAccelerator) GetCacheSize() int {
 
 // Stop 停止加速器
 func (na *NetworkAccelerator) Stop() {
+	na.keepAlive.stop()
 	na.monitor.Stop()
 }
 
@@ -175,7 +227,7 @@ func (na *NetworkAccelerator) PrintStatus() {
 	}
 	
 	fmt.Println("\n🚀 网络加速器状态:")
-	fmt.Printf("📍 当前数据中心: %s (%s)\n", currentDC.Name, currentDC.Location)
+	fmt.Printf("📍 当前接入点: %s (%s)\n", currentDC.Name, currentDC.Location)
 	fmt.Printf("⚡ 延迟: %v\n", currentDC.Latency)
 	fmt.Printf("💾 缓存条目: %d\n", na.GetCacheSize())
 	
@@ -194,5 +246,5 @@ func (na *NetworkAccelerator) PrintStatus() {
 	fmt.Println("  ✅ 智能重试机制")
 	fmt.Println("  ✅ 响应缓存")
 	fmt.Println("  ✅ 网络质量监控")
-	fmt.Println("  ✅ 自动数据中心切换")
+	fmt.Println("  ✅ 自动接入点切换")
 }
\ No newline at end of file
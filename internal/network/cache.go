@@ -87,7 +87,7 @@ func (rc *ResponseCache) Set(method, url string, headers http.Header, response *
 	key := rc.generateKey(method, url, headers)
 	
 	if ttl == 0 {
-		ttl = rc.ttl
+		ttl = rc.TTL()
 	}
 	
 	entry := &CacheEntry{
@@ -102,6 +102,20 @@ func (rc *ResponseCache) Set(method, url string, headers http.Header, response *
 	rc.mu.Unlock()
 }
 
+// TTL 默认缓存时长
+func (rc *ResponseCache) TTL() time.Duration {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return rc.ttl
+}
+
+// SetTTL 修改默认缓存时长，只影响之后写入的条目
+func (rc *ResponseCache) SetTTL(ttl time.Duration) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.ttl = ttl
+}
+
 // Delete 删除缓存条目
 func (rc *ResponseCache) Delete(method, url string, headers http.Header) {
 	key := rc.generateKey(method, url, headers)
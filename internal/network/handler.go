@@ -0,0 +1,79 @@
+package network
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+)
+
+// Handler /netconfig 管理员命令的处理器，用于在运行时调整网络加速器阈值
+type Handler struct {
+	accelerator *NetworkAccelerator
+	adminIDs    []int64
+}
+
+// NewHandler 创建网络加速器阈值处理器
+func NewHandler(accelerator *NetworkAccelerator, adminIDs []int64) *Handler {
+	return &Handler{accelerator: accelerator, adminIDs: adminIDs}
+}
+
+// Register 注册 /netconfig 命令，仅限管理员
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.NetConfigCommand, h.NetConfig, middleware.AdminOnly(h.adminIDs))
+}
+
+// NetConfig 不带参数时查看当前阈值，带 key=value 参数时修改对应阈值
+func (h *Handler) NetConfig(ctx *middleware.Context) error {
+	current := h.accelerator.Thresholds()
+	if strings.TrimSpace(ctx.Args) == "" {
+		return ctx.Reply(formatThresholds(current))
+	}
+
+	updated, err := parseThresholds(current, ctx.Args)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error() + "\n" + ui.FormatNetConfigUsage())
+	}
+	if err := h.accelerator.SetThresholds(updated); err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+
+	log.Printf("🌐 管理员 %d 调整网络加速器阈值: %+v", ctx.UserID, updated)
+	return ctx.Reply("✅ 已更新\n\n" + formatThresholds(updated))
+}
+
+// parseThresholds 在当前阈值的基础上应用 key=value 形式的修改
+func parseThresholds(current Thresholds, args string) (Thresholds, error) {
+	for _, field := range strings.Fields(args) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return current, fmt.Errorf("参数格式错误: %s", field)
+		}
+
+		var err error
+		switch key {
+		case "error_rate":
+			current.ErrorRate, err = strconv.ParseFloat(value, 64)
+		case "latency":
+			current.Latency, err = time.ParseDuration(value)
+		case "check_interval":
+			current.CheckInterval, err = time.ParseDuration(value)
+		case "cache_ttl":
+			current.CacheTTL, err = time.ParseDuration(value)
+		default:
+			return current, fmt.Errorf("未知参数: %s", key)
+		}
+		if err != nil {
+			return current, fmt.Errorf("%s 的值无效: %s", key, value)
+		}
+	}
+	return current, nil
+}
+
+func formatThresholds(t Thresholds) string {
+	return ui.FormatNetworkThresholds(t.ErrorRate, t.Latency, t.CheckInterval, t.CacheTTL)
+}
@@ -46,20 +46,24 @@ type NetworkMonitor struct {
 	checkInterval    time.Duration
 	switchThreshold  float64  // 错误率阈值
 	latencyThreshold time.Duration // 延迟阈值
+
+	// 因超过阈值切换接入点时的告警回调
+	onAlert func(message string)
 }
 
 // NewNetworkMonitor 创建网络监控器
 func NewNetworkMonitor(optimizer *NetworkOptimizer) *NetworkMonitor {
 	ctx, cancel := context.WithCancel(context.Background())
 	
+	defaults := DefaultThresholds()
 	monitor := &NetworkMonitor{
 		optimizer:        optimizer,
 		stats:           make(map[string]*NetworkStats),
 		ctx:             ctx,
 		cancel:          cancel,
-		checkInterval:   30 * time.Second,
-		switchThreshold: 0.3, // 30%错误率
-		latencyThreshold: 200 * time.Millisecond,
+		checkInterval:   defaults.CheckInterval,
+		switchThreshold: defaults.ErrorRate,
+		latencyThreshold: defaults.Latency,
 	}
 	
 	// 启动监控协程
@@ -128,9 +132,10 @@ func (nm *NetworkMonitor) GetAllStats() map[string]*NetworkStats {
 	return result
 }
 
-// startMonitoring 启动监控
+// startMonitoring 启动监控，检查间隔在运行时调整后于下一次检查生效
 func (nm *NetworkMonitor) startMonitoring() {
-	ticker := time.NewTicker(nm.checkInterval)
+	interval := nm.Thresholds().CheckInterval
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 	
 	for {
@@ -139,6 +144,10 @@ func (nm *NetworkMonitor) startMonitoring() {
 			return
 		case <-ticker.C:
 			nm.checkAndSwitch()
+			if current := nm.Thresholds().CheckInterval; current != interval {
+				interval = current
+				ticker.Reset(interval)
+			}
 		}
 	}
 }
@@ -158,28 +167,32 @@ func (nm *NetworkMonitor) checkAndSwitch() {
 	// 检查是否需要切换
 	needSwitch := false
 	reason := ""
+	thresholds := nm.Thresholds()
 	
-	if currentStats.ErrorRate > nm.switchThreshold {
+	if currentStats.ErrorRate > thresholds.ErrorRate {
 		needSwitch = true
-		reason = fmt.Sprintf("错误率过高: %.2f%%", currentStats.ErrorRate*100)
-	} else if currentStats.Latency > nm.latencyThreshold {
+		reason = fmt.Sprintf("错误率过高: %.2f%%（阈值 %.0f%%）", currentStats.ErrorRate*100, thresholds.ErrorRate*100)
+	} else if currentStats.Latency > thresholds.Latency {
 		needSwitch = true
-		reason = fmt.Sprintf("延迟过高: %v", currentStats.Latency)
+		reason = fmt.Sprintf("延迟过高: %v（阈值 %v）", currentStats.Latency, thresholds.Latency)
 	}
 	
 	if needSwitch {
-		log.Printf("检测到网络质量问题: %s，尝试切换数据中心", reason)
+		log.Printf("检测到网络质量问题: %s，尝试切换接入点", reason)
 		
-		// 重新测试所有数据中心
+		// 重新测试所有接入点
 		newDC, err := nm.optimizer.FindBestDatacenter(nm.ctx)
 		if err != nil {
-			log.Printf("切换数据中心失败: %v", err)
+			log.Printf("切换接入点失败: %v", err)
+			nm.alert(fmt.Sprintf("⚠️ 网络质量告警：%s\n❌ 切换接入点失败: %v", reason, err))
 			return
 		}
 		
 		if newDC.Endpoint != currentDC.Endpoint {
-			log.Printf("已切换到新的数据中心: %s -> %s (延迟: %v)", 
+			log.Printf("已切换到新的接入点: %s -> %s (延迟: %v)", 
 				currentDC.Name, newDC.Name, newDC.Latency)
+			nm.alert(fmt.Sprintf("⚠️ 网络质量告警：%s\n🔀 已切换接入点: %s -> %s（延迟 %v）",
+				reason, currentDC.Name, newDC.Name, newDC.Latency))
 		}
 	}
 }
@@ -198,6 +211,46 @@ func (nm *NetworkMonitor) SetThresholds(errorRate float64, latency time.Duration
 	nm.latencyThreshold = latency
 }
 
+// Thresholds 当前的切换阈值和检查间隔（不含缓存时长）
+func (nm *NetworkMonitor) Thresholds() Thresholds {
+	nm.mu.RLock()
+	defer nm.mu.RUnlock()
+
+	return Thresholds{
+		ErrorRate:     nm.switchThreshold,
+		Latency:       nm.latencyThreshold,
+		CheckInterval: nm.checkInterval,
+	}
+}
+
+// applyThresholds 应用已校验的阈值和检查间隔
+func (nm *NetworkMonitor) applyThresholds(t Thresholds) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+
+	nm.switchThreshold = t.ErrorRate
+	nm.latencyThreshold = t.Latency
+	nm.checkInterval = t.CheckInterval
+}
+
+// SetAlertHandler 设置告警回调
+func (nm *NetworkMonitor) SetAlertHandler(alert func(message string)) {
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	nm.onAlert = alert
+}
+
+// alert 发送告警，未设置回调时忽略
+func (nm *NetworkMonitor) alert(message string) {
+	nm.mu.RLock()
+	onAlert := nm.onAlert
+	nm.mu.RUnlock()
+
+	if onAlert != nil {
+		onAlert(message)
+	}
+}
+
 // ResetStats 重置统计信息
 func (nm *NetworkMonitor) ResetStats() {
 	nm.mu.Lock()
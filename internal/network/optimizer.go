@@ -5,15 +5,19 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 )
 
-// TelegramDC 表示Telegram数据中心信息
+// DefaultAPIEndpoint 官方 Bot API 地址
+const DefaultAPIEndpoint = "https://api.telegram.org"
+
+// TelegramDC 表示一个 Bot API 接入点（官方地址或自建的本地 Bot API 服务器）
 type TelegramDC struct {
 	ID       int
 	Name     string
-	Endpoint string
+	Endpoint string // Bot API 基础地址，如 https://api.telegram.org
 	Location string
 	Latency  time.Duration
 	Success  bool
@@ -24,23 +28,51 @@ type NetworkOptimizer struct {
 	datacenters []TelegramDC
 	bestDC      *TelegramDC
 	client      *http.Client
+	proxy       string // 已隐藏密码的代理地址
 	mu          sync.RWMutex
+
+	// 最佳接入点变化时的回调
+	onSwitch func(dc *TelegramDC)
+}
+
+// NewNetworkOptimizer 创建网络优化器，endpoints 为待探测的 Bot API 地址，为空时使用官方地址
+func NewNetworkOptimizer(endpoints ...string) *NetworkOptimizer {
+	optimizer, _ := NewNetworkOptimizerWithProxy(ProxyConfig{}, endpoints...)
+	return optimizer
 }
 
-// NewNetworkOptimizer 创建网络优化器
-func NewNetworkOptimizer() *NetworkOptimizer {
-	// 定义Telegram的主要数据中心
-	datacenters := []TelegramDC{
-		{ID: 1, Name: "DC1-Miami", Endpoint: "149.154.175.53", Location: "Miami, US", Latency: 0, Success: false},
-		{ID: 2, Name: "DC2-Amsterdam", Endpoint: "149.154.167.51", Location: "Amsterdam, NL", Latency: 0, Success: false},
-		{ID: 3, Name: "DC3-Miami", Endpoint: "149.154.175.100", Location: "Miami, US", Latency: 0, Success: false},
-		{ID: 4, Name: "DC4-Amsterdam", Endpoint: "149.154.167.91", Location: "Amsterdam, NL", Latency: 0, Success: false},
-		{ID: 5, Name: "DC5-Singapore", Endpoint: "91.108.56.130", Location: "Singapore, SG", Latency: 0, Success: false},
+// NewNetworkOptimizerWithProxy 创建通过代理访问 Telegram 的网络优化器
+func NewNetworkOptimizerWithProxy(proxy ProxyConfig, endpoints ...string) (*NetworkOptimizer, error) {
+	proxyFunc, proxyInfo, err := proxy.proxyFunc()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(endpoints) == 0 {
+		endpoints = []string{DefaultAPIEndpoint}
+	}
+
+	datacenters := make([]TelegramDC, 0, len(endpoints))
+	for i, endpoint := range endpoints {
+		endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+		if endpoint == "" {
+			continue
+		}
+		location := "local"
+		if endpoint == DefaultAPIEndpoint {
+			location = "cloud"
+		}
+		datacenters = append(datacenters, TelegramDC{
+			ID:       i + 1,
+			Name:     strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://"),
+			Endpoint: endpoint,
+			Location: location,
+		})
 	}
 
 	// 创建优化的HTTP客户端
 	transport := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+		Proxy: proxyFunc,
 		DialContext: (&net.Dialer{
 			Timeout:   10 * time.Second,  // 连接超时
 			KeepAlive: 60 * time.Second,  // 增加保活时间，复用连接
@@ -67,28 +99,37 @@ func NewNetworkOptimizer() *NetworkOptimizer {
 	return &NetworkOptimizer{
 		datacenters: datacenters,
 		client:      client,
-	}
+		proxy:       proxyInfo,
+	}, nil
 }
 
-// TestDatacenterLatency 测试数据中心延迟
+// TestDatacenterLatency 通过 HTTPS 请求测试接入点延迟（同时预热连接池）
 func (no *NetworkOptimizer) TestDatacenterLatency(ctx context.Context, dc *TelegramDC) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, dc.Endpoint, nil)
+	if err != nil {
+		dc.Success = false
+		dc.Latency = time.Hour
+		return err
+	}
+
 	start := time.Now()
-	
-	// 使用TCP连接测试延迟
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:443", dc.Endpoint), 10*time.Second)
+	resp, err := no.client.Do(req)
 	if err != nil {
 		dc.Success = false
 		dc.Latency = time.Hour // 设置一个很大的延迟表示失败
 		return err
 	}
-	defer conn.Close()
+	resp.Body.Close()
 
 	dc.Latency = time.Since(start)
 	dc.Success = true
 	return nil
 }
 
-// FindBestDatacenter 查找最佳数据中心
+// FindBestDatacenter 查找延迟最低的接入点
 func (no *NetworkOptimizer) FindBestDatacenter(ctx context.Context) (*TelegramDC, error) {
 	var wg sync.WaitGroup
 	
@@ -116,17 +157,38 @@ func (no *NetworkOptimizer) FindBestDatacenter(ctx context.Context) (*TelegramDC
 	}
 
 	if bestDC == nil {
-		return nil, fmt.Errorf("无法连接到任何Telegram数据中心")
+		return nil, fmt.Errorf("无法连接到任何Bot API接入点")
 	}
 
 	no.mu.Lock()
+	previous := no.bestDC
 	no.bestDC = bestDC
+	onSwitch := no.onSwitch
 	no.mu.Unlock()
 
+	if onSwitch != nil && (previous == nil || previous.Endpoint != bestDC.Endpoint) {
+		onSwitch(bestDC)
+	}
+
 	return bestDC, nil
 }
 
-// GetBestDatacenter 获取最佳数据中心
+// PrimaryEndpoint 返回首个配置的接入点地址
+func (no *NetworkOptimizer) PrimaryEndpoint() string {
+	if len(no.datacenters) == 0 {
+		return DefaultAPIEndpoint
+	}
+	return no.datacenters[0].Endpoint
+}
+
+// SetSwitchCallback 设置最佳接入点变化时的回调
+func (no *NetworkOptimizer) SetSwitchCallback(callback func(dc *TelegramDC)) {
+	no.mu.Lock()
+	defer no.mu.Unlock()
+	no.onSwitch = callback
+}
+
+// GetBestDatacenter 获取最佳接入点
 func (no *NetworkOptimizer) GetBestDatacenter() *TelegramDC {
 	no.mu.RLock()
 	defer no.mu.RUnlock()
@@ -138,7 +200,7 @@ func (no *NetworkOptimizer) GetOptimizedClient() *http.Client {
 	return no.client
 }
 
-// GetDatacenterStats 获取所有数据中心统计信息
+// GetDatacenterStats 获取所有接入点统计信息
 func (no *NetworkOptimizer) GetDatacenterStats() []TelegramDC {
 	no.mu.RLock()
 	defer no.mu.RUnlock()
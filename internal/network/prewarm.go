@@ -0,0 +1,132 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// keepAliveInterval 对局期间的保活间隔，需小于连接池的空闲超时
+const keepAliveInterval = 20 * time.Second
+
+// keepAliveMaxGame 单局最长保活时间，防止未正常结束的对局一直占用保活
+const keepAliveMaxGame = 5 * time.Minute
+
+// keepAlive 记录进行中的对局，有对局时定期保活连接
+type keepAlive struct {
+	mu      sync.Mutex
+	games   map[string]time.Time
+	running bool
+	quit    chan struct{}
+}
+
+func newKeepAlive() *keepAlive {
+	return &keepAlive{
+		games: make(map[string]time.Time),
+		quit:  make(chan struct{}),
+	}
+}
+
+func (k *keepAlive) stop() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	select {
+	case <-k.quit:
+	default:
+		close(k.quit)
+	}
+}
+
+// Prewarm 预热到 Bot API 的连接，避免空闲后首次发送骰子时重新握手。
+// Bot API 客户端需使用 HTTPClient 创建，预热的才是发送骰子所用的连接池
+func (na *NetworkAccelerator) Prewarm(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, na.warmURL, nil)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	resp, err := na.HTTPClient().Do(req)
+	na.monitor.RecordRequest(na.warmURL, time.Since(start), err != nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// HealthCheck 检查配置的主 Bot API 接入点是否可达
+func (na *NetworkAccelerator) HealthCheck(ctx context.Context) error {
+	endpoint := na.optimizer.PrimaryEndpoint()
+	dc := &TelegramDC{Endpoint: endpoint}
+	if err := na.optimizer.TestDatacenterLatency(ctx, dc); err != nil {
+		return fmt.Errorf("Bot API 接入点 %s 不可达: %v", endpoint, err)
+	}
+	return nil
+}
+
+// GameStarted 对局开始（有玩家加入）时预热连接，并在对局期间保持连接活跃
+func (na *NetworkAccelerator) GameStarted(gameID string) {
+	go func() {
+		if err := na.Prewarm(context.Background()); err != nil {
+			log.Printf("⚠️ 连接预热失败: %v", err)
+		}
+	}()
+
+	k := na.keepAlive
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.games[gameID] = time.Now()
+	if !k.running {
+		k.running = true
+		go na.keepAliveLoop()
+	}
+}
+
+// GameFinished 对局结束，没有进行中的对局时停止保活
+func (na *NetworkAccelerator) GameFinished(gameID string) {
+	k := na.keepAlive
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	delete(k.games, gameID)
+}
+
+// keepAliveLoop 定期发送轻量请求保持连接
+func (na *NetworkAccelerator) keepAliveLoop() {
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	k := na.keepAlive
+	for {
+		select {
+		case <-ticker.C:
+			k.mu.Lock()
+			for gameID, started := range k.games {
+				if time.Since(started) > keepAliveMaxGame {
+					delete(k.games, gameID)
+				}
+			}
+			if len(k.games) == 0 {
+				k.running = false
+				k.mu.Unlock()
+				return
+			}
+			k.mu.Unlock()
+
+			if err := na.Prewarm(context.Background()); err != nil {
+				log.Printf("⚠️ 连接保活失败: %v", err)
+			}
+		case <-k.quit:
+			return
+		}
+	}
+}
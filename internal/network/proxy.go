@@ -0,0 +1,95 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyConfig 访问 Telegram 使用的代理配置，支持 http、https、socks5 和 socks5h
+type ProxyConfig struct {
+	URL      string
+	Username string
+	Password string
+}
+
+// Enabled 是否配置了代理
+func (p ProxyConfig) Enabled() bool {
+	return strings.TrimSpace(p.URL) != ""
+}
+
+// Parse 解析并校验代理地址，单独配置的用户名密码优先于地址中的认证信息
+func (p ProxyConfig) Parse() (*url.URL, error) {
+	proxyURL, err := url.Parse(strings.TrimSpace(p.URL))
+	if err != nil {
+		return nil, fmt.Errorf("代理地址格式错误: %v", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("不支持的代理协议 %q，仅支持 http、https、socks5、socks5h", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("代理地址缺少主机: %s", proxyURL.Redacted())
+	}
+
+	if p.Username != "" {
+		proxyURL.User = url.UserPassword(p.Username, p.Password)
+	}
+	return proxyURL, nil
+}
+
+// proxyFunc 返回 Transport 使用的代理函数，未配置时沿用环境变量
+func (p ProxyConfig) proxyFunc() (func(*http.Request) (*url.URL, error), string, error) {
+	if !p.Enabled() {
+		return http.ProxyFromEnvironment, "", nil
+	}
+
+	proxyURL, err := p.Parse()
+	if err != nil {
+		return nil, "", err
+	}
+	return http.ProxyURL(proxyURL), proxyURL.Redacted(), nil
+}
+
+// SelfTest 启动自检：确认至少一个 Bot API 接入点可以连通
+func (no *NetworkOptimizer) SelfTest(ctx context.Context) error {
+	no.mu.RLock()
+	datacenters := make([]TelegramDC, len(no.datacenters))
+	copy(datacenters, no.datacenters)
+	no.mu.RUnlock()
+
+	var failures []string
+	for i := range datacenters {
+		dc := &datacenters[i]
+		if err := no.TestDatacenterLatency(ctx, dc); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", dc.Endpoint, err))
+			continue
+		}
+		return nil
+	}
+
+	if no.proxy != "" {
+		return fmt.Errorf("通过代理 %s 无法连接任何 Bot API 接入点，请检查代理地址和认证信息: %s",
+			no.proxy, strings.Join(failures, "; "))
+	}
+	return fmt.Errorf("无法连接任何 Bot API 接入点，受限网络请配置 PROXY_URL: %s", strings.Join(failures, "; "))
+}
+
+// ProxyInfo 返回当前使用的代理地址（已隐藏密码），未配置时为空
+func (no *NetworkOptimizer) ProxyInfo() string {
+	return no.proxy
+}
+
+// SelfTest 启动自检：确认通过加速器的连接（含代理）至少能连上一个 Bot API 接入点
+func (na *NetworkAccelerator) SelfTest(ctx context.Context) error {
+	return na.optimizer.SelfTest(ctx)
+}
+
+// ProxyInfo 返回当前使用的代理地址（已隐藏密码），未配置时为空
+func (na *NetworkAccelerator) ProxyInfo() string {
+	return na.optimizer.ProxyInfo()
+}
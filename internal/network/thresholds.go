@@ -0,0 +1,57 @@
+package network
+
+import (
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+)
+
+// Thresholds 网络加速器的接入点切换阈值和缓存时长
+type Thresholds struct {
+	ErrorRate     float64       `json:"error_rate"`     // 当前接入点错误率超过该值时重新选择接入点
+	Latency       time.Duration `json:"latency"`        // 当前接入点平均延迟超过该值时重新选择接入点
+	CheckInterval time.Duration `json:"check_interval"` // 检查网络质量的间隔
+	CacheTTL      time.Duration `json:"cache_ttl"`      // GET 响应的缓存时长
+}
+
+// DefaultThresholds 默认阈值：错误率 30%、延迟 200ms、每 30 秒检查、缓存 5 分钟
+func DefaultThresholds() Thresholds {
+	return Thresholds{
+		ErrorRate:     0.3,
+		Latency:       200 * time.Millisecond,
+		CheckInterval: 30 * time.Second,
+		CacheTTL:      5 * time.Minute,
+	}
+}
+
+// ThresholdsFromConfig 从配置读取并校验阈值
+func ThresholdsFromConfig(cfg *config.Config) (Thresholds, error) {
+	t := Thresholds{
+		ErrorRate:     cfg.AcceleratorErrorRate,
+		Latency:       time.Duration(cfg.AcceleratorLatency) * time.Millisecond,
+		CheckInterval: time.Duration(cfg.AcceleratorCheckInterval) * time.Second,
+		CacheTTL:      time.Duration(cfg.AcceleratorCacheTTL) * time.Second,
+	}
+	if err := t.Validate(); err != nil {
+		return Thresholds{}, fmt.Errorf("网络加速器配置无效: %v", err)
+	}
+	return t, nil
+}
+
+// Validate 校验阈值范围
+func (t Thresholds) Validate() error {
+	if t.ErrorRate <= 0 || t.ErrorRate > 1 {
+		return fmt.Errorf("错误率阈值必须在 0 到 1 之间")
+	}
+	if t.Latency < 10*time.Millisecond || t.Latency > time.Minute {
+		return fmt.Errorf("延迟阈值必须在 10ms 到 1m 之间")
+	}
+	if t.CheckInterval < 5*time.Second || t.CheckInterval > time.Hour {
+		return fmt.Errorf("检查间隔必须在 5s 到 1h 之间")
+	}
+	if t.CacheTTL < 0 || t.CacheTTL > 24*time.Hour {
+		return fmt.Errorf("缓存时长必须在 0 到 24h 之间")
+	}
+	return nil
+}
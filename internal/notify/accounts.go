@@ -0,0 +1,84 @@
+package notify
+
+import (
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// AccountWatcher 定期检查等待中对局的发起者是否已注销 Telegram 账号，及时让其对局过期退款
+type AccountWatcher struct {
+	db       *database.DB
+	client   telegram.Client
+	interval time.Duration
+	// 发现账号已注销时的回调
+	onDeleted func(userID int64)
+	quit      chan struct{}
+}
+
+// NewAccountWatcher 创建已注销账号检查器，interval 为检查间隔
+func NewAccountWatcher(db *database.DB, client telegram.Client, interval time.Duration, onDeleted func(userID int64)) *AccountWatcher {
+	return &AccountWatcher{
+		db:        db,
+		client:    client,
+		interval:  interval,
+		onDeleted: onDeleted,
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start 启动定期检查
+func (w *AccountWatcher) Start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				w.Check()
+			case <-w.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定期检查
+func (w *AccountWatcher) Stop() {
+	close(w.quit)
+}
+
+// Check 逐个查询等待中对局发起者在群内的成员信息，API 报告账号已停用或返回空用户时视为已注销
+func (w *AccountWatcher) Check() {
+	creators, err := w.db.GetWaitingCreators()
+	if err != nil {
+		log.Printf("⚠️ 获取等待中对局的发起者失败: %v", err)
+		return
+	}
+
+	checked := make(map[int64]bool)
+	for _, game := range creators {
+		if checked[game.Player1ID] {
+			continue
+		}
+
+		member, err := w.client.GetChatMember(tgbotapi.GetChatMemberConfig{
+			ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: game.ChatID, UserID: game.Player1ID},
+		})
+		if err != nil && !IsAccountDeleted(err) {
+			// 网络错误或机器人已不在群内，下次再查
+			log.Printf("⚠️ 检查用户 %d 账号状态失败: %v", game.Player1ID, err)
+			continue
+		}
+		checked[game.Player1ID] = true
+		if err == nil && !IsDeletedUser(member.User) {
+			continue
+		}
+		w.onDeleted(game.Player1ID)
+	}
+}
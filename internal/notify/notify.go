@@ -0,0 +1,308 @@
+package notify
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Notifier 向用户发送私信通知，遵循用户的通知偏好
+type Notifier struct {
+	db     *database.DB
+	client telegram.Client
+	// 私信发现用户账号已注销时的回调
+	onAccountDeleted func(userID int64)
+}
+
+// NewNotifier 创建私信通知器
+func NewNotifier(db *database.DB, client telegram.Client) *Notifier {
+	return &Notifier{db: db, client: client}
+}
+
+// SetAccountDeletedCallback 设置私信发现用户账号已注销时的回调
+func (n *Notifier) SetAccountDeletedCallback(callback func(userID int64)) {
+	n.onAccountDeleted = callback
+}
+
+// GameExpired 对局超时退款后私信通知发起者
+func (n *Notifier) GameExpired(gameID string) {
+	game, err := n.db.GetGame(gameID)
+	if err != nil || game == nil {
+		log.Printf("⚠️ 超时通知获取对局 %s 失败: %v", gameID, err)
+		return
+	}
+	if game.Status != models.GameStatusExpired {
+		return
+	}
+
+	user, err := n.db.GetUser(game.Player1ID)
+	if err != nil || user == nil {
+		log.Printf("⚠️ 超时通知获取用户 %d 失败: %v", game.Player1ID, err)
+		return
+	}
+
+	n.Send(user.ID, ui.GameExpiredDM(game.ID, game.BetAmount, user.Balance))
+}
+
+// Send 向用户发送私信，用户关闭通知或屏蔽机器人时跳过
+func (n *Notifier) Send(userID int64, text string) {
+	ok, err := n.db.CanDMUser(userID)
+	if err != nil {
+		log.Printf("⚠️ 获取用户 %d 通知设置失败: %v", userID, err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	if _, err := n.client.Send(tgbotapi.NewMessage(userID, text)); err != nil {
+		if IsAccountDeleted(err) && n.onAccountDeleted != nil {
+			n.onAccountDeleted(userID)
+			return
+		}
+		if IsUnreachable(err) {
+			// 用户屏蔽了机器人或从未私聊过，停止后续私信直到用户再次私聊
+			if err := n.db.SetUserDMBlocked(userID, true); err != nil {
+				log.Printf("⚠️ 标记用户 %d 屏蔽私信失败: %v", userID, err)
+			}
+			log.Printf("🔕 用户 %d 无法接收私信，已关闭私信通知: %v", userID, err)
+			return
+		}
+		log.Printf("⚠️ 私信用户 %d 失败: %v", userID, err)
+	}
+}
+
+// Reachable 用户主动私聊机器人时调用，恢复私信通知
+func (n *Notifier) Reachable(userID int64) {
+	if err := n.db.SetUserDMBlocked(userID, false); err != nil {
+		log.Printf("⚠️ 恢复用户 %d 私信通知失败: %v", userID, err)
+	}
+}
+
+// IsUnreachable 判断发送失败是否因为用户屏蔽了机器人、已注销或从未开启过私聊
+func IsUnreachable(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 403 && apiErr.Code != 400 {
+		return false
+	}
+
+	message := strings.ToLower(apiErr.Message)
+	for _, reason := range []string{"bot was blocked", "user is deactivated", "bot can't initiate", "chat not found"} {
+		if strings.Contains(message, reason) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAccountDeleted 判断请求失败是否因为用户的 Telegram 账号已注销
+func IsAccountDeleted(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) || apiErr.Code != 403 && apiErr.Code != 400 {
+		return false
+	}
+	return strings.Contains(strings.ToLower(apiErr.Message), "user is deactivated")
+}
+
+// IsDeletedUser 判断 Telegram 返回的用户是否为已注销账号：注销后名字被清空或替换为 Deleted Account，且没有用户名
+func IsDeletedUser(user *tgbotapi.User) bool {
+	if user == nil || user.ID == 0 {
+		return true
+	}
+	if user.IsBot || user.UserName != "" {
+		return false
+	}
+	return user.FirstName == "" || user.FirstName == "Deleted Account"
+}
+
+// AdminAlert 返回向管理员告警群组发送消息的函数，chatID 为 0 时只记录日志
+func AdminAlert(client telegram.Client, chatID int64) func(message string) {
+	return func(message string) {
+		log.Printf("🚨 %s", message)
+		if chatID == 0 {
+			return
+		}
+		if _, err := client.Send(tgbotapi.NewMessage(chatID, message)); err != nil {
+			log.Printf("⚠️ 发送管理员告警失败: %v", err)
+		}
+	}
+}
+
+// ExposureAlert 返回群组反复触发每小时下注上限时向管理员告警群组发送告警的回调
+func (n *Notifier) ExposureAlert(alertChatID int64) func(chatID int64, hits int, hourlyCap int64) {
+	alert := AdminAlert(n.client, alertChatID)
+	return func(chatID int64, hits int, hourlyCap int64) {
+		var title string
+		if chat, err := n.db.GetChat(chatID); err != nil {
+			log.Printf("⚠️ 下注上限告警获取群组 %d 失败: %v", chatID, err)
+		} else if chat != nil {
+			title = chat.Title
+		}
+		alert(ui.FormatExposureAlert(chatID, title, hits, hourlyCap))
+	}
+}
+
+// WithdrawalRequested 返回用户提交提现申请后向管理员告警群组发送待审核提醒的回调
+func (n *Notifier) WithdrawalRequested(alertChatID int64) func(withdrawal *models.Withdrawal) {
+	alert := AdminAlert(n.client, alertChatID)
+	return func(withdrawal *models.Withdrawal) {
+		var name string
+		if user, err := n.db.GetUser(withdrawal.UserID); err != nil {
+			log.Printf("⚠️ 提现告警获取用户 %d 失败: %v", withdrawal.UserID, err)
+		} else if user != nil {
+			name = ui.PublicName(user.ID, user.Username, user.FirstName, false)
+		}
+		alert(ui.FormatWithdrawalAlert(withdrawal, name))
+	}
+}
+
+// ReconcileAlert 返回余额对账发现新差异后向管理员告警群组发送告警的回调
+func (n *Notifier) ReconcileAlert(alertChatID int64) func(run *models.ReconcileRun) {
+	alert := AdminAlert(n.client, alertChatID)
+	return func(run *models.ReconcileRun) {
+		names := make(map[int64]string, len(run.Issues))
+		for _, issue := range run.Issues {
+			if user, err := n.db.GetUser(issue.UserID); err != nil {
+				log.Printf("⚠️ 对账告警获取用户 %d 失败: %v", issue.UserID, err)
+			} else if user != nil {
+				names[user.ID] = ui.PublicName(user.ID, user.Username, user.FirstName, false)
+			}
+		}
+		alert(ui.FormatReconcileAlert(run, names))
+	}
+}
+
+// RiskAlert 返回风控标记可疑账户后向管理员告警群组发送告警的回调
+func (n *Notifier) RiskAlert(alertChatID int64) func(events []*models.RiskEvent) {
+	alert := AdminAlert(n.client, alertChatID)
+	return func(events []*models.RiskEvent) {
+		names := make(map[int64]string, len(events))
+		for _, event := range events {
+			if _, ok := names[event.UserID]; ok {
+				continue
+			}
+			if user, err := n.db.GetUser(event.UserID); err != nil {
+				log.Printf("⚠️ 风控告警获取用户 %d 失败: %v", event.UserID, err)
+			} else if user != nil {
+				names[user.ID] = ui.PublicName(user.ID, user.Username, user.FirstName, false)
+			}
+		}
+		alert(ui.FormatRiskAlert(events, names))
+	}
+}
+
+// WithdrawalReviewed 提现申请被批准或拒绝后私信通知用户
+func (n *Notifier) WithdrawalReviewed(withdrawal *models.Withdrawal) {
+	n.Send(withdrawal.UserID, ui.WithdrawalReviewedDM(withdrawal))
+}
+
+// DisputeOpened 返回玩家对对局结果提出争议后向管理员告警群组发送待审核提醒的回调
+func (n *Notifier) DisputeOpened(alertChatID int64) func(dispute *models.Dispute) {
+	alert := AdminAlert(n.client, alertChatID)
+	return func(dispute *models.Dispute) {
+		var name string
+		if user, err := n.db.GetUser(dispute.ReporterID); err != nil {
+			log.Printf("⚠️ 争议告警获取用户 %d 失败: %v", dispute.ReporterID, err)
+		} else if user != nil {
+			name = ui.PublicName(user.ID, user.Username, user.FirstName, false)
+		}
+		alert(ui.FormatDisputeAlert(dispute, name))
+	}
+}
+
+// DisputeResolved 争议审核完成后私信通知对局双方
+func (n *Notifier) DisputeResolved(dispute *models.Dispute) {
+	for _, userID := range []int64{dispute.WinnerID, dispute.LoserID} {
+		n.Send(userID, ui.DisputeResolvedDM(dispute, userID))
+	}
+}
+
+// DepositCredited 链上充值自动入账后私信通知用户
+func (n *Notifier) DepositCredited(userID int64, amount float64, txHash string) {
+	user, err := n.db.GetUser(userID)
+	if err != nil || user == nil {
+		log.Printf("⚠️ 充值通知获取用户 %d 失败: %v", userID, err)
+		return
+	}
+	n.Send(user.ID, ui.DepositCreditedDM(amount, txHash, user.Balance))
+}
+
+// recordGameMessage 将为对局发送的群内消息写入对局时间线
+func (n *Notifier) recordGameMessage(game *models.Game, messageID int, text string) {
+	err := n.db.RecordGameEvent(&models.GameEvent{
+		GameID:    game.ID,
+		Type:      models.GameEventMessage,
+		ChatID:    game.ChatID,
+		MessageID: messageID,
+		Detail:    text,
+	})
+	if err != nil {
+		log.Printf("⚠️ 记录对局 %s 消息失败: %v", game.ID, err)
+	}
+}
+
+// QueueRemoved 管理员将玩家移出排队时私信通知
+func (n *Notifier) QueueRemoved(entry *models.QueueEntry) {
+	var title string
+	if chat, err := n.db.GetChat(entry.ChatID); err != nil {
+		log.Printf("⚠️ 排队通知获取群组 %d 失败: %v", entry.ChatID, err)
+	} else if chat != nil {
+		title = chat.Title
+	}
+	n.Send(entry.PlayerID, ui.QueueRemovedDM(entry, title))
+}
+
+// SettlementDelayed 开骰后迟迟未结算时私信双方，说明延迟并告知自动退款的期限
+func (n *Notifier) SettlementDelayed(game *models.Game, refundIn time.Duration) {
+	players := []int64{game.Player1ID}
+	if game.Player2ID != nil {
+		players = append(players, *game.Player2ID)
+	}
+	for _, playerID := range players {
+		n.Send(playerID, ui.SettlementDelayedDM(game.ID, game.BetAmount, refundIn))
+	}
+}
+
+// GameAborted 对局中止退款后在群内公告，并私信通知双方，reason 为 models.GameAbort* 之一
+func (n *Notifier) GameAborted(game *models.Game, reason string) {
+	text := ui.FormatGameAborted(n.chatLanguage(game.ChatID), game.ID, game.BetAmount, reason)
+	if sent, err := n.client.Send(tgbotapi.NewMessage(game.ChatID, text)); err != nil {
+		log.Printf("⚠️ 发送对局 %s 中止公告失败: %v", game.ID, err)
+	} else {
+		n.recordGameMessage(game, sent.MessageID, text)
+	}
+
+	players := []int64{game.Player1ID}
+	if game.Player2ID != nil {
+		players = append(players, *game.Player2ID)
+	}
+	for _, playerID := range players {
+		user, err := n.db.GetUser(playerID)
+		if err != nil || user == nil {
+			log.Printf("⚠️ 中止通知获取用户 %d 失败: %v", playerID, err)
+			continue
+		}
+		n.Send(user.ID, ui.GameAbortedDM(game.ID, game.BetAmount, user.Balance, reason))
+	}
+}
+
+// chatLanguage 群内公告使用的群组语言，获取失败时使用默认语言
+func (n *Notifier) chatLanguage(chatID int64) string {
+	chat, err := n.db.GetChat(chatID)
+	if err != nil {
+		log.Printf("⚠️ 获取群组 %d 语言失败: %v", chatID, err)
+		return ui.DefaultLanguage
+	}
+	if chat == nil {
+		return ui.DefaultLanguage
+	}
+	return ui.NormalizeLanguage(chat.Language)
+}
@@ -0,0 +1,231 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// pollInterval 检查待发送消息的周期，写入消息后可通过 Notify 立即发送
+	pollInterval = time.Second
+	// batchSize 每轮最多发送的消息数（每个群组一条）
+	batchSize = 50
+	// maxAttempts 单条消息的最多发送次数，用尽后放弃，同群组的后续消息继续投递
+	maxAttempts = 8
+	// maxBackoff 重试间隔上限
+	maxBackoff = 5 * time.Minute
+	// retention 已送达消息的保留时长
+	retention = 7 * 24 * time.Hour
+)
+
+// Sender 发件箱发送器：按写入顺序投递发件箱中的消息，同一群组内严格保序，
+// 消息送达后才标记完成，进程崩溃后重启会重新发送未标记的消息（至少投递一次）
+type Sender struct {
+	db     *database.DB
+	client telegram.Client
+
+	flushMu   sync.Mutex // 同一时间只有一轮发送
+	lastPurge time.Time
+	gate      func() bool // 返回 false 时后台不发送，由其他进程负责
+
+	wake chan struct{}
+	quit chan struct{}
+}
+
+// NewSender 创建发件箱发送器
+func NewSender(db *database.DB, client telegram.Client) *Sender {
+	return &Sender{
+		db:     db,
+		client: client,
+		wake:   make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start 启动后台发送，启动时立即补发上次进程退出前未送达的消息
+func (s *Sender) Start() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		s.flushIfActive()
+		for {
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			case <-s.quit:
+				return
+			}
+			s.flushIfActive()
+		}
+	}()
+}
+
+// SetGate 设置后台发送的开关，多进程部署时只由一个进程投递发件箱
+func (s *Sender) SetGate(gate func() bool) {
+	s.gate = gate
+}
+
+// flushIfActive 后台发送未被关闭时执行一轮发送
+func (s *Sender) flushIfActive() {
+	if s.gate != nil && !s.gate() {
+		return
+	}
+	s.Flush()
+}
+
+// Stop 停止后台发送，未送达的消息保留到下次启动
+func (s *Sender) Stop() {
+	close(s.quit)
+}
+
+// Notify 有新消息写入后唤醒发送器，不必等待下一个检查周期
+func (s *Sender) Notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue 写入不伴随对局状态变更的消息并唤醒发送器
+func (s *Sender) Enqueue(messages ...*models.OutboxMessage) error {
+	if err := s.db.EnqueueOutbox(messages); err != nil {
+		return fmt.Errorf("写入发件箱失败: %v", err)
+	}
+	s.Notify()
+	return nil
+}
+
+// Flush 发送所有已到发送时间的消息，返回本次送达的数量
+func (s *Sender) Flush() int {
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.purge()
+
+	sent := 0
+	for {
+		messages, err := s.db.GetDueOutbox(time.Now(), batchSize)
+		if err != nil {
+			log.Printf("⚠️ 读取发件箱失败: %v", err)
+			return sent
+		}
+		if len(messages) == 0 {
+			return sent
+		}
+
+		// 本轮每个群组只发一条，有消息送达时继续发送各群组的下一条，其余等待下一个周期
+		delivered := 0
+		for _, message := range messages {
+			if s.deliver(message) {
+				delivered++
+			}
+		}
+		sent += delivered
+		if delivered == 0 {
+			return sent
+		}
+	}
+}
+
+// deliver 发送一条消息并记录结果，送达并标记完成时返回 true；失败后按退避时间重试，无法送达或次数用尽时放弃
+func (s *Sender) deliver(message *models.OutboxMessage) bool {
+	msg := tgbotapi.NewMessage(message.ChatID, message.Text)
+	msg.ParseMode = message.ParseMode
+	if message.ReplyMarkup != "" {
+		var markup tgbotapi.InlineKeyboardMarkup
+		if err := json.Unmarshal([]byte(message.ReplyMarkup), &markup); err != nil {
+			s.fail(message, fmt.Errorf("解析键盘失败: %v", err))
+			return false
+		}
+		msg.ReplyMarkup = markup
+	}
+
+	sent, err := s.client.Send(msg)
+	if err != nil {
+		attempts := message.Attempts + 1
+		if permanent(err) || attempts >= maxAttempts {
+			s.fail(message, err)
+			return false
+		}
+		delay := backoff(attempts, err)
+		if dbErr := s.db.RetryOutbox(message.ID, err.Error(), time.Now().Add(delay)); dbErr != nil {
+			log.Printf("❌ 更新发件箱消息 %d 失败: %v", message.ID, dbErr)
+		}
+		log.Printf("⚠️ 发件箱消息 %d 发送失败（第 %d 次），%v 后重试: %v", message.ID, attempts, delay, err)
+		return false
+	}
+
+	// 已送达但未能标记时，下一个周期会重复发送一次
+	if err := s.db.MarkOutboxSent(message.ID); err != nil {
+		log.Printf("❌ 标记发件箱消息 %d 已送达失败: %v", message.ID, err)
+		return false
+	}
+	if message.GameID != "" {
+		if err := s.db.RecordGameEvent(&models.GameEvent{
+			GameID:    message.GameID,
+			Type:      models.GameEventMessage,
+			ChatID:    message.ChatID,
+			MessageID: sent.MessageID,
+			Detail:    message.Text,
+		}); err != nil {
+			log.Printf("⚠️ 记录对局 %s 消息失败: %v", message.GameID, err)
+		}
+	}
+	return true
+}
+
+// fail 放弃发送一条消息
+func (s *Sender) fail(message *models.OutboxMessage, err error) {
+	if dbErr := s.db.FailOutbox(message.ID, err.Error()); dbErr != nil {
+		log.Printf("❌ 更新发件箱消息 %d 失败: %v", message.ID, dbErr)
+	}
+	log.Printf("❌ 发件箱消息 %d 无法送达群组 %d，已放弃: %v", message.ID, message.ChatID, err)
+}
+
+// purge 每小时清理一次过期的已送达消息，调用方需持有 flushMu
+func (s *Sender) purge() {
+	if time.Since(s.lastPurge) < time.Hour {
+		return
+	}
+	s.lastPurge = time.Now()
+
+	if deleted, err := s.db.PurgeSentOutbox(time.Now().Add(-retention)); err != nil {
+		log.Printf("⚠️ 清理发件箱失败: %v", err)
+	} else if deleted > 0 {
+		log.Printf("🧹 已清理 %d 条已送达的发件箱消息", deleted)
+	}
+}
+
+// permanent 判断发送失败是否无法通过重试恢复（机器人被移出群组、群组不存在、消息格式错误等）
+func permanent(err error) bool {
+	var apiErr *tgbotapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.Code == 400 || apiErr.Code == 403
+}
+
+// backoff 第 attempts 次失败后的重试间隔，Telegram 限流时按其要求的时间等待
+func backoff(attempts int, err error) time.Duration {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		return time.Duration(apiErr.RetryAfter) * time.Second
+	}
+
+	delay := time.Second << uint(attempts)
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
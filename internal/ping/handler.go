@@ -0,0 +1,85 @@
+package ping
+
+import (
+	"log"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/pool"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /ping 命令的处理器，测量端到端延迟，管理员额外查看数据库、工作队列和发送额度
+type Handler struct {
+	db      *database.DB
+	workers *pool.WorkerPool
+	admins  map[int64]bool
+}
+
+// NewHandler 创建延迟诊断处理器，workers 为 nil 时不显示工作队列
+func NewHandler(db *database.DB, workers *pool.WorkerPool, adminIDs []int64) *Handler {
+	admins := make(map[int64]bool, len(adminIDs))
+	for _, id := range adminIDs {
+		admins[id] = true
+	}
+	return &Handler{db: db, workers: workers, admins: admins}
+}
+
+// Register 注册 /ping 命令，任何聊天中都可使用
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.PingCommand, h.Ping)
+}
+
+// Ping 先发送占位消息测量 Telegram 往返耗时，再编辑该消息填入测量结果
+func (h *Handler) Ping(ctx *middleware.Context) error {
+	received := time.Now()
+	report := ui.PingReport{Admin: h.admins[ctx.UserID]}
+	if ctx.Update.Message != nil {
+		report.UpdateDelay = received.Sub(ctx.Update.Message.Time())
+	}
+
+	msg := tgbotapi.NewMessage(ctx.ChatID, ui.FormatPingPending())
+	if ctx.Update.Message != nil {
+		msg.ReplyToMessageID = ctx.Update.Message.MessageID
+	}
+	report.Processing = time.Since(received)
+
+	sendStart := time.Now()
+	sent, err := ctx.Client.Send(msg)
+	if err != nil {
+		return err
+	}
+	report.RoundTrip = time.Since(sendStart)
+
+	if report.Admin {
+		h.diagnose(ctx, &report)
+	}
+
+	edit := tgbotapi.NewEditMessageText(ctx.ChatID, sent.MessageID, ui.FormatPingReport(report))
+	if _, err := ctx.Client.Send(edit); err != nil {
+		log.Printf("⚠️ 更新 /ping 结果失败: %v", err)
+		return err
+	}
+	return nil
+}
+
+// diagnose 填入数据库耗时、工作队列积压和本群剩余发送额度
+func (h *Handler) diagnose(ctx *middleware.Context, report *ui.PingReport) {
+	report.DBLatency, report.DBError = h.db.Ping()
+
+	if h.workers != nil {
+		for _, lane := range h.workers.Stats() {
+			report.Lanes = append(report.Lanes, ui.PingLane{Priority: lane.Priority, Pending: lane.Pending})
+		}
+	}
+
+	// 客户端套了限流器时读取剩余额度
+	if limited, ok := ctx.Client.(interface{ Limiter() *telegram.Limiter }); ok {
+		report.Budget = limited.Limiter().Budget(ctx.ChatID)
+		report.HasBudget = true
+	}
+}
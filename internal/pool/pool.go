@@ -5,18 +5,53 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// Priority 任务优先级
+type Priority int
+
+const (
+	PriorityCritical Priority = iota // 结算、退款等资金相关任务
+	PriorityNormal                   // 普通命令
+	PriorityLow                      // 统计、摘要等后台任务
+
+	priorityCount = 3
+)
+
+// starvationLimit 低优先级队列被连续跳过的最大次数，超过后强制调度一次
+const starvationLimit = 8
+
+// String 优先级名称
+func (p Priority) String() string {
+	switch p {
+	case PriorityCritical:
+		return "critical"
+	case PriorityNormal:
+		return "normal"
+	case PriorityLow:
+		return "low"
+	default:
+		return "unknown"
+	}
+}
+
 // WorkerPool 工作池，用于处理并发任务
 type WorkerPool struct {
 	workers    int
-	jobQueue   chan Job
+	lanes      [priorityCount]chan *laneJob
 	workerPool chan chan Job
 	quit       chan bool
 	wg         sync.WaitGroup
+
+	metrics [priorityCount]laneMetrics
+	skipped [priorityCount]int // 仅由调度协程访问
+
+	// 执行失败的任务交给死信队列记录，未设置时只计数
+	deadLetters DeadLetterSink
 }
 
 // Job 工作任务接口
@@ -24,6 +59,11 @@ type Job interface {
 	Execute() error
 }
 
+// DeadLetterSink 接收执行失败的任务，用于记录和重放
+type DeadLetterSink interface {
+	Capture(job Job, priority Priority, err error)
+}
+
 // MessageJob 消息处理任务
 type MessageJob struct {
 	Handler func() error
@@ -33,18 +73,63 @@ func (j *MessageJob) Execute() error {
 	return j.Handler()
 }
 
-// NewWorkerPool 创建新的工作池
+// laneMetrics 单个优先级队列的统计
+type laneMetrics struct {
+	submitted int64
+	executed  int64
+	failed    int64
+	overflow  int64
+	waitNanos int64
+}
+
+// LaneStats 优先级队列统计快照
+type LaneStats struct {
+	Priority  string        `json:"priority"`
+	Pending   int           `json:"pending"`
+	Submitted int64         `json:"submitted"`
+	Executed  int64         `json:"executed"`
+	Failed    int64         `json:"failed"`
+	Overflow  int64         `json:"overflow"` // 队列满时直接执行的任务数
+	AvgWait   time.Duration `json:"avg_wait"`
+}
+
+// laneJob 带优先级和入队时间的任务
+type laneJob struct {
+	job         Job
+	priority    Priority
+	metrics     *laneMetrics
+	enqueued    time.Time
+	deadLetters DeadLetterSink
+}
+
+func (j *laneJob) Execute() error {
+	atomic.AddInt64(&j.metrics.waitNanos, int64(time.Since(j.enqueued)))
+	err := j.job.Execute()
+	atomic.AddInt64(&j.metrics.executed, 1)
+	if err != nil {
+		atomic.AddInt64(&j.metrics.failed, 1)
+		if j.deadLetters != nil {
+			j.deadLetters.Capture(j.job, j.priority, err)
+		}
+	}
+	return err
+}
+
+// NewWorkerPool 创建新的工作池，queueSize 为每个优先级队列的容量
 func NewWorkerPool(workers int, queueSize int) *WorkerPool {
 	if workers <= 0 {
 		workers = runtime.NumCPU()
 	}
 
-	return &WorkerPool{
+	p := &WorkerPool{
 		workers:    workers,
-		jobQueue:   make(chan Job, queueSize),
 		workerPool: make(chan chan Job, workers),
 		quit:       make(chan bool),
 	}
+	for i := range p.lanes {
+		p.lanes[i] = make(chan *laneJob, queueSize)
+	}
+	return p
 }
 
 // Start 启动工作池
@@ -59,37 +144,125 @@ func (p *WorkerPool) Start() {
 	go p.dispatch()
 }
 
+// SetDeadLetterSink 设置死信队列，需在 Start 之前调用
+func (p *WorkerPool) SetDeadLetterSink(sink DeadLetterSink) {
+	p.deadLetters = sink
+}
+
 // Stop 停止工作池
 func (p *WorkerPool) Stop() {
 	close(p.quit)
 	p.wg.Wait()
 }
 
-// Submit 提交任务
+// Submit 以普通优先级提交任务
 func (p *WorkerPool) Submit(job Job) {
+	p.SubmitWithPriority(job, PriorityNormal)
+}
+
+// SubmitWithPriority 按优先级提交任务
+func (p *WorkerPool) SubmitWithPriority(job Job, priority Priority) {
+	if priority < PriorityCritical || priority > PriorityLow {
+		priority = PriorityNormal
+	}
+
+	metrics := &p.metrics[priority]
+	atomic.AddInt64(&metrics.submitted, 1)
+	lj := &laneJob{job: job, priority: priority, metrics: metrics, enqueued: time.Now(), deadLetters: p.deadLetters}
+
 	select {
-	case p.jobQueue <- job:
+	case p.lanes[priority] <- lj:
 	default:
 		// 队列满时，直接执行（防止阻塞）
-		go job.Execute()
+		atomic.AddInt64(&metrics.overflow, 1)
+		go lj.Execute()
 	}
 }
 
-// dispatch 调度任务
+// Stats 获取各优先级队列的统计
+func (p *WorkerPool) Stats() []LaneStats {
+	stats := make([]LaneStats, priorityCount)
+	for i := range p.lanes {
+		m := &p.metrics[i]
+		executed := atomic.LoadInt64(&m.executed)
+		var avgWait time.Duration
+		if executed > 0 {
+			avgWait = time.Duration(atomic.LoadInt64(&m.waitNanos) / executed)
+		}
+		stats[i] = LaneStats{
+			Priority:  Priority(i).String(),
+			Pending:   len(p.lanes[i]),
+			Submitted: atomic.LoadInt64(&m.submitted),
+			Executed:  executed,
+			Failed:    atomic.LoadInt64(&m.failed),
+			Overflow:  atomic.LoadInt64(&m.overflow),
+			AvgWait:   avgWait,
+		}
+	}
+	return stats
+}
+
+// dispatch 调度任务：先获取空闲工作者，再按优先级选择任务
 func (p *WorkerPool) dispatch() {
 	for {
+		var jobChannel chan Job
 		select {
-		case job := <-p.jobQueue:
-			// 获取可用的工作者
-			select {
-			case jobChannel := <-p.workerPool:
-				jobChannel <- job
-			case <-p.quit:
-				return
-			}
+		case jobChannel = <-p.workerPool:
 		case <-p.quit:
 			return
 		}
+
+		job := p.next()
+		if job == nil {
+			return
+		}
+		jobChannel <- job
+	}
+}
+
+// next 选择下一个任务，高优先级优先，长时间被跳过的队列会被强制调度
+func (p *WorkerPool) next() Job {
+	// 饥饿保护
+	for i := priorityCount - 1; i > 0; i-- {
+		if p.skipped[i] >= starvationLimit {
+			p.skipped[i] = 0
+			select {
+			case job := <-p.lanes[i]:
+				return job
+			default:
+			}
+		}
+	}
+
+	for i := range p.lanes {
+		select {
+		case job := <-p.lanes[i]:
+			p.markSkipped(i)
+			return job
+		default:
+		}
+	}
+
+	// 所有队列为空，阻塞等待
+	select {
+	case job := <-p.lanes[PriorityCritical]:
+		return job
+	case job := <-p.lanes[PriorityNormal]:
+		return job
+	case job := <-p.lanes[PriorityLow]:
+		return job
+	case <-p.quit:
+		return nil
+	}
+}
+
+// markSkipped 记录比 served 优先级低且有积压的队列被跳过
+func (p *WorkerPool) markSkipped(served int) {
+	p.skipped[served] = 0
+	for i := served + 1; i < priorityCount; i++ {
+		if len(p.lanes[i]) > 0 {
+			p.skipped[i]++
+		}
 	}
 }
 
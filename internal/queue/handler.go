@@ -0,0 +1,114 @@
+package queue
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /queue 命令和改为排队加入新对局按钮的处理器
+type Handler struct {
+	manager *game.Manager
+	codec   *callback.Codec
+}
+
+// NewHandler 创建排队管理处理器
+func NewHandler(manager *game.Manager, codec *callback.Codec) *Handler {
+	return &Handler{manager: manager, codec: codec}
+}
+
+// Register 注册 /queue 命令（仅限群管理员）和改为排队加入新对局的按钮回调
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.QueueCommand, h.Queue, middleware.ChatAdminOnly())
+	router.HandleCallback(callback.Prefix(ui.QueueReplaceAction), h.Replace)
+}
+
+// Queue 查看本群排队的加入请求，或移除单个请求、清空队列，被移出的玩家会收到私信
+func (h *Handler) Queue(ctx *middleware.Context) error {
+	fields := strings.Fields(strings.ToLower(ctx.Args))
+	if len(fields) == 0 {
+		entries, err := h.manager.ChatQueue(ctx.ChatID)
+		if err != nil {
+			return err
+		}
+		return ctx.Reply(ui.FormatChatQueue(entries, time.Now()))
+	}
+
+	switch {
+	case fields[0] == ui.QueueClear && len(fields) == 1:
+		entries, err := h.manager.ClearQueue(ctx.ChatID)
+		if err != nil {
+			return err
+		}
+		return ctx.Reply(ui.FormatQueueRemoved(entries))
+	case fields[0] == ui.QueueRemove && len(fields) == 2:
+		gameID, err := h.resolve(ctx.ChatID, fields[1])
+		if err != nil {
+			return err
+		}
+		if gameID == "" {
+			return ctx.Reply("❌ 队列中没有该请求\n" + ui.FormatQueueUsage())
+		}
+		entry, err := h.manager.RemoveQueuedJoin(ctx.ChatID, gameID)
+		if err != nil {
+			return ctx.Reply("❌ " + err.Error())
+		}
+		return ctx.Reply(ui.FormatQueueRemoved([]*models.QueueEntry{entry}))
+	default:
+		return ctx.Reply(ui.FormatQueueUsage())
+	}
+}
+
+// OfferReplace 加入请求因已有排队请求被拒绝时，发送改为排队加入新对局的提示
+func (h *Handler) OfferReplace(ctx *middleware.Context, queued *game.AlreadyQueuedError) error {
+	msg, err := ui.BuildQueueReplaceMessage(h.codec, ctx.ChatID, queued.Position, queued.GameID, queued.NewGameID)
+	if err != nil {
+		return err
+	}
+	_, err = ctx.Client.Send(msg)
+	return err
+}
+
+// Replace 改为排队加入新对局按钮：只替换点击者自己的排队请求，保留原排队位置
+func (h *Handler) Replace(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	gameID, err := ui.ParseQueueReplaceCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+
+	entry, err := h.manager.ReplaceQueuedJoin(ctx.ChatID, ctx.UserID, gameID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "🔁 已替换")); err != nil {
+		log.Printf("⚠️ 应答替换排队按钮失败: %v", err)
+	}
+	_, err = ctx.Client.Send(tgbotapi.NewMessage(ctx.ChatID, ui.FormatQueueReplaced(entry)))
+	return err
+}
+
+// resolve 将队列序号或对局ID转换为对局ID，队列中不存在时返回空字符串
+func (h *Handler) resolve(chatID int64, arg string) (string, error) {
+	entries, err := h.manager.ChatQueue(chatID)
+	if err != nil {
+		return "", err
+	}
+	position, convErr := strconv.Atoi(arg)
+	for _, entry := range entries {
+		if (convErr == nil && entry.Position == position) || strings.EqualFold(entry.GameID, arg) {
+			return entry.GameID, nil
+		}
+	}
+	return "", nil
+}
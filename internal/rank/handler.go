@@ -0,0 +1,90 @@
+package rank
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/cache"
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /rank 本群排行榜的处理器，排行榜经缓存读取，切换周期按钮原地编辑消息
+type Handler struct {
+	rankings *cache.RankingCache
+	client   telegram.Client
+	codec    *callback.Codec
+	debounce time.Duration // 同一用户两次切换周期的最小间隔
+}
+
+// NewHandler 创建排行榜处理器
+func NewHandler(rankings *cache.RankingCache, client telegram.Client, codec *callback.Codec, debounce time.Duration) *Handler {
+	return &Handler{rankings: rankings, client: client, codec: codec, debounce: debounce}
+}
+
+// Register 注册 /rank 命令和切换周期按钮回调，切换按用户限频
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.RankCommand, h.Show)
+	router.HandleCallback(callback.Prefix(ui.RankAction), h.Switch, middleware.RateLimit(1, h.debounce))
+}
+
+// Show 发送本群排行榜，默认今日，可用 /rank week 或 /rank month 指定周期
+func (h *Handler) Show(ctx *middleware.Context) error {
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("⚠️ 请在群组中使用 /rank 查看本群排行榜")
+	}
+
+	period := strings.ToLower(strings.TrimSpace(ctx.Args))
+	if period == "" {
+		period = ui.RankPeriodDay
+	}
+	if !ui.ValidRankPeriod(period) {
+		return ctx.Reply("⚠️ 用法：/rank [day|week|month]")
+	}
+
+	entries, err := h.rankings.Get(ctx.ChatID, ui.RankPeriodStart(period, time.Now()))
+	if err != nil {
+		return fmt.Errorf("获取排行榜失败: %v", err)
+	}
+	msg, err := ui.BuildRankMessage(h.codec, ctx.ChatID, period, entries)
+	if err != nil {
+		return err
+	}
+	_, err = h.client.Send(msg)
+	return err
+}
+
+// Switch 切换周期按钮：原地编辑被点击的排行榜消息
+func (h *Handler) Switch(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	period, err := ui.ParseRankCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("⚠️ " + callback.ErrMalformed.Error())
+	}
+
+	entries, err := h.rankings.Get(ctx.ChatID, ui.RankPeriodStart(period, time.Now()))
+	if err != nil {
+		return fmt.Errorf("获取排行榜失败: %v", err)
+	}
+	edit, err := ui.BuildRankEdit(h.codec, ctx.ChatID, query.Message.MessageID, period, entries)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.client.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		log.Printf("⚠️ 应答排行榜按钮失败: %v", err)
+	}
+	if _, err := h.client.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package ready
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler 开骰前的准备确认：对局进入等待准备后发送提示，双方点击「✅准备」后开骰，超时后更新提示
+type Handler struct {
+	db      *database.DB
+	manager *game.Manager
+	client  telegram.Client
+	codec   *callback.Codec
+
+	mu      sync.Mutex
+	prompts map[string]int // 对局ID → 准备提示的消息ID
+}
+
+// NewHandler 创建准备确认处理器
+func NewHandler(db *database.DB, manager *game.Manager, client telegram.Client, codec *callback.Codec) *Handler {
+	return &Handler{
+		db:      db,
+		manager: manager,
+		client:  client,
+		codec:   codec,
+		prompts: make(map[string]int),
+	}
+}
+
+// Register 注册准备按钮回调
+func (h *Handler) Register(router *middleware.Router) {
+	router.HandleCallback(callback.Prefix(ui.ReadyAction), h.Ready)
+}
+
+// Prompt 加入（或排队开局）返回 AwaitingReady 时在群内发送准备提示
+func (h *Handler) Prompt(chatID int64, result *game.GameResult) error {
+	keyboard, err := ui.BuildReadyKeyboard(h.codec, result.GameID)
+	if err != nil {
+		return err
+	}
+
+	player1, player2 := h.name(result.Player1), h.name(result.Player2)
+	text := ui.FormatReadyPrompt(result.GameID, result.BetAmount, player1, player2, false, false, time.Until(result.ReadyDeadline))
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
+	sent, err := h.client.Send(msg)
+	if err != nil {
+		return fmt.Errorf("发送准备提示失败: %v", err)
+	}
+
+	h.mu.Lock()
+	h.prompts[result.GameID] = sent.MessageID
+	h.mu.Unlock()
+	return nil
+}
+
+// Ready 准备按钮：记录确认，双方均已准备时由管理器回调开骰
+func (h *Handler) Ready(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	gameID, err := ui.ParseReadyCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+
+	state, err := h.manager.ConfirmReady(gameID, ctx.UserID)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "✅ 已准备")); err != nil {
+		log.Printf("⚠️ 应答准备按钮失败: %v", err)
+	}
+	return h.update(state, query.Message.MessageID)
+}
+
+// Expired 准备超时后更新提示，可作为 game.Manager.SetReadyCallbacks 的超时回调
+func (h *Handler) Expired(state *game.ReadyState, reverted bool) {
+	h.mu.Lock()
+	messageID, ok := h.prompts[state.GameID]
+	delete(h.prompts, state.GameID)
+	h.mu.Unlock()
+
+	player1, player2 := h.names(state)
+	text := ui.FormatReadyExpired(state.GameID, state.BetAmount, player1, player2, reverted)
+	if ok {
+		h.edit(state.ChatID, messageID, text, nil)
+		return
+	}
+	if _, err := h.client.Send(tgbotapi.NewMessage(state.ChatID, text)); err != nil {
+		log.Printf("⚠️ 发送对局 %s 准备超时通知失败: %v", state.GameID, err)
+	}
+}
+
+// update 按准备进度更新提示，已开骰时移除按钮
+func (h *Handler) update(state *game.ReadyState, messageID int) error {
+	player1, player2 := h.names(state)
+	if state.Started {
+		h.mu.Lock()
+		delete(h.prompts, state.GameID)
+		h.mu.Unlock()
+		h.edit(state.ChatID, messageID, ui.FormatReadyStarted(state.GameID, player1, player2), nil)
+		return nil
+	}
+
+	keyboard, err := ui.BuildReadyKeyboard(h.codec, state.GameID)
+	if err != nil {
+		return err
+	}
+	text := ui.FormatReadyPrompt(state.GameID, state.BetAmount, player1, player2, state.Player1Ready, state.Player2Ready, time.Until(state.Deadline))
+	h.edit(state.ChatID, messageID, text, &keyboard)
+	return nil
+}
+
+// edit 编辑准备提示，keyboard 为 nil 时移除按钮
+func (h *Handler) edit(chatID int64, messageID int, text string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, text)
+	if keyboard != nil {
+		edit.ReplyMarkup = keyboard
+	} else {
+		edit.ReplyMarkup = &tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+	}
+	if _, err := h.client.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		log.Printf("⚠️ 更新准备提示失败: %v", err)
+	}
+}
+
+// names 双方在群内显示的名称
+func (h *Handler) names(state *game.ReadyState) (string, string) {
+	return h.userName(state.Player1ID), h.userName(state.Player2ID)
+}
+
+// userName 按用户ID查询显示名称，查询失败时显示玩家ID
+func (h *Handler) userName(userID int64) string {
+	user, err := h.db.GetUser(userID)
+	if err != nil || user == nil {
+		return fmt.Sprintf("玩家 %d", userID)
+	}
+	return h.name(user)
+}
+
+// name 用户在群内显示的名称
+func (h *Handler) name(user *models.User) string {
+	if user == nil {
+		return "玩家"
+	}
+	return ui.PublicName(user.ID, user.Username, user.FirstName, false)
+}
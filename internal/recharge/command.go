@@ -0,0 +1,105 @@
+package recharge
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// recentDepositLimit 充值页面展示的最近充值条数
+const recentDepositLimit = 5
+
+// NewRechargeManagerFromConfig 按配置创建充值管理器，未配置地址文件或处于沙盒模式时返回 nil 表示不启用充值
+func NewRechargeManagerFromConfig(db *database.DB, cfg *config.Config) (*RechargeManager, error) {
+	if cfg.RechargeAddressFile == "" {
+		log.Printf("ℹ️ 未配置充值地址文件，/recharge 已禁用")
+		return nil, nil
+	}
+	if cfg.Sandbox {
+		log.Printf("ℹ️ 沙盒模式不接受真实充值，/recharge 已禁用")
+		return nil, nil
+	}
+	manager, err := NewRechargeManager(db, cfg.RechargeAddressFile)
+	if err != nil {
+		return nil, err
+	}
+	manager.SetRate(cfg.RechargeRate)
+	return manager, nil
+}
+
+// CommandHandler /recharge 命令和 💳 按钮的处理器
+type CommandHandler struct {
+	manager   *RechargeManager
+	minAmount float64
+	rate      float64
+	qrURL     string
+}
+
+// NewCommandHandler 创建充值命令处理器
+func NewCommandHandler(manager *RechargeManager, cfg *config.Config) *CommandHandler {
+	return &CommandHandler{
+		manager:   manager,
+		minAmount: cfg.RechargeMinAmount,
+		rate:      cfg.RechargeRate,
+		qrURL:     cfg.RechargeQRURL,
+	}
+}
+
+// Register 注册 /recharge 命令和 💳 按钮回调，仅限私聊
+func (h *CommandHandler) Register(router *middleware.Router) {
+	router.Handle(ui.RechargeCommand, h.Handle, middleware.PrivateOnly())
+	router.HandleCallback(ui.RechargeCommand, h.Handle, middleware.PrivateOnly())
+}
+
+// Handle 发送用户的充值地址、二维码、最低金额、兑换比例和最近充值
+func (h *CommandHandler) Handle(ctx *middleware.Context) error {
+	if ctx.IsCallback() {
+		if _, err := ctx.Client.Request(tgbotapi.NewCallback(ctx.Update.CallbackQuery.ID, "")); err != nil {
+			log.Printf("⚠️ 应答充值按钮失败: %v", err)
+		}
+	}
+
+	address, err := h.manager.GetUserRechargeAddress(ctx.UserID)
+	if err != nil {
+		log.Printf("❌ 获取用户 %d 充值地址失败: %v", ctx.UserID, err)
+		return ctx.Reply("❌ 暂时无法分配充值地址，请稍后再试")
+	}
+
+	records, err := h.manager.GetRechargeRecords(ctx.UserID, recentDepositLimit)
+	if err != nil {
+		return fmt.Errorf("获取充值记录失败: %v", err)
+	}
+	deposits := make([]ui.RechargeDeposit, 0, len(records))
+	for _, record := range records {
+		deposits = append(deposits, ui.RechargeDeposit{
+			Amount:    record.Amount,
+			Status:    record.Status,
+			CreatedAt: record.CreatedAt,
+		})
+	}
+
+	text := ui.FormatRechargeInfo(address, h.minAmount, h.rate, deposits)
+
+	if h.qrURL != "" {
+		photo := tgbotapi.NewPhoto(ctx.ChatID, tgbotapi.FileURL(fmt.Sprintf(h.qrURL, url.QueryEscape(address))))
+		photo.Caption = text
+		photo.ParseMode = "Markdown"
+		_, err := ctx.Client.Send(photo)
+		if err == nil {
+			return nil
+		}
+		log.Printf("⚠️ 发送充值二维码失败，改为发送文字: %v", err)
+	}
+
+	msg := tgbotapi.NewMessage(ctx.ChatID, text)
+	msg.ParseMode = "Markdown"
+	_, err = ctx.Client.Send(msg)
+	return err
+}
@@ -13,6 +13,7 @@ import (
 
 	"telegram-dice-bot/internal/database"
 	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
 )
 
 // RechargeManager 充值管理器
@@ -21,8 +22,12 @@ type RechargeManager struct {
 	usdtAddresses []string
 	addressMutex  sync.RWMutex
 	addressFile   string
+	rate          float64 // 1 USDT 兑换的游戏币数，未设置时为 defaultRate
 }
 
+// defaultRate 未设置兑换比例时 1 USDT 兑换的游戏币数
+const defaultRate = 10
+
 // UserRechargeInfo 用户充值信息
 type UserRechargeInfo struct {
 	UserID          int64     `json:"user_id" db:"user_id"`
@@ -66,6 +71,19 @@ func NewRechargeManager(db *database.DB, addressFile string) (*RechargeManager,
 	return rm, nil
 }
 
+// SetRate 设置 1 USDT 兑换的游戏币数
+func (rm *RechargeManager) SetRate(rate float64) {
+	rm.rate = rate
+}
+
+// Rate 1 USDT 兑换的游戏币数
+func (rm *RechargeManager) Rate() float64 {
+	if rm.rate <= 0 {
+		return defaultRate
+	}
+	return rm.rate
+}
+
 // loadUSDTAddresses 加载USDT地址
 func (rm *RechargeManager) loadUSDTAddresses() error {
 	file, err := os.Open(rm.addressFile)
@@ -139,6 +157,15 @@ func (rm *RechargeManager) initTables() error {
 		return fmt.Errorf("创建充值记录表失败: %v", err)
 	}
 
+	// 同一交易哈希只能记录一次，没有交易哈希的记录存为 NULL 不受限制
+	if _, err := tx.Exec(`UPDATE recharge_records SET tx_hash = NULL WHERE tx_hash = ''`); err != nil {
+		return fmt.Errorf("清理空交易哈希失败: %v", err)
+	}
+	if _, err := tx.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_recharge_records_tx_hash
+		ON recharge_records(tx_hash) WHERE tx_hash IS NOT NULL`); err != nil {
+		return fmt.Errorf("创建交易哈希唯一索引失败: %v", err)
+	}
+
 	return tx.Commit()
 }
 
@@ -146,9 +173,9 @@ func (rm *RechargeManager) initTables() error {
 func (rm *RechargeManager) GetUserRechargeAddress(userID int64) (string, error) {
 	// 先检查用户是否已经有分配的地址
 	var info UserRechargeInfo
-	var lastRechargeStr string
+	var lastRecharge sql.NullTime
 	query := `SELECT user_id, usdt_address, address_index, total_recharged, 
-		       COALESCE(last_recharge_at, ''), created_at 
+		       last_recharge_at, created_at 
 		FROM user_recharge_info WHERE user_id = ?`
 	
 	// 使用事务来查询
@@ -160,15 +187,10 @@ func (rm *RechargeManager) GetUserRechargeAddress(userID int64) (string, error)
 
 	err = tx.QueryRow(query, userID).Scan(
 		&info.UserID, &info.USDTAddress, &info.AddressIndex,
-		&info.TotalRecharged, &lastRechargeStr, &info.CreatedAt)
+		&info.TotalRecharged, &lastRecharge, &info.CreatedAt)
 
 	if err == nil {
-		// 解析最后充值时间
-		if lastRechargeStr != "" {
-			if t, err := time.Parse("2006-01-02 15:04:05", lastRechargeStr); err == nil {
-				info.LastRechargeAt = t
-			}
-		}
+		info.LastRechargeAt = lastRecharge.Time
 		// 用户已有地址，直接返回
 		tx.Commit()
 		return info.USDTAddress, nil
@@ -245,7 +267,7 @@ func (rm *RechargeManager) assignNewAddress(userID int64) (string, error) {
 // GetUserRechargeInfo 获取用户充值信息
 func (rm *RechargeManager) GetUserRechargeInfo(userID int64) (*UserRechargeInfo, error) {
 	var info UserRechargeInfo
-	var lastRechargeStr string
+	var lastRecharge sql.NullTime
 	
 	tx, err := rm.db.BeginTx()
 	if err != nil {
@@ -254,12 +276,12 @@ func (rm *RechargeManager) GetUserRechargeInfo(userID int64) (*UserRechargeInfo,
 	defer tx.Rollback()
 
 	query := `SELECT user_id, usdt_address, address_index, total_recharged, 
-		       COALESCE(last_recharge_at, ''), created_at 
+		       last_recharge_at, created_at 
 		FROM user_recharge_info WHERE user_id = ?`
 	
 	err = tx.QueryRow(query, userID).Scan(
 		&info.UserID, &info.USDTAddress, &info.AddressIndex,
-		&info.TotalRecharged, &lastRechargeStr, &info.CreatedAt)
+		&info.TotalRecharged, &lastRecharge, &info.CreatedAt)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -268,12 +290,7 @@ func (rm *RechargeManager) GetUserRechargeInfo(userID int64) (*UserRechargeInfo,
 		return nil, fmt.Errorf("查询用户充值信息失败: %v", err)
 	}
 
-	// 解析最后充值时间
-	if lastRechargeStr != "" {
-		if t, err := time.Parse("2006-01-02 15:04:05", lastRechargeStr); err == nil {
-			info.LastRechargeAt = t
-		}
-	}
+	info.LastRechargeAt = lastRecharge.Time
 
 	tx.Commit()
 	return &info, nil
@@ -297,7 +314,7 @@ func (rm *RechargeManager) AddRechargeRecord(userID int64, amount float64, txHas
 	_, err = tx.Exec(`
 		INSERT INTO recharge_records (user_id, usdt_address, amount, tx_hash, status, created_at)
 		VALUES (?, ?, ?, ?, 'pending', ?)`,
-		userID, address, amount, txHash, time.Now())
+		userID, address, amount, sql.NullString{String: txHash, Valid: txHash != ""}, time.Now())
 
 	if err != nil {
 		return fmt.Errorf("添加充值记录失败: %v", err)
@@ -322,7 +339,7 @@ func (rm *RechargeManager) ConfirmRecharge(recordID int64, actualAmount float64)
 	// 获取充值记录
 	var record RechargeRecord
 	err = tx.QueryRow(`
-		SELECT id, user_id, usdt_address, amount, tx_hash, status
+		SELECT id, user_id, usdt_address, amount, COALESCE(tx_hash, ''), status
 		FROM recharge_records WHERE id = ?`, recordID).Scan(
 		&record.ID, &record.UserID, &record.USDTAddress,
 		&record.Amount, &record.TxHash, &record.Status)
@@ -345,8 +362,19 @@ func (rm *RechargeManager) ConfirmRecharge(recordID int64, actualAmount float64)
 		return fmt.Errorf("更新充值记录失败: %v", err)
 	}
 
-	// 计算游戏币数量 (1 USDT = 10 游戏币)
-	gameCoins := int64(actualAmount * 10)
+	// 按兑换比例计算游戏币数量，按基本单位入账，不足 1 金币的部分同样到账
+	gameCoins := utils.AmountFromFloat(actualAmount * rm.Rate())
+
+	// 判断是否为首次充值（用于首充赠送活动）
+	var confirmedCount int
+	err = tx.QueryRow(`
+		SELECT COUNT(*) FROM recharge_records
+		WHERE user_id = ? AND status = 'confirmed' AND id != ?`,
+		record.UserID, recordID).Scan(&confirmedCount)
+
+	if err != nil {
+		return fmt.Errorf("查询充值次数失败: %v", err)
+	}
 
 	// 更新用户余额
 	_, err = tx.Exec(`
@@ -357,18 +385,29 @@ func (rm *RechargeManager) ConfirmRecharge(recordID int64, actualAmount float64)
 		return fmt.Errorf("更新用户余额失败: %v", err)
 	}
 
+	var newBalance int64
+	if err = tx.QueryRow("SELECT balance FROM users WHERE id = ?", record.UserID).Scan(&newBalance); err != nil {
+		return fmt.Errorf("查询用户余额失败: %v", err)
+	}
+
 	// 添加交易记录
 	_, err = tx.Exec(`
-		INSERT INTO transactions (user_id, type, amount, description, created_at)
-		VALUES (?, ?, ?, ?, ?)`,
-		record.UserID, models.TransactionTypeDeposit, gameCoins,
-		fmt.Sprintf("USDT充值确认 %.2f USDT -> %d 游戏币", actualAmount, gameCoins),
+		INSERT INTO transactions (id, user_id, type, amount, balance, description, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		utils.GenerateTransactionID(), record.UserID, models.TransactionTypeDeposit, gameCoins, newBalance,
+		fmt.Sprintf("USDT充值确认 %.2f USDT -> %s 游戏币", actualAmount, utils.FormatAmount(gameCoins)),
 		time.Now())
 
 	if err != nil {
 		return fmt.Errorf("添加交易记录失败: %v", err)
 	}
 
+	// 发放充值赠送，赠送金额单独记为 bonus 交易并附带流水要求
+	bonus, err := rm.db.ApplyDepositBonusInTx(tx, record.UserID, gameCoins, confirmedCount == 0)
+	if err != nil {
+		return fmt.Errorf("发放充值赠送失败: %v", err)
+	}
+
 	// 更新用户充值信息
 	_, err = tx.Exec(`
 		UPDATE user_recharge_info 
@@ -383,8 +422,8 @@ func (rm *RechargeManager) ConfirmRecharge(recordID int64, actualAmount float64)
 		return fmt.Errorf("提交事务失败: %v", err)
 	}
 
-	log.Printf("✅ 充值确认成功: 用户 %d, 金额 %.2f USDT, 获得 %d 游戏币", 
-		record.UserID, actualAmount, gameCoins)
+	log.Printf("✅ 充值确认成功: 用户 %d, 金额 %.2f USDT, 获得 %s 游戏币, 赠送 %s 游戏币", 
+		record.UserID, actualAmount, utils.FormatAmount(gameCoins), utils.FormatAmount(bonus))
 	return nil
 }
 
@@ -398,7 +437,7 @@ func (rm *RechargeManager) GetRechargeRecords(userID int64, limit int) ([]Rechar
 
 	rows, err := tx.Query(`
 		SELECT id, user_id, usdt_address, amount, COALESCE(tx_hash, ''), 
-		       status, created_at, COALESCE(confirmed_at, '')
+		       status, created_at, confirmed_at
 		FROM recharge_records 
 		WHERE user_id = ? 
 		ORDER BY created_at DESC 
@@ -412,29 +451,18 @@ func (rm *RechargeManager) GetRechargeRecords(userID int64, limit int) ([]Rechar
 	var records []RechargeRecord
 	for rows.Next() {
 		var record RechargeRecord
-		var confirmedAtStr string
-		var createdAtStr string
+		var confirmedAt sql.NullTime
 
 		err := rows.Scan(&record.ID, &record.UserID, &record.USDTAddress,
 			&record.Amount, &record.TxHash, &record.Status,
-			&createdAtStr, &confirmedAtStr)
+			&record.CreatedAt, &confirmedAt)
 
 		if err != nil {
 			return nil, fmt.Errorf("扫描充值记录失败: %v", err)
 		}
 
-		// 解析创建时间
-		if createdAtStr != "" {
-			if t, err := time.Parse("2006-01-02 15:04:05", createdAtStr); err == nil {
-				record.CreatedAt = t
-			}
-		}
-
-		// 解析确认时间
-		if confirmedAtStr != "" {
-			if t, err := time.Parse("2006-01-02 15:04:05", confirmedAtStr); err == nil {
-				record.ConfirmedAt = &t
-			}
+		if confirmedAt.Valid {
+			record.ConfirmedAt = &confirmedAt.Time
 		}
 
 		records = append(records, record)
@@ -467,4 +495,68 @@ func (rm *RechargeManager) GetUsedAddressCount() (int, error) {
 	
 	tx.Commit()
 	return count, nil
-}
\ No newline at end of file
+}
+// AssignedAddresses 获取已分配给用户的充值地址，键为地址，值为用户ID
+func (rm *RechargeManager) AssignedAddresses() (map[string]int64, error) {
+	tx, err := rm.db.BeginTx()
+	if err != nil {
+		return nil, fmt.Errorf("开始事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query("SELECT usdt_address, user_id FROM user_recharge_info")
+	if err != nil {
+		return nil, fmt.Errorf("查询已分配地址失败: %v", err)
+	}
+	defer rows.Close()
+
+	addresses := make(map[string]int64)
+	for rows.Next() {
+		var address string
+		var userID int64
+		if err := rows.Scan(&address, &userID); err != nil {
+			return nil, fmt.Errorf("扫描已分配地址失败: %v", err)
+		}
+		addresses[address] = userID
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tx.Commit()
+	return addresses, nil
+}
+
+// RecordDeposit 记录链上检测到的充值，status 为 pending 或 failed，同一交易哈希只记录一次（由唯一索引保证），返回记录ID；已记录过时返回 0
+func (rm *RechargeManager) RecordDeposit(userID int64, address string, amount float64, txHash, status string) (int64, error) {
+	tx, err := rm.db.BeginTx()
+	if err != nil {
+		return 0, fmt.Errorf("开始事务失败: %v", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO recharge_records (user_id, usdt_address, amount, tx_hash, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (tx_hash) WHERE tx_hash IS NOT NULL DO NOTHING`,
+		userID, address, amount, txHash, status, time.Now())
+	if err != nil {
+		return 0, fmt.Errorf("添加充值记录失败: %v", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if affected == 0 {
+		return 0, nil
+	}
+	recordID, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("提交事务失败: %v", err)
+	}
+	return recordID, nil
+}
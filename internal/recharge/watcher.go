@@ -0,0 +1,306 @@
+package recharge
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+)
+
+// watchLookback 首次检查地址时回溯的时间，重启期间到账的转账也能补记
+const watchLookback = 24 * time.Hour
+
+// watchMaxPages 单个地址每次检查最多翻页数
+const watchMaxPages = 10
+
+// watchCursorKeyPrefix 各地址检查进度在 bot_settings 中的键前缀，重启后从上次的进度继续检查
+const watchCursorKeyPrefix = "recharge_watch_since:"
+
+// Transfer 链上检测到的一笔 TRC20 转账
+type Transfer struct {
+	TxHash    string
+	To        string
+	Amount    float64 // USDT
+	Timestamp int64   // 区块时间（毫秒）
+}
+
+// Watcher 定期查询已分配充值地址收到的 USDT 转账，自动创建并确认充值记录
+type Watcher struct {
+	manager   *RechargeManager
+	client    *http.Client
+	apiURL    string
+	apiKey    string
+	contract  string
+	minAmount float64
+	interval  time.Duration
+	// 各地址已检查到的区块时间（毫秒），同时持久化到 bot_settings
+	since map[string]int64
+	// 充值自动入账后的回调
+	onCredited func(userID int64, amount float64, txHash string)
+	// 返回 false 时跳过本次检查
+	gate func() bool
+	quit chan struct{}
+}
+
+// NewWatcher 创建链上充值检测器，apiURL 为 TronGrid 兼容的 API 地址
+func NewWatcher(manager *RechargeManager, apiURL, apiKey, contract string, minAmount float64, interval time.Duration) *Watcher {
+	return &Watcher{
+		manager:   manager,
+		client:    &http.Client{Timeout: 15 * time.Second},
+		apiURL:    strings.TrimRight(apiURL, "/"),
+		apiKey:    apiKey,
+		contract:  contract,
+		minAmount: minAmount,
+		interval:  interval,
+		since:     make(map[string]int64),
+		quit:      make(chan struct{}),
+	}
+}
+
+// NewWatcherFromConfig 按配置创建链上充值检测器，未配置查询间隔时返回 nil 表示只能手动确认充值
+func NewWatcherFromConfig(manager *RechargeManager, cfg *config.Config) *Watcher {
+	if cfg.RechargeWatchInterval <= 0 || cfg.RechargeWatchAPI == "" {
+		log.Printf("ℹ️ 未配置链上充值检测，充值需手动确认")
+		return nil
+	}
+	return NewWatcher(manager, cfg.RechargeWatchAPI, cfg.RechargeWatchAPIKey, cfg.RechargeUSDTContract,
+		cfg.RechargeMinAmount, time.Duration(cfg.RechargeWatchInterval)*time.Second)
+}
+
+// SetCreditedCallback 设置充值自动入账后的回调（通知用户）
+func (w *Watcher) SetCreditedCallback(callback func(userID int64, amount float64, txHash string)) {
+	w.onCredited = callback
+}
+
+// Start 启动定期检查
+func (w *Watcher) Start() {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		w.checkIfActive()
+		for {
+			select {
+			case <-ticker.C:
+				w.checkIfActive()
+			case <-w.quit:
+				return
+			}
+		}
+	}()
+}
+
+// SetGate 设置定期检查的开关，多进程部署时只由一个进程检查链上转账
+func (w *Watcher) SetGate(gate func() bool) {
+	w.gate = gate
+}
+
+// checkIfActive 定期检查未被关闭时执行一次检查
+func (w *Watcher) checkIfActive() {
+	if w.gate != nil && !w.gate() {
+		return
+	}
+	w.Check()
+}
+
+// Stop 停止定期检查
+func (w *Watcher) Stop() {
+	close(w.quit)
+}
+
+// Check 逐个查询已分配地址收到的转账并入账，单个地址失败不影响其他地址
+func (w *Watcher) Check() {
+	addresses, err := w.manager.AssignedAddresses()
+	if err != nil {
+		log.Printf("⚠️ 链上充值检测获取地址失败: %v", err)
+		return
+	}
+
+	for address, userID := range addresses {
+		if err := w.checkAddress(address, userID); err != nil {
+			log.Printf("⚠️ 检查充值地址 %s 失败: %v", address, err)
+		}
+	}
+}
+
+// checkAddress 查询地址自上次检查以来的转账，按交易哈希去重后入账
+func (w *Watcher) checkAddress(address string, userID int64) error {
+	since := w.cursor(address)
+
+	transfers, err := w.FetchTransfers(address, since)
+	if err != nil {
+		return err
+	}
+
+	latest := since
+	for _, transfer := range transfers {
+		if err := w.credit(userID, transfer); err != nil {
+			// 保留检查进度，下次从这笔转账重新检查
+			w.saveCursor(address, since, latest)
+			return err
+		}
+		if transfer.Timestamp > latest {
+			latest = transfer.Timestamp
+		}
+	}
+	w.saveCursor(address, since, latest)
+	return nil
+}
+
+// cursor 地址已检查到的区块时间，优先取内存中的进度，其次取持久化的进度，都没有时回溯 watchLookback
+func (w *Watcher) cursor(address string) int64 {
+	if since, ok := w.since[address]; ok {
+		return since
+	}
+	since := time.Now().Add(-watchLookback).UnixMilli()
+	value, ok, err := w.manager.db.GetSetting(watchCursorKeyPrefix + address)
+	if err != nil {
+		log.Printf("⚠️ 读取充值地址 %s 的检查进度失败: %v", address, err)
+	} else if ok {
+		if saved, err := strconv.ParseInt(value, 10, 64); err == nil {
+			since = saved
+		}
+	}
+	w.since[address] = since
+	return since
+}
+
+// saveCursor 更新地址的检查进度，进度前进时写入 bot_settings
+func (w *Watcher) saveCursor(address string, previous, latest int64) {
+	w.since[address] = latest
+	if latest == previous {
+		return
+	}
+	if err := w.manager.db.SetSetting(watchCursorKeyPrefix+address, strconv.FormatInt(latest, 10)); err != nil {
+		log.Printf("⚠️ 保存充值地址 %s 的检查进度失败: %v", address, err)
+	}
+}
+
+// credit 记录一笔转账，达到最低金额的自动确认入账，低于最低金额的记为失败不予入账
+func (w *Watcher) credit(userID int64, transfer Transfer) error {
+	status := "pending"
+	if transfer.Amount < w.minAmount {
+		status = "failed"
+	}
+
+	recordID, err := w.manager.RecordDeposit(userID, transfer.To, transfer.Amount, transfer.TxHash, status)
+	if err != nil {
+		return err
+	}
+	if recordID == 0 {
+		return nil
+	}
+	if status == "failed" {
+		log.Printf("⚠️ 用户 %d 充值 %.2f USDT 低于最低金额，不予入账，交易哈希 %s", userID, transfer.Amount, transfer.TxHash)
+		return nil
+	}
+
+	if err := w.manager.ConfirmRecharge(recordID, transfer.Amount); err != nil {
+		// 记录已存在，不会重复入账，需在后台手动确认
+		log.Printf("❌ 自动确认充值记录 %d 失败，需手动确认: %v", recordID, err)
+		return nil
+	}
+	if w.onCredited != nil {
+		go w.onCredited(userID, transfer.Amount, transfer.TxHash)
+	}
+	return nil
+}
+
+// trc20Response TronGrid TRC20 转账查询的响应
+type trc20Response struct {
+	Data []struct {
+		TransactionID string `json:"transaction_id"`
+		TokenInfo     struct {
+			Address  string `json:"address"`
+			Decimals int    `json:"decimals"`
+		} `json:"token_info"`
+		BlockTimestamp int64  `json:"block_timestamp"`
+		To             string `json:"to"`
+		Type           string `json:"type"`
+		Value          string `json:"value"`
+	} `json:"data"`
+	Success bool   `json:"success"`
+	Error   string `json:"error"`
+	Meta    struct {
+		Links struct {
+			Next string `json:"next"`
+		} `json:"links"`
+	} `json:"meta"`
+}
+
+// FetchTransfers 查询地址在 since（毫秒）之后收到的已确认 USDT 转账，按区块时间正序返回
+func (w *Watcher) FetchTransfers(address string, since int64) ([]Transfer, error) {
+	query := url.Values{}
+	query.Set("only_to", "true")
+	query.Set("only_confirmed", "true")
+	query.Set("contract_address", w.contract)
+	query.Set("min_timestamp", strconv.FormatInt(since, 10))
+	query.Set("order_by", "block_timestamp,asc")
+	query.Set("limit", "200")
+	next := fmt.Sprintf("%s/v1/accounts/%s/transactions/trc20?%s", w.apiURL, address, query.Encode())
+
+	var transfers []Transfer
+	for page := 0; next != "" && page < watchMaxPages; page++ {
+		resp, err := w.get(next)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range resp.Data {
+			if item.Type != "Transfer" || item.To != address || item.TokenInfo.Address != w.contract {
+				continue
+			}
+			value, err := strconv.ParseFloat(item.Value, 64)
+			if err != nil {
+				log.Printf("⚠️ 解析转账 %s 金额失败: %v", item.TransactionID, err)
+				continue
+			}
+			transfers = append(transfers, Transfer{
+				TxHash:    item.TransactionID,
+				To:        item.To,
+				Amount:    value / math.Pow10(item.TokenInfo.Decimals),
+				Timestamp: item.BlockTimestamp,
+			})
+		}
+		next = resp.Meta.Links.Next
+	}
+	return transfers, nil
+}
+
+// get 请求 TronGrid 接口并解析响应
+func (w *Watcher) get(rawURL string) (*trc20Response, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if w.apiKey != "" {
+		req.Header.Set("TRON-PRO-API-KEY", w.apiKey)
+	}
+
+	httpResp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("请求链上接口失败: %v", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("链上接口返回状态码 %d", httpResp.StatusCode)
+	}
+
+	var resp trc20Response
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("解析链上接口响应失败: %v", err)
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("链上接口返回错误: %s", resp.Error)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,171 @@
+package reconcile
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/maintenance"
+	"telegram-dice-bot/internal/models"
+)
+
+// lockName 多进程共享数据库时，同一时间只允许一个进程对账
+const lockName = "ledger_reconcile"
+
+// ErrRunning 已有对账任务在执行
+var ErrRunning = errors.New("对账正在进行中")
+
+// Reconciler 每晚在指定时段按流水重算用户余额，记录差异、提醒管理员，并可自动冻结差异账户
+type Reconciler struct {
+	db         *database.DB
+	window     maintenance.Window
+	autoFreeze bool
+	owner      string
+	// 发现新差异后的回调（通知管理员）
+	onDiscrepancy func(run *models.ReconcileRun)
+
+	mu      sync.Mutex
+	running bool
+	last    *models.ReconcileRun
+	quit    chan struct{}
+}
+
+// NewReconciler 创建对账任务，owner 用于多进程间的对账锁
+func NewReconciler(db *database.DB, window maintenance.Window, autoFreeze bool, owner string) *Reconciler {
+	return &Reconciler{
+		db:         db,
+		window:     window,
+		autoFreeze: autoFreeze,
+		owner:      owner,
+		quit:       make(chan struct{}),
+	}
+}
+
+// SetDiscrepancyCallback 设置发现新差异后的回调
+func (r *Reconciler) SetDiscrepancyCallback(callback func(run *models.ReconcileRun)) {
+	r.onDiscrepancy = callback
+}
+
+// Start 启动定时检查，每 10 分钟检查一次是否进入对账时段
+func (r *Reconciler) Start() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if r.due(now) {
+					if _, err := r.Run("schedule"); err != nil && !errors.Is(err, ErrRunning) {
+						log.Printf("❌ 定时对账失败: %v", err)
+					}
+				}
+			case <-r.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时检查
+func (r *Reconciler) Stop() {
+	close(r.quit)
+}
+
+// due 判断当前是否应执行定时对账，每个时段只执行一次
+func (r *Reconciler) due(now time.Time) bool {
+	if !r.window.Contains(now) {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last == nil || now.Sub(r.last.StartedAt) >= 20*time.Hour
+}
+
+// Run 立即执行一次对账，trigger 为 schedule 或 manual
+func (r *Reconciler) Run(trigger string) (*models.ReconcileRun, error) {
+	r.mu.Lock()
+	if r.running {
+		r.mu.Unlock()
+		return nil, ErrRunning
+	}
+	r.running = true
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		r.running = false
+		r.mu.Unlock()
+	}()
+
+	acquired, err := r.db.AcquireLock(lockName, r.owner, time.Hour)
+	if err != nil {
+		return nil, fmt.Errorf("获取对账锁失败: %v", err)
+	}
+	if !acquired {
+		return nil, ErrRunning
+	}
+	defer r.db.ReleaseLock(lockName, r.owner)
+
+	run := &models.ReconcileRun{Trigger: trigger, StartedAt: time.Now()}
+	err = r.reconcile(run)
+	run.Duration = time.Since(run.StartedAt)
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	r.last = run
+	r.mu.Unlock()
+
+	if err != nil {
+		return run, err
+	}
+
+	log.Printf("🧾 对账完成（%s）：核对 %d 个用户，新差异 %d 个，冻结 %d 个，耗时 %v",
+		trigger, run.Checked, len(run.Issues), run.Frozen, run.Duration)
+	if len(run.Issues) > 0 && r.onDiscrepancy != nil {
+		go r.onDiscrepancy(run)
+	}
+	return run, nil
+}
+
+// reconcile 比较流水与余额，记录新发现或差额有变化的差异
+func (r *Reconciler) reconcile(run *models.ReconcileRun) error {
+	checked, mismatches, err := r.db.LedgerMismatches()
+	if err != nil {
+		return fmt.Errorf("重算用户余额失败: %v", err)
+	}
+	run.Checked = checked
+
+	for _, issue := range mismatches {
+		recorded, err := r.db.RecordReconcileIssue(issue, r.autoFreeze)
+		if err != nil {
+			return fmt.Errorf("记录用户 %d 对账差异失败: %v", issue.UserID, err)
+		}
+		if !recorded {
+			continue
+		}
+		run.Issues = append(run.Issues, issue)
+		if r.autoFreeze {
+			run.Frozen++
+		}
+		log.Printf("⚠️ 用户 %d 余额与流水不符：实际 %d，流水 %d，差额 %d", issue.UserID, issue.Actual, issue.Expected, issue.Difference)
+	}
+	return nil
+}
+
+// Status 对账状态快照
+func (r *Reconciler) Status() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return map[string]interface{}{
+		"running":     r.running,
+		"auto_freeze": r.autoFreeze,
+		"last_run":    r.last,
+	}
+}
@@ -0,0 +1,98 @@
+package referral
+
+import (
+	"errors"
+	"log"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /invite 命令、游戏中心「邀请朋友」按钮和邀请链接注册的处理器
+type Handler struct {
+	db      *database.DB
+	client  telegram.Client
+	rewards models.ReferralRewards
+	// 有新用户通过邀请链接注册时私信邀请人，未设置时不通知
+	notify func(userID int64, text string)
+}
+
+// NewHandler 创建邀请处理器，奖励金额取自配置
+func NewHandler(db *database.DB, client telegram.Client, cfg *config.Config) *Handler {
+	return &Handler{
+		db:     db,
+		client: client,
+		rewards: models.ReferralRewards{
+			SignupReferrer:     cfg.ReferralSignupReferrer,
+			SignupReferee:      cfg.ReferralSignupReferee,
+			WagerThreshold:     cfg.ReferralWagerThreshold,
+			WagerReferrer:      cfg.ReferralWagerReferrer,
+			WagerReferee:       cfg.ReferralWagerReferee,
+			WageringMultiplier: cfg.ReferralWageringMultiplier,
+		},
+	}
+}
+
+// SetNotifier 设置私信邀请人的方式（如 notify.Notifier.Send），遵循对方的通知偏好
+func (h *Handler) SetNotifier(notify func(userID int64, text string)) {
+	h.notify = notify
+}
+
+// Register 注册 /invite 命令和「邀请朋友」按钮回调
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.InviteCommand, h.Invite)
+	router.HandleCallback(ui.InviteFriendsAction, h.Invite)
+}
+
+// Invite 发送用户的邀请链接、奖励规则和邀请收益
+func (h *Handler) Invite(ctx *middleware.Context) error {
+	summary, err := h.db.GetReferralSummary(ctx.UserID)
+	if err != nil {
+		return err
+	}
+	text := ui.FormatInvite(ui.ReferralLink(h.client.Self().UserName, ctx.UserID), summary, &h.rewards)
+
+	// 回调弹窗中的链接无法点击，按钮点击时改为发送消息
+	if ctx.IsCallback() {
+		if _, err := ctx.Client.Request(tgbotapi.NewCallback(ctx.Update.CallbackQuery.ID, "")); err != nil {
+			log.Printf("⚠️ 应答邀请按钮失败: %v", err)
+		}
+		_, err := ctx.Client.Send(tgbotapi.NewMessage(ctx.ChatID, text))
+		return err
+	}
+	return ctx.Reply(text)
+}
+
+// Redeem 处理 /start 的邀请参数：新用户通过邀请链接注册时记录邀请关系并发放注册奖励。
+// 由 /start 处理函数在用户已创建后调用，参数不是邀请链接时返回 false，由调用方继续显示欢迎信息
+func (h *Handler) Redeem(ctx *middleware.Context) (bool, error) {
+	referrerID, ok := ui.ParseReferralPayload(ctx.Args)
+	if !ok {
+		return false, nil
+	}
+
+	_, err := h.db.CreateReferral(ctx.UserID, referrerID, &h.rewards)
+	if errors.Is(err, database.ErrSelfReferral) || errors.Is(err, database.ErrReferrerNotFound) ||
+		errors.Is(err, database.ErrAlreadyReferred) {
+		return true, ctx.Reply("ℹ️ " + err.Error())
+	}
+	if err != nil {
+		return true, err
+	}
+	log.Printf("🤝 用户 %d 通过用户 %d 的邀请链接注册", ctx.UserID, referrerID)
+
+	if h.notify != nil {
+		name := "新朋友"
+		if ctx.From != nil {
+			name = ui.PublicName(ctx.UserID, ctx.From.UserName, ctx.From.FirstName, false)
+		}
+		h.notify(referrerID, ui.FormatReferrerNotice(name, &h.rewards))
+	}
+	return true, ctx.Reply(ui.FormatReferralJoined(&h.rewards))
+}
@@ -0,0 +1,113 @@
+package rematch
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/events"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler 对局结束后的再来一局：结算后发送按钮，任一方点击即以相同下注额向对手发起邀请
+type Handler struct {
+	db      *database.DB
+	manager *game.Manager
+	client  telegram.Client
+	codec   *callback.Codec
+	// 再来一局前的下注资格检查，未设置时不限制
+	eligibility middleware.EligibilityChecker
+}
+
+// NewHandler 创建再来一局处理器
+func NewHandler(db *database.DB, manager *game.Manager, client telegram.Client, codec *callback.Codec) *Handler {
+	return &Handler{db: db, manager: manager, client: client, codec: codec}
+}
+
+// Register 注册再来一局按钮回调，发起前检查下注资格
+func (h *Handler) Register(router *middleware.Router) {
+	router.HandleCallback(callback.Prefix(ui.RematchAction), h.Rematch, middleware.Eligible(h.eligible))
+}
+
+// SetEligibility 设置下注资格检查（如 eligibility.Checker.Check），未设置时不限制
+func (h *Handler) SetEligibility(check middleware.EligibilityChecker) {
+	h.eligibility = check
+}
+
+// eligible 按已设置的下注资格检查用户
+func (h *Handler) eligible(chatID int64, from *tgbotapi.User) (string, error) {
+	if h.eligibility == nil {
+		return "", nil
+	}
+	return h.eligibility(chatID, from)
+}
+
+// Subscribe 订阅对局结算事件，结算后在群内发送再来一局按钮
+func (h *Handler) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.GameSettledEvent, h.handle)
+}
+
+// handle 双人对局结算后发送再来一局按钮
+func (h *Handler) handle(event events.Event) {
+	settled, ok := event.(events.GameSettled)
+	if !ok || settled.Player2ID == 0 {
+		return
+	}
+
+	msg, err := ui.BuildRematchOffer(h.codec, settled.ChatID, settled.GameID, settled.BetAmount, game.DisputeWindow)
+	if err != nil {
+		log.Printf("⚠️ 生成对局 %s 的再来一局按钮失败: %v", settled.GameID, err)
+		return
+	}
+	if _, err := h.client.Send(msg); err != nil {
+		log.Printf("⚠️ 发送对局 %s 的再来一局按钮失败: %v", settled.GameID, err)
+	}
+}
+
+// Rematch 再来一局按钮：以上一局的下注额发起只有对手能加入的对局，对手已发起时提示其接受
+func (h *Handler) Rematch(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	sourceID, err := ui.ParseRematchCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+
+	rematch, err := h.manager.CreateRematch(sourceID, ctx.UserID)
+	if errors.Is(err, game.ErrRematchPending) {
+		return ctx.Reply("ℹ️ " + err.Error())
+	}
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "🔄 已发起再来一局")); err != nil {
+		log.Printf("⚠️ 应答再来一局按钮失败: %v", err)
+	}
+
+	initiator := "玩家"
+	if ctx.From != nil {
+		initiator = ui.PublicName(ctx.UserID, ctx.From.UserName, ctx.From.FirstName, false)
+	}
+	opponent, err := h.db.GetUser(rematch.OpponentID)
+	if err != nil {
+		return fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	opponentName := fmt.Sprintf("玩家 %d", rematch.OpponentID)
+	if opponent != nil {
+		opponentName = ui.PublicName(opponent.ID, opponent.Username, opponent.FirstName, false)
+	}
+
+	msg, err := ui.BuildRematchInvite(h.codec, rematch.ChatID, rematch.GameID, rematch.BetAmount, initiator, opponentName, game.RematchWindow)
+	if err != nil {
+		return err
+	}
+	_, err = ctx.Client.Send(msg)
+	return err
+}
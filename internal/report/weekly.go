@@ -0,0 +1,129 @@
+package report
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/maintenance"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+)
+
+// ErrRunning 已有每周战报任务在执行
+var ErrRunning = errors.New("每周战报正在生成中")
+
+// Weekly 每周一在指定时段为订阅的用户生成上一周的战报，经发件箱私信发送
+type Weekly struct {
+	db     *database.DB
+	window maintenance.Window
+	// 战报写入发件箱后唤醒发送器
+	notify func()
+
+	mu      sync.Mutex
+	running bool
+	quit    chan struct{}
+}
+
+// NewWeekly 创建每周战报任务，notify 在写入战报后调用（如 outbox.Sender.Notify），可为 nil
+func NewWeekly(db *database.DB, window maintenance.Window, notify func()) *Weekly {
+	return &Weekly{
+		db:     db,
+		window: window,
+		notify: notify,
+		quit:   make(chan struct{}),
+	}
+}
+
+// Start 启动定时检查，每 10 分钟检查一次是否进入周一的发送时段
+func (w *Weekly) Start() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Minute)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case now := <-ticker.C:
+				if now.Weekday() != time.Monday || !w.window.Contains(now) {
+					continue
+				}
+				if _, err := w.Run(now); err != nil && !errors.Is(err, ErrRunning) {
+					log.Printf("❌ 生成每周战报失败: %v", err)
+				}
+			case <-w.quit:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止定时检查
+func (w *Weekly) Stop() {
+	close(w.quit)
+}
+
+// WeekBounds 返回 now 之前最近一个完整的自然周：[上周一零点, 本周一零点)，按 now 的时区
+func WeekBounds(now time.Time) (since, until time.Time) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	daysSinceMonday := (int(midnight.Weekday()) + 6) % 7
+	until = midnight.AddDate(0, 0, -daysSinceMonday)
+	return until.AddDate(0, 0, -7), until
+}
+
+// Run 为尚未收到上一周战报的订阅用户生成战报并写入发件箱，本周没有对局的用户不发送，返回写入的战报数
+func (w *Weekly) Run(now time.Time) (int, error) {
+	w.mu.Lock()
+	if w.running {
+		w.mu.Unlock()
+		return 0, ErrRunning
+	}
+	w.running = true
+	w.mu.Unlock()
+
+	defer func() {
+		w.mu.Lock()
+		w.running = false
+		w.mu.Unlock()
+	}()
+
+	since, until := WeekBounds(now)
+	recipients, err := w.db.GetWeeklySummaryRecipients(until)
+	if err != nil {
+		return 0, fmt.Errorf("获取战报订阅用户失败: %v", err)
+	}
+
+	queued := 0
+	for _, recipient := range recipients {
+		summary, err := w.db.GetWeeklySummary(recipient.UserID, since, until)
+		if err != nil {
+			log.Printf("⚠️ 统计用户 %d 每周战报失败: %v", recipient.UserID, err)
+			continue
+		}
+
+		var message *models.OutboxMessage
+		if summary.Games > 0 {
+			message = &models.OutboxMessage{
+				ChatID: recipient.UserID,
+				Text:   ui.FormatWeeklySummary(recipient.Language, summary),
+			}
+		}
+		ok, err := w.db.EnqueueWeeklySummary(recipient.UserID, until, message)
+		if err != nil {
+			log.Printf("⚠️ 写入用户 %d 每周战报失败: %v", recipient.UserID, err)
+			continue
+		}
+		if ok && message != nil {
+			queued++
+		}
+	}
+
+	if queued > 0 && w.notify != nil {
+		w.notify()
+	}
+	log.Printf("📅 每周战报（%s - %s）：订阅 %d 人，发送 %d 份",
+		since.Format("01-02"), until.AddDate(0, 0, -1).Format("01-02"), len(recipients), queued)
+	return queued, nil
+}
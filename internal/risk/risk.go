@@ -0,0 +1,143 @@
+package risk
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// Rules 风控规则阈值，为 0 的规则不检查
+type Rules struct {
+	// 统计窗口，只统计窗口内结算的对局，0 表示不启用风控
+	Window time.Duration
+	// 同一对玩家在窗口内的对局数达到该值时标记双方
+	PairGames int
+	// 窗口内分出胜负的对局数达到 MinGames 且胜率不低于 WinRate 时标记该用户
+	MinGames int
+	WinRate  float64
+	// 同一对玩家之间的净输赢达到该金额（基本单位）时标记双方
+	NetTransfer int64
+}
+
+// Engine 对局结算后检查双方近期的对局，标记疑似串通或转移筹码的账户，并可自动冻结等待管理员审核
+type Engine struct {
+	db         *database.DB
+	rules      Rules
+	autoFreeze bool
+	// 标记新风控事件后的回调（通知管理员）
+	onFlagged func(events []*models.RiskEvent)
+
+	// 串行执行检查，避免同一对玩家并发结算时重复标记
+	mu sync.Mutex
+}
+
+// NewEngine 创建风控引擎
+func NewEngine(db *database.DB, rules Rules, autoFreeze bool) *Engine {
+	return &Engine{db: db, rules: rules, autoFreeze: autoFreeze}
+}
+
+// SetFlaggedCallback 设置标记新风控事件后的回调
+func (e *Engine) SetFlaggedCallback(callback func(events []*models.RiskEvent)) {
+	e.onFlagged = callback
+}
+
+// Enabled 是否启用风控检查
+func (e *Engine) Enabled() bool {
+	return e != nil && e.rules.Window > 0
+}
+
+// CheckSettlement 分出胜负的对局结算后检查双方，返回本次新标记的风控事件。
+// 统计窗口内已审核过的用户、规则和对手组合不再重复标记
+func (e *Engine) CheckSettlement(game *models.Game, winnerID, loserID int64) ([]*models.RiskEvent, error) {
+	if !e.Enabled() {
+		return nil, nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	since := time.Now().Add(-e.rules.Window)
+	var flagged []*models.RiskEvent
+	flag := func(event *models.RiskEvent) error {
+		event.GameID = game.ID
+		recorded, err := e.db.RecordRiskEvent(event, e.autoFreeze, since)
+		if err != nil {
+			return fmt.Errorf("记录用户 %d 风控事件失败: %v", event.UserID, err)
+		}
+		if recorded {
+			log.Printf("🚨 风控标记用户 %d（%s）：%s", event.UserID, event.Rule, event.Detail)
+			flagged = append(flagged, event)
+		}
+		return nil
+	}
+
+	pair, net, err := e.db.GetPairActivity(winnerID, loserID, since)
+	if err != nil {
+		return nil, fmt.Errorf("统计交手记录失败: %v", err)
+	}
+	window := formatWindow(e.rules.Window)
+	if e.rules.PairGames > 0 && pair.Games >= e.rules.PairGames {
+		for _, users := range [][2]int64{{winnerID, loserID}, {loserID, winnerID}} {
+			userID, counterparty := users[0], users[1]
+			if err := flag(&models.RiskEvent{UserID: userID, CounterpartyID: counterparty, Rule: models.RiskRulePairGames,
+				Detail: fmt.Sprintf("%s内与用户 %d 对局 %d 局（%d 胜 %d 负）", window, counterparty, pair.Games,
+					pair.Wins(userID), pair.Wins(counterparty))}); err != nil {
+				return flagged, err
+			}
+		}
+	}
+	if e.rules.NetTransfer > 0 && net >= e.rules.NetTransfer {
+		if err := flag(&models.RiskEvent{UserID: winnerID, CounterpartyID: loserID, Rule: models.RiskRuleChipDump,
+			Detail: fmt.Sprintf("%s内从用户 %d 处净赢得 %s 金币", window, loserID, utils.FormatAmount(net))}); err != nil {
+			return flagged, err
+		}
+		if err := flag(&models.RiskEvent{UserID: loserID, CounterpartyID: winnerID, Rule: models.RiskRuleChipDump,
+			Detail: fmt.Sprintf("%s内向用户 %d 净输出 %s 金币", window, winnerID, utils.FormatAmount(net))}); err != nil {
+			return flagged, err
+		}
+	}
+
+	if e.rules.WinRate > 0 && e.rules.MinGames > 0 {
+		games, wins, err := e.db.GetWinRecord(winnerID, since)
+		if err != nil {
+			return flagged, fmt.Errorf("统计用户 %d 胜率失败: %v", winnerID, err)
+		}
+		if games >= e.rules.MinGames && float64(wins)/float64(games) >= e.rules.WinRate {
+			if err := flag(&models.RiskEvent{UserID: winnerID, Rule: models.RiskRuleWinRate,
+				Detail: fmt.Sprintf("%s内 %d 局胜 %d 局，胜率 %.0f%%", window, games, wins, float64(wins)*100/float64(games))}); err != nil {
+				return flagged, err
+			}
+		}
+	}
+
+	if len(flagged) > 0 && e.onFlagged != nil {
+		go e.onFlagged(flagged)
+	}
+	return flagged, nil
+}
+
+// Status 风控配置快照
+func (e *Engine) Status() map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":      e.Enabled(),
+		"window_hours": e.rules.Window.Hours(),
+		"pair_games":   e.rules.PairGames,
+		"min_games":    e.rules.MinGames,
+		"win_rate":     e.rules.WinRate,
+		"net_transfer": utils.AmountToFloat(e.rules.NetTransfer),
+		"auto_freeze":  e.autoFreeze,
+	}
+}
+
+// formatWindow 统计窗口的展示文本，如“24 小时”
+func formatWindow(window time.Duration) string {
+	if window%time.Hour == 0 {
+		return fmt.Sprintf("%d 小时", int(window.Hours()))
+	}
+	return fmt.Sprintf("%d 分钟", int(window.Minutes()))
+}
@@ -0,0 +1,31 @@
+package plugins
+
+import "telegram-dice-bot/internal/rules"
+
+// LowestTotalName 点数低者获胜插件的名称
+const LowestTotalName = "lowest_total_wins"
+
+// LowestTotalWins 点数低者获胜，点数相同为平局
+type LowestTotalWins struct{}
+
+func init() {
+	rules.Register(LowestTotalWins{})
+}
+
+// Name 实现 rules.Rule
+func (LowestTotalWins) Name() string {
+	return LowestTotalName
+}
+
+// Winner 实现 rules.WinnerRule
+func (LowestTotalWins) Winner(outcome rules.Outcome) int {
+	total1, total2 := rules.Total(outcome.Player1), rules.Total(outcome.Player2)
+	switch {
+	case total1 < total2:
+		return rules.Player1
+	case total2 < total1:
+		return rules.Player2
+	default:
+		return rules.Draw
+	}
+}
@@ -0,0 +1,26 @@
+package plugins
+
+import "telegram-dice-bot/internal/rules"
+
+// TripleBonusName 豹子免手续费插件的名称
+const TripleBonusName = "triple_bonus"
+
+// TripleBonus 获胜方掷出豹子（三个骰子点数相同）时免收手续费，整个奖池派给获胜者
+type TripleBonus struct{}
+
+func init() {
+	rules.Register(TripleBonus{})
+}
+
+// Name 实现 rules.Rule
+func (TripleBonus) Name() string {
+	return TripleBonusName
+}
+
+// ModifyPayout 实现 rules.PayoutModifier
+func (TripleBonus) ModifyPayout(outcome rules.Outcome, payout rules.Payout) rules.Payout {
+	if dice, ok := outcome.WinnerDice(); ok && rules.IsTriple(dice) {
+		payout.Commission = 0
+	}
+	return payout
+}
@@ -0,0 +1,199 @@
+package rules
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+)
+
+// 对局胜负：平局、玩家1胜、玩家2胜
+const (
+	Draw    = 0
+	Player1 = 1
+	Player2 = 2
+)
+
+// Outcome 一局对战的骰子结果，供插件判定胜负和调整派奖
+type Outcome struct {
+	ChatID    int64
+	BetAmount int64
+	Player1   [3]int
+	Player2   [3]int
+	// 胜负判定结果，调用派奖插件时已填写
+	Winner int
+}
+
+// WinnerDice 获胜方的骰子，平局时返回 false
+func (o Outcome) WinnerDice() ([3]int, bool) {
+	switch o.Winner {
+	case Player1:
+		return o.Player1, true
+	case Player2:
+		return o.Player2, true
+	default:
+		return [3]int{}, false
+	}
+}
+
+// Total 三个骰子的总点数
+func Total(dice [3]int) int {
+	return dice[0] + dice[1] + dice[2]
+}
+
+// IsTriple 三个骰子点数相同（豹子）
+func IsTriple(dice [3]int) bool {
+	return dice[0] == dice[1] && dice[1] == dice[2]
+}
+
+// Payout 获胜方的派奖：奖池扣除手续费后全部派给获胜者
+type Payout struct {
+	Pot        int64
+	Commission int64
+}
+
+// WinAmount 获胜者拿到的金额
+func (p Payout) WinAmount() int64 {
+	return p.Pot - p.Commission
+}
+
+// Rule 自定义玩法插件，通过 Register 注册后按名称在 GAME_RULES 中启用。
+// 插件还需实现 WinnerRule 或 PayoutModifier 中的至少一个
+type Rule interface {
+	Name() string
+}
+
+// WinnerRule 自定义胜负判定，返回 Draw、Player1 或 Player2；同时只能启用一个
+type WinnerRule interface {
+	Rule
+	Winner(outcome Outcome) int
+}
+
+// PayoutModifier 调整获胜方的派奖，只能调整手续费，奖池其余部分全部派给获胜者；可启用多个，按启用顺序依次调整
+type PayoutModifier interface {
+	Rule
+	ModifyPayout(outcome Outcome, payout Payout) Payout
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Rule)
+)
+
+// Register 注册插件，通常在插件包的 init 中调用；名称重复或插件未实现任何钩子时 panic
+func Register(rule Rule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if rule == nil {
+		panic("rules: 插件不能为 nil")
+	}
+	name := rule.Name()
+	if _, ok := rule.(WinnerRule); !ok {
+		if _, ok := rule.(PayoutModifier); !ok {
+			panic(fmt.Sprintf("rules: 插件 %s 未实现 WinnerRule 或 PayoutModifier", name))
+		}
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("rules: 插件 %s 重复注册", name))
+	}
+	registry[name] = rule
+}
+
+// Names 已注册的插件名称，按字母排序
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registeredNames()
+}
+
+// registeredNames 已注册的插件名称，调用方需持有 registryMu
+func registeredNames() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Set 启用的插件组合，nil 或空 Set 按默认规则：点数高者获胜，手续费不变
+type Set struct {
+	names     []string
+	winner    WinnerRule
+	modifiers []PayoutModifier
+}
+
+// Load 按名称组合已注册的插件，名称未注册或启用了多个胜负判定插件时返回错误
+func Load(names []string) (*Set, error) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	set := &Set{}
+	for _, name := range names {
+		rule, ok := registry[name]
+		if !ok {
+			return nil, fmt.Errorf("玩法插件 %s 未注册，可用插件: %v", name, registeredNames())
+		}
+		if winner, ok := rule.(WinnerRule); ok {
+			if set.winner != nil {
+				return nil, fmt.Errorf("只能启用一个胜负判定插件: %s 与 %s 冲突", set.winner.Name(), name)
+			}
+			set.winner = winner
+		}
+		if modifier, ok := rule.(PayoutModifier); ok {
+			set.modifiers = append(set.modifiers, modifier)
+		}
+		set.names = append(set.names, name)
+	}
+	return set, nil
+}
+
+// Names 启用的插件名称，按启用顺序
+func (s *Set) Names() []string {
+	if s == nil {
+		return nil
+	}
+	return s.names
+}
+
+// Winner 判定胜负，未启用胜负判定插件或插件返回无效结果时点数高者获胜
+func (s *Set) Winner(outcome Outcome) int {
+	if s != nil && s.winner != nil {
+		switch winner := s.winner.Winner(outcome); winner {
+		case Draw, Player1, Player2:
+			return winner
+		default:
+			log.Printf("⚠️ 玩法插件 %s 返回了无效的胜负 %d，按默认规则判定", s.winner.Name(), winner)
+		}
+	}
+
+	total1, total2 := Total(outcome.Player1), Total(outcome.Player2)
+	switch {
+	case total1 > total2:
+		return Player1
+	case total2 > total1:
+		return Player2
+	default:
+		return Draw
+	}
+}
+
+// Payout 依次应用派奖插件，手续费限定在 0 到奖池之间，保证结算前后资金守恒
+func (s *Set) Payout(outcome Outcome, payout Payout) Payout {
+	if s == nil {
+		return payout
+	}
+	for _, modifier := range s.modifiers {
+		pot := payout.Pot
+		payout = modifier.ModifyPayout(outcome, payout)
+		payout.Pot = pot
+		if payout.Commission < 0 {
+			payout.Commission = 0
+		}
+		if payout.Commission > payout.Pot {
+			payout.Commission = payout.Pot
+		}
+	}
+	return payout
+}
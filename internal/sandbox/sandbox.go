@@ -0,0 +1,70 @@
+package sandbox
+
+import (
+	"fmt"
+	"log"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+)
+
+// Prepare 启动时校验数据库与运行模式一致：沙盒进程不能使用含有真实数据的数据库，正式进程不能使用沙盒数据库
+func Prepare(db *database.DB, sandbox bool) error {
+	marked, err := db.IsSandbox()
+	if err != nil {
+		return fmt.Errorf("读取沙盒标记失败: %v", err)
+	}
+
+	if !sandbox {
+		if marked {
+			return fmt.Errorf("当前数据库为沙盒数据库，正式环境不能使用")
+		}
+		return nil
+	}
+
+	if !marked {
+		users, err := db.GetTotalUsersCount()
+		if err != nil {
+			return fmt.Errorf("检查数据库失败: %v", err)
+		}
+		if users > 0 {
+			return fmt.Errorf("数据库中已有 %d 个用户，沙盒模式只能使用全新的数据库", users)
+		}
+		if err := db.MarkSandbox(); err != nil {
+			return fmt.Errorf("写入沙盒标记失败: %v", err)
+		}
+	}
+
+	log.Printf("🧪 沙盒模式已启用，余额均为练习金币")
+	return nil
+}
+
+// Handler 沙盒模式下 /faucet 领取练习金币的处理器
+type Handler struct {
+	db      *database.DB
+	balance int64
+}
+
+// NewHandler 创建练习金币处理器，balance 为领取后的余额
+func NewHandler(db *database.DB, balance int64) *Handler {
+	return &Handler{db: db, balance: balance}
+}
+
+// Register 注册 /faucet 命令，仅应在沙盒模式下注册
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.FaucetCommand, h.Faucet)
+}
+
+// Faucet 将用户余额补足到练习金币额度
+func (h *Handler) Faucet(ctx *middleware.Context) error {
+	granted, balance, err := h.db.GrantPlayMoney(ctx.UserID, h.balance)
+	if err != nil {
+		return fmt.Errorf("发放练习金币失败: %v", err)
+	}
+	if granted > 0 {
+		log.Printf("🧪 用户 %d 领取练习金币 %s", ctx.UserID, utils.FormatAmount(granted))
+	}
+	return ctx.Reply(ui.FormatPlayMoneyGranted(granted, balance))
+}
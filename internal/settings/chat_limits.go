@@ -0,0 +1,94 @@
+package settings
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+)
+
+// ChatLimits 查看或修改本群的下注限额、手续费率和等待超时
+func (h *Handler) ChatLimits(ctx *middleware.Context) error {
+	fields := strings.Fields(strings.ToLower(ctx.Args))
+	if len(fields) == 0 {
+		return h.showChatLimits(ctx)
+	}
+	if len(fields) != 2 {
+		return ctx.Reply("❌ 参数错误\n" + ui.FormatChatLimitsUsage())
+	}
+
+	limits, err := h.manager.ChatLimits(ctx.ChatID)
+	if err != nil {
+		return err
+	}
+	custom := *limits.Custom
+	reset := fields[1] == ui.ChatLimitDefault
+
+	switch fields[0] {
+	case ui.ChatLimitMinBet, ui.ChatLimitMaxBet:
+		var amount int64
+		if !reset {
+			amount, err = utils.ParseAmount(fields[1])
+			if err != nil {
+				return ctx.Reply("❌ 金额必须是正数，最多两位小数\n" + ui.FormatChatLimitsUsage())
+			}
+		}
+		if fields[0] == ui.ChatLimitMinBet {
+			custom.MinBet = amount
+		} else {
+			custom.MaxBet = amount
+		}
+	case ui.ChatLimitFee:
+		custom.FeeRate = nil
+		if !reset {
+			percent, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "%"), 64)
+			if err != nil {
+				return ctx.Reply("❌ 无效的手续费率\n" + ui.FormatChatLimitsUsage())
+			}
+			rate := percent / 100
+			custom.FeeRate = &rate
+		}
+	case ui.ChatLimitTimeout:
+		custom.GameTimeout = 0
+		if !reset {
+			timeout, ok := parseTimeout(fields[1])
+			if !ok {
+				return ctx.Reply("❌ 无效的等待超时\n" + ui.FormatChatLimitsUsage())
+			}
+			custom.GameTimeout = int(timeout / time.Second)
+		}
+	default:
+		return ctx.Reply("❌ 未知的设置项\n" + ui.FormatChatLimitsUsage())
+	}
+
+	if err := h.manager.SetChatGameLimits(ctx.ChatID, &custom); err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	log.Printf("⚙️ 群管理员 %d 将群组 %d 的 %s 设置为 %s", ctx.UserID, ctx.ChatID, fields[0], fields[1])
+	return h.showChatLimits(ctx)
+}
+
+// showChatLimits 发送本群当前生效的对局设置
+func (h *Handler) showChatLimits(ctx *middleware.Context) error {
+	limits, err := h.manager.ChatLimits(ctx.ChatID)
+	if err != nil {
+		return err
+	}
+	return ctx.Reply(ui.FormatChatLimits(limits.MinBet, limits.MaxBet, limits.FeeRate, limits.GameTimeout, limits.Custom))
+}
+
+// parseTimeout 解析等待超时：秒数或 Go 时长格式（如 2m、90s），按秒取整
+func parseTimeout(arg string) (time.Duration, bool) {
+	if seconds, err := strconv.Atoi(arg); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	d, err := time.ParseDuration(arg)
+	if err != nil {
+		return 0, false
+	}
+	return d.Truncate(time.Second), true
+}
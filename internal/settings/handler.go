@@ -0,0 +1,106 @@
+package settings
+
+import (
+	"fmt"
+	"log"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /settings 命令和设置开关按钮的处理器：私聊中为个人设置，群组中为本群对局设置
+type Handler struct {
+	db      *database.DB
+	manager *game.Manager
+	codec   *callback.Codec
+}
+
+// NewHandler 创建设置处理器
+func NewHandler(db *database.DB, manager *game.Manager, codec *callback.Codec) *Handler {
+	return &Handler{db: db, manager: manager, codec: codec}
+}
+
+// Register 注册 /settings 命令和个人设置开关回调，开关回调仅限私聊
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle("settings", h.Settings)
+	router.HandleCallback(callback.Prefix(ui.SettingsAction), h.Toggle, middleware.PrivateOnly())
+}
+
+// Settings 私聊中显示个人设置，群组中由群管理员查看或修改本群对局设置
+func (h *Handler) Settings(ctx *middleware.Context) error {
+	if ctx.ChatID < 0 {
+		return middleware.Chain(h.ChatLimits, middleware.ChatAdminOnly())(ctx)
+	}
+	return middleware.Chain(h.Show, middleware.PrivateOnly())(ctx)
+}
+
+// Show 发送个人设置页面
+func (h *Handler) Show(ctx *middleware.Context) error {
+	msg, err := h.settingsMessage(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = ctx.Client.Send(msg)
+	return err
+}
+
+// Toggle 切换设置项并刷新设置页面
+func (h *Handler) Toggle(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	setting, enabled, err := ui.ParseSettingsCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+
+	switch setting {
+	case ui.SettingNotifications:
+		err = h.db.SetUserNotifications(ctx.UserID, enabled)
+	case ui.SettingAnonymous:
+		err = h.db.SetUserAnonymous(ctx.UserID, enabled)
+	case ui.SettingCelebrate:
+		err = h.db.SetUserCelebrateStreaks(ctx.UserID, enabled)
+	case ui.SettingWeekly:
+		// 每周战报使用订阅时的客户端语言
+		language := ui.DefaultLanguage
+		if ctx.From != nil {
+			language = ui.NormalizeLanguage(ctx.From.LanguageCode)
+		}
+		err = h.db.SetUserWeeklySummary(ctx.UserID, enabled, language)
+	}
+	if err != nil {
+		return fmt.Errorf("更新用户设置失败: %v", err)
+	}
+	log.Printf("⚙️ 用户 %d 将 %s 设置为 %v", ctx.UserID, setting, enabled)
+
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "✅ 设置已保存")); err != nil {
+		log.Printf("⚠️ 应答设置按钮失败: %v", err)
+	}
+
+	msg, err := h.settingsMessage(ctx)
+	if err != nil {
+		return err
+	}
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(ctx.ChatID, query.Message.MessageID, msg.Text,
+		msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup))
+	_, err = ctx.Client.Request(edit)
+	return err
+}
+
+// settingsMessage 按用户当前设置构建个人设置页面
+func (h *Handler) settingsMessage(ctx *middleware.Context) (tgbotapi.MessageConfig, error) {
+	notifications, anonymous, celebrate, err := h.db.GetUserPreferences(ctx.UserID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, fmt.Errorf("获取用户设置失败: %v", err)
+	}
+	weekly, err := h.db.IsWeeklySummaryEnabled(ctx.UserID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, fmt.Errorf("获取用户设置失败: %v", err)
+	}
+	return ui.BuildSettingsMessage(h.codec, ctx.ChatID, notifications, anonymous, celebrate, weekly)
+}
@@ -0,0 +1,107 @@
+package shard
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// queuePollInterval 检查更新队列的周期
+	queuePollInterval = 100 * time.Millisecond
+	// queueBatchSize 每次最多领取的更新数
+	queueBatchSize = 50
+)
+
+// Queue 从共享的更新队列领取本分片群组的更新，作为 middleware.Poller 的更新来源。
+// 只在本进程服务本分片时领取；更新处理完后从队列删除，进程崩溃时已领取的更新在其心跳过期后由接管的进程重新领取
+type Queue struct {
+	coordinator *Coordinator
+
+	updates chan tgbotapi.Update
+	quit    chan struct{}
+}
+
+// NewQueue 创建分片的更新队列消费者
+func NewQueue(coordinator *Coordinator) *Queue {
+	return &Queue{
+		coordinator: coordinator,
+		updates:     make(chan tgbotapi.Update),
+		quit:        make(chan struct{}),
+	}
+}
+
+// Updates 开始领取更新，Stop 后通道关闭
+func (q *Queue) Updates() <-chan tgbotapi.Update {
+	go q.run()
+	return q.updates
+}
+
+// Done 更新处理完毕，从队列删除
+func (q *Queue) Done(updateID int) error {
+	return q.coordinator.db.CompleteUpdate(updateID)
+}
+
+// Stop 停止领取，已领取但尚未交付的更新放回队列
+func (q *Queue) Stop() {
+	close(q.quit)
+}
+
+// run 周期性领取更新并逐条交付
+func (q *Queue) run() {
+	defer close(q.updates)
+
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+
+	for {
+		if q.coordinator.Serving() && !q.drain() {
+			return
+		}
+		select {
+		case <-ticker.C:
+		case <-q.quit:
+			return
+		}
+	}
+}
+
+// drain 领取并交付队列中本分片的全部更新，停止时返回 false
+func (q *Queue) drain() bool {
+	c := q.coordinator
+	for {
+		claimed, err := c.db.ClaimUpdates(c.node.ShardID, c.node.ShardCount, c.instanceID, time.Now().Add(-nodeTTL), queueBatchSize)
+		if err != nil {
+			log.Printf("⚠️ 领取更新失败: %v", err)
+		}
+		if len(claimed) == 0 {
+			return true
+		}
+
+		for i, queued := range claimed {
+			var update tgbotapi.Update
+			if err := json.Unmarshal([]byte(queued.Payload), &update); err != nil {
+				log.Printf("⚠️ 解析队列中的更新 %d 失败，已丢弃: %v", queued.UpdateID, err)
+				if err := q.Done(queued.UpdateID); err != nil {
+					log.Printf("⚠️ 删除更新 %d 失败: %v", queued.UpdateID, err)
+				}
+				continue
+			}
+
+			select {
+			case q.updates <- update:
+			case <-q.quit:
+				ids := make([]int, 0, len(claimed)-i)
+				for _, rest := range claimed[i:] {
+					ids = append(ids, rest.UpdateID)
+				}
+				if err := c.db.ReleaseUpdates(c.instanceID, ids); err != nil {
+					log.Printf("⚠️ 放回未处理的更新失败: %v", err)
+				}
+				return false
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package shard
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// receiverPollTimeout 接收器长轮询的超时时间（秒），失去接收资格后最多再等待这么久才停止拉取
+	receiverPollTimeout = 10
+	// receiverIdleInterval 没有接收资格时重新检查的间隔
+	receiverIdleInterval = time.Second
+	// receiverRetryInterval 拉取或写入队列失败后的重试间隔
+	receiverRetryInterval = 3 * time.Second
+)
+
+// Receiver 分片部署时唯一拉取 Telegram 更新的接收器：Telegram 同一 Token 只允许一个 getUpdates 请求，
+// 由持有接收资格的进程拉取后写入共享的更新队列，各分片从队列领取自己的群组（见 Queue）。
+// 更新与偏移量在同一事务中写入，偏移量只有一个写入者，不会因多个进程交替推进而丢失更新
+type Receiver struct {
+	db     *database.DB
+	client telegram.Client
+	gate   func() bool // 返回 false 时不拉取，由其他进程负责
+
+	quit chan struct{}
+	done chan struct{}
+}
+
+// NewReceiver 创建更新接收器
+func NewReceiver(db *database.DB, client telegram.Client) *Receiver {
+	return &Receiver{
+		db:     db,
+		client: client,
+		quit:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// SetGate 设置接收资格，多进程部署时只由一个进程拉取更新
+func (r *Receiver) SetGate(gate func() bool) {
+	r.gate = gate
+}
+
+// Start 启动后台拉取
+func (r *Receiver) Start() {
+	go r.run()
+}
+
+// Stop 停止拉取，等待进行中的一轮拉取写入队列后返回
+func (r *Receiver) Stop() {
+	close(r.quit)
+	<-r.done
+}
+
+// run 有接收资格时持续拉取更新写入队列
+func (r *Receiver) run() {
+	defer close(r.done)
+
+	for {
+		wait := time.Duration(0)
+		if r.gate != nil && !r.gate() {
+			wait = receiverIdleInterval
+		} else if err := r.receive(); err != nil {
+			log.Printf("⚠️ 拉取更新失败: %v", err)
+			wait = receiverRetryInterval
+		}
+
+		select {
+		case <-r.quit:
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// receive 从持久化的偏移量拉取一轮更新并写入队列
+func (r *Receiver) receive() error {
+	offset, err := r.offset()
+	if err != nil {
+		return err
+	}
+
+	config := tgbotapi.NewUpdate(offset)
+	config.Timeout = receiverPollTimeout
+	updates, err := telegram.GetUpdates(r.client, config)
+	if err != nil {
+		return err
+	}
+
+	queued := make([]*models.QueuedUpdate, 0, len(updates))
+	for i := range updates {
+		payload, err := json.Marshal(updates[i])
+		if err != nil {
+			return fmt.Errorf("序列化更新 %d 失败: %v", updates[i].UpdateID, err)
+		}
+		queued = append(queued, &models.QueuedUpdate{
+			UpdateID: updates[i].UpdateID,
+			ChatID:   telegram.UpdateChatID(&updates[i]),
+			Payload:  string(payload),
+		})
+	}
+	if err := r.db.EnqueueUpdates(queued, telegram.OffsetSettingKey); err != nil {
+		return fmt.Errorf("写入更新队列失败: %v", err)
+	}
+	return nil
+}
+
+// offset 长轮询的起始 offset，与单进程部署共用同一偏移量，切换部署方式时不会重复或遗漏更新
+func (r *Receiver) offset() (int, error) {
+	value, ok, err := r.db.GetSetting(telegram.OffsetSettingKey)
+	if err != nil {
+		return 0, fmt.Errorf("读取更新偏移量失败: %v", err)
+	}
+	if !ok {
+		return 0, nil
+	}
+	lastID, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("更新偏移量格式错误: %v", err)
+	}
+	return lastID + 1, nil
+}
@@ -0,0 +1,221 @@
+package shard
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+)
+
+const (
+	// heartbeatInterval 心跳及分片锁续期间隔
+	heartbeatInterval = 10 * time.Second
+	// nodeTTL 超过该时间没有心跳的进程视为下线
+	nodeTTL = 3 * heartbeatInterval
+)
+
+// Of 计算群组所属的分片
+func Of(chatID int64, count int) int {
+	if count <= 1 {
+		return 0
+	}
+	if chatID < 0 {
+		chatID = -chatID
+	}
+	return int(chatID % int64(count))
+}
+
+// Status 分片状态，用于管理后台展示
+type Status struct {
+	InstanceID  string              `json:"instance_id"`
+	ShardID     int                 `json:"shard_id"`
+	ShardCount  int                 `json:"shard_count"`
+	Serving     bool                `json:"serving"`
+	Rebalancing bool                `json:"rebalancing"`
+	Nodes       []*models.ShardNode `json:"nodes"`
+}
+
+// Coordinator 负责分片归属判断、分片锁和心跳
+type Coordinator struct {
+	db         *database.DB
+	node       *models.ShardNode
+	instanceID string
+
+	mu          sync.RWMutex
+	holdsShard  bool // 是否持有本分片的锁（防止两个进程认领同一分片）
+	rebalancing bool // 存在分片总数不一致的进程，暂停服务直到全部进程切换完成
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewCoordinator 创建分片协调器，shardCount <= 1 时不分片
+func NewCoordinator(db *database.DB, shardID, shardCount int) (*Coordinator, error) {
+	if shardCount < 1 {
+		shardCount = 1
+	}
+	if shardID < 0 || shardID >= shardCount {
+		return nil, fmt.Errorf("分片编号 %d 超出范围 [0, %d)", shardID, shardCount)
+	}
+
+	hostname, _ := os.Hostname()
+	instanceID := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+
+	return &Coordinator{
+		db:         db,
+		instanceID: instanceID,
+		node: &models.ShardNode{
+			InstanceID: instanceID,
+			ShardID:    shardID,
+			ShardCount: shardCount,
+			StartedAt:  time.Now(),
+		},
+		stopCh: make(chan struct{}),
+	}, nil
+}
+
+// Start 立即上报一次心跳并启动后台续期
+func (c *Coordinator) Start() {
+	c.tick()
+
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.tick()
+			case <-c.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止心跳并释放分片锁，使其他进程可以接管
+func (c *Coordinator) Stop() {
+	close(c.stopCh)
+	c.wg.Wait()
+
+	if err := c.db.ReleaseLock(c.shardLockName(), c.instanceID); err != nil {
+		log.Printf("⚠️ 释放分片锁失败: %v", err)
+	}
+	if err := c.db.RemoveShardNode(c.instanceID); err != nil {
+		log.Printf("⚠️ 移除分片心跳失败: %v", err)
+	}
+}
+
+// Owns 当前进程是否负责处理该群组
+func (c *Coordinator) Owns(chatID int64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.holdsShard || c.rebalancing {
+		return false
+	}
+	return Of(chatID, c.node.ShardCount) == c.node.ShardID
+}
+
+// Serving 当前进程是否正在服务本分片（持有分片锁且不在重新分片中）
+func (c *Coordinator) Serving() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.holdsShard && !c.rebalancing
+}
+
+// Leader 当前进程是否负责全局单例任务（发件箱、充值检测、公告和锦标赛调度）：
+// 由持有 0 号分片锁的进程负责，重新分片期间暂停，避免两个进程同时执行
+func (c *Coordinator) Leader() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.holdsShard && !c.rebalancing && c.node.ShardID == 0
+}
+
+// TryLock 获取分片范围内的分布式锁，key 相同的操作在所有进程间互斥
+func (c *Coordinator) TryLock(key string, ttl time.Duration) (release func(), ok bool, err error) {
+	name := fmt.Sprintf("shard:%d/%d:%s", c.node.ShardID, c.node.ShardCount, key)
+	ok, err = c.db.AcquireLock(name, c.instanceID, ttl)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	return func() {
+		if err := c.db.ReleaseLock(name, c.instanceID); err != nil {
+			log.Printf("⚠️ 释放分布式锁 %s 失败: %v", name, err)
+		}
+	}, true, nil
+}
+
+// Status 获取当前分片状态及所有在线进程
+func (c *Coordinator) Status() (*Status, error) {
+	nodes, err := c.db.GetShardNodes(time.Now().Add(-nodeTTL))
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &Status{
+		InstanceID:  c.instanceID,
+		ShardID:     c.node.ShardID,
+		ShardCount:  c.node.ShardCount,
+		Serving:     c.holdsShard && !c.rebalancing,
+		Rebalancing: c.rebalancing,
+		Nodes:       nodes,
+	}, nil
+}
+
+// tick 上报心跳、续期分片锁并检查是否处于重新分片中
+func (c *Coordinator) tick() {
+	if err := c.db.ShardHeartbeat(c.node); err != nil {
+		log.Printf("⚠️ 分片心跳上报失败: %v", err)
+	}
+
+	holds, err := c.db.AcquireLock(c.shardLockName(), c.instanceID, nodeTTL)
+	if err != nil {
+		log.Printf("⚠️ 续期分片锁失败: %v", err)
+		holds = false
+	}
+
+	nodes, err := c.db.GetShardNodes(time.Now().Add(-nodeTTL))
+	if err != nil {
+		log.Printf("⚠️ 获取分片进程列表失败: %v", err)
+	}
+	rebalancing := false
+	for _, node := range nodes {
+		if node.ShardCount != c.node.ShardCount {
+			rebalancing = true
+			break
+		}
+	}
+
+	c.mu.Lock()
+	if holds != c.holdsShard {
+		if holds {
+			log.Printf("✅ 已认领分片 %d/%d", c.node.ShardID, c.node.ShardCount)
+		} else {
+			log.Printf("⚠️ 分片 %d/%d 已被其他进程占用，暂停处理", c.node.ShardID, c.node.ShardCount)
+		}
+	}
+	if rebalancing != c.rebalancing {
+		if rebalancing {
+			log.Printf("🔄 检测到分片总数不一致的进程，暂停处理直至重新分片完成")
+		} else {
+			log.Printf("✅ 重新分片完成，恢复处理")
+		}
+	}
+	c.holdsShard = holds
+	c.rebalancing = rebalancing
+	c.mu.Unlock()
+}
+
+// shardLockName 分片归属锁名称
+func (c *Coordinator) shardLockName() string {
+	return fmt.Sprintf("shard-owner:%d/%d", c.node.ShardID, c.node.ShardCount)
+}
@@ -0,0 +1,61 @@
+package stats
+
+import (
+	"fmt"
+	"log"
+
+	"telegram-dice-bot/internal/cache"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /stats 命令和主菜单"我的统计"按钮的处理器，战绩经缓存读取
+type Handler struct {
+	db    *database.DB
+	stats *cache.UserStatsCache
+}
+
+// NewHandler 创建个人战绩处理器
+func NewHandler(db *database.DB, stats *cache.UserStatsCache) *Handler {
+	return &Handler{db: db, stats: stats}
+}
+
+// Register 注册 /stats 命令和"我的统计"按钮回调
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.StatsCommand, h.Show)
+	router.HandleCallback(ui.StatsAction, h.Show)
+}
+
+// Show 发送用户的个人战绩，群内开启匿名的用户显示化名
+func (h *Handler) Show(ctx *middleware.Context) error {
+	stats, err := h.stats.Get(ctx.UserID)
+	if err != nil {
+		return fmt.Errorf("获取战绩失败: %v", err)
+	}
+
+	// 群内查看时遵循用户的匿名设置
+	anonymous := false
+	if ctx.ChatID < 0 {
+		if anonymous, err = h.db.IsUserAnonymous(ctx.UserID); err != nil {
+			log.Printf("⚠️ 获取用户 %d 匿名设置失败: %v", ctx.UserID, err)
+		}
+	}
+	var name string
+	if ctx.From != nil {
+		name = ui.PublicName(ctx.UserID, ctx.From.UserName, ctx.From.FirstName, anonymous)
+	}
+
+	msg := tgbotapi.NewMessage(ctx.ChatID, ui.FormatUserStats(stats, name))
+	if query := ctx.Update.CallbackQuery; query != nil {
+		if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+			log.Printf("⚠️ 应答统计按钮失败: %v", err)
+		}
+	} else if ctx.Update.Message != nil {
+		msg.ReplyToMessageID = ctx.Update.Message.MessageID
+	}
+	_, err = ctx.Client.Send(msg)
+	return err
+}
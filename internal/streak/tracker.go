@@ -0,0 +1,147 @@
+package streak
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/events"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// win 一次获胜记录
+type win struct {
+	chatID int64
+	amount int64
+	at     time.Time
+}
+
+// Tracker 按用户聚合各群的结算事件，时间窗口内在多个群获胜时到用户的常驻群庆祝
+type Tracker struct {
+	db       *database.DB
+	client   telegram.Client
+	window   time.Duration // 统计获胜的时间窗口，同一用户每个窗口最多庆祝一次
+	minChats int           // 至少在这么多个不同的群获胜才庆祝
+
+	mu        sync.Mutex
+	wins      map[int64][]win     // 用户ID -> 窗口内的获胜记录
+	announced map[int64]time.Time // 用户ID -> 上次庆祝时间
+	swept     time.Time
+}
+
+// NewTracker 创建跨群连胜追踪器
+func NewTracker(db *database.DB, client telegram.Client, window time.Duration, minChats int) *Tracker {
+	return &Tracker{
+		db:        db,
+		client:    client,
+		window:    window,
+		minChats:  minChats,
+		wins:      make(map[int64][]win),
+		announced: make(map[int64]time.Time),
+		swept:     time.Now(),
+	}
+}
+
+// Subscribe 订阅对局结算事件
+func (t *Tracker) Subscribe(bus *events.Bus) {
+	bus.Subscribe(events.GameSettledEvent, t.handle)
+}
+
+// handle 记录获胜，达到跨群门槛且用户开启了连胜播报时庆祝
+func (t *Tracker) handle(event events.Event) {
+	settled, ok := event.(events.GameSettled)
+	if !ok || settled.Draw || settled.WinnerID == 0 {
+		return
+	}
+
+	chats, wins, winnings, ok := t.record(settled.WinnerID, settled.ChatID, settled.WinAmount, time.Now())
+	if !ok {
+		return
+	}
+
+	_, anonymous, celebrate, err := t.db.GetUserPreferences(settled.WinnerID)
+	if err != nil {
+		log.Printf("⚠️ 获取用户 %d 设置失败: %v", settled.WinnerID, err)
+		return
+	}
+	if !celebrate {
+		return
+	}
+
+	// 常驻群为用户首次接触机器人的群组，未知时在本次获胜的群庆祝
+	homeChatID := settled.ChatID
+	if user, err := t.db.GetUser(settled.WinnerID); err == nil && user != nil && user.FirstChatID < 0 {
+		homeChatID = user.FirstChatID
+	}
+
+	name := ui.PublicName(settled.WinnerID, settled.WinnerUsername, settled.WinnerFirstName, anonymous)
+	text := ui.FormatCrossChatStreak(name, chats, wins, winnings, t.window)
+	if _, err := t.client.Send(tgbotapi.NewMessage(homeChatID, text)); err != nil {
+		log.Printf("❌ 发送跨群连胜庆祝到群组 %d 失败: %v", homeChatID, err)
+		return
+	}
+	log.Printf("🔥 用户 %d 在 %d 个群连胜，已在群组 %d 庆祝", settled.WinnerID, chats, homeChatID)
+}
+
+// record 记录一次获胜，达到跨群门槛且本窗口内未庆祝过时返回 ok=true 及窗口内的统计
+func (t *Tracker) record(userID, chatID, amount int64, now time.Time) (chats, wins int, winnings int64, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.sweep(now)
+
+	recent := t.prune(t.wins[userID], now)
+	recent = append(recent, win{chatID: chatID, amount: amount, at: now})
+	t.wins[userID] = recent
+
+	seen := make(map[int64]bool)
+	for _, w := range recent {
+		seen[w.chatID] = true
+		winnings += w.amount
+	}
+	if len(seen) < t.minChats {
+		return 0, 0, 0, false
+	}
+	if last, exists := t.announced[userID]; exists && now.Sub(last) < t.window {
+		return 0, 0, 0, false
+	}
+
+	t.announced[userID] = now
+	return len(seen), len(recent), winnings, true
+}
+
+// prune 丢弃窗口外的获胜记录
+func (t *Tracker) prune(wins []win, now time.Time) []win {
+	recent := wins[:0]
+	for _, w := range wins {
+		if now.Sub(w.at) < t.window {
+			recent = append(recent, w)
+		}
+	}
+	return recent
+}
+
+// sweep 每个窗口清理一次不再活跃的用户，避免内存持续增长
+func (t *Tracker) sweep(now time.Time) {
+	if now.Sub(t.swept) < t.window {
+		return
+	}
+	t.swept = now
+
+	for userID, wins := range t.wins {
+		if recent := t.prune(wins, now); len(recent) == 0 {
+			delete(t.wins, userID)
+		} else {
+			t.wins[userID] = recent
+		}
+	}
+	for userID, last := range t.announced {
+		if now.Sub(last) >= t.window {
+			delete(t.announced, userID)
+		}
+	}
+}
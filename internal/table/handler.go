@@ -0,0 +1,227 @@
+package table
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /table、/dicemulti 命令和快速桌按钮的处理器
+type Handler struct {
+	manager     *game.Manager
+	codec       *callback.Codec
+	client      telegram.Client
+	defaultAnte int64
+	// 开设和加入快速桌前的下注资格检查，未设置时不限制
+	eligibility middleware.EligibilityChecker
+
+	mu       sync.Mutex
+	messages map[string]int // 快速桌ID -> 公告消息ID，超时关闭时更新公告
+}
+
+// NewHandler 创建快速桌处理器，并接管快速桌超时关闭的公告
+func NewHandler(manager *game.Manager, codec *callback.Codec, client telegram.Client, defaultAnte int64) *Handler {
+	h := &Handler{
+		manager:     manager,
+		codec:       codec,
+		client:      client,
+		defaultAnte: defaultAnte,
+		messages:    make(map[string]int),
+	}
+	manager.SetTableExpiredCallback(h.expired)
+	return h
+}
+
+// Register 注册 /table、/dicemulti 命令和快速桌按钮回调，多人大战与快速桌共用按钮
+func (h *Handler) Register(router *middleware.Router) {
+	eligible := middleware.Eligible(h.eligible)
+	router.Handle(ui.TableCommand, h.Open, eligible)
+	router.Handle(ui.BattleCommand, h.OpenBattle, eligible)
+	router.HandleCallback(callback.Prefix(ui.TableJoinAction), h.Button, eligible)
+	router.HandleCallback(callback.Prefix(ui.TableStartAction), h.Button)
+	router.HandleCallback(callback.Prefix(ui.TableCancelAction), h.Button)
+}
+
+// SetEligibility 设置下注资格检查（如 eligibility.Checker.Check），未设置时不限制
+func (h *Handler) SetEligibility(check middleware.EligibilityChecker) {
+	h.eligibility = check
+}
+
+// eligible 按已设置的下注资格检查用户
+func (h *Handler) eligible(chatID int64, from *tgbotapi.User) (string, error) {
+	if h.eligibility == nil {
+		return "", nil
+	}
+	return h.eligibility(chatID, from)
+}
+
+// Open 在群内开设快速桌并发送招募公告，不带参数时使用默认底注
+func (h *Handler) Open(ctx *middleware.Context) error {
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("❌ 快速桌只能在群组中开设")
+	}
+
+	ante := h.defaultAnte
+	if args := strings.TrimSpace(ctx.Args); args != "" {
+		value, err := utils.ParseAmount(args)
+		if err != nil {
+			return ctx.Reply("❌ 用法：/table <底注>")
+		}
+		ante = value
+	}
+
+	table, err := h.manager.CreateTable(ctx.UserID, ctx.ChatID, ante)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	return h.announce(ctx, table)
+}
+
+// OpenBattle 在群内开设多人大战并发送招募公告
+func (h *Handler) OpenBattle(ctx *middleware.Context) error {
+	usage := ui.FormatBattleUsage(game.MinTablePlayers, game.MaxBattlePlayers)
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("❌ 多人大战只能在群组中开设")
+	}
+
+	fields := strings.Fields(ctx.Args)
+	if len(fields) != 2 {
+		return ctx.Reply(usage)
+	}
+	ante, err := utils.ParseAmount(fields[0])
+	if err != nil {
+		return ctx.Reply("❌ 底注必须是正数，最多两位小数\n" + usage)
+	}
+	maxPlayers, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ctx.Reply("❌ 人数上限必须是整数\n" + usage)
+	}
+
+	table, err := h.manager.CreateBattle(ctx.UserID, ctx.ChatID, ante, maxPlayers)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	return h.announce(ctx, table)
+}
+
+// announce 发送招募公告并记录公告消息，超时关闭时更新
+func (h *Handler) announce(ctx *middleware.Context, table *models.Table) error {
+	players, err := h.manager.TablePlayers(table.ID)
+	if err != nil {
+		return err
+	}
+
+	msg, err := ui.BuildTableMessage(h.codec, ctx.ChatID, table, players, game.MinTablePlayers, game.TableSeats(table))
+	if err != nil {
+		return err
+	}
+	sent, err := ctx.Client.Send(msg)
+	if err != nil {
+		return err
+	}
+	h.mu.Lock()
+	h.messages[table.ID] = sent.MessageID
+	h.mu.Unlock()
+	return nil
+}
+
+// Button 处理入座、开骰和关闭按钮，并原地更新快速桌公告
+func (h *Handler) Button(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	action, tableID, err := ui.ParseTableCallback(h.codec, query.Data)
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error())
+	}
+	messageID := query.Message.MessageID
+
+	switch action {
+	case ui.TableJoinAction:
+		table, players, err := h.manager.JoinTable(tableID, ctx.UserID)
+		if err != nil {
+			return ctx.Reply("❌ " + err.Error())
+		}
+		if len(players) >= game.TableSeats(table) {
+			// 坐满自动开骰
+			return h.start(ctx, tableID, messageID)
+		}
+		answer(ctx, "🪑 已入座")
+		edit, err := ui.BuildTableEdit(h.codec, ctx.ChatID, messageID, table, players, game.MinTablePlayers, game.TableSeats(table))
+		if err != nil {
+			return err
+		}
+		return h.edit(edit)
+	case ui.TableStartAction:
+		return h.start(ctx, tableID, messageID)
+	default:
+		table, players, err := h.manager.CancelTable(tableID, ctx.UserID)
+		if err != nil {
+			return ctx.Reply("❌ " + err.Error())
+		}
+		h.forget(tableID)
+		answer(ctx, "↩️ 已关闭")
+		return h.edit(tgbotapi.NewEditMessageText(ctx.ChatID, messageID, ui.FormatTableClosed(table, players, false)))
+	}
+}
+
+// start 开骰并将公告更新为结果
+func (h *Handler) start(ctx *middleware.Context, tableID string, messageID int) error {
+	result, err := h.manager.StartTable(tableID, ctx.UserID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	h.forget(tableID)
+	answer(ctx, "🎲 开骰！")
+	text := ui.FormatTableResult(result.Table, result.Players, result.Pot, result.Commission)
+	return h.edit(tgbotapi.NewEditMessageText(ctx.ChatID, messageID, text))
+}
+
+// expired 快速桌超时关闭后更新公告
+func (h *Handler) expired(table *models.Table, players []*models.TablePlayer) {
+	h.mu.Lock()
+	messageID, ok := h.messages[table.ID]
+	delete(h.messages, table.ID)
+	h.mu.Unlock()
+
+	text := ui.FormatTableClosed(table, players, true)
+	if !ok {
+		if _, err := h.client.Send(tgbotapi.NewMessage(table.ChatID, text)); err != nil {
+			log.Printf("⚠️ 发送快速桌关闭公告失败: %v", err)
+		}
+		return
+	}
+	if err := h.edit(tgbotapi.NewEditMessageText(table.ChatID, messageID, text)); err != nil {
+		log.Printf("⚠️ 更新快速桌公告失败: %v", err)
+	}
+}
+
+// edit 原地更新公告，内容未变化时忽略
+func (h *Handler) edit(edit tgbotapi.EditMessageTextConfig) error {
+	if _, err := h.client.Request(edit); err != nil && !strings.Contains(err.Error(), "message is not modified") {
+		return err
+	}
+	return nil
+}
+
+// answer 以轻提示应答按钮
+func answer(ctx *middleware.Context, text string) {
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(ctx.Update.CallbackQuery.ID, text)); err != nil {
+		log.Printf("⚠️ 应答快速桌按钮失败: %v", err)
+	}
+}
+
+func (h *Handler) forget(tableID string) {
+	h.mu.Lock()
+	delete(h.messages, tableID)
+	h.mu.Unlock()
+}
@@ -0,0 +1,133 @@
+package telegram
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Client Telegram API 抽象，便于单元测试替换以及后续切换 MTProto 客户端
+type Client interface {
+	// Self 返回机器人自身信息
+	Self() tgbotapi.User
+	// Send 发送消息类请求并返回消息
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	// Request 发送任意请求（回调应答、删除消息等）
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	// GetChatMember 获取群成员信息
+	GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error)
+	// GetUpdatesChan 以长轮询方式获取更新
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+	// StopReceivingUpdates 停止长轮询
+	StopReceivingUpdates()
+	// SetWebhook 设置 Webhook 地址，为空时删除 Webhook
+	SetWebhook(link string) error
+}
+
+var _ Client = (*APIClient)(nil)
+
+// DefaultBaseURL 官方 Bot API 地址
+const DefaultBaseURL = "https://api.telegram.org"
+
+// APIClient 基于 go-telegram-bot-api 的 Client 实现
+type APIClient struct {
+	api     *tgbotapi.BotAPI
+	baseURL string
+}
+
+// NewAPIClient 包装已有的 BotAPI
+func NewAPIClient(api *tgbotapi.BotAPI) *APIClient {
+	return &APIClient{api: api, baseURL: DefaultBaseURL}
+}
+
+// NewAPIClientWithToken 通过 Token 创建连接官方 Bot API 的客户端
+func NewAPIClientWithToken(token string) (*APIClient, error) {
+	return NewAPIClientWithEndpoint(token, DefaultBaseURL, &http.Client{})
+}
+
+// NewAPIClientWithEndpoint 通过 Token 创建客户端，baseURL 可指向自建的 Bot API 服务器
+func NewAPIClientWithEndpoint(token, baseURL string, httpClient *http.Client) (*APIClient, error) {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+
+	api, err := tgbotapi.NewBotAPIWithClient(token, baseURL+"/bot%s/%s", httpClient)
+	if err != nil {
+		return nil, fmt.Errorf("连接Bot API %s 失败: %v", baseURL, err)
+	}
+	return &APIClient{api: api, baseURL: baseURL}, nil
+}
+
+// BaseURL 返回当前使用的 Bot API 地址
+func (c *APIClient) BaseURL() string {
+	return c.baseURL
+}
+
+// IsLocalServer 是否使用自建的 Bot API 服务器
+func (c *APIClient) IsLocalServer() bool {
+	return c.baseURL != DefaultBaseURL
+}
+
+// HealthCheck 通过 getMe 检查配置的 Bot API 地址是否可用
+func (c *APIClient) HealthCheck() error {
+	if _, err := c.api.GetMe(); err != nil {
+		return fmt.Errorf("Bot API %s 健康检查失败: %v", c.baseURL, err)
+	}
+	return nil
+}
+
+// API 返回底层 BotAPI，供尚未迁移的代码使用
+func (c *APIClient) API() *tgbotapi.BotAPI {
+	return c.api
+}
+
+// Self 返回机器人自身信息
+func (c *APIClient) Self() tgbotapi.User {
+	return c.api.Self
+}
+
+// Send 发送消息
+func (c *APIClient) Send(chattable tgbotapi.Chattable) (tgbotapi.Message, error) {
+	return c.api.Send(chattable)
+}
+
+// Request 发送请求
+func (c *APIClient) Request(chattable tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	return c.api.Request(chattable)
+}
+
+// GetChatMember 获取群成员信息
+func (c *APIClient) GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error) {
+	return c.api.GetChatMember(config)
+}
+
+// GetUpdatesChan 获取更新通道
+func (c *APIClient) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return c.api.GetUpdatesChan(config)
+}
+
+// StopReceivingUpdates 停止长轮询
+func (c *APIClient) StopReceivingUpdates() {
+	c.api.StopReceivingUpdates()
+}
+
+// SetWebhook 设置或删除 Webhook
+func (c *APIClient) SetWebhook(link string) error {
+	if link == "" {
+		_, err := c.api.Request(tgbotapi.DeleteWebhookConfig{})
+		return err
+	}
+
+	wh, err := tgbotapi.NewWebhook(link)
+	if err != nil {
+		return fmt.Errorf("无效的Webhook地址: %v", err)
+	}
+	_, err = c.api.Request(wh)
+	return err
+}
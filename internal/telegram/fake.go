@@ -0,0 +1,126 @@
+package telegram
+
+import (
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var _ Client = (*FakeClient)(nil)
+
+// FakeClient 用于测试的内存客户端，记录所有发出的请求
+type FakeClient struct {
+	mu sync.Mutex
+
+	User    tgbotapi.User
+	Sent    []tgbotapi.Chattable
+	Members map[int64]tgbotapi.ChatMember // key: userID
+	Webhook string
+
+	// SendErr 不为空时所有 Send/Request 均返回该错误
+	SendErr error
+
+	updates chan tgbotapi.Update
+	nextID  int
+}
+
+// NewFakeClient 创建测试客户端
+func NewFakeClient() *FakeClient {
+	return &FakeClient{
+		User:    tgbotapi.User{ID: 1, IsBot: true, UserName: "test_dice_bot"},
+		Members: make(map[int64]tgbotapi.ChatMember),
+		updates: make(chan tgbotapi.Update, 100),
+	}
+}
+
+// Self 返回机器人自身信息
+func (f *FakeClient) Self() tgbotapi.User {
+	return f.User
+}
+
+// Send 记录消息并返回带递增ID的消息
+func (f *FakeClient) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.SendErr != nil {
+		return tgbotapi.Message{}, f.SendErr
+	}
+	f.Sent = append(f.Sent, c)
+	f.nextID++
+	return tgbotapi.Message{MessageID: f.nextID}, nil
+}
+
+// Request 记录请求
+func (f *FakeClient) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.SendErr != nil {
+		return nil, f.SendErr
+	}
+	f.Sent = append(f.Sent, c)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+// GetChatMember 返回预置的成员信息，未预置时视为普通成员
+func (f *FakeClient) GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if member, ok := f.Members[config.UserID]; ok {
+		return member, nil
+	}
+	return tgbotapi.ChatMember{
+		User:   &tgbotapi.User{ID: config.UserID},
+		Status: "member",
+	}, nil
+}
+
+// GetUpdatesChan 返回由 PushUpdate 写入的更新通道
+func (f *FakeClient) GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	return f.updates
+}
+
+// StopReceivingUpdates 关闭更新通道
+func (f *FakeClient) StopReceivingUpdates() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.updates != nil {
+		close(f.updates)
+		f.updates = nil
+	}
+}
+
+// SetWebhook 记录 Webhook 地址
+func (f *FakeClient) SetWebhook(link string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Webhook = link
+	return nil
+}
+
+// PushUpdate 模拟收到一条更新
+func (f *FakeClient) PushUpdate(update tgbotapi.Update) {
+	f.updates <- update
+}
+
+// SentMessages 返回已发送的消息副本
+func (f *FakeClient) SentMessages() []tgbotapi.Chattable {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sent := make([]tgbotapi.Chattable, len(f.Sent))
+	copy(sent, f.Sent)
+	return sent
+}
+
+// Reset 清空发送记录
+func (f *FakeClient) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Sent = nil
+}
@@ -0,0 +1,242 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// FakeServerToken 假 Bot API 服务器接受的 Token
+const FakeServerToken = "123456:FAKE-TOKEN"
+
+// APICall 假服务器收到的一次 Bot API 调用
+type APICall struct {
+	Method string
+	Params map[string]string
+}
+
+// Int64 读取整数参数，不存在或格式错误时返回 0
+func (c APICall) Int64(key string) int64 {
+	v, _ := strconv.ParseInt(c.Params[key], 10, 64)
+	return v
+}
+
+// FakeServer 基于 httptest 的假 Bot API 服务器，用于端到端集成测试
+type FakeServer struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	calls   []APICall
+	dice    []int
+	updates []tgbotapi.Update
+	nextMsg int
+	nextUpd int
+	Bot     tgbotapi.User
+
+	polling   int // 进行中的 getUpdates 请求数
+	conflicts int // 因并发 getUpdates 被拒绝的请求数
+}
+
+// NewFakeServer 启动假 Bot API 服务器
+func NewFakeServer() *FakeServer {
+	f := &FakeServer{
+		Bot: tgbotapi.User{ID: 1, IsBot: true, FirstName: "Dice", UserName: "test_dice_bot"},
+	}
+	f.server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+// URL 返回服务器地址，可作为 BOT_API_URL 使用
+func (f *FakeServer) URL() string {
+	return f.server.URL
+}
+
+// Close 关闭服务器
+func (f *FakeServer) Close() {
+	f.server.Close()
+}
+
+// NewClient 创建连接到假服务器的 APIClient
+func (f *FakeServer) NewClient() (*APIClient, error) {
+	return NewAPIClientWithEndpoint(FakeServerToken, f.URL(), f.server.Client())
+}
+
+// QueueDice 预设后续 sendDice 返回的点数，未预设时返回 1
+func (f *FakeServer) QueueDice(values ...int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.dice = append(f.dice, values...)
+}
+
+// PushUpdate 加入一条待 getUpdates 拉取的更新，UpdateID 为 0 时自动分配
+func (f *FakeServer) PushUpdate(update tgbotapi.Update) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if update.UpdateID == 0 {
+		f.nextUpd++
+		update.UpdateID = f.nextUpd
+	} else if update.UpdateID > f.nextUpd {
+		f.nextUpd = update.UpdateID
+	}
+	f.updates = append(f.updates, update)
+}
+
+// Calls 返回收到的全部调用（不含 getMe 和 getUpdates）
+func (f *FakeServer) Calls() []APICall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]APICall(nil), f.calls...)
+}
+
+// CallsTo 返回指定方法的调用
+func (f *FakeServer) CallsTo(method string) []APICall {
+	var calls []APICall
+	for _, call := range f.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Conflicts 返回因并发 getUpdates 被拒绝的请求数，多个进程同时轮询同一 Token 时大于 0
+func (f *FakeServer) Conflicts() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.conflicts
+}
+
+// Reset 清空调用记录
+func (f *FakeServer) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = nil
+}
+
+// handle 处理 /bot<token>/<method> 请求
+func (f *FakeServer) handle(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/bot")
+	token, method, ok := strings.Cut(path, "/")
+	if !ok || token != FakeServerToken {
+		writeAPIResponse(w, false, nil, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		r.ParseMultipartForm(32 << 20)
+	} else {
+		r.ParseForm()
+	}
+	params := make(map[string]string, len(r.Form))
+	for key := range r.Form {
+		params[key] = r.Form.Get(key)
+	}
+
+	switch method {
+	case "getMe":
+		writeAPIResponse(w, true, f.Bot, "", http.StatusOK)
+	case "getUpdates":
+		// 与 Telegram 一致：同一 Token 同时只允许一个 getUpdates 请求
+		if !f.beginPolling() {
+			writeAPIResponse(w, false, nil, "Conflict: terminated by other getUpdates request; make sure that only one bot instance is running", http.StatusConflict)
+			return
+		}
+		defer f.endPolling()
+		writeAPIResponse(w, true, f.pendingUpdates(params), "", http.StatusOK)
+	case "sendMessage", "sendDice", "sendPhoto", "sendAnimation":
+		writeAPIResponse(w, true, f.record(method, params), "", http.StatusOK)
+	default:
+		f.record(method, params)
+		writeAPIResponse(w, true, true, "", http.StatusOK)
+	}
+}
+
+// record 记录调用，并为发送类方法生成返回的消息
+func (f *FakeServer) record(method string, params map[string]string) tgbotapi.Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, APICall{Method: method, Params: params})
+	f.nextMsg++
+
+	chatID, _ := strconv.ParseInt(params["chat_id"], 10, 64)
+	msg := tgbotapi.Message{
+		MessageID: f.nextMsg,
+		Date:      int(time.Now().Unix()),
+		Chat:      &tgbotapi.Chat{ID: chatID, Type: "supergroup"},
+		From:      &f.Bot,
+		Text:      params["text"],
+	}
+
+	if method == "sendDice" {
+		value := 1
+		if len(f.dice) > 0 {
+			value, f.dice = f.dice[0], f.dice[1:]
+		}
+		emoji := params["emoji"]
+		if emoji == "" {
+			emoji = "🎲"
+		}
+		msg.Dice = &tgbotapi.Dice{Emoji: emoji, Value: value}
+	}
+	return msg
+}
+
+// beginPolling 登记一个 getUpdates 请求，已有请求进行中时记为冲突并返回 false
+func (f *FakeServer) beginPolling() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.polling > 0 {
+		f.conflicts++
+		return false
+	}
+	f.polling++
+	return true
+}
+
+func (f *FakeServer) endPolling() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polling--
+}
+
+// pendingUpdates 返回 offset 之后的更新，没有时短暂等待以免客户端空转
+func (f *FakeServer) pendingUpdates(params map[string]string) []tgbotapi.Update {
+	offset, _ := strconv.Atoi(params["offset"])
+
+	deadline := time.Now().Add(100 * time.Millisecond)
+	for {
+		f.mu.Lock()
+		var updates []tgbotapi.Update
+		for _, update := range f.updates {
+			if update.UpdateID >= offset {
+				updates = append(updates, update)
+			}
+		}
+		f.mu.Unlock()
+
+		if len(updates) > 0 || time.Now().After(deadline) {
+			return updates
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func writeAPIResponse(w http.ResponseWriter, ok bool, result interface{}, description string, status int) {
+	body := map[string]interface{}{"ok": ok}
+	if ok {
+		body["result"] = result
+	} else {
+		body["error_code"] = status
+		body["description"] = description
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,218 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram 官方建议的发送频率上限
+const (
+	DefaultGlobalRate   = 30 // 每秒全局消息数
+	DefaultChatRate     = 20 // 每分钟单个群组消息数
+	chatBucketIdleReset = 10 * time.Minute
+)
+
+// bucket 按时间惰性补充的令牌桶
+type bucket struct {
+	tokens   float64
+	capacity float64
+	perSec   float64
+	updated  time.Time
+}
+
+func newBucket(rate int, window time.Duration, now time.Time) *bucket {
+	return &bucket{
+		tokens:   float64(rate),
+		capacity: float64(rate),
+		perSec:   float64(rate) / window.Seconds(),
+		updated:  now,
+	}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.perSec
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.updated = now
+	}
+}
+
+// wait 距离下一个令牌可用还需等待的时间
+func (b *bucket) wait() time.Duration {
+	if b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.perSec * float64(time.Second))
+}
+
+// Limiter 全局 + 单群组两级限流器，并对外暴露剩余额度
+type Limiter struct {
+	mu         sync.Mutex
+	global     *bucket
+	chats      map[int64]*bucket
+	chatRate   int
+	chatWindow time.Duration
+	now        func() time.Time
+}
+
+// NewLimiter 创建两级限流器
+func NewLimiter(globalRate int, globalWindow time.Duration, chatRate int, chatWindow time.Duration) *Limiter {
+	now := time.Now()
+	return &Limiter{
+		global:     newBucket(globalRate, globalWindow, now),
+		chats:      make(map[int64]*bucket),
+		chatRate:   chatRate,
+		chatWindow: chatWindow,
+		now:        time.Now,
+	}
+}
+
+// NewDefaultLimiter 按 Telegram 官方频率上限创建限流器
+func NewDefaultLimiter() *Limiter {
+	return NewLimiter(DefaultGlobalRate, time.Second, DefaultChatRate, time.Minute)
+}
+
+// chatBucket 获取群组令牌桶，调用方需持有锁
+func (l *Limiter) chatBucket(chatID int64, now time.Time) *bucket {
+	b, ok := l.chats[chatID]
+	if !ok {
+		b = newBucket(l.chatRate, l.chatWindow, now)
+		l.chats[chatID] = b
+	}
+	b.refill(now)
+	return b
+}
+
+// reserve 尝试同时占用全局和群组令牌，失败时返回需要等待的时间
+func (l *Limiter) reserve(chatID int64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.global.refill(now)
+	wait := l.global.wait()
+
+	var chat *bucket
+	if chatID != 0 {
+		chat = l.chatBucket(chatID, now)
+		if w := chat.wait(); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	l.global.tokens--
+	if chat != nil {
+		chat.tokens--
+	}
+	return 0
+}
+
+// Allow 额度充足时占用一次发送额度，chatID 为 0 时只检查全局额度
+func (l *Limiter) Allow(chatID int64) bool {
+	return l.reserve(chatID) == 0
+}
+
+// Wait 阻塞直到可以向群组发送消息
+func (l *Limiter) Wait(ctx context.Context, chatID int64) error {
+	for {
+		wait := l.reserve(chatID)
+		if wait == 0 {
+			return nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// Budget 返回群组剩余发送额度占比（0-1），取全局和群组两级中较小的一个
+func (l *Limiter) Budget(chatID int64) float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.global.refill(now)
+	budget := l.global.tokens / l.global.capacity
+
+	if chatID != 0 {
+		chat := l.chatBucket(chatID, now)
+		if b := chat.tokens / chat.capacity; b < budget {
+			budget = b
+		}
+	}
+	if budget < 0 {
+		return 0
+	}
+	return budget
+}
+
+// Cleanup 清理长时间未使用且已回满的群组令牌桶
+func (l *Limiter) Cleanup() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for chatID, b := range l.chats {
+		if now.Sub(b.updated) > chatBucketIdleReset {
+			delete(l.chats, chatID)
+		}
+	}
+}
+
+var _ Client = (*LimitedClient)(nil)
+
+// LimitedClient 发送前经过限流器的 Client 包装
+type LimitedClient struct {
+	Client
+	limiter *Limiter
+}
+
+// NewLimitedClient 为 client 套上两级限流
+func NewLimitedClient(client Client, limiter *Limiter) *LimitedClient {
+	return &LimitedClient{Client: client, limiter: limiter}
+}
+
+// Limiter 返回使用的限流器
+func (c *LimitedClient) Limiter() *Limiter {
+	return c.limiter
+}
+
+// Send 等待发送额度后发送消息
+func (c *LimitedClient) Send(msg tgbotapi.Chattable) (tgbotapi.Message, error) {
+	if err := c.limiter.Wait(context.Background(), chatIDOf(msg)); err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return c.Client.Send(msg)
+}
+
+// chatIDOf 取出会在群组中产生消息的请求的目标群组，其他请求返回 0
+func chatIDOf(msg tgbotapi.Chattable) int64 {
+	switch m := msg.(type) {
+	case tgbotapi.MessageConfig:
+		return m.ChatID
+	case tgbotapi.DiceConfig:
+		return m.ChatID
+	case tgbotapi.PhotoConfig:
+		return m.ChatID
+	case tgbotapi.AnimationConfig:
+		return m.ChatID
+	case tgbotapi.StickerConfig:
+		return m.ChatID
+	case tgbotapi.EditMessageTextConfig:
+		return m.ChatID
+	}
+	return 0
+}
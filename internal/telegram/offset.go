@@ -0,0 +1,141 @@
+package telegram
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// OffsetSettingKey 最后处理的 update_id 在 bot_settings 中的键
+const OffsetSettingKey = "last_update_id"
+
+// DefaultDedupWindow 默认的 update_id 去重窗口
+const DefaultDedupWindow = 10 * time.Minute
+
+// SettingStore 持久化键值配置（由 database.DB 实现）
+type SettingStore interface {
+	GetSetting(key string) (string, bool, error)
+	SetSetting(key, value string) error
+}
+
+// UpdateTracker 记录已处理的更新，重启后从上次的 offset 继续拉取，避免重复处理。
+// 更新并发处理时只持久化其下全部更新都已处理完的 update_id，处理中的更新重启后会重新拉取
+type UpdateTracker struct {
+	store  SettingStore
+	window time.Duration
+
+	mu       sync.Mutex
+	lastID   int              // 已持久化的 update_id，不大于它的更新都已处理完
+	doneID   int              // 已处理完的最大 update_id
+	inFlight map[int]struct{} // 已接收、尚未处理完的更新
+	seen     map[int]time.Time
+}
+
+// NewUpdateTracker 创建更新跟踪器并加载上次持久化的 update_id
+func NewUpdateTracker(store SettingStore, window time.Duration) (*UpdateTracker, error) {
+	if window <= 0 {
+		window = DefaultDedupWindow
+	}
+
+	tracker := &UpdateTracker{
+		store:    store,
+		window:   window,
+		inFlight: make(map[int]struct{}),
+		seen:     make(map[int]time.Time),
+	}
+
+	value, ok, err := store.GetSetting(OffsetSettingKey)
+	if err != nil {
+		return nil, fmt.Errorf("读取更新偏移量失败: %v", err)
+	}
+	if ok {
+		lastID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("更新偏移量格式错误: %v", err)
+		}
+		tracker.lastID = lastID
+		tracker.doneID = lastID
+	}
+
+	return tracker, nil
+}
+
+// Offset 返回长轮询应使用的起始 offset
+func (t *UpdateTracker) Offset() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.lastID == 0 {
+		return 0
+	}
+	return t.lastID + 1
+}
+
+// UpdateConfig 构建带持久化 offset 的长轮询配置
+func (t *UpdateTracker) UpdateConfig(timeout int) tgbotapi.UpdateConfig {
+	config := tgbotapi.NewUpdate(t.Offset())
+	config.Timeout = timeout
+	return config
+}
+
+// Accept 判断更新是否需要处理，重复或已处理过的更新返回 false。
+// 返回 true 的更新无论处理成功与否都需调用 MarkProcessed，否则偏移量不再前进
+func (t *UpdateTracker) Accept(update tgbotapi.Update) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	t.pruneLocked(now)
+
+	if _, ok := t.seen[update.UpdateID]; ok {
+		return false
+	}
+	if update.UpdateID <= t.lastID {
+		// 已处理过的旧更新（如重启前最后一批被重新投递）
+		return false
+	}
+
+	t.seen[update.UpdateID] = now
+	t.inFlight[update.UpdateID] = struct{}{}
+	return true
+}
+
+// MarkProcessed 更新处理完成，持久化其下全部更新都已处理完的 update_id。
+// 在锁内写入，保证持久化的值只增不减
+func (t *UpdateTracker) MarkProcessed(updateID int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.inFlight, updateID)
+	if updateID > t.doneID {
+		t.doneID = updateID
+	}
+
+	watermark := t.doneID
+	for id := range t.inFlight {
+		if id-1 < watermark {
+			watermark = id - 1
+		}
+	}
+	if watermark <= t.lastID {
+		return nil
+	}
+
+	if err := t.store.SetSetting(OffsetSettingKey, strconv.Itoa(watermark)); err != nil {
+		return fmt.Errorf("保存更新偏移量失败: %v", err)
+	}
+	t.lastID = watermark
+	return nil
+}
+
+// pruneLocked 清理超出去重窗口的记录
+func (t *UpdateTracker) pruneLocked(now time.Time) {
+	for id, seenAt := range t.seen {
+		if now.Sub(seenAt) > t.window {
+			delete(t.seen, id)
+		}
+	}
+}
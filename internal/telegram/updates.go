@@ -0,0 +1,167 @@
+package telegram
+
+import (
+	"encoding/json"
+	"fmt"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// UpdateKind 更新类型
+type UpdateKind string
+
+const (
+	UpdateMessage           UpdateKind = "message"
+	UpdateEditedMessage     UpdateKind = "edited_message"
+	UpdateChannelPost       UpdateKind = "channel_post"
+	UpdateEditedChannelPost UpdateKind = "edited_channel_post"
+	UpdateInlineQuery       UpdateKind = "inline_query"
+	UpdateChosenInline      UpdateKind = "chosen_inline_result"
+	UpdateCallbackQuery     UpdateKind = "callback_query"
+	UpdateShippingQuery     UpdateKind = "shipping_query"
+	UpdatePreCheckoutQuery  UpdateKind = "pre_checkout_query"
+	UpdatePoll              UpdateKind = "poll"
+	UpdatePollAnswer        UpdateKind = "poll_answer"
+	UpdateMyChatMember      UpdateKind = "my_chat_member"
+	UpdateChatMember        UpdateKind = "chat_member"
+	UpdateUnknown           UpdateKind = "unknown"
+)
+
+// ClassifyUpdate 判断更新类型
+func ClassifyUpdate(update *tgbotapi.Update) UpdateKind {
+	if update == nil {
+		return UpdateUnknown
+	}
+
+	switch {
+	case update.Message != nil:
+		return UpdateMessage
+	case update.EditedMessage != nil:
+		return UpdateEditedMessage
+	case update.ChannelPost != nil:
+		return UpdateChannelPost
+	case update.EditedChannelPost != nil:
+		return UpdateEditedChannelPost
+	case update.InlineQuery != nil:
+		return UpdateInlineQuery
+	case update.ChosenInlineResult != nil:
+		return UpdateChosenInline
+	case update.CallbackQuery != nil:
+		return UpdateCallbackQuery
+	case update.ShippingQuery != nil:
+		return UpdateShippingQuery
+	case update.PreCheckoutQuery != nil:
+		return UpdatePreCheckoutQuery
+	case update.Poll != nil:
+		return UpdatePoll
+	case update.PollAnswer != nil:
+		return UpdatePollAnswer
+	case update.MyChatMember != nil:
+		return UpdateMyChatMember
+	case update.ChatMember != nil:
+		return UpdateChatMember
+	default:
+		return UpdateUnknown
+	}
+}
+
+// IsSupported 判断更新是否可交给业务处理器
+// 消息和回调需要携带聊天与发送者信息，否则视为不支持
+func IsSupported(update *tgbotapi.Update) bool {
+	switch ClassifyUpdate(update) {
+	case UpdateMessage:
+		msg := update.Message
+		return msg.Chat != nil && msg.From != nil && !msg.Chat.IsChannel()
+	case UpdateCallbackQuery:
+		query := update.CallbackQuery
+		return query.From != nil && query.Message != nil && query.Message.Chat != nil
+	case UpdateMyChatMember:
+		return true
+	default:
+		return false
+	}
+}
+
+// UnsupportedResponse 为不支持的更新生成明确的回应，无需回应时返回 nil
+func UnsupportedResponse(update *tgbotapi.Update) tgbotapi.Chattable {
+	switch ClassifyUpdate(update) {
+	case UpdateChannelPost:
+		return tgbotapi.NewMessage(update.ChannelPost.Chat.ID, "⚠️ 本机器人不支持在频道中使用，请将我添加到群组")
+	case UpdateInlineQuery:
+		return tgbotapi.InlineConfig{
+			InlineQueryID:     update.InlineQuery.ID,
+			Results:           []interface{}{},
+			IsPersonal:        true,
+			SwitchPMText:      "请在私聊或群组中使用机器人",
+			SwitchPMParameter: "inline",
+		}
+	case UpdateCallbackQuery:
+		return tgbotapi.NewCallbackWithAlert(update.CallbackQuery.ID, "⚠️ 该按钮仅支持在聊天中使用")
+	case UpdateShippingQuery:
+		return tgbotapi.ShippingConfig{
+			ShippingQueryID: update.ShippingQuery.ID,
+			OK:              false,
+			ErrorMessage:    "暂不支持该支付方式",
+		}
+	case UpdatePreCheckoutQuery:
+		return tgbotapi.PreCheckoutConfig{
+			PreCheckoutQueryID: update.PreCheckoutQuery.ID,
+			OK:                 false,
+			ErrorMessage:       "暂不支持该支付方式",
+		}
+	case UpdateMessage:
+		// 频道以匿名身份在讨论组发言等情况，From 可能为空
+		if msg := update.Message; msg.Chat != nil && msg.Chat.IsChannel() {
+			return tgbotapi.NewMessage(msg.Chat.ID, "⚠️ 本机器人不支持在频道中使用，请将我添加到群组")
+		}
+		return nil
+	default:
+		// 编辑消息、投票等直接忽略
+		return nil
+	}
+}
+
+// UpdateChatID 更新所属的聊天，不属于任何聊天的更新（内联查询、投票等）返回 0
+func UpdateChatID(update *tgbotapi.Update) int64 {
+	switch ClassifyUpdate(update) {
+	case UpdateMessage:
+		if update.Message.Chat != nil {
+			return update.Message.Chat.ID
+		}
+	case UpdateEditedMessage:
+		if update.EditedMessage.Chat != nil {
+			return update.EditedMessage.Chat.ID
+		}
+	case UpdateChannelPost:
+		if update.ChannelPost.Chat != nil {
+			return update.ChannelPost.Chat.ID
+		}
+	case UpdateEditedChannelPost:
+		if update.EditedChannelPost.Chat != nil {
+			return update.EditedChannelPost.Chat.ID
+		}
+	case UpdateCallbackQuery:
+		if msg := update.CallbackQuery.Message; msg != nil && msg.Chat != nil {
+			return msg.Chat.ID
+		}
+	case UpdateMyChatMember:
+		return update.MyChatMember.Chat.ID
+	case UpdateChatMember:
+		return update.ChatMember.Chat.ID
+	}
+	return 0
+}
+
+// GetUpdates 拉取一次更新（单次 getUpdates 请求），由调用方自行推进 offset
+func GetUpdates(client Client, config tgbotapi.UpdateConfig) ([]tgbotapi.Update, error) {
+	resp, err := client.Request(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var updates []tgbotapi.Update
+	if err := json.Unmarshal(resp.Result, &updates); err != nil {
+		return nil, fmt.Errorf("解析更新失败: %v", err)
+	}
+	return updates, nil
+}
@@ -0,0 +1,83 @@
+package timeline
+
+import (
+	"strings"
+
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+)
+
+// maxMessageLength Telegram 单条消息的长度上限（按字节保守计算）
+const maxMessageLength = 4000
+
+// Handler /timeline 管理员命令的处理器，用于处理玩家争议时还原对局全过程
+type Handler struct {
+	manager  *game.Manager
+	adminIDs []int64
+}
+
+// NewHandler 创建对局时间线处理器
+func NewHandler(manager *game.Manager, adminIDs []int64) *Handler {
+	return &Handler{manager: manager, adminIDs: adminIDs}
+}
+
+// Register 注册 /timeline 命令，仅限管理员
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.TimelineCommand, h.Timeline, middleware.AdminOnly(h.adminIDs))
+}
+
+// Timeline 按对局ID查看时间线，记录较多时分多条消息发送
+func (h *Handler) Timeline(ctx *middleware.Context) error {
+	gameID := strings.TrimSpace(ctx.Args)
+	if gameID == "" {
+		return ctx.Reply("用法：/timeline <对局ID>")
+	}
+
+	timeline, err := h.manager.GameTimeline(gameID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	for _, chunk := range splitLines(ui.FormatGameTimeline(timeline), maxMessageLength) {
+		if err := ctx.Reply(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitLines 按行将文本拆分为不超过 limit 字节的片段，单行超长时截断
+func splitLines(text string, limit int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if len(line) > limit {
+			line = truncate(line, limit)
+		}
+		if current.Len() > 0 && current.Len()+1+len(line) > limit {
+			chunks = append(chunks, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		chunks = append(chunks, current.String())
+	}
+	return chunks
+}
+
+// truncate 按字节截断且不拆开多字节字符
+func truncate(s string, limit int) string {
+	limit -= len("…")
+	cut := 0
+	for i := range s {
+		if i > limit {
+			break
+		}
+		cut = i
+	}
+	return s[:cut] + "…"
+}
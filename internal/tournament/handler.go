@@ -0,0 +1,142 @@
+package tournament
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Handler /tournament 和 /enter 命令的处理器
+type Handler struct {
+	manager       *game.Manager
+	scheduler     *Scheduler
+	adminIDs      []int64
+	defaultSignup time.Duration
+	// 报名前的下注资格检查，未设置时不限制
+	eligibility middleware.EligibilityChecker
+}
+
+// NewHandler 创建锦标赛处理器，defaultSignup 为未指定报名时长时的默认值
+func NewHandler(manager *game.Manager, scheduler *Scheduler, adminIDs []int64, defaultSignup time.Duration) *Handler {
+	return &Handler{
+		manager:       manager,
+		scheduler:     scheduler,
+		adminIDs:      adminIDs,
+		defaultSignup: defaultSignup,
+	}
+}
+
+// Register 注册 /tournament 和 /enter 命令，开设和取消锦标赛仅限管理员
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.TournamentCommand, h.Tournament)
+	router.Handle(ui.EnterCommand, h.Enter, middleware.Eligible(h.eligible))
+}
+
+// SetEligibility 设置报名资格检查（如 eligibility.Checker.Check），未设置时不限制
+func (h *Handler) SetEligibility(check middleware.EligibilityChecker) {
+	h.eligibility = check
+}
+
+// eligible 按已设置的下注资格检查用户
+func (h *Handler) eligible(chatID int64, from *tgbotapi.User) (string, error) {
+	if h.eligibility == nil {
+		return "", nil
+	}
+	return h.eligibility(chatID, from)
+}
+
+// Tournament 不带参数时查看本群锦标赛，open 开设、cancel 取消由管理员执行
+func (h *Handler) Tournament(ctx *middleware.Context) error {
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("❌ 锦标赛只能在群组中进行")
+	}
+
+	fields := strings.Fields(ctx.Args)
+	if len(fields) == 0 {
+		return h.Status(ctx)
+	}
+	switch strings.ToLower(fields[0]) {
+	case "open":
+		return middleware.Chain(h.Open, middleware.AdminOnly(h.adminIDs))(ctx)
+	case "cancel":
+		return middleware.Chain(h.Cancel, middleware.AdminOnly(h.adminIDs))(ctx)
+	default:
+		return ctx.Reply(ui.FormatTournamentUsage(game.MinTournamentPlayers, game.MaxTournamentPlayers))
+	}
+}
+
+// Status 查看本群报名中或比赛中的锦标赛
+func (h *Handler) Status(ctx *middleware.Context) error {
+	progress, err := h.manager.ActiveTournament(ctx.ChatID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	if progress == nil {
+		return ctx.Reply("📭 本群暂无锦标赛\n\n" + ui.FormatTournamentUsage(game.MinTournamentPlayers, game.MaxTournamentPlayers))
+	}
+	return ctx.Reply(ui.FormatTournamentStatus(progress.Tournament, progress.Entries, progress.Matches,
+		game.TournamentRounds(len(progress.Entries))))
+}
+
+// Open 开设锦标赛：/tournament open <报名费> <人数上限> [报名分钟数]
+func (h *Handler) Open(ctx *middleware.Context) error {
+	usage := ui.FormatTournamentUsage(game.MinTournamentPlayers, game.MaxTournamentPlayers)
+	fields := strings.Fields(ctx.Args)[1:]
+	if len(fields) < 2 || len(fields) > 3 {
+		return ctx.Reply(usage)
+	}
+	entryFee, err := utils.ParseAmount(fields[0])
+	if err != nil {
+		return ctx.Reply("❌ 报名费必须是正数，最多两位小数\n" + usage)
+	}
+	maxPlayers, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ctx.Reply("❌ 人数上限必须是整数\n" + usage)
+	}
+	signup := h.defaultSignup
+	if len(fields) == 3 {
+		minutes, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return ctx.Reply("❌ 报名分钟数必须是整数\n" + usage)
+		}
+		signup = time.Duration(minutes) * time.Minute
+	}
+
+	tournament, err := h.manager.OpenTournament(ctx.UserID, ctx.ChatID, entryFee, maxPlayers, signup)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	return ctx.Reply(ui.FormatTournamentOpened(tournament))
+}
+
+// Cancel 取消本群报名中的锦标赛并退还报名费
+func (h *Handler) Cancel(ctx *middleware.Context) error {
+	tournament, entries, err := h.manager.CancelTournament(ctx.ChatID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	return ctx.Reply(ui.FormatTournamentCancelled(tournament, entries, false))
+}
+
+// Enter 报名本群的锦标赛，报满时唤醒调度器立即开赛
+func (h *Handler) Enter(ctx *middleware.Context) error {
+	if ctx.ChatID >= 0 {
+		return ctx.Reply("❌ 请在开设锦标赛的群组中报名")
+	}
+
+	tournament, entries, err := h.manager.EnterTournament(ctx.ChatID, ctx.UserID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	if len(entries) >= tournament.MaxPlayers {
+		h.scheduler.Wake()
+	}
+	return ctx.Reply(ui.FormatTournamentEntered(tournament, entries, ctx.UserID))
+}
@@ -0,0 +1,140 @@
+package tournament
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// DefaultRoundInterval 两轮之间的默认间隔
+	DefaultRoundInterval = time.Minute
+	// pollInterval 检查到期锦标赛的周期，报满开赛通过唤醒立即处理
+	pollInterval = 10 * time.Second
+)
+
+// Scheduler 定时推进锦标赛：报名截止后抽签开赛，之后每隔 roundInterval 进行一轮，
+// 每一步的结果在群内公布。进度记录在数据库中，进程重启后从下一步继续
+type Scheduler struct {
+	manager       *game.Manager
+	client        telegram.Client
+	roundInterval time.Duration
+
+	runMu sync.Mutex  // 同一时间只有一轮推进
+	gate  func() bool // 返回 false 时后台不推进
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+}
+
+// NewScheduler 创建锦标赛调度器，roundInterval 不大于 0 时使用默认间隔
+func NewScheduler(manager *game.Manager, client telegram.Client, roundInterval time.Duration) *Scheduler {
+	if roundInterval <= 0 {
+		roundInterval = DefaultRoundInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		manager:       manager,
+		client:        client,
+		roundInterval: roundInterval,
+		ctx:           ctx,
+		cancel:        cancel,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Start 启动后台调度，启动时立即处理进程退出期间到期的锦标赛
+func (s *Scheduler) Start() {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			if s.gate == nil || s.gate() {
+				s.Run(time.Now())
+			}
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// SetGate 设置后台调度的开关，多进程部署时只由一个进程推进锦标赛，避免同一轮重复开赛
+func (s *Scheduler) SetGate(gate func() bool) {
+	s.gate = gate
+}
+
+// Stop 停止后台调度，未完成的锦标赛在下次启动后继续
+func (s *Scheduler) Stop() {
+	s.cancel()
+}
+
+// Wake 立即检查到期的锦标赛（如报名已满）
+func (s *Scheduler) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run 推进所有到期的锦标赛并在群内公布结果，返回推进的锦标赛数；单场失败只记录日志，下次继续
+func (s *Scheduler) Run(now time.Time) int {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	tournaments, err := s.manager.DueTournaments(now)
+	if err != nil {
+		log.Printf("❌ 获取到期锦标赛失败: %v", err)
+		return 0
+	}
+
+	advanced := 0
+	for _, tournament := range tournaments {
+		progress, err := s.manager.AdvanceTournament(tournament.ID, s.roundInterval)
+		if err != nil {
+			log.Printf("❌ 推进锦标赛 %s 失败: %v", tournament.ID, err)
+			continue
+		}
+		advanced++
+		s.announce(progress)
+	}
+	return advanced
+}
+
+// announce 在群内公布取消、开赛、本轮结果和冠军
+func (s *Scheduler) announce(progress *game.TournamentProgress) {
+	tournament := progress.Tournament
+	totalRounds := game.TournamentRounds(len(progress.Entries))
+
+	var texts []string
+	switch {
+	case tournament.Status == models.TournamentStatusCancelled:
+		texts = append(texts, ui.FormatTournamentCancelled(tournament, progress.Entries, true))
+	case len(progress.Matches) == 0 && tournament.Status == models.TournamentStatusRunning:
+		texts = append(texts, ui.FormatTournamentStarted(tournament, progress.Entries, totalRounds))
+	case len(progress.Matches) > 0:
+		texts = append(texts, ui.FormatTournamentRound(tournament, progress.Entries, progress.Matches, totalRounds))
+	}
+	if tournament.Status == models.TournamentStatusFinished {
+		texts = append(texts, ui.FormatTournamentFinished(tournament, progress.Entries))
+	}
+
+	for _, text := range texts {
+		if _, err := s.client.Send(tgbotapi.NewMessage(tournament.ChatID, text)); err != nil {
+			log.Printf("⚠️ 发送锦标赛 %s 公告失败: %v", tournament.ID, err)
+		}
+	}
+}
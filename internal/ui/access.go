@@ -0,0 +1,17 @@
+package ui
+
+import "telegram-dice-bot/internal/models"
+
+// ChatAccessDeniedMessage 群组未获准使用机器人时，离开前发送的提示
+func ChatAccessDeniedMessage(mode string) string {
+	if mode == models.ChatAccessAllowlist {
+		return `🙏 感谢邀请！
+
+本机器人目前仅在合作群组中提供服务，暂时无法在本群使用，即将退出。
+如需开通，请联系客服申请。`
+	}
+	return `🙏 感谢邀请！
+
+本群暂时无法使用本机器人，即将退出。
+如有疑问，请联系客服。`
+}
@@ -0,0 +1,98 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// BalanceCommand 查看余额的命令
+	BalanceCommand = "balance"
+	// BalanceRefreshAction 余额消息的刷新按钮，参数为余额所属用户
+	BalanceRefreshAction = "balance_refresh"
+)
+
+// FormatBalanceMessage 生成 /balance 的余额信息，包含赠送余额的流水进度和余额的读取时间
+func FormatBalanceMessage(user *models.User, progress *models.BonusProgress, updatedAt time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("💰 您的账户余额：\n\n")
+	sb.WriteString(fmt.Sprintf("可提现余额：%s💎\n", utils.FormatAmount(user.Balance)))
+
+	if progress != nil && progress.BonusBalance > 0 {
+		sb.WriteString(fmt.Sprintf("赠送余额：%s💎（下注时优先使用）\n", utils.FormatAmount(progress.BonusBalance)))
+
+		if progress.WageringRequired > 0 {
+			percent := progress.Wagered * 100 / progress.WageringRequired
+			sb.WriteString(fmt.Sprintf("流水进度：%s/%s（%d%%）\n", utils.FormatAmount(progress.Wagered), utils.FormatAmount(progress.WageringRequired), percent))
+			sb.WriteString(fmt.Sprintf("%s\n", progressBar(percent)))
+			sb.WriteString("完成流水后赠送余额将自动转为可提现余额\n")
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("\n🕒 更新于 %s\n", updatedAt.Format("2006-01-02 15:04:05")))
+	sb.WriteString("\n可通过\"财务管理\"菜单进行充值和提现操作。")
+	return sb.String()
+}
+
+// BuildBalanceMessage 带刷新按钮的余额消息，只有余额所属用户可以刷新
+func BuildBalanceMessage(codec *callback.Codec, chatID, userID int64, text string) (tgbotapi.MessageConfig, error) {
+	markup, err := balanceKeyboard(codec, userID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = markup
+	return msg, nil
+}
+
+// BuildBalanceEdit 将已发送的余额消息原地更新为最新余额
+func BuildBalanceEdit(codec *callback.Codec, chatID int64, messageID int, userID int64, text string) (tgbotapi.EditMessageTextConfig, error) {
+	markup, err := balanceKeyboard(codec, userID)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	return tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, markup), nil
+}
+
+// ParseBalanceRefreshCallback 校验并解析余额刷新回调，返回余额所属用户
+func ParseBalanceRefreshCallback(codec *callback.Codec, data string) (int64, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return 0, err
+	}
+	if parsed.Action != BalanceRefreshAction || len(parsed.Args) != 1 {
+		return 0, callback.ErrMalformed
+	}
+	userID, err := strconv.ParseInt(parsed.Arg(0), 10, 64)
+	if err != nil {
+		return 0, callback.ErrMalformed
+	}
+	return userID, nil
+}
+
+func balanceKeyboard(codec *callback.Codec, userID int64) (tgbotapi.InlineKeyboardMarkup, error) {
+	data, err := codec.Encode(BalanceRefreshAction, strconv.FormatInt(userID, 10))
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData("🔄 刷新", data)),
+	), nil
+}
+
+// progressBar 生成 10 格进度条
+func progressBar(percent int64) string {
+	if percent > 100 {
+		percent = 100
+	}
+	filled := int(percent / 10)
+	return strings.Repeat("🟩", filled) + strings.Repeat("⬜", 10-filled)
+}
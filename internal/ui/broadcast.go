@@ -0,0 +1,70 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// BroadcastCommand 管理员发送公告的命令
+const BroadcastCommand = "broadcast"
+
+// broadcastAudienceLabels 公告接收范围的显示名称
+var broadcastAudienceLabels = map[string]string{
+	models.BroadcastGroups: "全部群组",
+	models.BroadcastUsers:  "全部用户",
+	models.BroadcastAll:    "群组和用户",
+}
+
+// broadcastStatusLabels 公告状态的显示名称
+var broadcastStatusLabels = map[string]string{
+	models.BroadcastScheduled: "⏳ 待发送",
+	models.BroadcastSending:   "📤 发送中",
+	models.BroadcastCompleted: "✅ 已完成",
+	models.BroadcastCancelled: "🚫 已取消",
+}
+
+// FormatBroadcastUsage /broadcast 命令用法
+func FormatBroadcastUsage() string {
+	return "用法：\n" +
+		"/broadcast groups <内容> — 发送到全部群组\n" +
+		"/broadcast users <内容> — 私信全部开启通知的用户\n" +
+		"/broadcast all <内容> — 发送到群组和用户\n" +
+		"/broadcast cancel <公告ID> — 取消待发送或发送中的公告\n" +
+		"/broadcast — 查看最近的公告"
+}
+
+// FormatBroadcastCreated 公告创建成功的回复
+func FormatBroadcastCreated(broadcast *models.Broadcast) string {
+	return fmt.Sprintf("📢 公告 #%d 已创建，将发送到%s\n取消：/broadcast cancel %d",
+		broadcast.ID, broadcastAudienceLabels[broadcast.Audience], broadcast.ID)
+}
+
+// FormatBroadcastList 最近的公告及投递进度
+func FormatBroadcastList(broadcasts []*models.Broadcast) string {
+	if len(broadcasts) == 0 {
+		return "📢 暂无公告\n\n" + FormatBroadcastUsage()
+	}
+
+	var b strings.Builder
+	b.WriteString("📢 最近的公告\n")
+	for _, broadcast := range broadcasts {
+		fmt.Fprintf(&b, "\n#%d %s｜%s｜%s\n", broadcast.ID, broadcastStatusLabels[broadcast.Status],
+			broadcastAudienceLabels[broadcast.Audience], broadcast.ScheduledAt.Format("01-02 15:04"))
+		if broadcast.Status != models.BroadcastScheduled {
+			fmt.Fprintf(&b, "送达 %d｜失败 %d｜共 %d\n", broadcast.Sent, broadcast.Failed, broadcast.Total)
+		}
+		fmt.Fprintf(&b, "%s\n", truncateRunes(strings.Join(strings.Fields(broadcast.Text), " "), 40))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// truncateRunes 按字符截断，超出部分以省略号代替
+func truncateRunes(s string, limit int) string {
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	return string(runes[:limit]) + "…"
+}
@@ -0,0 +1,16 @@
+package ui
+
+import "fmt"
+
+// WaitlistMessage 群组超出服务容量时的等待提示
+func WaitlistMessage(position int) string {
+	return fmt.Sprintf(`⏳ 当前服务的群组已满，本群已加入等待队列
+
+📋 排队位置：第 %d 位
+🔔 有空余名额时会在本群通知，届时再发送 /start 即可开始游戏`, position)
+}
+
+// WaitlistAdmittedMessage 群组从等待队列获得名额时的通知
+func WaitlistAdmittedMessage() string {
+	return "🎉 本群已获得服务名额，发送 /start 开始游戏吧！"
+}
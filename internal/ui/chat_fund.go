@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/utils"
+)
+
+// FundAction 群组基金管理命令的操作类型
+const (
+	FundActionShow    = "show"
+	FundActionAirdrop = "airdrop"
+	FundActionPrize   = "prize"
+)
+
+// ParseFundCommand 解析 /fund 命令参数，如 "airdrop 1000"、"prize 500"，空参数表示查看余额
+func ParseFundCommand(args string) (action string, amount int64, err error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return FundActionShow, 0, nil
+	}
+
+	action = strings.ToLower(fields[0])
+	switch action {
+	case FundActionAirdrop, FundActionPrize:
+	default:
+		return "", 0, fmt.Errorf("未知的基金操作: %s", fields[0])
+	}
+
+	if len(fields) != 2 {
+		return "", 0, fmt.Errorf("请指定金额，例如 /fund %s 1000", action)
+	}
+	amount, err = utils.ParseAmount(fields[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("无效的金额: %s", fields[1])
+	}
+	return action, amount, nil
+}
+
+// FormatChatFund 群组基金余额消息
+func FormatChatFund(balance, prizePool int64) string {
+	return fmt.Sprintf(`🏦 本群基金
+
+💰 基金余额：%s 金币
+🏆 比赛奖池：%s 金币
+
+管理员可使用：
+/fund airdrop <金额> 空投给近期活跃玩家
+/fund prize <金额> 转入比赛奖池（由下一场锦标赛的冠军获得）`, utils.FormatAmount(balance), utils.FormatAmount(prizePool))
+}
+
+// FormatFundAirdrop 群组基金空投结果消息
+func FormatFundAirdrop(recipients int, perUser, remaining int64) string {
+	return fmt.Sprintf("🪂 群组基金空投完成！%d 名活跃玩家每人获得 %s 金币\n🏦 基金剩余：%s 金币",
+		recipients, utils.FormatAmount(perUser), utils.FormatAmount(remaining))
+}
+
+// FormatFundPrize 群组基金转入比赛奖池的结果消息
+func FormatFundPrize(amount, prizePool int64) string {
+	return fmt.Sprintf("🏆 已从群组基金转入 %s 金币，当前比赛奖池：%s 金币\n下一场锦标赛的冠军将额外获得全部比赛奖池",
+		utils.FormatAmount(amount), utils.FormatAmount(prizePool))
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// /settings 在群组中可设置的项
+const (
+	ChatLimitMinBet  = "minbet"
+	ChatLimitMaxBet  = "maxbet"
+	ChatLimitFee     = "fee"
+	ChatLimitTimeout = "timeout"
+	// ChatLimitDefault 参数值，恢复沿用全局配置
+	ChatLimitDefault = "default"
+)
+
+// FormatChatLimits 群组当前生效的下注限额、手续费率和等待超时，沿用全局配置的项单独标注
+func FormatChatLimits(minBet, maxBet int64, feeRate float64, timeout time.Duration, custom *models.ChatGameLimits) string {
+	source := func(customized bool) string {
+		if customized {
+			return ""
+		}
+		return "（全局默认）"
+	}
+
+	var b strings.Builder
+	b.WriteString("⚙️ 本群对局设置\n\n")
+	b.WriteString(fmt.Sprintf("💎 最低下注：%s%s\n", utils.FormatAmount(minBet), source(custom.MinBet > 0)))
+	b.WriteString(fmt.Sprintf("💎 最高下注：%s%s\n", utils.FormatAmount(maxBet), source(custom.MaxBet > 0)))
+	b.WriteString(fmt.Sprintf("💸 手续费率：%g%%%s\n", feeRate*100, source(custom.FeeRate != nil)))
+	b.WriteString(fmt.Sprintf("⏰ 等待加入超时：%v%s\n\n", timeout, source(custom.GameTimeout > 0)))
+	b.WriteString(FormatChatLimitsUsage())
+	return b.String()
+}
+
+// FormatChatLimitsUsage 群组 /settings 命令用法
+func FormatChatLimitsUsage() string {
+	return fmt.Sprintf(`用法：
+/settings %s <金额>
+/settings %s <金额>
+/settings %s <百分比，如 5 或 2.5%%>
+/settings %s <秒数|2m>
+参数为 %s 时恢复全局默认`, ChatLimitMinBet, ChatLimitMaxBet, ChatLimitFee, ChatLimitTimeout, ChatLimitDefault)
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 群组设置导出和导入的管理员命令
+const (
+	ExportSettingsCommand = "exportsettings"
+	ImportSettingsCommand = "importsettings"
+)
+
+// ChatSettingsApplyAction 确认导入群组设置按钮的回调动作
+const ChatSettingsApplyAction = "chatset"
+
+// FormatChatSettingsExport 导出的群组设置，可直接复制到 /importsettings
+func FormatChatSettingsExport(chatID int64, data string) string {
+	return fmt.Sprintf("📤 群组 %d 的设置：\n\n%s\n\n导入到其他群组：/importsettings <群组ID,群组ID> <以上JSON>", chatID, data)
+}
+
+// FormatChatSettingsPreview 导入前的变更预览
+func FormatChatSettingsPreview(chatIDs []int64, changes []models.ChatSettingChange) string {
+	if len(changes) == 0 {
+		return fmt.Sprintf("📥 %d 个群组的设置已与导入内容一致，无需变更", len(chatIDs))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📥 即将修改 %d 个群组的设置：\n", len(chatIDs))
+	for _, change := range changes {
+		fmt.Fprintf(&b, "\n• %d %s：%v → %v", change.ChatID, change.Field, change.From, change.To)
+	}
+	b.WriteString("\n\n确认无误后点击下方按钮应用（10 分钟内有效）")
+	return b.String()
+}
+
+// BuildChatSettingsPreview 带确认按钮的导入预览消息
+func BuildChatSettingsPreview(codec *callback.Codec, chatID int64, token, text string) (tgbotapi.MessageConfig, error) {
+	data, err := codec.Encode(ChatSettingsApplyAction, token)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ 确认导入", data),
+		),
+	)
+	return msg, nil
+}
+
+// ParseChatSettingsApplyCallback 校验并解析确认导入回调，返回待导入任务的令牌
+func ParseChatSettingsApplyCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != ChatSettingsApplyAction || len(parsed.Args) != 1 || parsed.Arg(0) == "" {
+		return "", callback.ErrMalformed
+	}
+	return parsed.Arg(0), nil
+}
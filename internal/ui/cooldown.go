@@ -0,0 +1,41 @@
+package ui
+
+import "time"
+
+// CooldownCommand 查看或设置群组两局之间冷却时间的命令
+const CooldownCommand = "cooldown"
+
+// CooldownOff /cooldown 参数，取消冷却
+const CooldownOff = "off"
+
+// FormatGameCooldown 冷却中拒绝开局时的倒计时提示
+func FormatGameCooldown(lang string, remaining time.Duration) string {
+	seconds := int((remaining + time.Second - 1) / time.Second)
+	return T(lang, "cooldown.waiting", Args{"Minutes": seconds / 60, "Seconds": seconds % 60})
+}
+
+// FormatChatCooldown 群组当前冷却设置
+func FormatChatCooldown(lang string, cooldown time.Duration) string {
+	if cooldown <= 0 {
+		return T(lang, "cooldown.off", Args{"Usage": FormatCooldownUsage(lang)})
+	}
+	return T(lang, "cooldown.current", Args{"Cooldown": cooldown, "Usage": FormatCooldownUsage(lang)})
+}
+
+// FormatCooldownUsage /cooldown 命令用法
+func FormatCooldownUsage(lang string) string {
+	return T(lang, "cooldown.usage", Args{"Off": CooldownOff})
+}
+
+// FormatInvalidCooldown 冷却时间参数无法解析时的提示
+func FormatInvalidCooldown(lang string) string {
+	return T(lang, "cooldown.invalid", Args{"Usage": FormatCooldownUsage(lang)})
+}
+
+// FormatChatCooldownSet 设置群组冷却时间的结果
+func FormatChatCooldownSet(lang string, cooldown time.Duration) string {
+	if cooldown <= 0 {
+		return T(lang, "cooldown.disabled", nil)
+	}
+	return T(lang, "cooldown.set", Args{"Cooldown": cooldown})
+}
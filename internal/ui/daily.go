@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// DailyCommand 每日签到领取免费金币的命令
+const DailyCommand = "daily"
+
+// FormatDailyClaimed 签到成功：本次金额、连续天数和加成，以及明天签到可得的金额
+func FormatDailyClaimed(claim *models.DailyClaim, balance, base, tomorrow int64) string {
+	text := fmt.Sprintf("🎁 签到成功！获得 %s 金币\n🔥 已连续签到 %d 天", utils.FormatAmount(claim.Amount), claim.Streak)
+	if claim.Amount > base && base > 0 {
+		text += fmt.Sprintf("（加成 +%d%%）", (claim.Amount-base)*100/base)
+	}
+	return text + fmt.Sprintf("\n💳 当前余额：%s 金币\n\n📅 24 小时后再来，连续签到明天可得 %s 金币\n⚠️ 超过 48 小时未签到将重新计算连续天数",
+		utils.FormatAmount(balance), utils.FormatAmount(tomorrow))
+}
+
+// FormatDailyNotReady 冷却中重复签到时的提示
+func FormatDailyNotReady(remaining time.Duration, streak int) string {
+	minutes := int((remaining + time.Minute - 1) / time.Minute)
+	return fmt.Sprintf("⏳ 今天已经签到过了\n🔥 已连续签到 %d 天\n⌛ %d 小时 %02d 分后可再次签到", streak, minutes/60, minutes%60)
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// DiceCommand 发起对局的命令，金额支持整数、all、half 和百分比
+	DiceCommand = "dice"
+	// DiceConfirmAction 确认押上全部余额，参数为发起者ID和金额
+	DiceConfirmAction = "dice_confirm"
+	// DiceCancelAction 取消押上全部余额，参数为发起者ID
+	DiceCancelAction = "dice_cancel"
+	// CustomBetAction 自定义赌注按钮，点击后提示玩家直接回复金额
+	CustomBetAction = "custom_bet"
+)
+
+// FormatDiceUsage /dice 命令用法
+func FormatDiceUsage() string {
+	return "用法：/dice <金额>\n例如：/dice 100、/dice half、/dice 25%、/dice all"
+}
+
+// CustomBetButton 自定义赌注按钮
+func CustomBetButton(codec *callback.Codec) (tgbotapi.InlineKeyboardButton, error) {
+	data, err := codec.Encode(CustomBetAction)
+	if err != nil {
+		return tgbotapi.InlineKeyboardButton{}, err
+	}
+	return tgbotapi.NewInlineKeyboardButtonData("✏️ 自定义赌注", data), nil
+}
+
+// BuildCustomBetPrompt 请玩家直接回复下注金额，群组中只对该玩家弹出回复框
+func BuildCustomBetPrompt(chatID int64, name string, minBet, maxBet int64, timeout time.Duration) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✏️ %s 请直接回复本条消息输入下注金额\n💰 单注范围：%s - %s 金币\n📝 也可以输入 half、25%% 或 all\n⌛ %d 分钟内有效",
+		name, utils.FormatAmount(minBet), utils.FormatAmount(maxBet), int(timeout.Minutes())))
+	msg.ReplyMarkup = tgbotapi.ForceReply{
+		ForceReply:            true,
+		InputFieldPlaceholder: "例如 100",
+		Selective:             true,
+	}
+	return msg
+}
+
+// BuildBetAllConfirm 押上全部余额前请发起者确认
+func BuildBetAllConfirm(codec *callback.Codec, chatID, userID, amount, available int64) (tgbotapi.MessageConfig, error) {
+	user := strconv.FormatInt(userID, 10)
+	confirm, err := codec.Encode(DiceConfirmAction, user, strconv.FormatInt(amount, 10))
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	cancel, err := codec.Encode(DiceCancelAction, user)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+
+	text := fmt.Sprintf("⚠️ 确认梭哈？\n\n💰 本局下注：%s 金币\n💳 可用余额：%s 金币", utils.FormatAmount(amount), utils.FormatAmount(available))
+	if amount < available {
+		text += "\n📌 已按单注上限下注"
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ 确认 %s 金币", utils.FormatAmount(amount)), confirm),
+			tgbotapi.NewInlineKeyboardButtonData("❌ 取消", cancel),
+		),
+	)
+	return msg, nil
+}
+
+// ParseDiceConfirmCallback 校验并解析梭哈确认按钮回调，返回动作、发起者ID和金额（取消时为 0）
+func ParseDiceConfirmCallback(codec *callback.Codec, data string) (string, int64, int64, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	userID, err := parsed.Int64(0)
+	if err != nil {
+		return "", 0, 0, callback.ErrMalformed
+	}
+
+	switch {
+	case parsed.Action == DiceCancelAction && len(parsed.Args) == 1:
+		return parsed.Action, userID, 0, nil
+	case parsed.Action == DiceConfirmAction && len(parsed.Args) == 2:
+		amount, err := parsed.Int64(1)
+		if err != nil || amount <= 0 {
+			return "", 0, 0, callback.ErrMalformed
+		}
+		return parsed.Action, userID, amount, nil
+	default:
+		return "", 0, 0, callback.ErrMalformed
+	}
+}
+
+// BuildGameCreated 对局发起公告，附带加入按钮，有种子承诺时一并公布
+func BuildGameCreated(codec *callback.Codec, chatID int64, gameID string, amount int64, creator, seedHash string) (tgbotapi.MessageConfig, error) {
+	join, err := codec.Encode(JoinAction, gameID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	text := fmt.Sprintf("🎲 %s 发起了 %s 金币的对局\n🆔 %s\n\n点击下方按钮加入，或发送 /join %s",
+		creator, utils.FormatAmount(amount), gameID, gameID)
+	if seedHash != "" {
+		text += "\n\n" + FormatSeedCommitment(gameID, seedHash)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚔️ 加入对局（%s 金币）", utils.FormatAmount(amount)), join),
+		),
+	)
+	return msg, nil
+}
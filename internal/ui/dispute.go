@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"fmt"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// DisputeAction 对局结果下方的争议按钮，参数为对局ID
+const DisputeAction = "dispute"
+
+// DisputeButton 争议按钮，可附加在对局结果消息下方
+func DisputeButton(codec *callback.Codec, gameID string) (tgbotapi.InlineKeyboardButton, error) {
+	data, err := codec.Encode(DisputeAction, gameID)
+	if err != nil {
+		return tgbotapi.InlineKeyboardButton{}, err
+	}
+	return tgbotapi.NewInlineKeyboardButtonData("⚖️ 争议", data), nil
+}
+
+// ParseDisputeCallback 校验并解析争议按钮回调，返回对局ID
+func ParseDisputeCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != DisputeAction || len(parsed.Args) != 1 || parsed.Args[0] == "" {
+		return "", callback.ErrMalformed
+	}
+	return parsed.Args[0], nil
+}
+
+// FormatDisputeOpened 提出争议后的群内公告
+func FormatDisputeOpened(d *models.Dispute, reporter string) string {
+	return fmt.Sprintf(`⚖️ %s 对对局 %s 的结果提出争议
+
+💰 获胜方的派奖 %s 金币已冻结
+🕵️ 管理员将根据对局记录审核，结果会私信通知双方`, reporter, d.GameID, utils.FormatAmount(d.Held))
+}
+
+// FormatDisputeAlert 新争议的管理员告警
+func FormatDisputeAlert(d *models.Dispute, reporter string) string {
+	return fmt.Sprintf("⚖️ 新对局争议 #%d 待审核\n\n🆔 对局：%s\n👤 提出者：%s（%d）\n🏆 获胜者：%d\n💰 派奖 %s 金币，已冻结 %s 金币\n\n请在管理后台查看对局时间线后维持或撤销结果",
+		d.ID, d.GameID, reporter, d.ReporterID, d.WinnerID, utils.FormatAmount(d.Payout), utils.FormatAmount(d.Held))
+}
+
+// DisputeResolvedDM 争议审核完成后私信对局双方的通知
+func DisputeResolvedDM(d *models.Dispute, userID int64) string {
+	var text string
+	switch {
+	case d.Status == models.DisputeStatusReleased && userID == d.WinnerID:
+		text = fmt.Sprintf("✅ 对局 %s 的争议 #%d 已审核，维持原结果\n\n💰 冻结的 %s 金币已退回余额", d.GameID, d.ID, utils.FormatAmount(d.Held))
+	case d.Status == models.DisputeStatusReleased:
+		text = fmt.Sprintf("⚖️ 对局 %s 的争议 #%d 已审核，维持原结果", d.GameID, d.ID)
+	default:
+		text = fmt.Sprintf("↩️ 对局 %s 的争议 #%d 已审核，原结果已撤销\n\n对局作废，双方下注 %s 金币已退还", d.GameID, d.ID, utils.FormatAmount(d.BetAmount))
+	}
+	if d.Note != "" {
+		text += "\n📝 说明：" + d.Note
+	}
+	return text
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// EligibilityCommand 查看或设置群组下注资格的命令
+const EligibilityCommand = "eligibility"
+
+// EligibilityOff /eligibility 参数，取消全部下注资格要求
+const EligibilityOff = "off"
+
+// FormatAccountTooNew 账号注册时间不足时拒绝下注的提示
+func FormatAccountTooNew(minDays int) string {
+	return fmt.Sprintf("🪪 本群仅允许注册满 %d 天的 Telegram 账号参与对局\n您的账号注册时间较短，请过段时间再来", minDays)
+}
+
+// FormatMemberTooNew 加入本群时间不足时拒绝下注的提示
+func FormatMemberTooNew(minDays int, remaining time.Duration) string {
+	hours := int((remaining + time.Hour - 1) / time.Hour)
+	wait := fmt.Sprintf("%d 小时", hours)
+	if hours >= 24 {
+		wait = fmt.Sprintf("%d 天 %d 小时", hours/24, hours%24)
+	}
+	return fmt.Sprintf("🪪 本群仅允许在群内满 %d 天的成员参与对局\n⌛ 还需等待 %s", minDays, wait)
+}
+
+// FormatChatEligibility 群组当前的下注资格要求
+func FormatChatEligibility(eligibility *models.ChatEligibility) string {
+	if eligibility.MinAccountDays <= 0 && eligibility.MinMemberDays <= 0 {
+		return "🪪 本群未设置下注资格要求\n\n" + FormatEligibilityUsage()
+	}
+
+	lines := []string{"🪪 本群下注资格要求"}
+	if eligibility.MinAccountDays > 0 {
+		lines = append(lines, fmt.Sprintf("• 账号注册满 %d 天（按用户ID估算）", eligibility.MinAccountDays))
+	}
+	if eligibility.MinMemberDays > 0 {
+		lines = append(lines, fmt.Sprintf("• 在本群满 %d 天（从机器人首次见到该成员起算）", eligibility.MinMemberDays))
+	}
+	return strings.Join(lines, "\n") + "\n\n" + FormatEligibilityUsage()
+}
+
+// FormatEligibilityUsage /eligibility 命令用法
+func FormatEligibilityUsage() string {
+	return fmt.Sprintf("用法：/eligibility account=<天数> member=<天数>，0 表示不限制，/eligibility %s 取消全部要求", EligibilityOff)
+}
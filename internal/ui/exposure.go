@@ -0,0 +1,83 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/utils"
+)
+
+// ExposureCommand 查看或调整群组每小时下注上限的命令，仅限机器人管理员
+const ExposureCommand = "exposure"
+
+// /exposure 参数
+const (
+	// ExposureResume 解除本群本小时的暂停
+	ExposureResume = "resume"
+	// ExposureOff 本群不限制
+	ExposureOff = "off"
+	// ExposureDefault 恢复沿用全局配置
+	ExposureDefault = "default"
+)
+
+// FormatChatExposure 群组本小时的下注总额、生效的上限和暂停状态
+func FormatChatExposure(hourlyCap, custom, turnover int64, windowEnd time.Time, overridden bool) string {
+	var b strings.Builder
+	b.WriteString("🛡️ 本群每小时下注上限\n\n")
+	switch {
+	case custom < 0:
+		b.WriteString("📏 上限：不限制（本群设置）\n")
+	case hourlyCap <= 0:
+		b.WriteString("📏 上限：不限制（全局默认）\n")
+	case custom > 0:
+		b.WriteString(fmt.Sprintf("📏 上限：%s 金币\n", utils.FormatAmount(hourlyCap)))
+	default:
+		b.WriteString(fmt.Sprintf("📏 上限：%s 金币（全局默认）\n", utils.FormatAmount(hourlyCap)))
+	}
+	b.WriteString(fmt.Sprintf("📊 本小时下注总额：%s 金币\n", utils.FormatAmount(turnover)))
+	switch {
+	case overridden:
+		b.WriteString("🔓 本小时已由管理员解除暂停\n")
+	case hourlyCap > 0 && turnover >= hourlyCap:
+		b.WriteString(fmt.Sprintf("🛑 已暂停发起新对局，%s 恢复\n", windowEnd.Format("15:04")))
+	}
+	b.WriteString("\n" + FormatExposureUsage())
+	return b.String()
+}
+
+// FormatExposureUsage /exposure 命令用法
+func FormatExposureUsage() string {
+	return fmt.Sprintf(`用法：
+/exposure <金额> — 设置本群每小时下注上限
+/exposure %s — 本群不限制
+/exposure %s — 恢复全局默认
+/exposure %s — 解除本小时的暂停`, ExposureOff, ExposureDefault, ExposureResume)
+}
+
+// FormatExposureCapSet 调整群组每小时下注上限的结果
+func FormatExposureCapSet(hourlyCap int64) string {
+	switch {
+	case hourlyCap < 0:
+		return "✅ 本群已不再限制每小时下注总额"
+	case hourlyCap == 0:
+		return "✅ 本群每小时下注上限已恢复全局默认"
+	default:
+		return fmt.Sprintf("✅ 本群每小时下注总额上限已设置为 %s 金币", utils.FormatAmount(hourlyCap))
+	}
+}
+
+// FormatExposureResumed 管理员解除本小时暂停的结果
+func FormatExposureResumed(windowEnd time.Time) string {
+	return fmt.Sprintf("🔓 已解除本群本小时的暂停，%s 起恢复按上限检查", windowEnd.Format("15:04"))
+}
+
+// FormatExposureAlert 群组反复触发下注上限时发给管理员的告警
+func FormatExposureAlert(chatID int64, title string, hits int, hourlyCap int64) string {
+	name := fmt.Sprintf("%d", chatID)
+	if title != "" {
+		name = fmt.Sprintf("%s（%d）", title, chatID)
+	}
+	return fmt.Sprintf("群组 %s 24 小时内已有 %d 个小时下注总额达到上限 %s 金币，请检查是否存在异常账户",
+		name, hits, utils.FormatAmount(hourlyCap))
+}
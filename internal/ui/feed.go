@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/utils"
+)
+
+// MaskName 对外展示的脱敏名字：保留首尾字符，中间用 *** 代替
+func MaskName(username, firstName string) string {
+	name := firstName
+	if name == "" {
+		name = username
+	}
+
+	runes := []rune(strings.TrimSpace(name))
+	switch len(runes) {
+	case 0:
+		return "神秘玩家"
+	case 1:
+		return string(runes) + "***"
+	case 2:
+		return string(runes[:1]) + "***"
+	default:
+		return string(runes[:1]) + "***" + string(runes[len(runes)-1:])
+	}
+}
+
+// FormatBigWin 大奖频道的转发消息，不包含群组和用户标识
+func FormatBigWin(maskedName string, betAmount, winAmount int64) string {
+	return fmt.Sprintf(`🎉 大奖快讯
+
+🏆 %s 赢得 %s 金币
+🎲 下注：%s 金币
+
+想试试手气？把机器人拉进你的群，发送 /dice 开局`, maskedName, utils.FormatAmount(winAmount), utils.FormatAmount(betAmount))
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"fmt"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// FormatHeadToHead 开局公告中的双方交手记录，如"过去交手 5 次，A 胜 3 次，B 胜 1 次，平局 1 次"
+func FormatHeadToHead(record *models.HeadToHead, player1, player2 *models.User) string {
+	if record == nil || record.Games == 0 || player1 == nil || player2 == nil {
+		return ""
+	}
+
+	name1 := PublicName(player1.ID, player1.Username, player1.FirstName, false)
+	name2 := PublicName(player2.ID, player2.Username, player2.FirstName, false)
+	text := fmt.Sprintf("⚔️ 老对手相遇！过去交手 %d 次，%s 胜 %d 次，%s 胜 %d 次",
+		record.Games, name1, record.Wins(player1.ID), name2, record.Wins(player2.ID))
+	if draws := record.Draws(); draws > 0 {
+		text += fmt.Sprintf("，平局 %d 次", draws)
+	}
+	return text
+}
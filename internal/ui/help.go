@@ -0,0 +1,475 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"telegram-dice-bot/internal/callback"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// HelpCommand 查看帮助的命令，可带主题参数，如 /help dice
+	HelpCommand = "help"
+	// HelpTopicAction 帮助主题之间跳转的按钮，参数为主题名
+	HelpTopicAction = "help_topic"
+	// HelpIndexTopic 帮助目录
+	HelpIndexTopic = "index"
+)
+
+// helpTopicsPerRow 帮助按钮每行的主题数
+const helpTopicsPerRow = 3
+
+// HelpData 帮助模板可用的变量，限制取自本群当前设置
+type HelpData struct {
+	InGroup bool // 在群组中查看时才显示本群设置
+
+	MinBet     string // 已按两位小数格式化的金额
+	MaxBet     string
+	FeePercent float64
+
+	Sequential     bool // 顺序模式：同一时间只进行一局
+	MaxActiveGames int  // 并行模式下同时进行的对局上限，0 表示不限
+	Cooldown       int  // 两局之间的冷却时间（秒），0 表示不限制
+
+	SurrenderEnabled       bool
+	SurrenderRefundPercent float64
+	ReadyCheck             bool // 对手加入后需双方确认准备才开骰
+	ReadySeconds           int  // 确认准备的时限（秒）
+
+	TableMinPlayers int
+	TableMaxPlayers int
+	TableTimeout    int // 快速桌等待开骰的时间（分钟）
+
+	DailyBonus string // 每日签到金额，未开启时为空
+}
+
+// helpTopic 一个帮助主题：按钮标题和正文模板
+type helpTopic struct {
+	Title string
+	Text  string
+}
+
+// helpTopicOrder 帮助主题的显示顺序
+var helpTopicOrder = []string{"dice", "join", "games", "table", "balance"}
+
+// defaultHelpCatalog 内置的帮助主题，按语言索引
+var defaultHelpCatalog = map[string]map[string]helpTopic{
+	"zh": {
+		HelpIndexTopic: {Title: "📖 目录", Text: `📖 帮助目录
+
+🎲 /dice <金额> — 发起对局
+🤝 /join <对局ID> — 加入对局
+📋 /games — 查看等待中的对局
+🏆 /rank — 本群今日/本周/本月排行榜
+📊 /stats — 查看我的战绩
+📜 /history — 查看我最近的对局记录
+🔐 /verify <对局ID> — 审计对局结算
+🪑 /table [底注] — 开设 3-6 人快速桌
+💰 /balance — 查看余额
+{{if .InGroup}}
+💎 本群单注范围：{{.MinBet}} - {{.MaxBet}} 金币
+{{end}}
+发送 /help <主题> 或点击下方按钮查看详细用法和示例`},
+		"dice": {Title: "🎲 发起", Text: `🎲 /dice <金额> — 发起对局
+
+双方各掷三个骰子，点数之和大者赢得对方的下注，扣除 {{.FeePercent}}% 手续费；点数相同为平局，退还下注。
+
+示例：
+• /dice 100 — 发起一局 100 金币的对局
+{{if .InGroup}}
+📌 本群设置：
+• 单注范围：{{.MinBet}} - {{.MaxBet}} 金币
+{{- if .Sequential}}
+• 顺序模式：同一时间只进行一局，其余对局排队
+{{- else if gt .MaxActiveGames 0}}
+• 同时进行的对局不超过 {{.MaxActiveGames}} 局
+{{- end}}
+{{- if gt .Cooldown 0}}
+• 每局结束后需等待 {{.Cooldown}} 秒才能开始下一局
+{{- end}}
+{{- if .SurrenderEnabled}}
+• 对局中可认输，退还 {{.SurrenderRefundPercent}}% 下注
+{{- end}}
+{{- if .ReadyCheck}}
+• 对手加入后双方需在 {{.ReadySeconds}} 秒内点击「✅准备」才开骰，加入者未准备将撤销加入并退款
+{{- end}}
+{{end}}`},
+		"join": {Title: "🤝 加入", Text: `🤝 /join <对局ID> — 加入对局
+
+加入等待中的对局并下同样的金额，加入后立即开骰。也可以直接点击对局公告下方的加入按钮。
+
+示例：
+• /join GAME1672531200001
+{{if .InGroup}}
+📌 本群设置：
+{{- if .Sequential}}
+• 顺序模式：已有对局进行中时，加入请求按顺序排队，轮到时自动开骰，每人最多排队一个请求
+{{- else if gt .MaxActiveGames 0}}
+• 同时进行的对局达到 {{.MaxActiveGames}} 局时，加入请求需要排队
+{{- else}}
+• 不限制同时进行的对局数
+{{- end}}
+{{- if gt .Cooldown 0}}
+• 冷却时间：{{.Cooldown}} 秒，冷却中的加入请求会被拒绝
+{{- end}}
+{{end}}`},
+		"games": {Title: "📋 对局", Text: `📋 /games — 查看等待中的对局
+
+列出本群等待加入的对局，点击按钮即可加入，列表可原地刷新。
+
+🏆 /rank [day|week|month] — 本群排行榜，按净盈亏排序，显示胜场和对局数，可用按钮切换周期
+
+🔐 /verify <对局ID> — 发起对局时公布种子的承诺哈希，结束后公开种子，并核对结算点数与群内骰子消息是否一致
+
+示例：
+• /games
+• /rank week
+• /verify <对局ID>`},
+		"table": {Title: "🪑 快速桌", Text: `🪑 /table [底注] — 开设快速桌
+
+{{.TableMinPlayers}}-{{.TableMaxPlayers}} 人各下相同底注，每人掷一次三个骰子，点数最高的两位瓜分奖池（第一名 60%，第二名 40%）。满 {{.TableMinPlayers}} 人后开桌者可以开骰，坐满自动开骰；{{.TableTimeout}} 分钟内未开骰自动关闭并退还底注。
+
+⚔️ /dicemulti <底注> <人数上限> — 开设多人大战，规则相同，但点数最高者独得奖池（并列最高时平分）
+
+示例：
+• /table — 使用默认底注
+• /table 50 — 底注 50 金币
+• /dicemulti 100 8 — 底注 100，最多 8 人
+{{if .InGroup}}
+📌 本群底注范围：{{.MinBet}} - {{.MaxBet}} 金币
+{{end}}`},
+		"balance": {Title: "💰 余额", Text: `💰 /balance — 查看余额
+
+显示现金余额和赠送余额，点击刷新按钮可更新。下注时优先使用赠送余额。
+{{if .DailyBonus}}
+🎁 /daily — 每 24 小时签到领取 {{.DailyBonus}} 金币，连续签到有额外加成
+{{end}}👥 /invite — 获取邀请链接，好友注册和下注达标后双方都有奖励
+
+示例：
+• /balance`},
+	},
+	"en": {
+		HelpIndexTopic: {Title: "📖 Contents", Text: `📖 Help
+
+🎲 /dice <amount> — start a game
+🤝 /join <game ID> — join a game
+📋 /games — list waiting games
+🏆 /rank — today/this week/this month leaderboard for this chat
+📊 /stats — your game statistics
+📜 /history — your recent games
+🔐 /verify <game ID> — audit a settled game
+🪑 /table [ante] — open a 3-6 player quick table
+💰 /balance — check your balance
+{{if .InGroup}}
+💎 Bet range in this chat: {{.MinBet}} - {{.MaxBet}} coins
+{{end}}
+Send /help <topic> or tap a button below for details and examples`},
+		"dice": {Title: "🎲 Start", Text: `🎲 /dice <amount> — start a game
+
+Both players roll three dice; the higher total wins the opponent's stake minus a {{.FeePercent}}% fee. Equal totals are a draw and stakes are refunded.
+
+Example:
+• /dice 100 — start a 100 coin game
+{{if .InGroup}}
+📌 This chat:
+• Bet range: {{.MinBet}} - {{.MaxBet}} coins
+{{- if .Sequential}}
+• Sequential mode: one game at a time, others wait in a queue
+{{- else if gt .MaxActiveGames 0}}
+• At most {{.MaxActiveGames}} games at the same time
+{{- end}}
+{{- if gt .Cooldown 0}}
+• {{.Cooldown}} second cooldown after each game
+{{- end}}
+{{- if .SurrenderEnabled}}
+• Surrender is allowed and refunds {{.SurrenderRefundPercent}}% of the stake
+{{- end}}
+{{- if .ReadyCheck}}
+• After someone joins, both players must tap "✅准备" within {{.ReadySeconds}} seconds; if the joiner doesn't, the join is undone and refunded
+{{- end}}
+{{end}}`},
+		"join": {Title: "🤝 Join", Text: `🤝 /join <game ID> — join a game
+
+Join a waiting game with the same stake; the dice are rolled right away. You can also tap the join button under a game announcement.
+
+Example:
+• /join GAME1672531200001
+{{if .InGroup}}
+📌 This chat:
+{{- if .Sequential}}
+• Sequential mode: while a game is running, joins wait in a queue and start automatically; one queued join per player
+{{- else if gt .MaxActiveGames 0}}
+• Joins are queued once {{.MaxActiveGames}} games are running
+{{- else}}
+• No limit on games running at the same time
+{{- end}}
+{{- if gt .Cooldown 0}}
+• Cooldown: {{.Cooldown}} seconds, joins during the cooldown are rejected
+{{- end}}
+{{end}}`},
+		"games": {Title: "📋 Games", Text: `📋 /games — list waiting games
+
+Lists the games in this chat waiting for an opponent, with join buttons and an in-place refresh.
+
+🏆 /rank [day|week|month] — this chat's leaderboard by net profit, with wins and games played; buttons switch the period
+
+🔐 /verify <game ID> — each game publishes a hash commitment of its seed when created and reveals the seed once finished, and the settled dice are checked against the dice messages in the chat
+
+Examples:
+• /games
+• /rank week
+• /verify <game ID>`},
+		"table": {Title: "🪑 Table", Text: `🪑 /table [ante] — open a quick table
+
+{{.TableMinPlayers}}-{{.TableMaxPlayers}} players pay the same ante and roll three dice once; the top two split the pot (60% / 40%). The opener can roll once {{.TableMinPlayers}} players are seated, a full table rolls automatically, and a table not rolled within {{.TableTimeout}} minutes closes with a full refund.
+
+⚔️ /dicemulti <ante> <max players> — open a battle with the same rules, except the highest roll takes the whole pot (ties split it)
+
+Examples:
+• /table — use the default ante
+• /table 50 — 50 coin ante
+• /dicemulti 100 8 — 100 coin ante, up to 8 players
+{{if .InGroup}}
+📌 Ante range in this chat: {{.MinBet}} - {{.MaxBet}} coins
+{{end}}`},
+		"balance": {Title: "💰 Balance", Text: `💰 /balance — check your balance
+
+Shows your cash and bonus balance with a refresh button. Bonus balance is used first when you bet.
+{{if .DailyBonus}}
+🎁 /daily — claim {{.DailyBonus}} free coins every 24 hours, with a bonus for consecutive days
+{{end}}👥 /invite — get your invite link; you and your friends earn rewards when they sign up and play
+
+Example:
+• /balance`},
+	},
+	"ru": {
+		HelpIndexTopic: {Title: "📖 Содержание", Text: `📖 Справка
+
+🎲 /dice <сумма> — начать игру
+🤝 /join <ID игры> — присоединиться к игре
+📋 /games — список ожидающих игр
+🏆 /rank — рейтинг чата за день/неделю/месяц
+📊 /stats — ваша статистика
+📜 /history — ваши последние игры
+🔐 /verify <ID игры> — проверить расчёт игры
+🪑 /table [ставка] — открыть быстрый стол на 3-6 игроков
+💰 /balance — проверить баланс
+{{if .InGroup}}
+💎 Диапазон ставок в этом чате: {{.MinBet}} - {{.MaxBet}} монет
+{{end}}
+Отправьте /help <тема> или нажмите кнопку ниже, чтобы увидеть подробности и примеры`},
+		"dice": {Title: "🎲 Игра", Text: `🎲 /dice <сумма> — начать игру
+
+Оба игрока бросают три кости; у кого сумма больше, тот забирает ставку соперника за вычетом комиссии {{.FeePercent}}%. При равенстве объявляется ничья и ставки возвращаются.
+
+Пример:
+• /dice 100 — начать игру на 100 монет
+{{if .InGroup}}
+📌 В этом чате:
+• Диапазон ставок: {{.MinBet}} - {{.MaxBet}} монет
+{{- if .Sequential}}
+• Последовательный режим: одновременно идёт одна игра, остальные ждут в очереди
+{{- else if gt .MaxActiveGames 0}}
+• Одновременно не больше {{.MaxActiveGames}} игр
+{{- end}}
+{{- if gt .Cooldown 0}}
+• Пауза {{.Cooldown}} секунд после каждой игры
+{{- end}}
+{{- if .SurrenderEnabled}}
+• Можно сдаться и вернуть {{.SurrenderRefundPercent}}% ставки
+{{- end}}
+{{- if .ReadyCheck}}
+• После присоединения оба игрока должны нажать «✅准备» в течение {{.ReadySeconds}} секунд; если присоединившийся не успеет, его участие отменяется, а ставка возвращается
+{{- end}}
+{{end}}`},
+		"join": {Title: "🤝 Вход", Text: `🤝 /join <ID игры> — присоединиться к игре
+
+Присоединитесь к ожидающей игре с той же ставкой, кости будут брошены сразу. Также можно нажать кнопку входа под объявлением игры.
+
+Пример:
+• /join GAME1672531200001
+{{if .InGroup}}
+📌 В этом чате:
+{{- if .Sequential}}
+• Последовательный режим: пока идёт игра, входы ждут в очереди и начинаются автоматически; у каждого игрока одно место в очереди
+{{- else if gt .MaxActiveGames 0}}
+• Когда идут {{.MaxActiveGames}} игр, входы ставятся в очередь
+{{- else}}
+• Число одновременных игр не ограничено
+{{- end}}
+{{- if gt .Cooldown 0}}
+• Пауза: {{.Cooldown}} секунд, входы во время паузы отклоняются
+{{- end}}
+{{end}}`},
+		"games": {Title: "📋 Игры", Text: `📋 /games — список ожидающих игр
+
+Показывает игры этого чата, ожидающие соперника, с кнопками входа и обновлением на месте.
+
+🏆 /rank [day|week|month] — рейтинг чата по чистой прибыли с числом побед и игр; кнопки переключают период
+
+🔐 /verify <ID игры> — при создании игра публикует хеш своего seed, а после завершения раскрывает его; выпавшие при расчёте кости сверяются с сообщениями с костями в чате
+
+Примеры:
+• /games
+• /rank week
+• /verify <ID игры>`},
+		"table": {Title: "🪑 Стол", Text: `🪑 /table [ставка] — открыть быстрый стол
+
+{{.TableMinPlayers}}-{{.TableMaxPlayers}} игроков вносят одинаковую ставку и один раз бросают три кости; двое лучших делят банк (60% / 40%). Создатель может бросить кости, когда за столом {{.TableMinPlayers}} игроков, полный стол бросает автоматически, а стол без броска в течение {{.TableTimeout}} минут закрывается с полным возвратом.
+
+⚔️ /dicemulti <ставка> <макс. игроков> — битва по тем же правилам, но весь банк забирает лучший бросок (при равенстве банк делится)
+
+Примеры:
+• /table — ставка по умолчанию
+• /table 50 — ставка 50 монет
+• /dicemulti 100 8 — ставка 100 монет, до 8 игроков
+{{if .InGroup}}
+📌 Диапазон ставок в этом чате: {{.MinBet}} - {{.MaxBet}} монет
+{{end}}`},
+		"balance": {Title: "💰 Баланс", Text: `💰 /balance — проверить баланс
+
+Показывает основной и бонусный баланс с кнопкой обновления. При ставке сначала расходуется бонусный баланс.
+{{if .DailyBonus}}
+🎁 /daily — получайте {{.DailyBonus}} бесплатных монет каждые 24 часа, с бонусом за дни подряд
+{{end}}👥 /invite — ваша пригласительная ссылка; вы и друзья получаете награды, когда они регистрируются и играют
+
+Пример:
+• /balance`},
+	},
+}
+
+// HelpTopics 返回可查看的帮助主题（不含目录）
+func HelpTopics() []string {
+	return append([]string(nil), helpTopicOrder...)
+}
+
+// LookupHelpTopic 将 /help 参数（如 dice、/join）归一为帮助主题，空参数对应目录
+func LookupHelpTopic(arg string) (string, bool) {
+	topic := strings.TrimPrefix(strings.ToLower(strings.TrimSpace(arg)), "/")
+	if topic == "" {
+		return HelpIndexTopic, true
+	}
+	_, ok := defaultHelpCatalog[DefaultLanguage][topic]
+	return topic, ok
+}
+
+// RenderHelp 渲染指定语言的帮助主题，缺少该语言时使用默认语言
+func RenderHelp(lang, topic string, data HelpData) (string, error) {
+	entry, ok := helpEntry(lang, topic)
+	if !ok {
+		return "", fmt.Errorf("未知的帮助主题: %s", topic)
+	}
+
+	tmpl, err := template.New("help").Option("missingkey=error").Parse(entry.Text)
+	if err != nil {
+		return "", fmt.Errorf("解析帮助模板失败: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染帮助模板失败: %v", err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// FormatUnknownHelpTopic 未知帮助主题的提示
+func FormatUnknownHelpTopic(lang, topic string) string {
+	return T(lang, "help.unknown_topic", Args{"Topic": topic, "Topics": strings.Join(helpTopicOrder, ", ")})
+}
+
+// BuildHelpMessage 带主题跳转按钮的帮助消息
+func BuildHelpMessage(codec *callback.Codec, chatID int64, lang, topic string, data HelpData) (tgbotapi.MessageConfig, error) {
+	text, err := RenderHelp(lang, topic, data)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	markup, err := helpKeyboard(codec, lang, topic)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = markup
+	return msg, nil
+}
+
+// BuildHelpEdit 点击主题按钮后原地切换帮助内容
+func BuildHelpEdit(codec *callback.Codec, chatID int64, messageID int, lang, topic string, data HelpData) (tgbotapi.EditMessageTextConfig, error) {
+	text, err := RenderHelp(lang, topic, data)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	markup, err := helpKeyboard(codec, lang, topic)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	return tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, markup), nil
+}
+
+// ParseHelpCallback 校验并解析帮助主题按钮回调，返回主题名
+func ParseHelpCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != HelpTopicAction || len(parsed.Args) != 1 {
+		return "", callback.ErrMalformed
+	}
+	topic, ok := LookupHelpTopic(parsed.Arg(0))
+	if !ok || topic != parsed.Arg(0) {
+		return "", callback.ErrMalformed
+	}
+	return topic, nil
+}
+
+// helpKeyboard 其他主题的跳转按钮，查看主题时附带返回目录的按钮
+func helpKeyboard(codec *callback.Codec, lang, current string) (tgbotapi.InlineKeyboardMarkup, error) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, topic := range helpTopicOrder {
+		if topic == current {
+			continue
+		}
+		button, err := helpButton(codec, lang, topic)
+		if err != nil {
+			return tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		row = append(row, button)
+		if len(row) == helpTopicsPerRow {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+
+	if current != HelpIndexTopic {
+		button, err := helpButton(codec, lang, HelpIndexTopic)
+		if err != nil {
+			return tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(button))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...), nil
+}
+
+func helpButton(codec *callback.Codec, lang, topic string) (tgbotapi.InlineKeyboardButton, error) {
+	data, err := codec.Encode(HelpTopicAction, topic)
+	if err != nil {
+		return tgbotapi.InlineKeyboardButton{}, err
+	}
+	entry, _ := helpEntry(lang, topic)
+	return tgbotapi.NewInlineKeyboardButtonData(entry.Title, data), nil
+}
+
+// helpEntry 查找帮助主题，缺少该语言的翻译时使用默认语言
+func helpEntry(lang, topic string) (helpTopic, bool) {
+	if entry, ok := defaultHelpCatalog[NormalizeLanguage(lang)][topic]; ok {
+		return entry, true
+	}
+	entry, ok := defaultHelpCatalog[DefaultLanguage][topic]
+	return entry, ok
+}
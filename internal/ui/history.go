@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// HistoryCommand 查看最近对局记录的命令
+	HistoryCommand = "history"
+	// HistoryAction 对局记录翻页按钮
+	HistoryAction = "history"
+)
+
+const (
+	// HistoryGames /history 可翻阅的最近对局数
+	HistoryGames = 20
+	// HistoryPageSize 每页显示的对局数
+	HistoryPageSize = 5
+)
+
+// HistoryPages 对局记录的总页数，没有记录时为 1 页
+func HistoryPages(total int) int {
+	if total <= 0 {
+		return 1
+	}
+	return (total + HistoryPageSize - 1) / HistoryPageSize
+}
+
+// FormatGameHistory 构建用户最近对局记录的第 page 页（从 0 开始），names 为对手的显示名称
+func FormatGameHistory(userID int64, games []*models.Game, names map[int64]string, page int) string {
+	if len(games) == 0 {
+		return "📜 我的对局记录\n\n还没有对局记录，发送 /dice <金额> 来一局吧"
+	}
+
+	pages := HistoryPages(len(games))
+	var b strings.Builder
+	fmt.Fprintf(&b, "📜 我的对局记录（第 %d/%d 页）\n", page+1, pages)
+
+	start := page * HistoryPageSize
+	end := start + HistoryPageSize
+	if end > len(games) {
+		end = len(games)
+	}
+	for i, game := range games[start:end] {
+		opponent := "等待对手"
+		var opponentID int64
+		if game.Player2ID != nil {
+			opponentID = *game.Player2ID
+			if opponentID == userID {
+				opponentID = game.Player1ID
+			}
+			opponent = names[opponentID]
+		}
+
+		fmt.Fprintf(&b, "\n%d. %s vs %s\n", start+i+1, historyResult(userID, game), opponent)
+		if dice := historyDice(userID, game); dice != "" {
+			fmt.Fprintf(&b, "   🎲 %s\n", dice)
+		}
+		fmt.Fprintf(&b, "   💰 %s｜🕐 %s\n", historyAmount(userID, game), game.CreatedAt.Format("01-02 15:04"))
+	}
+	return b.String()
+}
+
+// BuildHistoryMessage 对局记录消息，记录多于一页时附带翻页按钮
+func BuildHistoryMessage(codec *callback.Codec, chatID, userID int64, page int, games []*models.Game, names map[int64]string) (tgbotapi.MessageConfig, error) {
+	msg := tgbotapi.NewMessage(chatID, FormatGameHistory(userID, games, names, page))
+	markup, err := buildHistoryKeyboard(codec, userID, page, len(games))
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	if markup != nil {
+		msg.ReplyMarkup = *markup
+	}
+	return msg, nil
+}
+
+// BuildHistoryEdit 将已发送的对局记录消息原地翻到第 page 页
+func BuildHistoryEdit(codec *callback.Codec, chatID int64, messageID int, userID int64, page int, games []*models.Game, names map[int64]string) (tgbotapi.EditMessageTextConfig, error) {
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, FormatGameHistory(userID, games, names, page))
+	markup, err := buildHistoryKeyboard(codec, userID, page, len(games))
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	edit.ReplyMarkup = markup
+	return edit, nil
+}
+
+// ParseHistoryCallback 解析翻页按钮，返回记录所属的用户和目标页
+func ParseHistoryCallback(codec *callback.Codec, data string) (userID int64, page int, err error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	if parsed.Action != HistoryAction || len(parsed.Args) != 2 {
+		return 0, 0, callback.ErrMalformed
+	}
+	userID, err = parsed.Int64(0)
+	if err != nil {
+		return 0, 0, callback.ErrMalformed
+	}
+	page, err = strconv.Atoi(parsed.Args[1])
+	if err != nil || page < 0 {
+		return 0, 0, callback.ErrMalformed
+	}
+	return userID, page, nil
+}
+
+// buildHistoryKeyboard 上一页/下一页按钮，只有一页时返回 nil
+func buildHistoryKeyboard(codec *callback.Codec, userID int64, page, total int) (*tgbotapi.InlineKeyboardMarkup, error) {
+	pages := HistoryPages(total)
+	if pages <= 1 {
+		return nil, nil
+	}
+
+	var row []tgbotapi.InlineKeyboardButton
+	button := func(label string, target int) error {
+		data, err := codec.Encode(HistoryAction, strconv.FormatInt(userID, 10), strconv.Itoa(target))
+		if err != nil {
+			return err
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, data))
+		return nil
+	}
+	if page > 0 {
+		if err := button("⬅️ 上一页", page-1); err != nil {
+			return nil, err
+		}
+	}
+	if page < pages-1 {
+		if err := button("下一页 ➡️", page+1); err != nil {
+			return nil, err
+		}
+	}
+	markup := tgbotapi.NewInlineKeyboardMarkup(row)
+	return &markup, nil
+}
+
+// historyResult 对局结果：胜、负、平、认输或已退款
+func historyResult(userID int64, game *models.Game) string {
+	won := game.WinnerID != nil && *game.WinnerID == userID
+	switch game.Status {
+	case models.GameStatusFinished:
+		switch {
+		case game.WinnerID == nil:
+			return "🤝 平局"
+		case won:
+			return "🏆 胜"
+		default:
+			return "💔 负"
+		}
+	case models.GameStatusSurrendered:
+		if won {
+			return "🏳️ 对手认输"
+		}
+		return "🏳️ 认输"
+	case models.GameStatusCancelled, models.GameStatusExpired:
+		return "↩️ 已退款"
+	default:
+		return "⏳ 进行中"
+	}
+}
+
+// historyDice 用户和对手的点数，尚未开骰时为空
+func historyDice(userID int64, game *models.Game) string {
+	p1, ok1 := diceTotal(game.Player1Dice1, game.Player1Dice2, game.Player1Dice3)
+	p2, ok2 := diceTotal(game.Player2Dice1, game.Player2Dice2, game.Player2Dice3)
+	if !ok1 || !ok2 {
+		return ""
+	}
+	if game.Player1ID != userID {
+		p1, p2 = p2, p1
+	}
+	return fmt.Sprintf("%s : %s", p1, p2)
+}
+
+// diceTotal 三颗骰子的点数及总点数
+func diceTotal(d1, d2, d3 *int) (string, bool) {
+	if d1 == nil || d2 == nil || d3 == nil {
+		return "", false
+	}
+	return fmt.Sprintf("%d+%d+%d=%d", *d1, *d2, *d3, *d1+*d2+*d3), true
+}
+
+// historyAmount 用户在对局中的输赢；认输、退款和进行中的对局只显示下注额
+func historyAmount(userID int64, game *models.Game) string {
+	if game.Status == models.GameStatusFinished {
+		switch {
+		case game.WinnerID == nil:
+			return "±0 金币"
+		case *game.WinnerID == userID:
+			return "+" + utils.FormatAmount(game.BetAmount-game.Commission) + " 金币"
+		default:
+			return "-" + utils.FormatAmount(game.BetAmount) + " 金币"
+		}
+	}
+	return "下注 " + utils.FormatAmount(game.BetAmount) + " 金币"
+}
@@ -0,0 +1,159 @@
+package ui
+
+import (
+	"bytes"
+	"log"
+	"sort"
+	"text/template"
+)
+
+// messageCatalog 内置的消息目录，按语言索引，值为 text/template 模板。
+// 新增语言时需补全全部键，并在 defaultWelcomeTemplates 和 languageNames 中加入该语言
+var messageCatalog = map[string]map[string]string{
+	"zh": {
+		"access.banned":       "🙏 抱歉，您的账户已被暂停使用{{if .Reason}}\n📝 原因：{{.Reason}}{{end}}\n如有疑问请联系管理员",
+		"access.admin_only":   "⛔ 该功能仅限管理员使用",
+		"access.group_only":   "👥 该功能仅限在群组中使用",
+		"access.chat_admin":   "⛔ 该功能仅限群管理员使用",
+		"access.chat_setup":   "⚙️ 本群尚未启用游戏，请管理员先发送 /setup",
+		"access.rate_limited": "⏳ 操作太频繁，请稍后再试",
+		"access.private_only": "🔒 该功能涉及个人信息，请私聊机器人使用",
+
+		"language.current":     "🌐 本群语言：{{.Name}}\n📝 {{if .Manual}}由管理员手动指定{{else}}根据群内消息自动检测{{end}}\n\n{{.Usage}}",
+		"language.usage":       "用法：/language <{{.Options}}>",
+		"language.set":         "✅ 本群语言已设置为 {{.Name}}",
+		"language.auto":        "✅ 已恢复自动检测，当前语言：{{.Name}}",
+		"language.unsupported": "❌ 不支持的语言\n{{.Usage}}",
+
+		"cooldown.waiting":  "⏳ 上一局刚结束，休息一下吧\n⌛ {{.Minutes}} 分 {{printf \"%02d\" .Seconds}} 秒后可开始下一局",
+		"cooldown.off":      "🧊 本群未设置对局冷却\n\n{{.Usage}}",
+		"cooldown.current":  "🧊 本群对局冷却：{{.Cooldown}}\n📝 管理员不受冷却限制\n\n{{.Usage}}",
+		"cooldown.usage":    "用法：/cooldown <秒数|2m|{{.Off}}>",
+		"cooldown.invalid":  "❌ 无效的冷却时间\n{{.Usage}}",
+		"cooldown.disabled": "✅ 已取消本群对局冷却",
+		"cooldown.set":      "✅ 本群每局结束后需等待 {{.Cooldown}} 才能开始下一局",
+
+		"abort.stalled":  "⚠️ 对局 {{.GameID}} 开骰超时，未能完成结算",
+		"abort.admin":    "🛑 对局 {{.GameID}} 已被管理员中止",
+		"abort.shutdown": "🔧 机器人维护重启，对局 {{.GameID}} 未能在重启前完成结算",
+		"abort.refunded": "对局已中止，双方各退还下注 {{.Amount}} 金币\n本群可以继续发起新的对局",
+
+		"help.unknown_topic": "❌ 没有「{{.Topic}}」的帮助\n可选主题：{{.Topics}}",
+	},
+	"en": {
+		"access.banned":       "🙏 Sorry, your account has been suspended{{if .Reason}}\n📝 Reason: {{.Reason}}{{end}}\nPlease contact an admin if you have any questions",
+		"access.admin_only":   "⛔ This feature is for bot admins only",
+		"access.group_only":   "👥 This feature is only available in groups",
+		"access.chat_admin":   "⛔ This feature is for group admins only",
+		"access.chat_setup":   "⚙️ Games are not enabled in this chat yet, an admin needs to send /setup first",
+		"access.rate_limited": "⏳ Too many requests, please try again later",
+		"access.private_only": "🔒 This feature involves personal information, please message the bot privately",
+
+		"language.current":     "🌐 Chat language: {{.Name}}\n📝 {{if .Manual}}Set manually by an admin{{else}}Detected automatically from chat messages{{end}}\n\n{{.Usage}}",
+		"language.usage":       "Usage: /language <{{.Options}}>",
+		"language.set":         "✅ Chat language set to {{.Name}}",
+		"language.auto":        "✅ Automatic detection restored, current language: {{.Name}}",
+		"language.unsupported": "❌ Unsupported language\n{{.Usage}}",
+
+		"cooldown.waiting":  "⏳ The last game just ended, take a short break\n⌛ The next game can start in {{.Minutes}}:{{printf \"%02d\" .Seconds}}",
+		"cooldown.off":      "🧊 No game cooldown is set in this chat\n\n{{.Usage}}",
+		"cooldown.current":  "🧊 Game cooldown in this chat: {{.Cooldown}}\n📝 Admins are not affected by the cooldown\n\n{{.Usage}}",
+		"cooldown.usage":    "Usage: /cooldown <seconds|2m|{{.Off}}>",
+		"cooldown.invalid":  "❌ Invalid cooldown\n{{.Usage}}",
+		"cooldown.disabled": "✅ Game cooldown disabled in this chat",
+		"cooldown.set":      "✅ Players now wait {{.Cooldown}} after each game before starting the next one",
+
+		"abort.stalled":  "⚠️ Game {{.GameID}} timed out after rolling and could not be settled",
+		"abort.admin":    "🛑 Game {{.GameID}} was stopped by an admin",
+		"abort.shutdown": "🔧 The bot restarted for maintenance before game {{.GameID}} could be settled",
+		"abort.refunded": "The game was cancelled and both players were refunded {{.Amount}} coins\nYou can start a new game in this chat",
+
+		"help.unknown_topic": "❌ No help for \"{{.Topic}}\"\nAvailable topics: {{.Topics}}",
+	},
+	"ru": {
+		"access.banned":       "🙏 Извините, ваш аккаунт заблокирован{{if .Reason}}\n📝 Причина: {{.Reason}}{{end}}\nЕсли у вас есть вопросы, свяжитесь с администратором",
+		"access.admin_only":   "⛔ Эта функция доступна только администраторам бота",
+		"access.group_only":   "👥 Эта функция доступна только в группах",
+		"access.chat_admin":   "⛔ Эта функция доступна только администраторам группы",
+		"access.chat_setup":   "⚙️ Игры в этом чате ещё не включены, администратору нужно отправить /setup",
+		"access.rate_limited": "⏳ Слишком много запросов, попробуйте позже",
+		"access.private_only": "🔒 Эта функция связана с личными данными, напишите боту в личные сообщения",
+
+		"language.current":     "🌐 Язык чата: {{.Name}}\n📝 {{if .Manual}}Задан администратором вручную{{else}}Определён автоматически по сообщениям в чате{{end}}\n\n{{.Usage}}",
+		"language.usage":       "Использование: /language <{{.Options}}>",
+		"language.set":         "✅ Язык чата изменён на {{.Name}}",
+		"language.auto":        "✅ Автоопределение включено, текущий язык: {{.Name}}",
+		"language.unsupported": "❌ Язык не поддерживается\n{{.Usage}}",
+
+		"cooldown.waiting":  "⏳ Предыдущая игра только что закончилась, сделайте паузу\n⌛ Следующую игру можно начать через {{.Minutes}}:{{printf \"%02d\" .Seconds}}",
+		"cooldown.off":      "🧊 Пауза между играми в этом чате не задана\n\n{{.Usage}}",
+		"cooldown.current":  "🧊 Пауза между играми в этом чате: {{.Cooldown}}\n📝 На администраторов пауза не распространяется\n\n{{.Usage}}",
+		"cooldown.usage":    "Использование: /cooldown <секунды|2m|{{.Off}}>",
+		"cooldown.invalid":  "❌ Неверная длительность паузы\n{{.Usage}}",
+		"cooldown.disabled": "✅ Пауза между играми в этом чате отключена",
+		"cooldown.set":      "✅ Теперь после каждой игры нужно подождать {{.Cooldown}}, прежде чем начать следующую",
+
+		"abort.stalled":  "⚠️ Игра {{.GameID}} не была рассчитана вовремя после броска",
+		"abort.admin":    "🛑 Игра {{.GameID}} остановлена администратором",
+		"abort.shutdown": "🔧 Бот перезапустился на обслуживание до расчёта игры {{.GameID}}",
+		"abort.refunded": "Игра отменена, каждому игроку возвращено {{.Amount}} монет\nВ этом чате можно начать новую игру",
+
+		"help.unknown_topic": "❌ Нет справки по «{{.Topic}}»\nДоступные темы: {{.Topics}}",
+	},
+}
+
+// Args 消息模板的变量
+type Args map[string]interface{}
+
+// messageTemplates 预先解析的消息模板，模板有误时启动即失败
+var messageTemplates = compileMessages(messageCatalog)
+
+// compileMessages 解析消息目录中的全部模板
+func compileMessages(catalog map[string]map[string]string) map[string]map[string]*template.Template {
+	compiled := make(map[string]map[string]*template.Template, len(catalog))
+	for lang, messages := range catalog {
+		compiled[lang] = make(map[string]*template.Template, len(messages))
+		for key, text := range messages {
+			compiled[lang][key] = template.Must(template.New(lang + ":" + key).Option("missingkey=error").Parse(text))
+		}
+	}
+	return compiled
+}
+
+// T 按语言渲染消息目录中的消息，缺少该语言的翻译时使用默认语言，未知的键原样返回
+func T(lang, key string, data interface{}) string {
+	tmpl, ok := messageTemplates[NormalizeLanguage(lang)][key]
+	if !ok {
+		if tmpl, ok = messageTemplates[DefaultLanguage][key]; !ok {
+			log.Printf("⚠️ 消息目录中缺少 %s", key)
+			return key
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Printf("⚠️ 渲染消息 %s 失败: %v", tmpl.Name(), err)
+		return key
+	}
+	return buf.String()
+}
+
+// MissingMessages 返回各语言相对默认语言缺少的消息键，用于检查翻译是否完整
+func MissingMessages() map[string][]string {
+	missing := make(map[string][]string)
+	for lang, messages := range messageCatalog {
+		for key := range messageCatalog[DefaultLanguage] {
+			if _, ok := messages[key]; !ok {
+				missing[lang] = append(missing[lang], key)
+			}
+		}
+		sort.Strings(missing[lang])
+	}
+	return missing
+}
+
+// catalogLanguage 消息目录中是否有该语言
+func catalogLanguage(lang string) bool {
+	_, ok := messageCatalog[lang]
+	return ok
+}
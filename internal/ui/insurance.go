@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// InsuranceAction 投保按钮的回调动作
+const InsuranceAction = "insure"
+
+// BuildInsuranceRow 构建建局键盘中的投保按钮行，追加在加入按钮下方，仅发起者可点击
+func BuildInsuranceRow(codec *callback.Codec, gameID string, premium, coverage int64) ([]tgbotapi.InlineKeyboardButton, error) {
+	data, err := codec.Encode(InsuranceAction, gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	text := fmt.Sprintf("🛡️ 投保 %s 金币（输局退 %s）", utils.FormatAmount(premium), utils.FormatAmount(coverage))
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(text, data),
+	), nil
+}
+
+// ParseInsuranceCallback 校验并解析投保回调，返回游戏ID
+func ParseInsuranceCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != InsuranceAction || len(parsed.Args) != 1 || utils.ValidateGameID(parsed.Arg(0)) != nil {
+		return "", callback.ErrMalformed
+	}
+	return parsed.Arg(0), nil
+}
+
+// FormatInsuranceOffer 建局公告中的保险说明
+func FormatInsuranceOffer(premium, coverage int64) string {
+	return fmt.Sprintf("🛡️ 可选保险：支付 %s 金币，输局退还 %s 金币（平局退还保险费；认输不赔付，开局后保险费不退）", utils.FormatAmount(premium), utils.FormatAmount(coverage))
+}
+
+// FormatInsurancePurchased 投保成功的提示
+func FormatInsurancePurchased(premium, coverage int64) string {
+	return fmt.Sprintf("✅ 投保成功，已扣除保险费 %s 金币，输局将退还 %s 金币", utils.FormatAmount(premium), utils.FormatAmount(coverage))
+}
+
+// FormatInsurancePayout 结算消息中的保险赔付行
+func FormatInsurancePayout(userName string, payout int64) string {
+	return fmt.Sprintf("🛡️ %s 获得保险赔付 %s 金币", userName, utils.FormatAmount(payout))
+}
@@ -0,0 +1,131 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// 对局已被抢先加入时提供的后续操作
+const (
+	// JoinAction 加入指定对局
+	JoinAction = "join"
+	// CreateStakeAction 以相同下注额创建新对局
+	CreateStakeAction = "create_stake"
+	// WaitingGamesAction 查看本群等待中的对局
+	WaitingGamesAction = "waiting"
+	// RefreshGamesAction 原地刷新等待中的对局列表
+	RefreshGamesAction = "refresh_games"
+)
+
+// GamesCommand 查看本群等待中对局的命令
+const GamesCommand = "games"
+
+// GameTakenText 对局已被抢先加入时的回调提示
+const GameTakenText = "对局已被抢先加入"
+
+// maxWaitingButtons 等待中对局列表最多显示的加入按钮数
+const maxWaitingButtons = 5
+
+// BuildGameTakenMessage 对局已被抢先加入时发给点击者的提示，附带同额开局和查看其他对局的按钮
+func BuildGameTakenMessage(codec *callback.Codec, chatID int64, betAmount int64) (tgbotapi.MessageConfig, error) {
+	createData, err := codec.Encode(CreateStakeAction, strconv.FormatInt(betAmount, 10))
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	waitingData, err := codec.Encode(WaitingGamesAction)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("😅 %s，手慢了一步！\n\n可以发起一局同样 %s 金币的对局，或看看其他等待中的对局", GameTakenText, utils.FormatAmount(betAmount)))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🎲 发起 %s 金币对局", utils.FormatAmount(betAmount)), createData),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📋 查看等待中的对局", waitingData),
+		),
+	)
+	return msg, nil
+}
+
+// ParseCreateStakeCallback 校验并解析同额开局回调，返回下注额
+func ParseCreateStakeCallback(codec *callback.Codec, data string) (int64, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return 0, err
+	}
+	if parsed.Action != CreateStakeAction || len(parsed.Args) != 1 {
+		return 0, callback.ErrMalformed
+	}
+	amount, err := parsed.Int64(0)
+	if err != nil || amount <= 0 {
+		return 0, callback.ErrMalformed
+	}
+	return amount, nil
+}
+
+// BuildWaitingGamesMessage 本群等待中的对局列表，excludeUserID 发起的对局不显示加入按钮
+func BuildWaitingGamesMessage(codec *callback.Codec, chatID int64, games []*models.Game, excludeUserID int64) (tgbotapi.MessageConfig, error) {
+	text, markup, err := buildWaitingGames(codec, games, excludeUserID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = markup
+	return msg, nil
+}
+
+// BuildWaitingGamesEdit 将已发送的对局列表消息原地更新为最新列表
+func BuildWaitingGamesEdit(codec *callback.Codec, chatID int64, messageID int, games []*models.Game, excludeUserID int64) (tgbotapi.EditMessageTextConfig, error) {
+	text, markup, err := buildWaitingGames(codec, games, excludeUserID)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	return tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, markup), nil
+}
+
+// buildWaitingGames 对局列表的文本和键盘，末尾附带刷新按钮
+func buildWaitingGames(codec *callback.Codec, games []*models.Game, excludeUserID int64) (string, tgbotapi.InlineKeyboardMarkup, error) {
+	refreshData, err := codec.Encode(RefreshGamesAction)
+	if err != nil {
+		return "", tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	refreshRow := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 刷新战场", refreshData),
+	)
+
+	if len(games) == 0 {
+		return "📋 当前没有等待中的对局，发送 /dice <金额> 发起一局吧", tgbotapi.NewInlineKeyboardMarkup(refreshRow), nil
+	}
+
+	var text strings.Builder
+	text.WriteString("📋 等待中的对局\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, game := range games {
+		text.WriteString(fmt.Sprintf("\n%d. 🎲 %s 金币", i+1, utils.FormatAmount(game.BetAmount)))
+		if game.Player1ID == excludeUserID || len(rows) >= maxWaitingButtons {
+			continue
+		}
+
+		data, err := codec.Encode(JoinAction, game.ID)
+		if err != nil {
+			return "", tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("加入第 %d 局（%s 金币）", i+1, utils.FormatAmount(game.BetAmount)), data),
+		))
+	}
+
+	rows = append(rows, refreshRow)
+	return text.String(), tgbotapi.NewInlineKeyboardMarkup(rows...), nil
+}
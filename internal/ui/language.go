@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// LanguageCommand 查看或设置群组语言的命令
+const LanguageCommand = "language"
+
+// LanguageAuto /language 参数，恢复自动检测
+const LanguageAuto = "auto"
+
+// languageNames 内置语言的显示名称
+var languageNames = map[string]string{
+	"zh": "中文",
+	"en": "English",
+	"ru": "Русский",
+}
+
+// SupportedLanguages 返回内置支持的语言代码
+func SupportedLanguages() []string {
+	languages := make([]string, 0, len(messageCatalog))
+	for lang := range messageCatalog {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// LanguageName 语言的显示名称
+func LanguageName(lang string) string {
+	lang = NormalizeLanguage(lang)
+	if name, ok := languageNames[lang]; ok {
+		return fmt.Sprintf("%s (%s)", name, lang)
+	}
+	return lang
+}
+
+// FormatChatLanguage 群组当前语言及设置方式，以该语言显示
+func FormatChatLanguage(lang string, manual bool) string {
+	return T(lang, "language.current", Args{
+		"Name":   LanguageName(lang),
+		"Manual": manual,
+		"Usage":  FormatLanguageUsage(lang),
+	})
+}
+
+// FormatLanguageUsage /language 命令用法
+func FormatLanguageUsage(lang string) string {
+	options := strings.Join(append(SupportedLanguages(), LanguageAuto), "|")
+	return T(lang, "language.usage", Args{"Options": options})
+}
+
+// FormatUnsupportedLanguage 指定了不支持的语言时的提示，以群组当前语言显示
+func FormatUnsupportedLanguage(lang string) string {
+	return T(lang, "language.unsupported", Args{"Usage": FormatLanguageUsage(lang)})
+}
+
+// FormatChatLanguageSet 设置群组语言的结果，以新语言显示
+func FormatChatLanguageSet(lang string, manual bool) string {
+	key := "language.set"
+	if !manual {
+		key = "language.auto"
+	}
+	return T(lang, key, Args{"Name": LanguageName(lang)})
+}
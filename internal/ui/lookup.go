@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// FormatUserLookup 格式化 /whois 管理员命令的查询结果
+func FormatUserLookup(query string, user *models.User, history []*models.UsernameHistory, formerOwners []int64) string {
+	query = "@" + strings.TrimPrefix(strings.TrimSpace(query), "@")
+
+	if user == nil {
+		if len(formerOwners) == 0 {
+			return fmt.Sprintf("❓ 未找到用户 %s", query)
+		}
+		ids := make([]string, len(formerOwners))
+		for i, id := range formerOwners {
+			ids[i] = fmt.Sprintf("<code>%d</code>", id)
+		}
+		return fmt.Sprintf("❓ 当前没有用户使用 %s\n🕘 曾使用该用户名的用户ID：%s", query, strings.Join(ids, "、"))
+	}
+
+	var builder strings.Builder
+	builder.WriteString(fmt.Sprintf("👤 %s\n🆔 用户ID：<code>%d</code>\n📛 昵称：%s %s\n💰 余额：%s",
+		query, user.ID, user.FirstName, user.LastName, utils.FormatAmount(user.Balance)))
+
+	if len(history) > 1 {
+		builder.WriteString("\n🕘 历史用户名：")
+		for _, item := range history {
+			if strings.EqualFold(item.Username, user.Username) {
+				continue
+			}
+			builder.WriteString(fmt.Sprintf("\n  • @%s（%s 前使用）", item.Username, item.LastSeen.Format("2006-01-02")))
+		}
+	}
+	return builder.String()
+}
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"telegram-dice-bot/internal/telegram"
+
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
@@ -19,9 +21,9 @@ func NewMenuHandler() *MenuHandler {
 }
 
 // HandleMessage 处理消息并根据内容选择菜单
-func (h *MenuHandler) HandleMessage(msg *tgbotapi.Message, bot *tgbotapi.BotAPI, userID int64, isAdmin bool) (tgbotapi.Chattable, error) {
+func (h *MenuHandler) HandleMessage(msg *tgbotapi.Message, bot telegram.Client, userID int64, isAdmin bool) (tgbotapi.Chattable, error) {
 	// 检查消息文本
-	if msg.Text == "" {
+	if msg == nil || msg.Chat == nil || msg.Text == "" {
 		return nil, nil
 	}
 
@@ -116,7 +118,17 @@ func (h *MenuHandler) HandleMessage(msg *tgbotapi.Message, bot *tgbotapi.BotAPI,
 }
 
 // HandleCallbackQuery 处理回调查询
-func (h *MenuHandler) HandleCallbackQuery(query *tgbotapi.CallbackQuery, bot *tgbotapi.BotAPI, userID int64, isAdmin bool) (tgbotapi.Chattable, error) {
+func (h *MenuHandler) HandleCallbackQuery(query *tgbotapi.CallbackQuery, bot telegram.Client, userID int64, isAdmin bool) (tgbotapi.Chattable, error) {
+	if query == nil {
+		return nil, nil
+	}
+
+	// 内联模式消息的回调不携带 Message，无法确定回复的聊天
+	if query.Message == nil || query.Message.Chat == nil {
+		bot.Request(tgbotapi.NewCallbackWithAlert(query.ID, "⚠️ 该按钮仅支持在聊天中使用"))
+		return nil, nil
+	}
+
 	// 处理内联键盘按钮点击
 	data := query.Data
 	chatID := query.Message.Chat.ID
@@ -249,7 +261,7 @@ func (h *MenuHandler) SetMenuType(userID int64, menuType MenuType) {
 
 // 处理各种菜单选项的辅助函数
 
-func (h *MenuHandler) handleStartGame(userID int64, bot *tgbotapi.BotAPI) (tgbotapi.Chattable, error) {
+func (h *MenuHandler) handleStartGame(userID int64, bot telegram.Client) (tgbotapi.Chattable, error) {
 	// 这里实现游戏开始逻辑
 	msg := tgbotapi.NewMessage(userID, "🎲 请选择游戏模式和下注金额：")
 
@@ -269,13 +281,13 @@ func (h *MenuHandler) handleStartGame(userID int64, bot *tgbotapi.BotAPI) (tgbot
 	return msg, nil
 }
 
-func (h *MenuHandler) handleWinRateQuery(userID int64, bot *tgbotapi.BotAPI) (tgbotapi.Chattable, error) {
+func (h *MenuHandler) handleWinRateQuery(userID int64, bot telegram.Client) (tgbotapi.Chattable, error) {
 	// 这里实现胜率查询逻辑
 	msg := tgbotapi.NewMessage(userID, "🔍 您的游戏胜率统计：\n\n总场次：0\n胜利：0\n失败：0\n胜率：0%\n\n暂无游戏记录，开始游戏吧！")
 	return msg, nil
 }
 
-func (h *MenuHandler) handleBalanceQuery(userID int64, bot *tgbotapi.BotAPI) (tgbotapi.Chattable, error) {
+func (h *MenuHandler) handleBalanceQuery(userID int64, bot telegram.Client) (tgbotapi.Chattable, error) {
 	// 这里实现余额查询逻辑
 	msg := tgbotapi.NewMessage(userID, "💰 您的账户余额：\n\n当前余额：0💎\n\n可通过\"财务管理\"菜单进行充值和提现操作。")
 
@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+)
+
+// NetConfigCommand 查看或调整网络加速器阈值的管理员命令
+const NetConfigCommand = "netconfig"
+
+// FormatNetworkThresholds 网络加速器当前的切换阈值和缓存时长
+func FormatNetworkThresholds(errorRate float64, latency, checkInterval, cacheTTL time.Duration) string {
+	return fmt.Sprintf(`🌐 网络加速器阈值
+❗ 错误率阈值：%.0f%%
+🐢 延迟阈值：%v
+⏱️ 检查间隔：%v
+🗂️ 缓存时长：%v
+
+%s`, errorRate*100, latency, checkInterval, cacheTTL, FormatNetConfigUsage())
+}
+
+// FormatNetConfigUsage /netconfig 命令用法
+func FormatNetConfigUsage() string {
+	return "用法：/netconfig error_rate=0.3 latency=200ms check_interval=30s cache_ttl=5m（可只填需要修改的项）"
+}
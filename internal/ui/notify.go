@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// GameExpiredDM 对局超时退款后私信发起者的通知
+func GameExpiredDM(gameID string, amount, balance int64) string {
+	return fmt.Sprintf(`⏰ 你发起的对局已超时，无人加入
+
+🆔 对局：%s
+💰 已退还：%s 金币
+💳 当前余额：%s 金币
+
+🔕 可在个人设置中关闭私信通知`, gameID, utils.FormatAmount(amount), utils.FormatAmount(balance))
+}
+
+// SettlementDelayedDM 开骰后迟迟未结算时私信双方的说明，refundIn 后仍未结算将自动退款
+func SettlementDelayedDM(gameID string, amount int64, refundIn time.Duration) string {
+	return fmt.Sprintf(`🐢 你参与的对局结算出现延迟
+
+🆔 对局：%s
+💰 下注额：%s 金币
+
+骰子已经掷出，结算暂时未能完成，可能是 Telegram 服务波动所致
+⏳ 若 %s 内仍未完成结算，对局将自动中止并全额退还双方下注，无需联系客服
+
+🔕 可在个人设置中关闭私信通知`, gameID, utils.FormatAmount(amount), formatWaited(refundIn))
+}
+
+// gameAbortReasons 对局中止原因在私信中的说明
+var gameAbortReasons = map[string]string{
+	models.GameAbortStalled:  "⚠️ 你参与的对局开骰超时，已中止",
+	models.GameAbortAdmin:    "🛑 你参与的对局已被管理员中止",
+	models.GameAbortShutdown: "🔧 机器人维护重启，你参与的对局已中止",
+}
+
+// gameAbortMessages 对局中止原因在群内公告中的消息键
+var gameAbortMessages = map[string]string{
+	models.GameAbortStalled:  "abort.stalled",
+	models.GameAbortAdmin:    "abort.admin",
+	models.GameAbortShutdown: "abort.shutdown",
+}
+
+// FormatGameAborted 对局中止退款后以群组语言发布的公告，reason 为 models.GameAbort* 之一
+func FormatGameAborted(lang, gameID string, amount int64, reason string) string {
+	key, ok := gameAbortMessages[reason]
+	if !ok {
+		key = gameAbortMessages[models.GameAbortStalled]
+	}
+	return T(lang, key, Args{"GameID": gameID}) + "\n\n" + T(lang, "abort.refunded", Args{"Amount": utils.FormatAmount(amount)})
+}
+
+// GameAbortedDM 对局中止退款后私信双方的通知
+func GameAbortedDM(gameID string, amount, balance int64, reason string) string {
+	text, ok := gameAbortReasons[reason]
+	if !ok {
+		text = gameAbortReasons[models.GameAbortStalled]
+	}
+	return fmt.Sprintf(text+`
+
+🆔 对局：%s
+💰 已退还：%s 金币
+💳 当前余额：%s 金币
+
+🔕 可在个人设置中关闭私信通知`, gameID, utils.FormatAmount(amount), utils.FormatAmount(balance))
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PingCommand 延迟诊断命令（不含 /）
+const PingCommand = "ping"
+
+// PingLane 一个优先级队列的积压任务数
+type PingLane struct {
+	Priority string
+	Pending  int
+}
+
+// PingReport /ping 的测量结果，管理员额外查看数据库、工作队列和发送额度
+type PingReport struct {
+	UpdateDelay time.Duration // 从用户发出消息到机器人收到更新（秒级精度）
+	Processing  time.Duration // 机器人处理命令的耗时
+	RoundTrip   time.Duration // 向 Telegram 发送回复的往返耗时
+
+	Admin     bool
+	DBLatency time.Duration
+	DBError   error
+	Lanes     []PingLane // 为空时表示未接入工作池
+	Budget    float64    // 本群剩余发送额度占比（0-1）
+	HasBudget bool
+}
+
+// FormatPingPending 测量往返延迟时先发送的占位消息
+func FormatPingPending() string {
+	return "🏓 Pong…"
+}
+
+// FormatPingReport 测量完成后替换占位消息的诊断结果
+func FormatPingReport(report PingReport) string {
+	var b strings.Builder
+
+	b.WriteString("🏓 Pong!\n\n")
+	fmt.Fprintf(&b, "📨 收到更新：约 %s\n", formatLatency(report.UpdateDelay))
+	fmt.Fprintf(&b, "⚙️ 处理耗时：%s\n", formatLatency(report.Processing))
+	fmt.Fprintf(&b, "📡 Telegram 往返：%s", formatLatency(report.RoundTrip))
+	if !report.Admin {
+		return b.String()
+	}
+
+	b.WriteString("\n\n🛠️ 管理员诊断\n")
+	if report.DBError != nil {
+		fmt.Fprintf(&b, "🗄️ 数据库：❌ %v\n", report.DBError)
+	} else {
+		fmt.Fprintf(&b, "🗄️ 数据库：%s\n", formatLatency(report.DBLatency))
+	}
+
+	if len(report.Lanes) == 0 {
+		b.WriteString("📥 工作队列：未接入\n")
+	} else {
+		total := 0
+		parts := make([]string, 0, len(report.Lanes))
+		for _, lane := range report.Lanes {
+			total += lane.Pending
+			parts = append(parts, fmt.Sprintf("%s %d", lane.Priority, lane.Pending))
+		}
+		fmt.Fprintf(&b, "📥 工作队列：积压 %d（%s）\n", total, strings.Join(parts, " / "))
+	}
+
+	if report.HasBudget {
+		fmt.Fprintf(&b, "🚦 发送额度：剩余 %.0f%%", report.Budget*100)
+	} else {
+		b.WriteString("🚦 发送额度：未启用限流")
+	}
+	return b.String()
+}
+
+// formatLatency 按毫秒显示耗时，不足 1 毫秒时保留两位小数
+func formatLatency(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	if d < time.Millisecond {
+		return fmt.Sprintf("%.2f ms", float64(d)/float64(time.Millisecond))
+	}
+	return fmt.Sprintf("%d ms", d.Milliseconds())
+}
@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SettingsAction 个人设置开关按钮的回调动作
+const SettingsAction = "settings"
+
+// 个人设置项
+const (
+	SettingNotifications = "notify"
+	SettingAnonymous     = "anon"
+	SettingCelebrate     = "celebrate"
+	SettingWeekly        = "weekly"
+)
+
+// AnonymousAlias 匿名玩家的固定代号，同一用户始终相同，便于在排行榜中区分
+func AnonymousAlias(userID int64) string {
+	sum := sha256.Sum256([]byte("anonymous:" + strconv.FormatInt(userID, 10)))
+	return fmt.Sprintf("匿名玩家#%X", sum[:2])
+}
+
+// PublicName 排行榜和播报中显示的名字，匿名用户显示代号
+func PublicName(userID int64, username, firstName string, anonymous bool) string {
+	if anonymous {
+		return AnonymousAlias(userID)
+	}
+	if username != "" {
+		return "@" + username
+	}
+	if firstName != "" {
+		return firstName
+	}
+	return "玩家"
+}
+
+// FormatLeaderboard 构建公开排行榜，匿名用户只显示代号
+func FormatLeaderboard(title string, entries []*models.LeaderboardEntry) string {
+	if len(entries) == 0 {
+		return title + "\n\n暂无数据，快来开局吧！"
+	}
+
+	medals := []string{"🥇", "🥈", "🥉"}
+	var b strings.Builder
+	b.WriteString(title + "\n")
+	for i, entry := range entries {
+		rank := fmt.Sprintf("%d.", i+1)
+		if i < len(medals) {
+			rank = medals[i]
+		}
+		name := PublicName(entry.UserID, entry.Username, entry.FirstName, entry.Anonymous)
+		fmt.Fprintf(&b, "\n%s %s — %s 金币（%d 胜）", rank, name, utils.FormatAmount(entry.Winnings), entry.Wins)
+	}
+	return b.String()
+}
+
+// BuildSettingsMessage 个人设置页面：私信通知、匿名显示、跨群连胜播报和每周战报开关
+func BuildSettingsMessage(codec *callback.Codec, chatID int64, notifications, anonymous, celebrate, weekly bool) (tgbotapi.MessageConfig, error) {
+	notifyData, err := codec.Encode(SettingsAction, SettingNotifications, strconv.FormatBool(!notifications))
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	anonData, err := codec.Encode(SettingsAction, SettingAnonymous, strconv.FormatBool(!anonymous))
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	celebrateData, err := codec.Encode(SettingsAction, SettingCelebrate, strconv.FormatBool(!celebrate))
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	weeklyData, err := codec.Encode(SettingsAction, SettingWeekly, strconv.FormatBool(!weekly))
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+
+	text := fmt.Sprintf(`⚙️ 个人设置
+
+🔔 私信通知：%s
+🕶️ 匿名显示：%s
+🔥 跨群连胜播报：%s
+📅 每周战报：%s
+
+开启匿名后，排行榜、群内播报和大奖频道中将以「匿名玩家#XXXX」代替你的名字
+开启连胜播报后，短时间内在多个群获胜时会在你的常驻群中庆祝
+开启每周战报后，每周一私信发送上周的对局、盈亏和排名汇总（需开启私信通知）`,
+		onOff(notifications), onOff(anonymous), onOff(celebrate), onOff(weekly))
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(toggleText("🔔 私信通知", notifications), notifyData),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(toggleText("🕶️ 匿名显示", anonymous), anonData),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(toggleText("🔥 跨群连胜播报", celebrate), celebrateData),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(toggleText("📅 每周战报", weekly), weeklyData),
+		),
+	)
+	return msg, nil
+}
+
+// ParseSettingsCallback 校验并解析设置开关回调，返回设置项和目标状态
+func ParseSettingsCallback(codec *callback.Codec, data string) (string, bool, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", false, err
+	}
+	if parsed.Action != SettingsAction || len(parsed.Args) != 2 {
+		return "", false, callback.ErrMalformed
+	}
+
+	setting := parsed.Arg(0)
+	if setting != SettingNotifications && setting != SettingAnonymous && setting != SettingCelebrate && setting != SettingWeekly {
+		return "", false, callback.ErrMalformed
+	}
+	enabled, err := strconv.ParseBool(parsed.Arg(1))
+	if err != nil {
+		return "", false, callback.ErrMalformed
+	}
+	return setting, enabled, nil
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "已开启"
+	}
+	return "已关闭"
+}
+
+func toggleText(label string, enabled bool) string {
+	if enabled {
+		return label + "：点击关闭"
+	}
+	return label + "：点击开启"
+}
@@ -0,0 +1,125 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// QueueCommand 查看和管理本群排队请求的命令
+const QueueCommand = "queue"
+
+// /queue 子命令
+const (
+	// QueueRemove 移除指定位置或对局的排队请求
+	QueueRemove = "remove"
+	// QueueClear 清空本群的排队请求
+	QueueClear = "clear"
+)
+
+// QueueReplaceAction 已有排队请求时改为排队加入新对局的按钮，参数为新对局ID
+const QueueReplaceAction = "queue_replace"
+
+// FormatChatQueue 本群排队中的加入请求：玩家、下注额和已等待时间
+func FormatChatQueue(entries []*models.QueueEntry, now time.Time) string {
+	if len(entries) == 0 {
+		return "📭 本群当前没有排队的加入请求\n\n" + FormatQueueUsage()
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("⏳ 本群排队中的加入请求（%d 个，每人最多 1 个）\n\n", len(entries)))
+	for _, entry := range entries {
+		b.WriteString(fmt.Sprintf("%d. %s  💰 %s  🆔 %s  ⌛ 已等待 %s\n", entry.Position,
+			PublicName(entry.PlayerID, entry.Username, entry.FirstName, false), utils.FormatAmount(entry.BetAmount), entry.GameID,
+			formatWaited(now.Sub(entry.QueuedAt))))
+	}
+	b.WriteString("\n" + FormatQueueUsage())
+	return b.String()
+}
+
+// FormatQueueUsage /queue 命令用法
+func FormatQueueUsage() string {
+	return fmt.Sprintf("用法：/queue [%s <序号|对局ID>|%s]", QueueRemove, QueueClear)
+}
+
+// FormatQueueRemoved 移除排队请求的结果
+func FormatQueueRemoved(entries []*models.QueueEntry) string {
+	if len(entries) == 0 {
+		return "📭 队列已经是空的"
+	}
+	if len(entries) == 1 {
+		entry := entries[0]
+		return fmt.Sprintf("✅ 已将 %s 移出队列（对局 %s），并私信通知",
+			PublicName(entry.PlayerID, entry.Username, entry.FirstName, false), entry.GameID)
+	}
+	return fmt.Sprintf("✅ 已清空队列，%d 名玩家已收到私信通知", len(entries))
+}
+
+// BuildQueueReplaceMessage 玩家已有排队请求时的提示，附带改为排队加入新对局的按钮，点击后保留原排队位置
+func BuildQueueReplaceMessage(codec *callback.Codec, chatID int64, position int, queuedGameID, newGameID string) (tgbotapi.MessageConfig, error) {
+	data, err := codec.Encode(QueueReplaceAction, newGameID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏳ 你已在排队加入对局 %s（第 %d 位），每人同时只能排队一个加入请求\n\n可以改为排队加入对局 %s，排队位置不变",
+		queuedGameID, position, newGameID))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔁 改为排队加入该对局", data),
+		),
+	)
+	return msg, nil
+}
+
+// ParseQueueReplaceCallback 校验并解析改为排队加入新对局的回调，返回新对局ID
+func ParseQueueReplaceCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != QueueReplaceAction || len(parsed.Args) != 1 || parsed.Args[0] == "" {
+		return "", callback.ErrMalformed
+	}
+	return parsed.Args[0], nil
+}
+
+// FormatQueueReplaced 改为排队加入新对局后的提示
+func FormatQueueReplaced(entry *models.QueueEntry) string {
+	return fmt.Sprintf("🔁 %s 已改为排队加入对局 %s（💰 %s，第 %d 位）",
+		PublicName(entry.PlayerID, entry.Username, entry.FirstName, false), entry.GameID, utils.FormatAmount(entry.BetAmount), entry.Position)
+}
+
+// QueueRemovedDM 被管理员移出队列时的私信
+func QueueRemovedDM(entry *models.QueueEntry, chatTitle string) string {
+	where := "群组"
+	if chatTitle != "" {
+		where = "「" + chatTitle + "」"
+	}
+	return fmt.Sprintf(`ℹ️ 你在%s的排队加入请求已被管理员移除
+
+🆔 对局：%s
+💰 下注额：%s 金币（排队期间未扣款）
+
+对局仍在等待时可以重新加入
+
+🔕 可在个人设置中关闭私信通知`, where, entry.GameID, utils.FormatAmount(entry.BetAmount))
+}
+
+// formatWaited 已等待时间，精确到秒
+func formatWaited(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	d = d.Round(time.Second)
+	if d < time.Minute {
+		return fmt.Sprintf("%d 秒", int(d/time.Second))
+	}
+	return fmt.Sprintf("%d 分 %02d 秒", int(d/time.Minute), int(d%time.Minute/time.Second))
+}
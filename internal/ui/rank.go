@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// RankCommand 查看本群排行榜的命令
+	RankCommand = "rank"
+	// RankAction 排行榜切换统计周期的按钮
+	RankAction = "rank"
+)
+
+// 排行榜统计周期
+const (
+	RankPeriodDay   = "day"
+	RankPeriodWeek  = "week"
+	RankPeriodMonth = "month"
+)
+
+// rankPeriods 按钮顺序和显示名称
+var rankPeriods = []struct {
+	period string
+	label  string
+}{
+	{RankPeriodDay, "今日"},
+	{RankPeriodWeek, "本周"},
+	{RankPeriodMonth, "本月"},
+}
+
+// ValidRankPeriod 是否为支持的统计周期
+func ValidRankPeriod(period string) bool {
+	for _, p := range rankPeriods {
+		if p.period == period {
+			return true
+		}
+	}
+	return false
+}
+
+// RankPeriodStart 统计周期的起点：今天零点、本周一零点或本月一日零点
+func RankPeriodStart(period string, now time.Time) time.Time {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case RankPeriodWeek:
+		// 周一为一周的第一天
+		return today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+	case RankPeriodMonth:
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	default:
+		return today
+	}
+}
+
+// FormatRanking 构建本群排行榜：净盈亏、胜场和对局数，匿名用户只显示代号
+func FormatRanking(period string, entries []*models.RankEntry) string {
+	title := "🏆 " + rankPeriodLabel(period) + "排行榜"
+	if len(entries) == 0 {
+		return title + "\n\n暂无数据，快来开局吧！"
+	}
+
+	medals := []string{"🥇", "🥈", "🥉"}
+	var b strings.Builder
+	b.WriteString(title + "\n")
+	for i, entry := range entries {
+		rank := fmt.Sprintf("%d.", i+1)
+		if i < len(medals) {
+			rank = medals[i]
+		}
+		name := PublicName(entry.UserID, entry.Username, entry.FirstName, entry.Anonymous)
+		profit := utils.FormatAmount(entry.NetProfit)
+		if entry.NetProfit > 0 {
+			profit = "+" + profit
+		}
+		fmt.Fprintf(&b, "\n%s %s — %s 金币（%d 胜 / %d 局）", rank, name, profit, entry.Wins, entry.Games)
+	}
+	return b.String()
+}
+
+// BuildRankMessage 本群排行榜消息，附带切换统计周期的按钮
+func BuildRankMessage(codec *callback.Codec, chatID int64, period string, entries []*models.RankEntry) (tgbotapi.MessageConfig, error) {
+	markup, err := buildRankKeyboard(codec, period)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+
+	msg := tgbotapi.NewMessage(chatID, FormatRanking(period, entries))
+	msg.ReplyMarkup = markup
+	return msg, nil
+}
+
+// BuildRankEdit 将已发送的排行榜消息原地切换为另一个统计周期
+func BuildRankEdit(codec *callback.Codec, chatID int64, messageID int, period string, entries []*models.RankEntry) (tgbotapi.EditMessageTextConfig, error) {
+	markup, err := buildRankKeyboard(codec, period)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	return tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, FormatRanking(period, entries), markup), nil
+}
+
+// ParseRankCallback 解析切换统计周期按钮，返回目标周期
+func ParseRankCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != RankAction || len(parsed.Args) != 1 || !ValidRankPeriod(parsed.Args[0]) {
+		return "", callback.ErrMalformed
+	}
+	return parsed.Args[0], nil
+}
+
+// buildRankKeyboard 统计周期按钮，当前周期带选中标记
+func buildRankKeyboard(codec *callback.Codec, current string) (tgbotapi.InlineKeyboardMarkup, error) {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, p := range rankPeriods {
+		data, err := codec.Encode(RankAction, p.period)
+		if err != nil {
+			return tgbotapi.InlineKeyboardMarkup{}, err
+		}
+		label := p.label
+		if p.period == current {
+			label = "✅ " + label
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(label, data))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row), nil
+}
+
+// rankPeriodLabel 统计周期的显示名称
+func rankPeriodLabel(period string) string {
+	for _, p := range rankPeriods {
+		if p.period == period {
+			return p.label
+		}
+	}
+	return rankPeriods[0].label
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ReadyAction 开骰前确认准备的按钮，参数为对局ID
+const ReadyAction = "ready"
+
+// BuildReadyKeyboard 双方共用的准备按钮
+func BuildReadyKeyboard(codec *callback.Codec, gameID string) (tgbotapi.InlineKeyboardMarkup, error) {
+	data, err := codec.Encode(ReadyAction, gameID)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅准备", data),
+		),
+	), nil
+}
+
+// ParseReadyCallback 校验并解析准备回调，返回对局ID
+func ParseReadyCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != ReadyAction || len(parsed.Args) != 1 || utils.ValidateGameID(parsed.Arg(0)) != nil {
+		return "", callback.ErrMalformed
+	}
+	return parsed.Arg(0), nil
+}
+
+// FormatReadyPrompt 等待双方确认准备的提示，显示各自的准备状态和剩余时间
+func FormatReadyPrompt(gameID string, amount int64, player1, player2 string, ready1, ready2 bool, remaining time.Duration) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🤝 %s 加入了 %s 的对局（%s 金币）\n", player2, player1, utils.FormatAmount(amount))
+	fmt.Fprintf(&b, "🆔 %s\n\n", gameID)
+	fmt.Fprintf(&b, "%s %s\n", readyMark(ready1), player1)
+	fmt.Fprintf(&b, "%s %s\n\n", readyMark(ready2), player2)
+	fmt.Fprintf(&b, "⌛ 双方请在 %d 秒内点击「✅准备」，加入者未准备将撤销加入并退款", int(remaining.Round(time.Second).Seconds()))
+	return b.String()
+}
+
+// FormatReadyStarted 双方均已准备
+func FormatReadyStarted(gameID, player1, player2 string) string {
+	return fmt.Sprintf("✅ %s 与 %s 均已准备，开始掷骰！\n🆔 %s", player1, player2, gameID)
+}
+
+// FormatReadyExpired 准备超时：reverted 为 true 时加入者未准备，对局恢复等待；否则发起者未准备，对局已取消
+func FormatReadyExpired(gameID string, amount int64, player1, player2 string, reverted bool) string {
+	if reverted {
+		return fmt.Sprintf("⌛ %s 未在限定时间内准备，已撤销加入并退还 %s 金币\n🆔 %s 重新等待对手加入",
+			player2, utils.FormatAmount(amount), gameID)
+	}
+	return fmt.Sprintf("⌛ %s 未在限定时间内准备，对局已取消，双方下注已退还\n🆔 %s", player1, gameID)
+}
+
+// readyMark 准备状态标记
+func readyMark(ready bool) string {
+	if ready {
+		return "✅"
+	}
+	return "⏳"
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/utils"
+)
+
+// RechargeCommand 充值命令（不含 /），同时作为 💳 按钮的回调数据
+const RechargeCommand = "recharge"
+
+// RechargeDeposit 充值页面展示的一笔充值记录
+type RechargeDeposit struct {
+	Amount    float64
+	Status    string
+	CreatedAt time.Time
+}
+
+// rechargeStatusText 充值状态的展示文案
+var rechargeStatusText = map[string]string{
+	"pending":   "⏳ 待确认",
+	"confirmed": "✅ 已到账",
+	"failed":    "❌ 失败",
+}
+
+// FormatRechargeInfo 构建充值页面：专属地址、最低金额、兑换比例和最近充值
+func FormatRechargeInfo(address string, minAmount, rate float64, deposits []RechargeDeposit) string {
+	var b strings.Builder
+
+	b.WriteString("💳 USDT 充值（TRC20）\n\n")
+	b.WriteString("📮 你的专属充值地址：\n")
+	fmt.Fprintf(&b, "`%s`\n\n", address)
+	fmt.Fprintf(&b, "💵 最低充值：%.2f USDT\n", minAmount)
+	fmt.Fprintf(&b, "🔄 兑换比例：1 USDT = %g 金币\n", rate)
+	b.WriteString("⚠️ 仅支持 TRC20 网络，低于最低金额的转账不予入账\n")
+
+	b.WriteString("\n📜 最近充值：\n")
+	if len(deposits) == 0 {
+		b.WriteString("暂无充值记录")
+		return b.String()
+	}
+	for _, d := range deposits {
+		status, ok := rechargeStatusText[d.Status]
+		if !ok {
+			status = d.Status
+		}
+		fmt.Fprintf(&b, "• %s  %.2f USDT  %s\n", d.CreatedAt.Format("01-02 15:04"), d.Amount, status)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// DepositCreditedDM 链上充值自动入账后的私信
+func DepositCreditedDM(amount float64, txHash string, balance int64) string {
+	return fmt.Sprintf("✅ 充值已到账\n\n💵 金额：%.2f USDT\n💰 当前余额：%s 金币\n🔗 交易哈希：%s",
+		amount, utils.FormatAmount(balance), txHash)
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// reconcileAlertLimit 对账告警中最多列出的差异数
+const reconcileAlertLimit = 10
+
+// FormatReconcileAlert 对账发现新差异时发给管理员的告警，names 为用户 ID 对应的显示名称
+func FormatReconcileAlert(run *models.ReconcileRun, names map[int64]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "🧾 余额对账发现 %d 个差异（核对 %d 个用户）\n\n", len(run.Issues), run.Checked)
+	for i, issue := range run.Issues {
+		if i == reconcileAlertLimit {
+			fmt.Fprintf(&b, "…… 另有 %d 个\n", len(run.Issues)-reconcileAlertLimit)
+			break
+		}
+		sign := ""
+		if issue.Difference > 0 {
+			sign = "+"
+		}
+		fmt.Fprintf(&b, "#%d %s（%d）：余额 %s，流水 %s，差额 %s%s\n", issue.ID, names[issue.UserID], issue.UserID,
+			utils.FormatAmount(issue.Actual), utils.FormatAmount(issue.Expected), sign, utils.FormatAmount(issue.Difference))
+	}
+
+	if run.Frozen > 0 {
+		fmt.Fprintf(&b, "\n🔒 已冻结 %d 个账户，", run.Frozen)
+	} else {
+		b.WriteString("\n")
+	}
+	b.WriteString("请在管理后台核对后确认或修正")
+	return b.String()
+}
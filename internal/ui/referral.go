@@ -0,0 +1,105 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// InviteCommand 查看邀请链接和邀请收益的命令
+const InviteCommand = "invite"
+
+// InviteFriendsAction 游戏中心菜单「邀请朋友」按钮的回调数据
+const InviteFriendsAction = "invite_friends"
+
+// ReferralPayloadPrefix 邀请链接 /start 参数的前缀，后接邀请人ID
+const ReferralPayloadPrefix = "ref_"
+
+// ReferralLink 用户的邀请链接，打开后以 /start ref_<用户ID> 启动机器人
+func ReferralLink(botUsername string, userID int64) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s%d", botUsername, ReferralPayloadPrefix, userID)
+}
+
+// ParseReferralPayload 解析 /start 参数中的邀请人ID，不是邀请链接时 ok 为 false
+func ParseReferralPayload(payload string) (referrerID int64, ok bool) {
+	code, found := strings.CutPrefix(strings.TrimSpace(payload), ReferralPayloadPrefix)
+	if !found {
+		return 0, false
+	}
+	referrerID, err := strconv.ParseInt(code, 10, 64)
+	if err != nil || referrerID <= 0 {
+		return 0, false
+	}
+	return referrerID, true
+}
+
+// FormatInvite 邀请链接、奖励规则和邀请收益
+func FormatInvite(link string, summary *models.ReferralSummary, rewards *models.ReferralRewards) string {
+	lines := []string{
+		"👥 邀请朋友",
+		"",
+		"🔗 您的邀请链接：",
+		link,
+		"",
+	}
+	if rewards.SignupReferee > 0 || rewards.SignupReferrer > 0 {
+		lines = append(lines, fmt.Sprintf("🎁 好友通过链接注册：好友得 %s 金币，您得 %s 金币",
+			utils.FormatAmount(rewards.SignupReferee), utils.FormatAmount(rewards.SignupReferrer)))
+	}
+	if rewards.WagerReferee > 0 || rewards.WagerReferrer > 0 {
+		lines = append(lines, fmt.Sprintf("🎲 好友累计下注满 %s 金币：好友再得 %s 金币，您得 %s 金币",
+			utils.FormatAmount(rewards.WagerThreshold), utils.FormatAmount(rewards.WagerReferee), utils.FormatAmount(rewards.WagerReferrer)))
+	}
+	if note := referralWageringNote(rewards); note != "" {
+		lines = append(lines, note)
+	}
+	lines = append(lines,
+		"",
+		fmt.Sprintf("📊 已邀请 %d 人，%d 人下注达标", summary.Invited, summary.Qualified),
+		fmt.Sprintf("💰 累计邀请收益：%s 金币", utils.FormatAmount(summary.Earned)),
+	)
+	return strings.Join(lines, "\n")
+}
+
+// FormatReferralJoined 被邀请人通过邀请链接注册成功的提示
+func FormatReferralJoined(rewards *models.ReferralRewards) string {
+	text := "🤝 已通过好友的邀请链接加入"
+	if rewards.SignupReferee > 0 {
+		text += fmt.Sprintf("\n🎁 注册奖励 %s 金币已到账", utils.FormatAmount(rewards.SignupReferee))
+	}
+	if rewards.WagerReferee > 0 {
+		text += fmt.Sprintf("\n🎲 累计下注满 %s 金币后还可获得 %s 金币",
+			utils.FormatAmount(rewards.WagerThreshold), utils.FormatAmount(rewards.WagerReferee))
+	}
+	if note := referralWageringNote(rewards); note != "" && (rewards.SignupReferee > 0 || rewards.WagerReferee > 0) {
+		text += "\n" + note
+	}
+	return text
+}
+
+// FormatReferrerNotice 有新用户通过邀请链接注册时私信邀请人
+func FormatReferrerNotice(name string, rewards *models.ReferralRewards) string {
+	text := fmt.Sprintf("🎉 %s 通过您的邀请链接加入了", name)
+	if rewards.SignupReferrer > 0 {
+		text += fmt.Sprintf("\n💰 邀请奖励 %s 金币已到账", utils.FormatAmount(rewards.SignupReferrer))
+	}
+	if rewards.WagerReferrer > 0 {
+		text += fmt.Sprintf("\n🎲 对方累计下注满 %s 金币后您将再获得 %s 金币",
+			utils.FormatAmount(rewards.WagerThreshold), utils.FormatAmount(rewards.WagerReferrer))
+	}
+	if note := referralWageringNote(rewards); note != "" && (rewards.SignupReferrer > 0 || rewards.WagerReferrer > 0) {
+		text += "\n" + note
+	}
+	return text
+}
+
+// referralWageringNote 邀请奖励计入赠送余额的流水说明，无流水要求时返回空
+func referralWageringNote(rewards *models.ReferralRewards) string {
+	if rewards.WageringMultiplier <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("💡 邀请奖励计入赠送余额，下注流水满奖励金额的 %g 倍后可提现", rewards.WageringMultiplier)
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// RematchAction 对局结果下方的再来一局按钮，参数为上一局的对局ID
+const RematchAction = "rematch"
+
+// RematchButton 再来一局按钮，可附加在对局结果消息下方
+func RematchButton(codec *callback.Codec, gameID string) (tgbotapi.InlineKeyboardButton, error) {
+	data, err := codec.Encode(RematchAction, gameID)
+	if err != nil {
+		return tgbotapi.InlineKeyboardButton{}, err
+	}
+	return tgbotapi.NewInlineKeyboardButtonData("🔄 再来一局", data), nil
+}
+
+// BuildRematchOffer 对局结算后发给双方的再来一局按钮，附带在 disputeWindow 内可用的争议按钮
+func BuildRematchOffer(codec *callback.Codec, chatID int64, gameID string, amount int64, disputeWindow time.Duration) (tgbotapi.MessageConfig, error) {
+	button, err := RematchButton(codec, gameID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	dispute, err := DisputeButton(codec, gameID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🎲 对局 %s 已结束，双方都可以点击按钮以 %s 金币再来一局\n⚖️ 对结果有异议可在 %d 分钟内点击争议",
+		gameID, utils.FormatAmount(amount), int(disputeWindow.Minutes())))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(button, dispute))
+	return msg, nil
+}
+
+// ParseRematchCallback 校验并解析再来一局回调，返回上一局的对局ID
+func ParseRematchCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != RematchAction || len(parsed.Args) != 1 || parsed.Args[0] == "" {
+		return "", callback.ErrMalformed
+	}
+	return parsed.Args[0], nil
+}
+
+// BuildRematchInvite 再来一局邀请，附带对手专用的加入按钮，超时未接受自动退款
+func BuildRematchInvite(codec *callback.Codec, chatID int64, gameID string, amount int64, initiator, opponent string, window time.Duration) (tgbotapi.MessageConfig, error) {
+	join, err := codec.Encode(JoinAction, gameID)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔄 %s 邀请 %s 再来一局（%s 金币）\n🆔 %s\n\n⌛ %d 秒内未接受将自动取消并退款",
+		initiator, opponent, utils.FormatAmount(amount), gameID, int(window.Seconds())))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("✅ 接受再来一局（%s 金币）", utils.FormatAmount(amount)), join),
+		),
+	)
+	return msg, nil
+}
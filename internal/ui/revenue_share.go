@@ -0,0 +1,18 @@
+package ui
+
+import (
+	"fmt"
+
+	"telegram-dice-bot/internal/utils"
+)
+
+// FormatCommissionFooter 对局结果底部的手续费去向说明
+func FormatCommissionFooter(commission, chatShare int64) string {
+	if commission <= 0 {
+		return ""
+	}
+	if chatShare <= 0 {
+		return fmt.Sprintf("💼 手续费：%s 金币", utils.FormatAmount(commission))
+	}
+	return fmt.Sprintf("💼 手续费：%s 金币（其中 %s 金币归入本群基金）", utils.FormatAmount(commission), utils.FormatAmount(chatShare))
+}
@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// riskRuleLabels 风控规则的展示名称
+var riskRuleLabels = map[string]string{
+	models.RiskRulePairGames: "反复对局",
+	models.RiskRuleWinRate:   "胜率异常",
+	models.RiskRuleChipDump:  "筹码转移",
+}
+
+// FormatRiskAlert 风控标记可疑账户时发给管理员的告警，names 为用户 ID 对应的显示名称
+func FormatRiskAlert(events []*models.RiskEvent, names map[int64]string) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "🚨 风控标记 %d 条可疑记录（对局 %s）\n\n", len(events), events[0].GameID)
+	frozen := make(map[int64]bool)
+	for _, event := range events {
+		label := riskRuleLabels[event.Rule]
+		if label == "" {
+			label = event.Rule
+		}
+		fmt.Fprintf(&b, "#%d %s（%d）【%s】%s\n", event.ID, names[event.UserID], event.UserID, label, event.Detail)
+		if event.Frozen {
+			frozen[event.UserID] = true
+		}
+	}
+
+	if len(frozen) > 0 {
+		fmt.Fprintf(&b, "\n🔒 已冻结 %d 个账户，", len(frozen))
+	} else {
+		b.WriteString("\n")
+	}
+	b.WriteString("请在管理后台审核后确认或标记为误报")
+	return b.String()
+}
@@ -0,0 +1,21 @@
+package ui
+
+import (
+	"fmt"
+
+	"telegram-dice-bot/internal/utils"
+)
+
+// SandboxBanner 沙盒机器人消息中的提示，提醒余额均为练习金币
+const SandboxBanner = "🧪 沙盒模式：余额均为练习金币，不可充值或提现"
+
+// FaucetCommand 沙盒模式下领取练习金币的命令
+const FaucetCommand = "faucet"
+
+// FormatPlayMoneyGranted 领取练习金币的结果
+func FormatPlayMoneyGranted(granted, balance int64) string {
+	if granted == 0 {
+		return fmt.Sprintf("%s\n\n💰 当前余额 %s 金币，无需补充", SandboxBanner, utils.FormatAmount(balance))
+	}
+	return fmt.Sprintf("%s\n\n🎁 已补充 %s 练习金币，当前余额 %s 金币", SandboxBanner, utils.FormatAmount(granted), utils.FormatAmount(balance))
+}
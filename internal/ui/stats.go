@@ -0,0 +1,39 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+const (
+	// StatsCommand 查看个人战绩的命令
+	StatsCommand = "stats"
+	// StatsAction 主菜单中"我的统计"按钮的回调数据
+	StatsAction = "my_stats"
+)
+
+// FormatUserStats /stats 的个人战绩，name 为显示名称
+func FormatUserStats(stats *models.UserStats, name string) string {
+	if stats.Games == 0 {
+		return fmt.Sprintf("📊 %s 的战绩\n\n还没有完成的对局，发送 /dice <金额> 来一局吧", name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 %s 的战绩\n\n", name)
+	fmt.Fprintf(&b, "🎲 总对局：%d\n", stats.Games)
+	fmt.Fprintf(&b, "🏆 胜 %d｜💔 负 %d｜🤝 平 %d\n", stats.Wins, stats.Losses, stats.Draws)
+	fmt.Fprintf(&b, "📈 胜率：%.1f%%\n", stats.WinRate()*100)
+	if stats.BiggestWin > 0 {
+		fmt.Fprintf(&b, "💰 最大单局赢额：%s 金币\n", utils.FormatAmount(stats.BiggestWin))
+	}
+
+	sign := ""
+	if stats.NetProfit > 0 {
+		sign = "+"
+	}
+	fmt.Fprintf(&b, "💹 净收益：%s%s 金币", sign, utils.FormatAmount(stats.NetProfit))
+	return b.String()
+}
@@ -0,0 +1,17 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"telegram-dice-bot/internal/utils"
+)
+
+// FormatCrossChatStreak 跨群连胜庆祝消息，只显示群数不显示具体群组
+func FormatCrossChatStreak(name string, chats, wins int, winnings int64, window time.Duration) string {
+	return fmt.Sprintf(`🔥🔥🔥 火力全开！
+
+%s 在 %d 分钟内横扫 %d 个群，连赢 %d 局，共赢得 %s 金币！
+
+谁来终结这波连胜？发送 /dice 开局挑战`, name, int(window.Minutes()), chats, wins, utils.FormatAmount(winnings))
+}
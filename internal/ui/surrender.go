@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"fmt"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// SurrenderAction 认输按钮的回调动作
+const SurrenderAction = "surrender"
+
+// BuildSurrenderKeyboard 构建开骰动画期间显示的认输按钮
+func BuildSurrenderKeyboard(codec *callback.Codec, gameID string, refundRate float64) (tgbotapi.InlineKeyboardMarkup, error) {
+	data, err := codec.Encode(SurrenderAction, gameID)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	text := fmt.Sprintf("🏳️ 认输（退还%d%%）", int(refundRate*100+0.5))
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text, data),
+		),
+	), nil
+}
+
+// ParseSurrenderCallback 校验并解析认输回调，返回游戏ID
+func ParseSurrenderCallback(codec *callback.Codec, data string) (string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", err
+	}
+	if parsed.Action != SurrenderAction || len(parsed.Args) != 1 || utils.ValidateGameID(parsed.Arg(0)) != nil {
+		return "", callback.ErrMalformed
+	}
+	return parsed.Arg(0), nil
+}
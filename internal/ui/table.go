@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// TableCommand 开设快速桌的命令
+	TableCommand = "table"
+	// BattleCommand 开设多人大战的命令，参数为底注和人数上限
+	BattleCommand = "dicemulti"
+	// TableJoinAction 快速桌的入座按钮，参数为快速桌ID
+	TableJoinAction = "table_join"
+	// TableStartAction 快速桌的开骰按钮，参数为快速桌ID
+	TableStartAction = "table_start"
+	// TableCancelAction 快速桌的关闭按钮，参数为快速桌ID
+	TableCancelAction = "table_cancel"
+)
+
+// FormatTableAnnouncement 快速桌的招募公告，列出已入座的玩家
+func FormatTableAnnouncement(table *models.Table, players []*models.TablePlayer, minPlayers, maxPlayers int) string {
+	battle := table.Mode == models.TableModeBattle
+
+	var b strings.Builder
+	if battle {
+		b.WriteString("⚔️ 多人骰子大战招募中！\n\n")
+	} else {
+		b.WriteString("🎲 快速桌开张啦！\n\n")
+	}
+	b.WriteString(fmt.Sprintf("💎 底注：%s\n", utils.FormatAmount(table.Ante)))
+	b.WriteString(fmt.Sprintf("💰 当前奖池：%s\n", utils.FormatAmount(table.Ante*int64(len(players)))))
+	b.WriteString(fmt.Sprintf("🪑 座位：%d/%d（满 %d 人可开骰）\n\n", len(players), maxPlayers, minPlayers))
+	for i, player := range players {
+		b.WriteString(fmt.Sprintf("%d. %s\n", i+1, tablePlayerName(player)))
+	}
+	if battle {
+		b.WriteString("\n每人掷一次三个骰子，点数最高者赢得全部奖池（并列最高时平分）")
+	} else {
+		b.WriteString("\n每人掷一次三个骰子，点数最高的两位瓜分奖池（第一名 60%，第二名 40%）")
+	}
+	return b.String()
+}
+
+// FormatTableResult 快速桌的开骰结果和派奖
+func FormatTableResult(table *models.Table, players []*models.TablePlayer, pot, commission int64) string {
+	medals := map[int]string{1: "🥇", 2: "🥈"}
+
+	var b strings.Builder
+	if table.Mode == models.TableModeBattle {
+		medals = map[int]string{1: "👑"}
+		b.WriteString("⚔️ 多人大战开骰结果\n\n")
+	} else {
+		b.WriteString("🎲 快速桌开骰结果\n\n")
+	}
+	for _, player := range players {
+		medal, ok := medals[player.Rank]
+		if !ok {
+			medal = fmt.Sprintf("%d.", player.Rank)
+		}
+		b.WriteString(fmt.Sprintf("%s %s  🎲 %d+%d+%d=%d", medal, tablePlayerName(player),
+			player.Dice1, player.Dice2, player.Dice3, player.Total()))
+		if player.Payout > 0 {
+			b.WriteString(fmt.Sprintf("  +%s💎", utils.FormatAmount(player.Payout)))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString(fmt.Sprintf("\n💰 奖池：%s", utils.FormatAmount(pot)))
+	if commission > 0 {
+		b.WriteString(fmt.Sprintf("（手续费 %s）", utils.FormatAmount(commission)))
+	} else {
+		b.WriteString("（全员同点，原额退还）")
+	}
+	return b.String()
+}
+
+// FormatBattleUsage /dicemulti 命令用法
+func FormatBattleUsage(minPlayers, maxPlayers int) string {
+	return fmt.Sprintf("用法：/%s <底注> <人数上限>\n人数上限为 %d 到 %d 人，坐满自动开骰", BattleCommand, minPlayers, maxPlayers)
+}
+
+// FormatTableClosed 快速桌关闭（开桌者取消或超时）并退款的公告
+func FormatTableClosed(table *models.Table, players []*models.TablePlayer, expired bool) string {
+	name := "快速桌"
+	if table.Mode == models.TableModeBattle {
+		name = "多人大战"
+	}
+	reason := "开桌者已关闭" + name
+	if expired {
+		reason = name + "等待超时，已自动关闭"
+	}
+	return fmt.Sprintf("↩️ %s\n已向 %d 名玩家退还底注 %s💎", reason, len(players), utils.FormatAmount(table.Ante))
+}
+
+// BuildTableMessage 带入座、开骰和关闭按钮的快速桌公告
+func BuildTableMessage(codec *callback.Codec, chatID int64, table *models.Table, players []*models.TablePlayer, minPlayers, maxPlayers int) (tgbotapi.MessageConfig, error) {
+	markup, err := tableKeyboard(codec, table.ID, len(players), maxPlayers)
+	if err != nil {
+		return tgbotapi.MessageConfig{}, err
+	}
+	msg := tgbotapi.NewMessage(chatID, FormatTableAnnouncement(table, players, minPlayers, maxPlayers))
+	msg.ReplyMarkup = markup
+	return msg, nil
+}
+
+// BuildTableEdit 玩家入座后原地更新快速桌公告
+func BuildTableEdit(codec *callback.Codec, chatID int64, messageID int, table *models.Table, players []*models.TablePlayer, minPlayers, maxPlayers int) (tgbotapi.EditMessageTextConfig, error) {
+	markup, err := tableKeyboard(codec, table.ID, len(players), maxPlayers)
+	if err != nil {
+		return tgbotapi.EditMessageTextConfig{}, err
+	}
+	text := FormatTableAnnouncement(table, players, minPlayers, maxPlayers)
+	return tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, markup), nil
+}
+
+// ParseTableCallback 校验并解析快速桌按钮回调，返回动作和快速桌ID
+func ParseTableCallback(codec *callback.Codec, data string) (string, string, error) {
+	parsed, err := codec.Decode(data)
+	if err != nil {
+		return "", "", err
+	}
+	switch parsed.Action {
+	case TableJoinAction, TableStartAction, TableCancelAction:
+	default:
+		return "", "", callback.ErrMalformed
+	}
+	if len(parsed.Args) != 1 || utils.ValidateTableID(parsed.Arg(0)) != nil {
+		return "", "", callback.ErrMalformed
+	}
+	return parsed.Action, parsed.Arg(0), nil
+}
+
+func tableKeyboard(codec *callback.Codec, tableID string, seated, maxPlayers int) (tgbotapi.InlineKeyboardMarkup, error) {
+	join, err := codec.Encode(TableJoinAction, tableID)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	start, err := codec.Encode(TableStartAction, tableID)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+	cancel, err := codec.Encode(TableCancelAction, tableID)
+	if err != nil {
+		return tgbotapi.InlineKeyboardMarkup{}, err
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🪑 入座（%d/%d）", seated, maxPlayers), join),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🎲 开骰", start),
+			tgbotapi.NewInlineKeyboardButtonData("❌ 关闭", cancel),
+		),
+	), nil
+}
+
+func tablePlayerName(player *models.TablePlayer) string {
+	return PublicName(player.UserID, player.Username, player.FirstName, player.Anonymous)
+}
@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TimelineCommand 管理员查看对局时间线的命令
+const TimelineCommand = "timeline"
+
+// timelineLabels 时间线条目类型的显示名称
+var timelineLabels = map[string]string{
+	models.GameEventCreated:     "🎲 发起",
+	models.GameEventJoined:      "🤝 加入",
+	models.GameEventReady:       "✅ 准备",
+	models.GameEventRoll:        "🎯 骰子",
+	models.GameEventSeedRoll:    "🔐 种子骰子",
+	models.GameEventHeld:        "⏸️ 暂停",
+	models.GameEventSettled:     "🏁 结算",
+	models.GameEventSurrendered: "🏳️ 认输",
+	models.GameEventCancelled:   "↩️ 取消",
+	models.GameEventExpired:     "⏰ 超时",
+	models.GameEventDisputed:    "⚖️ 争议",
+	models.GameEventSecurity:    "🔐 安全",
+	models.GameEventMessage:     "💬 消息",
+	models.GameEventTransaction: "💰 流水",
+	models.GameEventAdmin:       "🛠️ 管理",
+}
+
+// FormatGameTimeline 对局时间线：对局概要和按时间排列的事件、消息、流水和管理员操作
+func FormatGameTimeline(timeline *models.GameTimeline) string {
+	game := timeline.Game
+	var b strings.Builder
+	fmt.Fprintf(&b, "🧾 对局 %s 时间线\n", game.ID)
+	fmt.Fprintf(&b, "群组：%d｜下注：%s 金币｜状态：%s\n", game.ChatID, utils.FormatAmount(game.BetAmount), game.Status)
+	fmt.Fprintf(&b, "玩家1：%d", game.Player1ID)
+	if game.Player2ID != nil {
+		fmt.Fprintf(&b, "｜玩家2：%d", *game.Player2ID)
+	}
+	b.WriteString("\n")
+
+	if len(timeline.Entries) == 0 {
+		b.WriteString("\n暂无记录")
+		return b.String()
+	}
+
+	lastDate := ""
+	for _, entry := range timeline.Entries {
+		if date := entry.Time.Format("2006-01-02"); date != lastDate {
+			fmt.Fprintf(&b, "\n📅 %s\n", date)
+			lastDate = date
+		}
+
+		label, ok := timelineLabels[entry.Type]
+		if !ok {
+			label = entry.Type
+		}
+		fmt.Fprintf(&b, "%s %s", entry.Time.Format("15:04:05.000"), label)
+		if entry.UserID != 0 {
+			fmt.Fprintf(&b, " 用户 %d", entry.UserID)
+		}
+		if entry.MessageID != 0 {
+			fmt.Fprintf(&b, " 消息 #%d", entry.MessageID)
+		}
+		if entry.Type == models.GameEventTransaction {
+			amount := utils.FormatAmount(entry.Amount)
+			if entry.Amount > 0 {
+				amount = "+" + amount
+			}
+			fmt.Fprintf(&b, " %s", amount)
+		}
+		if entry.Detail != "" {
+			// 消息正文可能有多行，时间线中压缩为一行
+			fmt.Fprintf(&b, " — %s", strings.Join(strings.Fields(entry.Detail), " "))
+		}
+		b.WriteString("\n")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
@@ -0,0 +1,201 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+const (
+	// TournamentCommand 查看本群锦标赛，管理员用 open/cancel 开设或取消
+	TournamentCommand = "tournament"
+	// EnterCommand 报名本群正在报名的锦标赛
+	EnterCommand = "enter"
+)
+
+// FormatTournamentUsage /tournament 命令用法
+func FormatTournamentUsage(minPlayers, maxPlayers int) string {
+	return fmt.Sprintf("用法：\n/%s — 查看本群锦标赛\n/%s open <报名费> <人数上限> [报名分钟数] — 开设锦标赛（人数上限 %d 到 %d 人）\n/%s cancel — 取消报名中的锦标赛并退款\n/%s — 报名",
+		TournamentCommand, TournamentCommand, minPlayers, maxPlayers, TournamentCommand, EnterCommand)
+}
+
+// FormatTournamentOpened 锦标赛开设后的报名公告
+func FormatTournamentOpened(tournament *models.Tournament) string {
+	var b strings.Builder
+	b.WriteString("🏆 骰子锦标赛开始报名！\n\n")
+	fmt.Fprintf(&b, "💎 报名费：%s\n", utils.FormatAmount(tournament.EntryFee))
+	fmt.Fprintf(&b, "👥 人数上限：%d 人\n", tournament.MaxPlayers)
+	fmt.Fprintf(&b, "⏰ 报名截止：%s（报满提前开赛）\n\n", tournament.StartsAt.Format("15:04"))
+	fmt.Fprintf(&b, "单败淘汰，每场双方各掷三个骰子，点数高者晋级（同点重掷），冠军独得全部奖池（扣除手续费）\n发送 /%s 报名", EnterCommand)
+	return b.String()
+}
+
+// FormatTournamentEntered 玩家报名成功的提示
+func FormatTournamentEntered(tournament *models.Tournament, entries []*models.TournamentEntry, userID int64) string {
+	name := fmt.Sprintf("%d", userID)
+	for _, entry := range entries {
+		if entry.UserID == userID {
+			name = tournamentEntryName(entry)
+		}
+	}
+	text := fmt.Sprintf("✅ %s 报名成功（%d/%d），当前奖池 %s💎", name, len(entries), tournament.MaxPlayers,
+		utils.FormatAmount(tournament.EntryFee*int64(len(entries))))
+	if len(entries) >= tournament.MaxPlayers {
+		text += "\n👥 报名已满，即将抽签开赛！"
+	}
+	return text
+}
+
+// FormatTournamentStarted 报名截止、抽签开赛的公告，列出种子位
+func FormatTournamentStarted(tournament *models.Tournament, entries []*models.TournamentEntry, totalRounds int) string {
+	var b strings.Builder
+	b.WriteString("🏆 锦标赛报名截止，抽签完成！\n\n")
+	fmt.Fprintf(&b, "👥 参赛：%d 人，共 %d 轮\n", len(entries), totalRounds)
+	fmt.Fprintf(&b, "💰 奖池：%s💎\n\n", utils.FormatAmount(tournament.EntryFee*int64(len(entries))))
+	for _, entry := range entries {
+		fmt.Fprintf(&b, "%d. %s\n", entry.Seed, tournamentEntryName(entry))
+	}
+	fmt.Fprintf(&b, "\n🎲 %s 开始%s", tournament.NextRoundAt.Format("15:04"), TournamentRoundName(1, totalRounds))
+	return b.String()
+}
+
+// FormatTournamentRound 一轮比赛的结果，比赛继续时附上下一轮的开赛时间
+func FormatTournamentRound(tournament *models.Tournament, entries []*models.TournamentEntry, matches []*models.TournamentMatch, totalRounds int) string {
+	names := tournamentNames(entries)
+	round := matches[0].Round
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🎲 锦标赛%s结果\n\n", TournamentRoundName(round, totalRounds))
+	for _, match := range matches {
+		b.WriteString(formatTournamentMatch(match, names))
+		b.WriteString("\n")
+	}
+	if tournament.Status == models.TournamentStatusRunning && round < totalRounds {
+		fmt.Fprintf(&b, "\n⏰ %s 开始%s", tournament.NextRoundAt.Format("15:04"), TournamentRoundName(round+1, totalRounds))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// FormatTournamentFinished 锦标赛决出冠军并派奖的公告
+func FormatTournamentFinished(tournament *models.Tournament, entries []*models.TournamentEntry) string {
+	names := tournamentNames(entries)
+
+	var b strings.Builder
+	b.WriteString("👑 锦标赛结束！\n\n")
+	fmt.Fprintf(&b, "🏆 冠军：%s\n", names[*tournament.WinnerID])
+	fmt.Fprintf(&b, "💰 奖金：%s💎", utils.FormatAmount(tournament.Prize))
+	if tournament.FundPrize > 0 {
+		fmt.Fprintf(&b, "（含群组奖池 %s）", utils.FormatAmount(tournament.FundPrize))
+	}
+	if tournament.Commission > 0 {
+		fmt.Fprintf(&b, "（手续费 %s）", utils.FormatAmount(tournament.Commission))
+	}
+	fmt.Fprintf(&b, "\n👥 参赛：%d 人，共 %d 轮", len(entries), tournament.Round)
+	return b.String()
+}
+
+// FormatTournamentCancelled 锦标赛取消（管理员取消或报名人数不足）并退款的公告
+func FormatTournamentCancelled(tournament *models.Tournament, entries []*models.TournamentEntry, expired bool) string {
+	reason := "管理员已取消锦标赛"
+	if expired {
+		reason = "锦标赛报名人数不足，已自动取消"
+	}
+	if len(entries) == 0 {
+		return "↩️ " + reason
+	}
+	return fmt.Sprintf("↩️ %s\n已向 %d 名玩家退还报名费 %s💎", reason, len(entries), utils.FormatAmount(tournament.EntryFee))
+}
+
+// FormatTournamentStatus /tournament 查看本群锦标赛：报名中列出报名玩家，比赛中列出已进行的对阵
+func FormatTournamentStatus(tournament *models.Tournament, entries []*models.TournamentEntry, matches []*models.TournamentMatch, totalRounds int) string {
+	var b strings.Builder
+	pool := tournament.EntryFee * int64(len(entries))
+
+	if tournament.Status == models.TournamentStatusOpen {
+		b.WriteString("🏆 锦标赛报名中\n\n")
+		fmt.Fprintf(&b, "💎 报名费：%s\n", utils.FormatAmount(tournament.EntryFee))
+		fmt.Fprintf(&b, "👥 已报名：%d/%d\n", len(entries), tournament.MaxPlayers)
+		fmt.Fprintf(&b, "💰 当前奖池：%s💎\n", utils.FormatAmount(pool))
+		fmt.Fprintf(&b, "⏰ 报名截止：%s（剩余 %s）\n", tournament.StartsAt.Format("15:04"), formatTournamentWait(time.Until(tournament.StartsAt)))
+		for i, entry := range entries {
+			fmt.Fprintf(&b, "\n%d. %s", i+1, tournamentEntryName(entry))
+		}
+		fmt.Fprintf(&b, "\n\n发送 /%s 报名", EnterCommand)
+		return b.String()
+	}
+
+	names := tournamentNames(entries)
+	fmt.Fprintf(&b, "🏆 锦标赛进行中（%d/%d 轮）\n\n", tournament.Round, totalRounds)
+	fmt.Fprintf(&b, "💰 奖池：%s💎\n", utils.FormatAmount(pool))
+	if len(matches) == 0 {
+		b.WriteString("\n")
+		for _, entry := range entries {
+			fmt.Fprintf(&b, "%d. %s\n", entry.Seed, tournamentEntryName(entry))
+		}
+	}
+	round := 0
+	for _, match := range matches {
+		if match.Round != round {
+			round = match.Round
+			fmt.Fprintf(&b, "\n【%s】\n", TournamentRoundName(round, totalRounds))
+		}
+		b.WriteString(formatTournamentMatch(match, names))
+		b.WriteString("\n")
+	}
+	if tournament.Round < totalRounds {
+		fmt.Fprintf(&b, "\n⏰ %s 开始%s", tournament.NextRoundAt.Format("15:04"), TournamentRoundName(tournament.Round+1, totalRounds))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// TournamentRoundName 轮次的名称：最后一轮为决赛，倒数第二轮为半决赛
+func TournamentRoundName(round, totalRounds int) string {
+	switch round {
+	case totalRounds:
+		return "决赛"
+	case totalRounds - 1:
+		return "半决赛"
+	default:
+		return fmt.Sprintf("第 %d 轮", round)
+	}
+}
+
+// formatTournamentMatch 一场对阵的结果，如“A 🎲 4+5+6=15 vs B 🎲 1+2+3=6 → A 晋级”
+func formatTournamentMatch(match *models.TournamentMatch, names map[int64]string) string {
+	if match.Bye() {
+		return fmt.Sprintf("%d. %s 轮空晋级", match.Slot, names[match.Player1ID])
+	}
+	total1, total2 := match.Totals()
+	line := fmt.Sprintf("%d. %s 🎲 %d+%d+%d=%d vs %s 🎲 %d+%d+%d=%d → 🏅 %s", match.Slot,
+		names[match.Player1ID], match.Player1Dice[0], match.Player1Dice[1], match.Player1Dice[2], total1,
+		names[match.Player2ID], match.Player2Dice[0], match.Player2Dice[1], match.Player2Dice[2], total2,
+		names[match.WinnerID])
+	if match.Rerolls > 0 {
+		line += fmt.Sprintf("（同点重掷 %d 次）", match.Rerolls)
+	}
+	return line
+}
+
+// formatTournamentWait 距报名截止的剩余时间
+func formatTournamentWait(wait time.Duration) string {
+	if wait < time.Minute {
+		return "不到 1 分钟"
+	}
+	return fmt.Sprintf("%d 分钟", int(wait.Minutes()))
+}
+
+// tournamentNames 参赛玩家 ID 对应的显示名称
+func tournamentNames(entries []*models.TournamentEntry) map[int64]string {
+	names := make(map[int64]string, len(entries))
+	for _, entry := range entries {
+		names[entry.UserID] = tournamentEntryName(entry)
+	}
+	return names
+}
+
+func tournamentEntryName(entry *models.TournamentEntry) string {
+	return PublicName(entry.UserID, entry.Username, entry.FirstName, entry.Anonymous)
+}
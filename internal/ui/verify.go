@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// VerifyCommand 玩家核对对局公平性的命令
+const VerifyCommand = "verify"
+
+// FormatSeedCommitment 对局发起公告中的种子承诺
+func FormatSeedCommitment(gameID, seedHash string) string {
+	return fmt.Sprintf("🔐 种子承诺：%s\n对局结束后发送 /verify %s 公开种子并审计结算点数", seedHash, gameID)
+}
+
+// FormatGameVerification 对局审计结果：承诺哈希与公开的种子；骰子按种子生成时附重算结果，
+// 由 Telegram 动画开出时附记录的骰子消息点数，并与结算点数核对
+func FormatGameVerification(v *models.GameVerification) string {
+	game := v.Game
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔐 对局 %s 审计\n", game.ID)
+	fmt.Fprintf(&b, "下注：%s 金币｜状态：%s\n", utils.FormatAmount(game.BetAmount), game.Status)
+	fmt.Fprintf(&b, "\n承诺哈希：%s\n", v.SeedHash)
+
+	if !v.Revealed {
+		b.WriteString("🔒 对局尚未结束，种子将在结束后公开")
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "种子：%s\n", v.Seed)
+	if v.HashValid {
+		b.WriteString("✅ SHA256(种子) 与承诺哈希一致\n")
+	} else {
+		b.WriteString("❌ SHA256(种子) 与承诺哈希不一致\n")
+	}
+
+	if !v.Rolled {
+		b.WriteString("\n本局未开骰")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\n🎯 结算骰子：玩家1 %s｜玩家2 %s\n", formatDice(v.Dice1), formatDice(v.Dice2))
+
+	switch v.DiceSource {
+	case models.DiceSourceSeed:
+		fmt.Fprintf(&b, "🎲 按种子重算：玩家1 %s｜玩家2 %s\n", formatDice(v.SeedDice1), formatDice(v.SeedDice2))
+		if v.DiceMatch {
+			b.WriteString("✅ 结算骰子与种子重算结果一致")
+		} else {
+			b.WriteString("❌ 结算骰子与种子重算结果不一致")
+		}
+		b.WriteString("\n\n📐 自行验证：第 n 个骰子 = SHA256(\"种子-玩家序号\" + 后缀) 前8字节按无符号整数 mod 6 + 1，三个骰子的后缀依次为空、dice2、dice3")
+	case models.DiceSourceTelegram:
+		fmt.Fprintf(&b, "📨 Telegram 骰子消息：玩家1 %s｜玩家2 %s\n", formatValues(v.TelegramDice1), formatValues(v.TelegramDice2))
+		if v.DiceMatch {
+			b.WriteString("✅ 结算骰子与群内骰子消息一致")
+		} else {
+			b.WriteString("❌ 结算骰子与记录的骰子消息不一致，请联系管理员")
+		}
+		b.WriteString("\n\nℹ️ 本局骰子由 Telegram 骰子动画开出，点数不由种子决定，种子承诺只证明开局后对局记录未被替换")
+	default:
+		b.WriteString("ℹ️ 本局没有骰子来源记录，无法核对点数，以群内骰子消息为准")
+	}
+	return b.String()
+}
+
+// formatDice 三个骰子及总点数
+func formatDice(dice [3]int) string {
+	return fmt.Sprintf("%d+%d+%d=%d", dice[0], dice[1], dice[2], dice[0]+dice[1]+dice[2])
+}
+
+// formatValues 记录的骰子消息点数，如 3+5+1
+func formatValues(values []int) string {
+	if len(values) == 0 {
+		return "无记录"
+	}
+	parts := make([]string, len(values))
+	for i, value := range values {
+		parts[i] = fmt.Sprint(value)
+	}
+	return strings.Join(parts, "+")
+}
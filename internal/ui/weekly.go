@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// weeklySummaryText 每周战报各语言的文案
+type weeklySummaryText struct {
+	Title      string
+	DateLayout string
+	Games      string // 场数、胜、负、平
+	Net        string
+	BestWin    string
+	NoBestWin  string
+	Rank       string // 名次、玩家总数
+	Fees       string
+	Footer     string
+}
+
+// weeklySummaryTexts 内置语言的每周战报文案，未支持的语言使用默认语言
+var weeklySummaryTexts = map[string]weeklySummaryText{
+	"zh": {
+		Title:      "📅 每周战报（%s - %s）",
+		DateLayout: "01-02",
+		Games:      "🎲 对局：%d 场（%d 胜 %d 负 %d 平）",
+		Net:        "💰 净盈亏：%s 金币",
+		BestWin:    "🏆 最佳单局：+%s 金币",
+		NoBestWin:  "🏆 最佳单局：本周暂无胜局",
+		Rank:       "📊 本周排名：第 %d 名（共 %d 名玩家）",
+		Fees:       "🧾 手续费支出：%s 金币",
+		Footer:     "在 /settings 中可关闭每周战报",
+	},
+	"en": {
+		Title:      "📅 Weekly summary (%s - %s)",
+		DateLayout: "Jan 2",
+		Games:      "🎲 Games: %d (%d W / %d L / %d D)",
+		Net:        "💰 Net result: %s coins",
+		BestWin:    "🏆 Best win: +%s coins",
+		NoBestWin:  "🏆 Best win: no wins this week",
+		Rank:       "📊 Weekly rank: #%d of %d players",
+		Fees:       "🧾 Fees paid: %s coins",
+		Footer:     "Turn off weekly summaries in /settings",
+	},
+}
+
+// FormatWeeklySummary 按用户语言构建每周战报私信
+func FormatWeeklySummary(lang string, summary *models.WeeklySummary) string {
+	text, ok := weeklySummaryTexts[NormalizeLanguage(lang)]
+	if !ok {
+		text = weeklySummaryTexts[DefaultLanguage]
+	}
+
+	// 统计区间不含 Until，显示为该周的最后一天
+	last := summary.Until.AddDate(0, 0, -1)
+	net := utils.FormatAmount(summary.NetProfit)
+	if summary.NetProfit > 0 {
+		net = "+" + net
+	}
+
+	lines := []string{
+		fmt.Sprintf(text.Title, summary.Since.Format(text.DateLayout), last.Format(text.DateLayout)),
+		"",
+		fmt.Sprintf(text.Games, summary.Games, summary.Wins, summary.Losses, summary.Draws),
+		fmt.Sprintf(text.Net, net),
+	}
+	if summary.BestWin > 0 {
+		lines = append(lines, fmt.Sprintf(text.BestWin, utils.FormatAmount(summary.BestWin)))
+	} else {
+		lines = append(lines, text.NoBestWin)
+	}
+	lines = append(lines,
+		fmt.Sprintf(text.Rank, summary.Rank, summary.Players),
+		fmt.Sprintf(text.Fees, utils.FormatAmount(summary.Fees)),
+		"",
+		text.Footer,
+	)
+	return strings.Join(lines, "\n")
+}
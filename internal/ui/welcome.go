@@ -0,0 +1,133 @@
+package ui
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// WelcomeTemplateKey 欢迎消息模板在 bot_settings 中的键前缀
+const WelcomeTemplateKey = "welcome_template:"
+
+// DefaultLanguage 未找到对应语言模板时使用的语言
+const DefaultLanguage = "zh"
+
+// WelcomeData 欢迎消息模板可用的变量
+type WelcomeData struct {
+	ChatTitle   string
+	BotUsername string
+	MinBet      int64
+	MaxBet      int64
+}
+
+// defaultWelcomeTemplates 内置的欢迎消息模板
+var defaultWelcomeTemplates = map[string]string{
+	"zh": `🎲 大家好！我是骰子游戏机器人，感谢把我加入 {{.ChatTitle}}
+
+📋 开始之前请管理员完成设置：
+1. 将我设为群管理员
+2. 授予「删除消息」和「置顶消息」权限
+3. 点击下方按钮或发送 /setup 完成初始化
+
+💰 单注范围：{{.MinBet}} - {{.MaxBet}} 金币
+❓ 私聊 @{{.BotUsername}} 查看更多帮助`,
+	"en": `🎲 Hi everyone! I'm the dice game bot, thanks for adding me to {{.ChatTitle}}
+
+📋 An admin needs to finish setup first:
+1. Promote me to group admin
+2. Grant "Delete messages" and "Pin messages" permissions
+3. Tap the button below or send /setup
+
+💰 Bet range: {{.MinBet}} - {{.MaxBet}} coins
+❓ Message @{{.BotUsername}} privately for more help`,
+	"ru": `🎲 Всем привет! Я бот для игры в кости, спасибо, что добавили меня в {{.ChatTitle}}
+
+📋 Сначала администратору нужно завершить настройку:
+1. Назначьте меня администратором группы
+2. Выдайте права «Удаление сообщений» и «Закрепление сообщений»
+3. Нажмите кнопку ниже или отправьте /setup
+
+💰 Диапазон ставок: {{.MinBet}} - {{.MaxBet}} монет
+❓ Напишите @{{.BotUsername}} в личные сообщения, чтобы узнать больше`,
+}
+
+// WelcomeTemplateSettingKey 返回指定语言的模板配置键
+func WelcomeTemplateSettingKey(lang string) string {
+	return WelcomeTemplateKey + NormalizeLanguage(lang)
+}
+
+// NormalizeLanguage 将 Telegram 语言代码归一为内置支持的语言
+func NormalizeLanguage(lang string) string {
+	if lang, ok := LookupLanguage(lang); ok {
+		return lang
+	}
+	return DefaultLanguage
+}
+
+// LookupLanguage 将语言代码（如 en-US）归一为内置支持的语言，不支持时返回 ok=false
+func LookupLanguage(lang string) (string, bool) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	return lang, catalogLanguage(lang)
+}
+
+// DefaultWelcomeTemplate 获取内置欢迎模板
+func DefaultWelcomeTemplate(lang string) string {
+	return defaultWelcomeTemplates[NormalizeLanguage(lang)]
+}
+
+// ValidateWelcomeTemplate 校验模板语法及变量是否合法
+func ValidateWelcomeTemplate(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("欢迎模板不能为空")
+	}
+	if len(text) > 4096 {
+		return fmt.Errorf("欢迎模板超过 Telegram 消息长度限制")
+	}
+	_, err := RenderWelcome(text, WelcomeData{})
+	return err
+}
+
+// RenderWelcome 渲染欢迎消息
+func RenderWelcome(text string, data WelcomeData) (string, error) {
+	tmpl, err := template.New("welcome").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("解析欢迎模板失败: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染欢迎模板失败: %v", err)
+	}
+	return buf.String(), nil
+}
+
+// BotJoinedChat 判断 my_chat_member 更新是否为机器人被拉入群组
+func BotJoinedChat(update *tgbotapi.ChatMemberUpdated) bool {
+	if update == nil {
+		return false
+	}
+	if !update.Chat.IsGroup() && !update.Chat.IsSuperGroup() {
+		return false
+	}
+
+	wasOut := update.OldChatMember.Status == "left" || update.OldChatMember.Status == "kicked"
+	isIn := update.NewChatMember.Status == "member" || update.NewChatMember.Status == "administrator"
+	return wasOut && isIn
+}
+
+// BuildWelcomeMessage 构建带 /setup 按钮的欢迎消息
+func BuildWelcomeMessage(chatID int64, text string) tgbotapi.MessageConfig {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⚙️ /setup", "setup"),
+		),
+	)
+	return msg
+}
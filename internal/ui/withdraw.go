@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// WithdrawCommand 提现命令（不含 /），同时作为 💸 按钮的回调数据
+const WithdrawCommand = "withdraw"
+
+// withdrawalStatusText 提现状态的展示文案
+var withdrawalStatusText = map[string]string{
+	models.WithdrawalStatusPending:  "⏳ 待审核",
+	models.WithdrawalStatusApproved: "✅ 已转出",
+	models.WithdrawalStatusRejected: "↩️ 已拒绝",
+}
+
+// withdrawalStatus 提现状态的展示文案，未知状态原样显示
+func withdrawalStatus(status string) string {
+	if text, ok := withdrawalStatusText[status]; ok {
+		return text
+	}
+	return status
+}
+
+// FormatWithdrawInfo 构建提现页面：可提现余额、最低金额、兑换比例、用法和最近提现
+func FormatWithdrawInfo(balance, minAmount int64, rate float64, withdrawals []*models.Withdrawal) string {
+	var b strings.Builder
+
+	b.WriteString("💸 USDT 提现（TRC20）\n\n")
+	fmt.Fprintf(&b, "💰 可提现余额：%s 金币\n", utils.FormatAmount(balance))
+	fmt.Fprintf(&b, "💵 单笔最低：%s 金币\n", utils.FormatAmount(minAmount))
+	fmt.Fprintf(&b, "🔄 兑换比例：%g 金币 = 1 USDT\n", rate)
+	b.WriteString("⚠️ 赠送余额需完成流水后才能提现，申请期间金额冻结，审核通过后转出\n\n")
+	b.WriteString(FormatWithdrawUsage())
+
+	b.WriteString("\n\n📜 最近提现：\n")
+	if len(withdrawals) == 0 {
+		b.WriteString("暂无提现记录")
+		return b.String()
+	}
+	for _, w := range withdrawals {
+		fmt.Fprintf(&b, "• #%d %s  %s 金币 → %.2f USDT  %s\n", w.ID, w.CreatedAt.Format("01-02 15:04"),
+			utils.FormatAmount(w.Amount), w.USDTAmount, withdrawalStatus(w.Status))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// FormatWithdrawUsage /withdraw 命令用法
+func FormatWithdrawUsage() string {
+	return "用法：/withdraw <金额> <TRC20地址>\n例如：/withdraw 1000 TXYZ..."
+}
+
+// FormatWithdrawRequested 提交提现申请后的回复
+func FormatWithdrawRequested(w *models.Withdrawal, balance int64) string {
+	return fmt.Sprintf("✅ 提现申请 #%d 已提交\n\n💸 金额：%s 金币 → %.2f USDT\n📮 收款地址：%s\n💰 剩余余额：%s 金币\n\n⏳ 金额已冻结，管理员审核后会私信通知你，被拒绝时自动退回余额",
+		w.ID, utils.FormatAmount(w.Amount), w.USDTAmount, w.Address, utils.FormatAmount(balance))
+}
+
+// WithdrawalReviewedDM 提现申请审核结果的私信
+func WithdrawalReviewedDM(w *models.Withdrawal) string {
+	if w.Status == models.WithdrawalStatusApproved {
+		text := fmt.Sprintf("✅ 提现申请 #%d 已批准\n\n💵 %.2f USDT 已转至 %s", w.ID, w.USDTAmount, w.Address)
+		if w.TxHash != "" {
+			text += "\n🔗 交易哈希：" + w.TxHash
+		}
+		return text
+	}
+
+	text := fmt.Sprintf("↩️ 提现申请 #%d 未通过\n\n💰 冻结的 %s 金币已退回余额", w.ID, utils.FormatAmount(w.Amount))
+	if w.Reason != "" {
+		text += "\n📝 原因：" + w.Reason
+	}
+	return text
+}
+
+// FormatWithdrawalAlert 新提现申请的管理员告警
+func FormatWithdrawalAlert(w *models.Withdrawal, name string) string {
+	return fmt.Sprintf("💸 新提现申请 #%d 待审核\n\n👤 用户：%s（%d）\n💰 金额：%s 金币 → %.2f USDT\n📮 地址：%s\n\n请在管理后台批准或拒绝",
+		w.ID, name, w.UserID, utils.FormatAmount(w.Amount), w.USDTAmount, w.Address)
+}
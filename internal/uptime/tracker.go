@@ -0,0 +1,219 @@
+package uptime
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+)
+
+const (
+	// heartbeatInterval 心跳间隔，异常退出时停机时间的误差不超过该值
+	heartbeatInterval = time.Minute
+	// DefaultGapThreshold 更新积压超过该时长才记为服务中断
+	DefaultGapThreshold = 2 * time.Minute
+)
+
+// 停机原因
+const (
+	ReasonRestart = "重启或停机维护"
+	ReasonCrash   = "进程异常退出"
+	ReasonBacklog = "更新处理积压"
+)
+
+// Tracker 记录进程启停和运行期间的服务中断，统计可用性
+type Tracker struct {
+	db           *database.DB
+	instance     string
+	gapThreshold time.Duration
+
+	mu        sync.Mutex
+	sessionID int64
+	gapEnd    time.Time // 最近一次记录的中断结束时间，避免同一批积压的更新重复记录
+	quit      chan struct{}
+}
+
+// NewTracker 创建可用性记录器，instance 用于区分多个进程
+func NewTracker(db *database.DB, instance string, gapThreshold time.Duration) *Tracker {
+	if gapThreshold <= 0 {
+		gapThreshold = DefaultGapThreshold
+	}
+	return &Tracker{
+		db:           db,
+		instance:     instance,
+		gapThreshold: gapThreshold,
+		quit:         make(chan struct{}),
+	}
+}
+
+// Start 记录进程启动并定期写入心跳
+func (t *Tracker) Start() error {
+	sessionID, err := t.db.StartUptimeSession(t.instance)
+	if err != nil {
+		return fmt.Errorf("记录进程启动失败: %v", err)
+	}
+	t.mu.Lock()
+	t.sessionID = sessionID
+	t.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := t.db.TouchUptimeSession(sessionID); err != nil {
+					log.Printf("⚠️ 写入运行心跳失败: %v", err)
+				}
+			case <-t.quit:
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 记录进程正常停止
+func (t *Tracker) Stop() {
+	close(t.quit)
+	t.mu.Lock()
+	sessionID := t.sessionID
+	t.mu.Unlock()
+	if err := t.db.StopUptimeSession(sessionID); err != nil {
+		log.Printf("⚠️ 记录进程停止失败: %v", err)
+	}
+}
+
+// ObserveUpdate 根据收到的更新的发送时间检测服务中断：
+// 更新在发出很久之后才被处理，说明这段时间内机器人没有响应
+func (t *Tracker) ObserveUpdate(sentAt time.Time) {
+	now := time.Now()
+	if sentAt.IsZero() || now.Sub(sentAt) < t.gapThreshold {
+		return
+	}
+
+	t.mu.Lock()
+	if !sentAt.After(t.gapEnd) {
+		t.mu.Unlock()
+		return
+	}
+	t.gapEnd = now
+	t.mu.Unlock()
+
+	gap := &models.UptimeGap{StartedAt: sentAt, EndedAt: now, Reason: ReasonBacklog}
+	if err := t.db.RecordUptimeGap(gap); err != nil {
+		log.Printf("⚠️ 记录服务中断失败: %v", err)
+		return
+	}
+	log.Printf("⚠️ 检测到服务中断：更新积压 %v", now.Sub(sentAt).Round(time.Second))
+}
+
+// MonthlyReport 统计 month 所在自然月（截至当前）的可用性
+func (t *Tracker) MonthlyReport(month time.Time) (*models.UptimeReport, error) {
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, month.Location())
+	to := from.AddDate(0, 1, 0)
+	if now := time.Now(); to.After(now) {
+		to = now
+	}
+
+	sessions, err := t.db.GetUptimeSessions(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("获取运行记录失败: %v", err)
+	}
+	gaps, err := t.db.GetUptimeGaps(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("获取中断记录失败: %v", err)
+	}
+
+	t.mu.Lock()
+	current := t.sessionID
+	t.mu.Unlock()
+	return BuildReport(sessions, gaps, current, from, to), nil
+}
+
+// BuildReport 根据运行会话和中断记录计算 [from, to) 内的可用性。
+// 相邻会话之间的空档记为停机，current 为当前进程的会话，视为一直在线；
+// 第一次启动之前没有记录的时间不计入统计
+func BuildReport(sessions []*models.UptimeSession, gaps []*models.UptimeGap, current int64, from, to time.Time) *models.UptimeReport {
+	report := &models.UptimeReport{From: from, To: to, Availability: 100}
+	if len(sessions) == 0 || !to.After(from) {
+		return report
+	}
+
+	sorted := append([]*models.UptimeSession(nil), sessions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].StartedAt.Before(sorted[j].StartedAt)
+	})
+
+	start := from
+	if sorted[0].StartedAt.After(start) {
+		start = sorted[0].StartedAt
+	}
+	if !to.After(start) {
+		return report
+	}
+
+	var outages []*models.UptimeGap
+	for i, session := range sorted {
+		var end time.Time
+		if i+1 < len(sorted) {
+			end = sorted[i+1].StartedAt
+		} else if session.ID != current {
+			// 最后一个会话不是当前进程，说明之后一直未恢复
+			end = to
+		} else {
+			continue
+		}
+		reason := ReasonRestart
+		if session.StoppedAt == nil {
+			reason = ReasonCrash
+		}
+		outages = append(outages, &models.UptimeGap{StartedAt: session.EndedAt(), EndedAt: end, Reason: reason})
+	}
+	outages = append(outages, gaps...)
+
+	report.Outages = mergeOutages(outages, start, to)
+	for _, outage := range report.Outages {
+		report.Downtime += outage.EndedAt.Sub(outage.StartedAt)
+	}
+	report.Monitored = to.Sub(start)
+	report.Availability = 100 * float64(report.Monitored-report.Downtime) / float64(report.Monitored)
+	return report
+}
+
+// mergeOutages 将停机时段截取到 [from, to) 并合并重叠部分，合并后沿用最早时段的原因
+func mergeOutages(outages []*models.UptimeGap, from, to time.Time) []*models.UptimeGap {
+	var clipped []*models.UptimeGap
+	for _, outage := range outages {
+		start, end := outage.StartedAt, outage.EndedAt
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			clipped = append(clipped, &models.UptimeGap{StartedAt: start, EndedAt: end, Reason: outage.Reason})
+		}
+	}
+	sort.Slice(clipped, func(i, j int) bool {
+		return clipped[i].StartedAt.Before(clipped[j].StartedAt)
+	})
+
+	var merged []*models.UptimeGap
+	for _, outage := range clipped {
+		if n := len(merged); n > 0 && !outage.StartedAt.After(merged[n-1].EndedAt) {
+			if outage.EndedAt.After(merged[n-1].EndedAt) {
+				merged[n-1].EndedAt = outage.EndedAt
+			}
+			continue
+		}
+		merged = append(merged, outage)
+	}
+	return merged
+}
@@ -0,0 +1,245 @@
+package userimport
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// Source 导入用户的来源标记，计入用户来源统计
+const Source = "migration"
+
+// MaxRows 单次导入的最大行数
+const MaxRows = 50000
+
+// header CSV 表头，首行与之相同时跳过
+var header = []string{"telegram_id", "username", "balance"}
+
+// Row 一行待导入的用户
+type Row struct {
+	Line       int    `json:"line"`
+	TelegramID int64  `json:"telegram_id"`
+	Username   string `json:"username"`
+	Balance    int64  `json:"balance"`
+}
+
+// RowError 未导入的行及原因
+type RowError struct {
+	Line       int    `json:"line"`
+	TelegramID int64  `json:"telegram_id,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Report 导入报告
+type Report struct {
+	DryRun   bool       `json:"dry_run"`
+	Total    int        `json:"total"`
+	Imported int        `json:"imported"`
+	Balance  int64      `json:"balance"` // 导入的余额合计
+	Errors   []RowError `json:"errors"`
+}
+
+// OK 全部行均已导入（试运行时为全部通过校验）
+func (r *Report) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// String 可读的导入报告
+func (r *Report) String() string {
+	var b strings.Builder
+	if r.DryRun {
+		b.WriteString("🔍 用户导入试运行，未写入数据\n\n")
+	} else {
+		b.WriteString("📥 用户导入完成\n\n")
+	}
+	fmt.Fprintf(&b, "共 %d 行，%s %d 个用户，余额合计 %s 金币，失败 %d 行\n",
+		r.Total, r.verb(), r.Imported, utils.FormatAmount(r.Balance), len(r.Errors))
+	for _, rowErr := range r.Errors {
+		fmt.Fprintf(&b, "  ❌ 第 %d 行：%s\n", rowErr.Line, rowErr.Message)
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// verb 试运行时为“可导入”，否则为“已导入”
+func (r *Report) verb() string {
+	if r.DryRun {
+		return "可导入"
+	}
+	return "已导入"
+}
+
+// Parse 读取 telegram_id,username,balance 格式的 CSV，首行为表头时跳过。
+// 格式错误的行记入 errors 并继续读取，文件本身无法读取时返回 error
+func Parse(r io.Reader) ([]Row, []RowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []Row
+	var errs []RowError
+	seen := make(map[int64]int)
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, RowError{Line: parseErr.StartLine, Message: fmt.Sprintf("CSV 格式错误: %v", parseErr.Err)})
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取 CSV 失败: %v", err)
+		}
+		// 空行已被跳过，按文件中的实际行号报告
+		line, _ := reader.FieldPos(0)
+		if first && isHeader(record) {
+			continue
+		}
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+		if len(rows)+len(errs) >= MaxRows {
+			return nil, nil, fmt.Errorf("单次最多导入 %d 行", MaxRows)
+		}
+
+		row, err := parseRow(line, record)
+		if err != nil {
+			errs = append(errs, RowError{Line: line, TelegramID: row.TelegramID, Message: err.Error()})
+			continue
+		}
+		if previous, ok := seen[row.TelegramID]; ok {
+			errs = append(errs, RowError{Line: line, TelegramID: row.TelegramID, Message: fmt.Sprintf("与第 %d 行的用户重复", previous)})
+			continue
+		}
+		seen[row.TelegramID] = line
+		rows = append(rows, row)
+	}
+	return rows, errs, nil
+}
+
+// Import 校验 CSV 并创建用户，每个用户的创建和迁移交易在同一事务中写入。
+// 已存在的用户不做修改并记为失败；dryRun 为 true 时只校验不写入
+func Import(db *database.DB, r io.Reader, dryRun bool) (*Report, error) {
+	rows, errs, err := Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{DryRun: dryRun, Total: len(rows) + len(errs), Errors: errs}
+	for _, row := range rows {
+		if err := importRow(db, row, dryRun); err != nil {
+			report.Errors = append(report.Errors, RowError{Line: row.Line, TelegramID: row.TelegramID, Message: err.Error()})
+			continue
+		}
+		report.Imported++
+		report.Balance += row.Balance
+	}
+
+	sort.Slice(report.Errors, func(i, j int) bool { return report.Errors[i].Line < report.Errors[j].Line })
+	return report, nil
+}
+
+// importRow 创建一个用户，试运行时只检查用户是否已存在
+func importRow(db *database.DB, row Row, dryRun bool) error {
+	if dryRun {
+		existing, err := db.GetUser(row.TelegramID)
+		if err != nil {
+			return fmt.Errorf("查询用户失败: %v", err)
+		}
+		if existing != nil {
+			return fmt.Errorf("用户 %d 已存在", row.TelegramID)
+		}
+		return nil
+	}
+
+	user := &models.User{
+		ID:       row.TelegramID,
+		Username: row.Username,
+		Balance:  row.Balance,
+		Source:   Source,
+	}
+	created, err := db.ImportUser(user, "迁移导入余额")
+	if err != nil {
+		return fmt.Errorf("创建用户失败: %v", err)
+	}
+	if !created {
+		return fmt.Errorf("用户 %d 已存在", row.TelegramID)
+	}
+	return nil
+}
+
+// parseRow 解析并校验一行，返回的 Row 在出错时也尽量带上 TelegramID 便于定位
+func parseRow(line int, record []string) (Row, error) {
+	row := Row{Line: line}
+	if len(record) != len(header) {
+		return row, fmt.Errorf("应有 %d 列，实际 %d 列", len(header), len(record))
+	}
+
+	id, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+	if err != nil || id <= 0 {
+		return row, fmt.Errorf("无效的 telegram_id: %q", record[0])
+	}
+	row.TelegramID = id
+
+	username := strings.TrimPrefix(strings.TrimSpace(record[1]), "@")
+	if username != "" && !validUsername(username) {
+		return row, fmt.Errorf("无效的用户名: %q", record[1])
+	}
+	row.Username = username
+
+	balance, err := parseBalance(record[2])
+	if err != nil {
+		return row, fmt.Errorf("无效的余额 %q: %v", record[2], err)
+	}
+	row.Balance = balance
+	return row, nil
+}
+
+// parseBalance 解析金币余额，与下注金额格式相同但允许为 0（如 0、0.00）
+func parseBalance(text string) (int64, error) {
+	text = strings.TrimSpace(text)
+	amount, err := utils.ParseAmount(text)
+	if err == nil {
+		return amount, nil
+	}
+	if integer, fraction, _ := strings.Cut(text, "."); integer != "" && strings.Trim(integer, "0") == "" &&
+		len(fraction) <= 2 && strings.Trim(fraction, "0") == "" && !strings.HasSuffix(text, ".") {
+		return 0, nil
+	}
+	return 0, err
+}
+
+// validUsername Telegram 用户名：5-32 位字母、数字或下划线
+func validUsername(username string) bool {
+	if len(username) < 5 || len(username) > 32 {
+		return false
+	}
+	for _, c := range username {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// isHeader 首行是否为表头
+func isHeader(record []string) bool {
+	if len(record) != len(header) {
+		return false
+	}
+	for i, name := range header {
+		if !strings.EqualFold(strings.TrimSpace(record[i]), name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// AmountScale 1 金币对应的基本单位数。余额、下注、手续费等金额在数据库和内存中
+// 均以基本单位（0.01 金币）的整数存储，只在输入和显示时换算为金币
+const AmountScale int64 = 100
+
+// amountDecimals 金币金额最多的小数位数，与 AmountScale 对应
+const amountDecimals = 2
+
+// maxAmountDigits 金额整数部分的最大位数，换算为基本单位后不会溢出
+const maxAmountDigits = 15
+
+// Coins 将整数金币换算为基本单位
+func Coins(coins int64) int64 {
+	return coins * AmountScale
+}
+
+// AmountFromFloat 将金币数（如后台提交的 12.5）换算为基本单位，按四舍五入处理浮点误差
+func AmountFromFloat(coins float64) int64 {
+	return int64(math.Round(coins * float64(AmountScale)))
+}
+
+// AmountToFloat 将基本单位换算为金币数，用于 JSON 接口输出
+func AmountToFloat(amount int64) float64 {
+	return float64(amount) / float64(AmountScale)
+}
+
+// FormatAmount 以两位小数显示金额，如 1250 显示为 12.50
+func FormatAmount(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/AmountScale, amount%AmountScale)
+}
+
+// ParseAmount 解析用户输入的金币金额，接受整数或最多两位小数（如 10、0.5、12.25），
+// 返回基本单位；只接受 ASCII 数字和小数点，金额必须大于 0
+func ParseAmount(text string) (int64, error) {
+	integer, fraction, hasPoint := strings.Cut(strings.TrimSpace(text), ".")
+	if integer == "" && fraction == "" {
+		return 0, fmt.Errorf("金额不能为空")
+	}
+	if hasPoint && fraction == "" {
+		return 0, fmt.Errorf("金额格式错误")
+	}
+	if len(fraction) > amountDecimals {
+		return 0, fmt.Errorf("金额最多保留 %d 位小数", amountDecimals)
+	}
+	if len(strings.TrimLeft(integer, "0")) > maxAmountDigits {
+		return 0, fmt.Errorf("金额过大")
+	}
+	for _, part := range []string{integer, fraction} {
+		for i := 0; i < len(part); i++ {
+			if part[i] < '0' || part[i] > '9' {
+				return 0, fmt.Errorf("金额格式错误")
+			}
+		}
+	}
+
+	var amount int64
+	if integer != "" {
+		whole, err := strconv.ParseInt(integer, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("金额格式错误")
+		}
+		amount = whole * AmountScale
+	}
+	if fraction != "" {
+		cents, err := strconv.ParseInt(fraction+strings.Repeat("0", amountDecimals-len(fraction)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("金额格式错误")
+		}
+		amount += cents
+	}
+	if amount <= 0 {
+		return 0, fmt.Errorf("金额必须大于 0")
+	}
+	return amount, nil
+}
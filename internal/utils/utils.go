@@ -4,14 +4,16 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// GenerateGameID 生成游戏ID
+// GenerateGameID 生成游戏ID（秒级时间戳 + 8位随机数，同一秒内大量建局也不易冲突）
 func GenerateGameID() string {
 	timestamp := time.Now().Unix()
-	randomNum, _ := rand.Int(rand.Reader, big.NewInt(10000))
-	return fmt.Sprintf("GAME%d%04d", timestamp, randomNum.Int64())
+	randomNum, _ := rand.Int(rand.Reader, big.NewInt(100000000))
+	return fmt.Sprintf("GAME%d%08d", timestamp, randomNum.Int64())
 }
 
 // GenerateTransactionID 生成交易ID
@@ -21,18 +23,135 @@ func GenerateTransactionID() string {
 	return fmt.Sprintf("TX%d%04d", timestamp, randomNum.Int64())
 }
 
+// GenerateTableID 生成快速桌ID（TABLE + 秒级时间戳 + 8位随机数）
+func GenerateTableID() string {
+	timestamp := time.Now().Unix()
+	randomNum, _ := rand.Int(rand.Reader, big.NewInt(100000000))
+	return fmt.Sprintf("TABLE%d%08d", timestamp, randomNum.Int64())
+}
+
+// GenerateTournamentID 生成锦标赛ID（TOUR + 秒级时间戳 + 8位随机数）
+func GenerateTournamentID() string {
+	timestamp := time.Now().Unix()
+	randomNum, _ := rand.Int(rand.Reader, big.NewInt(100000000))
+	return fmt.Sprintf("TOUR%d%08d", timestamp, randomNum.Int64())
+}
+
+// ValidateGameID 校验游戏ID格式（GAME + 时间戳 + 随机数），防止回调中夹带任意字符串
+func ValidateGameID(gameID string) error {
+	if !validPrefixedID(gameID, "GAME") {
+		return fmt.Errorf("无效的游戏ID")
+	}
+	return nil
+}
+
+// ValidateTableID 校验快速桌ID格式（TABLE + 时间戳 + 随机数）
+func ValidateTableID(tableID string) error {
+	if !validPrefixedID(tableID, "TABLE") {
+		return fmt.Errorf("无效的快速桌ID")
+	}
+	return nil
+}
+
+// validPrefixedID 检查ID是否为前缀加 5-24 位数字
+func validPrefixedID(id, prefix string) bool {
+	if !strings.HasPrefix(id, prefix) {
+		return false
+	}
+	digits := id[len(prefix):]
+	if len(digits) < 5 || len(digits) > 24 {
+		return false
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseBetArgs 解析 /dice 命令的下注金额参数，只接受一个正数（最多两位小数），返回基本单位
+func ParseBetArgs(args string) (int64, error) {
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("请输入下注金额，例如 /dice 100")
+	}
+	if len(fields) > 1 {
+		return 0, fmt.Errorf("参数过多，例如 /dice 100")
+	}
+
+	text := fields[0]
+	if len(text) > 18 {
+		return 0, fmt.Errorf("下注金额过大")
+	}
+
+	amount, err := ParseAmount(text)
+	if err != nil {
+		return 0, fmt.Errorf("下注金额必须是正数，最多两位小数，例如 /dice 12.5")
+	}
+	return amount, nil
+}
+
+// betAllWords 表示押上全部可用余额的金额表达式
+var betAllWords = map[string]bool{"all": true, "allin": true, "max": true, "全部": true, "梭哈": true}
+
+// betHalfWords 表示押上一半可用余额的金额表达式
+var betHalfWords = map[string]bool{"half": true, "一半": true}
+
+// ResolveBetAmount 解析下注金额，除具体金额外还支持 all、half 和百分比（如 25%），
+// 按可用余额换算后不超过单注上限；all 为 true 表示押上全部余额，需要玩家确认
+func ResolveBetAmount(args string, available, minBet, maxBet int64) (amount int64, all bool, err error) {
+	fields := strings.Fields(strings.ToLower(args))
+	if len(fields) != 1 {
+		// 空参数或多个参数，由金额解析给出统一的提示
+		_, err = ParseBetArgs(args)
+		return 0, false, err
+	}
+
+	expr := fields[0]
+	switch {
+	case betAllWords[expr]:
+		amount, all = available, true
+	case betHalfWords[expr]:
+		amount = available / 2
+	case strings.HasSuffix(expr, "%"):
+		percent, convErr := strconv.Atoi(strings.TrimSuffix(expr, "%"))
+		if convErr != nil || percent <= 0 || percent > 100 {
+			return 0, false, fmt.Errorf("百分比必须在 1%% 到 100%% 之间")
+		}
+		amount, all = available*int64(percent)/100, percent == 100
+	default:
+		amount, err = ParseBetArgs(expr)
+		if err != nil {
+			return 0, false, err
+		}
+		if err := ValidateBetAmount(amount, minBet, maxBet); err != nil {
+			return 0, false, err
+		}
+		return amount, false, nil
+	}
+
+	if amount > maxBet {
+		amount = maxBet
+	}
+	if amount < minBet {
+		return 0, false, fmt.Errorf("可用余额不足，按 %s 计算仅 %s 金币，最小下注金额为 %s", expr, FormatAmount(amount), FormatAmount(minBet))
+	}
+	return amount, all, nil
+}
+
 // FormatBalance 格式化余额显示
 func FormatBalance(balance int64) string {
-	return fmt.Sprintf("%d", balance)
+	return FormatAmount(balance)
 }
 
 // ValidateBetAmount 验证下注金额
 func ValidateBetAmount(amount int64, minBet, maxBet int64) error {
 	if amount < minBet {
-		return fmt.Errorf("最小下注金额为 %d", minBet)
+		return fmt.Errorf("最小下注金额为 %s", FormatAmount(minBet))
 	}
 	if amount > maxBet {
-		return fmt.Errorf("最大下注金额为 %d", maxBet)
+		return fmt.Errorf("最大下注金额为 %s", FormatAmount(maxBet))
 	}
 	return nil
 }
@@ -60,3 +179,31 @@ func SecureRandomInt(max int64) (int64, error) {
 	}
 	return n.Int64(), nil
 }
+
+// NormalizeStartParam 规范化 /start 深度链接参数，用作用户来源标识
+// Telegram 只允许 A-Z、a-z、0-9、_ 和 -，最长64个字符
+func NormalizeStartParam(param string) string {
+	param = strings.TrimSpace(param)
+	if len(param) > 64 {
+		param = param[:64]
+	}
+
+	var builder strings.Builder
+	for _, r := range param {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' || r == '-' {
+			builder.WriteRune(r)
+		}
+	}
+	return builder.String()
+}
+
+// AcquisitionSource 根据深度链接参数和首次接触的聊天生成来源标识
+func AcquisitionSource(startParam string, chatID int64) string {
+	if source := NormalizeStartParam(startParam); source != "" {
+		return source
+	}
+	if chatID < 0 {
+		return "group"
+	}
+	return "private"
+}
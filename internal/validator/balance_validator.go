@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/utils"
 )
 
 // BalanceValidator 余额验证器，提供实时资金校验保护
@@ -27,6 +28,13 @@ func NewBalanceValidator(db *database.DB) *BalanceValidator {
 	}
 }
 
+// SetOperationInterval 设置同一用户两次操作的最小间隔，0 表示不限制（测试中使用）
+func (v *BalanceValidator) SetOperationInterval(interval time.Duration) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.operationInterval = interval
+}
+
 // ValidateUserBalance 验证用户余额是否足够进行指定金额的操作
 func (v *BalanceValidator) ValidateUserBalance(userID int64, requiredAmount int64) error {
 	v.mutex.Lock()
@@ -49,13 +57,14 @@ func (v *BalanceValidator) ValidateUserBalance(userID int64, requiredAmount int6
 		return fmt.Errorf("用户不存在")
 	}
 
-	// 验证余额
-	if user.Balance < requiredAmount {
-		return fmt.Errorf("余额不足，请存款后再试。当前余额: %d，需要: %d", user.Balance, requiredAmount)
+	// 验证余额（下注时赠送余额同样可用）
+	spendable := user.Balance + user.BonusBalance
+	if spendable < requiredAmount {
+		return fmt.Errorf("余额不足，请存款后再试。当前余额: %s，需要: %s", utils.FormatAmount(spendable), utils.FormatAmount(requiredAmount))
 	}
 
 	// 二次验证：确保扣除后不会为负数
-	if user.Balance-requiredAmount < 0 {
+	if spendable-requiredAmount < 0 {
 		return fmt.Errorf("余额不足，请存款后再试")
 	}
 
@@ -65,6 +74,20 @@ func (v *BalanceValidator) ValidateUserBalance(userID int64, requiredAmount int6
 	return nil
 }
 
+// ThrottleUser 检查同一用户两次操作的最小间隔并记录本次操作，用于不涉及扣款的领取类操作（如每日签到）
+func (v *BalanceValidator) ThrottleUser(userID int64) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if lastOp, exists := v.userOperations[userID]; exists {
+		if time.Since(lastOp) < v.operationInterval {
+			return fmt.Errorf("操作过于频繁，请稍后再试")
+		}
+	}
+	v.userOperations[userID] = time.Now()
+	return nil
+}
+
 // ValidateGameOperation 验证游戏操作的余额要求
 func (v *BalanceValidator) ValidateGameOperation(userID int64, gameID string, operationType string) error {
 	// 获取游戏信息
@@ -96,9 +119,9 @@ func (v *BalanceValidator) ValidateTransactionSafety(userID int64, amount int64,
 	// 对于加款操作，检查是否合理
 	if amount > 0 {
 		// 防止异常大额加款
-		maxSingleCredit := int64(100000) // 最大单次加款限制
+		maxSingleCredit := utils.Coins(100000) // 最大单次加款限制（10 万金币）
 		if amount > maxSingleCredit {
-			return fmt.Errorf("单次加款金额过大: %d，最大允许: %d", amount, maxSingleCredit)
+			return fmt.Errorf("单次加款金额过大: %s，最大允许: %s", utils.FormatAmount(amount), utils.FormatAmount(maxSingleCredit))
 		}
 	}
 
@@ -0,0 +1,38 @@
+package verify
+
+import (
+	"strings"
+
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+)
+
+// Handler /verify 命令的处理器，玩家可在对局结束后获取种子并核对骰子
+type Handler struct {
+	manager *game.Manager
+}
+
+// NewHandler 创建公平性验证处理器
+func NewHandler(manager *game.Manager) *Handler {
+	return &Handler{manager: manager}
+}
+
+// Register 注册 /verify 命令
+func (h *Handler) Register(router *middleware.Router) {
+	router.Handle(ui.VerifyCommand, h.Verify)
+}
+
+// Verify 按对局ID返回承诺哈希，对局结束后同时公开种子和重算的骰子
+func (h *Handler) Verify(ctx *middleware.Context) error {
+	gameID := strings.TrimSpace(ctx.Args)
+	if gameID == "" {
+		return ctx.Reply("用法：/verify <对局ID>")
+	}
+
+	verification, err := h.manager.VerifyGame(gameID)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	return ctx.Reply(ui.FormatGameVerification(verification))
+}
@@ -0,0 +1,82 @@
+package withdraw
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// recentWithdrawalLimit 提现页面展示的最近提现条数
+const recentWithdrawalLimit = 5
+
+// CommandHandler /withdraw 命令和 💸 按钮的处理器
+type CommandHandler struct {
+	manager *Manager
+	db      *database.DB
+}
+
+// NewCommandHandler 创建提现命令处理器
+func NewCommandHandler(manager *Manager, db *database.DB) *CommandHandler {
+	return &CommandHandler{manager: manager, db: db}
+}
+
+// Register 注册 /withdraw 命令和 💸 按钮回调，仅限私聊
+func (h *CommandHandler) Register(router *middleware.Router) {
+	router.Handle(ui.WithdrawCommand, h.Handle, middleware.PrivateOnly())
+	router.HandleCallback(ui.WithdrawCommand, h.Handle, middleware.PrivateOnly())
+}
+
+// Handle 带金额和地址时提交提现申请，否则发送提现页面
+func (h *CommandHandler) Handle(ctx *middleware.Context) error {
+	if ctx.IsCallback() {
+		if _, err := ctx.Client.Request(tgbotapi.NewCallback(ctx.Update.CallbackQuery.ID, "")); err != nil {
+			log.Printf("⚠️ 应答提现按钮失败: %v", err)
+		}
+		return h.info(ctx)
+	}
+
+	args := strings.Fields(ctx.Args)
+	if len(args) == 0 {
+		return h.info(ctx)
+	}
+	if len(args) != 2 {
+		return ctx.Reply(ui.FormatWithdrawUsage())
+	}
+
+	amount, err := utils.ParseAmount(args[0])
+	if err != nil {
+		return ctx.Reply("⚠️ " + err.Error() + "\n" + ui.FormatWithdrawUsage())
+	}
+	withdrawal, balance, err := h.manager.Request(ctx.UserID, amount, args[1])
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	return ctx.Reply(ui.FormatWithdrawRequested(withdrawal, balance))
+}
+
+// info 发送可提现余额、最低金额、兑换比例和最近提现
+func (h *CommandHandler) info(ctx *middleware.Context) error {
+	user, err := h.db.GetUser(ctx.UserID)
+	if err != nil {
+		return fmt.Errorf("获取用户信息失败: %v", err)
+	}
+	var balance int64
+	if user != nil {
+		balance = user.Balance
+	}
+
+	withdrawals, err := h.manager.UserWithdrawals(ctx.UserID, recentWithdrawalLimit)
+	if err != nil {
+		return fmt.Errorf("获取提现记录失败: %v", err)
+	}
+	text := ui.FormatWithdrawInfo(balance, h.manager.MinAmount(), h.manager.Rate(), withdrawals)
+	_, err = ctx.Client.Send(tgbotapi.NewMessage(ctx.ChatID, text))
+	return err
+}
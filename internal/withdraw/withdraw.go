@@ -0,0 +1,150 @@
+package withdraw
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// base58Alphabet TRC20 地址使用的 Base58 字符集
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// Manager 提现申请管理：申请时冻结余额，管理员批准或拒绝，拒绝时自动退回
+type Manager struct {
+	db        *database.DB
+	minAmount int64   // 单笔最低提现金币
+	rate      float64 // 1 USDT 兑换的金币数
+	// 新提现申请和审核完成后的回调
+	onRequested func(withdrawal *models.Withdrawal)
+	onReviewed  func(withdrawal *models.Withdrawal)
+}
+
+// NewManager 创建提现管理器
+func NewManager(db *database.DB, minAmount int64, rate float64) *Manager {
+	return &Manager{db: db, minAmount: minAmount, rate: rate}
+}
+
+// NewManagerFromConfig 按配置创建提现管理器，沙盒模式或未配置兑换比例时返回 nil 表示不启用提现
+func NewManagerFromConfig(db *database.DB, cfg *config.Config) *Manager {
+	if cfg.Sandbox {
+		log.Printf("ℹ️ 沙盒模式不支持真实提现，/withdraw 已禁用")
+		return nil
+	}
+	if cfg.RechargeRate <= 0 {
+		log.Printf("ℹ️ 未配置兑换比例，/withdraw 已禁用")
+		return nil
+	}
+	return NewManager(db, cfg.WithdrawMinAmount, cfg.RechargeRate)
+}
+
+// SetRequestedCallback 设置用户提交提现申请后的回调（通知管理员审核）
+func (m *Manager) SetRequestedCallback(callback func(withdrawal *models.Withdrawal)) {
+	m.onRequested = callback
+}
+
+// SetReviewedCallback 设置提现申请被批准或拒绝后的回调（通知用户）
+func (m *Manager) SetReviewedCallback(callback func(withdrawal *models.Withdrawal)) {
+	m.onReviewed = callback
+}
+
+// MinAmount 单笔最低提现金币
+func (m *Manager) MinAmount() int64 {
+	return m.minAmount
+}
+
+// Rate 1 USDT 兑换的金币数
+func (m *Manager) Rate() float64 {
+	return m.rate
+}
+
+// ValidAddress 校验 TRC20 地址格式：T 开头、34 位 Base58 字符
+func ValidAddress(address string) bool {
+	if len(address) != 34 || !strings.HasPrefix(address, "T") {
+		return false
+	}
+	for _, c := range address {
+		if !strings.ContainsRune(base58Alphabet, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// Request 提交提现申请并冻结金额，返回申请和冻结后的余额
+func (m *Manager) Request(userID, amount int64, address string) (*models.Withdrawal, int64, error) {
+	if amount < m.minAmount {
+		return nil, 0, fmt.Errorf("单笔最低提现 %s 金币", utils.FormatAmount(m.minAmount))
+	}
+	if !ValidAddress(address) {
+		return nil, 0, fmt.Errorf("收款地址格式不正确，请填写 TRC20 USDT 地址")
+	}
+
+	withdrawal := &models.Withdrawal{
+		UserID:     userID,
+		Amount:     amount,
+		USDTAmount: m.USDTAmount(amount),
+		Address:    address,
+	}
+	balance, err := m.db.CreateWithdrawal(withdrawal)
+	if err != nil {
+		return nil, 0, err
+	}
+	log.Printf("💸 用户 %d 申请提现 #%d: %s 金币 -> %.2f USDT", userID, withdrawal.ID, utils.FormatAmount(amount), withdrawal.USDTAmount)
+
+	if m.onRequested != nil {
+		go m.onRequested(withdrawal)
+	}
+	return withdrawal, balance, nil
+}
+
+// USDTAmount 按兑换比例折算金币对应的 USDT，向下保留两位小数
+func (m *Manager) USDTAmount(amount int64) float64 {
+	// 金额以 0.01 金币为单位，除以兑换比例即为 0.01 USDT 的个数
+	return math.Floor(float64(amount)/m.rate+1e-9) / 100
+}
+
+// Approve 批准提现申请，txHash 为线下转账的交易哈希，可为空
+func (m *Manager) Approve(id int64, reviewer, txHash string) (*models.Withdrawal, error) {
+	if err := m.db.ApproveWithdrawal(id, reviewer, strings.TrimSpace(txHash)); err != nil {
+		return nil, err
+	}
+	log.Printf("✅ 提现申请 #%d 已由 %s 批准", id, reviewer)
+	return m.reviewed(id)
+}
+
+// Reject 拒绝提现申请，冻结的金额自动退回用户余额
+func (m *Manager) Reject(id int64, reviewer, reason string) (*models.Withdrawal, error) {
+	if err := m.db.RejectWithdrawal(id, reviewer, strings.TrimSpace(reason)); err != nil {
+		return nil, err
+	}
+	log.Printf("↩️ 提现申请 #%d 已由 %s 拒绝并退款", id, reviewer)
+	return m.reviewed(id)
+}
+
+// Withdrawals 按状态获取提现申请，status 为空时不限状态
+func (m *Manager) Withdrawals(status string, limit int) ([]*models.Withdrawal, error) {
+	return m.db.GetWithdrawals(status, limit)
+}
+
+// UserWithdrawals 获取用户最近的提现申请
+func (m *Manager) UserWithdrawals(userID int64, limit int) ([]*models.Withdrawal, error) {
+	return m.db.GetUserWithdrawals(userID, limit)
+}
+
+// reviewed 读取审核后的申请并触发回调
+func (m *Manager) reviewed(id int64) (*models.Withdrawal, error) {
+	withdrawal, err := m.db.GetWithdrawal(id)
+	if err != nil {
+		return nil, fmt.Errorf("获取提现申请失败: %v", err)
+	}
+	if withdrawal != nil && m.onReviewed != nil {
+		go m.onReviewed(withdrawal)
+	}
+	return withdrawal, nil
+}
@@ -1,16 +1,87 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/joho/godotenv"
-	"telegram-dice-bot/internal/bot"
+	"telegram-dice-bot/internal/access"
+	"telegram-dice-bot/internal/auth"
+	"telegram-dice-bot/internal/balance"
+	"telegram-dice-bot/internal/broadcast"
+	"telegram-dice-bot/internal/cache"
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/chatsettings"
 	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/cooldown"
+	"telegram-dice-bot/internal/daily"
 	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/deadletter"
+	"telegram-dice-bot/internal/dice"
+	"telegram-dice-bot/internal/dispute"
+	"telegram-dice-bot/internal/eligibility"
+	"telegram-dice-bot/internal/events"
+	"telegram-dice-bot/internal/exposure"
+	"telegram-dice-bot/internal/feed"
 	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/help"
+	"telegram-dice-bot/internal/history"
+	"telegram-dice-bot/internal/lobby"
+	"telegram-dice-bot/internal/locale"
+	"telegram-dice-bot/internal/maintenance"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/migration"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/monitor"
+	"telegram-dice-bot/internal/network"
+	"telegram-dice-bot/internal/notify"
+	"telegram-dice-bot/internal/outbox"
+	"telegram-dice-bot/internal/ping"
+	"telegram-dice-bot/internal/pool"
+	"telegram-dice-bot/internal/queue"
+	"telegram-dice-bot/internal/rank"
+	"telegram-dice-bot/internal/ready"
+	"telegram-dice-bot/internal/recharge"
+	"telegram-dice-bot/internal/reconcile"
+	"telegram-dice-bot/internal/referral"
+	"telegram-dice-bot/internal/rematch"
+	"telegram-dice-bot/internal/report"
+	"telegram-dice-bot/internal/risk"
+	"telegram-dice-bot/internal/rules"
+	_ "telegram-dice-bot/internal/rules/plugins"
+	"telegram-dice-bot/internal/sandbox"
+	"telegram-dice-bot/internal/settings"
+	"telegram-dice-bot/internal/shard"
+	"telegram-dice-bot/internal/stats"
+	"telegram-dice-bot/internal/streak"
+	"telegram-dice-bot/internal/table"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/timeline"
+	"telegram-dice-bot/internal/tournament"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/uptime"
+	"telegram-dice-bot/internal/userimport"
+	"telegram-dice-bot/internal/utils"
+	"telegram-dice-bot/internal/verify"
+	"telegram-dice-bot/internal/withdraw"
+	admin "telegram-dice-bot/web/admin/handlers"
+)
+
+const (
+	// profileSyncInterval 同一用户两次同步资料的最小间隔
+	profileSyncInterval = 10 * time.Minute
+	// refreshDebounce 刷新按钮（余额、排行榜、对局大厅）同一用户两次刷新的最小间隔
+	refreshDebounce = 2 * time.Second
+	// rankingTTL 排行榜缓存时长，rankingLimit 每个榜单显示的人数
+	rankingTTL   = 5 * time.Minute
+	rankingLimit = 10
 )
 
 func run() {
@@ -31,30 +102,424 @@ func run() {
 	}
 	defer db.Close()
 
+	// 沙盒与正式环境的数据库互不混用
+	if err := sandbox.Prepare(db, cfg.Sandbox); err != nil {
+		log.Fatal("数据库模式校验失败:", err)
+	}
+
+	// 网络加速器阈值配置有误时拒绝启动
+	if cfg.EnableAccelerator {
+		if _, err := network.ThresholdsFromConfig(cfg); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	// 访问 Telegram 的连接：可选经代理，启动时自检连通性，代理地址或认证有误时拒绝启动
+	accelerator, err := network.NewNetworkAcceleratorWithProxy(network.ProxyConfig{
+		URL:      cfg.ProxyURL,
+		Username: cfg.ProxyUsername,
+		Password: cfg.ProxyPassword,
+	}, cfg.BotAPIURL)
+	if err != nil {
+		log.Fatal("代理配置有误:", err)
+	}
+	defer accelerator.Stop()
+	selfTestCtx, cancelSelfTest := context.WithTimeout(context.Background(), 15*time.Second)
+	err = accelerator.SelfTest(selfTestCtx)
+	cancelSelfTest()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if proxy := accelerator.ProxyInfo(); proxy != "" {
+		log.Printf("🌐 通过代理访问 Telegram: %s", proxy)
+	}
+
+	// 性能监控：请求耗时、错误数和缓存命中率，定期输出报告，并作为 Prometheus 指标提供
+	perfMonitor := monitor.NewPerformanceMonitor()
+	perfMonitor.SetDebugAddr(cfg.DebugAddr)
+	perfMonitor.Start()
+	defer perfMonitor.Stop()
+
 	// 创建游戏管理器
 	gameManager := game.NewManager(db, cfg, cfg.FeeRate)
+	// 开局公告附带老对手的交手记录
+	headToHead := cache.NewHeadToHeadCache(db, 10*time.Minute)
+	headToHead.SetObserver(perfMonitor)
+	gameManager.SetHeadToHeadCache(headToHead)
+	// /stats 个人战绩缓存，对局结束后失效
+	userStats := cache.NewUserStatsCache(db, 10*time.Minute)
+	userStats.SetObserver(perfMonitor)
+	gameManager.SetUserStatsCache(userStats)
+	// /history 最近对局记录缓存，对局结束后失效
+	gameHistory := cache.NewGameHistoryCache(db, ui.HistoryGames)
+	gameHistory.SetObserver(perfMonitor)
+	gameManager.SetGameHistoryCache(gameHistory)
+	// 长期无活动的群组标记为休眠
+	gameManager.SetChatDormancy(time.Duration(cfg.ChatDormantDays) * 24 * time.Hour)
+	// 玩法插件配置有误时拒绝启动
+	gameRules, err := rules.Load(cfg.GameRules)
+	if err != nil {
+		log.Fatal(err)
+	}
+	gameManager.SetRules(gameRules)
+	if len(cfg.GameRules) > 0 {
+		log.Printf("🧩 已启用玩法插件: %v", gameRules.Names())
+	}
+
+	// 调试服务：仅限本机访问的 pprof 和运行时统计，用于排查线上内存和协程泄漏
+	if cfg.DebugAddr != "" {
+		debugServer, err := monitor.NewDebugServer(cfg.DebugAddr)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := debugServer.Start(); err != nil {
+			log.Fatal(err)
+		}
+		defer debugServer.Stop()
+	}
 
-	// 创建机器人实例
-	telegramBot, err := bot.NewBot(cfg, db, gameManager)
+	// 分片：多个进程共享数据库时按 chatID mod SHARD_COUNT 划分群组，各进程只处理本分片的群组，
+	// 全局单例任务只由持有 0 号分片锁的进程执行
+	shardCoordinator, err := shard.NewCoordinator(db, int(cfg.ShardID), int(cfg.ShardCount))
 	if err != nil {
-		log.Fatal("创建机器人失败:", err)
+		log.Fatal("创建分片协调器失败:", err)
+	}
+	shardCoordinator.Start()
+	defer shardCoordinator.Stop()
+	if cfg.ShardCount > 1 {
+		log.Printf("🧩 分片 %d/%d", cfg.ShardID, cfg.ShardCount)
+	}
+
+	// Telegram 客户端：接收更新和发送消息，与加速器共用经代理的连接池
+	client, err := telegram.NewAPIClientWithEndpoint(cfg.BotToken, cfg.BotAPIURL, accelerator.HTTPClient())
+	if err != nil {
+		log.Fatal("创建Telegram客户端失败:", err)
+	}
+	// 对局开始时预热上述连接池，对局期间定期保活，避免空闲后首次发送骰子时重新握手
+	gameManager.SetGameLifecycleCallbacks(accelerator.GameStarted, accelerator.GameFinished)
+	notifier := notify.NewNotifier(db, client)
+	// 管理员移出排队请求时私信通知玩家
+	gameManager.SetQueueRemovedCallback(notifier.QueueRemoved)
+	// 开骰后长时间未结算、被管理员强制中止或服务关闭前未能结算的对局退款，并在群内和私信通知
+	gameManager.SetMaxGameDuration(time.Duration(cfg.MaxGameDuration) * time.Second)
+	gameManager.SetGameAbortedCallback(notifier.GameAborted)
+	// 结算迟迟未完成时提前私信双方，说明会自动退款，减少故障期间的客服咨询
+	gameManager.SetSettlementDelayNotice(time.Duration(cfg.SettlementDelayNotice) * time.Second)
+	gameManager.SetSettlementDelayedCallback(notifier.SettlementDelayed)
+	// 对局结果争议：提出后提醒管理员审核，审核结果私信通知双方
+	gameManager.SetDisputeOpenedCallback(notifier.DisputeOpened(cfg.AlertChatID))
+	gameManager.SetDisputeResolvedCallback(notifier.DisputeResolved)
+	// 风控：结算后检查串通、胜率异常和筹码转移，标记后向管理员告警，可选冻结账户
+	riskEngine := risk.NewEngine(db, risk.Rules{
+		Window:      time.Duration(cfg.RiskWindow) * time.Hour,
+		PairGames:   int(cfg.RiskPairGames),
+		MinGames:    int(cfg.RiskMinGames),
+		WinRate:     cfg.RiskWinRate,
+		NetTransfer: cfg.RiskNetTransfer,
+	}, cfg.RiskAutoFreeze)
+	riskEngine.SetFlaggedCallback(notifier.RiskAlert(cfg.AlertChatID))
+	gameManager.SetRiskEngine(riskEngine)
+	// 群组反复触发每小时下注上限时向管理员告警
+	gameManager.SetExposureAlertCallback(notifier.ExposureAlert(cfg.AlertChatID))
+	if cfg.ChatHourlyCap > 0 {
+		log.Printf("🛡️ 群组每小时下注上限: %s", utils.FormatAmount(cfg.ChatHourlyCap))
+	}
+	// 发起者注销 Telegram 账号后，其等待中的对局立即过期退款，私信失败不影响退款
+	onAccountDeleted := func(userID int64) {
+		if _, err := gameManager.HandleAccountDeleted(userID); err != nil {
+			log.Printf("⚠️ 处理已注销用户 %d 失败: %v", userID, err)
+		}
+	}
+	notifier.SetAccountDeletedCallback(onAccountDeleted)
+	accountWatcher := notify.NewAccountWatcher(db, client, 30*time.Second, onAccountDeleted)
+	accountWatcher.Start()
+	defer accountWatcher.Stop()
+
+	// 提现申请：冻结余额后提醒管理员审核，审核结果私信通知用户
+	withdrawManager := withdraw.NewManagerFromConfig(db, cfg)
+	if withdrawManager != nil {
+		withdrawManager.SetRequestedCallback(notifier.WithdrawalRequested(cfg.AlertChatID))
+		withdrawManager.SetReviewedCallback(notifier.WithdrawalReviewed)
+		log.Printf("💸 提现已启用: 单笔最低 %s", utils.FormatAmount(withdrawManager.MinAmount()))
+	}
+
+	// 链上充值检测：已分配地址收到 USDT 后自动入账并私信通知
+	rechargeManager, err := recharge.NewRechargeManagerFromConfig(db, cfg)
+	if err != nil {
+		log.Fatal("创建充值管理器失败:", err)
+	}
+	if rechargeManager != nil {
+		if depositWatcher := recharge.NewWatcherFromConfig(rechargeManager, cfg); depositWatcher != nil {
+			depositWatcher.SetCreditedCallback(notifier.DepositCredited)
+			depositWatcher.SetGate(shardCoordinator.Leader)
+			depositWatcher.Start()
+			defer depositWatcher.Stop()
+			log.Printf("⛓️ 链上充值检测已启用: 每 %d 秒查询一次", cfg.RechargeWatchInterval)
+		}
+	}
+
+	// 后台任务工作池：执行失败的任务记入死信队列，修复后可在管理后台重放
+	workerPool := pool.NewWorkerPool(0, 1000)
+	deadLetters := deadletter.NewQueue(db, workerPool)
+	deadLetters.HandleMessages(client)
+	workerPool.Start()
+	defer workerPool.Stop()
+
+	// 发件箱：对局结算消息随结算写入数据库，由后台按群组顺序投递，进程崩溃重启后继续补发
+	outboxSender := outbox.NewSender(db, client)
+	outboxSender.SetGate(shardCoordinator.Leader)
+	outboxSender.Start()
+	defer outboxSender.Stop()
+
+	// 管理员公告：按速率限制经工作池投递到群组和用户，投递结果记录在数据库中，重启后继续发送
+	broadcaster := broadcast.NewBroadcaster(db, client, workerPool, int(cfg.BroadcastRate))
+	broadcaster.SetGate(shardCoordinator.Leader)
+	broadcaster.Start()
+	defer broadcaster.Stop()
+
+	// 锦标赛：报名截止后抽签开赛，按间隔自动进行单败淘汰赛并在群内公布结果，进度保存在数据库中，重启后继续
+	tournamentScheduler := tournament.NewScheduler(gameManager, client, time.Duration(cfg.TournamentRoundInterval)*time.Second)
+	tournamentScheduler.SetGate(shardCoordinator.Leader)
+	tournamentScheduler.Start()
+	defer tournamentScheduler.Stop()
+
+	// 事件总线：大奖频道转发和跨群连胜播报订阅对局结算事件
+	bus := events.NewBus()
+	gameManager.SetEventBus(bus)
+	if cfg.FeedChannelID != 0 {
+		feed.NewRelay(db, client, cfg.FeedChannelID, cfg.FeedMinStake, time.Duration(cfg.FeedMinGap)*time.Second).Subscribe(bus)
+		log.Printf("📣 大奖频道转发已启用: %d（下注 ≥ %s）", cfg.FeedChannelID, utils.FormatAmount(cfg.FeedMinStake))
+	}
+	if cfg.StreakMinChats > 0 {
+		streak.NewTracker(db, client, time.Duration(cfg.StreakWindow)*time.Minute, int(cfg.StreakMinChats)).Subscribe(bus)
+	}
+
+	// 数据库维护：低峰时段定期执行，管理后台可手动触发
+	var window maintenance.Window
+	if cfg.DBMaintenanceWindow != "" {
+		if window, err = maintenance.ParseWindow(cfg.DBMaintenanceWindow); err != nil {
+			log.Fatal("解析数据库维护时段失败:", err)
+		}
+	}
+	hostname, _ := os.Hostname()
+	maintainer := maintenance.NewScheduler(db, window, time.Duration(cfg.DBMaintenanceInterval)*time.Hour,
+		int(cfg.DBVacuumPages), fmt.Sprintf("%s:%d", hostname, os.Getpid()))
+	if cfg.DBMaintenanceWindow != "" {
+		maintainer.Start()
+		defer maintainer.Stop()
+		log.Printf("🧹 数据库维护时段: %s", cfg.DBMaintenanceWindow)
+	}
+
+	// Prometheus 指标：对局和结算、请求错误与响应时间、缓存命中率、协程数及数据库大小
+	if cfg.MetricsAddr != "" {
+		metricsServer, err := monitor.NewMetricsServer(cfg.MetricsAddr, gameManager.Metrics(), perfMonitor, maintainer)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := metricsServer.Start(); err != nil {
+			log.Fatal(err)
+		}
+		defer metricsServer.Stop()
+	}
+
+	// 余额对账：每晚按流水重算用户余额，差异记录后告警，可选冻结差异账户
+	var reconcileWindow maintenance.Window
+	if cfg.ReconcileWindow != "" {
+		if reconcileWindow, err = maintenance.ParseWindow(cfg.ReconcileWindow); err != nil {
+			log.Fatal("解析余额对账时段失败:", err)
+		}
+	}
+	reconciler := reconcile.NewReconciler(db, reconcileWindow, cfg.ReconcileAutoFreeze, fmt.Sprintf("%s:%d", hostname, os.Getpid()))
+	reconciler.SetDiscrepancyCallback(notifier.ReconcileAlert(cfg.AlertChatID))
+	if cfg.ReconcileWindow != "" {
+		reconciler.Start()
+		defer reconciler.Stop()
+		log.Printf("🧾 余额对账时段: %s", cfg.ReconcileWindow)
+	}
+
+	// 每周战报：每周一私信订阅用户上一周的汇总，经发件箱发送
+	if cfg.WeeklySummaryWindow != "" {
+		summaryWindow, err := maintenance.ParseWindow(cfg.WeeklySummaryWindow)
+		if err != nil {
+			log.Fatal("解析每周战报时段失败:", err)
+		}
+		weeklySummary := report.NewWeekly(db, summaryWindow, outboxSender.Notify)
+		weeklySummary.Start()
+		defer weeklySummary.Stop()
+		log.Printf("📅 每周战报时段: 每周一 %s", cfg.WeeklySummaryWindow)
 	}
 
-	// 设置游戏超时回调
-	gameManager.SetGameExpiredCallback(telegramBot.OnGameExpired)
+	// 可用性记录：进程启停和心跳写入数据库，管理后台按月统计 SLA
+	uptimeTracker := uptime.NewTracker(db, fmt.Sprintf("%s:%d", hostname, os.Getpid()), uptime.DefaultGapThreshold)
+	if err := uptimeTracker.Start(); err != nil {
+		log.Printf("⚠️ %v", err)
+	} else {
+		defer uptimeTracker.Stop()
+	}
+
+	// 管理后台：审核提现、争议、对账差异和风控事件，调整群组设置，需登录
+	if cfg.AdminAddr != "" {
+		authenticator, err := auth.New(cfg.AdminUsername, cfg.AdminPassword)
+		if err != nil {
+			log.Printf("⚠️ %v，管理后台未启用", err)
+		} else {
+			adminHandler := admin.NewAdminHandler(db, gameManager, authenticator)
+			adminHandler.SetMaintenance(maintainer)
+			adminHandler.SetDeadLetters(deadLetters)
+			adminHandler.SetUptime(uptimeTracker)
+			adminHandler.SetWithdrawals(withdrawManager)
+			adminHandler.SetReconciler(reconciler)
+			adminHandler.SetRiskEngine(riskEngine)
+			adminHandler.SetBroadcaster(broadcaster)
+			adminServer, err := admin.NewServer(cfg.AdminAddr, adminHandler)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := adminServer.Start(); err != nil {
+				log.Fatal(err)
+			}
+			defer adminServer.Stop()
+		}
+	}
 
-	// 启动机器人
-	go func() {
-		if err := telegramBot.Start(); err != nil {
-			log.Fatal("启动机器人失败:", err)
+	// 对局超时退款后在群内通知，并私信通知发起者
+	gameManager.SetGameExpiredCallback(func(gameID string, chatID int64) {
+		msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("⏰ 对局 %s 超时无人加入，下注已退还", gameID))
+		if _, err := client.Send(msg); err != nil {
+			log.Printf("⚠️ 发送超时通知失败: %v", err)
 		}
-	}()
+		notifier.GameExpired(gameID)
+	})
+
+	// 路由：命令和回调经统一的中间件分发到各功能模块
+	codec := callback.NewCodec(cfg.CallbackSecret)
+	languages := locale.NewDetector(db)
+	checker := eligibility.NewChecker(db)
+	profiles := cache.NewProfileSyncer(db, profileSyncInterval)
+	routing := []middleware.Middleware{
+		middleware.Recover(),
+		middleware.Logging(),
+		middleware.Metrics(perfMonitor),
+		// 只处理本分片的群组，其他分片的群组不会收到任何回复，也不占用服务名额
+		middleware.ShardOwner(shardCoordinator.Owns),
+		access.NewGuard(db, cfg).Middleware(),
+	}
+	// 容量模式：同时服务的群组达到上限后，新群组进入等待队列；机器人离开群组时释放名额并通知排队最早的群组
+	if cfg.MaxActiveChats > 0 {
+		routing = append(routing, middleware.Capacity(func(chatID int64) (bool, int, error) {
+			return db.AdmitChat(chatID, int(cfg.MaxActiveChats))
+		}))
+		log.Printf("🚦 同时服务的群组上限: %d", cfg.MaxActiveChats)
+	}
+	routing = append(routing,
+		middleware.EnsureUser(func(from *tgbotapi.User) (*models.User, error) {
+			user := &models.User{ID: from.ID, Username: from.UserName, FirstName: from.FirstName, LastName: from.LastName}
+			if _, err := db.EnsureUser(user); err != nil {
+				return nil, err
+			}
+			if _, err := profiles.Sync(from.ID, from.UserName, from.FirstName, from.LastName); err != nil {
+				log.Printf("⚠️ 同步用户 %d 资料失败: %v", from.ID, err)
+			}
+			return user, nil
+		}),
+		// 任意命令或回调处理成功后记录用户来源（深度链接参数或首次接触的群组），供管理后台统计获客渠道
+		middleware.Attribution(db.RecordUserAttribution),
+		middleware.BanCheck(db.GetUserBan),
+		middleware.WithLanguage(languages.Resolve),
+	)
+	router := middleware.NewRouter(client, routing...)
+	router.HandleMembership(middleware.ReleaseOnLeave(db.ReleaseChat))
+
+	diceHandler := dice.NewHandler(db, gameManager, codec, client)
+	diceHandler.SetEligibility(checker.Check)
+	rematchHandler := rematch.NewHandler(db, gameManager, client, codec)
+	rematchHandler.SetEligibility(checker.Check)
+	rematchHandler.Subscribe(bus)
+	tableHandler := table.NewHandler(gameManager, codec, client, cfg.MinBet)
+	tableHandler.SetEligibility(checker.Check)
+	tournamentHandler := tournament.NewHandler(gameManager, tournamentScheduler, cfg.AdminIDs, time.Duration(cfg.TournamentSignup)*time.Minute)
+	tournamentHandler.SetEligibility(checker.Check)
+	referralHandler := referral.NewHandler(db, client, cfg)
+	referralHandler.SetNotifier(notifier.Send)
+	gameLobby := lobby.NewLobby(db, client, codec, refreshDebounce)
+	gameLobby.Subscribe(bus)
+
+	diceHandler.Register(router)
+	rematchHandler.Register(router)
+	tableHandler.Register(router)
+	tournamentHandler.Register(router)
+	referralHandler.Register(router)
+	gameLobby.Register(router)
+	ready.NewHandler(db, gameManager, client, codec).Register(router)
+	queue.NewHandler(gameManager, codec).Register(router)
+	dispute.NewHandler(gameManager, codec).Register(router)
+	verify.NewHandler(gameManager).Register(router)
+	daily.NewHandler(gameManager, cfg).Register(router)
+	balance.NewHandler(db, cache.NewBalanceCache(db), codec, refreshDebounce).Register(router)
+	history.NewHandler(db, gameHistory, client, codec).Register(router)
+	stats.NewHandler(db, userStats).Register(router)
+	rank.NewHandler(cache.NewRankingCache(db, rankingTTL, rankingLimit), client, codec, refreshDebounce).Register(router)
+	help.NewHandler(db, cfg, codec).Register(router)
+	settings.NewHandler(db, gameManager, codec).Register(router)
+	languages.Register(router)
+	chatsettings.NewHandler(db, codec, cfg.AdminIDs).Register(router)
+	eligibility.NewHandler(db).Register(router)
+	cooldown.NewHandler(gameManager).Register(router)
+	ping.NewHandler(db, workerPool, cfg.AdminIDs).Register(router)
+	timeline.NewHandler(gameManager, cfg.AdminIDs).Register(router)
+	exposure.NewHandler(gameManager, cfg.AdminIDs).Register(router)
+	network.NewHandler(accelerator, cfg.AdminIDs).Register(router)
+	broadcast.NewHandler(broadcaster, cfg.AdminIDs).Register(router)
+	if rechargeManager != nil {
+		recharge.NewCommandHandler(rechargeManager, cfg).Register(router)
+	}
+	if withdrawManager != nil {
+		withdraw.NewCommandHandler(withdrawManager, db).Register(router)
+	}
+	if cfg.Sandbox {
+		sandbox.NewHandler(db, cfg.SandboxBalance).Register(router)
+	}
+
+	// 长轮询拉取更新并分发到路由；普通消息用于识别群组语言、记录群成员和自定义赌注回复，
+	// 积压很久才处理的消息记为服务中断
+	poller := middleware.NewPoller(client, router)
+	if cfg.ShardCount > 1 {
+		// 分片部署：Telegram 同一 Token 只允许一个 getUpdates 请求，由 0 号分片的进程拉取更新写入共享队列，
+		// 各进程从队列领取本分片的群组
+		receiver := shard.NewReceiver(db, client)
+		receiver.SetGate(shardCoordinator.Leader)
+		receiver.Start()
+		defer receiver.Stop()
+		poller.SetSource(shard.NewQueue(shardCoordinator))
+	} else {
+		// 重启后从上次处理完的 update_id 继续拉取，并发处理中的更新不会被跳过
+		updateTracker, err := telegram.NewUpdateTracker(db, telegram.DefaultDedupWindow)
+		if err != nil {
+			log.Fatal(err)
+		}
+		poller.SetUpdateTracker(updateTracker)
+	}
+	poller.Observe(func(update *tgbotapi.Update) {
+		if msg := update.Message; msg != nil && msg.Chat != nil && msg.From != nil {
+			uptimeTracker.ObserveUpdate(msg.Time())
+			languages.Observe(msg)
+			checker.Observe(msg.Chat.ID, msg.From.ID)
+			diceHandler.HandleReply(msg)
+		}
+		if member := update.MyChatMember; member != nil {
+			languages.ObserveJoin(member)
+		}
+	})
+	poller.Start()
 
 	log.Printf("🎲 Telegram骰子机器人已启动")
 	log.Printf("📊 配置信息:")
-	log.Printf("   - 数据库: %s", cfg.DatabaseURL)
+	log.Printf("   - 数据库: %s", database.DisplayURL(cfg.DatabaseURL))
 	log.Printf("   - 手续费率: %.1f%%", cfg.FeeRate*100)
-	log.Printf("   - 下注范围: %d - %d", cfg.MinBet, cfg.MaxBet)
+	log.Printf("   - 下注范围: %s - %s", utils.FormatAmount(cfg.MinBet), utils.FormatAmount(cfg.MaxBet))
+	if cfg.Sandbox {
+		log.Printf("   - 沙盒模式: 练习金币 %s", utils.FormatAmount(cfg.SandboxBalance))
+	}
 
 	// 等待中断信号
 	c := make(chan os.Signal, 1)
@@ -62,11 +527,80 @@ func run() {
 	<-c
 
 	log.Printf("🛑 正在关闭服务...")
-	
-	telegramBot.Stop()
+
+	// 停止接受新的对局，等待进行中的对局结算，超时未结算的退款后再停止机器人
+	settled, refunded, err := gameManager.Drain(time.Duration(cfg.ShutdownTimeout) * time.Second)
+	if err != nil {
+		log.Printf("⚠️ 等待对局结算失败: %v", err)
+	} else if settled > 0 || refunded > 0 {
+		log.Printf("🏁 关闭前结算 %d 局，退款 %d 局", settled, refunded)
+	}
+
+	poller.Stop()
 	log.Printf("✅ 服务已关闭")
 }
 
+// verifyMigrations 在副本上校验迁移并打印报告，返回进程退出码
+func verifyMigrations(source string) int {
+	report, err := migration.Verify(source)
+	if err != nil {
+		log.Printf("❌ 迁移校验失败: %v", err)
+		return 2
+	}
+	fmt.Println(report)
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+// importUsers 从 CSV 导入其他机器人的用户和余额并打印报告，返回进程退出码
+func importUsers(path string, dryRun bool) int {
+	if err := godotenv.Load(); err != nil {
+		log.Printf("警告: 无法加载.env文件: %v", err)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		log.Printf("❌ 加载配置失败: %v", err)
+		return 2
+	}
+	db, err := database.Init(cfg.DatabaseURL)
+	if err != nil {
+		log.Printf("❌ 初始化数据库失败: %v", err)
+		return 2
+	}
+	defer db.Close()
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Printf("❌ 打开导入文件失败: %v", err)
+		return 2
+	}
+	defer file.Close()
+
+	report, err := userimport.Import(db, file, dryRun)
+	if err != nil {
+		log.Printf("❌ 导入用户失败: %v", err)
+		return 2
+	}
+	fmt.Println(report)
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
 func main() {
+	verify := flag.String("verify-migrations", "", "在副本上校验数据库迁移（现有 .db 文件或 .sql 结构快照），不启动机器人")
+	importPath := flag.String("import-users", "", "从 CSV（telegram_id,username,balance）导入其他机器人的用户和余额，不启动机器人")
+	dryRun := flag.Bool("dry-run", false, "与 -import-users 一起使用，只校验不写入")
+	flag.Parse()
+	if *verify != "" {
+		os.Exit(verifyMigrations(*verify))
+	}
+	if *importPath != "" {
+		os.Exit(importUsers(*importPath, *dryRun))
+	}
+
 	run()
 }
@@ -0,0 +1,508 @@
+package test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/auth"
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/maintenance"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/reconcile"
+	"telegram-dice-bot/internal/utils"
+	"telegram-dice-bot/internal/withdraw"
+	admin "telegram-dice-bot/web/admin/handlers"
+)
+
+// adminTestServer 管理后台测试环境：数据库、游戏管理器、提现管理器和对账任务都接入后台，client 已登录
+type adminTestServer struct {
+	t           *testing.T
+	db          *database.DB
+	manager     *game.Manager
+	withdrawals *withdraw.Manager
+	server      *httptest.Server
+	client      *http.Client
+}
+
+func newAdminTestServer(t *testing.T) *adminTestServer {
+	t.Helper()
+	db, err := database.Init(filepath.Join(t.TempDir(), "admin.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	withdrawals := withdraw.NewManager(db, utils.Coins(100), 10)
+
+	authenticator, err := auth.New("admin", "secret")
+	if err != nil {
+		t.Fatalf("创建认证失败: %v", err)
+	}
+	handler := admin.NewAdminHandler(db, manager, authenticator)
+	handler.SetWithdrawals(withdrawals)
+	handler.SetReconciler(reconcile.NewReconciler(db, maintenance.Window{}, true, "test"))
+	server := httptest.NewServer(handler.Routes())
+	t.Cleanup(server.Close)
+
+	s := &adminTestServer{
+		t:           t,
+		db:          db,
+		manager:     manager,
+		withdrawals: withdrawals,
+		server:      server,
+		client:      newAdminClient(),
+	}
+	s.login("admin", "secret")
+	return s
+}
+
+// newAdminClient 保存 Cookie 且不跟随跳转的客户端，用于检查登录和鉴权的跳转
+func newAdminClient() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{
+		Jar: jar,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// login 以表单提交登录，成功后会话 Cookie 保存在 client 中
+func (s *adminTestServer) login(username, password string) {
+	s.t.Helper()
+	resp, err := s.client.PostForm(s.server.URL+"/admin/login", url.Values{"username": {username}, "password": {password}})
+	if err != nil {
+		s.t.Fatalf("登录请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		s.t.Fatalf("登录应跳转，实际状态码 %d", resp.StatusCode)
+	}
+}
+
+// do 发送 JSON 请求，返回状态码和响应内容
+func (s *adminTestServer) do(method, path string, body interface{}) (int, map[string]interface{}) {
+	s.t.Helper()
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	req, err := http.NewRequest(method, s.server.URL+path, bytes.NewReader(data))
+	if err != nil {
+		s.t.Fatalf("创建请求失败: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.t.Fatalf("%s %s 请求失败: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		s.t.Fatalf("%s %s 响应不是 JSON: %v", method, path, err)
+	}
+	return resp.StatusCode, result
+}
+
+func (s *adminTestServer) balance(userID int64) int64 {
+	s.t.Helper()
+	user, err := s.db.GetUser(userID)
+	if err != nil || user == nil {
+		s.t.Fatalf("读取用户失败: %v", err)
+	}
+	return user.Balance
+}
+
+func (s *adminTestServer) createUser(userID, balance int64) {
+	s.t.Helper()
+	if err := s.db.CreateUser(&models.User{ID: userID, Username: "player", Balance: balance}); err != nil {
+		s.t.Fatalf("创建用户失败: %v", err)
+	}
+}
+
+// lastAction 目标的最近一条管理员操作记录
+func (s *adminTestServer) lastAction(targetType, targetID string) *models.AdminAction {
+	s.t.Helper()
+	actions, err := s.db.GetAdminActions(targetType, targetID, 1)
+	if err != nil {
+		s.t.Fatalf("查询管理员操作失败: %v", err)
+	}
+	if len(actions) == 0 {
+		return nil
+	}
+	return actions[0]
+}
+
+// TestAdminAuth 未登录或密码错误时接口返回 401、页面跳转登录页，登录后可访问，登出后失效
+func TestAdminAuth(t *testing.T) {
+	s := newAdminTestServer(t)
+
+	anonymous := newAdminClient()
+	resp, err := anonymous.Post(s.server.URL+"/admin/api/withdrawals/1/review", "application/json", strings.NewReader(`{"action":"approve"}`))
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("未登录调用接口应返回 401，实际 %d", resp.StatusCode)
+	}
+	resp, err = anonymous.Get(s.server.URL + "/admin/users")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound || resp.Header.Get("Location") != "/admin/login" {
+		t.Errorf("未登录访问页面应跳转登录页，实际 %d %s", resp.StatusCode, resp.Header.Get("Location"))
+	}
+
+	// 密码错误不写入会话
+	wrong := newAdminClient()
+	resp, err = wrong.PostForm(s.server.URL+"/admin/login", url.Values{"username": {"admin"}, "password": {"wrong"}})
+	if err != nil {
+		t.Fatalf("登录请求失败: %v", err)
+	}
+	resp.Body.Close()
+	resp, err = wrong.Get(s.server.URL + "/admin/api/withdrawals")
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("密码错误后调用接口应返回 401，实际 %d", resp.StatusCode)
+	}
+
+	if status, body := s.do(http.MethodGet, "/admin/api/withdrawals", nil); status != http.StatusOK || body["success"] != true {
+		t.Errorf("登录后应能访问接口: %d %v", status, body)
+	}
+
+	resp, err = s.client.Post(s.server.URL+"/admin/logout", "", nil)
+	if err != nil {
+		t.Fatalf("登出请求失败: %v", err)
+	}
+	resp.Body.Close()
+	if status, _ := s.do(http.MethodGet, "/admin/api/withdrawals", nil); status != http.StatusUnauthorized {
+		t.Errorf("登出后调用接口应返回 401，实际 %d", status)
+	}
+}
+
+// TestAdminReviewWithdrawal 拒绝提现退回冻结金额并记录退款流水，批准后不再退回；重复审核返回 409，审核人为登录的管理员
+func TestAdminReviewWithdrawal(t *testing.T) {
+	s := newAdminTestServer(t)
+	userID := int64(4711)
+	s.createUser(userID, utils.Coins(5000))
+	address := "TXYZopqrstuvwxyzABCDEFGHJKLMNPQRST"
+
+	withdrawal, _, err := s.withdrawals.Request(userID, utils.Coins(2000), address)
+	if err != nil {
+		t.Fatalf("提交提现申请失败: %v", err)
+	}
+	path := fmt.Sprintf("/admin/api/withdrawals/%d/review", withdrawal.ID)
+	if status, _ := s.do(http.MethodPost, path, map[string]string{"action": "hold"}); status != http.StatusBadRequest {
+		t.Errorf("未知的审核操作应返回 400，实际 %d", status)
+	}
+	if status, body := s.do(http.MethodPost, path, map[string]string{"action": "reject", "reason": "地址有误"}); status != http.StatusOK {
+		t.Fatalf("拒绝提现失败: %d %v", status, body)
+	}
+	if b := s.balance(userID); b != utils.Coins(5000) {
+		t.Errorf("拒绝后应退回冻结金额，余额 %s", utils.FormatAmount(b))
+	}
+	refunds, _, err := s.db.SearchTransactions(&models.TransactionFilter{UserID: userID, Type: models.TransactionTypeWithdrawRefund}, "", 10)
+	if err != nil || len(refunds) != 1 || refunds[0].Amount != utils.Coins(2000) {
+		t.Errorf("应有一笔 2000 的提现退款流水: %+v（%v）", refunds, err)
+	}
+	if status, _ := s.do(http.MethodPost, path, map[string]string{"action": "approve", "tx_hash": "0xabc"}); status != http.StatusConflict {
+		t.Errorf("已审核的申请再次审核应返回 409，实际 %d", status)
+	}
+	if b := s.balance(userID); b != utils.Coins(5000) {
+		t.Errorf("重复审核不应改动余额: %s", utils.FormatAmount(b))
+	}
+	if action := s.lastAction("withdrawal", fmt.Sprint(withdrawal.ID)); action == nil || action.Action != "reject_withdrawal" || action.Admin != "admin" {
+		t.Errorf("拒绝操作应记录审核人: %+v", action)
+	}
+
+	withdrawal, _, err = s.withdrawals.Request(userID, utils.Coins(1000), address)
+	if err != nil {
+		t.Fatalf("提交提现申请失败: %v", err)
+	}
+	path = fmt.Sprintf("/admin/api/withdrawals/%d/review", withdrawal.ID)
+	if status, body := s.do(http.MethodPost, path, map[string]string{"action": "approve", "tx_hash": "0xabc"}); status != http.StatusOK {
+		t.Fatalf("批准提现失败: %d %v", status, body)
+	}
+	if status, _ := s.do(http.MethodPost, path, map[string]string{"action": "reject"}); status != http.StatusConflict {
+		t.Errorf("已批准的申请不能再拒绝，实际 %d", status)
+	}
+	if b := s.balance(userID); b != utils.Coins(4000) {
+		t.Errorf("批准后冻结金额不再退回，余额 %s", utils.FormatAmount(b))
+	}
+	approved, err := s.withdrawals.Withdrawals(models.WithdrawalStatusApproved, 10)
+	if err != nil || len(approved) != 1 || approved[0].TxHash != "0xabc" || approved[0].Reviewer != "admin" {
+		t.Errorf("批准的申请应记录转账哈希和审核人: %+v（%v）", approved, err)
+	}
+	if status, _ := s.do(http.MethodPost, "/admin/api/withdrawals/abc/review", map[string]string{"action": "approve"}); status != http.StatusBadRequest {
+		t.Errorf("无效的申请ID应返回 400，实际 %d", status)
+	}
+}
+
+// TestAdminResolveDispute 维持原结果退回冻结的派奖，撤销结果双方余额回到开局前；已处理的争议返回 409
+func TestAdminResolveDispute(t *testing.T) {
+	s := newAdminTestServer(t)
+	for id := int64(1); id <= 2; id++ {
+		s.createUser(id, utils.Coins(100))
+	}
+	play := func() string {
+		t.Helper()
+		gameID, err := s.manager.CreateGame(1, -4770, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := s.manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := s.manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return gameID
+	}
+
+	// 维持原结果
+	released := play()
+	winnerBefore := s.balance(1)
+	dispute, err := s.manager.OpenDispute(released, 2)
+	if err != nil {
+		t.Fatalf("提出争议失败: %v", err)
+	}
+	if b := s.balance(1); b != winnerBefore-dispute.Held {
+		t.Fatalf("提出争议后派奖应被冻结，余额 %s", utils.FormatAmount(b))
+	}
+	path := fmt.Sprintf("/admin/api/disputes/%d/resolve", dispute.ID)
+	if status, body := s.do(http.MethodPost, path, map[string]string{"action": "release", "note": "骰子消息核对无误"}); status != http.StatusOK {
+		t.Fatalf("维持原结果失败: %d %v", status, body)
+	}
+	if b := s.balance(1); b != winnerBefore {
+		t.Errorf("维持原结果后应退回派奖，余额 %s，应为 %s", utils.FormatAmount(b), utils.FormatAmount(winnerBefore))
+	}
+	if status, _ := s.do(http.MethodPost, path, map[string]string{"action": "reverse"}); status != http.StatusConflict {
+		t.Errorf("已处理的争议再次处理应返回 409，实际 %d", status)
+	}
+	if b := s.balance(1); b != winnerBefore {
+		t.Errorf("重复处理不应改动余额: %s", utils.FormatAmount(b))
+	}
+	if action := s.lastAction("game", released); action == nil || action.Action != "release_dispute" || action.Admin != "admin" {
+		t.Errorf("维持原结果应记录管理员操作: %+v", action)
+	}
+
+	// 撤销结果
+	before1, before2 := s.balance(1), s.balance(2)
+	reversed := play()
+	dispute, err = s.manager.OpenDispute(reversed, 2)
+	if err != nil {
+		t.Fatalf("提出争议失败: %v", err)
+	}
+	path = fmt.Sprintf("/admin/api/disputes/%d/resolve", dispute.ID)
+	if status, body := s.do(http.MethodPost, path, map[string]string{"action": "reverse", "note": "骰子消息与结果不符"}); status != http.StatusOK {
+		t.Fatalf("撤销结果失败: %d %v", status, body)
+	}
+	if b1, b2 := s.balance(1), s.balance(2); b1 != before1 || b2 != before2 {
+		t.Errorf("撤销结果后余额应回到开局前: %s / %s，应为 %s / %s",
+			utils.FormatAmount(b1), utils.FormatAmount(b2), utils.FormatAmount(before1), utils.FormatAmount(before2))
+	}
+	reversedDisputes, err := s.manager.Disputes(models.DisputeStatusReversed, 10)
+	if err != nil || len(reversedDisputes) != 1 || reversedDisputes[0].Reviewer != "admin" {
+		t.Errorf("撤销的争议应记录审核人: %+v（%v）", reversedDisputes, err)
+	}
+}
+
+// TestAdminChatLimits 后台设置的群组限额和手续费率在发起对局和结算时生效，超出全局范围的设置被拒绝且不覆盖原设置
+func TestAdminChatLimits(t *testing.T) {
+	s := newAdminTestServer(t)
+	for id := int64(1); id <= 2; id++ {
+		s.createUser(id, utils.Coins(1000))
+	}
+	chatID := int64(-4751)
+	path := fmt.Sprintf("/admin/api/chats/%d/limits", chatID)
+
+	if status, body := s.do(http.MethodPut, path, map[string]interface{}{"min_bet": 5, "max_bet": 50, "fee_rate": 0.1, "game_timeout": 30}); status != http.StatusOK {
+		t.Fatalf("设置群组限额失败: %d %v", status, body)
+	}
+	for _, invalid := range []map[string]interface{}{
+		{"max_bet": 1001},
+		{"min_bet": 50, "max_bet": 5},
+		{"fee_rate": 0.9},
+		{"game_timeout": 1},
+	} {
+		if status, _ := s.do(http.MethodPut, path, invalid); status != http.StatusBadRequest {
+			t.Errorf("无效的群组设置应返回 400: %v，实际 %d", invalid, status)
+		}
+	}
+
+	status, body := s.do(http.MethodGet, path, nil)
+	data, _ := body["data"].(map[string]interface{})
+	if status != http.StatusOK || data["min_bet"] != 5.0 || data["max_bet"] != 50.0 || data["fee_rate"] != 0.1 || data["game_timeout"] != 30.0 {
+		t.Errorf("生效的群组限额不符: %d %v", status, body)
+	}
+	if action := s.lastAction("chat", fmt.Sprint(chatID)); action == nil || action.Action != "update_chat_limits" || action.Admin != "admin" {
+		t.Errorf("设置群组限额应记录管理员操作: %+v", action)
+	}
+
+	// 超出群组上限的下注被拒绝且不扣款
+	if _, err := s.manager.CreateGame(1, chatID, utils.Coins(60)); err == nil {
+		t.Error("超出群组上限的下注应被拒绝")
+	}
+	if b := s.balance(1); b != utils.Coins(1000) {
+		t.Errorf("被拒绝的下注不应扣款: %s", utils.FormatAmount(b))
+	}
+
+	// 按群组费率 10% 结算
+	gameID, err := s.manager.CreateGame(1, chatID, utils.Coins(50))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := s.manager.JoinGame(gameID, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	result, err := s.manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1)
+	if err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+	if result.Commission != utils.Coins(10) {
+		t.Errorf("应按群组费率收取 10 手续费，实际 %s", utils.FormatAmount(result.Commission))
+	}
+	fees, _, err := s.db.SearchTransactions(&models.TransactionFilter{GameID: gameID, Type: models.TransactionTypeCommission}, "", 10)
+	if err != nil || len(fees) != 1 || fees[0].Amount != utils.Coins(10) {
+		t.Errorf("应有一笔 10 的手续费流水: %+v（%v）", fees, err)
+	}
+	if b1, b2 := s.balance(1), s.balance(2); b1 != utils.Coins(1040) || b2 != utils.Coins(950) {
+		t.Errorf("结算后余额不符: %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+}
+
+// TestAdminRevenueShare 后台设置的分成比例在结算时拆分手续费，报表接口按群组汇总；超过 100% 的比例被拒绝
+func TestAdminRevenueShare(t *testing.T) {
+	s := newAdminTestServer(t)
+	for id := int64(1); id <= 2; id++ {
+		s.createUser(id, utils.Coins(100))
+	}
+	chatID := int64(-4715)
+	path := fmt.Sprintf("/admin/api/chats/%d/revenue-share", chatID)
+
+	if status, _ := s.do(http.MethodPut, path, map[string]float64{"share": 1.5}); status != http.StatusBadRequest {
+		t.Errorf("超过 100%% 的分成比例应返回 400，实际 %d", status)
+	}
+	if status, body := s.do(http.MethodPut, path, map[string]float64{"share": 0.5}); status != http.StatusOK {
+		t.Fatalf("设置分成比例失败: %d %v", status, body)
+	}
+	if action := s.lastAction("chat", fmt.Sprint(chatID)); action == nil || action.Action != "update_chat_revenue_share" || action.Admin != "admin" {
+		t.Errorf("设置分成比例应记录管理员操作: %+v", action)
+	}
+
+	// 下注 10.10，奖池 20.20，手续费 1.01：群组 0.50，平台 0.51
+	gameID, err := s.manager.CreateGame(1, chatID, utils.Coins(10)+10)
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := s.manager.JoinGame(gameID, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if _, err := s.manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+	shares, _, err := s.db.SearchTransactions(&models.TransactionFilter{GameID: gameID, Type: models.TransactionTypeRevenueShare}, "", 10)
+	if err != nil || len(shares) != 1 || shares[0].Amount != 50 {
+		t.Errorf("应有一笔 0.50 的分成流水: %+v（%v）", shares, err)
+	}
+	if fund, _, err := s.db.GetChatFund(chatID); err != nil || fund != 50 {
+		t.Errorf("群组基金应为 0.50，实际 %s（%v）", utils.FormatAmount(fund), err)
+	}
+
+	status, body := s.do(http.MethodGet, "/admin/api/revenue-share?days=1", nil)
+	reports, _ := body["data"].([]interface{})
+	if status != http.StatusOK || len(reports) != 1 {
+		t.Fatalf("报表应包含 1 个群组: %d %v", status, body)
+	}
+	data, _ := json.Marshal(reports[0])
+	var report models.RevenueShareReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatalf("解析报表失败: %v", err)
+	}
+	if report.ChatID != chatID || report.Share != 0.5 || report.Games != 1 || report.Commission != 101 || report.ChatShare != 50 {
+		t.Errorf("报表不符: %+v", report)
+	}
+}
+
+// TestAdminReconcile 后台触发对账发现被改动的余额并冻结账户，确认差异后解冻，重复处理返回 409
+func TestAdminReconcile(t *testing.T) {
+	s := newAdminTestServer(t)
+	userID := int64(5001)
+	s.createUser(userID, 0)
+	deposit := utils.Coins(5000)
+	if err := s.db.UpdateUserBalance(userID, deposit); err != nil {
+		t.Fatalf("更新余额失败: %v", err)
+	}
+	if err := s.db.CreateTransaction(&models.Transaction{
+		ID: utils.GenerateTransactionID(), UserID: userID, Type: models.TransactionTypeDeposit, Amount: deposit, Balance: deposit,
+	}); err != nil {
+		t.Fatalf("记录充值失败: %v", err)
+	}
+
+	run := func() *models.ReconcileRun {
+		t.Helper()
+		status, body := s.do(http.MethodPost, "/admin/api/reconcile/run", nil)
+		if status != http.StatusOK {
+			t.Fatalf("触发对账失败: %d %v", status, body)
+		}
+		data, _ := json.Marshal(body["data"])
+		var result models.ReconcileRun
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatalf("解析对账结果失败: %v", err)
+		}
+		return &result
+	}
+	if result := run(); result.Checked != 1 || len(result.Issues) != 0 {
+		t.Fatalf("余额与流水一致时不应有差异: %+v", result)
+	}
+
+	// 绕过流水直接改余额
+	if err := s.db.UpdateUserBalance(userID, utils.Coins(5500)); err != nil {
+		t.Fatalf("更新余额失败: %v", err)
+	}
+	if result := run(); len(result.Issues) != 1 || result.Frozen != 1 {
+		t.Fatalf("应发现差异并冻结账户: %+v", result)
+	}
+	issues, err := s.db.GetReconcileIssues(models.ReconcileStatusOpen, 10)
+	if err != nil || len(issues) != 1 || issues[0].Difference != utils.Coins(500) {
+		t.Fatalf("应有一条 +500 的差异: %+v（%v）", issues, err)
+	}
+	if frozen, _ := s.db.IsUserFrozen(userID); !frozen {
+		t.Error("发现差异后账户应被冻结")
+	}
+
+	path := fmt.Sprintf("/admin/api/reconcile/%d/resolve", issues[0].ID)
+	if status, body := s.do(http.MethodPost, path, map[string]string{"action": "accept", "note": "补发活动奖励"}); status != http.StatusOK {
+		t.Fatalf("确认差异失败: %d %v", status, body)
+	}
+	if frozen, _ := s.db.IsUserFrozen(userID); frozen {
+		t.Error("确认差异后账户应解冻")
+	}
+	if status, _ := s.do(http.MethodPost, path, map[string]string{"action": "accept"}); status != http.StatusConflict {
+		t.Errorf("已处理的差异再次处理应返回 409，实际 %d", status)
+	}
+	if action := s.lastAction("user", fmt.Sprint(userID)); action == nil || action.Action != "accept_reconcile_issue" || action.Admin != "admin" {
+		t.Errorf("确认差异应记录管理员操作: %+v", action)
+	}
+	if result := run(); len(result.Issues) != 0 {
+		t.Errorf("确认后的差额应计入基线: %+v", result)
+	}
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/utils"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// legacyAmounts 旧版按整数金币存储的金额：每个金额字段各取不同的值，便于发现漏换算或重复换算的字段
+var legacyAmounts = []struct {
+	table, column string
+	value         int64
+}{
+	{"users", "balance", 101},
+	{"users", "bonus_balance", 102},
+	{"games", "bet_amount", 103},
+	{"games", "commission", 104},
+	{"games", "player1_bonus_stake", 105},
+	{"games", "player2_bonus_stake", 106},
+	{"games", "chat_share", 107},
+	{"games", "insurance_premium", 108},
+	{"games", "insurance_coverage", 109},
+	{"transactions", "amount", -110},
+	{"transactions", "balance", 111},
+	{"chats", "fund_balance", 112},
+	{"chats", "prize_pool", 113},
+	{"chats", "min_bet", 114},
+	{"chats", "max_bet", 115},
+	{"bonus_campaigns", "max_bonus", 116},
+	{"bonus_wagering", "bonus_amount", 117},
+	{"bonus_wagering", "wagering_required", 118},
+	{"bonus_wagering", "wagered", 119},
+	{"game_tables", "ante", 120},
+	{"game_tables", "commission", 121},
+	{"table_players", "bonus_stake", 122},
+	{"table_players", "payout", 123},
+}
+
+// legacyThreshold 旧版保险配置中的投保门槛（整数金币）
+const legacyThreshold = 50
+
+// seedLegacyAmounts 把当前结构的数据库改回换算前的状态：清除换算比例记录，每张表写入一行旧版金额
+func seedLegacyAmounts(t *testing.T, path string) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	defer conn.Close()
+
+	statements := []string{
+		`DELETE FROM bot_settings WHERE key = '` + database.AmountScaleSettingKey + `'`,
+		`INSERT INTO bot_settings (key, value, updated_at) VALUES ('` + database.InsuranceSettingKey + `',
+			'{"enabled":true,"threshold":` + fmt.Sprint(legacyThreshold) + `,"premium_rate":0.02,"refund_rate":0.5}', CURRENT_TIMESTAMP)`,
+		`INSERT INTO users (id, username, first_name, last_name) VALUES (1, 'alice', '', '')`,
+		`INSERT INTO games (id, player1_id, bet_amount, status, chat_id) VALUES ('GAME1', 1, 0, 'finished', -100)`,
+		`INSERT INTO transactions (id, user_id, type, amount, balance) VALUES ('TX1', 1, 'bet', 0, 0)`,
+		`INSERT INTO chats (id, title) VALUES (-100, 'dice')`,
+		`INSERT INTO bonus_campaigns (id, name, percent, starts_at, ends_at) VALUES (1, 'welcome', 0.1, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`,
+		`INSERT INTO bonus_wagering (user_id, campaign_id, bonus_amount, wagering_required) VALUES (1, 1, 0, 0)`,
+		`INSERT INTO game_tables (id, chat_id, creator_id, ante, status) VALUES ('TABLE1', -100, 1, 0, 'finished')`,
+		`INSERT INTO table_players (table_id, user_id) VALUES ('TABLE1', 1)`,
+	}
+	for _, amount := range legacyAmounts {
+		statements = append(statements, fmt.Sprintf(`UPDATE %s SET %s = %d`, amount.table, amount.column, amount.value))
+	}
+	for _, statement := range statements {
+		if _, err := conn.Exec(statement); err != nil {
+			t.Fatalf("写入旧版数据失败: %v\n%s", err, statement)
+		}
+	}
+}
+
+// checkAmounts 每个金额字段应为旧版金额乘以 scale
+func checkAmounts(t *testing.T, path string, scale int64, stage string) {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	defer conn.Close()
+
+	for _, amount := range legacyAmounts {
+		var value int64
+		query := fmt.Sprintf(`SELECT %s FROM %s`, amount.column, amount.table)
+		if err := conn.QueryRow(query).Scan(&value); err != nil {
+			t.Fatalf("读取 %s.%s 失败: %v", amount.table, amount.column, err)
+		}
+		if value != amount.value*scale {
+			t.Errorf("%s: %s.%s 应为 %d，实际 %d", stage, amount.table, amount.column, amount.value*scale, value)
+		}
+	}
+}
+
+// TestAmountScaleMigration 旧版数据库启动时每个金额字段和保险门槛恰好换算一次，
+// 记录换算比例后重复启动不再换算；记录的比例与程序不一致时拒绝启动
+func TestAmountScaleMigration(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "amount_scale.db")
+	db, err := database.Init(path)
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	db.Close()
+	seedLegacyAmounts(t, path)
+	checkAmounts(t, path, 1, "换算前")
+
+	for start := 1; start <= 2; start++ {
+		db, err := database.Init(path)
+		if err != nil {
+			t.Fatalf("第 %d 次启动失败: %v", start, err)
+		}
+		if value, ok, err := db.GetSetting(database.AmountScaleSettingKey); err != nil || !ok || value != fmt.Sprint(utils.AmountScale) {
+			t.Errorf("第 %d 次启动后应记录换算比例 %d，实际 %q（%v）", start, utils.AmountScale, value, err)
+		}
+		settings, err := db.GetInsuranceSettings()
+		if err != nil || settings.Threshold != legacyThreshold*utils.AmountScale || !settings.Enabled {
+			t.Errorf("第 %d 次启动后投保门槛应为 %d，实际 %+v（%v）", start, legacyThreshold*utils.AmountScale, settings, err)
+		}
+		if user, err := db.GetUser(1); err != nil || user.Balance != utils.Coins(101) {
+			t.Errorf("第 %d 次启动后余额应为 101 金币: %+v（%v）", start, user, err)
+		}
+		db.Close()
+		checkAmounts(t, path, utils.AmountScale, fmt.Sprintf("第 %d 次启动后", start))
+	}
+
+	// 记录的比例与程序不一致时拒绝启动，金额保持不变
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	if _, err := conn.Exec(`UPDATE bot_settings SET value = '1000' WHERE key = ?`, database.AmountScaleSettingKey); err != nil {
+		t.Fatalf("修改换算比例失败: %v", err)
+	}
+	conn.Close()
+	if db, err := database.Init(path); err == nil {
+		db.Close()
+		t.Error("换算比例不一致时应拒绝启动")
+	}
+	checkAmounts(t, path, utils.AmountScale, "拒绝启动后")
+}
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+)
+
+// TestAttribution 任意命令或回调后记录用户来源：群组中首次接触记为 group 并记录群组，/start 的深度链接参数优先，已有归因不覆盖
+func TestAttribution(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "attribution.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	client := telegram.NewFakeClient()
+	router := middleware.NewRouter(client)
+	// 模拟 /start：首次使用时创建用户
+	router.Handle("start", func(ctx *middleware.Context) error {
+		if user, _ := db.GetUser(ctx.UserID); user == nil {
+			return db.CreateUser(&models.User{ID: ctx.UserID, Username: "player"})
+		}
+		return nil
+	})
+	router.Handle("help", func(ctx *middleware.Context) error { return nil })
+	router.HandleCallback("help_", func(ctx *middleware.Context) error { return nil })
+	router.Use(middleware.Attribution(db.RecordUserAttribution))
+
+	dispatch := func(chatID, userID int64, text string) {
+		t.Helper()
+		update := commandUpdate(chatID, userID, text)
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理命令失败: %v", err)
+		}
+	}
+	check := func(userID, firstChatID int64, source string) {
+		t.Helper()
+		user, err := db.GetUser(userID)
+		if err != nil || user == nil {
+			t.Fatalf("读取用户失败: %v", err)
+		}
+		if user.FirstChatID != firstChatID || user.Source != source {
+			t.Errorf("用户 %d 来源应为 %d/%q，实际 %d/%q", userID, firstChatID, source, user.FirstChatID, user.Source)
+		}
+	}
+
+	dispatch(-3001, 1, "/start")
+	check(1, -3001, "group")
+	// 已有归因不被之后的深度链接覆盖
+	dispatch(1, 1, "/start ref_9")
+	check(1, -3001, "group")
+
+	dispatch(2, 2, "/start promo_spring")
+	check(2, 0, "promo_spring")
+
+	// 其他命令和回调同样记录首次接触，之后的 /start 不覆盖
+	if err := db.CreateUser(&models.User{ID: 3, Username: "player"}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	dispatch(-3002, 3, "/help")
+	check(3, -3002, "group")
+	dispatch(3, 3, "/start promo_spring")
+	check(3, -3002, "group")
+
+	if err := db.CreateUser(&models.User{ID: 4, Username: "player"}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	update := callbackUpdate(4, 4, 1, "help_topic")
+	update.CallbackQuery.Message.Chat.Type = "private"
+	if _, err := router.Dispatch(&update); err != nil {
+		t.Fatalf("处理回调失败: %v", err)
+	}
+	check(4, 0, "private")
+
+	sources, err := db.GetAcquisitionSources(10)
+	if err != nil || len(sources) != 4 {
+		t.Fatalf("应有 4 个来源分组: %+v（%v）", sources, err)
+	}
+}
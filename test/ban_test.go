@@ -0,0 +1,105 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestBanUser 封禁后用户的等待中对局退款，不能发起或加入对局，命令被礼貌拦截；解除封禁后恢复
+func TestBanUser(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "ban.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	chatID := int64(-1071)
+	waiting, err := manager.CreateGame(1, chatID, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.BanUser(3, "刷局", "admin"); err == nil {
+		t.Error("封禁不存在的用户应失败")
+	}
+	expired, err := manager.BanUser(1, "恶意刷局", "admin")
+	if err != nil || expired != 1 {
+		t.Fatalf("封禁用户应让 1 个等待中的对局退款: %d（%v）", expired, err)
+	}
+	if g, _ := db.GetGame(waiting); g == nil || g.Status != models.GameStatusExpired {
+		t.Errorf("被封禁用户的等待中对局应过期: %+v", g)
+	}
+	if user, _ := db.GetUser(1); user == nil || user.Balance != utils.Coins(100) {
+		t.Errorf("过期对局应退还下注: %+v", user)
+	}
+
+	var banned *game.BannedError
+	if _, err := manager.CreateGame(1, chatID, utils.Coins(10)); !errors.As(err, &banned) || !strings.Contains(err.Error(), "恶意刷局") {
+		t.Errorf("被封禁的用户不应能发起对局: %v", err)
+	}
+	gameID, err := manager.CreateGame(2, chatID, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.JoinGame(gameID, 1); !errors.As(err, &banned) {
+		t.Errorf("被封禁的用户不应能加入对局: %v", err)
+	}
+
+	client := telegram.NewFakeClient()
+	router := middleware.NewRouter(client, middleware.BanCheck(db.GetUserBan))
+	var handled int
+	router.Handle("dice", func(ctx *middleware.Context) error {
+		handled++
+		return nil
+	})
+	dispatch := func(userID int64) string {
+		t.Helper()
+		client.Reset()
+		update := commandUpdate(chatID, userID, "/dice 10")
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理命令失败: %v", err)
+		}
+		if sent := client.SentMessages(); len(sent) == 1 {
+			return sent[0].(tgbotapi.MessageConfig).Text
+		}
+		return ""
+	}
+	if text := dispatch(1); !strings.Contains(text, "暂停使用") || !strings.Contains(text, "原因：恶意刷局") || handled != 0 {
+		t.Errorf("被封禁用户的命令应被拦截并说明原因: %q", text)
+	}
+	if dispatch(2); handled != 1 {
+		t.Error("未封禁用户的命令应正常处理")
+	}
+
+	if bans, _ := db.GetBannedUsers(10); len(bans) != 1 || bans[0].UserID != 1 || bans[0].BannedBy != "admin" {
+		t.Errorf("封禁列表不符: %+v", bans)
+	}
+	if err := manager.UnbanUser(1); err != nil {
+		t.Fatalf("解除封禁失败: %v", err)
+	}
+	if err := manager.UnbanUser(1); err == nil {
+		t.Error("未被封禁的用户不应能重复解除封禁")
+	}
+	if _, err := manager.JoinGame(gameID, 1); err != nil {
+		t.Errorf("解除封禁后应能加入对局: %v", err)
+	}
+}
@@ -0,0 +1,246 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/recharge"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestBonusWinnings 赠送余额出资的下注获胜时，相应比例的奖金转回赠送余额，完成流水后才转为可提现余额
+func TestBonusWinnings(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "bonus_winnings.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	campaign := &models.BonusCampaign{
+		Name:               "首充",
+		Percent:            100,
+		WageringMultiplier: 3,
+		StartsAt:           time.Now().Add(-time.Hour),
+		EndsAt:             time.Now().Add(time.Hour),
+		Active:             true,
+	}
+	if err := db.CreateBonusCampaign(campaign); err != nil {
+		t.Fatalf("创建活动失败: %v", err)
+	}
+	grantDepositBonus(t, db, 1, utils.Coins(20))
+
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	play := func(p1d, p2d int) *game.GameResult {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, -1099, utils.Coins(20))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		result, err := manager.PlayGameWithDiceResults(gameID, p1d, p1d, p1d, p2d, p2d, p2d)
+		if err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return result
+	}
+
+	// 下注全部由赠送余额出资，奖金全部转回赠送余额，现金余额不变
+	result := play(6, 1)
+	user, _ := db.GetUser(1)
+	if user.Balance != utils.Coins(100) || user.BonusBalance != result.WinAmount {
+		t.Errorf("赠送出资的奖金应转回赠送余额，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+	wins, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 1, Type: models.TransactionTypeWin}, "", 10)
+	if err != nil || len(wins) != 1 || wins[0].Balance != utils.Coins(100) {
+		t.Fatalf("获胜交易记录的现金余额应为转回后的余额: %+v（%v）", wins, err)
+	}
+
+	// 流水完成（3 × 20 = 60）后剩余赠送余额转为现金
+	play(6, 1)
+	play(6, 1)
+	user, _ = db.GetUser(1)
+	if user.BonusBalance != 0 || user.Balance != utils.Coins(100)+3*result.WinAmount-utils.Coins(40) {
+		t.Errorf("完成流水后赠送余额应全部转为现金，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+
+	// 没有流水要求时获胜奖金全部计入现金
+	before, _ := db.GetUser(1)
+	result = play(6, 1)
+	if user, _ := db.GetUser(1); user.BonusBalance != 0 || user.Balance != before.Balance-utils.Coins(20)+result.WinAmount {
+		t.Errorf("无流水要求时奖金应计入现金，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+}
+
+// grantDepositBonus 按当前生效的活动为充值发放赠送金额
+func grantDepositBonus(t *testing.T, db *database.DB, userID, deposit int64) int64 {
+	t.Helper()
+	tx, err := db.BeginTx()
+	if err != nil {
+		t.Fatalf("开始事务失败: %v", err)
+	}
+	defer tx.Rollback()
+	bonus, err := db.ApplyDepositBonusInTx(tx, userID, deposit, true)
+	if err != nil {
+		t.Fatalf("发放充值赠送失败: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("提交事务失败: %v", err)
+	}
+	return bonus
+}
+
+// TestDepositBonus 充值按生效的活动发放赠送金额，下注优先使用赠送余额，平局退回赠送余额，
+// 对局计入流水，全部流水完成后剩余赠送余额转为可提现余额
+func TestDepositBonus(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.Init(filepath.Join(dir, "deposit_bonus.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	addressFile := filepath.Join(dir, "addresses.txt")
+	if err := os.WriteFile(addressFile, []byte("TXYZopqrstuvwxyzABCDEFGHJKLMNPQRST\n"), 0644); err != nil {
+		t.Fatalf("写入地址文件失败: %v", err)
+	}
+	recharges, err := recharge.NewRechargeManager(db, addressFile)
+	if err != nil {
+		t.Fatalf("创建充值管理器失败: %v", err)
+	}
+	recharges.SetRate(100)
+
+	if err := db.CreateUser(&models.User{ID: 1, Username: "depositor"}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	if err := db.CreateUser(&models.User{ID: 2, Username: "player", Balance: utils.Coins(100)}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+
+	now := time.Now()
+	campaigns := []*models.BonusCampaign{
+		{Name: "首充", Percent: 50, MaxBonus: utils.Coins(30), FirstDepositOnly: true, WageringMultiplier: 1},
+		{Name: "日常", Percent: 10, WageringMultiplier: 1},
+		{Name: "已结束", Percent: 100, WageringMultiplier: 1, StartsAt: now.Add(-2 * time.Hour), EndsAt: now.Add(-time.Hour)},
+	}
+	for _, campaign := range campaigns {
+		if campaign.StartsAt.IsZero() {
+			campaign.StartsAt, campaign.EndsAt = now.Add(-time.Hour), now.Add(time.Hour)
+		}
+		campaign.Active = true
+		if err := db.CreateBonusCampaign(campaign); err != nil {
+			t.Fatalf("创建活动失败: %v", err)
+		}
+	}
+
+	address, err := recharges.GetUserRechargeAddress(1)
+	if err != nil {
+		t.Fatalf("分配充值地址失败: %v", err)
+	}
+	deposit := func(txHash string) {
+		t.Helper()
+		recordID, err := recharges.RecordDeposit(1, address, 1, txHash, "pending")
+		if err != nil {
+			t.Fatalf("记录充值失败: %v", err)
+		}
+		if err := recharges.ConfirmRecharge(recordID, 1); err != nil {
+			t.Fatalf("确认充值失败: %v", err)
+		}
+	}
+
+	// 首充取赠送最多的活动（50% 封顶 30），之后的充值只适用日常活动（10%）
+	deposit("tx-first")
+	deposit("tx-second")
+	user, _ := db.GetUser(1)
+	if user.Balance != utils.Coins(200) || user.BonusBalance != utils.Coins(40) {
+		t.Fatalf("充值后余额 %s、赠送余额 %s，应为 200 和 40", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+	bonuses, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 1, Type: models.TransactionTypeBonus}, "", 10)
+	if err != nil || len(bonuses) != 2 {
+		t.Fatalf("应有两笔充值赠送记录，实际 %d（%v）", len(bonuses), err)
+	}
+	if withdrawable, _ := db.GetWithdrawableBalance(1); withdrawable != utils.Coins(200) {
+		t.Errorf("赠送余额不可提现，可提现 %s", utils.FormatAmount(withdrawable))
+	}
+
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	play := func(bet int64, p1d, p2d int) *game.GameResult {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, -1098, bet)
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		result, err := manager.PlayGameWithDiceResults(gameID, p1d, p1d, p1d, p2d, p2d, p2d)
+		if err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return result
+	}
+
+	// 下注优先扣赠送余额，平局退款原路退回赠送余额且不计流水
+	gameID, err := manager.CreateGame(1, -1098, utils.Coins(25))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if user, _ := db.GetUser(1); user.Balance != utils.Coins(200) || user.BonusBalance != utils.Coins(15) {
+		t.Errorf("下注应优先使用赠送余额，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+	if _, err := manager.JoinGame(gameID, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if _, err := manager.PlayGameWithDiceResults(gameID, 3, 3, 3, 3, 3, 3); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+	if user, _ := db.GetUser(1); user.Balance != utils.Coins(200) || user.BonusBalance != utils.Coins(40) {
+		t.Errorf("平局应退回赠送余额，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+
+	// 输掉 20：流水完成 20/40
+	play(utils.Coins(20), 1, 6)
+	progress, err := db.GetBonusProgress(1)
+	if err != nil || progress.WageringRequired != utils.Coins(40) || progress.Wagered != utils.Coins(20) || progress.BonusBalance != utils.Coins(20) {
+		t.Errorf("流水进度不符: %+v（%v）", progress, err)
+	}
+
+	// 再下注 20 获胜：流水完成，赠送出资的奖金连同剩余赠送余额一并转为现金
+	result := play(utils.Coins(20), 6, 1)
+	user, _ = db.GetUser(1)
+	if user.BonusBalance != 0 || user.Balance != utils.Coins(200)+result.WinAmount {
+		t.Errorf("完成流水后应转为现金，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+	if progress, _ := db.GetBonusProgress(1); progress.WageringRequired != 0 {
+		t.Errorf("流水要求应已全部完成: %+v", progress)
+	}
+	converts, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 1, Type: models.TransactionTypeBonusConvert}, "", 10)
+	if err != nil || len(converts) != 1 || converts[0].Amount != result.WinAmount {
+		t.Errorf("应有一笔赠送转现金记录: %+v（%v）", converts, err)
+	}
+
+	// 赠送发放、转回和转现金不影响按流水对账（对手的初始余额没有流水，不在核对范围内）
+	_, mismatches, err := db.LedgerMismatches()
+	if err != nil {
+		t.Fatalf("对账失败: %v", err)
+	}
+	for _, issue := range mismatches {
+		if issue.UserID == 1 {
+			t.Errorf("充值用户对账不应有差异: %+v", issue)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/broadcast"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/pool"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestBroadcast 管理员公告：按接收范围投递到未退出的群组和可私信的用户，记录投递结果，定时公告可取消
+func TestBroadcast(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "broadcast.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	// 用户 3 屏蔽了机器人
+	if err := db.SetUserDMBlocked(3, true); err != nil {
+		t.Fatalf("标记屏蔽私信失败: %v", err)
+	}
+	// 群组 -200 已移除机器人
+	for _, chatID := range []int64{-100, -200} {
+		if err := db.UpsertChat(&models.Chat{ID: chatID, Title: "group", Type: "supergroup"}); err != nil {
+			t.Fatalf("记录群组失败: %v", err)
+		}
+	}
+	if _, err := db.ReleaseChat(-200); err != nil {
+		t.Fatalf("释放群组失败: %v", err)
+	}
+
+	workers := pool.NewWorkerPool(2, 10)
+	workers.Start()
+	defer workers.Stop()
+	client := telegram.NewFakeClient()
+	broadcaster := broadcast.NewBroadcaster(db, client, workers, 100)
+	defer broadcaster.Stop()
+
+	if _, err := broadcaster.Schedule("  ", models.BroadcastAll, time.Time{}, "admin"); err == nil {
+		t.Error("空公告应被拒绝")
+	}
+	if _, err := broadcaster.Schedule("hello", "everyone", time.Time{}, "admin"); err == nil {
+		t.Error("无效的接收范围应被拒绝")
+	}
+
+	now, err := broadcaster.Schedule("📢 维护通知", models.BroadcastAll, time.Time{}, "admin")
+	if err != nil {
+		t.Fatalf("创建公告失败: %v", err)
+	}
+	later, err := broadcaster.Schedule("明日活动", models.BroadcastGroups, time.Now().Add(time.Hour), "admin")
+	if err != nil {
+		t.Fatalf("创建定时公告失败: %v", err)
+	}
+
+	sent, err := broadcaster.Run(time.Now())
+	if err != nil || sent != 3 {
+		t.Fatalf("应送达 3 条（1 个群组 + 2 个用户），实际 %d（%v）", sent, err)
+	}
+	recipients := make(map[int64]bool)
+	for _, c := range client.SentMessages() {
+		recipients[c.(tgbotapi.MessageConfig).ChatID] = true
+	}
+	if !recipients[-100] || !recipients[1] || !recipients[2] || recipients[-200] || recipients[3] {
+		t.Errorf("接收方不符: %v", recipients)
+	}
+
+	result, err := db.GetBroadcast(now.ID)
+	if err != nil || result == nil {
+		t.Fatalf("获取公告失败: %v", err)
+	}
+	if result.Status != models.BroadcastCompleted || result.Total != 3 || result.Sent != 3 || result.Failed != 0 {
+		t.Errorf("公告进度不符: %+v", result)
+	}
+
+	// 未到时间的定时公告不发送，取消后到时间也不再发送
+	if ok, err := broadcaster.Cancel(later.ID); err != nil || !ok {
+		t.Fatalf("取消定时公告失败: %v", err)
+	}
+	if ok, _ := broadcaster.Cancel(later.ID); ok {
+		t.Error("已取消的公告不应再次取消")
+	}
+	client.Reset()
+	if sent, err := broadcaster.Run(time.Now().Add(2 * time.Hour)); err != nil || sent != 0 || len(client.SentMessages()) != 0 {
+		t.Errorf("已取消的公告不应发送，实际 %d（%v）", sent, err)
+	}
+
+	// 屏蔽机器人的用户记为失败并停止后续私信
+	client.SendErr = &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was blocked by the user"}
+	failed, err := broadcaster.Schedule("私信公告", models.BroadcastUsers, time.Time{}, "admin")
+	if err != nil {
+		t.Fatalf("创建公告失败: %v", err)
+	}
+	if sent, err := broadcaster.Run(time.Now()); err != nil || sent != 0 {
+		t.Fatalf("屏蔽机器人的用户不应送达，实际 %d（%v）", sent, err)
+	}
+	result, _ = db.GetBroadcast(failed.ID)
+	if result == nil || result.Status != models.BroadcastCompleted || result.Total != 2 || result.Failed != 2 {
+		t.Errorf("失败统计不符: %+v", result)
+	}
+	if failures, _ := db.GetBroadcastFailures(failed.ID, 10); len(failures) != 2 || failures[0].Error == "" {
+		t.Errorf("应记录 2 条失败原因，实际 %d", len(failures))
+	}
+	if ok, _ := db.CanDMUser(1); ok {
+		t.Error("屏蔽机器人的用户应停止私信")
+	}
+}
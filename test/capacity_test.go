@@ -0,0 +1,104 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestChatCapacity 达到服务上限后新群组收到排队提示，机器人离开群组时释放名额并通知排队最早的群组
+func TestChatCapacity(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "capacity.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	client := telegram.NewFakeClient()
+	router := middleware.NewRouter(client)
+	var handled []int64
+	router.Handle("start", func(ctx *middleware.Context) error {
+		handled = append(handled, ctx.ChatID)
+		return nil
+	})
+	router.HandleMembership(middleware.ReleaseOnLeave(db.ReleaseChat))
+	router.Use(middleware.Capacity(func(chatID int64) (bool, int, error) {
+		return db.AdmitChat(chatID, 1)
+	}))
+
+	start := func(chatID int64) string {
+		t.Helper()
+		client.Reset()
+		update := commandUpdate(chatID, 1, "/start")
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理命令失败: %v", err)
+		}
+		if messages := client.SentMessages(); len(messages) == 1 {
+			return messages[0].(tgbotapi.MessageConfig).Text
+		}
+		return ""
+	}
+
+	if reply := start(-2001); reply != "" {
+		t.Errorf("名额未满时不应拦截: %q", reply)
+	}
+	if reply := start(-2002); reply != ui.WaitlistMessage(1) {
+		t.Errorf("名额已满时应提示排队第 1 位: %q", reply)
+	}
+	if reply := start(-2003); reply != ui.WaitlistMessage(2) {
+		t.Errorf("第二个排队的群组应提示第 2 位: %q", reply)
+	}
+	if reply := start(1); reply != "" {
+		t.Errorf("私聊不受群组名额限制: %q", reply)
+	}
+	if len(handled) != 2 || handled[0] != -2001 || handled[1] != 1 {
+		t.Errorf("只应处理已获得名额的群组和私聊，实际 %v", handled)
+	}
+
+	// 机器人被移出已服务的群组，名额释放给排队最早的群组
+	client.Reset()
+	update := tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          tgbotapi.Chat{ID: -2001, Type: "supergroup"},
+		From:          tgbotapi.User{ID: 1},
+		OldChatMember: tgbotapi.ChatMember{Status: "member"},
+		NewChatMember: tgbotapi.ChatMember{Status: "kicked"},
+	}}
+	if _, err := router.Dispatch(&update); err != nil {
+		t.Fatalf("处理成员变更失败: %v", err)
+	}
+	messages := client.SentMessages()
+	if len(messages) != 1 {
+		t.Fatalf("应通知排队最早的群组，实际发出 %d 条消息", len(messages))
+	}
+	if msg := messages[0].(tgbotapi.MessageConfig); msg.ChatID != -2002 || !strings.Contains(msg.Text, "/start") {
+		t.Errorf("获得名额的通知不符: %d %q", msg.ChatID, msg.Text)
+	}
+	if reply := start(-2002); reply != "" {
+		t.Errorf("获得名额后应正常处理: %q", reply)
+	}
+	if reply := start(-2003); reply != ui.WaitlistMessage(1) {
+		t.Errorf("剩余的排队群组应前移到第 1 位: %q", reply)
+	}
+
+	// 分片检查在容量检查之外：其他分片的群组不占名额也不收到排队提示
+	router = middleware.NewRouter(client)
+	router.Handle("start", func(ctx *middleware.Context) error { return nil })
+	router.Use(middleware.ShardOwner(func(chatID int64) bool { return chatID != -2004 }),
+		middleware.Capacity(func(chatID int64) (bool, int, error) {
+			return db.AdmitChat(chatID, 1)
+		}))
+	if reply := start(-2004); reply != "" {
+		t.Errorf("其他分片的群组不应收到回复: %q", reply)
+	}
+	if counts, _ := db.CountChatsByServiceStatus(); counts[models.ChatServiceWaitlist] != 1 {
+		t.Errorf("其他分片的群组不应进入等待队列: %v", counts)
+	}
+}
@@ -0,0 +1,100 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestChatFund 手续费分成计入群组基金，基金可平均空投给近期活跃玩家或转入比赛奖池，余额不足时拒绝
+func TestChatFund(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "chat_fund.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	chatID := int64(-1075)
+	if err := db.SetChatRevenueShare(chatID, 1); err != nil {
+		t.Fatalf("设置分成比例失败: %v", err)
+	}
+	since := time.Now().Add(-time.Minute)
+	for _, opponent := range []int64{2, 3} {
+		gameID, err := manager.CreateGame(1, chatID, utils.Coins(100))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, opponent); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+	}
+
+	fund, prizePool, err := db.GetChatFund(chatID)
+	if err != nil || fund != 2*utils.CalculateCommission(utils.Coins(200), 0.05) || prizePool != 0 {
+		t.Fatalf("两局手续费应全部计入群组基金: 基金 %d，奖池 %d（%v）", fund, prizePool, err)
+	}
+
+	players, err := db.GetActiveChatPlayers(chatID, since)
+	if err != nil || len(players) != 3 {
+		t.Fatalf("近期活跃玩家应为 3 人: %v（%v）", players, err)
+	}
+	if _, err := db.AirdropChatFund(chatID, fund+1, players); err == nil {
+		t.Error("空投金额超过基金余额时应被拒绝")
+	}
+
+	// 空投 10.01 分给 3 人，每人 3.33，零头 0.02 留在基金中
+	before := make(map[int64]int64)
+	for _, id := range players {
+		user, _ := db.GetUser(id)
+		before[id] = user.Balance
+	}
+	airdrop, err := db.AirdropChatFund(chatID, 1001, players)
+	if err != nil {
+		t.Fatalf("空投失败: %v", err)
+	}
+	if airdrop.PerUser != 333 || airdrop.Total != 999 || airdrop.Remaining != fund-999 {
+		t.Errorf("空投结果不符: %+v", airdrop)
+	}
+	for _, id := range players {
+		if user, _ := db.GetUser(id); user.Balance != before[id]+333 {
+			t.Errorf("玩家 %d 应获得空投 3.33，余额 %s", id, utils.FormatAmount(user.Balance))
+		}
+	}
+	airdrops, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 2, Type: models.TransactionTypeFundAirdrop}, "", 10)
+	if err != nil || len(airdrops) != 1 || airdrops[0].Balance != before[2]+333 {
+		t.Errorf("空投交易记录不符: %+v（%v）", airdrops, err)
+	}
+
+	// 剩余基金全部转入比赛奖池，超出余额时拒绝
+	remaining := airdrop.Remaining
+	if _, err := db.MoveChatFundToPrizePool(chatID, remaining+1); err == nil {
+		t.Error("转入金额超过基金余额时应被拒绝")
+	}
+	pool, err := db.MoveChatFundToPrizePool(chatID, remaining)
+	if err != nil || pool != remaining {
+		t.Fatalf("转入比赛奖池失败: %d（%v）", pool, err)
+	}
+	if fund, prizePool, _ := db.GetChatFund(chatID); fund != 0 || prizePool != remaining {
+		t.Errorf("转入后基金应为 0、奖池为 %d，实际基金 %d，奖池 %d", remaining, fund, prizePool)
+	}
+	if _, err := db.AirdropChatFund(chatID, 3, players); err == nil {
+		t.Error("基金为空时空投应被拒绝")
+	}
+}
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestChatGameLimits 群组自定义的下注限额在发起对局时生效，超限的下注不扣款；
+// 结算按群组手续费率扣除手续费，未设置的群组沿用全局费率
+func TestChatGameLimits(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "chat_limits.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	limitedChat, freeChat, defaultChat := int64(-4751), int64(-4752), int64(-4753)
+	tenPercent, zero, tooHigh := 0.1, 0.0, game.MaxChatFeeRate+0.1
+
+	// 超出全局范围或自相矛盾的设置被拒绝
+	for _, invalid := range []*models.ChatGameLimits{
+		{MinBet: utils.Coins(1) - 1},
+		{MaxBet: utils.Coins(1001)},
+		{MinBet: utils.Coins(50), MaxBet: utils.Coins(5)},
+		{FeeRate: &tooHigh},
+		{GameTimeout: 1},
+	} {
+		if err := manager.SetChatGameLimits(limitedChat, invalid); err == nil {
+			t.Errorf("无效的群组设置应被拒绝: %+v", invalid)
+		}
+	}
+	if err := manager.SetChatGameLimits(limitedChat, &models.ChatGameLimits{
+		MinBet: utils.Coins(5), MaxBet: utils.Coins(50), FeeRate: &tenPercent, GameTimeout: 30,
+	}); err != nil {
+		t.Fatalf("保存群组设置失败: %v", err)
+	}
+	if err := manager.SetChatGameLimits(freeChat, &models.ChatGameLimits{FeeRate: &zero}); err != nil {
+		t.Fatalf("保存群组设置失败: %v", err)
+	}
+	if limits, err := manager.ChatLimits(defaultChat); err != nil || limits.MinBet != utils.Coins(1) || limits.MaxBet != utils.Coins(1000) || limits.FeeRate != 0.05 {
+		t.Errorf("未设置的群组应沿用全局配置: %+v（%v）", limits, err)
+	}
+
+	balance := func(userID int64) int64 {
+		t.Helper()
+		user, err := db.GetUser(userID)
+		if err != nil || user == nil {
+			t.Fatalf("读取用户失败: %v", err)
+		}
+		return user.Balance
+	}
+	play := func(chatID, bet int64) (string, *game.GameResult) {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, chatID, bet)
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		result, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1)
+		if err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return gameID, result
+	}
+	commission := func(gameID string) int64 {
+		t.Helper()
+		rows, _, err := db.SearchTransactions(&models.TransactionFilter{GameID: gameID, Type: models.TransactionTypeCommission}, "", 10)
+		if err != nil {
+			t.Fatalf("查询手续费交易失败: %v", err)
+		}
+		var total int64
+		for _, row := range rows {
+			total += row.Amount
+		}
+		return total
+	}
+
+	// 超出群组限额的下注被拒绝，不扣款也不留下注记录；同样的金额在其他群组可以下注
+	for _, bet := range []int64{utils.Coins(4), utils.Coins(51)} {
+		if _, err := manager.CreateGame(1, limitedChat, bet); err == nil {
+			t.Errorf("超出群组限额的下注 %s 应被拒绝", utils.FormatAmount(bet))
+		}
+	}
+	if b := balance(1); b != utils.Coins(1000) {
+		t.Errorf("被拒绝的下注不应扣款，余额 %s", utils.FormatAmount(b))
+	}
+	if rows, _, _ := db.SearchTransactions(&models.TransactionFilter{UserID: 1, Type: models.TransactionTypeBet}, "", 10); len(rows) != 0 {
+		t.Errorf("被拒绝的下注不应有下注交易，实际 %d 笔", len(rows))
+	}
+
+	// 群组费率 10%：奖池 100，手续费 10
+	gameID, result := play(limitedChat, utils.Coins(50))
+	if result.Commission != utils.Coins(10) || commission(gameID) != utils.Coins(10) {
+		t.Errorf("应按群组费率 10%% 扣除手续费 10，实际 %s / 交易 %s", utils.FormatAmount(result.Commission), utils.FormatAmount(commission(gameID)))
+	}
+	if b1, b2 := balance(1), balance(2); b1 != utils.Coins(1040) || b2 != utils.Coins(950) {
+		t.Errorf("群组费率结算后余额不符: %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+
+	// 群组费率 0：赢家拿走全部奖池，不记录手续费
+	gameID, result = play(freeChat, utils.Coins(50))
+	if result.Commission != 0 || commission(gameID) != 0 {
+		t.Errorf("费率为 0 的群组不应扣除手续费，实际 %s", utils.FormatAmount(result.Commission))
+	}
+	if b1, b2 := balance(1), balance(2); b1 != utils.Coins(1090) || b2 != utils.Coins(900) {
+		t.Errorf("免手续费结算后余额不符: %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+
+	// 未设置的群组按全局费率 5%，且不受其他群组的限额影响
+	gameID, result = play(defaultChat, utils.Coins(100))
+	if result.Commission != utils.Coins(10) || commission(gameID) != utils.Coins(10) {
+		t.Errorf("应按全局费率扣除手续费 10，实际 %s", utils.FormatAmount(result.Commission))
+	}
+	if b1, b2 := balance(1), balance(2); b1 != utils.Coins(1180) || b2 != utils.Coins(800) {
+		t.Errorf("全局费率结算后余额不符: %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestSettlementKeepsConcurrentCredits 结算和退款按增量入账，开骰到结算之间其他流程写入的余额不会被覆盖
+func TestSettlementKeepsConcurrentCredits(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "concurrent_credit.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	// 结算事务提交前，双方各领取一次签到金币
+	claimed := time.Now()
+	manager.SetSettlementOutbox(func(result *game.GameResult) []*models.OutboxMessage {
+		claimed = claimed.Add(time.Hour)
+		for id := int64(1); id <= 2; id++ {
+			if _, err := db.ClaimDailyBonus(&models.DailyClaim{UserID: id, Amount: utils.Coins(7), Streak: 1, ClaimedAt: claimed}, time.Minute); err != nil {
+				t.Errorf("签到失败: %v", err)
+			}
+		}
+		return nil
+	}, nil)
+
+	play := func(p1, p2 int) *game.GameResult {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, -1097, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		result, err := manager.PlayGameWithDiceResults(gameID, p1, p1, p1, p2, p2, p2)
+		if err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return result
+	}
+
+	result := play(6, 1)
+	if user, _ := db.GetUser(1); user.Balance != utils.Coins(100-10+7)+result.WinAmount {
+		t.Errorf("获胜者的签到金币不应被结算覆盖，余额 %s", utils.FormatAmount(user.Balance))
+	}
+	if user, _ := db.GetUser(2); user.Balance != utils.Coins(100-10+7) {
+		t.Errorf("输家余额不符: %s", utils.FormatAmount(user.Balance))
+	}
+
+	// 平局退款同样按增量入账
+	before1, _ := db.GetUser(1)
+	before2, _ := db.GetUser(2)
+	play(3, 3)
+	for _, before := range []*models.User{before1, before2} {
+		if user, _ := db.GetUser(before.ID); user.Balance != before.Balance+utils.Coins(7) {
+			t.Errorf("玩家 %d 平局退款后余额 %s，应为 %s", before.ID, utils.FormatAmount(user.Balance), utils.FormatAmount(before.Balance+utils.Coins(7)))
+		}
+	}
+
+	wins, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 1, Type: models.TransactionTypeWin}, "", 10)
+	if err != nil || len(wins) != 1 || wins[0].Balance != utils.Coins(100-10+7)+result.WinAmount {
+		t.Errorf("获胜交易记录应为入账后的余额: %+v（%v）", wins, err)
+	}
+}
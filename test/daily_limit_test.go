@@ -0,0 +1,102 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestDailyGameLimit 每人每天的对局数达到上限后拒绝发起和加入，提示重置时间；被豁免的用户和管理员不受限制
+func TestDailyGameLimit(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "daily_limit.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 4; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{
+		MinBet:         utils.Coins(1),
+		MaxBet:         utils.Coins(1000),
+		DailyGameLimit: 2,
+		AdminIDs:       []int64{4},
+	}, 0.05)
+	manager.SetOperationInterval(0)
+
+	chatID := int64(-1041)
+	play := func(p1, p2 int64) {
+		t.Helper()
+		gameID, err := manager.CreateGame(p1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, p2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+	}
+
+	// 无人加入而取消的对局不计入
+	cancelled, err := manager.CreateGame(1, chatID, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.AbortGame(cancelled); err != nil {
+		t.Fatalf("取消对局失败: %v", err)
+	}
+	play(1, 2)
+	play(1, 2)
+
+	daily, err := manager.UserDailyGames(1)
+	if err != nil || daily.Played != 2 || !daily.Reached() {
+		t.Fatalf("今日对局数不符: %+v（%v）", daily, err)
+	}
+
+	var limitErr *game.DailyLimitError
+	if _, err := manager.CreateGame(1, chatID, utils.Coins(10)); !errors.As(err, &limitErr) {
+		t.Fatalf("达到上限后发起对局应被拒绝，实际: %v", err)
+	}
+	if limitErr.Limit != 2 || limitErr.ResetAt.Hour() != 0 || time.Until(limitErr.ResetAt) > 24*time.Hour {
+		t.Errorf("重置时间不符: %+v", limitErr)
+	}
+	if !strings.Contains(limitErr.Error(), limitErr.ResetAt.Format("01-02 15:04")) {
+		t.Errorf("提示应包含重置时间: %q", limitErr.Error())
+	}
+
+	waiting, err := manager.CreateGame(3, chatID, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.JoinGame(waiting, 2); !errors.As(err, &limitErr) {
+		t.Fatalf("达到上限后加入对局应被拒绝，实际: %v", err)
+	}
+	// 管理员不受限制
+	if _, err := manager.JoinGame(waiting, 4); err != nil {
+		t.Fatalf("管理员加入对局失败: %v", err)
+	}
+
+	// 豁免后恢复可玩
+	if err := manager.SetDailyLimitExempt(1, true); err != nil {
+		t.Fatalf("豁免用户失败: %v", err)
+	}
+	if daily, _ := manager.UserDailyGames(1); daily == nil || !daily.Exempt || daily.Reached() {
+		t.Errorf("豁免后不应受限: %+v", daily)
+	}
+	if _, err := manager.CreateGame(1, -1042, utils.Coins(10)); err != nil {
+		t.Errorf("豁免的用户发起对局失败: %v", err)
+	}
+}
@@ -0,0 +1,231 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestParseDatabaseURL 按协议前缀识别数据库方言
+func TestParseDatabaseURL(t *testing.T) {
+	cases := []struct {
+		url     string
+		dialect database.Dialect
+		dsn     string
+	}{
+		{"dice_bot.db", database.DialectSQLite, "dice_bot.db"},
+		{":memory:", database.DialectSQLite, ":memory:"},
+		{"sqlite://./data/bot.db", database.DialectSQLite, "./data/bot.db"},
+		{"postgres://bot:secret@db:5432/dice", database.DialectPostgres, "postgres://bot:secret@db:5432/dice"},
+		{"postgresql://db/dice?sslmode=disable", database.DialectPostgres, "postgresql://db/dice?sslmode=disable"},
+	}
+	for _, c := range cases {
+		dialect, dsn := database.ParseDatabaseURL(c.url)
+		if dialect != c.dialect || dsn != c.dsn {
+			t.Errorf("%s: 期望 %s %s，实际 %s %s", c.url, c.dialect, c.dsn, dialect, dsn)
+		}
+	}
+
+	if shown := database.DisplayURL("postgres://bot:secret@db:5432/dice"); strings.Contains(shown, "secret") {
+		t.Errorf("日志中的连接串不应包含密码: %s", shown)
+	}
+}
+
+// TestPostgresTranslate 改写占位符、字段类型和 SQLite 专有写法，字符串常量中的 ? 保持不变
+func TestPostgresTranslate(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{
+			`SELECT id FROM users WHERE id = ? AND username = '?' AND source = ?`,
+			`SELECT id FROM users WHERE id = $1 AND username = '?' AND source = $2`,
+		},
+		{
+			`CREATE TABLE IF NOT EXISTS t (id INTEGER PRIMARY KEY AUTOINCREMENT, user_id INTEGER NOT NULL, rate REAL, created_at DATETIME)`,
+			`CREATE TABLE IF NOT EXISTS t (id BIGSERIAL PRIMARY KEY, user_id BIGINT NOT NULL, rate DOUBLE PRECISION, created_at TIMESTAMPTZ)`,
+		},
+		{
+			`ALTER TABLE users ADD COLUMN frozen INTEGER DEFAULT 0`,
+			`ALTER TABLE users ADD COLUMN IF NOT EXISTS frozen BIGINT DEFAULT 0`,
+		},
+		{
+			`SELECT id FROM users WHERE username = ? COLLATE NOCASE LIMIT 1`,
+			`SELECT id FROM users WHERE LOWER(username) = LOWER($1) LIMIT 1`,
+		},
+		{
+			`CREATE INDEX IF NOT EXISTS idx_users_username_nocase ON users(username COLLATE NOCASE)`,
+			`CREATE INDEX IF NOT EXISTS idx_users_username_nocase ON users(LOWER(username))`,
+		},
+		{
+			`SELECT COUNT(*) FROM games WHERE created_at >= date('now')`,
+			`SELECT COUNT(*) FROM games WHERE created_at >= CURRENT_DATE`,
+		},
+	}
+	for _, c := range cases {
+		if got := database.DialectPostgres.Translate(c.query); got != c.want {
+			t.Errorf("改写结果不符:\n期望 %s\n实际 %s", c.want, got)
+		}
+	}
+
+	query := `SELECT id FROM users WHERE id = ?`
+	if got := database.DialectSQLite.Translate(query); got != query {
+		t.Errorf("SQLite 方言不应改写语句: %s", got)
+	}
+}
+
+// TestPostgresTranslateStatements 仓库中实际使用的语句形式：触发器、冲突处理、忽略冲突的插入、日期函数和模糊查询；
+// 字符串常量、带引号的标识符和函数体中的内容不改写
+func TestPostgresTranslateStatements(t *testing.T) {
+	cases := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			"冲突时更新",
+			`INSERT INTO chats (id, service_status, waitlisted_at, updated_at) VALUES (?, ?, ?, ?)
+			  ON CONFLICT(id) DO UPDATE SET service_status = excluded.service_status, updated_at = excluded.updated_at`,
+			`INSERT INTO chats (id, service_status, waitlisted_at, updated_at) VALUES ($1, $2, $3, $4)
+			  ON CONFLICT(id) DO UPDATE SET service_status = excluded.service_status, updated_at = excluded.updated_at`,
+		},
+		{
+			"冲突时忽略",
+			`INSERT INTO chat_members (chat_id, user_id, first_seen_at) VALUES (?, ?, ?)
+			  ON CONFLICT(chat_id, user_id) DO NOTHING`,
+			`INSERT INTO chat_members (chat_id, user_id, first_seen_at) VALUES ($1, $2, $3)
+			  ON CONFLICT(chat_id, user_id) DO NOTHING`,
+		},
+		{
+			"INSERT OR IGNORE",
+			`INSERT OR IGNORE INTO bot_settings (key, value) VALUES (?, ?)`,
+			`INSERT INTO bot_settings (key, value) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+		},
+		{
+			"INSERT OR IGNORE 多行",
+			"\n\t\tinsert or ignore into chat_members (chat_id, user_id)\n\t\tVALUES (?, ?)\n\t",
+			"\n\t\tINSERT INTO chat_members (chat_id, user_id)\n\t\tVALUES ($1, $2) ON CONFLICT DO NOTHING",
+		},
+		{
+			"触发器函数体中的 ? 和引号保持不变",
+			`CREATE OR REPLACE FUNCTION games_status_check() RETURNS trigger AS $$
+			BEGIN
+				IF TG_OP = 'UPDATE' AND (OLD.status || '>' || NEW.status) NOT IN ('waiting>playing') THEN
+					RAISE EXCEPTION 'illegal transition?';
+				END IF;
+				RETURN NEW;
+			END
+			$$ LANGUAGE plpgsql`,
+			`CREATE OR REPLACE FUNCTION games_status_check() RETURNS trigger AS $$
+			BEGIN
+				IF TG_OP = 'UPDATE' AND (OLD.status || '>' || NEW.status) NOT IN ('waiting>playing') THEN
+					RAISE EXCEPTION 'illegal transition?';
+				END IF;
+				RETURN NEW;
+			END
+			$$ LANGUAGE plpgsql`,
+		},
+		{
+			"触发器",
+			`CREATE TRIGGER games_status_check BEFORE INSERT OR UPDATE OF status ON games
+			FOR EACH ROW EXECUTE FUNCTION games_status_check()`,
+			`CREATE TRIGGER games_status_check BEFORE INSERT OR UPDATE OF status ON games
+			FOR EACH ROW EXECUTE FUNCTION games_status_check()`,
+		},
+		{
+			"函数体之后的占位符照常编号",
+			`SELECT $$a?b$$, id FROM users WHERE id = ? AND source = ?`,
+			`SELECT $$a?b$$, id FROM users WHERE id = $1 AND source = $2`,
+		},
+		{
+			"相对日期",
+			`SELECT COUNT(DISTINCT user_id) FROM transactions WHERE created_at >= datetime('now', '-7 days')`,
+			`SELECT COUNT(DISTINCT user_id) FROM transactions WHERE created_at >= (NOW() - INTERVAL '7 days')`,
+		},
+		{
+			"模糊查询不区分大小写",
+			`SELECT id FROM users WHERE username LIKE ? LIMIT ?`,
+			`SELECT id FROM users WHERE username ILIKE $1 LIMIT $2`,
+		},
+		{
+			"字符串常量中的关键字和日期函数保持不变",
+			`SELECT id FROM games WHERE status = 'LIKE INTEGER' AND note LIKE ? AND detail <> 'date(''now'')'`,
+			`SELECT id FROM games WHERE status = 'LIKE INTEGER' AND note ILIKE $1 AND detail <> 'date(''now'')'`,
+		},
+		{
+			"带引号的标识符保持不变",
+			`SELECT "REAL", "user LIKE" FROM t WHERE id = ?`,
+			`SELECT "REAL", "user LIKE" FROM t WHERE id = $1`,
+		},
+		{
+			"函数体中的类型名保持不变",
+			`CREATE OR REPLACE FUNCTION f() RETURNS trigger AS $$ DECLARE n INTEGER; BEGIN RETURN NEW; END $$ LANGUAGE plpgsql`,
+			`CREATE OR REPLACE FUNCTION f() RETURNS trigger AS $$ DECLARE n INTEGER; BEGIN RETURN NEW; END $$ LANGUAGE plpgsql`,
+		},
+		{
+			"字符串常量之后的日期函数照常改写",
+			`SELECT COUNT(*) FROM games WHERE status = 'x' AND created_at >= date('now') AND updated_date('now') IS NULL`,
+			`SELECT COUNT(*) FROM games WHERE status = 'x' AND created_at >= CURRENT_DATE AND updated_date('now') IS NULL`,
+		},
+	}
+	for _, c := range cases {
+		if got := database.DialectPostgres.Translate(c.query); got != c.want {
+			t.Errorf("%s 改写结果不符:\n期望 %s\n实际 %s", c.name, c.want, got)
+		}
+	}
+}
+
+// TestInitDialect 文件路径使用 SQLite；PostgreSQL 连接失败时返回错误
+func TestInitDialect(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "dialect.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+	if db.Dialect() != database.DialectSQLite {
+		t.Errorf("期望 SQLite 方言，实际 %s", db.Dialect())
+	}
+
+	if _, err := database.Init("postgres://bot@127.0.0.1:1/dice"); err == nil {
+		t.Error("无法连接 PostgreSQL 时应返回错误")
+	}
+}
+
+// TestTableSeatOrder 快速桌玩家按入座顺序排列，不依赖 SQLite 的 rowid
+func TestTableSeatOrder(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "seats.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	seats := []int64{3, 1, 2}
+	for _, id := range seats {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	table := &models.Table{ID: "T1", ChatID: -1074, CreatorID: seats[0], Ante: utils.Coins(10), Mode: models.TableModeSplit, MaxPlayers: 3}
+	if err := db.CreateTableWithAnte(table); err != nil {
+		t.Fatalf("开设快速桌失败: %v", err)
+	}
+	for _, id := range seats[1:] {
+		if _, err := db.JoinTableWithAnte(table.ID, id, 3); err != nil {
+			t.Fatalf("加入快速桌失败: %v", err)
+		}
+	}
+
+	players, err := db.GetTablePlayers(table.ID)
+	if err != nil || len(players) != len(seats) {
+		t.Fatalf("获取玩家失败: %d（%v）", len(players), err)
+	}
+	for i, player := range players {
+		if player.UserID != seats[i] {
+			t.Errorf("第 %d 位应为用户 %d，实际 %d", i+1, seats[i], player.UserID)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestDispute 结算后对局一方提出争议冻结获胜者派奖；维持原结果退回派奖，撤销结果则对局作废、双方退还下注
+func TestDispute(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "dispute.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	chatID := int64(-1061)
+	play := func(dice ...int) string {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, dice[0], dice[1], dice[2], dice[3], dice[4], dice[5]); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return gameID
+	}
+	balance := func(userID int64) int64 {
+		t.Helper()
+		user, err := db.GetUser(userID)
+		if err != nil || user == nil {
+			t.Fatalf("获取用户失败: %v", err)
+		}
+		return user.Balance
+	}
+
+	draw := play(3, 3, 3, 3, 3, 3)
+	if _, err := manager.OpenDispute(draw, 2); err == nil {
+		t.Error("平局不应允许提出争议")
+	}
+
+	// 维持原结果：冻结后原样退回
+	released := play(6, 6, 6, 1, 1, 1)
+	winnerBefore := balance(1)
+	if _, err := manager.OpenDispute(released, 3); err == nil {
+		t.Error("对局以外的用户不应能提出争议")
+	}
+	dispute, err := manager.OpenDispute(released, 2)
+	if err != nil {
+		t.Fatalf("提出争议失败: %v", err)
+	}
+	if dispute.WinnerID != 1 || dispute.LoserID != 2 || dispute.Payout != utils.Coins(19) || dispute.Held != dispute.Payout {
+		t.Fatalf("争议记录不符: %+v", dispute)
+	}
+	if got := balance(1); got != winnerBefore-dispute.Payout {
+		t.Errorf("获胜者派奖应被冻结，余额 %d", got)
+	}
+	if _, err := manager.OpenDispute(released, 1); !errors.Is(err, database.ErrDisputeExists) {
+		t.Errorf("同一局不应重复提出争议: %v", err)
+	}
+	if open, _ := manager.Disputes(models.DisputeStatusOpen, 10); len(open) != 1 {
+		t.Errorf("待审核争议应有 1 条，实际 %d 条", len(open))
+	}
+
+	if _, err := manager.ReleaseDispute(dispute.ID, "admin", "骰子消息核对无误"); err != nil {
+		t.Fatalf("维持原结果失败: %v", err)
+	}
+	if got := balance(1); got != winnerBefore {
+		t.Errorf("维持原结果后应退回派奖，余额 %d，应为 %d", got, winnerBefore)
+	}
+	if _, err := manager.ReleaseDispute(dispute.ID, "admin", ""); !errors.Is(err, database.ErrDisputeNotOpen) {
+		t.Errorf("已处理的争议不应重复处理: %v", err)
+	}
+
+	// 撤销结果：双方余额回到开局前
+	before1, before2 := balance(1), balance(2)
+	reversed := play(6, 6, 6, 1, 1, 1)
+	dispute, err = manager.OpenDispute(reversed, 2)
+	if err != nil {
+		t.Fatalf("提出争议失败: %v", err)
+	}
+	if dispute, err = manager.ReverseDispute(dispute.ID, "admin", "骰子消息与结果不符"); err != nil {
+		t.Fatalf("撤销结果失败: %v", err)
+	}
+	if dispute.Status != models.DisputeStatusReversed || dispute.ResolvedAt == nil {
+		t.Errorf("争议状态不符: %+v", dispute)
+	}
+	if got1, got2 := balance(1), balance(2); got1 != before1 || got2 != before2 {
+		t.Errorf("撤销结果后余额应回到开局前: %d/%d，应为 %d/%d", got1, got2, before1, before2)
+	}
+
+	timeline, err := manager.GameTimeline(reversed)
+	if err != nil {
+		t.Fatalf("获取对局时间线失败: %v", err)
+	}
+	if text := ui.FormatGameTimeline(timeline); strings.Count(text, "⚖️ 争议") != 2 || !strings.Contains(text, "骰子消息与结果不符") {
+		t.Errorf("时间线应包含争议及审核记录:\n%s", text)
+	}
+
+	codec := callback.NewCodec("dispute-secret")
+	offer, err := ui.BuildRematchOffer(codec, chatID, reversed, utils.Coins(10), game.DisputeWindow)
+	if err != nil {
+		t.Fatalf("生成结算按钮失败: %v", err)
+	}
+	row := offer.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup).InlineKeyboard[0]
+	if len(row) != 2 || row[1].CallbackData == nil {
+		t.Fatalf("结算消息应附带争议按钮: %+v", row)
+	}
+	if gameID, err := ui.ParseDisputeCallback(codec, *row[1].CallbackData); err != nil || gameID != reversed {
+		t.Errorf("争议按钮解析不符: %s（%v）", gameID, err)
+	}
+}
@@ -0,0 +1,256 @@
+package test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// drawRaceFixture 两名玩家和一个群组，用于平局结算与认输、中止等流程的竞争测试
+type drawRaceFixture struct {
+	t       *testing.T
+	db      *database.DB
+	manager *game.Manager
+	chatID  int64
+}
+
+func newDrawRaceFixture(t *testing.T, name string) *drawRaceFixture {
+	t.Helper()
+	db, err := database.Init(filepath.Join(t.TempDir(), name+".db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	return &drawRaceFixture{t: t, db: db, manager: manager, chatID: -1696}
+}
+
+// playing 发起并加入一局对局，返回进行中的对局ID
+func (f *drawRaceFixture) playing(bet int64) string {
+	f.t.Helper()
+	gameID, err := f.manager.CreateGame(1, f.chatID, bet)
+	if err != nil {
+		f.t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := f.manager.JoinGame(gameID, 2); err != nil {
+		f.t.Fatalf("加入对局失败: %v", err)
+	}
+	return gameID
+}
+
+// total 双方余额之和
+func (f *drawRaceFixture) total() int64 {
+	f.t.Helper()
+	var sum int64
+	for id := int64(1); id <= 2; id++ {
+		user, err := f.db.GetUser(id)
+		if err != nil || user == nil {
+			f.t.Fatalf("读取用户失败: %v", err)
+		}
+		sum += user.Balance
+	}
+	return sum
+}
+
+// refunds 对局的退款交易数
+func (f *drawRaceFixture) refunds(gameID string) int {
+	f.t.Helper()
+	rows, _, err := f.db.SearchTransactions(&models.TransactionFilter{GameID: gameID, Type: models.TransactionTypeRefund}, "", 10)
+	if err != nil {
+		f.t.Fatalf("查询退款交易失败: %v", err)
+	}
+	return len(rows)
+}
+
+// checkRefundedOnce 中止或平局退款后双方余额恢复原样，对局恰好有 2 笔退款交易
+func (f *drawRaceFixture) checkRefundedOnce(round int, gameID string, before int64) {
+	f.t.Helper()
+	if after := f.total(); after != before {
+		f.t.Fatalf("第 %d 局余额应恢复原样: 之前 %s，之后 %s", round, utils.FormatAmount(before), utils.FormatAmount(after))
+	}
+	if n := f.refunds(gameID); n != 2 {
+		f.t.Fatalf("第 %d 局应恰好退款一次（2 笔退款交易），实际 %d 笔", round, n)
+	}
+}
+
+// TestDrawSettleAfterSurrender 对局已认输结算后，迟到的平局结算不得再向双方退款
+func TestDrawSettleAfterSurrender(t *testing.T) {
+	f := newDrawRaceFixture(t, "draw_after_surrender")
+	if err := f.db.SetChatSurrenderEnabled(f.chatID, true); err != nil {
+		t.Fatalf("开启认输失败: %v", err)
+	}
+
+	gameID := f.playing(utils.Coins(100))
+	if _, err := f.manager.Surrender(gameID, 2); err != nil {
+		t.Fatalf("认输失败: %v", err)
+	}
+	before, refunds := f.total(), f.refunds(gameID)
+
+	// 模拟平局结算在认输提交前读到了进行中的状态
+	refund := []*models.Transaction{
+		{ID: utils.GenerateTransactionID(), UserID: 1, GameID: &gameID, Type: models.TransactionTypeRefund, Amount: utils.Coins(100)},
+		{ID: utils.GenerateTransactionID(), UserID: 2, GameID: &gameID, Type: models.TransactionTypeRefund, Amount: utils.Coins(100)},
+	}
+	player2 := int64(2)
+	if err := f.db.DrawGameWithTransaction(gameID, 3, 3, 3, 3, 3, 3, 1, &player2, refund, nil); err == nil {
+		t.Fatal("已认输的对局不应再按平局结算")
+	}
+	if after := f.total(); after != before {
+		t.Errorf("平局结算失败时余额不应变化: %s -> %s", utils.FormatAmount(before), utils.FormatAmount(after))
+	}
+	if n := f.refunds(gameID); n != refunds {
+		t.Errorf("平局结算失败时不应新增退款交易: %d -> %d", refunds, n)
+	}
+	if g, _ := f.db.GetGame(gameID); g == nil || g.Status != models.GameStatusSurrendered {
+		t.Errorf("对局应保持认输状态: %+v", g)
+	}
+}
+
+// TestDrawSettleRacesSurrender 平局结算与认输并发时只有一方生效，双方余额加手续费保持不变
+func TestDrawSettleRacesSurrender(t *testing.T) {
+	f := newDrawRaceFixture(t, "draw_races_surrender")
+	if err := f.db.SetChatSurrenderEnabled(f.chatID, true); err != nil {
+		t.Fatalf("开启认输失败: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		before := f.total()
+		gameID := f.playing(utils.Coins(10))
+
+		var wg sync.WaitGroup
+		var drawErr, surrenderErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, drawErr = f.manager.PlayGameWithDiceResults(gameID, 3, 3, 3, 3, 3, 3)
+		}()
+		go func() {
+			defer wg.Done()
+			_, surrenderErr = f.manager.Surrender(gameID, 2)
+		}()
+		wg.Wait()
+
+		if (drawErr == nil) == (surrenderErr == nil) {
+			t.Fatalf("第 %d 局平局结算和认输应恰好一方成功: %v / %v", i, drawErr, surrenderErr)
+		}
+		g, err := f.db.GetGame(gameID)
+		if err != nil || g == nil {
+			t.Fatalf("读取对局失败: %v", err)
+		}
+		if after := f.total(); after+g.Commission != before {
+			t.Fatalf("第 %d 局余额不守恒: 之前 %s，之后 %s，手续费 %s", i,
+				utils.FormatAmount(before), utils.FormatAmount(after), utils.FormatAmount(g.Commission))
+		}
+		if drawErr == nil && f.refunds(gameID) != 2 {
+			t.Errorf("第 %d 局平局应有 2 笔退款交易，实际 %d", i, f.refunds(gameID))
+		}
+		if surrenderErr == nil && f.refunds(gameID) > 1 {
+			t.Errorf("第 %d 局认输后只应有认输方的退款交易，实际 %d 笔", i, f.refunds(gameID))
+		}
+	}
+}
+
+// TestDrawSettleRacesWatchdog 开骰后超过最长时长的中止与迟到的平局结算并发时，双方只退款一次
+func TestDrawSettleRacesWatchdog(t *testing.T) {
+	f := newDrawRaceFixture(t, "draw_races_watchdog")
+	aborted := make(chan string, 1)
+	f.manager.SetGameAbortedCallback(func(game *models.Game, reason string) {
+		aborted <- game.ID
+	})
+	f.manager.SetMaxGameDuration(time.Millisecond)
+
+	for i := 0; i < 20; i++ {
+		before := f.total()
+		gameID := f.playing(utils.Coins(10))
+
+		if _, err := f.manager.PlayGameWithDiceResults(gameID, 3, 3, 3, 3, 3, 3); err != nil {
+			// 中止抢先，等待中止完成
+			select {
+			case id := <-aborted:
+				if id != gameID {
+					t.Fatalf("第 %d 局中止的对局不符: %s", i, id)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("第 %d 局平局结算失败但对局未被中止: %v", i, err)
+			}
+		}
+		f.checkRefundedOnce(i, gameID, before)
+	}
+}
+
+// TestDrawSettleRacesAdminAbort 管理员强制中止与平局结算并发时只有一方生效，双方只退款一次
+func TestDrawSettleRacesAdminAbort(t *testing.T) {
+	f := newDrawRaceFixture(t, "draw_races_abort")
+
+	for i := 0; i < 20; i++ {
+		before := f.total()
+		gameID := f.playing(utils.Coins(10))
+
+		var wg sync.WaitGroup
+		var drawErr, abortErr error
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, drawErr = f.manager.PlayGameWithDiceResults(gameID, 3, 3, 3, 3, 3, 3)
+		}()
+		go func() {
+			defer wg.Done()
+			_, abortErr = f.manager.AbortGame(gameID)
+		}()
+		wg.Wait()
+
+		if (drawErr == nil) == (abortErr == nil) {
+			t.Fatalf("第 %d 局平局结算和强制中止应恰好一方成功: %v / %v", i, drawErr, abortErr)
+		}
+		f.checkRefundedOnce(i, gameID, before)
+	}
+}
+
+// TestDrawSettleRacesDrain 关闭服务时的超时退款与平局结算并发时，双方只退款一次
+func TestDrawSettleRacesDrain(t *testing.T) {
+	f := newDrawRaceFixture(t, "draw_races_drain")
+
+	for i := 0; i < 20; i++ {
+		// 每轮模拟一次进程关闭，Drain 只处理本进程开局的对局
+		f.manager = game.NewManager(f.db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+		f.manager.SetOperationInterval(0)
+		before := f.total()
+		gameID := f.playing(utils.Coins(10))
+
+		var wg sync.WaitGroup
+		var drawErr, drainErr error
+		var refunded int
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, drawErr = f.manager.PlayGameWithDiceResults(gameID, 3, 3, 3, 3, 3, 3)
+		}()
+		go func() {
+			defer wg.Done()
+			_, refunded, drainErr = f.manager.Drain(0)
+		}()
+		wg.Wait()
+
+		if drainErr != nil {
+			t.Fatalf("第 %d 局关闭前处理对局失败: %v", i, drainErr)
+		}
+		if (drawErr == nil) == (refunded == 1) {
+			t.Fatalf("第 %d 局平局结算和关闭前退款应恰好一方生效: %v / 退款 %d 局", i, drawErr, refunded)
+		}
+		f.checkRefundedOnce(i, gameID, before)
+	}
+}
@@ -0,0 +1,293 @@
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// e2eEnv 端到端测试环境：假 Bot API 服务器 + 真实数据库和游戏管理器
+type e2eEnv struct {
+	server  *telegram.FakeServer
+	client  *telegram.APIClient
+	db      *database.DB
+	manager *game.Manager
+	router  *middleware.Router
+	updates tgbotapi.UpdatesChannel
+	result  *game.GameResult
+}
+
+// newE2EEnv 创建端到端测试环境，并注册 /dice 命令和加入按钮回调
+func newE2EEnv(t *testing.T) *e2eEnv {
+	t.Helper()
+
+	server := telegram.NewFakeServer()
+	t.Cleanup(server.Close)
+
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("连接假服务器失败: %v", err)
+	}
+
+	db, err := database.Init(t.TempDir() + "/e2e.db")
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	env := &e2eEnv{
+		server:  server,
+		client:  client,
+		db:      db,
+		manager: game.NewManager(db, &config.Config{MinBet: 1, MaxBet: 1000}, 0.05),
+		router:  middleware.NewRouter(client, middleware.Recover()),
+		updates: client.GetUpdatesChan(tgbotapi.UpdateConfig{Timeout: 0}),
+	}
+	t.Cleanup(client.StopReceivingUpdates)
+
+	env.router.Handle("dice", env.handleDice)
+	env.router.HandleCallback("join_", env.handleJoin)
+	return env
+}
+
+// handleDice 创建对局并发送带加入按钮的公告
+func (e *e2eEnv) handleDice(ctx *middleware.Context) error {
+	amount, err := strconv.ParseInt(strings.TrimSpace(ctx.Args), 10, 64)
+	if err != nil {
+		return ctx.Reply("❌ 请输入下注金额")
+	}
+
+	gameID, err := e.manager.CreateGame(ctx.UserID, ctx.ChatID, amount)
+	if err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+
+	msg := tgbotapi.NewMessage(ctx.ChatID, fmt.Sprintf("🎲 新对局 %s，下注 %d 金币", gameID, amount))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("加入", "join_"+gameID),
+		),
+	)
+	_, err = ctx.Client.Send(msg)
+	return err
+}
+
+// handleJoin 加入对局，依次发送 6 个骰子并按骰子结果结算
+func (e *e2eEnv) handleJoin(ctx *middleware.Context) error {
+	query := ctx.Update.CallbackQuery
+	gameID := strings.TrimPrefix(query.Data, "join_")
+
+	if _, err := e.manager.JoinGame(gameID, ctx.UserID); err != nil {
+		return ctx.Reply("❌ " + err.Error())
+	}
+	if _, err := ctx.Client.Request(tgbotapi.NewCallback(query.ID, "")); err != nil {
+		return err
+	}
+
+	var dice [6]int
+	for i := range dice {
+		msg, err := ctx.Client.Send(tgbotapi.NewDice(ctx.ChatID))
+		if err != nil {
+			return err
+		}
+		dice[i] = msg.Dice.Value
+	}
+
+	result, err := e.manager.PlayGameWithDiceResults(gameID, dice[0], dice[1], dice[2], dice[3], dice[4], dice[5])
+	if err != nil {
+		return err
+	}
+
+	if _, err := ctx.Client.Send(tgbotapi.NewMessage(ctx.ChatID, fmt.Sprintf("🏁 对局 %s 结束", gameID))); err != nil {
+		return err
+	}
+	e.result = result
+	return nil
+}
+
+// pump 拉取并分发更新，直到 done 返回 true 或超时
+func (e *e2eEnv) pump(t *testing.T, timeout time.Duration, done func() bool) {
+	t.Helper()
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+
+	deadline := time.After(timeout)
+	for !done() {
+		select {
+		case update := <-e.updates:
+			if _, err := e.router.Dispatch(&update); err != nil {
+				t.Fatalf("处理更新失败: %v", err)
+			}
+		case <-ticker.C:
+		case <-deadline:
+			t.Fatalf("等待超时，已收到调用: %+v", e.server.Calls())
+		}
+	}
+}
+
+// commandUpdate 构造群组内的命令消息
+func commandUpdate(chatID, userID int64, text string) tgbotapi.Update {
+	command := strings.Fields(text)[0]
+	return tgbotapi.Update{
+		Message: &tgbotapi.Message{
+			MessageID: 1,
+			From:      &tgbotapi.User{ID: userID, FirstName: fmt.Sprintf("user%d", userID)},
+			Chat:      &tgbotapi.Chat{ID: chatID, Type: "supergroup"},
+			Date:      int(time.Now().Unix()),
+			Text:      text,
+			Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(command)}},
+		},
+	}
+}
+
+// callbackUpdate 构造内联按钮点击
+func callbackUpdate(chatID, userID int64, messageID int, data string) tgbotapi.Update {
+	return tgbotapi.Update{
+		CallbackQuery: &tgbotapi.CallbackQuery{
+			ID:   fmt.Sprintf("cb-%d", userID),
+			From: &tgbotapi.User{ID: userID, FirstName: fmt.Sprintf("user%d", userID)},
+			Message: &tgbotapi.Message{
+				MessageID: messageID,
+				Chat:      &tgbotapi.Chat{ID: chatID, Type: "supergroup"},
+			},
+			Data: data,
+		},
+	}
+}
+
+// joinButtonData 从公告消息的内联键盘中取出加入按钮的回调数据
+func joinButtonData(t *testing.T, call telegram.APICall) string {
+	t.Helper()
+
+	var markup tgbotapi.InlineKeyboardMarkup
+	if err := json.Unmarshal([]byte(call.Params["reply_markup"]), &markup); err != nil {
+		t.Fatalf("解析公告键盘失败: %v", err)
+	}
+	if len(markup.InlineKeyboard) == 0 || len(markup.InlineKeyboard[0]) == 0 {
+		t.Fatal("公告缺少加入按钮")
+	}
+	button := markup.InlineKeyboard[0][0]
+	if button.CallbackData == nil {
+		t.Fatal("加入按钮缺少回调数据")
+	}
+	return *button.CallbackData
+}
+
+// TestEndToEndDiceGame 从 /dice 命令到结算的完整流程，只校验发往 Bot API 的调用和余额
+func TestEndToEndDiceGame(t *testing.T) {
+	env := newE2EEnv(t)
+
+	chatID := int64(-100123)
+	player1ID := int64(3001)
+	player2ID := int64(3002)
+	betAmount := int64(100)
+
+	if err := setupTestUsers(env.db, player1ID, player2ID); err != nil {
+		t.Fatalf("设置测试用户失败: %v", err)
+	}
+
+	// 第一步：玩家1发送 /dice 100，机器人发公告
+	env.server.PushUpdate(commandUpdate(chatID, player1ID, "/dice 100"))
+
+	env.pump(t, 5*time.Second, func() bool {
+		return len(env.server.CallsTo("sendMessage")) > 0
+	})
+	announcement := env.server.CallsTo("sendMessage")[0]
+
+	if announcement.Int64("chat_id") != chatID {
+		t.Fatalf("公告发往了错误的群组: %d", announcement.Int64("chat_id"))
+	}
+	data := joinButtonData(t, announcement)
+	if !strings.HasPrefix(data, "join_") {
+		t.Fatalf("加入按钮回调数据错误: %s", data)
+	}
+
+	// 第二步：玩家2点击加入，玩家1掷出 6-6-6，玩家2掷出 1-1-1
+	env.server.QueueDice(6, 6, 6, 1, 1, 1)
+	env.server.PushUpdate(callbackUpdate(chatID, player2ID, 1, data))
+
+	env.pump(t, 5*time.Second, func() bool { return env.result != nil })
+	result := env.result
+	if result.Winner == nil || result.Winner.ID != player1ID {
+		t.Fatalf("获胜者应为玩家1，实际: %+v", result.Winner)
+	}
+
+	// 第三步：校验发往 Bot API 的调用顺序
+	var methods []string
+	for _, call := range env.server.Calls() {
+		methods = append(methods, call.Method)
+	}
+	expected := []string{"sendMessage", "answerCallbackQuery",
+		"sendDice", "sendDice", "sendDice", "sendDice", "sendDice", "sendDice", "sendMessage"}
+	if strings.Join(methods, ",") != strings.Join(expected, ",") {
+		t.Fatalf("Bot API 调用顺序错误:\n实际 %v\n期望 %v", methods, expected)
+	}
+	for _, call := range env.server.CallsTo("sendDice") {
+		if call.Int64("chat_id") != chatID {
+			t.Fatalf("骰子发往了错误的群组: %d", call.Int64("chat_id"))
+		}
+	}
+
+	// 第四步：校验结算后的余额
+	commission := utils.CalculateCommission(betAmount*2, 0.05)
+	assertBalance(t, env.db, player1ID, 10000-betAmount+betAmount*2-commission)
+	assertBalance(t, env.db, player2ID, 10000-betAmount)
+
+	settled, err := env.db.GetGame(result.GameID)
+	if err != nil {
+		t.Fatalf("获取对局失败: %v", err)
+	}
+	if settled.Status != models.GameStatusFinished || settled.Commission != commission {
+		t.Fatalf("对局结算状态错误: status=%s commission=%d", settled.Status, settled.Commission)
+	}
+}
+
+// TestEndToEndInsufficientBalance 余额不足时只回复提示，不发公告
+func TestEndToEndInsufficientBalance(t *testing.T) {
+	env := newE2EEnv(t)
+
+	chatID := int64(-100456)
+	if err := env.db.CreateUser(&models.User{ID: 3101, Username: "poor", Balance: 10}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+
+	env.server.PushUpdate(commandUpdate(chatID, 3101, "/dice 100"))
+	env.pump(t, 5*time.Second, func() bool {
+		return len(env.server.CallsTo("sendMessage")) > 0
+	})
+
+	calls := env.server.CallsTo("sendMessage")
+	if len(calls) != 1 || !strings.Contains(calls[0].Params["text"], "余额不足") {
+		t.Fatalf("应只回复余额不足提示，实际: %+v", calls)
+	}
+	if _, ok := calls[0].Params["reply_markup"]; ok {
+		t.Fatal("余额不足时不应发送加入按钮")
+	}
+	assertBalance(t, env.db, 3101, 10)
+}
+
+func assertBalance(t *testing.T, db *database.DB, userID, expected int64) {
+	t.Helper()
+
+	user, err := db.GetUser(userID)
+	if err != nil {
+		t.Fatalf("获取用户 %d 失败: %v", userID, err)
+	}
+	if user.Balance != expected {
+		t.Fatalf("用户 %d 余额错误: 实际 %d，期望 %d", userID, user.Balance, expected)
+	}
+}
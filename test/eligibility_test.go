@@ -0,0 +1,106 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/eligibility"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestEligibility 下注资格：按用户ID估算账号注册时间、按首次在群内见到的时间计算群龄，不满足时拦截开局命令
+func TestEligibility(t *testing.T) {
+	now := time.Now()
+	if eligibility.AccountAgeDays(100_000_000, now) < 365*5 {
+		t.Errorf("早期账号应估算为多年前注册")
+	}
+	if days := eligibility.AccountAgeDays(100_000_000_000, now); days > 365 {
+		t.Errorf("新账号估算注册天数过长: %d", days)
+	}
+	if created := eligibility.EstimateAccountCreated(1_000_000_000_000_000, now); created.After(now) {
+		t.Errorf("估算的注册时间不应晚于当前时间")
+	}
+
+	db, err := database.Init(filepath.Join(t.TempDir(), "eligibility.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	chatID := int64(-1006)
+	oldUser, newAccount, newMember := int64(100_000_000), int64(100_000_000_000), int64(200_000_000)
+	// 老用户十天前就在群内
+	if err := db.RecordChatMember(chatID, oldUser, now.AddDate(0, 0, -10)); err != nil {
+		t.Fatalf("记录群成员失败: %v", err)
+	}
+	if err := db.RecordChatMember(chatID, newAccount, now.AddDate(0, 0, -10)); err != nil {
+		t.Fatalf("记录群成员失败: %v", err)
+	}
+
+	checker := eligibility.NewChecker(db)
+	client := telegram.NewFakeClient()
+	router := middleware.NewRouter(client)
+	played := make(map[int64]bool)
+	router.Handle("dice", func(ctx *middleware.Context) error {
+		played[ctx.UserID] = true
+		return nil
+	}, middleware.Eligible(checker.Check))
+
+	dispatch := func(userID int64) {
+		t.Helper()
+		update := commandUpdate(chatID, userID, "/dice 10")
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理命令失败: %v", err)
+		}
+	}
+
+	// 未设置要求时不限制
+	dispatch(newMember)
+	if !played[newMember] {
+		t.Fatal("未设置资格要求时应允许开局")
+	}
+
+	if err := db.SetChatEligibility(chatID, &models.ChatEligibility{MinAccountDays: 30, MinMemberDays: 3}); err != nil {
+		t.Fatalf("设置下注资格失败: %v", err)
+	}
+	played = make(map[int64]bool)
+	client.Reset()
+	for _, userID := range []int64{oldUser, newAccount, newMember} {
+		dispatch(userID)
+	}
+	if !played[oldUser] || played[newAccount] || played[newMember] {
+		t.Errorf("资格检查结果不符: %v", played)
+	}
+
+	var replies []string
+	for _, c := range client.SentMessages() {
+		if msg, ok := c.(tgbotapi.MessageConfig); ok {
+			replies = append(replies, msg.Text)
+		}
+	}
+	if len(replies) != 2 || !strings.Contains(replies[0], "注册满 30 天") || !strings.Contains(replies[1], "群内满 3 天") {
+		t.Errorf("拒绝提示不符: %q", replies)
+	}
+
+	// 首次见到的时间以第一次记录为准
+	since, ok, err := db.GetChatMemberSince(chatID, newMember)
+	if err != nil || !ok || now.Sub(since) > time.Minute {
+		t.Errorf("新成员首次见到的时间不符: %v %v %v", since, ok, err)
+	}
+	if since, _, _ := db.GetChatMemberSince(chatID, oldUser); now.Sub(since) < 9*24*time.Hour {
+		t.Errorf("老成员首次见到的时间不应被覆盖: %v", since)
+	}
+
+	// 私聊中不检查
+	private := commandUpdate(newAccount, newAccount, "/dice 10")
+	if _, err := router.Dispatch(&private); err != nil || !played[newAccount] {
+		t.Errorf("私聊中不应检查下注资格: %v", err)
+	}
+}
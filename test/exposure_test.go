@@ -0,0 +1,130 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestChatExposureCap 群组本小时的下注总额达到上限后暂停发起新对局，被拒绝的下注不扣款；
+// 关闭退款的快速桌不计入下注总额，管理员解除或群组取消上限后恢复，反复触发时告警
+func TestChatExposureCap(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "exposure.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{
+		MinBet: utils.Coins(1), MaxBet: utils.Coins(1000), ChatHourlyCap: utils.Coins(50), ChatCapAlertHits: 1,
+	}, 0.05)
+	manager.SetOperationInterval(0)
+	alerts := make(chan int64, 1)
+	manager.SetExposureAlertCallback(func(chatID int64, hits int, hourlyCap int64) {
+		alerts <- chatID
+	})
+
+	chatID := int64(-4754)
+	balance := func(userID int64) int64 {
+		t.Helper()
+		user, err := db.GetUser(userID)
+		if err != nil || user == nil {
+			t.Fatalf("读取用户失败: %v", err)
+		}
+		return user.Balance
+	}
+	bets := func() int {
+		t.Helper()
+		rows, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 1, Type: models.TransactionTypeBet}, "", 50)
+		if err != nil {
+			t.Fatalf("查询下注交易失败: %v", err)
+		}
+		return len(rows)
+	}
+	play := func(bet int64) {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, chatID, bet)
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+	}
+
+	// 关闭退款的快速桌不计入下注总额
+	table, err := manager.CreateTable(1, chatID, utils.Coins(100))
+	if err != nil {
+		t.Fatalf("开设快速桌失败: %v", err)
+	}
+	if _, _, err := manager.CancelTable(table.ID, 1); err != nil {
+		t.Fatalf("关闭快速桌失败: %v", err)
+	}
+	if exposure, err := manager.ChatExposure(chatID); err != nil || exposure.Turnover != 0 || exposure.Paused() {
+		t.Fatalf("关闭退款的快速桌不应计入下注总额: %+v（%v）", exposure, err)
+	}
+
+	// 两局共 60 超过上限 50，第三局被拒绝且不扣款
+	play(utils.Coins(20))
+	play(utils.Coins(10))
+	exposure, err := manager.ChatExposure(chatID)
+	if err != nil || exposure.Turnover != utils.Coins(60) || !exposure.Paused() {
+		t.Fatalf("本小时下注总额应为 60 并暂停: %+v（%v）", exposure, err)
+	}
+	before, betsBefore := balance(1), bets()
+	_, err = manager.CreateGame(1, chatID, utils.Coins(10))
+	var capErr *game.ExposureCapError
+	if !errors.As(err, &capErr) || capErr.Cap != utils.Coins(50) || capErr.Turnover != utils.Coins(60) {
+		t.Fatalf("达到上限后应返回 ExposureCapError，实际: %v", err)
+	}
+	if _, err := manager.CreateTable(1, chatID, utils.Coins(10)); !errors.As(err, &capErr) {
+		t.Errorf("达到上限后也不能开设快速桌，实际: %v", err)
+	}
+	if balance(1) != before || bets() != betsBefore {
+		t.Errorf("被拒绝的下注不应扣款或记录下注交易: 余额 %s → %s，下注 %d → %d 笔",
+			utils.FormatAmount(before), utils.FormatAmount(balance(1)), betsBefore, bets())
+	}
+	select {
+	case alerted := <-alerts:
+		if alerted != chatID {
+			t.Errorf("告警的群组不符: %d", alerted)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("达到告警阈值时应通知管理员")
+	}
+
+	// 其他群组不受影响
+	if _, err := manager.CreateGame(3, chatID-1, utils.Coins(10)); err != nil {
+		t.Errorf("其他群组不应被暂停: %v", err)
+	}
+
+	// 管理员解除本小时的暂停后可以继续下注
+	manager.OverrideExposureCap(chatID)
+	play(utils.Coins(10))
+	if b := balance(1); b != utils.Coins(1000)+utils.Coins(40)-utils.CalculateCommission(utils.Coins(80), 0.05) {
+		t.Errorf("解除暂停后结算的余额不符: %s", utils.FormatAmount(b))
+	}
+
+	// 群组取消上限：状态不再暂停
+	if err := manager.SetChatHourlyCap(chatID, -1); err != nil {
+		t.Fatalf("取消群组上限失败: %v", err)
+	}
+	if exposure, err := manager.ChatExposure(chatID); err != nil || exposure.Cap != 0 || exposure.Paused() {
+		t.Errorf("取消上限后不应暂停: %+v（%v）", exposure, err)
+	}
+}
@@ -0,0 +1,180 @@
+package test
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+)
+
+const fuzzSecret = "fuzz-secret"
+
+// FuzzCallbackDecode 任意回调数据都不能导致 panic，且只有本密钥签名的数据能通过校验
+func FuzzCallbackDecode(f *testing.F) {
+	codec := callback.NewCodec(fuzzSecret)
+	valid, _ := codec.Encode(ui.SurrenderAction, "GAME17000000001234")
+	for _, seed := range []string{valid, "", "|", "||", "1|", "1||", "1|surrender|", "surrender_GAME1", "2|a|b|c",
+		strings.Repeat("|", 100), valid + "x", "1|surrender|GAME1|" + strings.Repeat("A", 11)} {
+		f.Add(seed)
+	}
+
+	other := callback.NewCodec("other-secret")
+	f.Fuzz(func(t *testing.T, data string) {
+		parsed, err := codec.Decode(data)
+		if err != nil {
+			if !errors.Is(err, callback.ErrMalformed) && !errors.Is(err, callback.ErrStale) && !errors.Is(err, callback.ErrForged) {
+				t.Fatalf("未知错误类型: %v", err)
+			}
+			return
+		}
+
+		if len(data) > callback.MaxLength {
+			t.Fatalf("超长回调数据通过校验: %d 字节", len(data))
+		}
+		if parsed.Action == "" {
+			t.Fatalf("空动作通过校验: %q", data)
+		}
+		if _, err := other.Decode(data); err == nil {
+			t.Fatalf("其他密钥签名的数据通过校验: %q", data)
+		}
+
+		// 解析结果重新编码后必须得到相同的数据
+		encoded, err := codec.Encode(parsed.Action, parsed.Args...)
+		if err != nil || encoded != data {
+			t.Fatalf("重新编码不一致: %q -> %q (%v)", data, encoded, err)
+		}
+	})
+}
+
+// FuzzCallbackRoundTrip 编码成功的数据必须能原样解码
+func FuzzCallbackRoundTrip(f *testing.F) {
+	f.Add("surrender", "GAME17000000001234", "")
+	f.Add("fund", "airdrop", "100")
+	f.Add("a|b", "", "")
+
+	codec := callback.NewCodec(fuzzSecret)
+	f.Fuzz(func(t *testing.T, action, arg1, arg2 string) {
+		data, err := codec.Encode(action, arg1, arg2)
+		if err != nil {
+			return
+		}
+		if len(data) > callback.MaxLength {
+			t.Fatalf("编码结果超过 %d 字节: %q", callback.MaxLength, data)
+		}
+
+		parsed, err := codec.Decode(data)
+		if err != nil {
+			t.Fatalf("解码失败 %q: %v", data, err)
+		}
+		if parsed.Action != action || parsed.Arg(0) != arg1 || parsed.Arg(1) != arg2 {
+			t.Fatalf("往返结果不一致: %+v", parsed)
+		}
+	})
+}
+
+// FuzzParseSurrenderCallback 认输回调只能解析出合法的游戏ID
+func FuzzParseSurrenderCallback(f *testing.F) {
+	codec := callback.NewCodec(fuzzSecret)
+	for _, gameID := range []string{"GAME17000000001234", "", "GAME", "../../etc", "GAME1 OR 1=1", "game17000000001234"} {
+		data, err := codec.Encode(ui.SurrenderAction, gameID)
+		if err == nil {
+			f.Add(data)
+		}
+	}
+	f.Add("surrender_GAME17000000001234")
+
+	f.Fuzz(func(t *testing.T, data string) {
+		gameID, err := ui.ParseSurrenderCallback(codec, data)
+		if err != nil {
+			return
+		}
+		if err := utils.ValidateGameID(gameID); err != nil {
+			t.Fatalf("解析出非法游戏ID %q: %v", gameID, err)
+		}
+	})
+}
+
+// FuzzParseBetArgs /dice 参数解析只接受单个正数，最多两位小数，结果为基本单位
+func FuzzParseBetArgs(f *testing.F) {
+	for _, seed := range []string{"100", " 100 ", "", "-1", "0", "+5", "1e3", "100 200", "９９", "9223372036854775807",
+		"9223372036854775808", "0x10", "１００", "100\n", "\t50", "0.5", "12.25", "1.234", ".5", "5.", "0.00", "1,5"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, args string) {
+		amount, err := utils.ParseBetArgs(args)
+		if err != nil {
+			if amount != 0 {
+				t.Fatalf("出错时应返回 0，实际 %d", amount)
+			}
+			return
+		}
+		if amount <= 0 {
+			t.Fatalf("解析出非正金额: %q -> %d", args, amount)
+		}
+
+		// 格式化后再解析应得到同一金额，且与参数的数值一致（允许前导零和省略的小数位，大额时允许浮点误差）
+		if parsed, err := utils.ParseAmount(utils.FormatAmount(amount)); err != nil || parsed != amount {
+			t.Fatalf("金额格式化后无法还原: %q -> %d -> %v, %v", args, amount, parsed, err)
+		}
+		value, err := strconv.ParseFloat(strings.TrimSpace(args), 64)
+		if err != nil || math.Abs(value*float64(utils.AmountScale)-float64(amount)) > math.Max(0.5, float64(amount)*1e-12) {
+			t.Fatalf("金额与参数不一致: %q -> %d", args, amount)
+		}
+	})
+}
+
+// FuzzValidateGameID 通过校验的游戏ID只包含 GAME 前缀和数字
+func FuzzValidateGameID(f *testing.F) {
+	f.Add(utils.GenerateGameID())
+	for _, seed := range []string{"", "GAME", "GAME1", "GAME17000000001234", "GAME-1", "GAME１２３４５", "game12345",
+		"GAME12345\x00", "GAME" + strings.Repeat("9", 30)} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, gameID string) {
+		if err := utils.ValidateGameID(gameID); err != nil {
+			return
+		}
+		if !strings.HasPrefix(gameID, "GAME") || len(gameID) > 28 {
+			t.Fatalf("非法游戏ID通过校验: %q", gameID)
+		}
+		if _, err := strconv.ParseUint(gameID[4:], 10, 64); err != nil && len(gameID[4:]) <= 19 {
+			t.Fatalf("游戏ID包含非数字字符: %q", gameID)
+		}
+	})
+}
+
+// FuzzParseFundCommand /fund 参数解析不能 panic，成功时金额必须为正
+func FuzzParseFundCommand(f *testing.F) {
+	for _, seed := range []string{"", "airdrop 100", "prize 1", "AIRDROP 5", "airdrop", "airdrop -5", "prize abc", "x 1", "  "} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, args string) {
+		action, amount, err := ui.ParseFundCommand(args)
+		if err != nil {
+			return
+		}
+		switch action {
+		case ui.FundActionShow:
+			if strings.TrimSpace(args) != "" {
+				t.Fatalf("非空参数被当作查看: %q", args)
+			}
+		case ui.FundActionAirdrop, ui.FundActionPrize:
+			if amount <= 0 {
+				t.Fatalf("解析出非正金额: %q -> %d", args, amount)
+			}
+			if len(strings.Fields(args)) != 2 {
+				t.Fatalf("多余参数被忽略: %q", args)
+			}
+		default:
+			t.Fatalf("未知操作: %q", action)
+		}
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"telegram-dice-bot/internal/config"
 	"telegram-dice-bot/internal/database"
 	"telegram-dice-bot/internal/game"
 	"telegram-dice-bot/internal/logger"
@@ -26,7 +27,9 @@ func TestGameFlowIntegration(t *testing.T) {
 		t.Fatalf("初始化日志失败: %v", err)
 	}
 	securityManager := security.NewSecurityManager(logger)
-	enhancedManager := game.NewEnhancedManager(db, 0.05, logger)
+	enhancedManager := game.NewEnhancedManager(db, &config.Config{MinBet: 1, MaxBet: 10000}, 0.05, logger)
+	// 关闭同一用户的操作间隔限制，测试中连续下注无需等待
+	enhancedManager.SetOperationInterval(0)
 	timeoutManager := game.NewTimeoutManager(db, securityManager, logger)
 
 	// 创建测试用户
@@ -55,9 +58,6 @@ func TestGameFlowIntegration(t *testing.T) {
 		testGameTimeoutFlow(t, enhancedManager, timeoutManager, player1ID, chatID)
 	})
 
-	// 等待所有异步操作完成
-	time.Sleep(100 * time.Millisecond)
-
 	t.Run("测试余额一致性", func(t *testing.T) {
 		testBalanceConsistency(t, enhancedManager, db, player1ID, player2ID)
 	})
@@ -234,9 +234,6 @@ func testGameSettlementFlow(t *testing.T, manager *game.EnhancedManager, player1
 				t.Fatalf("创建测试游戏失败: %v", err)
 			}
 
-			// 等待避免频率限制
-			time.Sleep(1100 * time.Millisecond)
-
 			// 玩家2加入游戏（这会自动触发游戏结算）
 			result, err := manager.JoinGameSecure(newGameID, player2ID)
 			if err != nil {
@@ -279,11 +276,22 @@ func testGameTimeoutFlow(t *testing.T, manager *game.EnhancedManager, timeoutMan
 		t.Fatalf("创建游戏失败: %v", err)
 	}
 
-	// 设置短超时时间进行测试
+	// 设置短超时时间进行测试，超时处理完成后通过回调通知
+	handled := make(chan struct{})
+	timeoutManager.SetTimeoutHandledCallback(func(id string) {
+		if id == gameID {
+			close(handled)
+		}
+	})
+	defer timeoutManager.SetTimeoutHandledCallback(nil)
 	timeoutManager.SetGameTimeout(gameID, chatID, 100*time.Millisecond)
 
-	// 等待超时处理
-	time.Sleep(200 * time.Millisecond)
+	// 等待超时处理完成
+	select {
+	case <-handled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待游戏超时处理超时")
+	}
 
 	// 验证游戏状态
 	game, err := manager.GetDB().GetGame(gameID)
@@ -310,9 +318,6 @@ func testGameTimeoutFlow(t *testing.T, manager *game.EnhancedManager, timeoutMan
 
 // testBalanceConsistency 测试余额一致性
 func testBalanceConsistency(t *testing.T, manager *game.EnhancedManager, db *database.DB, player1ID, player2ID int64) {
-	// 等待一小段时间确保所有操作都已完成
-	time.Sleep(50 * time.Millisecond)
-	
 	// 验证玩家1余额一致性
 	if err := manager.ValidateUserBalanceConsistency(player1ID); err != nil {
 		t.Logf("玩家1余额一致性验证失败: %v", err)
@@ -389,7 +394,7 @@ func TestInsufficientBalanceScenarios(t *testing.T) {
 	if err != nil {
 		t.Fatalf("初始化日志失败: %v", err)
 	}
-	manager := game.NewEnhancedManager(db, 0.05, logger)
+	manager := game.NewEnhancedManager(db, &config.Config{MinBet: 1, MaxBet: 10000}, 0.05, logger)
 
 	// 创建余额不足的用户
 	playerID := int64(3001)
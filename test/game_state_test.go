@@ -0,0 +1,95 @@
+package test
+
+import (
+	"errors"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+)
+
+// TestGameStateTransitions 状态机只允许沿对局流程前进，终态不能再变更
+func TestGameStateTransitions(t *testing.T) {
+	cases := []struct {
+		from, to string
+		allowed  bool
+	}{
+		{models.GameStatusWaiting, models.GameStatusPlaying, true},
+		{models.GameStatusWaiting, models.GameStatusExpired, true},
+		{models.GameStatusPlaying, models.GameStatusFinished, true},
+		{models.GameStatusPlaying, models.GameStatusHeld, true},
+		{models.GameStatusHeld, models.GameStatusPlaying, true},
+		{models.GameStatusPlaying, models.GameStatusPlaying, true},
+		{models.GameStatusFinished, models.GameStatusPlaying, false},
+		{models.GameStatusExpired, models.GameStatusWaiting, false},
+		{models.GameStatusCancelled, models.GameStatusHeld, false},
+		{models.GameStatusWaiting, models.GameStatusFinished, false},
+		{models.GameStatusWaiting, "unknown", false},
+	}
+	for _, c := range cases {
+		err := models.ValidateGameTransition(c.from, c.to)
+		if c.allowed && err != nil {
+			t.Errorf("%s -> %s 应被允许: %v", c.from, c.to, err)
+		}
+		if !c.allowed && err == nil {
+			t.Errorf("%s -> %s 应被拒绝", c.from, c.to)
+		}
+	}
+
+	for _, state := range models.GameStates() {
+		want := state == models.GameStatusFinished || state == models.GameStatusExpired ||
+			state == models.GameStatusCancelled || state == models.GameStatusSurrendered
+		if state.Terminal() != want {
+			t.Errorf("状态 %s 的终态判断错误", state)
+		}
+	}
+}
+
+// TestGameStateEnforcedByDatabase 绕过管理器直接写库时，非法转换和无效状态同样被拒绝
+func TestGameStateEnforcedByDatabase(t *testing.T) {
+	db, err := database.Init(t.TempDir() + "/state.db")
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for _, id := range []int64{4001, 4002} {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: 100}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	game := &models.Game{ID: "GAMESTATE1", Player1ID: 4001, BetAmount: 10, Status: models.GameStatusWaiting, ChatID: -100}
+	if err := db.CreateGame(game); err != nil {
+		t.Fatalf("创建对局失败: %v", err)
+	}
+
+	ok, err := db.TransitionGameStatus(game.ID, models.GameStatusWaiting, models.GameStatusFinished)
+	var transitionErr *models.GameTransitionError
+	if ok || !errors.As(err, &transitionErr) {
+		t.Fatalf("waiting -> finished 应返回状态转换错误，实际 ok=%v err=%v", ok, err)
+	}
+
+	player2 := int64(4002)
+	game.Player2ID = &player2
+	game.Status = models.GameStatusPlaying
+	if err := db.UpdateGame(game); err != nil {
+		t.Fatalf("waiting -> playing 应成功: %v", err)
+	}
+	game.Status = models.GameStatusFinished
+	if err := db.UpdateGame(game); err != nil {
+		t.Fatalf("playing -> finished 应成功: %v", err)
+	}
+
+	game.Status = models.GameStatusPlaying
+	if err := db.UpdateGame(game); err == nil {
+		t.Fatal("finished -> playing 应被数据库拒绝")
+	}
+	if stored, err := db.GetGame(game.ID); err != nil || stored.Status != models.GameStatusFinished {
+		t.Fatalf("对局状态不应被修改: %+v, %v", stored, err)
+	}
+
+	invalid := &models.Game{ID: "GAMESTATE2", Player1ID: 4001, BetAmount: 10, Status: "bogus", ChatID: -100}
+	if err := db.CreateGame(invalid); err == nil {
+		t.Fatal("无效状态的对局不应写入")
+	}
+}
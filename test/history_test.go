@@ -0,0 +1,144 @@
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/cache"
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/history"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestHistoryPagination /history 显示调用者最近的对局，翻页按钮原地编辑消息且只有本人可以翻页
+func TestHistoryPagination(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "history.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: fmt.Sprintf("player%d", id), Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	games := cache.NewGameHistoryCache(db, ui.HistoryGames)
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	manager.SetGameHistoryCache(games)
+
+	chatID := int64(-1008)
+	play := func() {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+	}
+
+	client := telegram.NewFakeClient()
+	codec := callback.NewCodec("history-secret")
+	router := middleware.NewRouter(client)
+	history.NewHandler(db, games, client, codec).Register(router)
+
+	show := func() tgbotapi.MessageConfig {
+		t.Helper()
+		client.Reset()
+		update := commandUpdate(chatID, 1, "/history")
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理 /history 失败: %v", err)
+		}
+		sent := client.SentMessages()
+		if len(sent) != 1 {
+			t.Fatalf("应发送 1 条消息，实际 %d 条", len(sent))
+		}
+		return sent[0].(tgbotapi.MessageConfig)
+	}
+
+	for i := 0; i < 5; i++ {
+		play()
+	}
+	if msg := show(); strings.Contains(msg.Text, "/2 页") || msg.ReplyMarkup != nil {
+		t.Errorf("5 局只有一页，不应有翻页按钮: %q", msg.Text)
+	}
+
+	// 对局结束后缓存失效，新的对局立即出现在记录中
+	play()
+	play()
+	msg := show()
+	if !strings.Contains(msg.Text, "第 1/2 页") || !strings.Contains(msg.Text, "🏆 胜 vs @player2") ||
+		!strings.Contains(msg.Text, "6+6+6=18 : 1+1+1=3") || !strings.Contains(msg.Text, "+9.00 金币") {
+		t.Errorf("第一页内容不符: %q", msg.Text)
+	}
+	markup, ok := msg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup)
+	if !ok || len(markup.InlineKeyboard) != 1 || len(markup.InlineKeyboard[0]) != 1 {
+		t.Fatalf("第一页应只有下一页按钮: %+v", msg.ReplyMarkup)
+	}
+	next := *markup.InlineKeyboard[0][0].CallbackData
+
+	// 其他人不能翻阅
+	client.Reset()
+	update := callbackUpdate(chatID, 2, 10, next)
+	if _, err := router.Dispatch(&update); err != nil {
+		t.Fatalf("处理翻页失败: %v", err)
+	}
+	for _, sent := range client.SentMessages() {
+		if _, ok := sent.(tgbotapi.EditMessageTextConfig); ok {
+			t.Error("其他人点击翻页不应编辑消息")
+		}
+	}
+
+	client.Reset()
+	update = callbackUpdate(chatID, 1, 10, next)
+	if _, err := router.Dispatch(&update); err != nil {
+		t.Fatalf("处理翻页失败: %v", err)
+	}
+	var edit *tgbotapi.EditMessageTextConfig
+	for _, sent := range client.SentMessages() {
+		if e, ok := sent.(tgbotapi.EditMessageTextConfig); ok {
+			edit = &e
+		}
+	}
+	if edit == nil || edit.MessageID != 10 {
+		t.Fatalf("翻页应原地编辑消息: %+v", client.SentMessages())
+	}
+	if !strings.Contains(edit.Text, "第 2/2 页") || !strings.Contains(edit.Text, "\n7. ") || strings.Contains(edit.Text, "\n5. ") {
+		t.Errorf("第二页内容不符: %q", edit.Text)
+	}
+	if edit.ReplyMarkup == nil || len(edit.ReplyMarkup.InlineKeyboard[0]) != 1 {
+		t.Errorf("第二页应只有上一页按钮: %+v", edit.ReplyMarkup)
+	}
+
+	// 对手视角：负局显示扣除的下注
+	if text := ui.FormatGameHistory(2, mustHistory(t, games, 2), map[int64]string{1: "@player1"}, 0); !strings.Contains(text, "💔 负 vs @player1") ||
+		!strings.Contains(text, "1+1+1=3 : 6+6+6=18") || !strings.Contains(text, "-10.00 金币") {
+		t.Errorf("对手的记录不符: %q", text)
+	}
+}
+
+func mustHistory(t *testing.T, games *cache.GameHistoryCache, userID int64) []*models.Game {
+	t.Helper()
+	history, err := games.GetUserGameHistory(userID)
+	if err != nil {
+		t.Fatalf("获取对局记录失败: %v", err)
+	}
+	return history
+}
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/cooldown"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/locale"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestI18n 消息目录覆盖全部语言；/language 设置的群组语言持久化后，回复和拦截提示都按该语言显示
+func TestI18n(t *testing.T) {
+	if missing := ui.MissingMessages(); len(missing) != 0 {
+		t.Errorf("消息目录缺少翻译: %v", missing)
+	}
+	if got := strings.Join(ui.SupportedLanguages(), ","); got != "en,ru,zh" {
+		t.Errorf("支持的语言不符: %s", got)
+	}
+	for _, lang := range ui.SupportedLanguages() {
+		if lang != ui.DefaultLanguage && ui.DefaultWelcomeTemplate(lang) == ui.DefaultWelcomeTemplate(ui.DefaultLanguage) {
+			t.Errorf("缺少 %s 欢迎模板", lang)
+		}
+		if text, err := ui.RenderHelp(lang, ui.HelpIndexTopic, ui.HelpData{}); err != nil || text == "" {
+			t.Errorf("渲染 %s 帮助失败: %v", lang, err)
+		}
+	}
+	if got := ui.T("ru", "no.such.key", nil); got != "no.such.key" {
+		t.Errorf("未知的键应原样返回: %q", got)
+	}
+
+	db, err := database.Init(filepath.Join(t.TempDir(), "i18n.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	chatID, admin, member := int64(-1051), int64(1), int64(2)
+	client := telegram.NewFakeClient()
+	client.Members[admin] = tgbotapi.ChatMember{User: &tgbotapi.User{ID: admin}, Status: "administrator"}
+
+	detector := locale.NewDetector(db)
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	router := middleware.NewRouter(client, middleware.WithLanguage(detector.Resolve))
+	detector.Register(router)
+	cooldown.NewHandler(manager).Register(router)
+
+	reply := func(userID int64, text string) string {
+		t.Helper()
+		client.Reset()
+		update := commandUpdate(chatID, userID, text)
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理 %s 失败: %v", text, err)
+		}
+		sent := client.SentMessages()
+		if len(sent) != 1 {
+			t.Fatalf("%s 应回复 1 条消息，实际 %d 条", text, len(sent))
+		}
+		return sent[0].(tgbotapi.MessageConfig).Text
+	}
+
+	if text := reply(member, "/language ru"); !strings.Contains(text, "仅限群管理员") {
+		t.Errorf("未设置语言时应使用默认语言: %q", text)
+	}
+	if text := reply(admin, "/language ru"); !strings.Contains(text, "Язык чата изменён на Русский (ru)") {
+		t.Errorf("设置语言的回复应使用新语言: %q", text)
+	}
+	if chat, _ := db.GetChat(chatID); chat == nil || chat.Language != "ru" || !chat.LanguageManual {
+		t.Fatalf("群组语言未保存: %+v", chat)
+	}
+
+	if text := reply(member, "/language en"); !strings.Contains(text, "только администраторам группы") {
+		t.Errorf("拦截提示应使用群组语言: %q", text)
+	}
+	if text := reply(admin, "/language xx"); !strings.Contains(text, "Язык не поддерживается") || !strings.Contains(text, "en|ru|zh|auto") {
+		t.Errorf("不支持的语言提示不符: %q", text)
+	}
+	if text := reply(admin, "/cooldown 90"); !strings.Contains(text, "1m30s") || !strings.Contains(text, "подождать") {
+		t.Errorf("冷却设置的回复应使用群组语言: %q", text)
+	}
+	if text := reply(admin, "/language"); !strings.Contains(text, "Задан администратором вручную") {
+		t.Errorf("查看语言的回复不符: %q", text)
+	}
+
+	if text := ui.FormatGameAborted("en", "GAME1", utils.Coins(10), models.GameAbortShutdown); !strings.Contains(text, "game GAME1") || !strings.Contains(text, "10.00 coins") {
+		t.Errorf("中止公告不符: %q", text)
+	}
+}
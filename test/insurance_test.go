@@ -0,0 +1,184 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestGameInsurance 发起者可为等待中的对局投保：输局获得赔付，平局不赔付但退还保险费，对局未结算即过期或取消时同样退还保险费
+func TestGameInsurance(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "insurance.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	chatID := int64(-1125)
+	if _, err := manager.CreateGame(1, chatID, utils.Coins(100)); err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, _, ok := manager.InsuranceOffer(utils.Coins(100)); ok {
+		t.Error("保险未开启时不应提供报价")
+	}
+	if err := db.SetInsuranceSettings(&models.InsuranceSettings{
+		Enabled: true, Threshold: utils.Coins(50), PremiumRate: 0.05, RefundRate: 0.5,
+	}); err != nil {
+		t.Fatalf("保存保险配置失败: %v", err)
+	}
+
+	balance := func(userID int64) int64 {
+		t.Helper()
+		user, err := db.GetUser(userID)
+		if err != nil || user == nil {
+			t.Fatalf("读取用户失败: %v", err)
+		}
+		return user.Balance
+	}
+	insured := func(creator int64, bet int64) string {
+		t.Helper()
+		gameID, err := manager.CreateGame(creator, chatID, bet)
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		premium, coverage, err := manager.BuyInsurance(gameID, creator)
+		if err != nil {
+			t.Fatalf("购买保险失败: %v", err)
+		}
+		if premium != bet/20 || coverage != bet/2 {
+			t.Errorf("保险报价不符: 保险费 %d，赔付 %d", premium, coverage)
+		}
+		return gameID
+	}
+
+	// 购买：只有发起者、只能买一次，门槛以下不可投保
+	small, err := manager.CreateGame(2, chatID, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, _, err := manager.BuyInsurance(small, 2); err != game.ErrInsuranceUnavailable {
+		t.Errorf("未达投保门槛时应拒绝: %v", err)
+	}
+	before := balance(1)
+	lost := insured(1, utils.Coins(100))
+	if _, _, err := manager.BuyInsurance(lost, 1); err == nil {
+		t.Error("重复投保应被拒绝")
+	}
+	if _, _, err := manager.BuyInsurance(lost, 2); err == nil {
+		t.Error("非发起者不应能投保")
+	}
+	if got := balance(1); got != before-utils.Coins(105) {
+		t.Errorf("投保后应扣除下注和保险费，余额 %s", utils.FormatAmount(got))
+	}
+
+	// 输局：获得赔付
+	if _, err := manager.JoinGame(lost, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if _, err := manager.PlayGameWithDiceResults(lost, 1, 1, 1, 6, 6, 6); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+	if got := balance(1); got != before-utils.Coins(105)+utils.Coins(50) {
+		t.Errorf("输局应获得一半下注的赔付，余额 %s", utils.FormatAmount(got))
+	}
+	payouts, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 1, Type: models.TransactionTypeInsurancePayout}, "", 10)
+	if err != nil || len(payouts) != 1 || payouts[0].Amount != utils.Coins(50) {
+		t.Errorf("应记录一笔保险赔付: %+v（%v）", payouts, err)
+	}
+
+	// 平局：退还下注和保险费，不赔付
+	before = balance(1)
+	draw := insured(1, utils.Coins(100))
+	if _, err := manager.JoinGame(draw, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if _, err := manager.PlayGameWithDiceResults(draw, 3, 3, 3, 3, 3, 3); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+	if got := balance(1); got != before {
+		t.Errorf("平局应退还下注和保险费，余额 %s，应为 %s", utils.FormatAmount(got), utils.FormatAmount(before))
+	}
+	if g, _ := db.GetGame(draw); g == nil || g.Status != models.GameStatusFinished || g.InsurancePremium != 0 {
+		t.Errorf("平局的对局应已结束且保险费已退还: %+v", g)
+	}
+	drawRows := func(txType string) []*models.Transaction {
+		t.Helper()
+		rows, _, err := db.SearchTransactions(&models.TransactionFilter{GameID: draw, UserID: 1, Type: txType}, "", 10)
+		if err != nil {
+			t.Fatalf("查询交易失败: %v", err)
+		}
+		return rows
+	}
+	if premiums := drawRows(models.TransactionTypeInsurance); len(premiums) != 1 || premiums[0].Amount != -utils.Coins(5) {
+		t.Errorf("平局的对局应有一笔保险费扣款: %+v", premiums)
+	}
+	var betRefund, premiumRefund int
+	for _, refund := range drawRows(models.TransactionTypeRefund) {
+		switch refund.Amount {
+		case utils.Coins(100):
+			betRefund++
+		case utils.Coins(5):
+			premiumRefund++
+			if refund.Balance != before {
+				t.Errorf("保险费退款后的余额应为 %s: %+v", utils.FormatAmount(before), refund)
+			}
+		default:
+			t.Errorf("平局不应有其他金额的退款: %+v", refund)
+		}
+	}
+	if betRefund != 1 || premiumRefund != 1 {
+		t.Errorf("平局应各有一笔下注退款和保险费退款，实际 %d / %d", betRefund, premiumRefund)
+	}
+	if payouts := drawRows(models.TransactionTypeInsurancePayout); len(payouts) != 0 {
+		t.Errorf("平局不应赔付: %+v", payouts)
+	}
+
+	// 过期：下注和保险费在同一事务中退还
+	before = balance(3)
+	expired := insured(3, utils.Coins(100))
+	if _, err := manager.HandleAccountDeleted(3); err != nil {
+		t.Fatalf("处理注销用户失败: %v", err)
+	}
+	if g, _ := db.GetGame(expired); g == nil || g.Status != models.GameStatusExpired || g.InsurancePremium != 0 {
+		t.Errorf("对局应过期且保险费已退还: %+v", g)
+	}
+	if got := balance(3); got != before {
+		t.Errorf("过期后应退还下注和保险费，余额 %s，应为 %s", utils.FormatAmount(got), utils.FormatAmount(before))
+	}
+
+	// 取消：管理员中止对局同样退还保险费
+	before = balance(1)
+	aborted := insured(1, utils.Coins(100))
+	if _, err := manager.AbortGame(aborted); err != nil {
+		t.Fatalf("中止对局失败: %v", err)
+	}
+	if got := balance(1); got != before {
+		t.Errorf("中止后应退还下注和保险费，余额 %s，应为 %s", utils.FormatAmount(got), utils.FormatAmount(before))
+	}
+	refunds, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 1, Type: models.TransactionTypeRefund}, "", 10)
+	if err != nil {
+		t.Fatalf("查询退款记录失败: %v", err)
+	}
+	var premiumRefunds int
+	for _, refund := range refunds {
+		if refund.GameID != nil && *refund.GameID == aborted && refund.Amount == utils.Coins(5) {
+			premiumRefunds++
+		}
+	}
+	if premiumRefunds != 1 {
+		t.Errorf("中止的对局应记录一笔保险费退款，实际 %d", premiumRefunds)
+	}
+}
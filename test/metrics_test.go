@@ -0,0 +1,100 @@
+package test
+
+import (
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/cache"
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/monitor"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestMetricsEndpoint /metrics 汇总游戏流程指标和性能监控的计数，输出 Prometheus 文本格式
+func TestMetricsEndpoint(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "metrics.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+
+	perfMonitor := monitor.NewPerformanceMonitor()
+	stats := cache.NewUserStatsCache(db, time.Minute)
+	stats.SetObserver(perfMonitor)
+
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	gameID, err := manager.CreateGame(1, -1031, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.JoinGame(gameID, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+
+	perfMonitor.RecordRequest("dice", 30*time.Millisecond, true)
+	perfMonitor.RecordRequest("dice", 300*time.Millisecond, false)
+	for i := 0; i < 3; i++ {
+		if _, err := stats.Get(1); err != nil {
+			t.Fatalf("获取战绩失败: %v", err)
+		}
+	}
+
+	server, err := monitor.NewMetricsServer("127.0.0.1:0", manager.Metrics(), perfMonitor)
+	if err != nil {
+		t.Fatalf("创建指标服务失败: %v", err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("启动指标服务失败: %v", err)
+	}
+	defer server.Stop()
+
+	resp, err := http.Get("http://" + server.Addr() + "/metrics")
+	if err != nil {
+		t.Fatalf("请求 /metrics 失败: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	text := string(body)
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		t.Errorf("Content-Type 应为 text/plain，实际 %q", resp.Header.Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`dice_games_total{event="created"} 1`,
+		`dice_games_total{event="settled"} 1`,
+		`dice_requests_total{result="success"} 1`,
+		`dice_requests_total{result="error"} 1`,
+		"# TYPE dice_request_duration_seconds histogram",
+		`dice_request_duration_seconds_bucket{le="0.05"} 1`,
+		`dice_request_duration_seconds_bucket{le="0.5"} 2`,
+		`dice_request_duration_seconds_bucket{le="+Inf"} 2`,
+		"dice_request_duration_seconds_count 2",
+		`dice_cache_requests_total{result="hit"} 2`,
+		`dice_cache_requests_total{result="miss"} 1`,
+		"dice_goroutines ",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("指标中缺少 %q", want)
+		}
+	}
+	if !strings.Contains(text, "dice_cache_hit_ratio 0.666") {
+		t.Errorf("缓存命中率不符:\n%s", text)
+	}
+}
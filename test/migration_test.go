@@ -0,0 +1,178 @@
+package test
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/migration"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// legacySchema 早期版本的数据库结构和少量数据：没有赠送余额、群组设置等后续字段
+const legacySchema = `
+CREATE TABLE users (
+	id INTEGER PRIMARY KEY,
+	username TEXT,
+	first_name TEXT,
+	last_name TEXT,
+	balance INTEGER DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE TABLE games (
+	id TEXT PRIMARY KEY,
+	player1_id INTEGER NOT NULL,
+	player2_id INTEGER,
+	bet_amount INTEGER NOT NULL,
+	status TEXT DEFAULT 'waiting',
+	player1_dice1 INTEGER, player1_dice2 INTEGER, player1_dice3 INTEGER,
+	player2_dice1 INTEGER, player2_dice2 INTEGER, player2_dice3 INTEGER,
+	winner_id INTEGER,
+	commission INTEGER DEFAULT 0,
+	chat_id INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (player1_id) REFERENCES users(id),
+	FOREIGN KEY (player2_id) REFERENCES users(id),
+	FOREIGN KEY (winner_id) REFERENCES users(id)
+);
+CREATE TABLE transactions (
+	id TEXT PRIMARY KEY,
+	user_id INTEGER NOT NULL,
+	game_id TEXT,
+	type TEXT NOT NULL,
+	amount INTEGER NOT NULL,
+	balance INTEGER NOT NULL,
+	description TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id),
+	FOREIGN KEY (game_id) REFERENCES games(id)
+);
+CREATE TABLE chats (
+	id INTEGER PRIMARY KEY,
+	title TEXT,
+	type TEXT,
+	language TEXT DEFAULT '',
+	joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO users (id, username, balance) VALUES (1, 'alice', 90), (2, 'bob', 109);
+INSERT INTO chats (id, title, type) VALUES (-100, 'dice', 'group');
+INSERT INTO games (id, player1_id, player2_id, bet_amount, status, winner_id, commission, chat_id)
+	VALUES ('GAME1', 1, 2, 10, 'finished', 2, 1, -100);
+INSERT INTO transactions (id, user_id, game_id, type, amount, balance) VALUES
+	('TX1', 1, NULL, 'deposit', 100, 100),
+	('TX2', 2, NULL, 'deposit', 100, 100),
+	('TX3', 1, 'GAME1', 'bet', -10, 90),
+	('TX4', 2, 'GAME1', 'bet', -10, 90),
+	('TX5', 2, 'GAME1', 'win', 19, 109),
+	('TX6', 0, 'GAME1', 'commission', 1, 0);
+`
+
+func writeLegacySchema(t *testing.T) string {
+	path := filepath.Join(t.TempDir(), "legacy.sql")
+	if err := os.WriteFile(path, []byte(legacySchema), 0600); err != nil {
+		t.Fatalf("写入结构快照失败: %v", err)
+	}
+	return path
+}
+
+// TestVerifyMigrationsFromSchemaSnapshot 早期版本的结构快照可以无损迁移到当前版本
+func TestVerifyMigrationsFromSchemaSnapshot(t *testing.T) {
+	report, err := migration.Verify(writeLegacySchema(t))
+	if err != nil {
+		t.Fatalf("迁移校验出错: %v", err)
+	}
+	if !report.OK() {
+		t.Fatalf("迁移校验未通过:\n%s", report)
+	}
+
+	for _, column := range []string{"users.bonus_balance", "games.player1_bonus_stake", "chats.game_cooldown"} {
+		if !containsString(report.AddedColumns, column) {
+			t.Errorf("新增字段中缺少 %s: %v", column, report.AddedColumns)
+		}
+	}
+	for _, table := range []string{"bot_settings", "dead_letters", "game_tables"} {
+		if !containsString(report.AddedTables, table) {
+			t.Errorf("新增表中缺少 %s: %v", table, report.AddedTables)
+		}
+	}
+	if !strings.Contains(report.String(), "迁移校验通过") {
+		t.Errorf("报告中缺少结论:\n%s", report)
+	}
+}
+
+// TestVerifyMigrationsLeavesSourceUntouched 校验只在副本上进行，原数据库文件不变
+func TestVerifyMigrationsLeavesSourceUntouched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prod.db")
+	conn, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("创建数据库失败: %v", err)
+	}
+	if _, err := conn.Exec(legacySchema); err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	conn.Close()
+
+	original, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取数据库失败: %v", err)
+	}
+
+	report, err := migration.Verify(path)
+	if err != nil {
+		t.Fatalf("迁移校验出错: %v", err)
+	}
+	if !report.OK() {
+		t.Fatalf("迁移校验未通过:\n%s", report)
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取数据库失败: %v", err)
+	}
+	if !bytes.Equal(original, current) {
+		t.Fatal("迁移校验修改了原数据库文件")
+	}
+}
+
+// TestVerifyMigrationsReportsFailure 迁移无法执行时报告失败而不是返回错误
+func TestVerifyMigrationsReportsFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.sql")
+	// chats 被建成视图，迁移时无法为其补充字段
+	schema := strings.Replace(legacySchema, "INSERT INTO chats (id, title, type) VALUES (-100, 'dice', 'group');", "", 1)
+	schema = strings.Replace(schema, `CREATE TABLE chats (
+	id INTEGER PRIMARY KEY,
+	title TEXT,
+	type TEXT,
+	language TEXT DEFAULT '',
+	joined_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);`, `CREATE VIEW chats AS SELECT -100 AS id, 'dice' AS title;`, 1)
+	if err := os.WriteFile(path, []byte(schema), 0600); err != nil {
+		t.Fatalf("写入结构快照失败: %v", err)
+	}
+
+	report, err := migration.Verify(path)
+	if err != nil {
+		t.Fatalf("迁移校验出错: %v", err)
+	}
+	if report.OK() {
+		t.Fatalf("迁移失败时校验不应通过:\n%s", report)
+	}
+}
+
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+package test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestUpdateTrackerOffset 并发处理时只持久化其下全部更新都已处理完的 update_id，重启后重新拉取未处理完的更新
+func TestUpdateTrackerOffset(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "offset.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	tracker, err := telegram.NewUpdateTracker(db, 0)
+	if err != nil {
+		t.Fatalf("创建更新跟踪器失败: %v", err)
+	}
+	for id := 101; id <= 103; id++ {
+		if !tracker.Accept(tgbotapi.Update{UpdateID: id}) {
+			t.Fatalf("新更新 %d 应被接收", id)
+		}
+	}
+	if tracker.Accept(tgbotapi.Update{UpdateID: 102}) {
+		t.Error("重复的更新不应再次接收")
+	}
+
+	saved := func() string {
+		t.Helper()
+		value, _, err := db.GetSetting(telegram.OffsetSettingKey)
+		if err != nil {
+			t.Fatalf("读取偏移量失败: %v", err)
+		}
+		return value
+	}
+
+	// 103 先于 101、102 处理完，偏移量不能越过仍在处理的更新
+	if err := tracker.MarkProcessed(103); err != nil {
+		t.Fatalf("标记已处理失败: %v", err)
+	}
+	if value := saved(); value != "100" {
+		t.Errorf("101 仍在处理时偏移量应停在 100，实际 %q", value)
+	}
+	if err := tracker.MarkProcessed(101); err != nil {
+		t.Fatalf("标记已处理失败: %v", err)
+	}
+	if value := saved(); value != "101" {
+		t.Errorf("102 仍在处理时偏移量应为 101，实际 %q", value)
+	}
+
+	// 此时重启：从 102 重新拉取
+	restarted, err := telegram.NewUpdateTracker(db, 0)
+	if err != nil {
+		t.Fatalf("重新创建更新跟踪器失败: %v", err)
+	}
+	if offset := restarted.Offset(); offset != 102 {
+		t.Errorf("重启后应从 102 拉取，实际 %d", offset)
+	}
+
+	if err := tracker.MarkProcessed(102); err != nil {
+		t.Fatalf("标记已处理失败: %v", err)
+	}
+	if value := saved(); value != "103" {
+		t.Errorf("全部处理完后偏移量应为 103，实际 %q", value)
+	}
+	if tracker.Accept(tgbotapi.Update{UpdateID: 103}) || tracker.Offset() != 104 {
+		t.Errorf("已处理的更新不应再次接收，下次应从 104 拉取，实际 %d", tracker.Offset())
+	}
+
+	// 大量更新乱序并发处理完后，偏移量停在最大的 update_id
+	var wg sync.WaitGroup
+	for id := 200; id < 300; id++ {
+		tracker.Accept(tgbotapi.Update{UpdateID: id})
+	}
+	for id := 299; id >= 200; id-- {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			if err := tracker.MarkProcessed(id); err != nil {
+				t.Errorf("标记已处理失败: %v", err)
+			}
+		}(id)
+	}
+	wg.Wait()
+	if value := saved(); value != "299" {
+		t.Errorf("并发处理完后偏移量应为 299，实际 %q", value)
+	}
+}
+
+// pollerProcess 模拟一个机器人进程：长轮询分发器、持久化偏移量的更新跟踪器和记录处理过的命令的路由
+type pollerProcess struct {
+	poller *middleware.Poller
+
+	mu      sync.Mutex
+	handled map[string]int
+}
+
+func startPollerProcess(t *testing.T, server *telegram.FakeServer, db *database.DB, slow chan struct{}) *pollerProcess {
+	t.Helper()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("连接假服务器失败: %v", err)
+	}
+	tracker, err := telegram.NewUpdateTracker(db, 0)
+	if err != nil {
+		t.Fatalf("创建更新跟踪器失败: %v", err)
+	}
+
+	p := &pollerProcess{handled: make(map[string]int)}
+	router := middleware.NewRouter(client)
+	record := func(ctx *middleware.Context) error {
+		p.mu.Lock()
+		p.handled[ctx.Command]++
+		p.mu.Unlock()
+		return nil
+	}
+	router.Handle("fast", record)
+	router.Handle("after", record)
+	router.Handle("slow", func(ctx *middleware.Context) error {
+		record(ctx)
+		if slow != nil {
+			<-slow
+		}
+		return nil
+	})
+
+	p.poller = middleware.NewPoller(client, router)
+	p.poller.SetUpdateTracker(tracker)
+	p.poller.Start()
+	return p
+}
+
+// count 命令被处理的次数
+func (p *pollerProcess) count(command string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.handled[command]
+}
+
+// waitHandled 等待命令被处理
+func (p *pollerProcess) waitHandled(t *testing.T, commands ...string) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for _, command := range commands {
+		for p.count(command) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("等待 /%s 处理超时", command)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+}
+
+// TestPollerRestartResumesInFlight 进程在更新处理中途退出后重启，已处理完的更新不再重放，处理中的更新重新拉取并处理，
+// 正常关闭时等待处理中的更新完成后再退出
+func TestPollerRestartResumesInFlight(t *testing.T) {
+	server := telegram.NewFakeServer()
+	defer server.Close()
+	db, err := database.Init(filepath.Join(t.TempDir(), "poller_restart.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	saved := func() string {
+		t.Helper()
+		value, _, err := db.GetSetting(telegram.OffsetSettingKey)
+		if err != nil {
+			t.Fatalf("读取偏移量失败: %v", err)
+		}
+		return value
+	}
+
+	// 第一个进程：/fast 处理完，/slow 处理到一半
+	slow := make(chan struct{})
+	first := startPollerProcess(t, server, db, slow)
+	server.PushUpdate(commandUpdate(-4705, 1, "/fast"))
+	server.PushUpdate(commandUpdate(-4705, 1, "/slow"))
+	first.waitHandled(t, "fast", "slow")
+
+	// 进程退出：不再拉取更新，/slow 未处理完，偏移量停在 /fast
+	stopped := make(chan struct{})
+	go func() {
+		first.poller.Stop()
+		close(stopped)
+	}()
+	time.Sleep(300 * time.Millisecond) // 等待进行中的 getUpdates 请求返回
+	if value := saved(); value != "1" {
+		t.Fatalf("/slow 处理中时偏移量应停在 1，实际 %q", value)
+	}
+
+	// 重启：/slow 重新拉取处理一次，/fast 不重放，之后的新更新正常处理
+	second := startPollerProcess(t, server, db, nil)
+	server.PushUpdate(commandUpdate(-4705, 1, "/after"))
+	second.waitHandled(t, "slow", "after")
+	second.poller.Stop()
+	if second.count("fast") != 0 {
+		t.Error("重启前已处理完的更新不应重放")
+	}
+	if second.count("slow") != 1 || second.count("after") != 1 {
+		t.Errorf("重启后处理中的更新和新更新应各处理一次: slow=%d after=%d", second.count("slow"), second.count("after"))
+	}
+	if value := saved(); value != "3" {
+		t.Errorf("全部处理完后偏移量应为 3，实际 %q", value)
+	}
+	if first.count("after") != 0 {
+		t.Error("已退出的进程不应再拉取更新")
+	}
+
+	// 正常关闭等待处理中的更新完成
+	select {
+	case <-stopped:
+		t.Fatal("处理中的更新完成前关闭不应返回")
+	default:
+	}
+	close(slow)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("处理中的更新完成后关闭应返回")
+	}
+}
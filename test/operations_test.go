@@ -0,0 +1,123 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestOperationsAndAbort 运营监控：汇总各群未结束的对局和排队数，管理员强制中止对局后双方退款，排队的玩家被移出
+func TestOperationsAndAbort(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "operations.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	chatID := int64(-1007)
+	for id := int64(1); id <= 4; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	if err := manager.SetChatGameMode(chatID, true, 0); err != nil {
+		t.Fatalf("设置顺序模式失败: %v", err)
+	}
+
+	aborted := make(chan string, 2)
+	manager.SetGameAbortedCallback(func(game *models.Game, reason string) {
+		aborted <- reason
+	})
+	removed := make(chan int64, 1)
+	manager.SetQueueRemovedCallback(func(entry *models.QueueEntry) {
+		removed <- entry.PlayerID
+	})
+
+	playing, err := manager.CreateGame(1, chatID, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.JoinGame(playing, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	waiting, err := manager.CreateGame(3, chatID, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.JoinGame(waiting, 4); err == nil {
+		t.Fatal("有对局进行时加入请求应排队")
+	}
+
+	operations, err := manager.Operations()
+	if err != nil || len(operations) != 1 {
+		t.Fatalf("应有 1 个群组，实际 %d（%v）", len(operations), err)
+	}
+	ops := operations[0]
+	if ops.ChatID != chatID || ops.QueueDepth != 1 || len(ops.Games) != 2 {
+		t.Fatalf("群组汇总不符: %+v", ops)
+	}
+	phases := make(map[string]*game.ActiveGame)
+	for _, active := range ops.Games {
+		phases[active.Game.ID] = active
+	}
+	if phases[playing].Phase != game.PhaseRolling || phases[waiting].Phase != game.PhaseWaiting {
+		t.Errorf("对局阶段不符: %s, %s", phases[playing].Phase, phases[waiting].Phase)
+	}
+	if phases[waiting].QueuedPlayerID != 4 || phases[playing].QueuedPlayerID != 0 {
+		t.Errorf("排队玩家不符: %d, %d", phases[waiting].QueuedPlayerID, phases[playing].QueuedPlayerID)
+	}
+
+	// 中止等待中的对局：发起者退款，排队加入的玩家被移出队列
+	if _, err := manager.AbortGame(waiting); err != nil {
+		t.Fatalf("中止等待中的对局失败: %v", err)
+	}
+	select {
+	case playerID := <-removed:
+		if playerID != 4 {
+			t.Errorf("应通知排队的玩家 4，实际 %d", playerID)
+		}
+	case <-time.After(time.Second):
+		t.Error("排队的玩家未收到移出通知")
+	}
+	if n := manager.QueueLength(chatID); n != 0 {
+		t.Errorf("中止后队列应为空，实际 %d", n)
+	}
+
+	// 中止进行中的对局：双方退款
+	if _, err := manager.AbortGame(playing); err != nil {
+		t.Fatalf("中止进行中的对局失败: %v", err)
+	}
+	for id := int64(1); id <= 4; id++ {
+		if user, _ := db.GetUser(id); user == nil || user.Balance != utils.Coins(100) {
+			t.Errorf("玩家 %d 应全额退款，实际: %+v", id, user)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case reason := <-aborted:
+			if reason != models.GameAbortAdmin {
+				t.Errorf("中止原因应为管理员中止，实际 %q", reason)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("未收到中止通知")
+		}
+	}
+
+	if g, _ := db.GetGame(playing); g == nil || g.Status != models.GameStatusCancelled {
+		t.Errorf("对局应已取消: %+v", g)
+	}
+	if _, err := manager.AbortGame(playing); err == nil {
+		t.Error("已结束的对局不能再次中止")
+	}
+	if operations, _ := manager.Operations(); len(operations) != 0 {
+		t.Errorf("没有未结束的对局时应为空，实际 %d 个群组", len(operations))
+	}
+}
@@ -0,0 +1,132 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/outbox"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestSettlementOutbox 结算消息随结算写入发件箱，发送器按顺序投递；发送失败时保留待重试，无法送达时放弃
+func TestSettlementOutbox(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "outbox.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	chatID := int64(-1004)
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	client := telegram.NewFakeClient()
+	sender := outbox.NewSender(db, client)
+	manager.SetSettlementOutbox(func(result *game.GameResult) []*models.OutboxMessage {
+		summary := fmt.Sprintf("🎲 %s：%d vs %d", result.GameID, result.Player1Total, result.Player2Total)
+		if result.Winner == nil {
+			return []*models.OutboxMessage{{Text: summary}, {Text: "🤝 平局，双方退款"}}
+		}
+		return []*models.OutboxMessage{
+			{Text: summary},
+			{Text: fmt.Sprintf("🏆 获胜者余额 %s", utils.FormatAmount(result.Winner.Balance))},
+		}
+	}, sender.Notify)
+
+	play := func(p1, p2 int) string {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, p1, p1, p1, p2, p2, p2); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return gameID
+	}
+
+	// 结算提交后消息已在发件箱中，即使此时进程退出也不会丢失
+	gameID := play(6, 1)
+	if pending, err := db.CountPendingOutbox(); err != nil || pending != 2 {
+		t.Fatalf("结算后应有 2 条待发送消息，实际 %d（%v）", pending, err)
+	}
+
+	if sent := sender.Flush(); sent != 2 {
+		t.Fatalf("应送达 2 条消息，实际 %d", sent)
+	}
+	messages := client.SentMessages()
+	if len(messages) != 2 {
+		t.Fatalf("应发出 2 条消息，实际 %d", len(messages))
+	}
+	winner, _ := db.GetUser(1)
+	want := []string{
+		fmt.Sprintf("🎲 %s：18 vs 3", gameID),
+		fmt.Sprintf("🏆 获胜者余额 %s", utils.FormatAmount(winner.Balance)),
+	}
+	for i, message := range messages {
+		msg := message.(tgbotapi.MessageConfig)
+		if msg.ChatID != chatID || msg.Text != want[i] {
+			t.Errorf("第 %d 条消息不符: %d %q，期望 %q", i+1, msg.ChatID, msg.Text, want[i])
+		}
+	}
+	if pending, _ := db.CountPendingOutbox(); pending != 0 {
+		t.Errorf("送达后不应有待发送消息，实际 %d", pending)
+	}
+	if timeline, err := db.GetGameTimeline(gameID); err == nil {
+		var recorded int
+		for _, entry := range timeline.Entries {
+			if entry.Type == models.GameEventMessage {
+				recorded++
+			}
+		}
+		if recorded != 2 {
+			t.Errorf("送达的消息应写入对局时间线，实际 %d 条", recorded)
+		}
+	}
+
+	// 发送失败：消息保留待重试，同群组的后续消息等待前一条送达
+	client.Reset()
+	client.SendErr = errors.New("网络中断")
+	play(3, 3)
+	farFuture := time.Now().Add(time.Hour)
+	if sent := sender.Flush(); sent != 0 {
+		t.Errorf("发送失败时不应计入送达，实际 %d", sent)
+	}
+	if pending, _ := db.CountPendingOutbox(); pending != 2 {
+		t.Errorf("发送失败的消息应保留，实际待发送 %d", pending)
+	}
+	if due, _ := db.GetDueOutbox(farFuture, 10); len(due) != 1 || due[0].Attempts != 1 || due[0].LastError == "" {
+		t.Errorf("同群组每次只应出队最早一条且记录失败次数，实际 %+v", due)
+	}
+
+	// 无法送达（机器人被移出群组）：放弃后继续投递后续消息，直至全部放弃
+	client.SendErr = &tgbotapi.Error{Code: 403, Message: "Forbidden: bot was kicked from the group chat"}
+	if due, _ := db.GetDueOutbox(farFuture, 10); len(due) == 1 {
+		if err := db.RetryOutbox(due[0].ID, "立即重试", due[0].CreatedAt); err != nil {
+			t.Fatalf("重置重试时间失败: %v", err)
+		}
+	}
+	sender.Flush()
+	sender.Flush()
+	if pending, _ := db.CountPendingOutbox(); pending != 0 {
+		t.Errorf("无法送达的消息应放弃，实际待发送 %d", pending)
+	}
+}
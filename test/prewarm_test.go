@@ -0,0 +1,96 @@
+package test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/network"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestPrewarmBotAPIClient 对局开始时预热的连接由 Bot API 客户端复用，发送消息无需重新建立连接
+func TestPrewarmBotAPIClient(t *testing.T) {
+	var warmed, dialed atomic.Int64
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			warmed.Add(1)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == fmt.Sprintf("/bot%s/getMe", telegram.FakeServerToken) {
+			fmt.Fprint(w, `{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Dice"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1,"chat":{"id":-1},"date":0}}`)
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dialed.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	accelerator, err := network.NewNetworkAcceleratorWithProxy(network.ProxyConfig{}, server.URL)
+	if err != nil {
+		t.Fatalf("创建加速器失败: %v", err)
+	}
+	defer accelerator.Stop()
+
+	db, err := database.Init(filepath.Join(t.TempDir(), "prewarm.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	manager.SetGameLifecycleCallbacks(accelerator.GameStarted, accelerator.GameFinished)
+
+	gameID, err := manager.CreateGame(1, -1099, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.JoinGame(gameID, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for warmed.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if warmed.Load() == 0 {
+		t.Fatal("对局开始时应预热连接")
+	}
+
+	client, err := telegram.NewAPIClientWithEndpoint(telegram.FakeServerToken, server.URL, accelerator.HTTPClient())
+	if err != nil {
+		t.Fatalf("连接 Bot API 失败: %v", err)
+	}
+	if _, err := client.Send(tgbotapi.NewMessage(-1099, "🎲")); err != nil {
+		t.Fatalf("发送消息失败: %v", err)
+	}
+	if n := dialed.Load(); n != 1 {
+		t.Errorf("Bot API 客户端应复用预热的连接，实际建立 %d 个连接", n)
+	}
+
+	if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+}
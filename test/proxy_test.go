@@ -0,0 +1,99 @@
+package test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"telegram-dice-bot/internal/network"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestProxyClient 启动自检和 Bot API 客户端都经配置的代理访问 Telegram，代理不可用时自检给出明确错误
+func TestProxyClient(t *testing.T) {
+	server := telegram.NewFakeServer()
+	defer server.Close()
+
+	// 转发普通 HTTP 请求的正向代理，要求认证
+	var proxied atomic.Int64
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := parseProxyAuth(r); !ok || user != "bot" || pass != "secret" {
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		proxied.Add(1)
+		req, _ := http.NewRequest(r.Method, r.URL.String(), r.Body)
+		req.Header = r.Header.Clone()
+		req.Header.Del("Proxy-Authorization")
+		resp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		for key, values := range resp.Header {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	}))
+	defer proxy.Close()
+
+	accelerator, err := network.NewNetworkAcceleratorWithProxy(network.ProxyConfig{
+		URL:      proxy.URL,
+		Username: "bot",
+		Password: "secret",
+	}, server.URL())
+	if err != nil {
+		t.Fatalf("创建加速器失败: %v", err)
+	}
+	defer accelerator.Stop()
+
+	if err := accelerator.SelfTest(context.Background()); err != nil {
+		t.Fatalf("经代理的自检应通过: %v", err)
+	}
+	if proxied.Load() == 0 {
+		t.Error("自检应经过代理")
+	}
+	if info := accelerator.ProxyInfo(); info == "" || strings.Contains(info, "secret") {
+		t.Errorf("代理信息应隐藏密码: %q", info)
+	}
+
+	before := proxied.Load()
+	client, err := telegram.NewAPIClientWithEndpoint(telegram.FakeServerToken, server.URL(), accelerator.HTTPClient())
+	if err != nil {
+		t.Fatalf("经代理连接 Bot API 失败: %v", err)
+	}
+	if _, err := client.Send(tgbotapi.NewMessage(-1, "hi")); err != nil {
+		t.Fatalf("经代理发送消息失败: %v", err)
+	}
+	if proxied.Load() <= before {
+		t.Error("Bot API 客户端应经过代理")
+	}
+
+	// 代理已关闭：自检失败并提示检查代理
+	proxy.Close()
+	if err := accelerator.SelfTest(context.Background()); err == nil || !strings.Contains(err.Error(), "代理") {
+		t.Errorf("代理不可用时自检应失败并提示代理: %v", err)
+	}
+
+	if _, err := network.NewNetworkAcceleratorWithProxy(network.ProxyConfig{URL: "ftp://proxy:21"}); err == nil {
+		t.Error("不支持的代理协议应拒绝")
+	}
+}
+
+// parseProxyAuth 解析 Proxy-Authorization 中的 Basic 认证
+func parseProxyAuth(r *http.Request) (string, string, bool) {
+	auth := r.Header.Get("Proxy-Authorization")
+	if auth == "" {
+		return "", "", false
+	}
+	req := &http.Request{Header: http.Header{"Authorization": []string{auth}}}
+	return req.BasicAuth()
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestQueueOneRequestPerUser 顺序模式下每人每群只能排队一个加入请求，可改为排队加入新对局并保留位置
+func TestQueueOneRequestPerUser(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "queue.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	chatID := int64(-1001)
+	if err := db.UpsertChat(&models.Chat{ID: chatID, Title: "queue", Type: "supergroup"}); err != nil {
+		t.Fatalf("创建群组失败: %v", err)
+	}
+	for id := int64(1); id <= 6; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	if err := manager.SetChatGameMode(chatID, true, 0); err != nil {
+		t.Fatalf("设置顺序模式失败: %v", err)
+	}
+
+	create := func(playerID int64) string {
+		gameID, err := manager.CreateGame(playerID, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("玩家 %d 发起对局失败: %v", playerID, err)
+		}
+		return gameID
+	}
+	playing, gameA, gameB, gameC := create(1), create(2), create(3), create(4)
+	if _, err := manager.JoinGame(playing, 5); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+
+	var queued *game.JoinQueuedError
+	if _, err := manager.JoinGame(gameA, 6); !errors.As(err, &queued) || queued.Position != 1 {
+		t.Fatalf("有对局进行时应排队第 1 位，实际: %v", err)
+	}
+
+	var already *game.AlreadyQueuedError
+	if _, err := manager.JoinGame(gameB, 6); !errors.As(err, &already) {
+		t.Fatalf("已有排队请求时应拒绝并提供替换，实际: %v", err)
+	}
+	if already.GameID != gameA || already.NewGameID != gameB || already.Position != 1 {
+		t.Errorf("替换提示不正确: %+v", already)
+	}
+	if n := manager.QueueLength(chatID); n != 1 {
+		t.Errorf("同一玩家只能排队一个请求，实际 %d 个", n)
+	}
+
+	entry, err := manager.ReplaceQueuedJoin(chatID, 6, gameB)
+	if err != nil {
+		t.Fatalf("替换排队请求失败: %v", err)
+	}
+	if entry.GameID != gameB || entry.Position != 1 {
+		t.Errorf("应改为排队加入 %s 且位置不变，实际: %+v", gameB, entry)
+	}
+	if _, err := manager.ReplaceQueuedJoin(chatID, 5, gameC); err == nil {
+		t.Error("没有排队请求的玩家不能替换")
+	}
+
+	entries, err := manager.ChatQueue(chatID)
+	if err != nil || len(entries) != 1 || entries[0].GameID != gameB {
+		t.Errorf("队列中应只有对局 %s，实际: %+v, %v", gameB, entries, err)
+	}
+}
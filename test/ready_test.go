@@ -0,0 +1,126 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestReadyCheck 开启准备确认的群组：双方确认后开骰；加入者超时未确认时撤销加入、退款并恢复等待
+func TestReadyCheck(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "ready.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	chatID := int64(-1003)
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	if err := db.SetChatReadyCheck(chatID, true); err != nil {
+		t.Fatalf("开启准备确认失败: %v", err)
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	started := make(chan *game.GameResult, 1)
+	expired := make(chan bool, 1)
+	manager.SetReadyCallbacks(func(chatID int64, result *game.GameResult) {
+		started <- result
+	}, func(state *game.ReadyState, reverted bool) {
+		expired <- reverted
+	})
+
+	// 双方确认后开骰
+	gameID, err := manager.CreateGame(1, chatID, utils.Coins(20))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	result, err := manager.JoinGame(gameID, 2)
+	if err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if !result.AwaitingReady {
+		t.Fatal("开启准备确认后加入不应立即开骰")
+	}
+	if g, _ := db.GetGame(gameID); g.Status != models.GameStatusReady {
+		t.Errorf("加入后应等待准备，实际状态 %s", g.Status)
+	}
+	if _, err := manager.ConfirmReady(gameID, 3); err == nil {
+		t.Error("旁观者不能确认准备")
+	}
+	state, err := manager.ConfirmReady(gameID, 1)
+	if err != nil || state.Started || !state.Player1Ready {
+		t.Fatalf("发起者确认后应等待对手，实际: %+v %v", state, err)
+	}
+	if _, err := manager.ConfirmReady(gameID, 1); err == nil {
+		t.Error("重复确认应返回错误")
+	}
+	state, err = manager.ConfirmReady(gameID, 2)
+	if err != nil || !state.Started {
+		t.Fatalf("双方确认后应开骰，实际: %+v %v", state, err)
+	}
+	select {
+	case r := <-started:
+		if r.GameID != gameID || r.Player2 == nil || r.Player2.ID != 2 {
+			t.Errorf("开骰回调的对局不符: %+v", r)
+		}
+	default:
+		t.Error("双方确认后应触发开骰回调")
+	}
+	if g, _ := db.GetGame(gameID); g.Status != models.GameStatusPlaying {
+		t.Errorf("双方确认后应进行中，实际状态 %s", g.Status)
+	}
+	if _, err := manager.ConfirmReady(gameID, 2); !errors.Is(err, game.ErrNotAwaitingReady) {
+		t.Errorf("已开骰的对局不能再确认，实际: %v", err)
+	}
+	if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+
+	// 加入者超时未确认：撤销加入并退款，对局恢复等待
+	manager.SetReadyWindow(50 * time.Millisecond)
+	gameID, err = manager.CreateGame(1, chatID, utils.Coins(30))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	before, _ := db.GetUser(3)
+	if _, err := manager.JoinGame(gameID, 3); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if _, err := manager.ConfirmReady(gameID, 1); err != nil {
+		t.Fatalf("发起者确认失败: %v", err)
+	}
+	select {
+	case reverted := <-expired:
+		if !reverted {
+			t.Error("加入者未确认时应撤销加入而不是取消对局")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("准备超时后应触发回调")
+	}
+
+	g, _ := db.GetGame(gameID)
+	if g.Status != models.GameStatusWaiting || g.Player2ID != nil {
+		t.Errorf("撤销加入后应恢复等待且没有对手，实际状态 %s，对手 %v", g.Status, g.Player2ID)
+	}
+	after, _ := db.GetUser(3)
+	if after.Balance != before.Balance {
+		t.Errorf("加入者应全额退款：加入前 %d，撤销后 %d", before.Balance, after.Balance)
+	}
+
+	// 恢复等待后其他玩家仍可加入
+	if _, err := manager.JoinGame(gameID, 2); err != nil {
+		t.Errorf("撤销加入后应可重新加入: %v", err)
+	}
+}
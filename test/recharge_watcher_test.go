@@ -0,0 +1,106 @@
+package test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/recharge"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestRechargeWatcherCreditsDeposits 链上转账自动入账，同一交易只入账一次，低于最低金额的不予入账，
+// 检查进度持久化，重启后从上次的进度继续检查
+func TestRechargeWatcherCreditsDeposits(t *testing.T) {
+	dir := t.TempDir()
+	db, err := database.Init(filepath.Join(dir, "recharge.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	address := "TXYZopqrstuvwxyzABCDEFGHJKLMNPQRST"
+	addressFile := filepath.Join(dir, "addresses.txt")
+	if err := os.WriteFile(addressFile, []byte(address+"\n"), 0644); err != nil {
+		t.Fatalf("写入地址文件失败: %v", err)
+	}
+	manager, err := recharge.NewRechargeManager(db, addressFile)
+	if err != nil {
+		t.Fatalf("创建充值管理器失败: %v", err)
+	}
+	manager.SetRate(100)
+
+	userID := int64(5001)
+	if err := db.CreateUser(&models.User{ID: userID, Username: "depositor"}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	if _, err := manager.GetUserRechargeAddress(userID); err != nil {
+		t.Fatalf("分配充值地址失败: %v", err)
+	}
+
+	contract := "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t"
+	var since string
+	block := time.Now().Add(-time.Hour).UnixMilli()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("TRON-PRO-API-KEY") != "test-key" {
+			t.Errorf("请求应携带 API Key")
+		}
+		since = r.URL.Query().Get("min_timestamp")
+		fmt.Fprintf(w, `{"success":true,"meta":{},"data":[
+			{"transaction_id":"tx-ok","token_info":{"address":"%[1]s","decimals":6},"block_timestamp":%[3]d,"to":"%[2]s","type":"Transfer","value":"25000000"},
+			{"transaction_id":"tx-small","token_info":{"address":"%[1]s","decimals":6},"block_timestamp":%[4]d,"to":"%[2]s","type":"Transfer","value":"1000000"},
+			{"transaction_id":"tx-other-token","token_info":{"address":"TOtherToken","decimals":6},"block_timestamp":%[5]d,"to":"%[2]s","type":"Transfer","value":"99000000"}
+		]}`, contract, address, block, block+1000, block+2000)
+	}))
+	defer server.Close()
+
+	watcher := recharge.NewWatcher(manager, server.URL, "test-key", contract, 10, 0)
+	credited := make(chan string, 4)
+	watcher.SetCreditedCallback(func(userID int64, amount float64, txHash string) {
+		credited <- txHash
+	})
+
+	// 两次检查返回相同的转账，只应入账一次
+	watcher.Check()
+	watcher.Check()
+
+	user, _ := db.GetUser(userID)
+	if user.Balance != utils.Coins(2500) {
+		t.Errorf("25 USDT 按 1:100 应入账 2500 金币，实际 %s", utils.FormatAmount(user.Balance))
+	}
+	if hash := <-credited; hash != "tx-ok" || len(credited) != 0 {
+		t.Errorf("只应通知 tx-ok 入账一次，实际 %s，剩余 %d", hash, len(credited))
+	}
+
+	records, err := manager.GetRechargeRecords(userID, 10)
+	if err != nil {
+		t.Fatalf("获取充值记录失败: %v", err)
+	}
+	statuses := make(map[string]string)
+	for _, record := range records {
+		statuses[record.TxHash] = record.Status
+	}
+	if len(records) != 2 || statuses["tx-ok"] != "confirmed" || statuses["tx-small"] != "failed" {
+		t.Errorf("应有一笔已到账和一笔低于最低金额的记录，实际: %v", statuses)
+	}
+
+	// 重启后的检测器从持久化的进度继续检查，重复的交易哈希由唯一索引拦下
+	restarted := recharge.NewWatcher(manager, server.URL, "test-key", contract, 10, 0)
+	restarted.Check()
+	if since != strconv.FormatInt(block+1000, 10) {
+		t.Errorf("重启后应从上次检查到的区块时间 %d 继续，实际 %s", block+1000, since)
+	}
+	if user, _ := db.GetUser(userID); user.Balance != utils.Coins(2500) {
+		t.Errorf("重启后不应重复入账，余额 %s", utils.FormatAmount(user.Balance))
+	}
+	if recordID, err := manager.RecordDeposit(userID, address, 25, "tx-ok", "pending"); err != nil || recordID != 0 {
+		t.Errorf("重复的交易哈希不应再记录: %d（%v）", recordID, err)
+	}
+}
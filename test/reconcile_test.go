@@ -0,0 +1,93 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/maintenance"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/reconcile"
+	"telegram-dice-bot/internal/utils"
+	"telegram-dice-bot/internal/withdraw"
+)
+
+// TestLedgerReconcile 余额与流水一致时不报差异，被改动的余额记录差异并冻结账户，确认后计入基线并解冻
+func TestLedgerReconcile(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "reconcile.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	userID := int64(5001)
+	if err := db.CreateUser(&models.User{ID: userID, Username: "ledger"}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	deposit := utils.Coins(5000)
+	if err := db.UpdateUserBalance(userID, deposit); err != nil {
+		t.Fatalf("更新余额失败: %v", err)
+	}
+	if err := db.CreateTransaction(&models.Transaction{
+		ID: utils.GenerateTransactionID(), UserID: userID, Type: models.TransactionTypeDeposit, Amount: deposit, Balance: deposit,
+	}); err != nil {
+		t.Fatalf("记录充值失败: %v", err)
+	}
+
+	manager := withdraw.NewManager(db, utils.Coins(1000), 10)
+	address := "TXYZopqrstuvwxyzABCDEFGHJKLMNPQRST"
+	withdrawal, _, err := manager.Request(userID, utils.Coins(1000), address)
+	if err != nil {
+		t.Fatalf("提交提现申请失败: %v", err)
+	}
+	if _, err := manager.Approve(withdrawal.ID, "admin", "0xabc"); err != nil {
+		t.Fatalf("批准提现申请失败: %v", err)
+	}
+
+	reconciler := reconcile.NewReconciler(db, maintenance.Window{}, true, "test")
+	run, err := reconciler.Run("manual")
+	if err != nil {
+		t.Fatalf("对账失败: %v", err)
+	}
+	if run.Checked != 1 || len(run.Issues) != 0 {
+		t.Fatalf("余额与流水一致时不应有差异，实际核对 %d 个，差异 %d 个", run.Checked, len(run.Issues))
+	}
+
+	// 绕过流水直接改余额
+	if err := db.UpdateUserBalance(userID, utils.Coins(4500)); err != nil {
+		t.Fatalf("更新余额失败: %v", err)
+	}
+	run, err = reconciler.Run("manual")
+	if err != nil {
+		t.Fatalf("对账失败: %v", err)
+	}
+	if len(run.Issues) != 1 || run.Frozen != 1 || run.Issues[0].Difference != utils.Coins(500) {
+		t.Fatalf("应发现 +500 的差异并冻结账户，实际: %+v", run)
+	}
+	issueID := run.Issues[0].ID
+	if frozen, _ := db.IsUserFrozen(userID); !frozen {
+		t.Error("发现差异后账户应被冻结")
+	}
+	if _, _, err := manager.Request(userID, utils.Coins(1000), address); !errors.Is(err, database.ErrAccountFrozen) {
+		t.Errorf("冻结的账户不能提现，实际: %v", err)
+	}
+
+	// 差额未变化时不重复告警
+	if run, err = reconciler.Run("manual"); err != nil || len(run.Issues) != 0 {
+		t.Errorf("差额未变化时不应重复记录，实际: %+v, %v", run, err)
+	}
+
+	if err := db.ResolveReconcileIssue(issueID, models.ReconcileStatusAccepted, "admin", "补发活动奖励"); err != nil {
+		t.Fatalf("确认对账差异失败: %v", err)
+	}
+	if frozen, _ := db.IsUserFrozen(userID); frozen {
+		t.Error("确认差异后账户应解冻")
+	}
+	if err := db.ResolveReconcileIssue(issueID, models.ReconcileStatusAccepted, "admin", ""); !errors.Is(err, database.ErrReconcileIssueNotOpen) {
+		t.Errorf("已处理的差异不能重复处理，实际: %v", err)
+	}
+	if run, err = reconciler.Run("manual"); err != nil || len(run.Issues) != 0 {
+		t.Errorf("确认后的差额应计入基线，实际: %+v, %v", run, err)
+	}
+}
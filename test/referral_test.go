@@ -0,0 +1,159 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/referral"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestReferral 邀请奖励：新用户通过邀请链接注册时发放注册奖励，累计下注达标后向双方发放一次流水奖励，
+// 奖励计入赠送余额，完成流水后才转为可提现余额
+func TestReferral(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "referral.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+
+	cfg := &config.Config{
+		MinBet:                 utils.Coins(1),
+		MaxBet:                 utils.Coins(1000),
+		ReferralSignupReferee:  utils.Coins(5),
+		ReferralWagerThreshold: utils.Coins(100),
+		ReferralWagerReferrer:  utils.Coins(20),
+		ReferralWagerReferee:   utils.Coins(5),
+
+		ReferralWageringMultiplier: 1,
+	}
+	client := telegram.NewFakeClient()
+	handler := referral.NewHandler(db, client, cfg)
+	var notices []int64
+	handler.SetNotifier(func(userID int64, text string) {
+		notices = append(notices, userID)
+	})
+	router := middleware.NewRouter(client)
+	router.Handle("start", func(ctx *middleware.Context) error {
+		_, err := handler.Redeem(ctx)
+		return err
+	})
+	handler.Register(router)
+
+	start := func(userID int64, payload string) string {
+		t.Helper()
+		client.Reset()
+		update := commandUpdate(userID, userID, "/start "+payload)
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理 /start 失败: %v", err)
+		}
+		sent := client.SentMessages()
+		if len(sent) == 0 {
+			return ""
+		}
+		return sent[len(sent)-1].(tgbotapi.MessageConfig).Text
+	}
+
+	if reply := start(1, "ref_1"); !strings.Contains(reply, "自己的邀请链接") {
+		t.Errorf("不应允许使用自己的邀请链接: %q", reply)
+	}
+	if reply := start(2, "ref_1"); !strings.Contains(reply, "注册奖励 5.00 金币") {
+		t.Errorf("被邀请人应收到注册奖励: %q", reply)
+	}
+	if reply := start(2, "ref_3"); !strings.Contains(reply, "仅限新用户") {
+		t.Errorf("每人只能被邀请一次: %q", reply)
+	}
+	if len(notices) != 1 || notices[0] != 1 {
+		t.Errorf("应私信邀请人一次，实际 %v", notices)
+	}
+	if user, _ := db.GetUser(2); user.Balance != utils.Coins(1000) || user.BonusBalance != utils.Coins(5) {
+		t.Errorf("注册奖励应计入赠送余额，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+
+	// 被邀请人累计下注 120 金币，超过 100 的门槛，流水奖励只发放一次
+	manager := game.NewManager(db, cfg, 0.05)
+	manager.SetOperationInterval(0)
+	for i := 0; i < 4; i++ {
+		gameID, err := manager.CreateGame(2, -1007, utils.Coins(30))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 3); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+	}
+
+	ref, err := db.GetReferral(2)
+	if err != nil || ref == nil || ref.WagerRewardedAt == nil || ref.Wagered != utils.Coins(120) {
+		t.Fatalf("流水达标状态不符: %+v（%v）", ref, err)
+	}
+	if user, _ := db.GetUser(1); user.Balance != utils.Coins(1000) || user.BonusBalance != utils.Coins(20) {
+		t.Errorf("邀请人的 20 金币流水奖励应计入赠送余额，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+	if progress, err := db.GetBonusProgress(1); err != nil || progress.WageringRequired != utils.Coins(20) || progress.Wagered != 0 {
+		t.Errorf("邀请奖励应附带 1 倍流水要求: %+v（%v）", progress, err)
+	}
+	if withdrawable, _ := db.GetWithdrawableBalance(1); withdrawable != utils.Coins(1000) {
+		t.Errorf("未完成流水的邀请奖励不可提现，可提现 %s", utils.FormatAmount(withdrawable))
+	}
+	bonuses, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 2, Type: models.TransactionTypeReferralBonus}, "", 10)
+	if err != nil || len(bonuses) != 2 {
+		t.Fatalf("被邀请人应有注册和流水两笔邀请奖励，实际 %d（%v）", len(bonuses), err)
+	}
+	for _, bonus := range bonuses {
+		if bonus.Amount != utils.Coins(5) {
+			t.Errorf("被邀请人邀请奖励金额不符: %s", utils.FormatAmount(bonus.Amount))
+		}
+	}
+
+	// 邀请人用赠送余额下注并完成流水，奖励转为可提现余额
+	gameID, err := manager.CreateGame(1, -1007, utils.Coins(20))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.JoinGame(gameID, 3); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	result, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1)
+	if err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+	if user, _ := db.GetUser(1); user.BonusBalance != 0 || user.Balance != utils.Coins(1000)+result.WinAmount {
+		t.Errorf("完成流水后赠送余额应转为现金，余额 %s，赠送余额 %s", utils.FormatAmount(user.Balance), utils.FormatAmount(user.BonusBalance))
+	}
+
+	// /invite 显示邀请链接和收益
+	client.Reset()
+	update := commandUpdate(1, 1, "/invite")
+	if _, err := router.Dispatch(&update); err != nil {
+		t.Fatalf("处理 /invite 失败: %v", err)
+	}
+	sent := client.SentMessages()
+	if len(sent) != 1 {
+		t.Fatalf("应回复一条消息，实际 %d", len(sent))
+	}
+	text := sent[0].(tgbotapi.MessageConfig).Text
+	for _, want := range []string{"https://t.me/test_dice_bot?start=ref_1", "已邀请 1 人，1 人下注达标", "累计邀请收益：20.00 金币"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("邀请信息缺少 %q: %q", want, text)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestRematchReservedForOpponent 再来一局沿用上一局下注额，只有上一局的对手可以加入
+func TestRematchReservedForOpponent(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "rematch.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	chatID := int64(-1002)
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	sourceID, err := manager.CreateGame(1, chatID, utils.Coins(20))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.CreateRematch(sourceID, 1); err == nil {
+		t.Error("未结束的对局不能再来一局")
+	}
+	if _, err := manager.JoinGame(sourceID, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if _, err := manager.PlayGameWithDiceResults(sourceID, 6, 6, 6, 1, 1, 1); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+
+	if _, err := manager.CreateRematch(sourceID, 3); err == nil {
+		t.Error("旁观者不能发起再来一局")
+	}
+	rematch, err := manager.CreateRematch(sourceID, 2)
+	if err != nil {
+		t.Fatalf("发起再来一局失败: %v", err)
+	}
+	if rematch.OpponentID != 1 || rematch.BetAmount != utils.Coins(20) || rematch.ChatID != chatID {
+		t.Errorf("再来一局应以 20 金币邀请玩家 1，实际: %+v", rematch)
+	}
+	if _, err := manager.CreateRematch(sourceID, 1); !errors.Is(err, game.ErrRematchPending) {
+		t.Errorf("对手已发起时应提示接受邀请，实际: %v", err)
+	}
+	if _, err := manager.CreateRematch(sourceID, 2); err == nil {
+		t.Error("同一局不能重复发起再来一局")
+	}
+
+	if _, err := manager.JoinGame(rematch.GameID, 3); err == nil {
+		t.Error("再来一局只允许上一局的对手加入")
+	}
+	if _, err := manager.JoinGame(rematch.GameID, 1); err != nil {
+		t.Fatalf("对手接受再来一局失败: %v", err)
+	}
+	if played, _ := db.GetGame(rematch.GameID); played == nil || played.Status != models.GameStatusPlaying {
+		t.Errorf("接受后对局应开始，实际: %+v", played)
+	}
+}
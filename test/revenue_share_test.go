@@ -0,0 +1,173 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestCalculateChatShare 分成按 0.01 金币向下取整，零头归平台；比例为 0 或负数不分成，超过 100% 按 100% 计
+func TestCalculateChatShare(t *testing.T) {
+	cases := []struct {
+		name       string
+		commission int64
+		share      float64
+		want       int64
+	}{
+		{"奇数手续费对半分", 101, 0.5, 50},
+		{"最小单位手续费对半分", 1, 0.5, 0},
+		{"三成分成", 333, 0.3, 99},
+		{"全部分成", 101, 1, 101},
+		{"比例为 0", 101, 0, 0},
+		{"比例为负数", 101, -0.5, 0},
+		{"比例超过 100%", 101, 1.5, 101},
+		{"手续费为 0", 0, 0.5, 0},
+	}
+	for _, c := range cases {
+		if got := game.CalculateChatShare(c.commission, c.share); got != c.want {
+			t.Errorf("%s: 手续费 %d、比例 %g 应分成 %d，实际 %d", c.name, c.commission, c.share, c.want, got)
+		}
+	}
+}
+
+// TestRevenueShareSettlement 结算时手续费拆分为平台和群组两笔交易，群组部分计入对局和群组基金；
+// 未设置分成的群组手续费全部归平台，报表按群组汇总
+func TestRevenueShareSettlement(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "revenue_share.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	sharedChat, plainChat := int64(-4715), int64(-4716)
+	if err := db.SetChatRevenueShare(sharedChat, 1.5); err == nil {
+		t.Error("分成比例超过 1 时应拒绝")
+	}
+	if err := db.SetChatRevenueShare(sharedChat, 0.5); err != nil {
+		t.Fatalf("设置分成比例失败: %v", err)
+	}
+	if share, err := db.GetChatRevenueShare(plainChat); err != nil || share != 0 {
+		t.Errorf("未设置的群组分成比例应为 0: %g（%v）", share, err)
+	}
+
+	since := time.Now().Add(-time.Minute)
+	play := func(chatID int64) (string, *game.GameResult) {
+		t.Helper()
+		// 下注 10.10，奖池 20.20，手续费 1.01
+		gameID, err := manager.CreateGame(1, chatID, utils.Coins(10)+10)
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		result, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1)
+		if err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return gameID, result
+	}
+	commissionRows := func(gameID string) (platform, chat []*models.Transaction) {
+		t.Helper()
+		platform, _, err := db.SearchTransactions(&models.TransactionFilter{GameID: gameID, Type: models.TransactionTypeCommission}, "", 10)
+		if err != nil {
+			t.Fatalf("查询手续费交易失败: %v", err)
+		}
+		chat, _, err = db.SearchTransactions(&models.TransactionFilter{GameID: gameID, Type: models.TransactionTypeRevenueShare}, "", 10)
+		if err != nil {
+			t.Fatalf("查询分成交易失败: %v", err)
+		}
+		return platform, chat
+	}
+
+	// 奇数手续费对半分：群组 0.50，平台 0.51
+	sharedGame, result := play(sharedChat)
+	if result.Commission != 101 || result.ChatShare != 50 {
+		t.Errorf("手续费应为 1.01、群组分成 0.50，实际 %s / %s", utils.FormatAmount(result.Commission), utils.FormatAmount(result.ChatShare))
+	}
+	if user, _ := db.GetUser(1); user.Balance != utils.Coins(100)+utils.Coins(10)+10-101 {
+		t.Errorf("赢家余额不符: %s", utils.FormatAmount(user.Balance))
+	}
+	if user, _ := db.GetUser(2); user.Balance != utils.Coins(90)-10 {
+		t.Errorf("输家余额不符: %s", utils.FormatAmount(user.Balance))
+	}
+	platform, chat := commissionRows(sharedGame)
+	if len(platform) != 1 || platform[0].Amount != 51 || platform[0].UserID != 0 {
+		t.Errorf("平台手续费交易应为一笔 0.51: %+v", platform)
+	}
+	if len(chat) != 1 || chat[0].Amount != 50 || chat[0].UserID != 0 {
+		t.Errorf("群组分成交易应为一笔 0.50: %+v", chat)
+	}
+	if g, err := db.GetGame(sharedGame); err != nil || g.Commission != 101 || g.ChatShare != 50 {
+		t.Errorf("对局应记录手续费 1.01 和分成 0.50: %+v（%v）", g, err)
+	}
+	if fund, _, err := db.GetChatFund(sharedChat); err != nil || fund != 50 {
+		t.Errorf("群组基金应为 0.50，实际 %s（%v）", utils.FormatAmount(fund), err)
+	}
+
+	// 未设置分成：手续费全部归平台，不产生分成交易，基金不变
+	plainGame, result := play(plainChat)
+	if result.ChatShare != 0 {
+		t.Errorf("未设置分成的群组不应分成: %s", utils.FormatAmount(result.ChatShare))
+	}
+	platform, chat = commissionRows(plainGame)
+	if len(platform) != 1 || platform[0].Amount != 101 || len(chat) != 0 {
+		t.Errorf("手续费应全部归平台: 平台 %+v，分成 %+v", platform, chat)
+	}
+	if fund, _, _ := db.GetChatFund(plainChat); fund != 0 {
+		t.Errorf("未设置分成的群组基金应为 0，实际 %s", utils.FormatAmount(fund))
+	}
+
+	// 同一群组再结算一局，基金累加
+	play(sharedChat)
+	if fund, _, _ := db.GetChatFund(sharedChat); fund != 100 {
+		t.Errorf("两局后群组基金应为 1.00，实际 %s", utils.FormatAmount(fund))
+	}
+
+	// 报表按分成金额排序并汇总每个群组的局数、手续费和分成
+	reports, err := db.GetRevenueShareReport(since)
+	if err != nil || len(reports) != 2 {
+		t.Fatalf("报表应包含 2 个群组: %d（%v）", len(reports), err)
+	}
+	if r := reports[0]; r.ChatID != sharedChat || r.Share != 0.5 || r.Games != 2 || r.Commission != 202 || r.ChatShare != 100 {
+		t.Errorf("分成群组的报表不符: %+v", r)
+	}
+	if r := reports[1]; r.ChatID != plainChat || r.Share != 0 || r.Games != 1 || r.Commission != 101 || r.ChatShare != 0 {
+		t.Errorf("未分成群组的报表不符: %+v", r)
+	}
+	if later, err := db.GetRevenueShareReport(time.Now().Add(time.Minute)); err != nil || len(later) != 0 {
+		t.Errorf("统计区间之后不应有记录: %d（%v）", len(later), err)
+	}
+}
+
+// TestCommissionFooter 结果底部展示手续费，有分成时注明归入本群基金的部分
+func TestCommissionFooter(t *testing.T) {
+	cases := []struct {
+		commission, chatShare int64
+		want                  string
+	}{
+		{0, 0, ""},
+		{101, 0, "💼 手续费：1.01 金币"},
+		{101, 50, "💼 手续费：1.01 金币（其中 0.50 金币归入本群基金）"},
+	}
+	for _, c := range cases {
+		if got := ui.FormatCommissionFooter(c.commission, c.chatShare); got != c.want {
+			t.Errorf("手续费 %d、分成 %d 的底部文案应为 %q，实际 %q", c.commission, c.chatShare, c.want, got)
+		}
+	}
+}
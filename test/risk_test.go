@@ -0,0 +1,131 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/risk"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestRiskEngine 同一对玩家之间持续单向输赢时，结算后标记双方并自动冻结，管理员审核误报后解冻
+func TestRiskEngine(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "risk.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	engine := risk.NewEngine(db, risk.Rules{
+		Window:      time.Hour,
+		PairGames:   10,
+		MinGames:    3,
+		WinRate:     0.9,
+		NetTransfer: utils.Coins(30),
+	}, true)
+	alerts := make(chan []*models.RiskEvent, 4)
+	engine.SetFlaggedCallback(func(events []*models.RiskEvent) { alerts <- events })
+	manager.SetRiskEngine(engine)
+
+	chatID := int64(-1062)
+	play := func(dice ...int) string {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, dice[0], dice[1], dice[2], dice[3], dice[4], dice[5]); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return gameID
+	}
+
+	// 平局不计入输赢，前两局未达到阈值
+	play(3, 3, 3, 3, 3, 3)
+	play(6, 6, 6, 1, 1, 1)
+	play(6, 6, 6, 1, 1, 1)
+	last := play(6, 6, 6, 1, 1, 1)
+
+	var flagged []*models.RiskEvent
+	select {
+	case flagged = <-alerts:
+	case <-time.After(2 * time.Second):
+		t.Fatal("结算后应触发风控告警")
+	}
+	byRule := make(map[string][]*models.RiskEvent)
+	for _, event := range flagged {
+		byRule[event.Rule] = append(byRule[event.Rule], event)
+	}
+	if len(flagged) != 3 || len(byRule[models.RiskRuleChipDump]) != 2 || len(byRule[models.RiskRuleWinRate]) != 1 {
+		t.Fatalf("应标记双方筹码转移和获胜方胜率异常，实际 %d 条: %v", len(flagged), byRule)
+	}
+	if byRule[models.RiskRuleWinRate][0].UserID != 1 || !strings.Contains(byRule[models.RiskRuleWinRate][0].Detail, "胜率 100%") {
+		t.Errorf("胜率异常记录不符: %+v", byRule[models.RiskRuleWinRate][0])
+	}
+	if text := ui.FormatRiskAlert(flagged, nil); !strings.Contains(text, "已冻结 2 个账户") || !strings.Contains(text, "筹码转移") {
+		t.Errorf("告警内容不符:\n%s", text)
+	}
+
+	for _, id := range []int64{1, 2} {
+		if frozen, _ := db.IsUserFrozen(id); !frozen {
+			t.Errorf("用户 %d 应被自动冻结", id)
+		}
+	}
+	if _, err := manager.CreateGame(2, chatID, utils.Coins(10)); err == nil {
+		t.Error("冻结的账户不应能发起对局")
+	}
+
+	// 同一组合待审核期间不重复标记
+	lastGame, _ := db.GetGame(last)
+	if again, err := engine.CheckSettlement(lastGame, 1, 2); err != nil || len(again) != 0 {
+		t.Errorf("待审核期间不应重复标记: %d 条（%v）", len(again), err)
+	}
+
+	// 误报：用户 2 没有其他冻结中的事件，解冻；用户 1 仍有待审核的胜率异常，保持冻结
+	for _, event := range byRule[models.RiskRuleChipDump] {
+		if err := db.ResolveRiskEvent(event.ID, models.RiskStatusDismissed, "admin", "朋友间约局"); err != nil {
+			t.Fatalf("处理风控事件失败: %v", err)
+		}
+	}
+	if frozen, _ := db.IsUserFrozen(2); frozen {
+		t.Error("误报处理后用户 2 应解冻")
+	}
+	if frozen, _ := db.IsUserFrozen(1); !frozen {
+		t.Error("用户 1 仍有待审核的风控事件，应保持冻结")
+	}
+	if err := db.ResolveRiskEvent(byRule[models.RiskRuleWinRate][0].ID, models.RiskStatusConfirmed, "admin", ""); err != nil {
+		t.Fatalf("确认风控事件失败: %v", err)
+	}
+	if frozen, _ := db.IsUserFrozen(1); !frozen {
+		t.Error("确认违规后账户应保持冻结")
+	}
+	if err := db.ResolveRiskEvent(byRule[models.RiskRuleWinRate][0].ID, models.RiskStatusDismissed, "admin", ""); err != database.ErrRiskEventNotOpen {
+		t.Errorf("已处理的事件不应重复处理: %v", err)
+	}
+
+	// 统计窗口内审核过的组合不再重复标记
+	if again, err := engine.CheckSettlement(lastGame, 1, 2); err != nil || len(again) != 0 {
+		t.Errorf("审核过的组合不应在窗口内重复标记: %d 条（%v）", len(again), err)
+	}
+	if open, _ := db.GetRiskEvents(models.RiskStatusOpen, 10); len(open) != 0 {
+		t.Errorf("不应再有待审核的风控事件，实际 %d 条", len(open))
+	}
+}
@@ -0,0 +1,148 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/access"
+	"telegram-dice-bot/internal/balance"
+	"telegram-dice-bot/internal/cache"
+	"telegram-dice-bot/internal/callback"
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/shard"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/ui"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// waitSent 等待发往群组的消息达到 n 条，返回这些消息的文本
+func waitSent(t *testing.T, server *telegram.FakeServer, chatID int64, n int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var texts []string
+		for _, call := range server.CallsTo("sendMessage") {
+			if call.Int64("chat_id") == chatID {
+				texts = append(texts, call.Params["text"])
+			}
+		}
+		if len(texts) >= n {
+			return texts
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("群组 %d 应收到 %d 条消息，实际 %d 条: %q", chatID, n, len(texts), texts)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestRouterChainEndToEnd 更新经长轮询进入与 main 相同的中间件链：分片、准入、容量、用户和封禁检查后由 /balance 处理，
+// 其他分片的群组静默忽略，超出容量的群组排队，机器人离开群组时释放名额
+func TestRouterChainEndToEnd(t *testing.T) {
+	server := telegram.NewFakeServer()
+	defer server.Close()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("连接假服务器失败: %v", err)
+	}
+
+	db, err := database.Init(filepath.Join(t.TempDir(), "router_chain.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	coordinator, err := shard.NewCoordinator(db, 1, 2)
+	if err != nil {
+		t.Fatalf("创建分片协调器失败: %v", err)
+	}
+	coordinator.Start()
+	defer coordinator.Stop()
+
+	// 两个本分片的群组和一个其他分片的群组
+	var owned []int64
+	var foreign int64
+	for chatID := int64(-5001); len(owned) < 2 || foreign == 0; chatID-- {
+		if shard.Of(chatID, 2) == 1 {
+			if len(owned) < 2 {
+				owned = append(owned, chatID)
+			}
+		} else if foreign == 0 {
+			foreign = chatID
+		}
+	}
+	served, waiting := owned[0], owned[1]
+
+	router := middleware.NewRouter(client,
+		middleware.Recover(),
+		middleware.ShardOwner(coordinator.Owns),
+		access.NewGuard(db, &config.Config{ChatAccessMode: models.ChatAccessOpen}).Middleware(),
+		middleware.Capacity(func(chatID int64) (bool, int, error) {
+			return db.AdmitChat(chatID, 1)
+		}),
+		middleware.EnsureUser(func(from *tgbotapi.User) (*models.User, error) {
+			user := &models.User{ID: from.ID, Username: from.UserName, FirstName: from.FirstName}
+			if _, err := db.EnsureUser(user); err != nil {
+				return nil, err
+			}
+			return user, nil
+		}),
+		middleware.BanCheck(db.GetUserBan),
+	)
+	router.HandleMembership(middleware.ReleaseOnLeave(db.ReleaseChat))
+	balance.NewHandler(db, cache.NewBalanceCache(db), callback.NewCodec("secret"), time.Second).Register(router)
+
+	poller := middleware.NewPoller(client, router)
+	poller.Start()
+	stopped := false
+	defer func() {
+		if !stopped {
+			poller.Stop()
+		}
+	}()
+
+	server.PushUpdate(commandUpdate(foreign, 6001, "/balance"))
+	server.PushUpdate(commandUpdate(served, 6001, "/balance"))
+	if texts := waitSent(t, server, served, 1); texts[0] == ui.WaitlistMessage(1) {
+		t.Errorf("获得名额的群组应收到余额: %q", texts[0])
+	}
+	if user, err := db.GetUser(6001); err != nil || user == nil {
+		t.Errorf("经过中间件链后用户应已创建: %v", err)
+	}
+
+	server.PushUpdate(commandUpdate(waiting, 6002, "/balance"))
+	if texts := waitSent(t, server, waiting, 1); texts[0] != ui.WaitlistMessage(1) {
+		t.Errorf("名额已满的群组应收到排队提示: %q", texts[0])
+	}
+
+	// 机器人被移出已服务的群组，名额释放给排队的群组，之后排队的群组正常处理命令
+	server.PushUpdate(tgbotapi.Update{MyChatMember: &tgbotapi.ChatMemberUpdated{
+		Chat:          tgbotapi.Chat{ID: served, Type: "supergroup"},
+		From:          tgbotapi.User{ID: 6001},
+		OldChatMember: tgbotapi.ChatMember{Status: "member"},
+		NewChatMember: tgbotapi.ChatMember{Status: "kicked"},
+	}})
+	if texts := waitSent(t, server, waiting, 2); texts[1] != ui.WaitlistAdmittedMessage() {
+		t.Errorf("排队的群组应收到获得名额的通知: %q", texts[1])
+	}
+	server.PushUpdate(commandUpdate(waiting, 6002, "/balance"))
+	if texts := waitSent(t, server, waiting, 3); texts[2] == ui.WaitlistMessage(1) {
+		t.Errorf("获得名额后应收到余额: %q", texts[2])
+	}
+
+	poller.Stop()
+	stopped = true
+	for _, call := range server.Calls() {
+		if call.Int64("chat_id") == foreign {
+			t.Errorf("其他分片的群组不应收到任何调用: %s", call.Method)
+		}
+	}
+	if counts, _ := db.CountChatsByServiceStatus(); counts[models.ChatServiceWaitlist] != 0 {
+		t.Errorf("其他分片的群组不应进入等待队列: %v", counts)
+	}
+}
@@ -0,0 +1,44 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/telegram"
+)
+
+// TestRouterMiddlewareOrder 全局中间件对所有处理函数按相同顺序生效，与处理函数在 Use 之前还是之后注册无关，
+// 路由自带的中间件位于全局中间件之内
+func TestRouterMiddlewareOrder(t *testing.T) {
+	var trace []string
+	mark := func(name string) middleware.Middleware {
+		return func(next middleware.HandlerFunc) middleware.HandlerFunc {
+			return func(ctx *middleware.Context) error {
+				trace = append(trace, name)
+				return next(ctx)
+			}
+		}
+	}
+	handler := func(ctx *middleware.Context) error {
+		trace = append(trace, "handler")
+		return nil
+	}
+
+	router := middleware.NewRouter(telegram.NewFakeClient(), mark("a"))
+	router.Handle("early", handler, mark("route"))
+	router.Use(mark("b"))
+	router.Handle("late", handler, mark("route"))
+	router.Use(mark("c"))
+
+	for _, command := range []string{"early", "late"} {
+		trace = nil
+		update := commandUpdate(-3001, 1, "/"+command)
+		if handled, err := router.Dispatch(&update); err != nil || !handled {
+			t.Fatalf("处理 /%s 失败: %v", command, err)
+		}
+		if got, want := strings.Join(trace, ","), "a,b,c,route,handler"; got != want {
+			t.Errorf("/%s 中间件顺序不符: %s，应为 %s", command, got, want)
+		}
+	}
+}
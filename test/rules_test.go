@@ -0,0 +1,156 @@
+package test
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/rules"
+	"telegram-dice-bot/internal/rules/plugins"
+	"telegram-dice-bot/internal/utils"
+)
+
+// halfFee 自定义插件示例：下注不低于 100 金币的对局手续费减半
+type halfFee struct{}
+
+func init() {
+	// 插件在 init 中注册，启用时把名称加入 GAME_RULES
+	rules.Register(halfFee{})
+}
+
+func (halfFee) Name() string {
+	return "half_fee"
+}
+
+func (halfFee) ModifyPayout(outcome rules.Outcome, payout rules.Payout) rules.Payout {
+	if outcome.BetAmount >= utils.Coins(100) {
+		payout.Commission /= 2
+	}
+	return payout
+}
+
+// Example_lowestTotalWins 启用 lowest_total_wins 后点数低者获胜
+func Example_lowestTotalWins() {
+	set, err := rules.Load([]string{plugins.LowestTotalName})
+	if err != nil {
+		panic(err)
+	}
+
+	outcome := rules.Outcome{Player1: [3]int{1, 2, 3}, Player2: [3]int{4, 5, 6}}
+	fmt.Println(set.Winner(outcome) == rules.Player1)
+	// Output: true
+}
+
+// Example_tripleBonus 启用 triple_bonus 后获胜方掷出豹子时免收手续费
+func Example_tripleBonus() {
+	set, err := rules.Load([]string{plugins.TripleBonusName})
+	if err != nil {
+		panic(err)
+	}
+
+	outcome := rules.Outcome{Player1: [3]int{5, 5, 5}, Player2: [3]int{1, 2, 3}}
+	outcome.Winner = set.Winner(outcome)
+	payout := set.Payout(outcome, rules.Payout{Pot: 2000, Commission: 100})
+	fmt.Println(payout.Commission, payout.WinAmount())
+	// Output: 0 2000
+}
+
+// Example_customRule 自定义插件与内置插件按启用顺序组合
+func Example_customRule() {
+	set, err := rules.Load([]string{plugins.LowestTotalName, "half_fee"})
+	if err != nil {
+		panic(err)
+	}
+
+	outcome := rules.Outcome{BetAmount: utils.Coins(100), Player1: [3]int{6, 6, 5}, Player2: [3]int{1, 2, 4}}
+	outcome.Winner = set.Winner(outcome)
+	payout := set.Payout(outcome, rules.Payout{Pot: utils.Coins(200), Commission: utils.Coins(10)})
+	fmt.Println(outcome.Winner == rules.Player2, utils.FormatAmount(payout.WinAmount()))
+	// Output: true 195.00
+}
+
+// TestRulesLoadRejectsInvalidConfig 未注册的插件或多个胜负判定插件无法启用
+func TestRulesLoadRejectsInvalidConfig(t *testing.T) {
+	if _, err := rules.Load([]string{"no_such_rule"}); err == nil || !strings.Contains(err.Error(), "未注册") {
+		t.Errorf("未注册的插件应报错，实际: %v", err)
+	}
+	if _, err := rules.Load([]string{plugins.LowestTotalName, plugins.LowestTotalName}); err == nil {
+		t.Error("启用多个胜负判定插件应报错")
+	}
+
+	set, err := rules.Load(nil)
+	if err != nil {
+		t.Fatalf("空配置应按默认规则: %v", err)
+	}
+	if winner := set.Winner(rules.Outcome{Player1: [3]int{6, 6, 6}, Player2: [3]int{1, 1, 1}}); winner != rules.Player1 {
+		t.Errorf("默认规则应点数高者获胜，实际: %d", winner)
+	}
+}
+
+// TestSettlementWithRules 对局结算按启用的插件判定胜负和手续费，资金保持守恒
+func TestSettlementWithRules(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "rules.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	player1ID, player2ID, chatID := int64(3001), int64(3002), int64(-3001)
+	for _, id := range []int64{player1ID, player2ID} {
+		if err := db.CreateUser(&models.User{ID: id, Username: fmt.Sprintf("user%d", id), Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+
+	set, err := rules.Load([]string{plugins.LowestTotalName, plugins.TripleBonusName})
+	if err != nil {
+		t.Fatalf("加载插件失败: %v", err)
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	manager.SetRules(set)
+
+	play := func(p1, p2 [3]int) *game.GameResult {
+		gameID, err := manager.CreateGame(player1ID, chatID, utils.Coins(100))
+		if err != nil {
+			t.Fatalf("创建对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, player2ID); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		result, err := manager.PlayGameWithDiceResults(gameID, p1[0], p1[1], p1[2], p2[0], p2[1], p2[2])
+		if err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return result
+	}
+
+	// 点数低者获胜，豹子免手续费
+	result := play([3]int{1, 1, 1}, [3]int{6, 6, 6})
+	if result.Winner == nil || result.Winner.ID != player1ID {
+		t.Fatalf("点数低的玩家1应获胜，实际: %+v", result.Winner)
+	}
+	if result.Commission != 0 || result.WinAmount != utils.Coins(200) {
+		t.Errorf("豹子获胜应免手续费，实际手续费 %d，派奖 %d", result.Commission, result.WinAmount)
+	}
+
+	// 非豹子按费率收取手续费
+	result = play([3]int{6, 6, 5}, [3]int{1, 2, 3})
+	if result.Winner == nil || result.Winner.ID != player2ID {
+		t.Fatalf("点数低的玩家2应获胜，实际: %+v", result.Winner)
+	}
+	if result.Commission != utils.Coins(10) || result.WinAmount != utils.Coins(190) {
+		t.Errorf("应按 5%% 收取手续费，实际手续费 %d，派奖 %d", result.Commission, result.WinAmount)
+	}
+
+	player1, _ := db.GetUser(player1ID)
+	player2, _ := db.GetUser(player2ID)
+	if total := player1.Balance + player2.Balance; total != utils.Coins(2000)-utils.Coins(10) {
+		t.Errorf("双方余额合计应为 %s，实际 %s", utils.FormatAmount(utils.Coins(1990)), utils.FormatAmount(total))
+	}
+}
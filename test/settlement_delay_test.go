@@ -0,0 +1,92 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestSettlementDelayNotice 开骰后迟迟未结算时通知双方自动退款的期限，按时结算的对局不通知
+func TestSettlementDelayNotice(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "delay.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 4; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	manager.SetMaxGameDuration(time.Second)
+	manager.SetSettlementDelayNotice(200 * time.Millisecond)
+
+	type notice struct {
+		gameID   string
+		refundIn time.Duration
+	}
+	delayed := make(chan notice, 2)
+	manager.SetSettlementDelayedCallback(func(game *models.Game, refundIn time.Duration) {
+		delayed <- notice{game.ID, refundIn}
+	})
+	aborted := make(chan string, 1)
+	manager.SetGameAbortedCallback(func(game *models.Game, reason string) {
+		aborted <- game.ID
+	})
+
+	start := func(chatID, p1, p2 int64) string {
+		t.Helper()
+		gameID, err := manager.CreateGame(p1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, p2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		return gameID
+	}
+	settled := start(-1021, 1, 2)
+	stuck := start(-1022, 3, 4)
+	if _, err := manager.PlayGameWithDiceResults(settled, 6, 6, 6, 1, 1, 1); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+
+	select {
+	case got := <-delayed:
+		if got.gameID != stuck || got.refundIn != 800*time.Millisecond {
+			t.Errorf("延迟通知不符: %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("未收到结算延迟通知")
+	}
+
+	// 通知承诺的自动退款如期执行
+	select {
+	case gameID := <-aborted:
+		if gameID != stuck {
+			t.Errorf("应中止对局 %s，实际 %s", stuck, gameID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("超过最长时长后未自动退款")
+	}
+	select {
+	case got := <-delayed:
+		t.Errorf("按时结算的对局不应通知: %+v", got)
+	default:
+	}
+
+	if text := ui.SettlementDelayedDM(stuck, utils.Coins(10), 4*time.Minute); !strings.Contains(text, stuck) || !strings.Contains(text, "4 分 00 秒") {
+		t.Errorf("延迟私信内容不符: %q", text)
+	}
+}
@@ -0,0 +1,200 @@
+package test
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestSettlementHold 暂停自动结算期间开骰的对局等待审核：审核通过按保存的骰子结算，拒绝时双方退款；到期自动恢复
+func TestSettlementHold(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "settlement_hold.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	if _, err := manager.HoldSettlements(0); err == nil {
+		t.Error("暂停时长为 0 时应拒绝")
+	}
+	if _, err := manager.HoldSettlements(game.MaxSettlementHold + time.Minute); err == nil {
+		t.Error("暂停时长超过上限时应拒绝")
+	}
+	until, err := manager.HoldSettlements(time.Hour)
+	if err != nil {
+		t.Fatalf("暂停自动结算失败: %v", err)
+	}
+	if held, _, err := manager.SettlementHoldUntil(); err != nil || held.Unix() != until.Unix() {
+		t.Errorf("暂停截止时间不符: %v（%v）", held, err)
+	}
+
+	play := func(p1, p2 int) (string, *game.GameResult) {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, -1137, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		result, err := manager.PlayGameWithDiceResults(gameID, p1, p1, p1, p2, p2, p2)
+		if err != nil {
+			t.Fatalf("开骰失败: %v", err)
+		}
+		return gameID, result
+	}
+	balances := func() (int64, int64) {
+		t.Helper()
+		user1, _ := db.GetUser(1)
+		user2, _ := db.GetUser(2)
+		return user1.Balance, user2.Balance
+	}
+
+	// 暂停期间开骰：保存骰子结果，不派奖
+	approved, result := play(6, 1)
+	refunded, _ := play(1, 6)
+	if !result.Held || result.Winner != nil {
+		t.Errorf("暂停期间的结果不应包含胜负: %+v", result)
+	}
+	if b1, b2 := balances(); b1 != utils.Coins(80) || b2 != utils.Coins(80) {
+		t.Errorf("暂停期间不应派奖，余额 %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+	held, err := manager.HeldSettlements(10)
+	if err != nil || len(held) != 2 {
+		t.Fatalf("应有 2 局等待审核: %d（%v）", len(held), err)
+	}
+
+	// 审核通过：按保存的骰子结算，玩家 1 获胜
+	settled, err := manager.ApproveHeldSettlement(approved)
+	if err != nil {
+		t.Fatalf("审核结算失败: %v", err)
+	}
+	if settled.Winner == nil || settled.Winner.ID != 1 || settled.Player1Total != 18 {
+		t.Errorf("应按保存的骰子判定玩家 1 获胜: %+v", settled)
+	}
+	if _, err := manager.ApproveHeldSettlement(approved); err == nil {
+		t.Error("已结算的对局不应重复审核")
+	}
+
+	// 审核拒绝：双方退款
+	if err := manager.RefundHeldSettlement(refunded); err != nil {
+		t.Fatalf("审核退款失败: %v", err)
+	}
+	if g, _ := db.GetGame(refunded); g == nil || g.Status != models.GameStatusCancelled {
+		t.Errorf("拒绝的对局应取消: %+v", g)
+	}
+	if b1, b2 := balances(); b1 != utils.Coins(90)+settled.WinAmount || b2 != utils.Coins(90) {
+		t.Errorf("审核后余额不符: %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+	if remaining, _ := manager.HeldSettlements(10); len(remaining) != 0 {
+		t.Errorf("审核后不应有待审核对局，实际 %d", len(remaining))
+	}
+
+	// 手动恢复后正常结算
+	if err := manager.ResumeSettlements(); err != nil {
+		t.Fatalf("恢复自动结算失败: %v", err)
+	}
+	if _, result := play(6, 1); result.Held || result.Winner == nil {
+		t.Errorf("恢复后应正常结算: %+v", result)
+	}
+
+	// 到期自动恢复并清除配置项
+	if _, err := manager.HoldSettlements(50 * time.Millisecond); err != nil {
+		t.Fatalf("暂停自动结算失败: %v", err)
+	}
+	if held, err := manager.SettlementsHeld(); err != nil || !held {
+		t.Errorf("暂停期间应处于暂停状态: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if held, err := manager.SettlementsHeld(); err != nil || held {
+		t.Errorf("到期后应自动恢复: %v", err)
+	}
+	if _, ok, _ := db.GetSetting(database.SettlementHoldKey); ok {
+		t.Error("到期后应清除暂停配置项")
+	}
+	if _, result := play(6, 1); result.Held || result.Winner == nil {
+		t.Errorf("到期恢复后应正常结算: %+v", result)
+	}
+}
+
+// TestSettlementHoldFinishedOnce 暂停的对局开骰时不通知对局结束，审核结算或退款时各通知一次
+func TestSettlementHoldFinishedOnce(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "settlement_hold_finished.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	var mu sync.Mutex
+	finished := make(map[string]int)
+	manager.SetGameLifecycleCallbacks(nil, func(gameID string) {
+		mu.Lock()
+		finished[gameID]++
+		mu.Unlock()
+	})
+	count := func(gameID string) int {
+		mu.Lock()
+		defer mu.Unlock()
+		return finished[gameID]
+	}
+
+	if _, err := manager.HoldSettlements(time.Hour); err != nil {
+		t.Fatalf("暂停自动结算失败: %v", err)
+	}
+	play := func() string {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, -1737, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+			t.Fatalf("开骰失败: %v", err)
+		}
+		if n := count(gameID); n != 0 {
+			t.Errorf("暂停的对局开骰时不应通知对局结束，实际 %d 次", n)
+		}
+		return gameID
+	}
+
+	approved := play()
+	if _, err := manager.ApproveHeldSettlement(approved); err != nil {
+		t.Fatalf("审核结算失败: %v", err)
+	}
+	if n := count(approved); n != 1 {
+		t.Errorf("审核结算后应通知对局结束一次，实际 %d 次", n)
+	}
+
+	refunded := play()
+	if err := manager.RefundHeldSettlement(refunded); err != nil {
+		t.Fatalf("审核退款失败: %v", err)
+	}
+	if n := count(refunded); n != 1 {
+		t.Errorf("审核退款后应通知对局结束一次，实际 %d 次", n)
+	}
+}
@@ -0,0 +1,225 @@
+package test
+
+import (
+	"math/rand"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"testing/quick"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/logger"
+	"telegram-dice-bot/internal/models"
+)
+
+const propertyFeeRate = 0.05
+
+// settlementDesign 一种完整的建局-加入-结算流程，用于对比不同管理器实现
+type settlementDesign struct {
+	name string
+	play func(player1ID, player2ID, chatID, bet int64, dice [6]int) (*game.GameResult, error)
+}
+
+// settlementDesigns 当前的 Manager（骰子由 TG 动画提供）和 EnhancedManager（加入即自动结算）
+func settlementDesigns(t *testing.T, db *database.DB) []settlementDesign {
+	cfg := &config.Config{MinBet: 1, MaxBet: 1000000}
+	manager := game.NewManager(db, cfg, propertyFeeRate)
+
+	log, err := logger.NewLogger("test")
+	if err != nil {
+		t.Fatalf("初始化日志失败: %v", err)
+	}
+	enhanced := game.NewEnhancedManager(db, cfg, propertyFeeRate, log)
+
+	return []settlementDesign{
+		{
+			name: "Manager",
+			play: func(player1ID, player2ID, chatID, bet int64, dice [6]int) (*game.GameResult, error) {
+				gameID, err := manager.CreateGame(player1ID, chatID, bet)
+				if err != nil {
+					return nil, err
+				}
+				if _, err := manager.JoinGame(gameID, player2ID); err != nil {
+					return nil, err
+				}
+				return manager.PlayGameWithDiceResults(gameID, dice[0], dice[1], dice[2], dice[3], dice[4], dice[5])
+			},
+		},
+		{
+			name: "EnhancedManager",
+			play: func(player1ID, player2ID, chatID, bet int64, _ [6]int) (*game.GameResult, error) {
+				gameID, err := enhanced.CreateGameSecure(player1ID, chatID, bet)
+				if err != nil {
+					return nil, err
+				}
+				return enhanced.JoinGameSecure(gameID, player2ID)
+			},
+		},
+	}
+}
+
+// settlementCase quick 生成的一局输入
+type settlementCase struct {
+	Bet      int64
+	Balance1 int64
+	Balance2 int64
+	Dice     [6]int
+}
+
+// Generate 实现 quick.Generator，下注额 1-1000，余额不少于下注额，骰子点数 1-6
+func (settlementCase) Generate(r *rand.Rand, size int) reflect.Value {
+	c := settlementCase{Bet: int64(r.Intn(1000)) + 1}
+	c.Balance1 = c.Bet + int64(r.Intn(5000))
+	c.Balance2 = c.Bet + int64(r.Intn(5000))
+	for i := range c.Dice {
+		c.Dice[i] = r.Intn(6) + 1
+	}
+	// 约 1/6 的用例强制平局，覆盖退款路径
+	if r.Intn(6) == 0 {
+		c.Dice[3], c.Dice[4], c.Dice[5] = c.Dice[0], c.Dice[1], c.Dice[2]
+	}
+	return reflect.ValueOf(c)
+}
+
+var nextPropertyUserID int64 = 500000
+
+// newPropertyUsers 每个用例使用新用户，避免余额验证器的操作频率限制
+func newPropertyUsers(t *testing.T, db *database.DB, balance1, balance2 int64) (int64, int64) {
+	t.Helper()
+
+	ids := [2]int64{atomic.AddInt64(&nextPropertyUserID, 1), atomic.AddInt64(&nextPropertyUserID, 1)}
+	for i, balance := range []int64{balance1, balance2} {
+		if err := db.CreateUser(&models.User{ID: ids[i], Username: "prop", Balance: balance}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	return ids[0], ids[1]
+}
+
+// userLedger 返回用户按时间正序的全部交易
+func userLedger(t *testing.T, db *database.DB, userID int64) []*models.Transaction {
+	t.Helper()
+
+	transactions, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: userID}, "", 1000)
+	if err != nil {
+		t.Fatalf("查询交易失败: %v", err)
+	}
+	for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+		transactions[i], transactions[j] = transactions[j], transactions[i]
+	}
+	return transactions
+}
+
+// gameLedger 返回对局相关的全部交易
+func gameLedger(t *testing.T, db *database.DB, gameID string) []*models.Transaction {
+	t.Helper()
+
+	transactions, _, err := db.SearchTransactions(&models.TransactionFilter{GameID: gameID}, "", 1000)
+	if err != nil {
+		t.Fatalf("查询交易失败: %v", err)
+	}
+	return transactions
+}
+
+// TestSettlementInvariants 任意骰子和下注额下的结算资金守恒
+func TestSettlementInvariants(t *testing.T) {
+	db, err := database.Init(t.TempDir() + "/property.db")
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	// 开启群组分成，覆盖手续费拆分为平台和群组两笔流水的路径
+	chatID := int64(-1000)
+	if err := db.SetChatRevenueShare(chatID, 0.3); err != nil {
+		t.Fatalf("设置群组分成失败: %v", err)
+	}
+
+	for _, design := range settlementDesigns(t, db) {
+		design := design
+		t.Run(design.name, func(t *testing.T) {
+			property := func(c settlementCase) bool {
+				player1ID, player2ID := newPropertyUsers(t, db, c.Balance1, c.Balance2)
+
+				result, err := design.play(player1ID, player2ID, chatID, c.Bet, c.Dice)
+				if err != nil {
+					t.Logf("对局失败 %+v: %v", c, err)
+					return false
+				}
+
+				user1, _ := db.GetUser(player1ID)
+				user2, _ := db.GetUser(player2ID)
+
+				// 余额永远不为负
+				if user1.Balance < 0 || user2.Balance < 0 {
+					t.Logf("出现负余额: %d, %d", user1.Balance, user2.Balance)
+					return false
+				}
+
+				before := c.Balance1 + c.Balance2
+				after := user1.Balance + user2.Balance
+
+				if result.Winner == nil {
+					// 平局精确退还，双方余额不变，且不收手续费
+					if user1.Balance != c.Balance1 || user2.Balance != c.Balance2 || result.Commission != 0 {
+						t.Logf("平局资金不守恒 %+v: %d, %d, 手续费 %d", c, user1.Balance, user2.Balance, result.Commission)
+						return false
+					}
+				} else {
+					// 赢家所得 + 手续费 == 2×下注额
+					if result.WinAmount+result.Commission != 2*c.Bet {
+						t.Logf("派彩不守恒 %+v: 派彩 %d + 手续费 %d", c, result.WinAmount, result.Commission)
+						return false
+					}
+					// 两名玩家总余额只减少手续费
+					if before-after != result.Commission {
+						t.Logf("余额变化 %d 与手续费 %d 不一致", before-after, result.Commission)
+						return false
+					}
+
+					// 手续费相关流水（平台 + 群组分成）合计等于手续费
+					var fees int64
+					for _, tx := range gameLedger(t, db, result.GameID) {
+						if tx.Type == models.TransactionTypeCommission || tx.Type == models.TransactionTypeRevenueShare {
+							fees += tx.Amount
+						}
+					}
+					if fees != result.Commission {
+						t.Logf("手续费流水 %d 与手续费 %d 不一致", fees, result.Commission)
+						return false
+					}
+				}
+
+				// 重放流水可还原最终余额，且每笔流水记录的余额与重放结果一致
+				for _, check := range []struct {
+					userID  int64
+					initial int64
+					final   int64
+				}{
+					{player1ID, c.Balance1, user1.Balance},
+					{player2ID, c.Balance2, user2.Balance},
+				} {
+					balance := check.initial
+					for _, tx := range userLedger(t, db, check.userID) {
+						balance += tx.Amount
+						if tx.Balance != balance {
+							t.Logf("用户 %d 流水 %s 记录余额 %d，重放得到 %d", check.userID, tx.Type, tx.Balance, balance)
+							return false
+						}
+					}
+					if balance != check.final {
+						t.Logf("用户 %d 重放余额 %d，实际 %d", check.userID, balance, check.final)
+						return false
+					}
+				}
+				return true
+			}
+
+			if err := quick.Check(property, &quick.Config{MaxCount: 200}); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
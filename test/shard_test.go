@@ -0,0 +1,226 @@
+package test
+
+import (
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/outbox"
+	"telegram-dice-bot/internal/shard"
+	"telegram-dice-bot/internal/telegram"
+)
+
+// TestShardRouting 各进程只处理本分片的群组，全局单例任务只由持有 0 号分片锁的进程执行
+func TestShardRouting(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "shard.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := shard.NewCoordinator(db, 2, 2); err == nil {
+		t.Error("分片编号超出范围时应创建失败")
+	}
+
+	coordinators := make([]*shard.Coordinator, 2)
+	for id := range coordinators {
+		coordinator, err := shard.NewCoordinator(db, id, 2)
+		if err != nil {
+			t.Fatalf("创建分片协调器失败: %v", err)
+		}
+		coordinator.Start()
+		defer coordinator.Stop()
+		coordinators[id] = coordinator
+	}
+
+	for _, chatID := range []int64{-1001, -1002, 1003, 1004} {
+		for id, coordinator := range coordinators {
+			if want := shard.Of(chatID, 2) == id; coordinator.Owns(chatID) != want {
+				t.Errorf("分片 %d 对群组 %d 的归属应为 %v", id, chatID, want)
+			}
+		}
+	}
+	if !coordinators[0].Leader() || coordinators[1].Leader() {
+		t.Error("只有 0 号分片的进程应执行单例任务")
+	}
+
+	// 路由只分发本分片的群组，其他群组静默忽略
+	client := telegram.NewFakeClient()
+	router := middleware.NewRouter(client)
+	var handled []int64
+	router.Handle("dice", func(ctx *middleware.Context) error {
+		handled = append(handled, ctx.ChatID)
+		return nil
+	})
+	// 处理函数先于分片中间件注册，同样受分片限制
+	router.Use(middleware.ShardOwner(coordinators[1].Owns))
+	for _, chatID := range []int64{-1001, -1002} {
+		update := commandUpdate(chatID, 1, "/dice 10")
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理命令失败: %v", err)
+		}
+	}
+	if len(handled) != 1 || handled[0] != -1001 {
+		t.Errorf("分片 1 只应处理群组 -1001，实际 %v", handled)
+	}
+	if messages := client.SentMessages(); len(messages) != 0 {
+		t.Errorf("其他分片的群组不应收到回复，实际 %d 条", len(messages))
+	}
+
+	// 关闭开关时发件箱后台不投递，打开后恢复
+	var active atomic.Bool
+	sender := outbox.NewSender(db, client)
+	sender.SetGate(active.Load)
+	sender.Start()
+	defer sender.Stop()
+	if err := sender.Enqueue(&models.OutboxMessage{ChatID: -1001, Text: "📢 公告"}); err != nil {
+		t.Fatalf("写入发件箱失败: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if pending, _ := db.CountPendingOutbox(); pending != 1 || len(client.SentMessages()) != 0 {
+		t.Errorf("开关关闭时不应投递，实际待发送 %d，已发出 %d", pending, len(client.SentMessages()))
+	}
+
+	active.Store(true)
+	sender.Notify()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(client.SentMessages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if pending, _ := db.CountPendingOutbox(); pending != 0 || len(client.SentMessages()) != 1 {
+		t.Errorf("开关打开后应投递发件箱，实际待发送 %d，已发出 %d", pending, len(client.SentMessages()))
+	}
+}
+
+// shardProcess 模拟一个分片进程：分片协调器、拉取更新的接收器和从共享队列领取本分片更新的分发器
+type shardProcess struct {
+	coordinator *shard.Coordinator
+	receiver    *shard.Receiver
+	poller      *middleware.Poller
+}
+
+// shardedUpdates 记录各更新由哪些分片处理
+type shardedUpdates struct {
+	mu      sync.Mutex
+	handled map[int][]int
+}
+
+func (u *shardedUpdates) record(updateID, shardID int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.handled[updateID] = append(u.handled[updateID], shardID)
+}
+
+func (u *shardedUpdates) count() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.handled)
+}
+
+func startShardProcess(t *testing.T, server *telegram.FakeServer, db *database.DB, shardID int, updates *shardedUpdates) *shardProcess {
+	t.Helper()
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatalf("连接假服务器失败: %v", err)
+	}
+	coordinator, err := shard.NewCoordinator(db, shardID, 2)
+	if err != nil {
+		t.Fatalf("创建分片协调器失败: %v", err)
+	}
+	coordinator.Start()
+
+	router := middleware.NewRouter(client)
+	router.Use(middleware.ShardOwner(coordinator.Owns))
+	router.Handle("dice", func(ctx *middleware.Context) error {
+		updates.record(ctx.Update.UpdateID, shardID)
+		return nil
+	})
+
+	p := &shardProcess{
+		coordinator: coordinator,
+		receiver:    shard.NewReceiver(db, client),
+		poller:      middleware.NewPoller(client, router),
+	}
+	p.receiver.SetGate(coordinator.Leader)
+	p.receiver.Start()
+	p.poller.SetSource(shard.NewQueue(coordinator))
+	p.poller.Start()
+	return p
+}
+
+func (p *shardProcess) stop() {
+	p.poller.Stop()
+	p.receiver.Stop()
+	p.coordinator.Stop()
+}
+
+// TestShardedUpdatesHandledOnce 两个分片进程共用一个 Token：只有一个进程拉取更新，
+// 每条更新恰好由所属分片处理一次，拉取更新的进程重启后不重复也不遗漏
+func TestShardedUpdatesHandledOnce(t *testing.T) {
+	server := telegram.NewFakeServer()
+	defer server.Close()
+	db, err := database.Init(filepath.Join(t.TempDir(), "shard_updates.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	updates := &shardedUpdates{handled: make(map[int][]int)}
+	chats := make(map[int]int64)
+	push := func(n int) {
+		for i := 0; i < n; i++ {
+			chatID := int64(-5001 - len(chats)%4)
+			server.PushUpdate(commandUpdate(chatID, 1, "/dice 10"))
+			chats[len(chats)+1] = chatID
+		}
+	}
+	waitHandled := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for updates.count() < n {
+			if time.Now().After(deadline) {
+				t.Fatalf("等待 %d 条更新处理超时，已处理 %d 条", n, updates.count())
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	leader := startShardProcess(t, server, db, 0, updates)
+	follower := startShardProcess(t, server, db, 1, updates)
+	defer follower.stop()
+
+	push(40)
+	waitHandled(40)
+
+	// 拉取更新的进程重启，期间到达的更新在重启后拉取
+	leader.stop()
+	push(10)
+	leader = startShardProcess(t, server, db, 0, updates)
+	defer leader.stop()
+	waitHandled(50)
+	time.Sleep(300 * time.Millisecond) // 等待可能的重复处理
+
+	updates.mu.Lock()
+	for updateID, chatID := range chats {
+		want := shard.Of(chatID, 2)
+		if got := updates.handled[updateID]; len(got) != 1 || got[0] != want {
+			t.Errorf("更新 %d（群组 %d）应只由分片 %d 处理一次，实际 %v", updateID, chatID, want, got)
+		}
+	}
+	updates.mu.Unlock()
+
+	if conflicts := server.Conflicts(); conflicts != 0 {
+		t.Errorf("同一时间只应有一个进程拉取更新，实际冲突 %d 次", conflicts)
+	}
+	if value, _, err := db.GetSetting(telegram.OffsetSettingKey); err != nil || value != "50" {
+		t.Errorf("偏移量应为 50，实际 %q (%v)", value, err)
+	}
+	if queued, err := db.CountQueuedUpdates(); err != nil || queued != 0 {
+		t.Errorf("处理完后队列应为空，实际 %d (%v)", queued, err)
+	}
+}
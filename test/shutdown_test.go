@@ -0,0 +1,129 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestDrainOnShutdown 关闭服务：不再接受新的对局，等待本进程进行中的对局结算，超时仍未结算的对局向双方退款，
+// 其他进程开局的对局不受影响
+func TestDrainOnShutdown(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "shutdown.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 7; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	aborted := make(chan string, 2)
+	manager.SetGameAbortedCallback(func(game *models.Game, reason string) {
+		aborted <- game.ID + ":" + reason
+	})
+
+	start := func(chatID, p1, p2 int64) string {
+		t.Helper()
+		gameID, err := manager.CreateGame(p1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, p2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		return gameID
+	}
+	finishing := start(-1011, 1, 2)
+	stuck := start(-1012, 3, 4)
+	waiting, err := manager.CreateGame(5, -1013, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+
+	// 共用数据库的另一个进程开局的对局
+	other := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	other.SetOperationInterval(0)
+	foreign, err := other.CreateGame(6, -1015, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := other.JoinGame(foreign, 7); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+
+	type drained struct {
+		settled, refunded int
+		err               error
+	}
+	done := make(chan drained, 1)
+	go func() {
+		settled, refunded, err := manager.Drain(time.Second)
+		done <- drained{settled, refunded, err}
+	}()
+
+	// 关闭期间拒绝发起和加入对局
+	deadline := time.Now().Add(time.Second)
+	for !manager.Draining() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := manager.CreateGame(1, -1014, utils.Coins(10)); !errors.Is(err, game.ErrShuttingDown) {
+		t.Errorf("关闭期间发起对局应被拒绝，实际: %v", err)
+	}
+	if _, err := manager.JoinGame(waiting, 1); !errors.Is(err, game.ErrShuttingDown) {
+		t.Errorf("关闭期间加入对局应被拒绝，实际: %v", err)
+	}
+
+	// 进行中的对局在超时前正常结算
+	if _, err := manager.PlayGameWithDiceResults(finishing, 6, 6, 6, 1, 1, 1); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+
+	var result drained
+	select {
+	case result = <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待对局结算超时未返回")
+	}
+	if result.err != nil || result.settled != 1 || result.refunded != 1 {
+		t.Fatalf("应结算 1 局、退款 1 局，实际 %+v", result)
+	}
+
+	// 退款通知在 Drain 返回前同步发出
+	select {
+	case got := <-aborted:
+		if got != stuck+":"+models.GameAbortShutdown {
+			t.Errorf("中止通知不符: %s", got)
+		}
+	default:
+		t.Error("Drain 返回前应已发出退款通知")
+	}
+
+	if g, _ := db.GetGame(stuck); g == nil || g.Status != models.GameStatusCancelled {
+		t.Errorf("未结算的对局应已取消: %+v", g)
+	}
+	for _, id := range []int64{3, 4} {
+		if user, _ := db.GetUser(id); user == nil || user.Balance != utils.Coins(100) {
+			t.Errorf("玩家 %d 应全额退款，实际: %+v", id, user)
+		}
+	}
+	if g, _ := db.GetGame(foreign); g == nil || g.Status != models.GameStatusPlaying {
+		t.Errorf("其他进程的对局不应被中止: %+v", g)
+	}
+	// 等待对手的对局保留到重启后
+	if g, _ := db.GetGame(waiting); g == nil || g.Status != models.GameStatusWaiting {
+		t.Errorf("等待对手的对局应保留: %+v", g)
+	}
+}
@@ -0,0 +1,282 @@
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// tableFixture 六名玩家和一个群组，用于快速桌和多人大战的资金测试
+type tableFixture struct {
+	t       *testing.T
+	db      *database.DB
+	manager *game.Manager
+	chatID  int64
+}
+
+func newTableFixture(t *testing.T, name string) *tableFixture {
+	t.Helper()
+	db, err := database.Init(filepath.Join(t.TempDir(), name+".db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	for id := int64(1); id <= game.MaxTablePlayers; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+	return &tableFixture{t: t, db: db, manager: manager, chatID: -4741}
+}
+
+// balances 全部玩家的当前余额
+func (f *tableFixture) balances() map[int64]int64 {
+	f.t.Helper()
+	balances := make(map[int64]int64)
+	for id := int64(1); id <= game.MaxTablePlayers; id++ {
+		user, err := f.db.GetUser(id)
+		if err != nil || user == nil {
+			f.t.Fatalf("读取用户失败: %v", err)
+		}
+		balances[id] = user.Balance
+	}
+	return balances
+}
+
+// rows 快速桌的某类交易记录，按描述中的桌号筛选
+func (f *tableFixture) rows(tableID, txType string) []*models.Transaction {
+	f.t.Helper()
+	rows, _, err := f.db.SearchTransactions(&models.TransactionFilter{Memo: tableID, Type: txType}, "", 50)
+	if err != nil {
+		f.t.Fatalf("查询交易失败: %v", err)
+	}
+	return rows
+}
+
+// seat 由玩家 1 开桌，其余玩家依次入座，共 n 人；返回入座后、开骰前的余额
+func (f *tableFixture) seat(table *models.Table, n int) map[int64]int64 {
+	f.t.Helper()
+	for id := int64(2); id <= int64(n); id++ {
+		if _, _, err := f.manager.JoinTable(table.ID, id); err != nil {
+			f.t.Fatalf("玩家 %d 入座失败: %v", id, err)
+		}
+	}
+	if bets := f.rows(table.ID, models.TransactionTypeBet); len(bets) != n {
+		f.t.Fatalf("应有 %d 笔底注交易，实际 %d 笔", n, len(bets))
+	}
+	return f.balances()
+}
+
+// checkSettled 开骰后每名玩家的余额恰好增加其奖金并各有一笔等额派奖交易，
+// 奖金与手续费之和等于奖池，手续费交易与结算结果一致
+func (f *tableFixture) checkSettled(result *game.TableResult, seated map[int64]int64) {
+	f.t.Helper()
+	tableID := result.Table.ID
+	after := f.balances()
+
+	wins := make(map[int64]*models.Transaction)
+	for _, row := range f.rows(tableID, models.TransactionTypeWin) {
+		if wins[row.UserID] != nil {
+			f.t.Errorf("玩家 %d 不应有多笔派奖交易", row.UserID)
+		}
+		wins[row.UserID] = row
+	}
+
+	var paid int64
+	for _, player := range result.Players {
+		paid += player.Payout
+		if after[player.UserID] != seated[player.UserID]+player.Payout {
+			f.t.Errorf("玩家 %d 余额应增加奖金 %s: 开骰前 %s，开骰后 %s", player.UserID, utils.FormatAmount(player.Payout),
+				utils.FormatAmount(seated[player.UserID]), utils.FormatAmount(after[player.UserID]))
+		}
+		win := wins[player.UserID]
+		switch {
+		case player.Payout == 0 && win != nil:
+			f.t.Errorf("未得奖的玩家 %d 不应有派奖交易: %+v", player.UserID, win)
+		case player.Payout > 0 && (win == nil || win.Amount != player.Payout || win.Balance != after[player.UserID]):
+			f.t.Errorf("玩家 %d 的派奖交易应为 %s、余额 %s: %+v", player.UserID, utils.FormatAmount(player.Payout),
+				utils.FormatAmount(after[player.UserID]), win)
+		}
+	}
+	if paid+result.Commission != result.Pot {
+		f.t.Errorf("奖金 %s 与手续费 %s 之和应等于奖池 %s", utils.FormatAmount(paid), utils.FormatAmount(result.Commission), utils.FormatAmount(result.Pot))
+	}
+
+	var commission int64
+	for _, row := range f.rows(tableID, models.TransactionTypeCommission) {
+		commission += row.Amount
+	}
+	if commission != result.Commission {
+		f.t.Errorf("手续费交易合计 %s，结算结果为 %s", utils.FormatAmount(commission), utils.FormatAmount(result.Commission))
+	}
+	if table, err := f.db.GetTable(tableID); err != nil || table.Status != models.TableStatusFinished || table.Commission != result.Commission {
+		f.t.Errorf("快速桌应已结算并记录手续费: %+v（%v）", table, err)
+	}
+}
+
+// TestTableAllTied 只有全部玩家点数相同才视同平局不收手续费，
+// 部分并列时无论落后的玩家坐在哪个位置都照常收取
+func TestTableAllTied(t *testing.T) {
+	cases := []struct {
+		name   string
+		totals []int
+		want   bool
+	}{
+		{"三人全部并列", []int{10, 10, 10}, true},
+		{"两人并列", []int{7, 7}, true},
+		{"最后入座的玩家落后", []int{10, 10, 3}, false},
+		{"先入座的玩家落后", []int{3, 10, 10}, false},
+		{"中间入座的玩家落后", []int{10, 3, 10}, false},
+		{"并列第二", []int{12, 5, 5}, false},
+		{"各不相同", []int{4, 9, 15}, false},
+	}
+	for _, c := range cases {
+		ranks := game.RankTable(c.totals)
+		if got := game.TableAllTied(ranks); got != c.want {
+			t.Errorf("%s: 点数 %v（名次 %v）全部并列应为 %v，实际 %v", c.name, c.totals, ranks, c.want, got)
+		}
+	}
+}
+
+// TestTableSettlement 快速桌入座扣除底注，开骰后前两名按 60/40 瓜分扣除手续费后的奖池，
+// 全部并列时原额退还不收手续费；开骰前关闭时全额退还底注
+func TestTableSettlement(t *testing.T) {
+	f := newTableFixture(t, "table_settlement")
+	ante := utils.Coins(10)
+
+	// 入座人数不足时不能开骰，余额不变
+	table, err := f.manager.CreateTable(1, f.chatID, ante)
+	if err != nil {
+		t.Fatalf("开设快速桌失败: %v", err)
+	}
+	if _, _, err := f.manager.JoinTable(table.ID, 1); err == nil {
+		t.Error("已入座的玩家不应重复入座")
+	}
+	seated := f.seat(table, 2)
+	if _, err := f.manager.StartTable(table.ID, 1); err == nil {
+		t.Errorf("不足 %d 人时不应开骰", game.MinTablePlayers)
+	}
+	if b := f.balances(); b[1] != seated[1] || b[2] != seated[2] {
+		t.Error("未开骰时余额不应变化")
+	}
+
+	// 开骰前关闭：每人一笔等额退款，余额恢复原样
+	if _, _, err := f.manager.CancelTable(table.ID, 2); err == nil {
+		t.Error("只有开桌者可以关闭快速桌")
+	}
+	if _, _, err := f.manager.CancelTable(table.ID, 1); err != nil {
+		t.Fatalf("关闭快速桌失败: %v", err)
+	}
+	for id, b := range f.balances() {
+		if b != utils.Coins(1000) {
+			t.Errorf("关闭后玩家 %d 余额应恢复原样，实际 %s", id, utils.FormatAmount(b))
+		}
+	}
+	refunds := f.rows(table.ID, models.TransactionTypeRefund)
+	if len(refunds) != 2 || refunds[0].Amount != ante || refunds[1].Amount != ante {
+		t.Errorf("关闭后应有 2 笔等额退款交易: %+v", refunds)
+	}
+	if _, err := f.manager.StartTable(table.ID, 1); err == nil {
+		t.Error("已关闭的快速桌不应开骰")
+	}
+
+	// 骰子随机，多开几桌覆盖并列的情形
+	for round := 0; round < 20; round++ {
+		table, err := f.manager.CreateTable(1, f.chatID, ante)
+		if err != nil {
+			t.Fatalf("第 %d 桌开设失败: %v", round, err)
+		}
+		seated := f.seat(table, game.MaxTablePlayers)
+		result, err := f.manager.StartTable(table.ID, 1)
+		if err != nil {
+			t.Fatalf("第 %d 桌开骰失败: %v", round, err)
+		}
+		f.checkSettled(result, seated)
+
+		pot := ante * game.MaxTablePlayers
+		allTied := result.Players[len(result.Players)-1].Rank == 1
+		if allTied {
+			if result.Commission != 0 {
+				t.Errorf("第 %d 桌全部并列时不应收手续费", round)
+			}
+		} else if result.Commission != utils.CalculateCommission(pot, 0.05) {
+			t.Errorf("第 %d 桌手续费应为 %s，实际 %s", round, utils.FormatAmount(utils.CalculateCommission(pot, 0.05)), utils.FormatAmount(result.Commission))
+		}
+		for _, player := range result.Players {
+			if player.Rank > len(game.TablePrizeShares) && player.Payout != 0 {
+				t.Errorf("第 %d 桌第 %d 名不应得奖: %+v", round, player.Rank, player)
+			}
+		}
+		if result.Players[1].Rank == 2 && result.Players[2].Rank == 3 {
+			// 第一、二名各一人：第二名得 40%，其余（含零头）归第一名
+			second := (pot - result.Commission) * game.TablePrizeShares[1] / 100
+			if result.Players[0].Payout != pot-result.Commission-second || result.Players[1].Payout != second {
+				t.Errorf("第 %d 桌前两名应得 %s / %s，实际 %s / %s", round, utils.FormatAmount(pot-result.Commission-second),
+					utils.FormatAmount(second), utils.FormatAmount(result.Players[0].Payout), utils.FormatAmount(result.Players[1].Payout))
+			}
+		}
+	}
+}
+
+// TestBattleSettlement 多人大战由点数最高者独得扣除手续费后的全部奖池，并列最高时平分，其余玩家只扣底注
+func TestBattleSettlement(t *testing.T) {
+	f := newTableFixture(t, "battle_settlement")
+	ante := utils.Coins(10)
+
+	for _, maxPlayers := range []int{game.MinTablePlayers - 1, game.MaxBattlePlayers + 1} {
+		if _, err := f.manager.CreateBattle(1, f.chatID, ante, maxPlayers); err == nil {
+			t.Errorf("人数上限 %d 应被拒绝", maxPlayers)
+		}
+	}
+	if b := f.balances(); b[1] != utils.Coins(1000) {
+		t.Errorf("开设失败时不应扣除底注，余额 %s", utils.FormatAmount(b[1]))
+	}
+
+	// 坐满后入座的玩家都可以开骰，多余的玩家不能入座
+	const seats = 4
+	for round := 0; round < 20; round++ {
+		table, err := f.manager.CreateBattle(1, f.chatID, ante, seats)
+		if err != nil {
+			t.Fatalf("第 %d 场开设失败: %v", round, err)
+		}
+		seated := f.seat(table, seats)
+		if _, _, err := f.manager.JoinTable(table.ID, seats+1); err == nil {
+			t.Fatalf("第 %d 场坐满后不应再入座", round)
+		}
+		result, err := f.manager.StartTable(table.ID, seats)
+		if err != nil {
+			t.Fatalf("第 %d 场开骰失败: %v", round, err)
+		}
+		f.checkSettled(result, seated)
+
+		winners := 0
+		for _, player := range result.Players {
+			if player.Rank == 1 {
+				winners++
+			} else if player.Payout != 0 {
+				t.Errorf("第 %d 场非最高点数的玩家不应得奖: %+v", round, player)
+			}
+		}
+		pot := ante * seats
+		if winners == seats {
+			if result.Commission != 0 || result.Players[0].Payout != ante {
+				t.Errorf("第 %d 场全部并列时应原额退还且不收手续费: %+v", round, result.Players[0])
+			}
+			continue
+		}
+		if result.Commission != utils.CalculateCommission(pot, 0.05) {
+			t.Errorf("第 %d 场手续费应为 %s，实际 %s", round, utils.FormatAmount(utils.CalculateCommission(pot, 0.05)), utils.FormatAmount(result.Commission))
+		}
+		if each := (pot - result.Commission) / int64(winners); result.Players[winners-1].Payout != each {
+			t.Errorf("第 %d 场 %d 名最高点数玩家应各得 %s，实际 %s", round, winners, utils.FormatAmount(each), utils.FormatAmount(result.Players[winners-1].Payout))
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+	"telegram-dice-bot/internal/tournament"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TestTournament 管理员开设锦标赛，玩家报名缴费，调度器抽签后逐轮进行单败淘汰赛，
+// 冠军独得扣除手续费后的报名费和群组比赛奖池
+func TestTournament(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "tournament.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 7; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	// 群组分得全部手续费，用群内一局对局的手续费充实比赛奖池
+	chatID := int64(-1072)
+	if err := db.SetChatRevenueShare(chatID, 1); err != nil {
+		t.Fatalf("设置分成比例失败: %v", err)
+	}
+	gameID, err := manager.CreateGame(6, chatID, utils.Coins(20))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := manager.JoinGame(gameID, 7); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	if _, err := manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+	fundPrize, _, err := db.GetChatFund(chatID)
+	if err != nil || fundPrize <= 0 {
+		t.Fatalf("群组基金应有手续费分成: %d（%v）", fundPrize, err)
+	}
+	if _, err := db.MoveChatFundToPrizePool(chatID, fundPrize); err != nil {
+		t.Fatalf("转入比赛奖池失败: %v", err)
+	}
+
+	client := telegram.NewFakeClient()
+	scheduler := tournament.NewScheduler(manager, client, time.Minute)
+	handler := tournament.NewHandler(manager, scheduler, []int64{99}, 10*time.Minute)
+	router := middleware.NewRouter(client)
+	handler.Register(router)
+
+	dispatch := func(userID int64, text string) string {
+		t.Helper()
+		client.Reset()
+		update := commandUpdate(chatID, userID, text)
+		if _, err := router.Dispatch(&update); err != nil {
+			t.Fatalf("处理命令失败: %v", err)
+		}
+		var texts []string
+		for _, sent := range client.SentMessages() {
+			texts = append(texts, sent.(tgbotapi.MessageConfig).Text)
+		}
+		return strings.Join(texts, "\n")
+	}
+
+	if text := dispatch(1, "/tournament open 10 8"); strings.Contains(text, "开始报名") {
+		t.Error("非管理员不应能开设锦标赛")
+	}
+	if text := dispatch(99, "/tournament open 10 1"); !strings.Contains(text, "人数上限") {
+		t.Errorf("人数上限过小应被拒绝: %q", text)
+	}
+	if text := dispatch(99, "/tournament open 10 8"); !strings.Contains(text, "开始报名") {
+		t.Fatalf("开设锦标赛失败: %q", text)
+	}
+	if _, err := manager.OpenTournament(99, chatID, utils.Coins(10), 8, time.Minute); err == nil {
+		t.Error("同一群组不应同时开设两场锦标赛")
+	}
+
+	for id := int64(1); id <= 5; id++ {
+		if text := dispatch(id, "/enter"); !strings.Contains(text, "报名成功") {
+			t.Fatalf("用户 %d 报名失败: %q", id, text)
+		}
+	}
+	if text := dispatch(1, "/enter"); !strings.Contains(text, "已报名") {
+		t.Errorf("重复报名应被拒绝: %q", text)
+	}
+	if user, _ := db.GetUser(1); user == nil || user.Balance != utils.Coins(90) {
+		t.Errorf("报名应扣除报名费: %+v", user)
+	}
+	if text := dispatch(2, "/tournament"); !strings.Contains(text, "已报名：5/8") {
+		t.Errorf("报名状态不符: %q", text)
+	}
+
+	// 报名未截止时调度器不处理
+	if advanced := scheduler.Run(time.Now()); advanced != 0 {
+		t.Errorf("报名未截止时不应开赛，推进了 %d 场", advanced)
+	}
+
+	// 报名截止后抽签开赛，5 人的签表为 8，前 3 个种子位首轮轮空，共 3 轮
+	client.Reset()
+	later := time.Now().Add(time.Hour)
+	if advanced := scheduler.Run(later); advanced != 1 {
+		t.Fatalf("报名截止后应开赛，推进了 %d 场", advanced)
+	}
+	if sent := client.SentMessages(); len(sent) != 1 || !strings.Contains(sent[0].(tgbotapi.MessageConfig).Text, "抽签完成") {
+		t.Fatalf("开赛公告不符: %+v", sent)
+	}
+	if _, _, err := manager.EnterTournament(chatID, 1); err == nil {
+		t.Error("开赛后不应能报名")
+	}
+	if _, _, err := manager.CancelTournament(chatID); err == nil {
+		t.Error("开赛后不应能取消锦标赛")
+	}
+
+	var announcements []string
+	for round := 1; round <= 3; round++ {
+		client.Reset()
+		later = later.Add(time.Hour)
+		if advanced := scheduler.Run(later); advanced != 1 {
+			t.Fatalf("第 %d 轮未进行", round)
+		}
+		for _, sent := range client.SentMessages() {
+			announcements = append(announcements, sent.(tgbotapi.MessageConfig).Text)
+		}
+	}
+	if len(announcements) != 4 || !strings.Contains(announcements[0], "第 1 轮") || !strings.Contains(announcements[0], "轮空晋级") ||
+		!strings.Contains(announcements[1], "半决赛") || !strings.Contains(announcements[2], "决赛") ||
+		!strings.Contains(announcements[3], "冠军") || !strings.Contains(announcements[3], "含群组奖池") {
+		t.Fatalf("逐轮公告不符:\n%s", strings.Join(announcements, "\n---\n"))
+	}
+
+	progress, err := manager.ActiveTournament(chatID)
+	if err != nil || progress != nil {
+		t.Fatalf("锦标赛结束后本群不应再有进行中的锦标赛: %+v（%v）", progress, err)
+	}
+	finished, err := db.GetTournaments(models.TournamentStatusFinished, 10)
+	if err != nil || len(finished) != 1 {
+		t.Fatalf("应有 1 场已结束的锦标赛: %d（%v）", len(finished), err)
+	}
+	detail, err := manager.TournamentDetail(finished[0].ID)
+	if err != nil {
+		t.Fatalf("获取锦标赛失败: %v", err)
+	}
+	result := detail.Tournament
+	if result.Round != 3 || result.WinnerID == nil {
+		t.Fatalf("锦标赛结果不符: %+v", result)
+	}
+
+	// 4 场对决淘汰 4 人，点数高者晋级
+	played, eliminated := 0, 0
+	for _, match := range detail.Matches {
+		if match.Bye() {
+			continue
+		}
+		played++
+		total1, total2 := match.Totals()
+		if (total1 > total2) != (match.WinnerID == match.Player1ID) || total1 == total2 {
+			t.Errorf("对阵结果不符: %+v", match)
+		}
+	}
+	for _, entry := range detail.Entries {
+		if entry.EliminatedRound > 0 {
+			eliminated++
+		} else if entry.UserID != *result.WinnerID {
+			t.Errorf("只有冠军不应被淘汰: %+v", entry)
+		}
+	}
+	if played != 4 || eliminated != 4 {
+		t.Errorf("应进行 4 场对决淘汰 4 人，实际 %d 场、%d 人", played, eliminated)
+	}
+
+	pool := utils.Coins(50)
+	commission := utils.CalculateCommission(pool, 0.05)
+	if result.Commission != commission || result.FundPrize != fundPrize || result.Prize != pool-commission+fundPrize {
+		t.Errorf("奖金应为扣除手续费后的报名费加群组比赛奖池: 奖金 %d，群组奖池 %d，手续费 %d", result.Prize, result.FundPrize, result.Commission)
+	}
+	if fund, prizePool, _ := db.GetChatFund(chatID); prizePool != 0 || fund != commission {
+		t.Errorf("比赛奖池应已发放，锦标赛手续费计入群组基金: 基金 %d，奖池 %d", fund, prizePool)
+	}
+	var total int64
+	for id := int64(1); id <= 5; id++ {
+		user, _ := db.GetUser(id)
+		total += user.Balance
+		if id == *result.WinnerID && user.Balance != utils.Coins(90)+result.Prize {
+			t.Errorf("冠军应获得奖金，余额 %d", user.Balance)
+		}
+	}
+	if total != utils.Coins(500)-commission+fundPrize {
+		t.Errorf("玩家余额合计应只减少手续费并增加群组奖池，实际 %d", total)
+	}
+
+	// 报名截止时不足 2 人，取消并退还报名费
+	if _, err := manager.OpenTournament(99, chatID, utils.Coins(10), 4, time.Minute); err != nil {
+		t.Fatalf("开设锦标赛失败: %v", err)
+	}
+	before, _ := db.GetUser(1)
+	if _, _, err := manager.EnterTournament(chatID, 1); err != nil {
+		t.Fatalf("报名失败: %v", err)
+	}
+	client.Reset()
+	scheduler.Run(later.Add(time.Hour))
+	if sent := client.SentMessages(); len(sent) != 1 || !strings.Contains(sent[0].(tgbotapi.MessageConfig).Text, "报名人数不足") {
+		t.Errorf("人数不足时应取消并公告: %+v", sent)
+	}
+	if user, _ := db.GetUser(1); user.Balance != before.Balance {
+		t.Errorf("取消后应退还报名费，余额 %d，应为 %d", user.Balance, before.Balance)
+	}
+
+	if rounds := game.TournamentRounds(5); rounds != 3 || ui.TournamentRoundName(3, rounds) != "决赛" {
+		t.Errorf("5 人锦标赛应为 3 轮，实际 %d", rounds)
+	}
+}
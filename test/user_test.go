@@ -0,0 +1,135 @@
+package test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/middleware"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/telegram"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// firstContacts 同一新用户同时发来的请求数
+const firstContacts = 20
+
+// TestEnsureUserConcurrentFirstContact 新用户的并发请求同时创建用户时只有一个创建成功，其余均不报错
+func TestEnsureUserConcurrentFirstContact(t *testing.T) {
+	db, err := database.Init(t.TempDir() + "/users.db")
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	var created int32
+	var wg sync.WaitGroup
+	errs := make(chan error, firstContacts)
+	for i := 0; i < firstContacts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			user := &models.User{ID: 3001, Username: "newbie", FirstName: "New"}
+			ok, err := db.EnsureUser(user)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if ok {
+				atomic.AddInt32(&created, 1)
+			}
+			if user.ID != 3001 || user.Username != "newbie" {
+				t.Errorf("返回的用户记录不正确: %+v", user)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Errorf("并发创建用户失败: %v", err)
+	}
+	if created != 1 {
+		t.Errorf("应恰好创建一次用户，实际 %d 次", created)
+	}
+	if user, err := db.GetUser(3001); err != nil || user == nil {
+		t.Fatalf("用户未创建: %v", err)
+	}
+}
+
+// TestEnsureUserKeepsExisting 用户已存在时不覆盖余额等数据
+func TestEnsureUserKeepsExisting(t *testing.T) {
+	db, err := database.Init(t.TempDir() + "/users.db")
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.CreateUser(&models.User{ID: 3002, Username: "old", Balance: 500}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+
+	user := &models.User{ID: 3002, Username: "renamed"}
+	created, err := db.EnsureUser(user)
+	if err != nil {
+		t.Fatalf("确保用户存在失败: %v", err)
+	}
+	if created {
+		t.Error("已存在的用户不应再次创建")
+	}
+	if user.Balance != 500 || user.Username != "old" {
+		t.Errorf("应返回已有的用户记录，实际 %+v", user)
+	}
+}
+
+// TestEnsureUserMiddlewareConcurrentFirstContact 新用户同时发来多条命令时每条都能正常处理
+func TestEnsureUserMiddlewareConcurrentFirstContact(t *testing.T) {
+	db, err := database.Init(t.TempDir() + "/users.db")
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	load := func(from *tgbotapi.User) (*models.User, error) {
+		user := &models.User{ID: from.ID, Username: from.UserName, FirstName: from.FirstName}
+		if _, err := db.EnsureUser(user); err != nil {
+			return nil, err
+		}
+		return user, nil
+	}
+
+	var handled int32
+	router := middleware.NewRouter(telegram.NewFakeClient(), middleware.EnsureUser(load))
+	router.Handle("balance", func(ctx *middleware.Context) error {
+		if ctx.User == nil || ctx.User.ID != ctx.UserID {
+			t.Errorf("上下文中的用户不正确: %+v", ctx.User)
+		}
+		atomic.AddInt32(&handled, 1)
+		return nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < firstContacts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			update := &tgbotapi.Update{Message: &tgbotapi.Message{
+				MessageID: i + 1,
+				From:      &tgbotapi.User{ID: 3003, UserName: "racer"},
+				Chat:      &tgbotapi.Chat{ID: -100, Type: "group"},
+				Text:      "/balance",
+				Entities:  []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 8}},
+			}}
+			if _, err := router.Dispatch(update); err != nil {
+				t.Errorf("处理第 %d 条命令失败: %v", i+1, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if handled != firstContacts {
+		t.Errorf("应处理 %d 条命令，实际 %d 条", firstContacts, handled)
+	}
+}
@@ -0,0 +1,81 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/userimport"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestUserImport 从 CSV 导入用户：合法的行创建用户并记录迁移交易，错误的行逐行报告，已存在的用户不被修改
+func TestUserImport(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "import.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.CreateUser(&models.User{ID: 500, Username: "existing", Balance: utils.Coins(7)}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+
+	csv := strings.Join([]string{
+		"telegram_id,username,balance",
+		"101,@alice_01,12.50",
+		"102,,0",
+		"",
+		"abc,bob_02,5",
+		"103,bob_02,-5",
+		"104,x,5",
+		"101,alice_dup,1",
+		"500,existing,99",
+		"105,carol_03",
+	}, "\n")
+
+	preview, err := userimport.Import(db, strings.NewReader(csv), true)
+	if err != nil {
+		t.Fatalf("试运行失败: %v", err)
+	}
+	if preview.Imported != 2 || len(preview.Errors) != 6 {
+		t.Fatalf("试运行结果不符: %s", preview)
+	}
+	if user, _ := db.GetUser(101); user != nil {
+		t.Fatal("试运行不应写入用户")
+	}
+
+	report, err := userimport.Import(db, strings.NewReader(csv), false)
+	if err != nil {
+		t.Fatalf("导入失败: %v", err)
+	}
+	if report.Total != 8 || report.Imported != 2 || report.Balance != 1250 {
+		t.Fatalf("导入报告不符: %s", report)
+	}
+	wantLines := []int{5, 6, 7, 8, 9, 10}
+	for i, rowErr := range report.Errors {
+		if rowErr.Line != wantLines[i] {
+			t.Errorf("第 %d 个错误的行号应为 %d，实际 %d（%s）", i, wantLines[i], rowErr.Line, rowErr.Message)
+		}
+	}
+	if report.Errors[4].TelegramID != 500 || !strings.Contains(report.Errors[4].Message, "已存在") {
+		t.Errorf("已存在的用户应报告失败: %+v", report.Errors[4])
+	}
+
+	alice, _ := db.GetUser(101)
+	if alice == nil || alice.Username != "alice_01" || alice.Balance != 1250 || alice.Source != userimport.Source {
+		t.Fatalf("导入的用户不符: %+v", alice)
+	}
+	transactions, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: 101, Type: models.TransactionTypeMigration}, "", 10)
+	if err != nil || len(transactions) != 1 || transactions[0].Amount != 1250 {
+		t.Errorf("应记录 1 笔迁移交易: %+v（%v）", transactions, err)
+	}
+	if transactions, _, _ := db.SearchTransactions(&models.TransactionFilter{UserID: 102}, "", 10); len(transactions) != 0 {
+		t.Errorf("零余额不应记录交易: %+v", transactions)
+	}
+	if existing, _ := db.GetUser(500); existing == nil || existing.Balance != utils.Coins(7) {
+		t.Errorf("已存在的用户不应被修改: %+v", existing)
+	}
+}
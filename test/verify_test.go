@@ -0,0 +1,100 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/logger"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestVerifyGame Telegram 骰子开出的对局核对结算点数与骰子消息，按种子生成的对局按种子重算核对
+func TestVerifyGame(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "verify.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 2; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(100)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	cfg := &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}
+	manager := game.NewManager(db, cfg, 0.05)
+	manager.SetOperationInterval(0)
+
+	chatID := int64(-1073)
+	play := func(recorded []int, dice ...int) string {
+		t.Helper()
+		gameID, err := manager.CreateGame(1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if v, err := manager.VerifyGame(gameID); err != nil || v.Revealed {
+			t.Fatalf("对局结束前不应公开种子: %+v（%v）", v, err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		for i, value := range recorded {
+			playerID := int64(1 + i/3)
+			manager.RecordDiceRoll(gameID, chatID, playerID, 100+i, value, time.Now())
+		}
+		if _, err := manager.PlayGameWithDiceResults(gameID, dice[0], dice[1], dice[2], dice[3], dice[4], dice[5]); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+		return gameID
+	}
+
+	// Telegram 骰子：种子不决定点数，核对结算点数与骰子消息
+	matched := play([]int{6, 5, 4, 1, 2, 3}, 6, 5, 4, 1, 2, 3)
+	v, err := manager.VerifyGame(matched)
+	if err != nil {
+		t.Fatalf("验证对局失败: %v", err)
+	}
+	if !v.Revealed || !v.HashValid || v.DiceSource != models.DiceSourceTelegram || !v.DiceMatch {
+		t.Errorf("骰子消息一致的对局应核对通过: %+v", v)
+	}
+	if text := ui.FormatGameVerification(v); !strings.Contains(text, "与群内骰子消息一致") || !strings.Contains(text, "点数不由种子决定") {
+		t.Errorf("审计结果应说明骰子来源:\n%s", text)
+	}
+
+	tampered := play([]int{1, 1, 1, 6, 6, 6}, 6, 6, 6, 1, 1, 1)
+	if v, err := manager.VerifyGame(tampered); err != nil || v.DiceSource != models.DiceSourceTelegram || v.DiceMatch {
+		t.Errorf("结算点数与骰子消息不符时应核对失败: %+v（%v）", v, err)
+	}
+
+	// 按种子生成：加入即自动结算的对局可按公开的种子重算
+	log, err := logger.NewLogger("test")
+	if err != nil {
+		t.Fatalf("初始化日志失败: %v", err)
+	}
+	enhanced := game.NewEnhancedManager(db, cfg, 0.05, log)
+	enhanced.SetOperationInterval(0)
+	gameID, err := enhanced.CreateGameSecure(1, chatID, utils.Coins(10))
+	if err != nil {
+		t.Fatalf("发起对局失败: %v", err)
+	}
+	if _, err := enhanced.JoinGameSecure(gameID, 2); err != nil {
+		t.Fatalf("加入对局失败: %v", err)
+	}
+	v, err = manager.VerifyGame(gameID)
+	if err != nil {
+		t.Fatalf("验证对局失败: %v", err)
+	}
+	if v.DiceSource != models.DiceSourceSeed || !v.DiceMatch || v.SeedDice1 != v.Dice1 || v.SeedDice2 != v.Dice2 {
+		t.Errorf("按种子生成的对局应与种子重算结果一致: %+v", v)
+	}
+	if text := ui.FormatGameVerification(v); !strings.Contains(text, "与种子重算结果一致") {
+		t.Errorf("审计结果应包含种子重算核对:\n%s", text)
+	}
+}
@@ -0,0 +1,102 @@
+package test
+
+import (
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestWatchdogAbortRefund 开骰后超过最长时长仍未结算的对局被中止：双方各退还一笔下注，余额恢复原样，
+// 迟到的骰子结果不再结算；最长时长内结算的对局不受影响
+func TestWatchdogAbortRefund(t *testing.T) {
+	f := newDrawRaceFixture(t, "watchdog_abort")
+	aborted := make(chan *models.Game, 1)
+	f.manager.SetGameAbortedCallback(func(game *models.Game, reason string) {
+		if reason != models.GameAbortStalled {
+			t.Errorf("中止原因应为 %s，实际 %s", models.GameAbortStalled, reason)
+		}
+		aborted <- game
+	})
+	f.manager.SetMaxGameDuration(50 * time.Millisecond)
+
+	balance := func(userID int64) int64 {
+		t.Helper()
+		user, err := f.db.GetUser(userID)
+		if err != nil || user == nil {
+			t.Fatalf("读取用户失败: %v", err)
+		}
+		return user.Balance
+	}
+	rows := func(gameID, txType string) []*models.Transaction {
+		t.Helper()
+		rows, _, err := f.db.SearchTransactions(&models.TransactionFilter{GameID: gameID, Type: txType}, "", 10)
+		if err != nil {
+			t.Fatalf("查询交易失败: %v", err)
+		}
+		return rows
+	}
+
+	// 开骰后扣除双方下注，超时中止后全部退还
+	bet := utils.Coins(10)
+	gameID := f.playing(bet)
+	if b1, b2 := balance(1), balance(2); b1 != utils.Coins(990) || b2 != utils.Coins(990) {
+		t.Fatalf("开骰后应扣除双方下注，余额 %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+	if bets := rows(gameID, models.TransactionTypeBet); len(bets) != 2 {
+		t.Fatalf("开骰后应有 2 笔下注交易，实际 %d 笔", len(bets))
+	}
+
+	select {
+	case game := <-aborted:
+		if game.ID != gameID || game.Status != models.GameStatusCancelled {
+			t.Errorf("中止的对局不符: %+v", game)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("超过最长时长的对局应被中止")
+	}
+
+	if b1, b2 := balance(1), balance(2); b1 != utils.Coins(1000) || b2 != utils.Coins(1000) {
+		t.Errorf("中止后双方余额应恢复原样，实际 %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+	refunded := make(map[int64]int64)
+	for _, row := range rows(gameID, models.TransactionTypeRefund) {
+		refunded[row.UserID] += row.Amount
+	}
+	if len(refunded) != 2 || refunded[1] != bet || refunded[2] != bet {
+		t.Errorf("双方应各有一笔等额退款交易: %v", refunded)
+	}
+	if g, _ := f.db.GetGame(gameID); g == nil || g.Status != models.GameStatusCancelled || g.WinnerID != nil {
+		t.Errorf("中止的对局应为已取消且无胜者: %+v", g)
+	}
+
+	// 迟到的骰子结果不再结算，余额和交易记录不变
+	if _, err := f.manager.PlayGameWithDiceResults(gameID, 6, 6, 6, 1, 1, 1); err == nil {
+		t.Error("已中止的对局不应再结算")
+	}
+	if b1, b2 := balance(1), balance(2); b1 != utils.Coins(1000) || b2 != utils.Coins(1000) {
+		t.Errorf("迟到的结算不应改变余额，实际 %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+	if wins := rows(gameID, models.TransactionTypeWin); len(wins) != 0 {
+		t.Errorf("已中止的对局不应有派奖交易，实际 %d 笔", len(wins))
+	}
+
+	// 最长时长内结算的对局停止计时，不会被中止退款
+	settledID := f.playing(bet)
+	result, err := f.manager.PlayGameWithDiceResults(settledID, 6, 6, 6, 1, 1, 1)
+	if err != nil {
+		t.Fatalf("结算对局失败: %v", err)
+	}
+	select {
+	case game := <-aborted:
+		t.Fatalf("已结算的对局不应被中止: %s", game.ID)
+	case <-time.After(150 * time.Millisecond):
+	}
+	if n := len(rows(settledID, models.TransactionTypeRefund)); n != 0 {
+		t.Errorf("已结算的对局不应有退款交易，实际 %d 笔", n)
+	}
+	if b1, b2 := balance(1), balance(2); b1 != utils.Coins(990)+result.WinAmount || b2 != utils.Coins(990) {
+		t.Errorf("结算后余额不符: %s / %s", utils.FormatAmount(b1), utils.FormatAmount(b2))
+	}
+}
@@ -0,0 +1,107 @@
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"telegram-dice-bot/internal/config"
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/maintenance"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/report"
+	"telegram-dice-bot/internal/utils"
+)
+
+// TestWeeklySummary 每周战报：统计订阅用户上一周的对局并按语言写入发件箱，每周只发送一次
+func TestWeeklySummary(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "weekly.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	for id := int64(1); id <= 3; id++ {
+		if err := db.CreateUser(&models.User{ID: id, Username: "player", Balance: utils.Coins(1000)}); err != nil {
+			t.Fatalf("创建用户失败: %v", err)
+		}
+	}
+	manager := game.NewManager(db, &config.Config{MinBet: utils.Coins(1), MaxBet: utils.Coins(1000)}, 0.05)
+	manager.SetOperationInterval(0)
+
+	chatID := int64(-1005)
+	for _, dice := range [][2]int{{6, 1}, {3, 3}} {
+		gameID, err := manager.CreateGame(1, chatID, utils.Coins(10))
+		if err != nil {
+			t.Fatalf("发起对局失败: %v", err)
+		}
+		if _, err := manager.JoinGame(gameID, 2); err != nil {
+			t.Fatalf("加入对局失败: %v", err)
+		}
+		p1, p2 := dice[0], dice[1]
+		if _, err := manager.PlayGameWithDiceResults(gameID, p1, p1, p1, p2, p2, p2); err != nil {
+			t.Fatalf("结算对局失败: %v", err)
+		}
+	}
+
+	// 下周生成战报时，本周的对局落在统计区间内
+	since, until := report.WeekBounds(time.Now().AddDate(0, 0, 7))
+	if until.Weekday() != time.Monday || until.Sub(since) != 7*24*time.Hour {
+		t.Fatalf("统计区间应为周一至下周一: %v - %v", since, until)
+	}
+
+	summary, err := db.GetWeeklySummary(1, since, until)
+	if err != nil {
+		t.Fatalf("统计每周战报失败: %v", err)
+	}
+	if summary.Games != 2 || summary.Wins != 1 || summary.Draws != 1 || summary.Losses != 0 {
+		t.Errorf("胜负统计不符: %+v", summary)
+	}
+	if summary.NetProfit != utils.Coins(9) || summary.BestWin != utils.Coins(9) || summary.Fees != utils.Coins(1) {
+		t.Errorf("盈亏统计不符: 净盈亏 %d，最佳 %d，手续费 %d", summary.NetProfit, summary.BestWin, summary.Fees)
+	}
+	if summary.Rank != 1 || summary.Players != 2 {
+		t.Errorf("排名不符: 第 %d 名，共 %d 人", summary.Rank, summary.Players)
+	}
+	if loser, _ := db.GetWeeklySummary(2, since, until); loser == nil || loser.Rank != 2 || loser.NetProfit != -utils.Coins(10) {
+		t.Errorf("输家战报不符: %+v", loser)
+	}
+
+	// 用户 1 以英文订阅，用户 2 以中文订阅，用户 3 订阅但没有对局，不发送
+	for id, lang := range map[int64]string{1: "en", 2: "zh", 3: "zh"} {
+		if err := db.SetUserWeeklySummary(id, true, lang); err != nil {
+			t.Fatalf("订阅每周战报失败: %v", err)
+		}
+	}
+
+	weekly := report.NewWeekly(db, maintenance.Window{}, nil)
+	queued, err := weekly.Run(time.Now().AddDate(0, 0, 7))
+	if err != nil || queued != 2 {
+		t.Fatalf("应写入 2 份战报，实际 %d（%v）", queued, err)
+	}
+
+	due, err := db.GetDueOutbox(time.Now().Add(time.Hour), 10)
+	if err != nil || len(due) != 2 {
+		t.Fatalf("发件箱应有 2 条私信，实际 %d（%v）", len(due), err)
+	}
+	texts := make(map[int64]string)
+	for _, message := range due {
+		texts[message.ChatID] = message.Text
+	}
+	if !strings.Contains(texts[1], "Weekly summary") || !strings.Contains(texts[1], "#1 of 2") {
+		t.Errorf("英文战报不符: %q", texts[1])
+	}
+	if !strings.Contains(texts[2], "每周战报") || !strings.Contains(texts[2], "第 2 名") {
+		t.Errorf("中文战报不符: %q", texts[2])
+	}
+
+	// 同一周再次生成不重复发送
+	if queued, err := weekly.Run(time.Now().AddDate(0, 0, 7)); err != nil || queued != 0 {
+		t.Errorf("同一周不应重复发送，实际 %d（%v）", queued, err)
+	}
+	if recipients, _ := db.GetWeeklySummaryRecipients(until); len(recipients) != 0 {
+		t.Errorf("本周已处理的订阅用户不应再出现，实际 %d 人", len(recipients))
+	}
+}
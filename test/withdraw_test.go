@@ -0,0 +1,144 @@
+package test
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/models"
+	"telegram-dice-bot/internal/utils"
+	"telegram-dice-bot/internal/withdraw"
+)
+
+// TestWithdrawalFreezeAndReview 提现申请冻结余额，拒绝时退回，批准后不再退回
+func TestWithdrawalFreezeAndReview(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "withdraw.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	userID := int64(4001)
+	if err := db.CreateUser(&models.User{ID: userID, Username: "withdrawer", Balance: utils.Coins(5000)}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	manager := withdraw.NewManager(db, utils.Coins(1000), 10)
+	address := "TXYZopqrstuvwxyzABCDEFGHJKLMNPQRST"
+
+	if _, _, err := manager.Request(userID, utils.Coins(500), address); err == nil {
+		t.Error("低于最低金额的提现应被拒绝")
+	}
+	if _, _, err := manager.Request(userID, utils.Coins(1000), "not-an-address"); err == nil {
+		t.Error("地址格式错误的提现应被拒绝")
+	}
+	if _, _, err := manager.Request(userID, utils.Coins(6000), address); err == nil {
+		t.Error("余额不足的提现应被拒绝")
+	}
+
+	withdrawal, balance, err := manager.Request(userID, utils.Coins(2000), address)
+	if err != nil {
+		t.Fatalf("提交提现申请失败: %v", err)
+	}
+	if balance != utils.Coins(3000) || withdrawal.USDTAmount != 200 {
+		t.Errorf("应冻结 2000 金币并折算 200 USDT，实际余额 %s，USDT %.2f", utils.FormatAmount(balance), withdrawal.USDTAmount)
+	}
+	if _, _, err := manager.Request(userID, utils.Coins(1000), address); !errors.Is(err, database.ErrWithdrawalPending) {
+		t.Errorf("已有待审核申请时应拒绝新申请，实际: %v", err)
+	}
+
+	rejected, err := manager.Reject(withdrawal.ID, "admin", "地址有误")
+	if err != nil {
+		t.Fatalf("拒绝提现申请失败: %v", err)
+	}
+	if rejected.Status != models.WithdrawalStatusRejected || rejected.ReviewedAt == nil {
+		t.Errorf("申请应为已拒绝，实际: %+v", rejected)
+	}
+	if user, _ := db.GetUser(userID); user.Balance != utils.Coins(5000) {
+		t.Errorf("拒绝后应退回冻结金额，实际余额 %s", utils.FormatAmount(user.Balance))
+	}
+	if _, err := manager.Reject(withdrawal.ID, "admin", ""); !errors.Is(err, database.ErrWithdrawalNotPending) {
+		t.Errorf("重复审核应报错，实际: %v", err)
+	}
+
+	withdrawal, _, err = manager.Request(userID, utils.Coins(1000), address)
+	if err != nil {
+		t.Fatalf("提交提现申请失败: %v", err)
+	}
+	approved, err := manager.Approve(withdrawal.ID, "admin", "abc123")
+	if err != nil {
+		t.Fatalf("批准提现申请失败: %v", err)
+	}
+	if approved.Status != models.WithdrawalStatusApproved || approved.TxHash != "abc123" {
+		t.Errorf("申请应为已批准并记录交易哈希，实际: %+v", approved)
+	}
+	if _, err := manager.Reject(withdrawal.ID, "admin", ""); !errors.Is(err, database.ErrWithdrawalNotPending) {
+		t.Errorf("已批准的申请不能再拒绝，实际: %v", err)
+	}
+	if user, _ := db.GetUser(userID); user.Balance != utils.Coins(4000) {
+		t.Errorf("批准后冻结金额不再退回，实际余额 %s", utils.FormatAmount(user.Balance))
+	}
+
+	pending, err := manager.Withdrawals(models.WithdrawalStatusPending, 10)
+	if err != nil || len(pending) != 0 {
+		t.Errorf("不应有待审核申请，实际 %d 条，错误: %v", len(pending), err)
+	}
+	history, err := manager.UserWithdrawals(userID, 10)
+	if err != nil || len(history) != 2 {
+		t.Errorf("用户应有 2 条提现记录，实际 %d 条，错误: %v", len(history), err)
+	}
+}
+
+// TestWithdrawalConcurrentRequests 同一用户并发提交提现只有一笔成功，其余返回 ErrWithdrawalPending，余额只冻结一次
+func TestWithdrawalConcurrentRequests(t *testing.T) {
+	db, err := database.Init(filepath.Join(t.TempDir(), "withdraw_concurrent.db"))
+	if err != nil {
+		t.Fatalf("初始化数据库失败: %v", err)
+	}
+	defer db.Close()
+
+	userID := int64(4002)
+	if err := db.CreateUser(&models.User{ID: userID, Username: "withdrawer", Balance: utils.Coins(10000)}); err != nil {
+		t.Fatalf("创建用户失败: %v", err)
+	}
+	manager := withdraw.NewManager(db, utils.Coins(1000), 10)
+	address := "TXYZopqrstuvwxyzABCDEFGHJKLMNPQRST"
+
+	const requests = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, requests)
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := manager.Request(userID, utils.Coins(1000), address)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case !errors.Is(err, database.ErrWithdrawalPending):
+			t.Errorf("并发提交应返回 ErrWithdrawalPending，实际: %v", err)
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("应恰好一笔提现申请成功，实际 %d 笔", succeeded)
+	}
+	if user, _ := db.GetUser(userID); user.Balance != utils.Coins(9000) {
+		t.Errorf("余额只应冻结一次，实际余额 %s", utils.FormatAmount(user.Balance))
+	}
+	if pending, err := manager.Withdrawals(models.WithdrawalStatusPending, 10); err != nil || len(pending) != 1 {
+		t.Errorf("应只有 1 笔待审核申请，实际 %d 笔（%v）", len(pending), err)
+	}
+	rows, _, err := db.SearchTransactions(&models.TransactionFilter{UserID: userID, Type: models.TransactionTypeWithdraw}, "", 20)
+	if err != nil || len(rows) != 1 {
+		t.Errorf("应只有 1 笔提现冻结交易，实际 %d 笔（%v）", len(rows), err)
+	}
+}
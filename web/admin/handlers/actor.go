@@ -0,0 +1,41 @@
+package admin
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"telegram-dice-bot/internal/models"
+)
+
+// unknownAdmin 无法识别管理员身份时记录的操作者
+const unknownAdmin = "unknown"
+
+// adminActor 获取发起请求的管理员用户名，即当前登录会话的用户名
+func (h *AdminHandler) adminActor(r *http.Request) string {
+	if username := h.auth.Username(r); username != "" {
+		return username
+	}
+	return unknownAdmin
+}
+
+// recordAdminAction 记录管理员操作，失败只记日志不影响业务
+func (h *AdminHandler) recordAdminAction(r *http.Request, action, targetType, targetID string, details map[string]interface{}) {
+	detailJSON := ""
+	if details != nil {
+		if data, err := json.Marshal(details); err == nil {
+			detailJSON = string(data)
+		}
+	}
+
+	err := h.db.RecordAdminAction(&models.AdminAction{
+		Admin:      h.adminActor(r),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Details:    detailJSON,
+	})
+	if err != nil {
+		log.Printf("记录管理员操作失败: %v", err)
+	}
+}
@@ -2,36 +2,115 @@ package admin
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"telegram-dice-bot/internal/auth"
-	"telegram-dice-bot/internal/bot"
+	"telegram-dice-bot/internal/broadcast"
+	"telegram-dice-bot/internal/chatsettings"
 	"telegram-dice-bot/internal/database"
+	"telegram-dice-bot/internal/deadletter"
 	"telegram-dice-bot/internal/game"
+	"telegram-dice-bot/internal/maintenance"
 	"telegram-dice-bot/internal/models"
-
-	"github.com/gorilla/mux"
+	"telegram-dice-bot/internal/reconcile"
+	"telegram-dice-bot/internal/risk"
+	"telegram-dice-bot/internal/ui"
+	"telegram-dice-bot/internal/uptime"
+	"telegram-dice-bot/internal/userimport"
+	"telegram-dice-bot/internal/utils"
+	"telegram-dice-bot/internal/withdraw"
 )
 
 type AdminHandler struct {
 	db          *database.DB
 	gameManager *game.Manager
-	bot         *bot.Bot
 	templates   *template.Template
+	// 管理后台登录认证，操作记录中的管理员即当前会话的用户名
+	auth *auth.Authenticator
+	// 数据库维护调度器，未设置时维护接口不可用
+	maintenance *maintenance.Scheduler
+	// 死信队列，未设置时失败任务接口不可用
+	deadLetters *deadletter.Queue
+	// 可用性记录器，未设置时仪表板不显示 SLA
+	uptime *uptime.Tracker
+	// 提现管理器，未设置时提现审核接口不可用
+	withdrawals *withdraw.Manager
+	// 余额对账任务，未设置时不能手动触发对账
+	reconciler *reconcile.Reconciler
+	// 风控引擎，未设置时风控事件列表不附带规则配置
+	risk *risk.Engine
+	// 公告发送器，未设置时只能查看公告不能创建
+	broadcaster *broadcast.Broadcaster
 }
 
-func NewAdminHandler(db *database.DB, gameManager *game.Manager, bot *bot.Bot) *AdminHandler {
-	// 解析模板
-	templates := template.Must(template.ParseGlob("web/admin/templates/*.html"))
+func NewAdminHandler(db *database.DB, gameManager *game.Manager, authenticator *auth.Authenticator) *AdminHandler {
+	// 解析模板，模板缺失时页面返回错误，JSON 接口不受影响
+	templates, err := template.ParseGlob("web/admin/templates/*.html")
+	if err != nil {
+		log.Printf("⚠️ 加载管理后台模板失败: %v", err)
+	}
 
 	return &AdminHandler{
 		db:          db,
 		gameManager: gameManager,
-		bot:         bot,
 		templates:   templates,
+		auth:        authenticator,
+	}
+}
+
+// SetMaintenance 设置数据库维护调度器，用于手动触发维护和输出数据库指标
+func (h *AdminHandler) SetMaintenance(scheduler *maintenance.Scheduler) {
+	h.maintenance = scheduler
+}
+
+// SetDeadLetters 设置死信队列，用于查看和重放失败任务
+func (h *AdminHandler) SetDeadLetters(queue *deadletter.Queue) {
+	h.deadLetters = queue
+}
+
+// SetUptime 设置可用性记录器，用于在仪表板显示本月 SLA
+func (h *AdminHandler) SetUptime(tracker *uptime.Tracker) {
+	h.uptime = tracker
+}
+
+// SetWithdrawals 设置提现管理器，用于审核用户的提现申请
+func (h *AdminHandler) SetWithdrawals(manager *withdraw.Manager) {
+	h.withdrawals = manager
+}
+
+// SetReconciler 设置余额对账任务，用于手动触发对账
+func (h *AdminHandler) SetReconciler(reconciler *reconcile.Reconciler) {
+	h.reconciler = reconciler
+}
+
+// SetRiskEngine 设置风控引擎，用于在风控事件列表中展示规则配置
+func (h *AdminHandler) SetRiskEngine(engine *risk.Engine) {
+	h.risk = engine
+}
+
+// SetBroadcaster 设置公告发送器，用于创建和取消公告
+func (h *AdminHandler) SetBroadcaster(broadcaster *broadcast.Broadcaster) {
+	h.broadcaster = broadcaster
+}
+
+// render 渲染页面模板，模板未加载或渲染失败时返回 500
+func (h *AdminHandler) render(w http.ResponseWriter, name string, data interface{}) {
+	if h.templates == nil {
+		http.Error(w, "管理后台模板未加载", http.StatusInternalServerError)
+		return
+	}
+	if err := h.templates.ExecuteTemplate(w, name, data); err != nil {
+		log.Printf("渲染模板 %s 失败: %v", name, err)
+		http.Error(w, "模板渲染失败: "+err.Error(), http.StatusInternalServerError)
 	}
 }
 
@@ -44,188 +123,3207 @@ func (h *AdminHandler) Dashboard(w http.ResponseWriter, r *http.Request) {
 	activeUsers, _ := h.db.GetActiveUsersCount()
 	todayGames, _ := h.db.GetTodayGamesCount()
 	totalRecharge, _ := h.db.GetTotalRechargeAmount()
+	acquisitionSources, _ := h.db.GetAcquisitionSources(10)
+	sandbox, _ := h.db.IsSandbox()
+
+	title := "仪表板"
+	if sandbox {
+		title = "仪表板（沙盒数据）"
+	}
 
 	data := map[string]interface{}{
-		"Title": "仪表板",
+		"Title":   title,
+		"Sandbox": sandbox,
 		"Stats": map[string]interface{}{
 			"total_users":    totalUsers,
 			"active_users":   activeUsers,
 			"total_games":    todayGames,
-			"total_recharge": float64(totalRecharge) / 100, // 转换为元
+			"total_recharge": utils.AmountToFloat(totalRecharge), // 转换为金币
 			"update_time":    "刚刚",
 		},
+		"AcquisitionSources": acquisitionSources,
+		"GameMetrics":        h.gameManager.Metrics().Snapshot(),
+	}
+	if h.uptime != nil {
+		if report, err := h.uptime.MonthlyReport(time.Now()); err != nil {
+			log.Printf("Dashboard uptime error: %v", err)
+		} else {
+			data["Uptime"] = report
+		}
+	}
+
+	log.Printf("Dashboard data: %+v", data)
+
+	h.render(w, "layout", data)
+}
+
+// Users 用户管理页面
+func (h *AdminHandler) Users(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit := 20
+	offset := (page - 1) * limit
+
+	users, err := h.db.GetUsersWithPagination(offset, limit)
+	if err != nil {
+		http.Error(w, "获取用户数据失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	totalUsers, _ := h.db.GetTotalUsersCount()
+	totalPages := (totalUsers + limit - 1) / limit
+
+	data := map[string]interface{}{
+		"Title":      "用户管理",
+		"Users":      users,
+		"Page":       page,
+		"TotalPages": totalPages,
+		"HasPrev":    page > 1,
+		"HasNext":    page < totalPages,
+		"PrevPage":   page - 1,
+		"NextPage":   page + 1,
+	}
+
+	h.render(w, "users.html", data)
+}
+
+// Games 游戏记录页面
+func (h *AdminHandler) Games(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit := 20
+	offset := (page - 1) * limit
+
+	games, err := h.db.GetGamesWithPagination(offset, limit)
+	if err != nil {
+		http.Error(w, "获取游戏数据失败", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title": "游戏记录",
+		"Games": games,
+		"Page":  page,
+	}
+
+	h.render(w, "games.html", data)
+}
+
+// Operations 运营监控页面，页面定时刷新 APIOperations 并可强制中止对局
+func (h *AdminHandler) Operations(w http.ResponseWriter, r *http.Request) {
+	chats, err := h.operations()
+	if err != nil {
+		http.Error(w, "获取进行中的对局失败", http.StatusInternalServerError)
+		return
+	}
+
+	data := map[string]interface{}{
+		"Title": "运营监控",
+		"Chats": chats,
+	}
+
+	h.render(w, "operations.html", data)
+}
+
+// Recharges 充值记录页面
+func (h *AdminHandler) Recharges(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	limit := 20
+	offset := (page - 1) * limit
+
+	recharges, err := h.db.GetRechargesWithPagination(offset, limit)
+	if err != nil {
+		http.Error(w, "获取充值数据失败", http.StatusInternalServerError)
+		return
+	}
+
+	totalRecharges, _ := h.db.GetTotalRechargesCount()
+	totalPages := (totalRecharges + limit - 1) / limit
+
+	data := map[string]interface{}{
+		"Title":      "充值记录",
+		"Recharges":  recharges,
+		"Page":       page,
+		"TotalPages": totalPages,
+		"HasPrev":    page > 1,
+		"HasNext":    page < totalPages,
+		"PrevPage":   page - 1,
+		"NextPage":   page + 1,
+	}
+
+	h.render(w, "recharges.html", data)
+}
+
+// Transactions 交易记录查询页面
+func (h *AdminHandler) Transactions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cursor := r.URL.Query().Get("cursor")
+	transactions, nextCursor, err := h.db.SearchTransactions(filter, cursor, 50)
+	if err != nil {
+		http.Error(w, "获取交易记录失败: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	totals, _ := h.db.SumTransactions(filter)
+
+	data := map[string]interface{}{
+		"Title":        "交易记录",
+		"Transactions": transactions,
+		"Totals":       totals,
+		"Query":        r.URL.Query(),
+		"NextCursor":   nextCursor,
+		"HasNext":      nextCursor != "",
+	}
+
+	h.render(w, "transactions.html", data)
+}
+
+// API接口
+
+// APIStats 获取统计数据
+func (h *AdminHandler) APIStats(w http.ResponseWriter, r *http.Request) {
+	totalUsers, _ := h.db.GetTotalUsersCount()
+	activeUsers, _ := h.db.GetActiveUsersCount()
+	todayGames, _ := h.db.GetTodayGamesCount()
+	totalRecharge, _ := h.db.GetTotalRechargeAmount()
+	// 沙盒数据库中的余额和充值均为练习数据
+	sandbox, _ := h.db.IsSandbox()
+
+	stats := map[string]interface{}{
+		"sandbox":       sandbox,
+		"totalUsers":    totalUsers,
+		"activeUsers":   activeUsers,
+		"todayGames":    todayGames,
+		"totalRecharge": utils.AmountToFloat(totalRecharge),
+		"gameMetrics":   h.gameManager.Metrics().Snapshot(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// PrometheusMetrics 以 Prometheus 文本格式输出游戏流程指标
+func (h *AdminHandler) PrometheusMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := h.gameManager.Metrics().WritePrometheus(w); err != nil {
+		log.Printf("输出Prometheus指标失败: %v", err)
+	}
+	if h.maintenance != nil {
+		if err := h.maintenance.WritePrometheus(w); err != nil {
+			log.Printf("输出数据库指标失败: %v", err)
+		}
+	}
+}
+
+// APIChatLoads 获取各群组负载及容量使用情况，用于容量规划
+func (h *AdminHandler) APIChatLoads(w http.ResponseWriter, r *http.Request) {
+	hours, _ := strconv.Atoi(r.URL.Query().Get("hours"))
+	if hours < 1 || hours > 24*30 {
+		hours = 24
+	}
+
+	loads, err := h.db.GetChatLoads(time.Now().Add(-time.Duration(hours)*time.Hour), 100)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取群组负载失败",
+		})
+		return
+	}
+	counts, _ := h.db.CountChatsByServiceStatus()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"loads":    loads,
+			"active":   counts[models.ChatServiceActive],
+			"waitlist": counts[models.ChatServiceWaitlist],
+		},
+	})
+}
+
+// APIShardStatus 获取在线分片进程及重新分片状态
+func (h *AdminHandler) APIShardStatus(w http.ResponseWriter, r *http.Request) {
+	nodes, err := h.db.GetShardNodes(time.Now().Add(-30 * time.Second))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取分片状态失败",
+		})
+		return
+	}
+
+	// 所有进程的分片总数一致且每个分片都有进程认领时才算正常
+	shardCount := 0
+	rebalancing := false
+	covered := make(map[int]bool)
+	for _, node := range nodes {
+		if shardCount == 0 {
+			shardCount = node.ShardCount
+		} else if node.ShardCount != shardCount {
+			rebalancing = true
+		}
+		covered[node.ShardID] = true
+	}
+	var missing []int
+	for i := 0; i < shardCount; i++ {
+		if !covered[i] {
+			missing = append(missing, i)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"nodes":          nodes,
+			"shard_count":    shardCount,
+			"rebalancing":    rebalancing,
+			"missing_shards": missing,
+		},
+	})
+}
+
+// APIMaintenanceStatus 获取数据库大小及最近一次维护的结果
+func (h *AdminHandler) APIMaintenanceStatus(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.maintenance == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "数据库维护未启用",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    h.maintenance.Status(),
+	})
+}
+
+// APIUptime 按月统计的可用性和停机时段，month 格式为 2006-01，默认当月
+func (h *AdminHandler) APIUptime(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.uptime == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "可用性记录未启用",
+		})
+		return
+	}
+
+	month := time.Now()
+	if value := r.URL.Query().Get("month"); value != "" {
+		parsed, err := time.ParseInLocation("2006-01", value, time.Local)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "无效的月份，格式为 2006-01",
+			})
+			return
+		}
+		month = parsed
+	}
+
+	report, err := h.uptime.MonthlyReport(month)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取可用性统计失败",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// APIRunMaintenance 手动执行一次数据库维护（WAL检查点、增量VACUUM、ANALYZE）
+func (h *AdminHandler) APIRunMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.maintenance == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "数据库维护未启用",
+		})
+		return
+	}
+
+	run, err := h.maintenance.Run("manual")
+	if errors.Is(err, maintenance.ErrRunning) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "数据库维护失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "run_db_maintenance", "database", "main", map[string]interface{}{
+		"size_before": run.SizeBefore,
+		"size_after":  run.SizeAfter,
+		"freed_pages": run.FreedPages,
+		"duration_ms": run.Duration.Milliseconds(),
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "数据库维护完成",
+		"data":    run,
+	})
+}
+
+// APISettlementHold 获取自动结算暂停状态和等待审核的对局
+func (h *AdminHandler) APISettlementHold(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	until, held, err := h.gameManager.SettlementHoldUntil()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取结算暂停状态失败",
+		})
+		return
+	}
+
+	games, err := h.gameManager.HeldSettlements(200)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取待审核对局失败",
+		})
+		return
+	}
+
+	data := map[string]interface{}{
+		"held":    held,
+		"pending": games,
+	}
+	if held {
+		data["until"] = until
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// APIUpdateSettlementHold 暂停自动结算 minutes 分钟，minutes 为 0 时立即恢复
+func (h *AdminHandler) APIUpdateSettlementHold(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req struct {
+		Minutes int `json:"minutes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Minutes < 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if req.Minutes == 0 {
+		if err := h.gameManager.ResumeSettlements(); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		h.recordAdminAction(r, "resume_settlements", "settlement", "global", nil)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"message": "自动结算已恢复",
+		})
+		return
+	}
+
+	until, err := h.gameManager.HoldSettlements(time.Duration(req.Minutes) * time.Minute)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "hold_settlements", "settlement", "global", map[string]interface{}{
+		"minutes": req.Minutes,
+		"until":   until,
+	})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("自动结算已暂停，将于 %s 自动恢复", until.Format("2006-01-02 15:04:05")),
+	})
+}
+
+// APIResolveHeldSettlement 审核暂停结算的对局：approve 按骰子结果派奖，refund 向双方退款
+func (h *AdminHandler) APIResolveHeldSettlement(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	gameID := r.PathValue("id")
+
+	var req struct {
+		Action string `json:"action"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	var err error
+	var message string
+	switch req.Action {
+	case "approve":
+		_, err = h.gameManager.ApproveHeldSettlement(gameID)
+		message = "对局已结算"
+	case "refund":
+		err = h.gameManager.RefundHeldSettlement(gameID)
+		message = "对局已退款"
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的审核操作",
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, req.Action+"_held_settlement", "game", gameID, nil)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+// APIDeadLetters 获取执行失败的后台任务，默认只显示待处理的任务
+func (h *AdminHandler) APIDeadLetters(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.deadLetters == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "死信队列未启用",
+		})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.DeadLetterPending
+	} else if status == "all" {
+		status = ""
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	letters, err := h.db.GetDeadLetters(status, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取失败任务失败",
+		})
+		return
+	}
+
+	items := make([]map[string]interface{}, len(letters))
+	for i, letter := range letters {
+		items[i] = map[string]interface{}{
+			"letter":     letter,
+			"replayable": h.deadLetters.Replayable(letter),
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    items,
+	})
+}
+
+// APIReplayDeadLetter 重新提交失败任务，action 为 discard 时放弃该任务
+func (h *AdminHandler) APIReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.deadLetters == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "死信队列未启用",
+		})
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的任务ID",
+		})
+		return
+	}
+
+	action, message := "replay_dead_letter", "任务已重新提交"
+	if r.URL.Query().Get("action") == "discard" {
+		action, message = "discard_dead_letter", "任务已放弃"
+		err = h.deadLetters.Discard(id)
+	} else {
+		err = h.deadLetters.Replay(id)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, action, "dead_letter", strconv.FormatInt(id, 10), nil)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+// APIGetWithdrawals 获取提现申请，默认只显示待审核的申请
+func (h *AdminHandler) APIGetWithdrawals(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.withdrawals == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "提现未启用",
+		})
+		return
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.WithdrawalStatusPending
+	} else if status == "all" {
+		status = ""
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	withdrawals, err := h.withdrawals.Withdrawals(status, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取提现申请失败",
+		})
+		return
+	}
+
+	items := make([]map[string]interface{}, len(withdrawals))
+	for i, withdrawal := range withdrawals {
+		username := "未知用户"
+		if user, _ := h.db.GetUser(withdrawal.UserID); user != nil {
+			username = user.FirstName
+			if user.Username != "" {
+				username = "@" + user.Username
+			}
+		}
+
+		items[i] = map[string]interface{}{
+			"id":          withdrawal.ID,
+			"user_id":     withdrawal.UserID,
+			"username":    username,
+			"amount":      utils.AmountToFloat(withdrawal.Amount), // 转换为金币
+			"usdt_amount": withdrawal.USDTAmount,
+			"address":     withdrawal.Address,
+			"status":      withdrawal.Status,
+			"reviewer":    withdrawal.Reviewer,
+			"tx_hash":     withdrawal.TxHash,
+			"reason":      withdrawal.Reason,
+			"created_at":  withdrawal.CreatedAt,
+			"reviewed_at": withdrawal.ReviewedAt,
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    items,
+	})
+}
+
+// APIReviewWithdrawal 审核提现申请：action 为 approve 时批准并记录转账哈希，为 reject 时拒绝并退回冻结金额
+func (h *AdminHandler) APIReviewWithdrawal(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.withdrawals == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "提现未启用",
+		})
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的提现申请ID",
+		})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+		TxHash string `json:"tx_hash"`
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	reviewer := h.adminActor(r)
+	var withdrawal *models.Withdrawal
+	var message string
+	switch req.Action {
+	case "approve":
+		withdrawal, err = h.withdrawals.Approve(id, reviewer, req.TxHash)
+		message = "提现申请已批准"
+	case "reject":
+		withdrawal, err = h.withdrawals.Reject(id, reviewer, req.Reason)
+		message = "提现申请已拒绝，金额已退回"
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "action 只能是 approve 或 reject",
+		})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrWithdrawalNotPending) {
+			status = http.StatusConflict
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, req.Action+"_withdrawal", "withdrawal", strconv.FormatInt(id, 10), map[string]interface{}{
+		"user_id": withdrawal.UserID,
+		"amount":  utils.AmountToFloat(withdrawal.Amount),
+		"tx_hash": withdrawal.TxHash,
+		"reason":  withdrawal.Reason,
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+// APIGetDisputes 获取对局争议，默认只返回待审核的争议，每条附带对局时间线
+func (h *AdminHandler) APIGetDisputes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.DisputeStatusOpen
+	} else if status == "all" {
+		status = ""
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	disputes, err := h.gameManager.Disputes(status, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取对局争议失败",
+		})
+		return
+	}
+
+	items := make([]map[string]interface{}, len(disputes))
+	for i, dispute := range disputes {
+		username := "未知用户"
+		if user, _ := h.db.GetUser(dispute.ReporterID); user != nil {
+			username = user.FirstName
+			if user.Username != "" {
+				username = "@" + user.Username
+			}
+		}
+
+		// 审核时对照的对局时间线文本，获取失败时留空
+		var timeline string
+		if t, err := h.db.GetGameTimeline(dispute.GameID); err == nil && t != nil {
+			timeline = ui.FormatGameTimeline(t)
+		}
+
+		items[i] = map[string]interface{}{
+			"id":          dispute.ID,
+			"game_id":     dispute.GameID,
+			"chat_id":     dispute.ChatID,
+			"reporter_id": dispute.ReporterID,
+			"reporter":    username,
+			"winner_id":   dispute.WinnerID,
+			"loser_id":    dispute.LoserID,
+			"bet_amount":  utils.AmountToFloat(dispute.BetAmount), // 转换为金币
+			"payout":      utils.AmountToFloat(dispute.Payout),
+			"held":        utils.AmountToFloat(dispute.Held),
+			"status":      dispute.Status,
+			"reviewer":    dispute.Reviewer,
+			"note":        dispute.Note,
+			"created_at":  dispute.CreatedAt,
+			"resolved_at": dispute.ResolvedAt,
+			"timeline":    timeline,
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    items,
+	})
+}
+
+// APIResolveDispute 审核对局争议：action 为 release 时维持原结果并退回冻结的派奖，为 reverse 时撤销结果并退还双方下注
+func (h *AdminHandler) APIResolveDispute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的争议ID",
+		})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	reviewer := h.adminActor(r)
+	note := strings.TrimSpace(req.Note)
+	var dispute *models.Dispute
+	var message string
+	switch req.Action {
+	case "release":
+		dispute, err = h.gameManager.ReleaseDispute(id, reviewer, note)
+		message = "已维持原结果，冻结的派奖已退回"
+	case "reverse":
+		dispute, err = h.gameManager.ReverseDispute(id, reviewer, note)
+		message = "已撤销对局结果，双方下注已退还"
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "action 只能是 release 或 reverse",
+		})
+		return
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrDisputeNotOpen) {
+			status = http.StatusConflict
+		}
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, req.Action+"_dispute", "game", dispute.GameID, map[string]interface{}{
+		"dispute_id": id,
+		"held":       utils.AmountToFloat(dispute.Held),
+		"note":       note,
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+// APIGetReconcileIssues 获取余额对账差异，默认只返回待核对的差异
+func (h *AdminHandler) APIGetReconcileIssues(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.ReconcileStatusOpen
+	} else if status == "all" {
+		status = ""
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	issues, err := h.db.GetReconcileIssues(status, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取对账差异失败",
+		})
+		return
+	}
+
+	items := make([]map[string]interface{}, len(issues))
+	for i, issue := range issues {
+		username := "未知用户"
+		if user, _ := h.db.GetUser(issue.UserID); user != nil {
+			username = user.FirstName
+			if user.Username != "" {
+				username = "@" + user.Username
+			}
+		}
+
+		items[i] = map[string]interface{}{
+			"id":          issue.ID,
+			"user_id":     issue.UserID,
+			"username":    username,
+			"expected":    utils.AmountToFloat(issue.Expected), // 转换为金币
+			"actual":      utils.AmountToFloat(issue.Actual),
+			"difference":  utils.AmountToFloat(issue.Difference),
+			"status":      issue.Status,
+			"frozen":      issue.Frozen,
+			"reviewer":    issue.Reviewer,
+			"note":        issue.Note,
+			"created_at":  issue.CreatedAt,
+			"reviewed_at": issue.ReviewedAt,
+		}
+	}
+
+	data := map[string]interface{}{"issues": items}
+	if h.reconciler != nil {
+		data["reconciler"] = h.reconciler.Status()
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// APIResolveReconcileIssue 处理对账差异并解冻账户：action 为 accept 时确认当前余额无误，为 resolve 时表示已手动修正余额
+func (h *AdminHandler) APIResolveReconcileIssue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的对账差异ID",
+		})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	var status, message string
+	switch req.Action {
+	case "accept":
+		status, message = models.ReconcileStatusAccepted, "已确认余额，差额计入对账基线"
+	case "resolve":
+		status, message = models.ReconcileStatusResolved, "已标记为修正，下次对账重新核对"
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "action 只能是 accept 或 resolve",
+		})
+		return
+	}
+
+	issue, err := h.db.GetReconcileIssue(id)
+	if err == nil && issue == nil {
+		err = database.ErrReconcileIssueNotOpen
+	}
+	if err == nil {
+		err = h.db.ResolveReconcileIssue(id, status, h.adminActor(r), req.Note)
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, database.ErrReconcileIssueNotOpen) {
+			code = http.StatusConflict
+		}
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, req.Action+"_reconcile_issue", "user", strconv.FormatInt(issue.UserID, 10), map[string]interface{}{
+		"issue_id":   id,
+		"difference": utils.AmountToFloat(issue.Difference),
+		"note":       req.Note,
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+// APIGetRiskEvents 获取风控事件，默认只返回待审核的事件
+func (h *AdminHandler) APIGetRiskEvents(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.RiskStatusOpen
+	} else if status == "all" {
+		status = ""
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	events, err := h.db.GetRiskEvents(status, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取风控事件失败",
+		})
+		return
+	}
+
+	items := make([]map[string]interface{}, len(events))
+	for i, event := range events {
+		username := "未知用户"
+		if user, _ := h.db.GetUser(event.UserID); user != nil {
+			username = user.FirstName
+			if user.Username != "" {
+				username = "@" + user.Username
+			}
+		}
+
+		items[i] = map[string]interface{}{
+			"id":              event.ID,
+			"user_id":         event.UserID,
+			"username":        username,
+			"counterparty_id": event.CounterpartyID,
+			"rule":            event.Rule,
+			"game_id":         event.GameID,
+			"detail":          event.Detail,
+			"status":          event.Status,
+			"frozen":          event.Frozen,
+			"reviewer":        event.Reviewer,
+			"note":            event.Note,
+			"created_at":      event.CreatedAt,
+			"reviewed_at":     event.ReviewedAt,
+		}
+	}
+
+	data := map[string]interface{}{"events": items}
+	if h.risk != nil {
+		data["rules"] = h.risk.Status()
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// APIResolveRiskEvent 审核风控事件：action 为 confirm 时确认违规、账户保持冻结，为 dismiss 时标记为误报并解冻账户
+func (h *AdminHandler) APIResolveRiskEvent(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的风控事件ID",
+		})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	var status, message string
+	switch req.Action {
+	case "confirm":
+		status, message = models.RiskStatusConfirmed, "已确认违规，账户保持冻结"
+	case "dismiss":
+		status, message = models.RiskStatusDismissed, "已标记为误报，统计窗口内不再重复标记"
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "action 只能是 confirm 或 dismiss",
+		})
+		return
+	}
+
+	event, err := h.db.GetRiskEvent(id)
+	if err == nil && event == nil {
+		err = database.ErrRiskEventNotOpen
+	}
+	if err == nil {
+		err = h.db.ResolveRiskEvent(id, status, h.adminActor(r), req.Note)
+	}
+	if err != nil {
+		code := http.StatusInternalServerError
+		if errors.Is(err, database.ErrRiskEventNotOpen) {
+			code = http.StatusConflict
+		}
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, req.Action+"_risk_event", "user", strconv.FormatInt(event.UserID, 10), map[string]interface{}{
+		"event_id": id,
+		"rule":     event.Rule,
+		"game_id":  event.GameID,
+		"note":     req.Note,
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": message,
+	})
+}
+
+// APIRunReconcile 立即执行一次余额对账
+func (h *AdminHandler) APIRunReconcile(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.reconciler == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "余额对账未启用",
+		})
+		return
+	}
+
+	run, err := h.reconciler.Run("manual")
+	if errors.Is(err, reconcile.ErrRunning) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "余额对账失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "run_reconcile", "database", "main", map[string]interface{}{
+		"checked":     run.Checked,
+		"issues":      len(run.Issues),
+		"frozen":      run.Frozen,
+		"duration_ms": run.Duration.Milliseconds(),
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "余额对账完成",
+		"data":    run,
+	})
+}
+
+// APISearchTransactions 按用户、游戏、类型、金额、日期和备注搜索交易记录
+func (h *AdminHandler) APISearchTransactions(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTransactionFilter(r)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	transactions, nextCursor, err := h.db.SearchTransactions(filter, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取交易记录失败",
+		})
+		return
+	}
+
+	totals, err := h.db.SumTransactions(filter)
+	if err != nil {
+		log.Printf("统计交易记录失败: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"data":        transactions,
+		"totals":      totals,
+		"next_cursor": nextCursor,
+	})
+}
+
+// parseTransactionFilter 解析交易记录筛选参数，金额以元为单位，日期格式为 2006-01-02
+func parseTransactionFilter(r *http.Request) (*models.TransactionFilter, error) {
+	query := r.URL.Query()
+	filter := &models.TransactionFilter{
+		GameID: query.Get("game_id"),
+		Type:   query.Get("type"),
+		Memo:   query.Get("memo"),
+	}
+
+	if v := query.Get("user_id"); v != "" {
+		userID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的用户ID")
+		}
+		filter.UserID = userID
+	}
+
+	for _, p := range []struct {
+		key    string
+		target **int64
+	}{{"min_amount", &filter.MinAmount}, {"max_amount", &filter.MaxAmount}} {
+		if v := query.Get(p.key); v != "" {
+			amount, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, fmt.Errorf("无效的金额: %s", v)
+			}
+			cents := utils.AmountFromFloat(amount) // 转换为基本单位
+			*p.target = &cents
+		}
+	}
+
+	if v := query.Get("from"); v != "" {
+		from, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("无效的开始日期: %s", v)
+		}
+		filter.From = from
+	}
+	if v := query.Get("to"); v != "" {
+		to, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("无效的结束日期: %s", v)
+		}
+		filter.To = to.AddDate(0, 0, 1) // 包含结束日期当天
+	}
+
+	return filter, nil
+}
+
+// APIGameTimeline 对局时间线：发起、加入、骰子消息、结算、资金流水、安全校验、发送的消息和管理员操作按时间排列
+func (h *AdminHandler) APIGameTimeline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	gameID := r.PathValue("id")
+
+	timeline, err := h.db.GetGameTimeline(gameID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取对局时间线失败",
+		})
+		return
+	}
+	if timeline == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "对局不存在",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    timeline,
+		// 可直接复制给玩家或工单的文本版本
+		"text": ui.FormatGameTimeline(timeline),
+	})
+}
+
+// APIAdminActions 获取管理员操作记录，可按 target_type 和 target_id 筛选
+func (h *AdminHandler) APIAdminActions(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	actions, err := h.db.GetAdminActions(r.URL.Query().Get("target_type"), r.URL.Query().Get("target_id"), limit)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取操作记录失败",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    actions,
+	})
+}
+
+// APILookupUsername 通过 @用户名 查找用户，当前无人使用时返回曾使用过该用户名的用户
+func (h *AdminHandler) APILookupUsername(w http.ResponseWriter, r *http.Request) {
+	username := r.URL.Query().Get("username")
+	if strings.TrimPrefix(strings.TrimSpace(username), "@") == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "用户名不能为空",
+		})
+		return
+	}
+
+	user, err := h.db.FindUserByUsername(username)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "查找用户失败",
+		})
+		return
+	}
+	formerOwners, _ := h.db.FindUsersByFormerUsername(username)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"user":          user,
+			"former_owners": formerOwners,
+		},
+	})
+}
+
+// APIAcquisitionSources 获取用户来源统计
+func (h *AdminHandler) APIAcquisitionSources(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	sources, err := h.db.GetAcquisitionSources(limit)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取来源统计失败",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    sources,
+	})
+}
+
+// APIGetWelcomeTemplate 获取入群欢迎消息模板
+func (h *AdminHandler) APIGetWelcomeTemplate(w http.ResponseWriter, r *http.Request) {
+	lang := ui.NormalizeLanguage(r.URL.Query().Get("lang"))
+
+	text, custom, err := h.db.GetSetting(ui.WelcomeTemplateSettingKey(lang))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取欢迎模板失败",
+		})
+		return
+	}
+	if !custom {
+		text = ui.DefaultWelcomeTemplate(lang)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"lang":     lang,
+			"template": text,
+			"custom":   custom,
+		},
+	})
+}
+
+// APIUpdateWelcomeTemplate 更新入群欢迎消息模板，模板为空时恢复默认
+func (h *AdminHandler) APIUpdateWelcomeTemplate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Lang     string `json:"lang"`
+		Template string `json:"template"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	key := ui.WelcomeTemplateSettingKey(req.Lang)
+	var err error
+	if req.Template == "" {
+		err = h.db.DeleteSetting(key)
+	} else {
+		if err := ui.ValidateWelcomeTemplate(req.Template); err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		err = h.db.SetSetting(key, req.Template)
+	}
+
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "保存欢迎模板失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_welcome_template", "setting", key, map[string]interface{}{
+		"reset": req.Template == "",
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "欢迎模板已更新",
+	})
+}
+
+// APILeaderboard 获取排行榜，管理员可看到匿名玩家的真实身份
+func (h *AdminHandler) APILeaderboard(w http.ResponseWriter, r *http.Request) {
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days <= 0 {
+		days = 7
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	chatID, _ := strconv.ParseInt(r.URL.Query().Get("chat_id"), 10, 64)
+
+	entries, err := h.db.GetLeaderboard(chatID, time.Now().AddDate(0, 0, -days), limit)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取排行榜失败",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// APIGetInsuranceSettings 获取下注保险配置
+func (h *AdminHandler) APIGetInsuranceSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := h.db.GetInsuranceSettings()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取保险配置失败",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// APIUpdateInsuranceSettings 更新下注保险的开关、投保门槛、保险费率和赔付比例
+func (h *AdminHandler) APIUpdateInsuranceSettings(w http.ResponseWriter, r *http.Request) {
+	var req models.InsuranceSettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.SetInsuranceSettings(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_insurance_settings", "setting", database.InsuranceSettingKey, map[string]interface{}{
+		"enabled":      req.Enabled,
+		"threshold":    req.Threshold,
+		"premium_rate": req.PremiumRate,
+		"refund_rate":  req.RefundRate,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "保险配置已更新",
+	})
+}
+
+// APIGetChatAccess 获取群组准入模式和后台维护的白名单、黑名单
+func (h *AdminHandler) APIGetChatAccess(w http.ResponseWriter, r *http.Request) {
+	mode, custom, err := h.db.GetChatAccessMode()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取准入模式失败",
+		})
+		return
+	}
+
+	entries, err := h.db.GetChatAccessList(r.URL.Query().Get("list"))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取群组名单失败",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"mode":    mode,
+			"custom":  custom, // false 表示使用配置文件中的模式
+			"entries": entries,
+		},
+	})
+}
+
+// APIUpdateChatAccessMode 设置群组准入模式（open、allowlist、blocklist）
+func (h *AdminHandler) APIUpdateChatAccessMode(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Mode string `json:"mode"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.SetChatAccessMode(req.Mode); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_access_mode", "setting", database.ChatAccessModeSettingKey, map[string]interface{}{
+		"mode": req.Mode,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "准入模式已更新",
+	})
+}
+
+// APIUpdateChatAccessEntry 将群组加入白名单或黑名单，list 为空时移出名单
+func (h *AdminHandler) APIUpdateChatAccessEntry(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		List string `json:"list"`
+		Note string `json:"note"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if req.List == "" {
+		err = h.db.DeleteChatAccessEntry(chatID)
+	} else {
+		err = h.db.SetChatAccessEntry(chatID, req.List, req.Note)
+	}
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_access_entry", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"list": req.List,
+		"note": req.Note,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组名单已更新",
+	})
+}
+
+// APIUpdateChatSurrender 开启或关闭群组的认输功能
+func (h *AdminHandler) APIUpdateChatSurrender(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.SetChatSurrenderEnabled(chatID, req.Enabled); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "更新群组设置失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_surrender", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"enabled": req.Enabled,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组设置已更新",
+	})
+}
+
+// APIUpdateChatReadyCheck 开启或关闭群组开骰前的准备确认
+func (h *AdminHandler) APIUpdateChatReadyCheck(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.SetChatReadyCheck(chatID, req.Enabled); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "更新群组设置失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_ready_check", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"enabled": req.Enabled,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组设置已更新",
+	})
+}
+
+// APIUpdateChatJackpotAnnounce 开启或关闭群组的奖池播报
+func (h *AdminHandler) APIUpdateChatJackpotAnnounce(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		Enabled bool `json:"enabled"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.SetChatJackpotAnnounce(chatID, req.Enabled); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "更新群组设置失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_jackpot_announce", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"enabled": req.Enabled,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组设置已更新",
+	})
+}
+
+// APIUpdateChatFeedOptOut 设置群组是否退出大奖频道转发
+func (h *AdminHandler) APIUpdateChatFeedOptOut(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		OptOut bool `json:"opt_out"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.SetChatFeedOptOut(chatID, req.OptOut); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "更新群组设置失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_feed_opt_out", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"opt_out": req.OptOut,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组设置已更新",
+	})
+}
+
+// APIUpdateChatGameMode 设置群组对局模式：顺序进行（其余加入请求排队）或并行（可限制同时进行的对局数）
+func (h *AdminHandler) APIUpdateChatGameMode(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		Sequential bool `json:"sequential"`
+		MaxActive  int  `json:"max_active"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MaxActive < 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.gameManager.SetChatGameMode(chatID, req.Sequential, req.MaxActive); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "更新群组设置失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_game_mode", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"sequential": req.Sequential,
+		"max_active": req.MaxActive,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组设置已更新",
+	})
+}
+
+// APIUpdateChatCooldown 设置群组两局之间的冷却时间（秒），0 表示取消冷却
+func (h *AdminHandler) APIUpdateChatCooldown(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		Seconds int `json:"seconds"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.gameManager.SetChatGameCooldown(chatID, time.Duration(req.Seconds)*time.Second); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_cooldown", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"seconds": req.Seconds,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组设置已更新",
+	})
+}
+
+// APIGetChatLimits 获取群组生效的下注限额、手续费率和等待超时，以及群组自定义的原始设置
+func (h *AdminHandler) APIGetChatLimits(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	limits, err := h.gameManager.ChatLimits(chatID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"min_bet":      utils.AmountToFloat(limits.MinBet), // 转换为金币
+			"max_bet":      utils.AmountToFloat(limits.MaxBet),
+			"fee_rate":     limits.FeeRate,
+			"game_timeout": int(limits.GameTimeout / time.Second),
+			"custom":       limits.Custom,
+		},
+	})
+}
+
+// APIUpdateChatLimits 设置群组的下注限额（金币）、手续费率和等待超时（秒），
+// 0 或不填表示沿用全局配置，限额只能在全局限额之内收紧
+func (h *AdminHandler) APIUpdateChatLimits(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		MinBet      float64  `json:"min_bet"`
+		MaxBet      float64  `json:"max_bet"`
+		FeeRate     *float64 `json:"fee_rate"`
+		GameTimeout int      `json:"game_timeout"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	custom := &models.ChatGameLimits{
+		MinBet:      utils.AmountFromFloat(req.MinBet), // 转换为基本单位
+		MaxBet:      utils.AmountFromFloat(req.MaxBet),
+		FeeRate:     req.FeeRate,
+		GameTimeout: req.GameTimeout,
+	}
+	old, _ := h.db.GetChatGameLimits(chatID)
+	if err := h.gameManager.SetChatGameLimits(chatID, custom); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_limits", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"old": old,
+		"new": custom,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组设置已更新",
+	})
+}
+
+// APIUpdateChatEligibility 设置群组的下注资格：账号注册天数和在本群天数的下限，0 表示不限制
+func (h *AdminHandler) APIUpdateChatEligibility(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req models.ChatEligibility
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MinAccountDays < 0 || req.MinMemberDays < 0 {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.SetChatEligibility(chatID, &req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "更新群组设置失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_eligibility", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"min_account_days": req.MinAccountDays,
+		"min_member_days":  req.MinMemberDays,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "群组设置已更新",
+	})
+}
+
+// APIChatQueue 查看群组排队等待开局的加入请求：玩家、下注额和已等待时间
+func (h *AdminHandler) APIChatQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	entries, err := h.gameManager.ChatQueue(chatID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取排队请求失败",
+		})
+		return
+	}
+
+	now := time.Now()
+	items := make([]map[string]interface{}, len(entries))
+	for i, entry := range entries {
+		items[i] = map[string]interface{}{
+			"entry":          entry,
+			"waited_seconds": int64(now.Sub(entry.QueuedAt).Seconds()),
+		}
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    items,
+	})
+}
+
+// APIRemoveQueuedJoin 移除群组队列中某个对局的加入请求，并私信通知被移出的玩家
+func (h *AdminHandler) APIRemoveQueuedJoin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	entry, err := h.gameManager.RemoveQueuedJoin(chatID, r.PathValue("game_id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "remove_queued_join", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"game_id":   entry.GameID,
+		"player_id": entry.PlayerID,
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "已移出队列并通知玩家",
+	})
+}
+
+// APIClearChatQueue 清空群组的排队请求，并私信通知全部玩家
+func (h *AdminHandler) APIClearChatQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	entries, err := h.gameManager.ClearQueue(chatID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "清空队列失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "clear_chat_queue", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"removed": len(entries),
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("已清空队列，%d 名玩家已收到通知", len(entries)),
+	})
+}
+
+// APIOperations 获取所有群组未结束的对局、所处阶段、已持续时长和排队的加入请求数
+func (h *AdminHandler) APIOperations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	chats, err := h.operations()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取进行中的对局失败",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    chats,
+	})
+}
+
+// APIAbortGame 强制中止未结束的对局并向双方退款，群内公告并私信通知玩家
+func (h *AdminHandler) APIAbortGame(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	gameID := r.PathValue("id")
+
+	game, err := h.gameManager.AbortGame(gameID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "abort_game", "game", gameID, map[string]interface{}{
+		"chat_id":    game.ChatID,
+		"bet_amount": game.BetAmount,
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "对局已中止并退款",
+	})
+}
+
+// operations 按群组汇总未结束的对局，附带群组名称，供运营页面和接口使用
+func (h *AdminHandler) operations() ([]map[string]interface{}, error) {
+	operations, err := h.gameManager.Operations()
+	if err != nil {
+		return nil, err
+	}
+
+	chats := make([]map[string]interface{}, len(operations))
+	for i, ops := range operations {
+		var title string
+		if chat, err := h.db.GetChat(ops.ChatID); err == nil && chat != nil {
+			title = chat.Title
+		}
+
+		games := make([]map[string]interface{}, len(ops.Games))
+		for j, active := range ops.Games {
+			games[j] = map[string]interface{}{
+				"game":             active.Game,
+				"phase":            active.Phase,
+				"elapsed_seconds":  int64(active.Elapsed.Seconds()),
+				"queued_player_id": active.QueuedPlayerID,
+			}
+		}
+		chats[i] = map[string]interface{}{
+			"chat_id":     ops.ChatID,
+			"title":       title,
+			"games":       games,
+			"queue_depth": ops.QueueDepth,
+		}
+	}
+	return chats, nil
+}
+
+// APIUpdateChatRevenueShare 设置群组的手续费分成比例
+func (h *AdminHandler) APIUpdateChatRevenueShare(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		Share float64 `json:"share"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	oldShare, _ := h.db.GetChatRevenueShare(chatID)
+	if err := h.db.SetChatRevenueShare(chatID, req.Share); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "更新分成比例失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_chat_revenue_share", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"old_share": oldShare,
+		"new_share": req.Share,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "分成比例已更新",
+	})
+}
+
+// APIExportChatSettings 导出群组设置（语言、认输、奖池播报、分成比例、频道转发）为 JSON
+func (h *AdminHandler) APIExportChatSettings(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	settings, err := chatsettings.Export(h.db, chatID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// APIImportChatSettings 将导出的设置导入到多个群组，dry_run 为 true 时只返回变更预览
+func (h *AdminHandler) APIImportChatSettings(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ChatIDs  []int64         `json:"chat_ids"`
+		Settings json.RawMessage `json:"settings"`
+		DryRun   bool            `json:"dry_run"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	settings, err := chatsettings.Parse(req.Settings)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	apply := chatsettings.Apply
+	if req.DryRun {
+		apply = chatsettings.Preview
+	}
+	changes, err := apply(h.db, req.ChatIDs, settings)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if req.DryRun {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    changes,
+		})
+		return
+	}
+
+	chatIDs := make([]string, len(req.ChatIDs))
+	for i, chatID := range req.ChatIDs {
+		chatIDs[i] = strconv.FormatInt(chatID, 10)
+	}
+	h.recordAdminAction(r, "import_chat_settings", "chat", strings.Join(chatIDs, ","), map[string]interface{}{
+		"settings": settings,
+		"changes":  changes,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("已导入 %d 个群组，共 %d 项变更", len(req.ChatIDs), len(changes)),
+		"data":    changes,
+	})
+}
+
+// APIRevenueShareReport 获取各群组的手续费分成统计
+func (h *AdminHandler) APIRevenueShareReport(w http.ResponseWriter, r *http.Request) {
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days < 1 || days > 365 {
+		days = 30
+	}
+
+	reports, err := h.db.GetRevenueShareReport(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取分成统计失败",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    reports,
+	})
+}
+
+// APIChatFund 获取群组基金余额及近期活跃玩家数
+func (h *AdminHandler) APIChatFund(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	balance, prizePool, err := h.db.GetChatFund(chatID)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取群组基金失败",
+		})
+		return
+	}
+
+	players, _ := h.db.GetActiveChatPlayers(chatID, time.Now().AddDate(0, 0, -7))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"chat_id":        chatID,
+			"fund_balance":   balance,
+			"prize_pool":     prizePool,
+			"active_players": len(players),
+		},
+	})
+}
+
+// APIDistributeChatFund 分配群组基金：空投给近期活跃玩家或转入比赛奖池
+func (h *AdminHandler) APIDistributeChatFund(w http.ResponseWriter, r *http.Request) {
+	chatID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的群组ID",
+		})
+		return
+	}
+
+	var req struct {
+		Mode   string  `json:"mode"`   // airdrop 或 prize
+		Amount float64 `json:"amount"` // 金币，最多两位小数
+		Days   int     `json:"days"`   // 空投时活跃玩家的统计天数
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	amount := utils.AmountFromFloat(req.Amount)
+	var data interface{}
+	switch req.Mode {
+	case "airdrop":
+		if req.Days < 1 || req.Days > 90 {
+			req.Days = 7
+		}
+		var players []int64
+		players, err = h.db.GetActiveChatPlayers(chatID, time.Now().AddDate(0, 0, -req.Days))
+		if err == nil {
+			data, err = h.db.AirdropChatFund(chatID, amount, players)
+		}
+	case "prize":
+		var prizePool int64
+		prizePool, err = h.db.MoveChatFundToPrizePool(chatID, amount)
+		data = map[string]interface{}{"prize_pool": prizePool}
+	default:
+		err = fmt.Errorf("未知的分配方式: %s", req.Mode)
+	}
+
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "分配群组基金失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "distribute_chat_fund", "chat", strconv.FormatInt(chatID, 10), map[string]interface{}{
+		"mode":   req.Mode,
+		"amount": amount,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// APIGetBonusCampaigns 获取充值赠送活动列表
+func (h *AdminHandler) APIGetBonusCampaigns(w http.ResponseWriter, r *http.Request) {
+	campaigns, err := h.db.GetBonusCampaigns()
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取赠送活动失败",
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    campaigns,
+	})
+}
+
+// APICreateBonusCampaign 创建充值赠送活动
+func (h *AdminHandler) APICreateBonusCampaign(w http.ResponseWriter, r *http.Request) {
+	var campaign models.BonusCampaign
+	if err := json.NewDecoder(r.Body).Decode(&campaign); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.CreateBonusCampaign(&campaign); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "创建赠送活动失败: " + err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "create_bonus_campaign", "bonus_campaign", strconv.FormatInt(campaign.ID, 10), map[string]interface{}{
+		"name":    campaign.Name,
+		"percent": campaign.Percent,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    campaign,
+	})
+}
+
+// APIUpdateBonusCampaignStatus 启用或停用充值赠送活动
+func (h *AdminHandler) APIUpdateBonusCampaignStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的活动ID",
+		})
+		return
+	}
+
+	var req struct {
+		Active bool `json:"active"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	if err := h.db.SetBonusCampaignActive(id, req.Active); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "更新赠送活动失败",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "update_bonus_campaign_status", "bonus_campaign", strconv.FormatInt(id, 10), map[string]interface{}{
+		"active": req.Active,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "赠送活动已更新",
+	})
+}
+
+// APIGetBroadcasts 获取最近的公告及投递进度
+func (h *AdminHandler) APIGetBroadcasts(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	broadcasts, err := h.db.GetBroadcasts(limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取公告失败",
+		})
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    broadcasts,
+	})
+}
+
+// APIGetBroadcast 获取单条公告的投递进度和最近的失败原因
+func (h *AdminHandler) APIGetBroadcast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的公告ID",
+		})
+		return
+	}
+
+	broadcast, err := h.db.GetBroadcast(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取公告失败",
+		})
+		return
+	}
+	if broadcast == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "公告不存在",
+		})
+		return
+	}
+
+	failures, err := h.db.GetBroadcastFailures(id, 50)
+	if err != nil {
+		log.Printf("获取公告 #%d 失败记录失败: %v", id, err)
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"broadcast": broadcast,
+			"failures":  failures,
+		},
+	})
+}
+
+// APICreateBroadcast 创建公告，scheduled_at 为空时立即发送
+func (h *AdminHandler) APICreateBroadcast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.broadcaster == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "公告功能未启用",
+		})
+		return
+	}
+
+	var req struct {
+		Text        string     `json:"text"`
+		Audience    string     `json:"audience"`
+		ScheduledAt *time.Time `json:"scheduled_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+
+	var at time.Time
+	if req.ScheduledAt != nil {
+		at = *req.ScheduledAt
+	}
+	broadcast, err := h.broadcaster.Schedule(req.Text, req.Audience, at, h.adminActor(r))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "create_broadcast", "broadcast", strconv.FormatInt(broadcast.ID, 10), map[string]interface{}{
+		"audience":     broadcast.Audience,
+		"scheduled_at": broadcast.ScheduledAt,
+	})
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    broadcast,
+	})
+}
+
+// APICancelBroadcast 取消待发送或发送中的公告
+func (h *AdminHandler) APICancelBroadcast(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if h.broadcaster == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "公告功能未启用",
+		})
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的公告ID",
+		})
+		return
+	}
+
+	ok, err := h.broadcaster.Cancel(id)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if !ok {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "公告不存在或已结束",
+		})
+		return
+	}
+
+	h.recordAdminAction(r, "cancel_broadcast", "broadcast", strconv.FormatInt(id, 10), nil)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "公告已取消",
+	})
+}
+
+// APIUpdateUserBalance 更新用户余额
+func (h *AdminHandler) APIUpdateUserBalance(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "无效的用户ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Balance float64 `json:"balance"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "无效的请求数据", http.StatusBadRequest)
+		return
+	}
+
+	// 更新用户余额
+	oldUser, _ := h.db.GetUser(userID)
+	newBalance := utils.AmountFromFloat(req.Balance) // 转换为基本单位
+	err = h.db.UpdateUserBalance(userID, newBalance)
+	if err != nil {
+		http.Error(w, "更新余额失败", http.StatusInternalServerError)
+		return
+	}
+
+	details := map[string]interface{}{"new_balance": newBalance}
+	if oldUser != nil {
+		details["old_balance"] = oldUser.Balance
+	}
+	h.recordAdminAction(r, "update_balance", "user", strconv.FormatInt(userID, 10), details)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+}
+
+// APIUpdateUserDailyLimitExempt 豁免或恢复用户的每日对局数上限
+func (h *AdminHandler) APIUpdateUserDailyLimitExempt(w http.ResponseWriter, r *http.Request) {
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的用户ID",
+		})
+		return
+	}
+
+	var req struct {
+		Exempt bool `json:"exempt"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
 	}
 
-	log.Printf("Dashboard data: %+v", data)
+	if user, err := h.db.GetUser(userID); err != nil || user == nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "用户不存在",
+		})
+		return
+	}
 
-	err := h.templates.ExecuteTemplate(w, "layout", data)
-	if err != nil {
-		log.Printf("Dashboard template error: %v", err)
-		http.Error(w, "模板渲染失败: "+err.Error(), http.StatusInternalServerError)
+	if err := h.gameManager.SetDailyLimitExempt(userID, req.Exempt); err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
 		return
 	}
+
+	h.recordAdminAction(r, "update_daily_limit_exempt", "user", strconv.FormatInt(userID, 10), map[string]interface{}{
+		"exempt": req.Exempt,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "用户设置已更新",
+	})
 }
 
-// Users 用户管理页面
-func (h *AdminHandler) Users(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
-	}
+// APIGetBannedUsers 获取被封禁的用户
+func (h *AdminHandler) APIGetBannedUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	limit := 20
-	offset := (page - 1) * limit
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
 
-	users, err := h.db.GetUsersWithPagination(offset, limit)
+	bans, err := h.db.GetBannedUsers(limit)
 	if err != nil {
-		http.Error(w, "获取用户数据失败: "+err.Error(), http.StatusInternalServerError)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取封禁用户失败",
+		})
 		return
 	}
 
-	totalUsers, _ := h.db.GetTotalUsersCount()
-	totalPages := (totalUsers + limit - 1) / limit
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    bans,
+	})
+}
 
-	data := map[string]interface{}{
-		"Title":      "用户管理",
-		"Users":      users,
-		"Page":       page,
-		"TotalPages": totalPages,
-		"HasPrev":    page > 1,
-		"HasNext":    page < totalPages,
-		"PrevPage":   page - 1,
-		"NextPage":   page + 1,
-	}
+// APIBanUser 封禁用户：被封禁的用户不能使用机器人和参与对局，其等待中的对局过期退款
+func (h *AdminHandler) APIBanUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	err = h.templates.ExecuteTemplate(w, "users.html", data)
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		http.Error(w, "模板渲染失败: "+err.Error(), http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的用户ID",
+		})
 		return
 	}
-}
 
-// Games 游戏记录页面
-func (h *AdminHandler) Games(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的请求数据",
+		})
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "请填写封禁原因",
+		})
+		return
 	}
 
-	limit := 20
-	offset := (page - 1) * limit
+	if user, err := h.db.GetUser(userID); err != nil || user == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "用户不存在",
+		})
+		return
+	}
 
-	games, err := h.db.GetGamesWithPagination(offset, limit)
+	expired, err := h.gameManager.BanUser(userID, req.Reason, h.adminActor(r))
 	if err != nil {
-		http.Error(w, "获取游戏数据失败", http.StatusInternalServerError)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
 		return
 	}
 
-	data := map[string]interface{}{
-		"Title": "游戏记录",
-		"Games": games,
-		"Page":  page,
-	}
+	h.recordAdminAction(r, "ban_user", "user", strconv.FormatInt(userID, 10), map[string]interface{}{
+		"reason":        req.Reason,
+		"expired_games": expired,
+	})
 
-	h.templates.ExecuteTemplate(w, "games.html", data)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("用户已封禁，%d 个等待中的对局已退款", expired),
+	})
 }
 
-// Recharges 充值记录页面
-func (h *AdminHandler) Recharges(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
-	}
-
-	limit := 20
-	offset := (page - 1) * limit
+// APIUnbanUser 解除用户的封禁
+func (h *AdminHandler) APIUnbanUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	recharges, err := h.db.GetRechargesWithPagination(offset, limit)
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
-		http.Error(w, "获取充值数据失败", http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "无效的用户ID",
+		})
 		return
 	}
 
-	totalRecharges, _ := h.db.GetTotalRechargesCount()
-	totalPages := (totalRecharges + limit - 1) / limit
-
-	data := map[string]interface{}{
-		"Title":      "充值记录",
-		"Recharges":  recharges,
-		"Page":       page,
-		"TotalPages": totalPages,
-		"HasPrev":    page > 1,
-		"HasNext":    page < totalPages,
-		"PrevPage":   page - 1,
-		"NextPage":   page + 1,
+	ban, err := h.db.GetUserBan(userID)
+	if err == nil && ban == nil {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "用户未被封禁",
+		})
+		return
+	}
+	if err == nil {
+		err = h.gameManager.UnbanUser(userID)
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
 	}
 
-	h.templates.ExecuteTemplate(w, "recharges.html", data)
-}
+	h.recordAdminAction(r, "unban_user", "user", strconv.FormatInt(userID, 10), map[string]interface{}{
+		"reason": ban.Reason,
+	})
 
-// API接口
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "已解除封禁",
+	})
+}
 
-// APIStats 获取统计数据
-func (h *AdminHandler) APIStats(w http.ResponseWriter, r *http.Request) {
-	totalUsers, _ := h.db.GetTotalUsersCount()
-	activeUsers, _ := h.db.GetActiveUsersCount()
-	todayGames, _ := h.db.GetTodayGamesCount()
-	totalRecharge, _ := h.db.GetTotalRechargeAmount()
+// APIGetTournaments 获取锦标赛，status 为空时不限状态
+func (h *AdminHandler) APIGetTournaments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	stats := map[string]interface{}{
-		"totalUsers":    totalUsers,
-		"activeUsers":   activeUsers,
-		"todayGames":    todayGames,
-		"totalRecharge": float64(totalRecharge) / 100,
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit <= 0 || limit > 200 {
+		limit = 50
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
-}
-
-// APIUpdateUserBalance 更新用户余额
-func (h *AdminHandler) APIUpdateUserBalance(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.ParseInt(vars["id"], 10, 64)
+	tournaments, err := h.db.GetTournaments(r.URL.Query().Get("status"), limit)
 	if err != nil {
-		http.Error(w, "无效的用户ID", http.StatusBadRequest)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "获取锦标赛失败",
+		})
 		return
 	}
 
-	var req struct {
-		Balance float64 `json:"balance"`
-	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    tournaments,
+	})
+}
 
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "无效的请求数据", http.StatusBadRequest)
-		return
-	}
+// APIGetTournament 获取锦标赛的参赛玩家和对阵，用于核对签表和派奖
+func (h *AdminHandler) APIGetTournament(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// 更新用户余额
-	newBalance := int64(req.Balance * 100) // 转换为分
-	err = h.db.UpdateUserBalance(userID, newBalance)
+	progress, err := h.gameManager.TournamentDetail(r.PathValue("id"))
 	if err != nil {
-		http.Error(w, "更新余额失败", http.StatusInternalServerError)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if progress == nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": "锦标赛不存在",
+		})
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"tournament": progress.Tournament,
+			"entries":    progress.Entries,
+			"matches":    progress.Matches,
+			"rounds":     game.TournamentRounds(len(progress.Entries)),
+		},
+	})
 }
 
 // APIDeleteUser 删除用户
 func (h *AdminHandler) APIDeleteUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.ParseInt(vars["id"], 10, 64)
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
 		http.Error(w, "无效的用户ID", http.StatusBadRequest)
 		return
 	}
 
+	oldUser, _ := h.db.GetUser(userID)
 	err = h.db.DeleteUser(userID)
 	if err != nil {
 		http.Error(w, "删除用户失败", http.StatusInternalServerError)
 		return
 	}
 
+	details := map[string]interface{}{}
+	if oldUser != nil {
+		details["username"] = oldUser.Username
+		details["balance"] = oldUser.Balance
+	}
+	h.recordAdminAction(r, "delete_user", "user", strconv.FormatInt(userID, 10), details)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"status": "success"})
 }
@@ -233,7 +3331,7 @@ func (h *AdminHandler) APIDeleteUser(w http.ResponseWriter, r *http.Request) {
 // LoginPage 显示登录页面
 func (h *AdminHandler) LoginPage(w http.ResponseWriter, r *http.Request) {
 	// 如果已经登录，重定向到仪表板
-	if auth.IsAuthenticated(r) {
+	if h.auth.IsAuthenticated(r) {
 		http.Redirect(w, r, "/admin", http.StatusFound)
 		return
 	}
@@ -265,14 +3363,8 @@ func (h *AdminHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 	log.Printf("Login attempt - Username: %s", username)
 
-	if auth.ValidateCredentials(username, password) {
-		log.Printf("Login credentials valid for user: %s", username)
-		err := auth.Login(w, r)
-		if err != nil {
-			log.Printf("Login session creation failed for user %s: %v", username, err)
-			http.Redirect(w, r, "/admin/login?error=登录失败", http.StatusFound)
-			return
-		}
+	if h.auth.ValidateCredentials(username, password) {
+		h.auth.Login(w, username)
 		log.Printf("Login successful for user: %s", username)
 		http.Redirect(w, r, "/admin", http.StatusFound)
 	} else {
@@ -284,11 +3376,7 @@ func (h *AdminHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
 
 // LogoutHandler 处理登出请求
 func (h *AdminHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
-	err := auth.Logout(w, r)
-	if err != nil {
-		http.Error(w, "登出失败", http.StatusInternalServerError)
-		return
-	}
+	h.auth.Logout(w)
 	http.Redirect(w, r, "/admin/login", http.StatusFound)
 }
 
@@ -372,7 +3460,7 @@ func (h *AdminHandler) APICreateUser(w http.ResponseWriter, r *http.Request) {
 	newUser := &models.User{
 		ID:       req.ID,
 		Username: req.Username,
-		Balance:  int64(req.Balance * 100), // 转换为分
+		Balance:  utils.AmountFromFloat(req.Balance), // 转换为基本单位
 	}
 
 	err := h.db.CreateUser(newUser)
@@ -386,6 +3474,11 @@ func (h *AdminHandler) APICreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.recordAdminAction(r, "create_user", "user", strconv.FormatInt(newUser.ID, 10), map[string]interface{}{
+		"username": newUser.Username,
+		"balance":  newUser.Balance,
+	})
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -393,6 +3486,59 @@ func (h *AdminHandler) APICreateUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// maxImportSize 用户导入文件的大小上限
+const maxImportSize = 10 << 20
+
+// APIImportUsers 从其他机器人迁移用户：上传 telegram_id,username,balance 格式的 CSV（表单字段 file 或请求体），
+// 返回逐行的导入报告；dry_run=true 时只校验不写入
+func (h *AdminHandler) APIImportUsers(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var source io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"success": false,
+				"message": "请上传 CSV 文件",
+			})
+			return
+		}
+		defer file.Close()
+		source = file
+	}
+	dryRun := r.FormValue("dry_run") == "true"
+
+	report, err := userimport.Import(h.db, source, dryRun)
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if !dryRun && report.Imported > 0 {
+		h.recordAdminAction(r, "import_users", "user", "", map[string]interface{}{
+			"total":    report.Total,
+			"imported": report.Imported,
+			"balance":  report.Balance,
+			"failed":   len(report.Errors),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("共 %d 行，导入 %d 个用户，失败 %d 行", report.Total, report.Imported, len(report.Errors)),
+		"data":    report,
+	})
+}
+
 // APIGetRecharges 获取充值记录列表API
 func (h *AdminHandler) APIGetRecharges(w http.ResponseWriter, r *http.Request) {
 	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
@@ -435,7 +3581,7 @@ func (h *AdminHandler) APIGetRecharges(w http.ResponseWriter, r *http.Request) {
 			"id":         recharge.ID,
 			"user_id":    recharge.UserID,
 			"username":   username,
-			"amount":     float64(recharge.Amount) / 100, // 转换为元
+			"amount":     utils.AmountToFloat(recharge.Amount), // 转换为金币
 			"type":       recharge.Type,
 			"status":     "completed", // 默认状态
 			"created_at": recharge.CreatedAt,
@@ -454,8 +3600,7 @@ func (h *AdminHandler) APIGetRecharges(w http.ResponseWriter, r *http.Request) {
 
 // APIGetUser 获取单个用户信息API
 func (h *AdminHandler) APIGetUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.ParseInt(vars["id"], 10, 64)
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -479,14 +3624,38 @@ func (h *AdminHandler) APIGetUser(w http.ResponseWriter, r *http.Request) {
 
 	// 转换用户数据
 	userData := map[string]interface{}{
-		"id":         user.ID,
-		"username":   user.Username,
-		"first_name": user.FirstName,
-		"last_name":  user.LastName,
-		"balance":    float64(user.Balance) / 100, // 转换为元
-		"status":     "active",                    // 默认状态，可以根据需要扩展
-		"created_at": user.CreatedAt,
-		"updated_at": user.UpdatedAt,
+		"id":            user.ID,
+		"username":      user.Username,
+		"first_name":    user.FirstName,
+		"last_name":     user.LastName,
+		"balance":       utils.AmountToFloat(user.Balance), // 转换为金币
+		"status":        "active",                          // 默认状态，可以根据需要扩展
+		"first_chat_id": user.FirstChatID,
+		"source":        user.Source,
+		"created_at":    user.CreatedAt,
+		"updated_at":    user.UpdatedAt,
+		// 跳转到该用户的交易记录
+		"transactions_url": fmt.Sprintf("/admin/transactions?user_id=%d", user.ID),
+	}
+
+	if history, err := h.db.GetUsernameHistory(user.ID); err == nil {
+		userData["username_history"] = history
+	}
+
+	// 今日对局数及每日上限
+	if daily, err := h.gameManager.UserDailyGames(user.ID); err == nil {
+		userData["games_today"] = daily.Played
+		userData["daily_game_limit"] = daily.Limit
+		userData["daily_limit_exempt"] = daily.Exempt
+		userData["daily_limit_reset_at"] = daily.ResetAt
+	}
+
+	// 最近的管理员操作，显示由谁修改
+	if actions, err := h.db.GetAdminActions("user", strconv.FormatInt(user.ID, 10), 20); err == nil {
+		userData["admin_actions"] = actions
+		if len(actions) > 0 {
+			userData["last_modified_by"] = actions[0].Admin
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -495,8 +3664,7 @@ func (h *AdminHandler) APIGetUser(w http.ResponseWriter, r *http.Request) {
 
 // APIUpdateUser 更新用户信息API
 func (h *AdminHandler) APIUpdateUser(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	userID, err := strconv.ParseInt(vars["id"], 10, 64)
+	userID, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusBadRequest)
@@ -524,7 +3692,8 @@ func (h *AdminHandler) APIUpdateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 更新用户信息
-	err = h.db.UpdateUserInfo(userID, req.Username, int64(req.Balance*100))
+	oldUser, _ := h.db.GetUser(userID)
+	err = h.db.UpdateUserInfo(userID, req.Username, utils.AmountFromFloat(req.Balance))
 	if err != nil {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -535,6 +3704,16 @@ func (h *AdminHandler) APIUpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	details := map[string]interface{}{
+		"username":    req.Username,
+		"new_balance": utils.AmountFromFloat(req.Balance),
+	}
+	if oldUser != nil {
+		details["old_username"] = oldUser.Username
+		details["old_balance"] = oldUser.Balance
+	}
+	h.recordAdminAction(r, "update_user", "user", strconv.FormatInt(userID, 10), details)
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
@@ -594,10 +3773,14 @@ func (h *AdminHandler) APIGetGames(w http.ResponseWriter, r *http.Request) {
 			"player1":    player1Name,
 			"player2_id": game.Player2ID,
 			"player2":    player2Name,
-			"bet_amount": float64(game.BetAmount) / 100, // 转换为元
+			"bet_amount": utils.AmountToFloat(game.BetAmount), // 转换为金币
 			"status":     game.Status,
 			"winner_id":  game.WinnerID,
 			"created_at": game.CreatedAt,
+			// 跳转到该局的交易记录
+			"transactions_url": "/admin/transactions?game_id=" + url.QueryEscape(game.ID),
+			// 该局的完整时间线，用于处理争议
+			"timeline_url": "/admin/api/games/" + url.PathEscape(game.ID) + "/timeline",
 		}
 	}
 
@@ -0,0 +1,117 @@
+package admin
+
+import "net/http"
+
+// Routes 管理后台的全部路由：登录页不需要会话，其余页面和 /admin/api/ 接口均需登录
+func (h *AdminHandler) Routes() http.Handler {
+	protected := http.NewServeMux()
+
+	// 页面
+	protected.HandleFunc("GET /admin", h.Dashboard)
+	protected.HandleFunc("GET /admin/{$}", h.Dashboard)
+	protected.HandleFunc("GET /admin/users", h.Users)
+	protected.HandleFunc("GET /admin/games", h.Games)
+	protected.HandleFunc("GET /admin/operations", h.Operations)
+	protected.HandleFunc("GET /admin/recharges", h.Recharges)
+	protected.HandleFunc("GET /admin/transactions", h.Transactions)
+	protected.HandleFunc("POST /admin/logout", h.LogoutHandler)
+
+	// 统计和运行状态
+	protected.HandleFunc("GET /admin/api/stats", h.APIStats)
+	protected.HandleFunc("GET /admin/api/metrics", h.PrometheusMetrics)
+	protected.HandleFunc("GET /admin/api/chats/loads", h.APIChatLoads)
+	protected.HandleFunc("GET /admin/api/shards", h.APIShardStatus)
+	protected.HandleFunc("GET /admin/api/maintenance", h.APIMaintenanceStatus)
+	protected.HandleFunc("POST /admin/api/maintenance/run", h.APIRunMaintenance)
+	protected.HandleFunc("GET /admin/api/uptime", h.APIUptime)
+	protected.HandleFunc("GET /admin/api/actions", h.APIAdminActions)
+
+	// 结算暂停和失败任务
+	protected.HandleFunc("GET /admin/api/settlement-hold", h.APISettlementHold)
+	protected.HandleFunc("PUT /admin/api/settlement-hold", h.APIUpdateSettlementHold)
+	protected.HandleFunc("POST /admin/api/settlement-hold/{id}", h.APIResolveHeldSettlement)
+	protected.HandleFunc("GET /admin/api/dead-letters", h.APIDeadLetters)
+	protected.HandleFunc("POST /admin/api/dead-letters/{id}", h.APIReplayDeadLetter)
+
+	// 提现、争议、对账和风控审核
+	protected.HandleFunc("GET /admin/api/withdrawals", h.APIGetWithdrawals)
+	protected.HandleFunc("POST /admin/api/withdrawals/{id}/review", h.APIReviewWithdrawal)
+	protected.HandleFunc("GET /admin/api/disputes", h.APIGetDisputes)
+	protected.HandleFunc("POST /admin/api/disputes/{id}/resolve", h.APIResolveDispute)
+	protected.HandleFunc("GET /admin/api/reconcile", h.APIGetReconcileIssues)
+	protected.HandleFunc("POST /admin/api/reconcile/run", h.APIRunReconcile)
+	protected.HandleFunc("POST /admin/api/reconcile/{id}/resolve", h.APIResolveReconcileIssue)
+	protected.HandleFunc("GET /admin/api/risk-events", h.APIGetRiskEvents)
+	protected.HandleFunc("POST /admin/api/risk-events/{id}/resolve", h.APIResolveRiskEvent)
+
+	// 交易和对局
+	protected.HandleFunc("GET /admin/api/transactions", h.APISearchTransactions)
+	protected.HandleFunc("GET /admin/api/games", h.APIGetGames)
+	protected.HandleFunc("GET /admin/api/games/{id}/timeline", h.APIGameTimeline)
+	protected.HandleFunc("GET /admin/api/operations", h.APIOperations)
+	protected.HandleFunc("POST /admin/api/games/{id}/abort", h.APIAbortGame)
+	protected.HandleFunc("GET /admin/api/leaderboard", h.APILeaderboard)
+	protected.HandleFunc("GET /admin/api/tournaments", h.APIGetTournaments)
+	protected.HandleFunc("GET /admin/api/tournaments/{id}", h.APIGetTournament)
+
+	// 全局设置
+	protected.HandleFunc("GET /admin/api/welcome-template", h.APIGetWelcomeTemplate)
+	protected.HandleFunc("PUT /admin/api/welcome-template", h.APIUpdateWelcomeTemplate)
+	protected.HandleFunc("GET /admin/api/insurance", h.APIGetInsuranceSettings)
+	protected.HandleFunc("PUT /admin/api/insurance", h.APIUpdateInsuranceSettings)
+
+	// 群组设置
+	protected.HandleFunc("GET /admin/api/chat-access", h.APIGetChatAccess)
+	protected.HandleFunc("PUT /admin/api/chat-access", h.APIUpdateChatAccessMode)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/access", h.APIUpdateChatAccessEntry)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/surrender", h.APIUpdateChatSurrender)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/ready-check", h.APIUpdateChatReadyCheck)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/jackpot-announce", h.APIUpdateChatJackpotAnnounce)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/feed-opt-out", h.APIUpdateChatFeedOptOut)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/game-mode", h.APIUpdateChatGameMode)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/cooldown", h.APIUpdateChatCooldown)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/eligibility", h.APIUpdateChatEligibility)
+	protected.HandleFunc("GET /admin/api/chats/{id}/limits", h.APIGetChatLimits)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/limits", h.APIUpdateChatLimits)
+	protected.HandleFunc("PUT /admin/api/chats/{id}/revenue-share", h.APIUpdateChatRevenueShare)
+	protected.HandleFunc("GET /admin/api/chats/{id}/settings", h.APIExportChatSettings)
+	protected.HandleFunc("POST /admin/api/chats/settings/import", h.APIImportChatSettings)
+	protected.HandleFunc("GET /admin/api/revenue-share", h.APIRevenueShareReport)
+	protected.HandleFunc("GET /admin/api/chats/{id}/fund", h.APIChatFund)
+	protected.HandleFunc("POST /admin/api/chats/{id}/fund/distribute", h.APIDistributeChatFund)
+	protected.HandleFunc("GET /admin/api/chats/{id}/queue", h.APIChatQueue)
+	protected.HandleFunc("DELETE /admin/api/chats/{id}/queue", h.APIClearChatQueue)
+	protected.HandleFunc("DELETE /admin/api/chats/{id}/queue/{game_id}", h.APIRemoveQueuedJoin)
+
+	// 活动和公告
+	protected.HandleFunc("GET /admin/api/bonus-campaigns", h.APIGetBonusCampaigns)
+	protected.HandleFunc("POST /admin/api/bonus-campaigns", h.APICreateBonusCampaign)
+	protected.HandleFunc("PUT /admin/api/bonus-campaigns/{id}/status", h.APIUpdateBonusCampaignStatus)
+	protected.HandleFunc("GET /admin/api/broadcasts", h.APIGetBroadcasts)
+	protected.HandleFunc("POST /admin/api/broadcasts", h.APICreateBroadcast)
+	protected.HandleFunc("GET /admin/api/broadcasts/{id}", h.APIGetBroadcast)
+	protected.HandleFunc("POST /admin/api/broadcasts/{id}/cancel", h.APICancelBroadcast)
+
+	// 用户和充值
+	protected.HandleFunc("GET /admin/api/users", h.APIGetUsers)
+	protected.HandleFunc("POST /admin/api/users", h.APICreateUser)
+	protected.HandleFunc("POST /admin/api/users/import", h.APIImportUsers)
+	protected.HandleFunc("GET /admin/api/users/lookup", h.APILookupUsername)
+	protected.HandleFunc("GET /admin/api/users/sources", h.APIAcquisitionSources)
+	protected.HandleFunc("GET /admin/api/users/banned", h.APIGetBannedUsers)
+	protected.HandleFunc("GET /admin/api/users/{id}", h.APIGetUser)
+	protected.HandleFunc("PUT /admin/api/users/{id}", h.APIUpdateUser)
+	protected.HandleFunc("DELETE /admin/api/users/{id}", h.APIDeleteUser)
+	protected.HandleFunc("PUT /admin/api/users/{id}/balance", h.APIUpdateUserBalance)
+	protected.HandleFunc("PUT /admin/api/users/{id}/daily-limit-exempt", h.APIUpdateUserDailyLimitExempt)
+	protected.HandleFunc("POST /admin/api/users/{id}/ban", h.APIBanUser)
+	protected.HandleFunc("POST /admin/api/users/{id}/unban", h.APIUnbanUser)
+	protected.HandleFunc("GET /admin/api/recharges", h.APIGetRecharges)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin/login", h.LoginPage)
+	mux.HandleFunc("POST /admin/login", h.LoginHandler)
+	mux.Handle("/admin", h.auth.Middleware(protected))
+	mux.Handle("/admin/", h.auth.Middleware(protected))
+	return mux
+}
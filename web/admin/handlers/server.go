@@ -0,0 +1,63 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Server 管理后台的 HTTP 服务，页面和接口均需登录，建议只监听内网地址或置于 HTTPS 反向代理之后
+type Server struct {
+	addr   string
+	server *http.Server
+}
+
+// NewServer 创建管理后台服务
+func NewServer(addr string, handler *AdminHandler) (*Server, error) {
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("管理后台地址无效: %v", err)
+	}
+
+	return &Server{
+		addr: addr,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           handler.Routes(),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}, nil
+}
+
+// Addr 管理后台监听的地址
+func (s *Server) Addr() string {
+	return s.addr
+}
+
+// Start 在后台启动管理后台服务，监听失败时返回错误
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("启动管理后台失败: %v", err)
+	}
+	s.addr = listener.Addr().String()
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.Printf("❌ 管理后台服务错误: %v", err)
+		}
+	}()
+	log.Printf("🛠️ 管理后台已启动: http://%s/admin", s.addr)
+	return nil
+}
+
+// Stop 停止管理后台服务
+func (s *Server) Stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.server.Shutdown(ctx); err != nil {
+		log.Printf("⚠️ 停止管理后台失败: %v", err)
+	}
+}